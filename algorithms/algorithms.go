@@ -2,9 +2,12 @@ package algorithms
 
 import (
 	"github.com/sanderblue/algorithms/pkg/ringallreduce"
+	"github.com/sanderblue/algorithms/pkg/sort"
 )
 
 type Algorithms struct {
+	// RingAllReduce is kept for existing callers; new code should use
+	// Collective or the constructors below.
 	RingAllReduce ringallreduce.RingAllReduce
 }
 
@@ -13,3 +16,42 @@ func New() *Algorithms {
 		RingAllReduce: ringallreduce.New(),
 	}
 }
+
+// Collective returns the collective operations of topology t.
+func (a *Algorithms) Collective(t ringallreduce.Topology) ringallreduce.Collective {
+	return ringallreduce.NewCollective(t)
+}
+
+// Ring returns the collective operations of a ring of p ranks.
+func (a *Algorithms) Ring(p int) ringallreduce.Collective {
+	return ringallreduce.NewRingCollective(p)
+}
+
+// Tree returns the collective operations of a binary tree of p ranks.
+func (a *Algorithms) Tree(p int) ringallreduce.Collective {
+	return ringallreduce.NewTreeCollective(p)
+}
+
+// RecursiveDoubling returns the collective operations of recursive doubling
+// over p ranks.
+func (a *Algorithms) RecursiveDoubling(p int) ringallreduce.Collective {
+	return ringallreduce.NewRecursiveDoublingCollective(p)
+}
+
+// ParameterServer returns the collective operations of a parameter server
+// with p-1 workers, the centralized baseline to compare the others against.
+func (a *Algorithms) ParameterServer(p int) ringallreduce.Collective {
+	return ringallreduce.NewParameterServerCollective(p)
+}
+
+// Hierarchical returns the collective operations of groups groups of
+// perGroup ranks each.
+func (a *Algorithms) Hierarchical(groups, perGroup int) ringallreduce.Collective {
+	return ringallreduce.NewHierarchicalCollective(groups, perGroup)
+}
+
+// Sorts returns the comparison sorts of package sort over float64 vectors.
+// For other element types, call sort.All or the sorts directly.
+func (a *Algorithms) Sorts() []sort.Sorter[float64] {
+	return sort.All[float64]()
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sanderblue/algorithms/pkg/collectivebench"
+	"github.com/sanderblue/algorithms/pkg/ringallreduce"
+)
+
+// allReduce implements the allreduce subcommand.
+func allReduce(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("allreduce", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	procs := fs.Int("procs", 4, "number of ranks")
+	size := fs.Int("size", 1024, "vector length in float64 elements")
+	algo := fs.String("algo", "ring", "algorithm: "+strings.Join(algorithmNames(), ", ")+" or auto")
+	transport := fs.String("transport", "chan", "transport: chan or tcp")
+	tracePath := fs.String("trace", "", "write a Chrome trace of the run to this file")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if *procs < 1 || *size < 0 {
+		fmt.Fprintln(stderr, "allreduce: -procs must be positive and -size not negative")
+		return 2
+	}
+
+	topology, err := topologyFor(*algo, *procs, *size)
+	if err != nil {
+		fmt.Fprintf(stderr, "allreduce: %v\n", err)
+		return 2
+	}
+	comm := ringallreduce.NewCommunicator(*procs)
+	comm.Topology = func(int) ringallreduce.Topology { return topology }
+	switch *transport {
+	case "chan":
+	case "tcp":
+		var meshes []loopbackMesh
+		defer func() {
+			for _, m := range meshes {
+				m.Close()
+			}
+		}()
+		comm.NewTransport = func(t ringallreduce.Topology) ringallreduce.Transport {
+			mesh, err := newLoopbackMesh(t.Size())
+			if err != nil {
+				return failedTransport{err}
+			}
+			meshes = append(meshes, mesh)
+			return mesh
+		}
+	default:
+		fmt.Fprintf(stderr, "allreduce: unknown transport %q\n", *transport)
+		return 2
+	}
+	if *tracePath != "" {
+		comm.Trace = ringallreduce.NewTrace()
+	}
+	comm.Verify = true
+
+	data := make([][]float64, *procs)
+	for r := range data {
+		data[r] = make([]float64, *size)
+		for i := range data[r] {
+			data[r][i] = float64(r+1) + float64(i%7)/8
+		}
+	}
+
+	began := time.Now()
+	if err := comm.AllReduce(comm.NewOpID(), data); err != nil {
+		if errors.Is(err, ringallreduce.ErrVerification) {
+			fmt.Fprintf(stdout, "validation  FAILED: %v\n", err)
+		} else {
+			fmt.Fprintf(stderr, "allreduce: %v\n", err)
+		}
+		return 1
+	}
+	elapsed := time.Since(began)
+
+	fmt.Fprintf(stdout, "algorithm   %s\n", topology.Name())
+	fmt.Fprintf(stdout, "procs       %d\n", *procs)
+	fmt.Fprintf(stdout, "size        %d elements\n", *size)
+	fmt.Fprintf(stdout, "transport   %s\n", *transport)
+	fmt.Fprintf(stdout, "elapsed     %v\n", elapsed)
+	if elapsed > 0 {
+		fmt.Fprintf(stdout, "throughput  %.1f MB/s per rank\n", float64(8**size)/elapsed.Seconds()/1e6)
+	}
+
+	if *tracePath != "" {
+		if err := writeTrace(*tracePath, comm.Trace); err != nil {
+			fmt.Fprintf(stderr, "allreduce: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "trace       %s\n", *tracePath)
+	}
+
+	v, _ := comm.LastVerification()
+	fmt.Fprintf(stdout, "validation  ok (max abs error %g, max rel error %g)\n", v.MaxAbs(), v.MaxRel())
+	return 0
+}
+
+func algorithmNames() []string {
+	var names []string
+	for _, a := range collectivebench.DefaultAlgorithms() {
+		names = append(names, a.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// topologyFor resolves the -algo flag.
+func topologyFor(name string, p, n int) (ringallreduce.Topology, error) {
+	if name == "auto" {
+		return ringallreduce.NewAuto(ringallreduce.DefaultCostModel).Select(p, n), nil
+	}
+	for _, a := range collectivebench.DefaultAlgorithms() {
+		if a.Name != name {
+			continue
+		}
+		if t := a.Topology(p); t != nil {
+			return t, nil
+		}
+		return nil, fmt.Errorf("algorithm %s doesn't support %d ranks", name, p)
+	}
+	return nil, fmt.Errorf("unknown algorithm %q", name)
+}
+
+func writeTrace(path string, trace *ringallreduce.Trace) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := trace.WriteChrome(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loopbackMesh gives every rank its own TCPTransport on the loopback
+// interface and routes calls to the transport of the calling rank.
+type loopbackMesh []*ringallreduce.TCPTransport
+
+func newLoopbackMesh(p int) (loopbackMesh, error) {
+	listeners := make([]net.Listener, p)
+	peers := make([]string, p)
+	for i := range listeners {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			for _, l := range listeners[:i] {
+				l.Close()
+			}
+			return nil, err
+		}
+		listeners[i] = ln
+		peers[i] = ln.Addr().String()
+	}
+	mesh := make(loopbackMesh, p)
+	for i, ln := range listeners {
+		mesh[i] = ringallreduce.NewTCPTransport(i, ln, peers)
+	}
+	return mesh, nil
+}
+
+func (m loopbackMesh) Send(rank int, msg ringallreduce.Msg) error {
+	return m[msg.From].Send(rank, msg)
+}
+
+func (m loopbackMesh) Recv(rank int) (ringallreduce.Msg, error) {
+	return m[rank].Recv(rank)
+}
+
+func (m loopbackMesh) Close() error {
+	var errs []error
+	for _, t := range m {
+		errs = append(errs, t.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// failedTransport fails every call with the error that prevented creating
+// the real transport.
+type failedTransport struct{ err error }
+
+func (t failedTransport) Send(int, ringallreduce.Msg) error { return t.err }
+
+func (t failedTransport) Recv(int) (ringallreduce.Msg, error) { return ringallreduce.Msg{}, t.err }
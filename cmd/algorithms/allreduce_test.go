@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAllReduce(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"ring chan", []string{"-procs", "4", "-size", "100"}, "algorithm   ring"},
+		{"tree tcp", []string{"--procs", "5", "--size", "33", "--algo", "tree", "--transport", "tcp"}, "transport   tcp"},
+		{"torus", []string{"-procs", "6", "-size", "12", "-algo", "torus"}, "algorithm   torus"},
+		{"auto", []string{"-procs", "8", "-size", "4", "-algo", "auto"}, "algorithm   recursive-doubling"},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(append([]string{"allreduce"}, tc.args...), &stdout, &stderr); code != 0 {
+				t.Fatalf("exit code %d: %s", code, stderr.String())
+			}
+			out := stdout.String()
+			if !strings.Contains(out, tc.want) || !strings.Contains(out, "validation  ok") {
+				t.Errorf("unexpected output:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestAllReduce_Trace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.json")
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"allreduce", "-procs", "3", "-size", "9", "-trace", path}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		TraceEvents []json.RawMessage `json:"traceEvents"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil || len(doc.TraceEvents) == 0 {
+		t.Fatalf("expected a Chrome trace, got %v with %d events", err, len(doc.TraceEvents))
+	}
+}
+
+func TestRun_Usage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"no command", nil, 2},
+		{"unknown command", []string{"sort"}, 2},
+		{"help", []string{"help"}, 0},
+		{"unknown algorithm", []string{"allreduce", "-algo", "hypercube"}, 2},
+		{"unsupported size", []string{"allreduce", "-algo", "torus", "-procs", "7"}, 2},
+		{"unknown transport", []string{"allreduce", "-transport", "carrier-pigeon"}, 2},
+		{"bad flag", []string{"allreduce", "-procs", "x"}, 2},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tc.args, &stdout, &stderr); code != tc.code {
+				t.Errorf("exit code %d, want %d (stderr %q)", code, tc.code, stderr.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+// Command algorithms runs the algorithms of this module from the command
+// line.
+//
+// Usage:
+//
+//	algorithms allreduce [flags]
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the subcommand in args and returns the exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+	switch args[0] {
+	case "allreduce":
+		return allReduce(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		usage(stdout)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "algorithms: unknown command %q\n", args[0])
+		usage(stderr)
+		return 2
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprint(w, `Usage: algorithms <command> [flags]
+
+Commands:
+  allreduce   run a collective all-reduce and validate its result
+
+Run "algorithms <command> -h" for the flags of a command.
+`)
+}
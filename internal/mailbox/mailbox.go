@@ -0,0 +1,42 @@
+// Package mailbox provides the unbounded inbox that the simulated processes
+// of the distributed-algorithm packages receive their messages through.
+package mailbox
+
+import "sync"
+
+// Mailbox is an unbounded FIFO inbox, so a sending process never blocks on
+// a receiver that is itself busy sending.
+type Mailbox[T any] struct {
+	mu     sync.Mutex
+	queue  []T
+	signal chan struct{}
+}
+
+// New returns an empty mailbox.
+func New[T any]() *Mailbox[T] {
+	return &Mailbox[T]{signal: make(chan struct{}, 1)}
+}
+
+// Push appends msg to the mailbox. It never blocks.
+func (m *Mailbox[T]) Push(msg T) {
+	m.mu.Lock()
+	m.queue = append(m.queue, msg)
+	m.mu.Unlock()
+	select {
+	case m.signal <- struct{}{}:
+	default:
+	}
+}
+
+// Signal returns a channel that is ready whenever messages may have arrived
+// since the last Drain.
+func (m *Mailbox[T]) Signal() <-chan struct{} { return m.signal }
+
+// Drain removes and returns the queued messages, oldest first.
+func (m *Mailbox[T]) Drain() []T {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := m.queue
+	m.queue = nil
+	return out
+}
@@ -0,0 +1,69 @@
+package mailbox
+
+import (
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestMailbox(t *testing.T) {
+	m := New[int]()
+	if got := m.Drain(); len(got) != 0 {
+		t.Fatalf("new mailbox holds %v", got)
+	}
+	// Pushes never block, however many go unread.
+	for i := range 100 {
+		m.Push(i)
+	}
+	<-m.Signal()
+	got := m.Drain()
+	if len(got) != 100 || !slices.IsSorted(got) {
+		t.Fatalf("drained %v, want 0..99 in order", got)
+	}
+	select {
+	case <-m.Signal():
+		t.Error("signal left after draining")
+	default:
+	}
+}
+
+func TestMailbox_Concurrent(t *testing.T) {
+	m := New[int]()
+	const senders, each = 8, 1000
+	var wg sync.WaitGroup
+	for s := range senders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range each {
+				m.Push(s*each + i)
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	// Every message is received once, and those of a sender in order.
+	last := make([]int, senders)
+	for s := range last {
+		last[s] = -1
+	}
+	received := 0
+	for received < senders*each {
+		select {
+		case <-m.Signal():
+		case <-done:
+		}
+		for _, msg := range m.Drain() {
+			s, i := msg/each, msg%each
+			if i <= last[s] {
+				t.Fatalf("sender %d: message %d after %d", s, i, last[s])
+			}
+			last[s] = i
+			received++
+		}
+	}
+}
@@ -0,0 +1,221 @@
+// Package bitset implements a dense, fixed size set of bits backed by 64-bit words.
+package bitset
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+const wordSize = 64
+
+type Bitset struct {
+	n     int      // number of addressable bits
+	words []uint64 // bit i lives in words[i/64] at position i%64
+}
+
+func New(n int) *Bitset {
+	return &Bitset{n: n, words: make([]uint64, (n+wordSize-1)/wordSize)}
+}
+
+// Len returns the number of addressable bits.
+func (b *Bitset) Len() int {
+	return b.n
+}
+
+// Words returns a copy of the backing words, bit i being bit i%64 of word
+// i/64. Bits beyond Len are always zero.
+func (b *Bitset) Words() []uint64 {
+	return append([]uint64(nil), b.words...)
+}
+
+// Set sets bit i. It panics if i is outside [0, Len()).
+func (b *Bitset) Set(i int) {
+	b.mustIndex(i)
+	b.words[i/wordSize] |= 1 << (uint(i) % wordSize)
+}
+
+// Clear clears bit i. It panics if i is outside [0, Len()).
+func (b *Bitset) Clear(i int) {
+	b.mustIndex(i)
+	b.words[i/wordSize] &^= 1 << (uint(i) % wordSize)
+}
+
+// Flip toggles bit i. It panics if i is outside [0, Len()).
+func (b *Bitset) Flip(i int) {
+	b.mustIndex(i)
+	b.words[i/wordSize] ^= 1 << (uint(i) % wordSize)
+}
+
+// Test reports whether bit i is set. It panics if i is outside [0, Len()).
+func (b *Bitset) Test(i int) bool {
+	b.mustIndex(i)
+	return b.words[i/wordSize]&(1<<(uint(i)%wordSize)) != 0
+}
+
+// mustIndex panics unless i addresses a bit, as indexing a slice would.
+func (b *Bitset) mustIndex(i int) {
+	if i < 0 || i >= b.n {
+		panic(fmt.Sprintf("bitset: index %d out of range [0, %d)", i, b.n))
+	}
+}
+
+// Reset clears every bit.
+func (b *Bitset) Reset() {
+	clear(b.words)
+}
+
+func (b *Bitset) Clone() *Bitset {
+	c := &Bitset{n: b.n, words: make([]uint64, len(b.words))}
+	copy(c.words, b.words)
+	return c
+}
+
+// Count returns the number of set bits (population count).
+func (b *Bitset) Count() int {
+	n := 0
+	for _, w := range b.words {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// Any reports whether at least one bit is set.
+func (b *Bitset) Any() bool {
+	for _, w := range b.words {
+		if w != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Equal reports whether both sets have the same length and bits.
+func (b *Bitset) Equal(o *Bitset) bool {
+	if b.n != o.n {
+		return false
+	}
+	for i, w := range b.words {
+		if w != o.words[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// And, Or, Xor and AndNot update b in place and return it. Both sets must
+// have the same length.
+func (b *Bitset) And(o *Bitset) *Bitset {
+	b.mustMatch(o)
+	for i := range b.words {
+		b.words[i] &= o.words[i]
+	}
+	return b
+}
+
+func (b *Bitset) Or(o *Bitset) *Bitset {
+	b.mustMatch(o)
+	for i := range b.words {
+		b.words[i] |= o.words[i]
+	}
+	return b
+}
+
+func (b *Bitset) Xor(o *Bitset) *Bitset {
+	b.mustMatch(o)
+	for i := range b.words {
+		b.words[i] ^= o.words[i]
+	}
+	return b
+}
+
+func (b *Bitset) AndNot(o *Bitset) *Bitset {
+	b.mustMatch(o)
+	for i := range b.words {
+		b.words[i] &^= o.words[i]
+	}
+	return b
+}
+
+func (b *Bitset) mustMatch(o *Bitset) {
+	if b.n != o.n {
+		panic("bitset: length mismatch")
+	}
+}
+
+// NextSet returns the index of the first set bit at or after i, or -1.
+func (b *Bitset) NextSet(i int) int {
+	if i < 0 {
+		i = 0
+	}
+	if i >= b.n {
+		return -1
+	}
+	w := i / wordSize
+	word := b.words[w] >> (uint(i) % wordSize)
+	if word != 0 {
+		return i + bits.TrailingZeros64(word)
+	}
+	for w++; w < len(b.words); w++ {
+		if b.words[w] != 0 {
+			return w*wordSize + bits.TrailingZeros64(b.words[w])
+		}
+	}
+	return -1
+}
+
+// Each calls fn with the index of every set bit in increasing order, stopping
+// early when fn returns false.
+func (b *Bitset) Each(fn func(i int) bool) {
+	for w, word := range b.words {
+		for word != 0 {
+			t := bits.TrailingZeros64(word)
+			if !fn(w*wordSize + t) {
+				return
+			}
+			word &= word - 1
+		}
+	}
+}
+
+// Rank returns the number of set bits in [0, i).
+func (b *Bitset) Rank(i int) int {
+	if i <= 0 {
+		return 0
+	}
+	if i > b.n {
+		i = b.n
+	}
+	n := 0
+	w := i / wordSize
+	for _, word := range b.words[:w] {
+		n += bits.OnesCount64(word)
+	}
+	if r := uint(i) % wordSize; r != 0 {
+		n += bits.OnesCount64(b.words[w] & (1<<r - 1))
+	}
+	return n
+}
+
+// Select returns the index of the k-th set bit (0-based), or -1 when fewer
+// than k+1 bits are set.
+func (b *Bitset) Select(k int) int {
+	if k < 0 {
+		return -1
+	}
+	for w, word := range b.words {
+		c := bits.OnesCount64(word)
+		if k < c {
+			return w*wordSize + selectInWord(word, k)
+		}
+		k -= c
+	}
+	return -1
+}
+
+// selectInWord returns the position of the k-th set bit of word.
+func selectInWord(word uint64, k int) int {
+	for ; k > 0; k-- {
+		word &= word - 1
+	}
+	return bits.TrailingZeros64(word)
+}
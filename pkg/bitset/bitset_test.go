@@ -0,0 +1,171 @@
+package bitset
+
+import (
+	"reflect"
+	"testing"
+)
+
+func fromIndices(n int, idx ...int) *Bitset {
+	b := New(n)
+	for _, i := range idx {
+		b.Set(i)
+	}
+	return b
+}
+
+func members(b *Bitset) []int {
+	var out []int
+	b.Each(func(i int) bool {
+		out = append(out, i)
+		return true
+	})
+	return out
+}
+
+func TestBitset_SetClearTest(t *testing.T) {
+	b := New(130)
+	for _, i := range []int{0, 63, 64, 129} {
+		b.Set(i)
+	}
+	b.Flip(5)
+	b.Flip(63)
+	b.Clear(64)
+
+	if got, want := members(b), []int{0, 5, 129}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if !b.Test(129) || b.Test(64) {
+		t.Errorf("Test returned wrong membership")
+	}
+	if b.Count() != 3 || !b.Any() {
+		t.Errorf("expected 3 set bits, got %d", b.Count())
+	}
+	b.Reset()
+	if b.Any() {
+		t.Errorf("expected empty set after Reset")
+	}
+}
+
+func TestBitset_SetOperations(t *testing.T) {
+	tests := []struct {
+		name     string
+		op       func(a, b *Bitset) *Bitset
+		expected []int
+	}{
+		{name: "and", op: (*Bitset).And, expected: []int{2, 70}},
+		{name: "or", op: (*Bitset).Or, expected: []int{1, 2, 3, 70, 99}},
+		{name: "xor", op: (*Bitset).Xor, expected: []int{1, 3, 99}},
+		{name: "andnot", op: (*Bitset).AndNot, expected: []int{1, 99}},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			a := fromIndices(100, 1, 2, 70, 99)
+			b := fromIndices(100, 2, 3, 70)
+			got := members(tc.op(a.Clone(), b))
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestBitset_NextSet(t *testing.T) {
+	b := fromIndices(200, 3, 64, 190)
+	tests := []struct{ from, expected int }{
+		{from: -1, expected: 3},
+		{from: 3, expected: 3},
+		{from: 4, expected: 64},
+		{from: 65, expected: 190},
+		{from: 191, expected: -1},
+		{from: 500, expected: -1},
+	}
+	for _, tc := range tests {
+		if got := b.NextSet(tc.from); got != tc.expected {
+			t.Errorf("NextSet(%d): expected %d, got %d", tc.from, tc.expected, got)
+		}
+	}
+}
+
+func TestBitset_RankSelect(t *testing.T) {
+	idx := []int{0, 7, 63, 64, 65, 128, 255}
+	b := fromIndices(256, idx...)
+
+	for k, i := range idx {
+		if got := b.Select(k); got != i {
+			t.Errorf("Select(%d): expected %d, got %d", k, i, got)
+		}
+		if got := b.Rank(i); got != k {
+			t.Errorf("Rank(%d): expected %d, got %d", i, k, got)
+		}
+		if got := b.Rank(i + 1); got != k+1 {
+			t.Errorf("Rank(%d): expected %d, got %d", i+1, k+1, got)
+		}
+	}
+	if got := b.Select(len(idx)); got != -1 {
+		t.Errorf("Select past end: expected -1, got %d", got)
+	}
+	if got := b.Rank(1000); got != len(idx) {
+		t.Errorf("Rank past end: expected %d, got %d", len(idx), got)
+	}
+}
+
+func TestBitset_WordsIsACopy(t *testing.T) {
+	b := New(70)
+	b.Set(65)
+	words := b.Words()
+	if len(words) != 2 || words[1] != 2 {
+		t.Fatalf("unexpected words %v", words)
+	}
+	words[0] = ^uint64(0)
+	if b.Count() != 1 {
+		t.Errorf("modifying the words changed the bitset, count %d", b.Count())
+	}
+}
+
+func TestBitset_LengthMismatchPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic on length mismatch")
+		}
+	}()
+	New(10).Or(New(11))
+}
+
+func TestBitset_IndexOutOfRangePanics(t *testing.T) {
+	s := New(10)
+	ops := map[string]func(int){
+		"Set":   s.Set,
+		"Clear": s.Clear,
+		"Flip":  s.Flip,
+		"Test":  func(i int) { s.Test(i) },
+	}
+	// 10 and 63 lie within the backing word, -1 would wrap onto bit 63.
+	for _, i := range []int{-1, 10, 63, 64} {
+		for name, op := range ops {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("%s(%d): expected panic", name, i)
+					}
+				}()
+				op(i)
+			}()
+		}
+	}
+	if s.Count() != 0 {
+		t.Errorf("Count() = %d after out of range calls, want 0", s.Count())
+	}
+}
+
+func BenchmarkBitset_Count(b *testing.B) {
+	s := New(1 << 16)
+	for i := 0; i < s.Len(); i += 3 {
+		s.Set(i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = s.Count()
+	}
+}
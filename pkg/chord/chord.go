@@ -0,0 +1,239 @@
+// Package chord simulates the Chord distributed hash table. Nodes and keys
+// hash to identifiers on a ring of 2^m positions, and a key belongs to its
+// successor: the first node at or after it clockwise. Every node knows its
+// successor and predecessor, a list of the next few successors to survive
+// failures, and a finger table whose entry i points to the successor of
+// the node's identifier plus 2^i. Routing follows the finger that gets
+// closest to the key without passing it, halving the distance at every hop,
+// so a lookup takes O(log n) hops, ½·log₂ n on average.
+//
+// Nodes join through any member and leave gracefully or fail; periodic
+// stabilization repairs successors, predecessors and fingers, and keys move
+// to their new successor when the ring changes.
+//
+// The simulation runs in a single goroutine: remote calls are method calls
+// on the target node, and a call to a node that failed is detected at once.
+//
+// References:
+//
+// Stoica, Morris, Karger, Kaashoek and Balakrishnan, Chord: A Scalable
+// Peer-to-peer Lookup Service for Internet Applications, SIGCOMM 2001.
+package chord
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/sanderblue/algorithms/pkg/consistenthash"
+)
+
+// ID is a position on the identifier ring.
+type ID uint64
+
+// ErrUnreachable is returned when a lookup runs out of live nodes to route
+// through.
+var ErrUnreachable = errors.New("no live node to route through")
+
+// between reports whether x lies on the ring strictly between a and b,
+// going clockwise. If a == b, the interval is the whole ring but a.
+func between(x, a, b ID) bool {
+	if a < b {
+		return a < x && x < b
+	}
+	return x > a || x < b
+}
+
+// upTo reports whether x lies in the clockwise interval (a, b].
+func upTo(x, a, b ID) bool { return x == b || between(x, a, b) }
+
+// Network is a simulated Chord ring.
+type Network struct {
+	Bits       int // m: the ring has 2^Bits positions
+	Successors int // length of the successor lists
+
+	nodes map[ID]*Node
+}
+
+// NewRing returns a ring of nodes ids in the state stabilization converges
+// to: every successor list, predecessor and finger correct. It saves growing
+// large rings one join at a time.
+func NewRing(bits, successors int, ids []ID) (*Network, error) {
+	n, err := NewNetwork(bits, successors)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		id = n.mask(id)
+		if n.nodes[id] != nil {
+			return nil, fmt.Errorf("node %d appears twice", id)
+		}
+		n.nodes[id] = &Node{ID: id, net: n, finger: make([]ID, bits), data: make(map[ID]string)}
+	}
+	sorted := n.IDs()
+	for i, id := range sorted {
+		nd := n.nodes[id]
+		nd.predecessor, nd.hasPredecessor = sorted[(i+len(sorted)-1)%len(sorted)], true
+		for j := 1; j <= min(n.Successors, len(sorted)); j++ {
+			nd.successors = append(nd.successors, sorted[(i+j)%len(sorted)])
+		}
+		nd.trim()
+		for k := range nd.finger {
+			nd.finger[k] = ownerIn(sorted, n.mask(id+1<<k))
+		}
+	}
+	return n, nil
+}
+
+// NewNetwork returns an empty ring of 2^bits positions whose nodes keep
+// successors successors, at least 1.
+func NewNetwork(bits, successors int) (*Network, error) {
+	if bits < 1 || bits > 64 {
+		return nil, fmt.Errorf("%d bits outside [1, 64]", bits)
+	}
+	return &Network{Bits: bits, Successors: max(successors, 1), nodes: make(map[ID]*Node)}, nil
+}
+
+// Hash maps a name or key to the ring.
+func (n *Network) Hash(s string) ID { return n.mask(ID(consistenthash.DefaultHash(s))) }
+
+func (n *Network) mask(id ID) ID {
+	if n.Bits == 64 {
+		return id
+	}
+	return id & (1<<n.Bits - 1)
+}
+
+// Node returns the live node id, or nil.
+func (n *Network) Node(id ID) *Node { return n.nodes[id] }
+
+// IDs returns the identifiers of the live nodes in ring order.
+func (n *Network) IDs() []ID {
+	ids := make([]ID, 0, len(n.nodes))
+	for id := range n.nodes {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Owner returns the live node key belongs to, computed from the global view
+// of the ring rather than by routing: what lookups should return.
+func (n *Network) Owner(key ID) (ID, bool) {
+	ids := n.IDs()
+	if len(ids) == 0 {
+		return 0, false
+	}
+	return ownerIn(ids, key), true
+}
+
+// ownerIn returns the first of the sorted, non-empty ids at or after key.
+func ownerIn(ids []ID, key ID) ID {
+	i := sort.Search(len(ids), func(i int) bool { return ids[i] >= key })
+	return ids[i%len(ids)]
+}
+
+// Join adds node id to the ring through the live node via, or creates the
+// ring if it is empty. The new node only knows its successor until
+// stabilization runs.
+func (n *Network) Join(id, via ID) (*Node, error) {
+	id = n.mask(id)
+	if _, ok := n.nodes[id]; ok {
+		return nil, fmt.Errorf("node %d already joined", id)
+	}
+	nd := &Node{ID: id, net: n, finger: make([]ID, n.Bits), data: make(map[ID]string)}
+	if len(n.nodes) == 0 {
+		nd.successors = []ID{id}
+		nd.predecessor, nd.hasPredecessor = id, true
+	} else {
+		v := n.nodes[via]
+		if v == nil {
+			return nil, fmt.Errorf("node %d can't join through node %d, which isn't live", id, via)
+		}
+		succ, _, err := v.FindSuccessor(id)
+		if err != nil {
+			return nil, fmt.Errorf("node %d joining through %d: %w", id, via, err)
+		}
+		nd.successors = []ID{succ}
+	}
+	for i := range nd.finger {
+		nd.finger[i] = nd.successors[0]
+	}
+	n.nodes[id] = nd
+	return nd, nil
+}
+
+// Leave removes node id gracefully: it hands its keys to its successor and
+// links its predecessor and successor to each other.
+func (n *Network) Leave(id ID) error {
+	nd := n.nodes[id]
+	if nd == nil {
+		return fmt.Errorf("node %d isn't live", id)
+	}
+	delete(n.nodes, id)
+	succ := n.nodes[nd.successor()]
+	if succ == nil {
+		return nil
+	}
+	for k, v := range nd.data {
+		succ.data[k] = v
+	}
+	succ.predecessor, succ.hasPredecessor = nd.predecessor, nd.hasPredecessor && n.nodes[nd.predecessor] != nil
+	if pred := n.nodes[nd.predecessor]; nd.hasPredecessor && pred != nil {
+		pred.successors = append([]ID{succ.ID}, pred.successors...)
+		pred.trim()
+	}
+	return nil
+}
+
+// Fail removes node id abruptly, with its keys.
+func (n *Network) Fail(id ID) { delete(n.nodes, id) }
+
+// Stabilize runs rounds rounds of stabilization on every live node, in ring
+// order: each node checks its predecessor, adopts a closer successor its
+// successor knows of, notifies its successor of itself, refreshes its
+// successor list and fixes its fingers.
+func (n *Network) Stabilize(rounds int) {
+	for r := 0; r < rounds; r++ {
+		for _, id := range n.IDs() {
+			if nd := n.nodes[id]; nd != nil {
+				nd.stabilize()
+			}
+		}
+		for _, id := range n.IDs() {
+			if nd := n.nodes[id]; nd != nil {
+				nd.fixFingers()
+			}
+		}
+	}
+}
+
+// Put stores value under key at its successor, found by routing from node
+// from. It returns the number of hops.
+func (n *Network) Put(from ID, key string, value string) (int, error) {
+	owner, hops, err := n.route(from, n.Hash(key))
+	if err != nil {
+		return hops, err
+	}
+	n.nodes[owner].data[n.Hash(key)] = value
+	return hops, nil
+}
+
+// Get looks key up by routing from node from, and returns its value and the
+// number of hops.
+func (n *Network) Get(from ID, key string) (string, bool, int, error) {
+	owner, hops, err := n.route(from, n.Hash(key))
+	if err != nil {
+		return "", false, hops, err
+	}
+	v, ok := n.nodes[owner].data[n.Hash(key)]
+	return v, ok, hops, nil
+}
+
+func (n *Network) route(from, key ID) (ID, int, error) {
+	nd := n.nodes[from]
+	if nd == nil {
+		return 0, 0, fmt.Errorf("node %d isn't live", from)
+	}
+	return nd.FindSuccessor(key)
+}
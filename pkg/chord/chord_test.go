@@ -0,0 +1,178 @@
+package chord
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		x, a, b ID
+		want    bool
+	}{
+		{5, 1, 9, true},
+		{1, 1, 9, false},
+		{9, 1, 9, false},
+		{0, 9, 1, true},
+		{10, 9, 1, true},
+		{5, 9, 1, false},
+		{5, 3, 3, true}, // the whole ring but 3
+		{3, 3, 3, false},
+	}
+	for _, tc := range tests {
+		if got := between(tc.x, tc.a, tc.b); got != tc.want {
+			t.Errorf("between(%d, %d, %d) = %v, want %v", tc.x, tc.a, tc.b, got, tc.want)
+		}
+	}
+	if !upTo(9, 1, 9) || upTo(1, 1, 9) {
+		t.Error("upTo includes the wrong end")
+	}
+}
+
+// randomIDs returns n distinct identifiers of bits bits.
+func randomIDs(rng *rand.Rand, n, bits int) []ID {
+	seen := map[ID]bool{}
+	var out []ID
+	for len(out) < n {
+		id := ID(rng.Uint64()) & (1<<bits - 1)
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// checkRouting looks up random keys from random nodes and fails unless
+// every lookup finds the owner. It returns the mean hop count.
+func checkRouting(t *testing.T, n *Network, rng *rand.Rand, lookups int) float64 {
+	t.Helper()
+	ids := n.IDs()
+	total := 0
+	for i := 0; i < lookups; i++ {
+		from := ids[rng.Intn(len(ids))]
+		key := ID(rng.Uint64()) & (1<<n.Bits - 1)
+		got, hops, err := n.Node(from).FindSuccessor(key)
+		if err != nil {
+			t.Fatalf("lookup of %d from %d: %v", key, from, err)
+		}
+		if want, _ := n.Owner(key); got != want {
+			t.Fatalf("lookup of %d from %d found %d, want %d", key, from, got, want)
+		}
+		total += hops
+	}
+	return float64(total) / float64(lookups)
+}
+
+func TestFindSuccessor_Hops(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, size := range []int{16, 64, 256, 1024, 4096} {
+		n, err := NewRing(32, 4, randomIDs(rng, size, 32))
+		if err != nil {
+			t.Fatal(err)
+		}
+		mean := checkRouting(t, n, rng, 2000)
+		log := math.Log2(float64(size))
+		t.Logf("%5d nodes: %.2f hops on average, ½·log₂ n = %.2f", size, mean, log/2)
+		if mean < 0.35*log || mean > 0.65*log {
+			t.Errorf("%d nodes: %.2f hops on average, want about %.2f", size, mean, log/2)
+		}
+	}
+}
+
+func TestNetwork_JoinConverges(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	n, _ := NewNetwork(16, 3)
+	ids := randomIDs(rng, 40, 16)
+	if _, err := n.Join(ids[0], 0); err != nil {
+		t.Fatal(err)
+	}
+	for i, id := range ids[1:] {
+		if _, err := n.Join(id, ids[rng.Intn(i+1)]); err != nil {
+			t.Fatal(err)
+		}
+		n.Stabilize(1)
+	}
+	if _, err := n.Join(ids[3], ids[0]); err == nil {
+		t.Error("expected an error joining twice")
+	}
+	n.Stabilize(3)
+
+	ideal, _ := NewRing(16, 3, ids)
+	for _, id := range ids {
+		got, want := n.Node(id), ideal.Node(id)
+		gp, _ := got.Predecessor()
+		wp, _ := want.Predecessor()
+		if !reflect.DeepEqual(got.Successors(), want.Successors()) || gp != wp || !reflect.DeepEqual(got.Fingers(), want.Fingers()) {
+			t.Fatalf("node %d: successors %v, predecessor %d, fingers %v\nwant %v, %d, %v",
+				id, got.Successors(), gp, got.Fingers(), want.Successors(), wp, want.Fingers())
+		}
+	}
+	checkRouting(t, n, rng, 500)
+}
+
+func TestNetwork_Churn(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	n, _ := NewRing(20, 4, randomIDs(rng, 30, 20))
+	keys := map[string]string{}
+	for i := 0; i < 300; i++ {
+		k, v := fmt.Sprint("key-", i), fmt.Sprint("value-", i)
+		keys[k] = v
+		if _, err := n.Put(n.IDs()[rng.Intn(30)], k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkKeys := func(stage string) {
+		t.Helper()
+		ids := n.IDs()
+		for k, v := range keys {
+			got, ok, _, err := n.Get(ids[rng.Intn(len(ids))], k)
+			if err != nil || !ok || got != v {
+				t.Fatalf("%s: %s = %q, %v, %v, want %q", stage, k, got, ok, err, v)
+			}
+		}
+	}
+
+	// Keys move to joining nodes.
+	for _, id := range randomIDs(rng, 20, 20) {
+		if n.Node(id) == nil {
+			n.Join(id, n.IDs()[0])
+		}
+		n.Stabilize(1)
+	}
+	n.Stabilize(2)
+	checkKeys("after joins")
+	checkRouting(t, n, rng, 300)
+
+	// And to the successor of leaving nodes.
+	for i := 0; i < 15; i++ {
+		ids := n.IDs()
+		if err := n.Leave(ids[rng.Intn(len(ids))]); err != nil {
+			t.Fatal(err)
+		}
+		n.Stabilize(1)
+	}
+	checkKeys("after leaves")
+	if err := n.Leave(12345678); err == nil {
+		t.Error("expected an error leaving a node that isn't live")
+	}
+
+	// Failures lose keys, but successor lists keep the ring routable.
+	for i := 0; i < 3; i++ {
+		ids := n.IDs()
+		n.Fail(ids[rng.Intn(len(ids))])
+	}
+	checkRouting(t, n, rng, 300)
+	n.Stabilize(2)
+	checkRouting(t, n, rng, 300)
+	stored := 0
+	for _, id := range n.IDs() {
+		stored += n.Node(id).Keys()
+	}
+	if stored == 0 || stored >= len(keys) {
+		t.Errorf("%d of %d keys left after failures", stored, len(keys))
+	}
+}
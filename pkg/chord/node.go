@@ -0,0 +1,143 @@
+package chord
+
+// Node is a live member of a Network.
+type Node struct {
+	ID ID
+
+	net            *Network
+	successors     []ID // the next Successors nodes clockwise, as far as known
+	predecessor    ID
+	hasPredecessor bool
+	finger         []ID // finger[i] is the successor of ID+2^i
+	data           map[ID]string
+}
+
+// Successors returns the successor list of the node.
+func (nd *Node) Successors() []ID { return append([]ID(nil), nd.successors...) }
+
+// Predecessor returns the predecessor of the node, if it knows one.
+func (nd *Node) Predecessor() (ID, bool) { return nd.predecessor, nd.hasPredecessor }
+
+// Fingers returns the finger table of the node.
+func (nd *Node) Fingers() []ID { return append([]ID(nil), nd.finger...) }
+
+// Keys returns the number of keys the node stores.
+func (nd *Node) Keys() int { return len(nd.data) }
+
+func (nd *Node) live(id ID) bool { return nd.net.nodes[id] != nil }
+
+// successor returns the first live node of the successor list, or the node
+// itself if none is left.
+func (nd *Node) successor() ID {
+	for _, s := range nd.successors {
+		if nd.live(s) {
+			return s
+		}
+	}
+	return nd.ID
+}
+
+// distance returns how far b lies clockwise from a.
+func (nd *Node) distance(a, b ID) ID { return nd.net.mask(b - a) }
+
+// FindSuccessor routes a lookup of key from the node and returns the node
+// key belongs to and the number of hops: nodes the lookup was forwarded to.
+func (nd *Node) FindSuccessor(key ID) (ID, int, error) {
+	cur := nd
+	for hops := 0; hops <= len(nd.net.nodes); hops++ {
+		succ := cur.successor()
+		if upTo(key, cur.ID, succ) {
+			return succ, hops, nil
+		}
+		next := cur.closestPreceding(key)
+		if next == cur.ID {
+			return succ, hops, nil
+		}
+		cur = nd.net.nodes[next]
+	}
+	return 0, len(nd.net.nodes), ErrUnreachable
+}
+
+// closestPreceding returns the live node among the fingers and successors
+// that lies closest before key, or the node itself.
+func (nd *Node) closestPreceding(key ID) ID {
+	best := nd.ID
+	consider := func(c ID) {
+		if nd.live(c) && between(c, nd.ID, key) && nd.distance(nd.ID, c) > nd.distance(nd.ID, best) {
+			best = c
+		}
+	}
+	for _, f := range nd.finger {
+		consider(f)
+	}
+	for _, s := range nd.successors {
+		consider(s)
+	}
+	return best
+}
+
+// stabilize drops a failed predecessor, adopts the predecessor of its
+// successor as successor if it lies in between, refreshes its successor
+// list from its successor's and notifies the successor of itself.
+func (nd *Node) stabilize() {
+	if nd.hasPredecessor && !nd.live(nd.predecessor) {
+		nd.hasPredecessor = false
+	}
+	succ := nd.net.nodes[nd.successor()]
+	if succ.hasPredecessor && nd.live(succ.predecessor) && between(succ.predecessor, nd.ID, succ.ID) {
+		succ = nd.net.nodes[succ.predecessor]
+	}
+	if succ == nd {
+		// Alone, or cut off from every successor: a known predecessor is
+		// the best way back into the ring.
+		if nd.hasPredecessor && nd.predecessor != nd.ID {
+			succ = nd.net.nodes[nd.predecessor]
+		}
+	}
+	nd.successors = append([]ID{succ.ID}, succ.successors...)
+	nd.trim()
+	succ.notify(nd.ID)
+}
+
+// notify tells the node that id might be its predecessor. If it is, the
+// keys in between move to it.
+func (nd *Node) notify(id ID) {
+	if id == nd.ID {
+		return
+	}
+	if nd.hasPredecessor && nd.live(nd.predecessor) && nd.predecessor != nd.ID && !between(id, nd.predecessor, nd.ID) {
+		return
+	}
+	nd.predecessor, nd.hasPredecessor = id, true
+	pred := nd.net.nodes[id]
+	for k, v := range nd.data {
+		if !upTo(k, id, nd.ID) {
+			pred.data[k] = v
+			delete(nd.data, k)
+		}
+	}
+}
+
+// fixFingers looks up the successor of every finger start.
+func (nd *Node) fixFingers() {
+	for i := range nd.finger {
+		if f, _, err := nd.FindSuccessor(nd.net.mask(nd.ID + 1<<i)); err == nil {
+			nd.finger[i] = f
+		}
+	}
+}
+
+// trim cuts the successor list to its length, stopping before the list
+// wraps around to the node itself.
+func (nd *Node) trim() {
+	out := nd.successors[:0]
+	seen := map[ID]bool{}
+	for _, s := range nd.successors {
+		if len(out) == nd.net.Successors || s == nd.ID && len(out) > 0 || seen[s] {
+			break
+		}
+		seen[s] = true
+		out = append(out, s)
+	}
+	nd.successors = out
+}
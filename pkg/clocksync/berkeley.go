@@ -0,0 +1,57 @@
+package clocksync
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Berkeley moves every clock to the average of the clocks, polled by master.
+// Clocks estimated more than tolerance from the median are left out of the
+// average; with tolerance 0 none is.
+func (s *Simulation) Berkeley(master int, tolerance time.Duration) (Report, error) {
+	if master < 0 || master >= len(s.clocks) {
+		return Report{}, fmt.Errorf("no clock %d", master)
+	}
+	if tolerance < 0 {
+		return Report{}, fmt.Errorf("tolerance %v is negative", tolerance)
+	}
+	r := s.begin()
+
+	// The offsets of every clock relative to the master, each as of the
+	// moment its reply arrived.
+	offsets := make([]time.Duration, len(s.clocks))
+	for i := range s.clocks {
+		if i == master {
+			continue
+		}
+		offset, rtt := s.roundTrip(master, i)
+		offsets[i] = offset
+		r.Messages += 2
+		r.Bound = max(r.Bound, rtt/2-s.cfg.MinDelay)
+	}
+
+	sorted := slices.Clone(offsets)
+	slices.Sort(sorted)
+	median := sorted[len(sorted)/2]
+	var sum time.Duration
+	kept := 0
+	for i, o := range offsets {
+		if tolerance > 0 && abs(o-median) > tolerance {
+			r.Ignored = append(r.Ignored, i)
+			continue
+		}
+		sum += o
+		kept++
+	}
+	avg := sum / time.Duration(kept)
+
+	for i := range s.clocks {
+		if i != master {
+			s.now += s.delay()
+			r.Messages++
+		}
+		s.clocks[i].Adjust(avg - offsets[i])
+	}
+	return s.end(r), nil
+}
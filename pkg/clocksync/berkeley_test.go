@@ -0,0 +1,60 @@
+package clocksync
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSimulation_Berkeley(t *testing.T) {
+	tests := []struct {
+		name      string
+		tolerance time.Duration
+		ignored   []int
+	}{
+		// Clock 3 is an hour off and drags the average along.
+		{"plain average", 0, nil},
+		{"fault tolerant", 5 * time.Second, []int{3}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := New(Config{Clocks: 10, MaxOffset: time.Second, MaxDrift: 1e-5, MinDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond, Seed: 3})
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.Clock(3).Adjust(time.Hour)
+			r, err := s.Berkeley(0, tc.tolerance)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Log(r)
+			if !slices.Equal(r.Ignored, tc.ignored) {
+				t.Errorf("ignored %v, want %v", r.Ignored, tc.ignored)
+			}
+			// Every clock, the broken one too, lands within twice the
+			// estimate bound of the others.
+			drift := time.Duration(2e-5 * float64(s.Now()))
+			if r.SkewAfter > 2*r.Bound+drift {
+				t.Errorf("skew %v exceeds twice the bound %v", r.SkewAfter, r.Bound)
+			}
+			if r.Messages != 3*9 {
+				t.Errorf("%d messages, want %d", r.Messages, 3*9)
+			}
+			dragged := r.ErrorAfter > time.Hour/20
+			if dragged != (tc.tolerance == 0) {
+				t.Errorf("error after %v: the broken clock should drag the average only without tolerance", r.ErrorAfter)
+			}
+		})
+	}
+}
+
+func TestSimulation_BerkeleyErrors(t *testing.T) {
+	s, _ := New(Config{Clocks: 2})
+	if _, err := s.Berkeley(-1, 0); err == nil {
+		t.Error("expected an error for a missing master")
+	}
+	if _, err := s.Berkeley(0, -time.Second); err == nil {
+		t.Error("expected an error for a negative tolerance")
+	}
+}
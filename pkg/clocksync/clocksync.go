@@ -0,0 +1,168 @@
+// Package clocksync simulates physical clocks that disagree, and two ways to
+// bring them back together. Every clock starts at an offset from true time
+// and drifts at its own rate, and messages take a random delay between
+// MinDelay and MaxDelay.
+//
+// Cristian's algorithm sets every clock to the time of a server, read over
+// a round trip: the client assumes the reply spent half the round trip in
+// flight, which is off by at most half the round trip minus MinDelay. Of
+// several tries, the shortest round trip gives the tightest bound.
+//
+// The Berkeley algorithm needs no accurate server: a master polls every
+// clock, estimates its offset the way Cristian's algorithm does, and moves
+// every clock to the average of the estimates. The average leaves out the
+// clocks too far from the median, so one broken clock can't drag the others
+// away; it is corrected all the same. Corrections are sent as adjustments
+// rather than times, so their own delay doesn't matter.
+//
+// References:
+//
+// Cristian, Probabilistic Clock Synchronization, Distributed Computing 3(3),
+// 1989.
+//
+// Gusella and Zatti, The Accuracy of the Clock Synchronization Achieved by
+// TEMPO in Berkeley UNIX 4.3BSD, IEEE Transactions on Software Engineering
+// 15(7), 1989.
+package clocksync
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Clock is a physical clock: at true time t it reads t·(1+Drift) + Offset.
+type Clock struct {
+	Offset time.Duration
+	Drift  float64
+}
+
+// Read returns the reading of the clock at true time t.
+func (c *Clock) Read(t time.Duration) time.Duration {
+	return t + time.Duration(c.Drift*float64(t)) + c.Offset
+}
+
+// Adjust moves the clock by d.
+func (c *Clock) Adjust(d time.Duration) { c.Offset += d }
+
+// Config describes a set of clocks and the network between them.
+type Config struct {
+	Clocks    int
+	MaxOffset time.Duration // initial offsets are uniform in ±MaxOffset
+	MaxDrift  float64       // drift rates are uniform in ±MaxDrift
+	MinDelay  time.Duration // one-way message delays are uniform in [MinDelay, MaxDelay]
+	MaxDelay  time.Duration
+	Seed      int64
+}
+
+// Simulation runs clocks in virtual true time.
+type Simulation struct {
+	cfg    Config
+	clocks []Clock
+	rng    *rand.Rand
+	now    time.Duration
+}
+
+// New returns clocks with random offsets and drifts at true time 0.
+func New(cfg Config) (*Simulation, error) {
+	if cfg.Clocks < 1 {
+		return nil, fmt.Errorf("need at least one clock, got %d", cfg.Clocks)
+	}
+	if cfg.MinDelay < 0 || cfg.MaxDelay < cfg.MinDelay {
+		return nil, fmt.Errorf("delays [%v, %v] are not a range", cfg.MinDelay, cfg.MaxDelay)
+	}
+	if cfg.MaxOffset < 0 || cfg.MaxDrift < 0 {
+		return nil, fmt.Errorf("offset %v and drift %v must not be negative", cfg.MaxOffset, cfg.MaxDrift)
+	}
+	s := &Simulation{cfg: cfg, clocks: make([]Clock, cfg.Clocks), rng: rand.New(rand.NewSource(cfg.Seed))}
+	for i := range s.clocks {
+		s.clocks[i] = Clock{
+			Offset: time.Duration((2*s.rng.Float64() - 1) * float64(cfg.MaxOffset)),
+			Drift:  (2*s.rng.Float64() - 1) * cfg.MaxDrift,
+		}
+	}
+	return s, nil
+}
+
+// Clock returns clock i, to inspect or set.
+func (s *Simulation) Clock(i int) *Clock { return &s.clocks[i] }
+
+// Now returns the true time.
+func (s *Simulation) Now() time.Duration { return s.now }
+
+// Advance moves the true time forward by d.
+func (s *Simulation) Advance(d time.Duration) { s.now += d }
+
+// Read returns the reading of clock i now.
+func (s *Simulation) Read(i int) time.Duration { return s.clocks[i].Read(s.now) }
+
+// Skew returns the spread of the readings of the clocks now: the largest
+// minus the smallest.
+func (s *Simulation) Skew() time.Duration {
+	lo, hi := s.Read(0), s.Read(0)
+	for i := range s.clocks {
+		r := s.Read(i)
+		lo, hi = min(lo, r), max(hi, r)
+	}
+	return hi - lo
+}
+
+// Error returns the largest distance of a clock from true time now.
+func (s *Simulation) Error() time.Duration {
+	var worst time.Duration
+	for i := range s.clocks {
+		worst = max(worst, abs(s.Read(i)-s.now))
+	}
+	return worst
+}
+
+// delay returns the delay of one message.
+func (s *Simulation) delay() time.Duration {
+	return s.cfg.MinDelay + time.Duration(s.rng.Int63n(int64(s.cfg.MaxDelay-s.cfg.MinDelay)+1))
+}
+
+// roundTrip has clock from ask clock to for its time. It returns the
+// estimate of the reading of to minus the reading of from, taken when the
+// reply arrives, and the round trip as measured by from. The true time
+// advances by the round trip.
+func (s *Simulation) roundTrip(from, to int) (offset, rtt time.Duration) {
+	sent := s.Read(from)
+	s.now += s.delay()
+	remote := s.Read(to)
+	s.now += s.delay()
+	received := s.Read(from)
+	rtt = received - sent
+	return remote + rtt/2 - received, rtt
+}
+
+// Report describes one synchronization.
+type Report struct {
+	SkewBefore, SkewAfter   time.Duration // spread of the readings
+	ErrorBefore, ErrorAfter time.Duration // largest distance from true time
+	// Bound is the largest error of an offset estimate the round trips
+	// guarantee, ignoring drift during them.
+	Bound    time.Duration
+	Messages int
+	Ignored  []int // clocks left out of the Berkeley average
+}
+
+func (r Report) String() string {
+	return fmt.Sprintf("skew %v -> %v, error %v -> %v, bound %v, %d messages",
+		r.SkewBefore, r.SkewAfter, r.ErrorBefore, r.ErrorAfter, r.Bound, r.Messages)
+}
+
+func (s *Simulation) begin() Report {
+	return Report{SkewBefore: s.Skew(), ErrorBefore: s.Error()}
+}
+
+func (s *Simulation) end(r Report) Report {
+	r.SkewAfter, r.ErrorAfter = s.Skew(), s.Error()
+	return r
+}
+
+func abs(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,46 @@
+package clocksync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClock(t *testing.T) {
+	c := Clock{Offset: time.Second, Drift: 1e-3}
+	if got, want := c.Read(time.Hour), time.Hour+3600*time.Millisecond+time.Second; got != want {
+		t.Errorf("Read = %v, want %v", got, want)
+	}
+	c.Adjust(-time.Second)
+	if got, want := c.Read(time.Hour), time.Hour+3600*time.Millisecond; got != want {
+		t.Errorf("Read after Adjust = %v, want %v", got, want)
+	}
+}
+
+func TestSimulation_Drift(t *testing.T) {
+	s, err := New(Config{Clocks: 10, MaxDrift: 1e-5, MaxDelay: time.Millisecond, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Skew() != 0 {
+		t.Fatalf("clocks without offsets start %v apart", s.Skew())
+	}
+	s.Advance(time.Hour)
+	// Two clocks drift apart by at most 2·MaxDrift.
+	if skew, limit := s.Skew(), time.Duration(2e-5*float64(time.Hour)); skew == 0 || skew > limit {
+		t.Errorf("skew after an hour %v, want in (0, %v]", skew, limit)
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	for _, cfg := range []Config{
+		{Clocks: 0},
+		{Clocks: 1, MinDelay: 2, MaxDelay: 1},
+		{Clocks: 1, MinDelay: -1},
+		{Clocks: 1, MaxOffset: -1},
+		{Clocks: 1, MaxDrift: -1},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("expected an error for %+v", cfg)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package clocksync
+
+import "fmt"
+
+// Cristian sets every clock but server to the time of server, keeping the
+// shortest of tries round trips per clock.
+func (s *Simulation) Cristian(server, tries int) (Report, error) {
+	if server < 0 || server >= len(s.clocks) {
+		return Report{}, fmt.Errorf("no clock %d", server)
+	}
+	if tries < 1 {
+		return Report{}, fmt.Errorf("need at least one try, got %d", tries)
+	}
+	r := s.begin()
+	for i := range s.clocks {
+		if i == server {
+			continue
+		}
+		best, bestRTT := s.roundTrip(i, server)
+		for k := 1; k < tries; k++ {
+			// Later tries measure the offset at a later time; drift
+			// aside, it is the same offset.
+			if offset, rtt := s.roundTrip(i, server); rtt < bestRTT {
+				best, bestRTT = offset, rtt
+			}
+		}
+		r.Messages += 2 * tries
+		s.clocks[i].Adjust(best)
+		r.Bound = max(r.Bound, bestRTT/2-s.cfg.MinDelay)
+	}
+	return s.end(r), nil
+}
@@ -0,0 +1,61 @@
+package clocksync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSimulation_Cristian(t *testing.T) {
+	tests := []struct {
+		name     string
+		min, max time.Duration
+		tries    int
+	}{
+		{"fixed delay", 5 * time.Millisecond, 5 * time.Millisecond, 1},
+		{"jitter", time.Millisecond, 20 * time.Millisecond, 1},
+		{"jitter, best of 8", time.Millisecond, 20 * time.Millisecond, 8},
+	}
+	var bounds []time.Duration
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := New(Config{Clocks: 20, MaxOffset: time.Second, MaxDrift: 1e-5, MinDelay: tc.min, MaxDelay: tc.max, Seed: 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The server tells true time.
+			*s.Clock(0) = Clock{}
+			r, err := s.Cristian(0, tc.tries)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Log(r)
+			drift := time.Duration(2e-5 * float64(s.Now()))
+			if r.ErrorAfter > r.Bound+drift {
+				t.Errorf("error %v exceeds the bound %v", r.ErrorAfter, r.Bound)
+			}
+			if r.SkewAfter >= r.SkewBefore/100 {
+				t.Errorf("skew only went from %v to %v", r.SkewBefore, r.SkewAfter)
+			}
+			if r.Messages != 2*tc.tries*19 {
+				t.Errorf("%d messages, want %d", r.Messages, 2*tc.tries*19)
+			}
+			bounds = append(bounds, r.Bound)
+		})
+	}
+	// Round trips are measured by drifting clocks, so even a fixed delay
+	// leaves a few nanoseconds.
+	if bounds[0] > time.Microsecond || bounds[2] >= bounds[1] {
+		t.Errorf("bounds %v: want about 0 without jitter and tighter with more tries", bounds)
+	}
+}
+
+func TestSimulation_CristianErrors(t *testing.T) {
+	s, _ := New(Config{Clocks: 2})
+	if _, err := s.Cristian(2, 1); err == nil {
+		t.Error("expected an error for a missing server")
+	}
+	if _, err := s.Cristian(0, 0); err == nil {
+		t.Error("expected an error without tries")
+	}
+}
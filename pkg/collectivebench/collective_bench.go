@@ -0,0 +1,179 @@
+// Package collectivebench sweeps collective algorithms over group sizes and
+// vector lengths and reports how long they take.
+package collectivebench
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/sanderblue/algorithms/pkg/ringallreduce"
+)
+
+// Algorithm is a named way of wiring P ranks. Topology returns nil for group
+// sizes the algorithm doesn't support; those combinations are skipped.
+type Algorithm struct {
+	Name     string
+	Topology func(p int) ringallreduce.Topology
+}
+
+// DefaultAlgorithms returns every topology of the ringallreduce package.
+func DefaultAlgorithms() []Algorithm {
+	return []Algorithm{
+		{Name: "ring", Topology: func(p int) ringallreduce.Topology { return ringallreduce.NewRing(p) }},
+		{Name: "tree", Topology: func(p int) ringallreduce.Topology { return ringallreduce.NewTree(p) }},
+		{Name: "torus", Topology: squarishTorus},
+		{Name: "fully-connected", Topology: func(p int) ringallreduce.Topology { return ringallreduce.NewFullyConnected(p) }},
+		{Name: "recursive-doubling", Topology: func(p int) ringallreduce.Topology { return ringallreduce.NewRecursiveDoubling(p) }},
+		{Name: "parameter-server", Topology: func(p int) ringallreduce.Topology { return ringallreduce.NewParameterServer(p) }},
+	}
+}
+
+// squarishTorus lays p ranks out on the most square grid with at least two
+// rows and columns, or returns nil when p is prime or too small.
+func squarishTorus(p int) ringallreduce.Topology {
+	for rows := int(math.Sqrt(float64(p))); rows >= 2; rows-- {
+		if p%rows == 0 && p/rows >= 2 {
+			return ringallreduce.NewTorus(rows, p/rows)
+		}
+	}
+	return nil
+}
+
+// Config describes a sweep. Every algorithm runs for every combination of
+// group size and vector length.
+type Config struct {
+	Algorithms []Algorithm // defaults to DefaultAlgorithms
+	Sizes      []int       // group sizes P
+	Elements   []int       // vector lengths in float64 elements
+	Trials     int         // timed runs per combination; defaults to 5
+	Warmup     int         // untimed runs before the trials
+}
+
+// Result summarizes the trials of one combination.
+type Result struct {
+	Algorithm  string
+	P          int
+	Elements   int
+	Trials     int
+	Mean       time.Duration
+	Min        time.Duration
+	Max        time.Duration
+	Throughput float64 // reduced bytes per second, 8*Elements/Mean
+	Steps      int     // longest schedule of any rank
+	Messages   int     // chunk messages sent by all ranks together
+}
+
+// Run executes the sweep and returns one Result per combination, in the
+// order algorithms, sizes and element counts are listed.
+func Run(cfg Config) ([]Result, error) {
+	algorithms := cfg.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = DefaultAlgorithms()
+	}
+	trials := cfg.Trials
+	if trials < 1 {
+		trials = 5
+	}
+
+	var results []Result
+	for _, alg := range algorithms {
+		for _, p := range cfg.Sizes {
+			t := alg.Topology(p)
+			if t == nil {
+				continue
+			}
+			for _, n := range cfg.Elements {
+				res, err := measure(alg.Name, t, n, trials, cfg.Warmup)
+				if err != nil {
+					return nil, fmt.Errorf("%s p=%d n=%d: %w", alg.Name, p, n, err)
+				}
+				results = append(results, res)
+			}
+		}
+	}
+	return results, nil
+}
+
+func measure(name string, t ringallreduce.Topology, n, trials, warmup int) (Result, error) {
+	p := t.Size()
+	inputs := make([][]float64, p)
+	for i := range inputs {
+		inputs[i] = make([]float64, n)
+		for j := range inputs[i] {
+			inputs[i][j] = float64(i + 1)
+		}
+	}
+
+	r := ringallreduce.New()
+	res := Result{Algorithm: name, P: p, Elements: n, Trials: trials, Min: time.Duration(math.MaxInt64)}
+	var total time.Duration
+	for i := 0; i < warmup+trials; i++ {
+		began := time.Now()
+		if _, err := r.AllReduce(t, inputs); err != nil {
+			return Result{}, err
+		}
+		elapsed := time.Since(began)
+		if i < warmup {
+			continue
+		}
+		total += elapsed
+		res.Min = min(res.Min, elapsed)
+		res.Max = max(res.Max, elapsed)
+	}
+	res.Mean = total / time.Duration(trials)
+	if res.Mean > 0 {
+		res.Throughput = float64(8*n) / res.Mean.Seconds()
+	}
+
+	for rank := 0; rank < p; rank++ {
+		steps := t.Schedule(rank)
+		res.Steps = max(res.Steps, len(steps))
+		for _, step := range steps {
+			if step.SendTo != ringallreduce.NoPeer {
+				res.Messages += len(step.SendChunks)
+			}
+		}
+	}
+	return res, nil
+}
+
+var csvHeader = []string{"algorithm", "p", "elements", "trials", "mean_ns", "min_ns", "max_ns", "throughput_bytes_per_s", "steps", "messages"}
+
+// WriteCSV writes results as CSV with a header row.
+func WriteCSV(w io.Writer, results []Result) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, r := range results {
+		record := []string{
+			r.Algorithm,
+			strconv.Itoa(r.P),
+			strconv.Itoa(r.Elements),
+			strconv.Itoa(r.Trials),
+			strconv.FormatInt(r.Mean.Nanoseconds(), 10),
+			strconv.FormatInt(r.Min.Nanoseconds(), 10),
+			strconv.FormatInt(r.Max.Nanoseconds(), 10),
+			strconv.FormatFloat(r.Throughput, 'f', 0, 64),
+			strconv.Itoa(r.Steps),
+			strconv.Itoa(r.Messages),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes results as a JSON array.
+func WriteJSON(w io.Writer, results []Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
@@ -0,0 +1,87 @@
+package collectivebench
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	results, err := Run(Config{Sizes: []int{3, 4}, Elements: []int{8, 64}, Trials: 2, Warmup: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The torus skips p=3, every other algorithm runs all 4 combinations.
+	if len(results) != 5*4+2 {
+		t.Fatalf("expected 22 results, got %d", len(results))
+	}
+	tests := []struct {
+		algorithm string
+		p         int
+		steps     int
+		messages  int
+	}{
+		{algorithm: "ring", p: 4, steps: 6, messages: 4 * 6},
+		{algorithm: "tree", p: 3, steps: 4, messages: 2 * 2 * 3},
+		{algorithm: "torus", p: 4, steps: 4, messages: 4 * (2 + 1 + 1 + 2)},
+		{algorithm: "fully-connected", p: 3, steps: 4, messages: 3 * 4},
+		{algorithm: "recursive-doubling", p: 4, steps: 2, messages: 4 * 2 * 4},
+		{algorithm: "parameter-server", p: 4, steps: 6, messages: 2 * 3 * 4},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.algorithm, func(t *testing.T) {
+			for _, r := range results {
+				if r.Algorithm != tc.algorithm || r.P != tc.p {
+					continue
+				}
+				if r.Steps != tc.steps || r.Messages != tc.messages {
+					t.Errorf("n=%d: expected %d steps and %d messages, got %d and %d", r.Elements, tc.steps, tc.messages, r.Steps, r.Messages)
+				}
+				if r.Trials != 2 || r.Min > r.Mean || r.Mean > r.Max || r.Throughput <= 0 {
+					t.Errorf("n=%d: inconsistent timings %+v", r.Elements, r)
+				}
+				return
+			}
+			t.Errorf("no result for %s p=%d", tc.algorithm, tc.p)
+		})
+	}
+}
+
+func TestWriteCSVAndJSON(t *testing.T) {
+	results, err := Run(Config{
+		Algorithms: DefaultAlgorithms()[:1],
+		Sizes:      []int{2},
+		Elements:   []int{4, 16},
+		Trials:     1,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, results); err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(records) != 3 || records[0][0] != "algorithm" || records[2][2] != "16" {
+		t.Errorf("unexpected CSV %v", records)
+	}
+
+	buf.Reset()
+	if err := WriteJSON(&buf, results); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	var decoded []Result
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(decoded) != 2 || decoded[1].Elements != 16 {
+		t.Errorf("unexpected JSON %+v", decoded)
+	}
+}
@@ -0,0 +1,415 @@
+// Package commit implements atomic commitment protocols: a coordinator and
+// participants agree on committing or aborting a transaction, so that no
+// participant commits while another aborts. Two-phase commit blocks the
+// participants that voted yes when the coordinator fails; three-phase commit
+// adds a round so that they can finish on their own.
+//
+// Every role runs on its own goroutine and talks to the others by messages.
+// Tests choose how each participant votes, where the coordinator fails, and
+// how long the roles wait for one another, to show how each protocol
+// behaves under failures.
+//
+// References:
+//
+// Gray, Notes on Data Base Operating Systems, 1978.
+package commit
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Vote is the answer of a participant to the request to prepare.
+type Vote int
+
+const (
+	VoteYes  Vote = iota // ready to commit
+	VoteNo               // must abort
+	VoteNone             // never answers, as if it crashed
+)
+
+func (v Vote) String() string {
+	switch v {
+	case VoteYes:
+		return "yes"
+	case VoteNo:
+		return "no"
+	case VoteNone:
+		return "none"
+	default:
+		return fmt.Sprintf("vote(%d)", int(v))
+	}
+}
+
+// Outcome is the fate of the transaction.
+type Outcome int
+
+const (
+	Undecided Outcome = iota
+	Committed
+	Aborted
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case Undecided:
+		return "undecided"
+	case Committed:
+		return "committed"
+	case Aborted:
+		return "aborted"
+	default:
+		return fmt.Sprintf("outcome(%d)", int(o))
+	}
+}
+
+// State is the state of a participant.
+type State int
+
+const (
+	StateWorking      State = iota // hasn't voted yet
+	StatePrepared                  // voted yes and waits for the outcome
+	StatePreCommitted              // three-phase commit: knows every participant voted yes
+	StateCommitted
+	StateAborted
+)
+
+func (s State) String() string {
+	switch s {
+	case StateWorking:
+		return "working"
+	case StatePrepared:
+		return "prepared"
+	case StatePreCommitted:
+		return "pre-committed"
+	case StateCommitted:
+		return "committed"
+	case StateAborted:
+		return "aborted"
+	default:
+		return fmt.Sprintf("state(%d)", int(s))
+	}
+}
+
+// MessageType is the kind of a message.
+type MessageType int
+
+const (
+	MsgPrepare      MessageType = iota // coordinator asks for votes (can-commit)
+	MsgYes                             // participant votes yes
+	MsgNo                              // participant votes no
+	MsgCommit                          // coordinator decided to commit (do-commit)
+	MsgAbort                           // coordinator decided to abort
+	MsgAck                             // participant applied the outcome
+	MsgPreCommit                       // three-phase commit: every participant voted yes
+	MsgPreCommitAck                    // participant acknowledges the pre-commit
+	MsgStateRequest                    // termination protocol: a participant asks another's state
+	MsgStateReport                     // termination protocol: the answer
+)
+
+func (t MessageType) String() string {
+	switch t {
+	case MsgPrepare:
+		return "prepare"
+	case MsgYes:
+		return "yes"
+	case MsgNo:
+		return "no"
+	case MsgCommit:
+		return "commit"
+	case MsgAbort:
+		return "abort"
+	case MsgAck:
+		return "ack"
+	case MsgPreCommit:
+		return "pre-commit"
+	case MsgPreCommitAck:
+		return "pre-commit-ack"
+	case MsgStateRequest:
+		return "state-request"
+	case MsgStateReport:
+		return "state-report"
+	default:
+		return fmt.Sprintf("message(%d)", int(t))
+	}
+}
+
+// Crash is the point where the coordinator fails, if anywhere.
+type Crash int
+
+const (
+	CrashNone Crash = iota
+	// CrashBeforePrepare fails before asking for any vote.
+	CrashBeforePrepare
+	// CrashBeforeDecision fails after collecting the votes, before sending
+	// the outcome to anyone.
+	CrashBeforeDecision
+	// CrashDuringDecision fails after sending the outcome to participant 0
+	// only.
+	CrashDuringDecision
+	// CrashBeforeCommit fails, in three-phase commit, after every
+	// participant acknowledged the pre-commit but before any do-commit. In
+	// two-phase commit, which has no pre-commit, it is CrashBeforeDecision.
+	CrashBeforeCommit
+)
+
+// Crashes lists every crash point, CrashNone included.
+var Crashes = []Crash{CrashNone, CrashBeforePrepare, CrashBeforeDecision, CrashDuringDecision, CrashBeforeCommit}
+
+func (c Crash) String() string {
+	switch c {
+	case CrashNone:
+		return "none"
+	case CrashBeforePrepare:
+		return "before-prepare"
+	case CrashBeforeDecision:
+		return "before-decision"
+	case CrashDuringDecision:
+		return "during-decision"
+	case CrashBeforeCommit:
+		return "before-commit"
+	default:
+		return fmt.Sprintf("crash(%d)", int(c))
+	}
+}
+
+// Config describes one transaction.
+type Config struct {
+	// Votes gives the vote of every participant, one participant per vote.
+	Votes []Vote
+	// Timeout is how long the coordinator waits for votes before aborting,
+	// and participants for the coordinator before acting on their own.
+	Timeout time.Duration
+	// Crash is where the coordinator fails. In three-phase commit, the
+	// decision the crash points refer to is the pre-commit, or the abort.
+	Crash Crash
+}
+
+func (cfg Config) validate() error {
+	if len(cfg.Votes) == 0 {
+		return fmt.Errorf("no participants")
+	}
+	if cfg.Timeout <= 0 {
+		return fmt.Errorf("timeout %v is not positive", cfg.Timeout)
+	}
+	return nil
+}
+
+// Result reports how a transaction ended.
+type Result struct {
+	Decision Outcome // the coordinator's; Undecided if it failed first
+	States   []State // final state of every participant
+	// Blocked lists the participants that voted yes and never learned the
+	// outcome: they must hold their locks until the coordinator recovers.
+	Blocked  []int
+	Messages map[MessageType]int
+}
+
+// Consistent reports whether no participant committed while another
+// aborted.
+func (r Result) Consistent() bool {
+	committed, aborted := false, false
+	for _, s := range r.States {
+		committed = committed || s == StateCommitted
+		aborted = aborted || s == StateAborted
+	}
+	return !committed || !aborted
+}
+
+type message struct {
+	typ   MessageType
+	from  int   // participant, or -1 for the coordinator
+	state State // MsgStateReport
+}
+
+const coordinator = -1
+
+// network carries the messages of one transaction.
+type network struct {
+	participants []chan message
+	coordinator  chan message
+	sent         [numMessageTypes]atomic.Int64
+}
+
+const numMessageTypes = int(MsgStateReport) + 1
+
+func newNetwork(n int) *network {
+	// Buffers are large enough for every message of a run, so sends never
+	// block on a crashed or busy receiver.
+	net := &network{coordinator: make(chan message, 4*n)}
+	for i := 0; i < n; i++ {
+		net.participants = append(net.participants, make(chan message, 4*n+8))
+	}
+	return net
+}
+
+func (net *network) send(to int, m message) {
+	net.sent[m.typ].Add(1)
+	if to == coordinator {
+		net.coordinator <- m
+		return
+	}
+	net.participants[to] <- m
+}
+
+func (net *network) messages() map[MessageType]int {
+	out := make(map[MessageType]int)
+	for t := range net.sent {
+		if n := net.sent[t].Load(); n > 0 {
+			out[MessageType(t)] = int(n)
+		}
+	}
+	return out
+}
+
+// TwoPhase runs a transaction with the two-phase commit protocol. The
+// coordinator asks every participant to prepare and commits only if all of
+// them vote yes within Timeout, aborting otherwise. A participant that isn't
+// asked to prepare within Timeout aborts on its own; one that voted yes may
+// not, and blocks until it learns the outcome. The run ends once every
+// participant that answered knows the outcome, or 4×Timeout after the
+// coordinator is done.
+func TwoPhase(cfg Config) (Result, error) {
+	if err := cfg.validate(); err != nil {
+		return Result{}, err
+	}
+	return run(cfg, coordinator2PC, participant2PC), nil
+}
+
+// run runs the coordinator and participants of a transaction and collects
+// the result.
+func run(cfg Config,
+	coordinate func(n int, cfg Config, net *network) Outcome,
+	participate func(i int, vote Vote, timeout time.Duration, net *network, decided func(), stop <-chan struct{}) State,
+) Result {
+	n := len(cfg.Votes)
+	net := newNetwork(n)
+	stop := make(chan struct{})
+	states := make([]State, n)
+	var wg, undecided sync.WaitGroup
+	undecided.Add(n)
+	for i, vote := range cfg.Votes {
+		wg.Add(1)
+		go func(i int, vote Vote) {
+			defer wg.Done()
+			states[i] = participate(i, vote, cfg.Timeout, net, undecided.Done, stop)
+		}(i, vote)
+	}
+
+	decision := coordinate(n, cfg, net)
+
+	allDecided := make(chan struct{})
+	go func() {
+		undecided.Wait()
+		close(allDecided)
+	}()
+	select {
+	case <-allDecided:
+	case <-time.After(4 * cfg.Timeout):
+	}
+	close(stop)
+	wg.Wait()
+	res := Result{Decision: decision, States: states, Messages: net.messages()}
+	for i, s := range states {
+		if s == StatePrepared || s == StatePreCommitted {
+			res.Blocked = append(res.Blocked, i)
+		}
+	}
+	return res
+}
+
+func coordinator2PC(n int, cfg Config, net *network) Outcome {
+	if cfg.Crash == CrashBeforePrepare {
+		return Undecided
+	}
+	decision := collectVotes(n, cfg, net)
+	if cfg.Crash == CrashBeforeDecision || cfg.Crash == CrashBeforeCommit {
+		return Undecided
+	}
+	typ := MsgCommit
+	if decision == Aborted {
+		typ = MsgAbort
+	}
+	announce(n, typ, cfg.Crash == CrashDuringDecision, net)
+	return decision
+}
+
+// collectVotes asks every participant to prepare and returns Committed if
+// they all vote yes within the timeout.
+func collectVotes(n int, cfg Config, net *network) Outcome {
+	for i := 0; i < n; i++ {
+		net.send(i, message{typ: MsgPrepare, from: coordinator})
+	}
+	timeout := time.After(cfg.Timeout)
+	for votes := 0; votes < n; votes++ {
+		select {
+		case m := <-net.coordinator:
+			if m.typ == MsgNo {
+				return Aborted
+			}
+		case <-timeout:
+			return Aborted
+		}
+	}
+	return Committed
+}
+
+// announce sends a message of type typ to every participant, or only to
+// participant 0 if the coordinator crashes right after.
+func announce(n int, typ MessageType, crash bool, net *network) {
+	for i := 0; i < n; i++ {
+		net.send(i, message{typ: typ, from: coordinator})
+		if crash {
+			return
+		}
+	}
+}
+
+// participant2PC runs participant i until it knows the outcome, or until
+// stop if it blocks, and returns its final state. It calls decided once it
+// knows the outcome.
+func participant2PC(i int, vote Vote, timeout time.Duration, net *network, decided func(), stop <-chan struct{}) State {
+	state := StateWorking
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case m := <-net.participants[i]:
+			switch m.typ {
+			case MsgPrepare:
+				switch vote {
+				case VoteYes:
+					state = StatePrepared
+					net.send(coordinator, message{typ: MsgYes, from: i})
+				case VoteNo:
+					net.send(coordinator, message{typ: MsgNo, from: i})
+					decided()
+					return StateAborted
+				case VoteNone:
+					// Crashed: never answers nor learns anything.
+					<-stop
+					return state
+				}
+			case MsgCommit:
+				net.send(coordinator, message{typ: MsgAck, from: i})
+				decided()
+				return StateCommitted
+			case MsgAbort:
+				net.send(coordinator, message{typ: MsgAck, from: i})
+				decided()
+				return StateAborted
+			}
+		case <-timer.C:
+			if state == StateWorking {
+				// Not asked to prepare: nothing is promised, so abort.
+				decided()
+				return StateAborted
+			}
+			// Prepared: the outcome may be either, so all it can do is wait.
+		case <-stop:
+			return state
+		}
+	}
+}
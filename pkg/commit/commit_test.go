@@ -0,0 +1,88 @@
+package commit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+const timeout = 20 * time.Millisecond
+
+var (
+	C = StateCommitted
+	A = StateAborted
+	P = StatePrepared
+	W = StateWorking
+)
+
+func TestTwoPhase(t *testing.T) {
+	tests := []struct {
+		name     string
+		votes    []Vote
+		crash    Crash
+		decision Outcome
+		states   []State
+		blocked  []int
+	}{
+		{"all yes", []Vote{VoteYes, VoteYes, VoteYes}, CrashNone, Committed, []State{C, C, C}, nil},
+		{"one no", []Vote{VoteYes, VoteNo, VoteYes}, CrashNone, Aborted, []State{A, A, A}, nil},
+		// The silent participant crashed; the others abort on the timeout.
+		{"no answer", []Vote{VoteYes, VoteNone, VoteYes}, CrashNone, Aborted, []State{A, W, A}, nil},
+		{"crash before prepare", []Vote{VoteYes, VoteYes}, CrashBeforePrepare, Undecided, []State{A, A}, nil},
+		// Participants that voted yes can neither commit nor abort.
+		{"crash before decision", []Vote{VoteYes, VoteYes, VoteYes}, CrashBeforeDecision, Undecided, []State{P, P, P}, []int{0, 1, 2}},
+		{"crash before abort", []Vote{VoteYes, VoteNo, VoteYes}, CrashBeforeDecision, Undecided, []State{P, A, P}, []int{0, 2}},
+		{"crash during decision", []Vote{VoteYes, VoteYes, VoteYes}, CrashDuringDecision, Committed, []State{C, P, P}, []int{1, 2}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			res, err := TwoPhase(Config{Votes: tc.votes, Timeout: timeout, Crash: tc.crash})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Decision != tc.decision {
+				t.Errorf("decision %v, want %v", res.Decision, tc.decision)
+			}
+			if !reflect.DeepEqual(res.States, tc.states) {
+				t.Errorf("states %v, want %v", res.States, tc.states)
+			}
+			if !reflect.DeepEqual(res.Blocked, tc.blocked) {
+				t.Errorf("blocked %v, want %v", res.Blocked, tc.blocked)
+			}
+			if !res.Consistent() {
+				t.Errorf("inconsistent outcome %v", res.States)
+			}
+		})
+	}
+}
+
+func TestTwoPhase_Messages(t *testing.T) {
+	res, err := TwoPhase(Config{Votes: []Vote{VoteYes, VoteYes, VoteYes, VoteYes}, Timeout: timeout})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[MessageType]int{MsgPrepare: 4, MsgYes: 4, MsgCommit: 4, MsgAck: 4}
+	if !reflect.DeepEqual(res.Messages, want) {
+		t.Errorf("messages %v, want %v", res.Messages, want)
+	}
+}
+
+func TestTwoPhase_Errors(t *testing.T) {
+	if _, err := TwoPhase(Config{Timeout: timeout}); err == nil {
+		t.Error("expected an error without participants")
+	}
+	if _, err := TwoPhase(Config{Votes: []Vote{VoteYes}}); err == nil {
+		t.Error("expected an error without a timeout")
+	}
+}
+
+func TestResult_Consistent(t *testing.T) {
+	if (Result{States: []State{C, P, W}}).Consistent() != true {
+		t.Error("committed and undecided participants are consistent")
+	}
+	if (Result{States: []State{C, A}}).Consistent() != false {
+		t.Error("committed and aborted participants are inconsistent")
+	}
+}
@@ -0,0 +1,189 @@
+package commit
+
+import (
+	"sync"
+	"time"
+)
+
+// ThreePhase runs a transaction with the three-phase commit protocol. Once
+// every participant voted yes, the coordinator first pre-commits them, so
+// that no participant commits while another could still be uncertain, and
+// only then commits. A participant that times out waiting for the
+// coordinator runs the termination protocol instead of blocking: it asks
+// the others for their state and commits if any pre-committed or
+// committed, and aborts otherwise.
+//
+// The termination protocol relies on timeouts telling crashed processes
+// apart from slow ones: three-phase commit doesn't block when the
+// coordinator crashes, but isn't safe under network partitions.
+//
+// References:
+//
+// Skeen, Nonblocking Commit Protocols, SIGMOD 1981.
+func ThreePhase(cfg Config) (Result, error) {
+	if err := cfg.validate(); err != nil {
+		return Result{}, err
+	}
+	return run(cfg, coordinator3PC, participant3PC), nil
+}
+
+func coordinator3PC(n int, cfg Config, net *network) Outcome {
+	if cfg.Crash == CrashBeforePrepare {
+		return Undecided
+	}
+	decision := collectVotes(n, cfg, net)
+	if cfg.Crash == CrashBeforeDecision {
+		return Undecided
+	}
+	if decision == Aborted {
+		announce(n, MsgAbort, cfg.Crash == CrashDuringDecision, net)
+		return Aborted
+	}
+	announce(n, MsgPreCommit, cfg.Crash == CrashDuringDecision, net)
+	if cfg.Crash == CrashDuringDecision {
+		return Committed
+	}
+	timeout := time.After(cfg.Timeout)
+	for acks := 0; acks < n; acks++ {
+		select {
+		case <-net.coordinator:
+		case <-timeout:
+			// A participant crashed; the others commit all the same.
+			acks = n
+		}
+	}
+	if cfg.Crash != CrashBeforeCommit {
+		announce(n, MsgCommit, false, net)
+	}
+	return Committed
+}
+
+// participant3PC runs participant i until stop, answering the termination
+// protocol of the other participants even after it decided, and returns its
+// final state. It calls decided once it knows the outcome.
+func participant3PC(i int, vote Vote, timeout time.Duration, net *network, decided func(), stop <-chan struct{}) State {
+	state := StateWorking
+	var reports map[int]State // while running the termination protocol
+	var once sync.Once
+	decide := func(s State) {
+		state = s
+		reports = nil
+		once.Do(decided)
+	}
+	final := func() bool { return state == StateCommitted || state == StateAborted }
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case m := <-net.participants[i]:
+			switch m.typ {
+			case MsgStateRequest:
+				net.send(m.from, message{typ: MsgStateReport, from: i, state: state})
+			case MsgStateReport:
+				if reports != nil {
+					reports[m.from] = m.state
+				}
+			case MsgPrepare:
+				switch vote {
+				case VoteYes:
+					state = StatePrepared
+					net.send(coordinator, message{typ: MsgYes, from: i})
+					timer.Reset(timeout)
+				case VoteNo:
+					net.send(coordinator, message{typ: MsgNo, from: i})
+					decide(StateAborted)
+				case VoteNone:
+					<-stop
+					return state
+				}
+			case MsgPreCommit:
+				if state == StatePrepared {
+					state = StatePreCommitted
+					net.send(coordinator, message{typ: MsgPreCommitAck, from: i})
+					timer.Reset(timeout)
+				}
+			case MsgCommit, MsgAbort:
+				if !final() {
+					net.send(coordinator, message{typ: MsgAck, from: i})
+					if m.typ == MsgCommit {
+						decide(StateCommitted)
+					} else {
+						decide(StateAborted)
+					}
+				}
+			}
+		case <-timer.C:
+			switch {
+			case final():
+			case state == StateWorking:
+				// Not asked to prepare: nothing is promised, so abort.
+				decide(StateAborted)
+			case reports == nil:
+				// The coordinator is gone: ask the others what they know.
+				reports = map[int]State{i: state}
+				for j := range net.participants {
+					if j != i {
+						net.send(j, message{typ: MsgStateRequest, from: i})
+					}
+				}
+				timer.Reset(timeout)
+			default:
+				decide(terminate(reports))
+			}
+		case <-stop:
+			return state
+		}
+	}
+}
+
+// terminate decides the outcome of the termination protocol from the states
+// the participants reported. A pre-commit means every participant voted yes,
+// so committing is safe once any participant got that far; before that, the
+// coordinator can't have committed anyone, so aborting is.
+func terminate(reports map[int]State) State {
+	outcome := StateAborted
+	for _, s := range reports {
+		switch s {
+		case StateCommitted, StatePreCommitted:
+			outcome = StateCommitted
+		case StateAborted, StateWorking:
+			return StateAborted
+		}
+	}
+	return outcome
+}
+
+// Comparison contrasts two-phase and three-phase commit under one crash of
+// the coordinator.
+type Comparison struct {
+	Crash      Crash
+	TwoPhase   Result
+	ThreePhase Result
+}
+
+// CompareUnderFaults runs the transaction of cfg with both protocols, once
+// for every crash point of Crashes, and returns the results in that order.
+func CompareUnderFaults(cfg Config) ([]Comparison, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	out := make([]Comparison, len(Crashes))
+	var wg sync.WaitGroup
+	for k, crash := range Crashes {
+		c := cfg
+		c.Crash = crash
+		out[k].Crash = crash
+		wg.Add(2)
+		go func(k int) {
+			defer wg.Done()
+			out[k].TwoPhase = run(c, coordinator2PC, participant2PC)
+		}(k)
+		go func(k int) {
+			defer wg.Done()
+			out[k].ThreePhase = run(c, coordinator3PC, participant3PC)
+		}(k)
+	}
+	wg.Wait()
+	return out, nil
+}
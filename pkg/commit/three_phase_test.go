@@ -0,0 +1,85 @@
+package commit
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestThreePhase(t *testing.T) {
+	yes := []Vote{VoteYes, VoteYes, VoteYes}
+	tests := []struct {
+		name     string
+		votes    []Vote
+		crash    Crash
+		decision Outcome
+		states   []State
+	}{
+		{"all yes", yes, CrashNone, Committed, []State{C, C, C}},
+		{"one no", []Vote{VoteYes, VoteNo, VoteYes}, CrashNone, Aborted, []State{A, A, A}},
+		{"no answer", []Vote{VoteYes, VoteNone, VoteYes}, CrashNone, Aborted, []State{A, W, A}},
+		{"crash before prepare", yes, CrashBeforePrepare, Undecided, []State{A, A, A}},
+		// Where two-phase commit blocks, the participants terminate on their
+		// own: nobody pre-committed, so they abort...
+		{"crash before decision", yes, CrashBeforeDecision, Undecided, []State{A, A, A}},
+		{"crash before abort", []Vote{VoteYes, VoteNo, VoteYes}, CrashBeforeDecision, Undecided, []State{A, A, A}},
+		// ...and once anyone pre-committed, they commit.
+		{"crash during pre-commit", yes, CrashDuringDecision, Committed, []State{C, C, C}},
+		{"crash before commit", yes, CrashBeforeCommit, Committed, []State{C, C, C}},
+		{"crash with a silent participant", []Vote{VoteYes, VoteNone, VoteYes}, CrashBeforeDecision, Undecided, []State{A, W, A}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			res, err := ThreePhase(Config{Votes: tc.votes, Timeout: timeout, Crash: tc.crash})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Decision != tc.decision {
+				t.Errorf("decision %v, want %v", res.Decision, tc.decision)
+			}
+			if !reflect.DeepEqual(res.States, tc.states) {
+				t.Errorf("states %v, want %v", res.States, tc.states)
+			}
+			if len(res.Blocked) != 0 {
+				t.Errorf("participants %v blocked", res.Blocked)
+			}
+		})
+	}
+}
+
+func TestThreePhase_Messages(t *testing.T) {
+	res, err := ThreePhase(Config{Votes: []Vote{VoteYes, VoteYes, VoteYes, VoteYes}, Timeout: timeout})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[MessageType]int{MsgPrepare: 4, MsgYes: 4, MsgPreCommit: 4, MsgPreCommitAck: 4, MsgCommit: 4, MsgAck: 4}
+	if !reflect.DeepEqual(res.Messages, want) {
+		t.Errorf("messages %v, want %v", res.Messages, want)
+	}
+}
+
+func TestCompareUnderFaults(t *testing.T) {
+	comparisons, err := CompareUnderFaults(Config{Votes: []Vote{VoteYes, VoteYes, VoteYes, VoteYes}, Timeout: timeout})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comparisons) != len(Crashes) {
+		t.Fatalf("got %d comparisons, want %d", len(comparisons), len(Crashes))
+	}
+	blocking := map[Crash]bool{CrashBeforeDecision: true, CrashDuringDecision: true, CrashBeforeCommit: true}
+	for _, c := range comparisons {
+		if !c.TwoPhase.Consistent() || !c.ThreePhase.Consistent() {
+			t.Errorf("%v: inconsistent outcome: 2PC %v, 3PC %v", c.Crash, c.TwoPhase.States, c.ThreePhase.States)
+		}
+		if got := len(c.TwoPhase.Blocked) > 0; got != blocking[c.Crash] {
+			t.Errorf("%v: 2PC blocked %v", c.Crash, c.TwoPhase.Blocked)
+		}
+		if len(c.ThreePhase.Blocked) > 0 {
+			t.Errorf("%v: 3PC blocked %v", c.Crash, c.ThreePhase.Blocked)
+		}
+	}
+	if _, err := CompareUnderFaults(Config{Timeout: timeout}); err == nil {
+		t.Error("expected an error without participants")
+	}
+}
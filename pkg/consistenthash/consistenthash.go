@@ -0,0 +1,207 @@
+// Package consistenthash maps keys to nodes with a consistent hash ring:
+// nodes and keys hash onto a circle, and a key belongs to the first node
+// clockwise of it. Adding or removing a node only moves the keys of the arc
+// it gains or loses, about 1/n of them. Every node is placed at many
+// virtual points, which evens out the arcs.
+//
+// References:
+//
+// Karger et al., Consistent Hashing and Random Trees, STOC 1997.
+package consistenthash
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Hash hashes a string onto the ring.
+type Hash func(s string) uint64
+
+// DefaultHash is FNV-1a followed by the SplitMix64 finalizer, which spreads
+// the similar names of virtual nodes evenly.
+func DefaultHash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return mix(h.Sum64())
+}
+
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+type point struct {
+	hash uint64
+	node string
+}
+
+// Ring is a consistent hash ring. It is safe for concurrent use.
+type Ring struct {
+	VirtualNodes int // points per node
+
+	hash   Hash
+	mu     sync.RWMutex
+	points []point // sorted by hash
+	nodes  map[string]bool
+}
+
+// New returns an empty ring placing every node at vnodes points, hashed with
+// hash, or DefaultHash if nil.
+func New(vnodes int, hash Hash) *Ring {
+	if vnodes < 1 {
+		vnodes = 1
+	}
+	if hash == nil {
+		hash = DefaultHash
+	}
+	return &Ring{VirtualNodes: vnodes, hash: hash, nodes: make(map[string]bool)}
+}
+
+// Add adds nodes to the ring. Nodes already on it are left alone.
+func (r *Ring) Add(nodes ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, node := range nodes {
+		if r.nodes[node] {
+			continue
+		}
+		r.nodes[node] = true
+		for i := 0; i < r.VirtualNodes; i++ {
+			r.points = append(r.points, point{hash: r.hash(node + "#" + strconv.Itoa(i)), node: node})
+		}
+	}
+	// Ties, however unlikely, are broken by node so the ring doesn't depend
+	// on the order nodes were added in.
+	sort.Slice(r.points, func(i, j int) bool {
+		a, b := r.points[i], r.points[j]
+		return a.hash < b.hash || a.hash == b.hash && a.node < b.node
+	})
+}
+
+// Remove removes nodes from the ring.
+func (r *Ring) Remove(nodes ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	gone := make(map[string]bool)
+	for _, node := range nodes {
+		if r.nodes[node] {
+			gone[node] = true
+			delete(r.nodes, node)
+		}
+	}
+	kept := r.points[:0]
+	for _, p := range r.points {
+		if !gone[p.node] {
+			kept = append(kept, p)
+		}
+	}
+	r.points = kept
+}
+
+// Get returns the node key belongs to, or false if the ring is empty.
+func (r *Ring) Get(key string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.points) == 0 {
+		return "", false
+	}
+	h := r.hash(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.points[i].node, true
+}
+
+// Nodes returns the nodes of the ring in sorted order.
+func (r *Ring) Nodes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]string, 0, len(r.nodes))
+	for node := range r.nodes {
+		out = append(out, node)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Clone returns a copy of the ring, to compare placements before and after a
+// membership change.
+func (r *Ring) Clone() *Ring {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c := New(r.VirtualNodes, r.hash)
+	c.points = append([]point(nil), r.points...)
+	for node := range r.nodes {
+		c.nodes[node] = true
+	}
+	return c
+}
+
+// Locator maps keys to nodes.
+type Locator interface {
+	Get(key string) (string, bool)
+}
+
+// MoveStats counts the keys that changed nodes between two placements.
+type MoveStats struct {
+	Keys  int
+	Moved int
+}
+
+// Fraction returns the fraction of the keys that moved.
+func (m MoveStats) Fraction() float64 {
+	if m.Keys == 0 {
+		return 0
+	}
+	return float64(m.Moved) / float64(m.Keys)
+}
+
+func (m MoveStats) String() string {
+	return fmt.Sprintf("%d of %d keys moved (%.1f%%)", m.Moved, m.Keys, 100*m.Fraction())
+}
+
+// Moves counts the keys before and after place on different nodes.
+func Moves(before, after Locator, keys []string) MoveStats {
+	m := MoveStats{Keys: len(keys)}
+	for _, k := range keys {
+		a, _ := before.Get(k)
+		b, _ := after.Get(k)
+		if a != b {
+			m.Moved++
+		}
+	}
+	return m
+}
+
+// Load counts the keys l places on every node.
+func Load(l Locator, keys []string) map[string]int {
+	out := make(map[string]int)
+	for _, k := range keys {
+		if node, ok := l.Get(k); ok {
+			out[node]++
+		}
+	}
+	return out
+}
+
+// Imbalance returns the load of the busiest node over the mean load of
+// nodes, which should all appear in load: 1 is a perfect balance.
+func Imbalance(load map[string]int) float64 {
+	total, busiest := 0, 0
+	for _, n := range load {
+		total += n
+		busiest = max(busiest, n)
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(busiest) * float64(len(load)) / float64(total)
+}
@@ -0,0 +1,136 @@
+package consistenthash
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func keys(n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = fmt.Sprint("key-", i)
+	}
+	return out
+}
+
+func nodes(n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = fmt.Sprint("node-", i)
+	}
+	return out
+}
+
+func TestRing_Get(t *testing.T) {
+	// With an identity hash, the layout can be checked by hand: node "b"
+	// sits at 20, node "a" at 10 and 30.
+	points := map[string]uint64{"a#0": 10, "a#1": 30, "b#0": 20, "b#1": 20}
+	r := New(2, func(s string) uint64 {
+		if h, ok := points[s]; ok {
+			return h
+		}
+		var h uint64
+		fmt.Sscan(s, &h)
+		return h
+	})
+	if _, ok := r.Get("5"); ok {
+		t.Fatal("an empty ring placed a key")
+	}
+	r.Add("a", "b")
+	for key, want := range map[string]string{"5": "a", "10": "a", "11": "b", "25": "a", "31": "a"} {
+		if got, _ := r.Get(key); got != want {
+			t.Errorf("key %s on %s, want %s", key, got, want)
+		}
+	}
+	r.Remove("a")
+	if got, _ := r.Get("5"); got != "b" {
+		t.Errorf("key 5 on %s after removing a, want b", got)
+	}
+	if !reflect.DeepEqual(r.Nodes(), []string{"b"}) {
+		t.Errorf("nodes %v, want [b]", r.Nodes())
+	}
+}
+
+func TestRing_Balance(t *testing.T) {
+	ks := keys(100000)
+	tests := []struct {
+		vnodes int
+		max    float64
+	}{
+		{1, 5},
+		{100, 1.3},
+		{1000, 1.1},
+	}
+	for _, tc := range tests {
+		r := New(tc.vnodes, nil)
+		r.Add(nodes(10)...)
+		if got := Imbalance(Load(r, ks)); got > tc.max {
+			t.Errorf("%d virtual nodes: imbalance %.2f, want at most %.2f", tc.vnodes, got, tc.max)
+		}
+	}
+}
+
+func TestRing_Movement(t *testing.T) {
+	ks := keys(50000)
+	r := New(200, nil)
+	r.Add(nodes(10)...)
+	before := r.Clone()
+
+	// A new node takes about 1/11 of the keys, all of them from the others.
+	r.Add("node-10")
+	m := Moves(before, r, ks)
+	if math.Abs(m.Fraction()-1.0/11) > 0.02 {
+		t.Errorf("adding a node: %v, want about 9.1%%", m)
+	}
+	for _, k := range ks {
+		a, _ := before.Get(k)
+		b, _ := r.Get(k)
+		if a != b && b != "node-10" {
+			t.Fatalf("key %s moved from %s to %s, not to the new node", k, a, b)
+		}
+	}
+
+	// Removing it moves exactly those keys back.
+	after := r.Clone()
+	r.Remove("node-10")
+	if back := Moves(after, r, ks); back != m {
+		t.Errorf("removing the node: %v, want %v", back, m)
+	}
+	if Moves(before, r, ks).Moved != 0 {
+		t.Error("the ring differs from the original after adding and removing a node")
+	}
+}
+
+func TestRing_AddIsIdempotent(t *testing.T) {
+	r := New(10, nil)
+	r.Add("a", "b")
+	r.Add("a")
+	if len(r.points) != 20 {
+		t.Errorf("%d points, want 20", len(r.points))
+	}
+	r.Remove("c")
+	if len(r.Nodes()) != 2 {
+		t.Errorf("nodes %v", r.Nodes())
+	}
+}
+
+func TestImbalance(t *testing.T) {
+	if got := Imbalance(map[string]int{"a": 30, "b": 10}); got != 1.5 {
+		t.Errorf("imbalance %v, want 1.5", got)
+	}
+	if got := Imbalance(nil); got != 0 {
+		t.Errorf("imbalance of nothing %v, want 0", got)
+	}
+}
+
+func BenchmarkRing_Get(b *testing.B) {
+	r := New(200, nil)
+	r.Add(nodes(100)...)
+	ks := keys(1024)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.Get(ks[i%len(ks)])
+	}
+}
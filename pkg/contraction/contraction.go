@@ -0,0 +1,354 @@
+// Package contraction implements contraction hierarchies, which answer
+// shortest-path queries on a large static graph in a fraction of the time
+// of Dijkstra's algorithm after preprocessing it once.
+//
+// Preprocessing contracts the nodes one by one, in order of importance:
+// contracting a node removes it from the graph, adding a shortcut between
+// two of its neighbors wherever the path through it was the only shortest
+// one, as a witness search finds. Every node is then ranked by when it was
+// contracted. A shortest path between any two nodes turns, with shortcuts,
+// into a path climbing to a highest node and then descending, so a query
+// runs Dijkstra forward from the source and backward from the target, both
+// only upward, and meets in the middle. On road networks both searches
+// settle a few hundred nodes at most.
+//
+// References:
+//
+// Geisberger, Sanders, Schultes and Delling, Contraction Hierarchies:
+// Faster and Simpler Hierarchical Routing in Road Networks, Workshop on
+// Experimental Algorithms, 2008.
+//
+// Geisberger, Sanders, Schultes and Vetter, Exact Routing in Large Road
+// Networks Using Contraction Hierarchies, Transportation Science 46(3),
+// 2012.
+package contraction
+
+import (
+	"container/heap"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/sanderblue/algorithms/pkg/graph"
+)
+
+// Config tunes preprocessing.
+type Config struct {
+	// WitnessLimit bounds the nodes a witness search settles. Lower limits
+	// preprocess faster but add shortcuts a longer search would have found
+	// unnecessary, slowing queries. Default 500.
+	WitnessLimit int
+}
+
+func (c Config) withDefaults() Config {
+	if c.WitnessLimit == 0 {
+		c.WitnessLimit = 500
+	}
+	return c
+}
+
+// Hierarchy is a graph preprocessed for shortest-path queries.
+type Hierarchy[W graph.Weight] struct {
+	rank      []int        // position of every node in the contraction order
+	up        [][]arc[W]   // edges to higher ranked nodes, including shortcuts
+	down      [][]arc[W]   // edges from higher ranked nodes, reversed
+	middle    map[pair]int // node every shortcut skips
+	shortcuts int
+	pool      sync.Pool // of query searches
+}
+
+// arc is an edge of the hierarchy to or, reversed, from node to.
+type arc[W graph.Weight] struct {
+	to     int
+	weight W
+}
+
+type pair struct{ from, to int }
+
+// New preprocesses g, whose weights must not be negative, into a
+// hierarchy. Edges of an undirected graph are followed both ways; of
+// parallel edges only the lightest counts.
+//
+// Nodes are contracted in increasing order of priority: the shortcuts a
+// contraction adds minus the edges it removes, plus the neighbors already
+// contracted, which spreads contractions across the graph. Priorities are
+// updated lazily: the node of least priority is recomputed before being
+// contracted, and the neighbors of every contracted node after.
+func New[N any, W graph.Weight](g *graph.Graph[N, W], cfg Config) (*Hierarchy[W], error) {
+	cfg = cfg.withDefaults()
+	n := g.Order()
+	c := &contractor[W]{
+		out:     make([]map[int]W, n),
+		in:      make([]map[int]W, n),
+		deleted: make([]int, n),
+		done:    make([]bool, n),
+		witness: newWitness[W](n),
+		limit:   cfg.WitnessLimit,
+		h: &Hierarchy[W]{
+			rank:   make([]int, n),
+			up:     make([][]arc[W], n),
+			down:   make([][]arc[W], n),
+			middle: make(map[pair]int),
+		},
+	}
+	for v := range n {
+		c.out[v], c.in[v] = make(map[int]W), make(map[int]W)
+	}
+	for _, e := range g.Edges() {
+		if e.Weight < 0 {
+			return nil, fmt.Errorf("contraction: edge %v: %w", e, graph.ErrNegativeWeight)
+		}
+		c.link(e.From, e.To, e.Weight)
+		if !g.Directed() {
+			c.link(e.To, e.From, e.Weight)
+		}
+	}
+
+	priority := make([]int, n)
+	q := &priorityQueue{}
+	for v := range n {
+		priority[v] = c.priority(v)
+		heap.Push(q, nodePriority{v, priority[v]})
+	}
+	for next := 0; q.Len() > 0; {
+		np := heap.Pop(q).(nodePriority)
+		v := np.node
+		if c.done[v] || np.priority != priority[v] {
+			continue // stale
+		}
+		// Priorities of other nodes may have grown stale since; contract v
+		// only if it still comes first.
+		if p := c.priority(v); p != priority[v] {
+			priority[v] = p
+			if q.Len() > 0 && p > (*q)[0].priority {
+				heap.Push(q, nodePriority{v, p})
+				continue
+			}
+		}
+		c.h.rank[v] = next
+		next++
+		neighbors := c.contract(v)
+		for _, u := range neighbors {
+			c.deleted[u]++
+			priority[u] = c.priority(u)
+			heap.Push(q, nodePriority{u, priority[u]})
+		}
+	}
+	return c.h, nil
+}
+
+// Rank returns the position of v in the contraction order, from 0.
+func (h *Hierarchy[W]) Rank(v int) int { return h.rank[v] }
+
+// Shortcuts returns the number of shortcuts preprocessing added.
+func (h *Hierarchy[W]) Shortcuts() int { return h.shortcuts }
+
+// contractor holds the graph of the nodes left to contract.
+type contractor[W graph.Weight] struct {
+	out, in []map[int]W // lightest edge to and from every neighbor left
+	deleted []int       // neighbors contracted, of every node
+	done    []bool      // contracted
+	witness *witness[W]
+	limit   int
+	h       *Hierarchy[W]
+}
+
+// link adds an edge from u to v unless one at least as light exists, and
+// reports whether it did.
+func (c *contractor[W]) link(u, v int, w W) bool {
+	if u == v {
+		return false
+	}
+	if old, ok := c.out[u][v]; ok && old <= w {
+		return false
+	}
+	c.out[u][v], c.in[v][u] = w, w
+	return true
+}
+
+// shortcuts calls add for every shortcut contracting v needs: an edge from
+// u to x, weighing the path u, v, x, for every in-neighbor u and
+// out-neighbor x of v that have no other path as short, with witness
+// searches from every u avoiding v.
+func (c *contractor[W]) shortcuts(v int, add func(u, x int, w W)) {
+	outs := slices.Sorted(maps.Keys(c.out[v]))
+	for _, u := range slices.Sorted(maps.Keys(c.in[v])) {
+		var bound W
+		for _, x := range outs {
+			bound = max(bound, c.in[v][u]+c.out[v][x])
+		}
+		c.witness.search(c, u, v, outs, bound, c.limit)
+		for _, x := range outs {
+			if x == u {
+				continue
+			}
+			w := c.in[v][u] + c.out[v][x]
+			if d, ok := c.witness.distance(x); ok && d <= w {
+				continue
+			}
+			add(u, x, w)
+		}
+	}
+}
+
+// priority returns how early v should be contracted: the lower the
+// earlier.
+func (c *contractor[W]) priority(v int) int {
+	added := 0
+	c.shortcuts(v, func(u, x int, w W) { added++ })
+	return added - len(c.in[v]) - len(c.out[v]) + c.deleted[v]
+}
+
+// contract removes v from the graph, adding the shortcuts it needs and
+// moving its edges into the hierarchy, and returns its neighbors.
+func (c *contractor[W]) contract(v int) []int {
+	c.shortcuts(v, func(u, x int, w W) {
+		if c.link(u, x, w) {
+			c.h.middle[pair{u, x}] = v
+			c.h.shortcuts++
+		}
+	})
+	c.done[v] = true
+	var neighbors []int
+	for _, x := range slices.Sorted(maps.Keys(c.out[v])) {
+		c.h.up[v] = append(c.h.up[v], arc[W]{x, c.out[v][x]})
+		delete(c.in[x], v)
+		neighbors = append(neighbors, x)
+	}
+	for _, u := range slices.Sorted(maps.Keys(c.in[v])) {
+		c.h.down[v] = append(c.h.down[v], arc[W]{u, c.in[v][u]})
+		delete(c.out[u], v)
+		if _, ok := c.out[v][u]; !ok {
+			neighbors = append(neighbors, u)
+		}
+	}
+	c.out[v], c.in[v] = nil, nil
+	return neighbors
+}
+
+type nodePriority struct {
+	node     int
+	priority int
+}
+
+// priorityQueue is a heap of node priorities, ties by node ID; nodes pushed
+// again leave stale entries behind, skipped when popped.
+type priorityQueue []nodePriority
+
+func (q priorityQueue) Len() int { return len(q) }
+
+func (q priorityQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority < q[j].priority
+	}
+	return q[i].node < q[j].node
+}
+
+func (q priorityQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *priorityQueue) Push(x any)   { *q = append(*q, x.(nodePriority)) }
+
+func (q *priorityQueue) Pop() any {
+	old := *q
+	x := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return x
+}
+
+// witness searches for paths as short as a shortcut, avoiding the node
+// being contracted, with Dijkstra's algorithm over the nodes left.
+type witness[W graph.Weight] struct {
+	dist    []W
+	reached []bool
+	target  []bool
+	touched []int
+	queue   distHeap[W]
+}
+
+func newWitness[W graph.Weight](n int) *witness[W] {
+	return &witness[W]{dist: make([]W, n), reached: make([]bool, n), target: make([]bool, n)}
+}
+
+// search finds distances from source to targets, avoiding avoid. It stops
+// once it has settled every target, passed bound or settled limit nodes.
+func (s *witness[W]) search(c *contractor[W], source, avoid int, targets []int, bound W, limit int) {
+	for _, v := range s.touched {
+		s.reached[v] = false
+	}
+	s.touched = s.touched[:0]
+	s.queue = s.queue[:0]
+	left := 0
+	for _, x := range targets {
+		if x != source {
+			s.target[x] = true
+			left++
+		}
+	}
+	s.reach(source, 0)
+	for settled := 0; s.queue.Len() > 0 && settled < limit && left > 0; {
+		nd := heap.Pop(&s.queue).(nodeDist[W])
+		u := nd.node
+		if nd.dist != s.dist[u] {
+			continue // stale
+		}
+		if nd.dist > bound {
+			break
+		}
+		settled++
+		if s.target[u] {
+			s.target[u] = false
+			left--
+		}
+		for v, w := range c.out[u] {
+			if v != avoid && (!s.reached[v] || nd.dist+w < s.dist[v]) {
+				s.reach(v, nd.dist+w)
+			}
+		}
+	}
+	for _, x := range targets {
+		s.target[x] = false
+	}
+}
+
+func (s *witness[W]) reach(v int, d W) {
+	if !s.reached[v] {
+		s.reached[v] = true
+		s.touched = append(s.touched, v)
+	}
+	s.dist[v] = d
+	heap.Push(&s.queue, nodeDist[W]{v, d})
+}
+
+// distance returns the length of the shortest path to v the last search
+// found, if any.
+func (s *witness[W]) distance(v int) (W, bool) {
+	return s.dist[v], s.reached[v]
+}
+
+type nodeDist[W graph.Weight] struct {
+	node int
+	dist W
+}
+
+// distHeap is a heap of tentative distances, ties by node ID; nodes pushed
+// again with a shorter distance leave stale entries behind.
+type distHeap[W graph.Weight] []nodeDist[W]
+
+func (h distHeap[W]) Len() int { return len(h) }
+
+func (h distHeap[W]) Less(i, j int) bool {
+	if h[i].dist != h[j].dist {
+		return h[i].dist < h[j].dist
+	}
+	return h[i].node < h[j].node
+}
+
+func (h distHeap[W]) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *distHeap[W]) Push(x any)   { *h = append(*h, x.(nodeDist[W])) }
+
+func (h *distHeap[W]) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,95 @@
+package contraction
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/sanderblue/algorithms/pkg/graph"
+)
+
+// randomGraph returns a graph of n nodes and m random edges weighing
+// between lo and hi.
+func randomGraph(rng *rand.Rand, directed bool, n, m, lo, hi int) *graph.Graph[struct{}, int] {
+	g := graph.NewUndirected[struct{}, int]()
+	if directed {
+		g = graph.NewDirected[struct{}, int]()
+	}
+	g.AddNodes(make([]struct{}, n)...)
+	for range m {
+		g.AddEdge(rng.Intn(n), rng.Intn(n), lo+rng.Intn(hi-lo+1))
+	}
+	return g
+}
+
+// grid returns a rows by cols grid of random weights between 1 and 9, like
+// a small road network.
+func grid(rng *rand.Rand, rows, cols int) *graph.Graph[struct{}, int] {
+	g := graph.NewUndirected[struct{}, int]()
+	g.AddNodes(make([]struct{}, rows*cols)...)
+	for r := range rows {
+		for c := range cols {
+			if c+1 < cols {
+				g.AddEdge(r*cols+c, r*cols+c+1, 1+rng.Intn(9))
+			}
+			if r+1 < rows {
+				g.AddEdge(r*cols+c, (r+1)*cols+c, 1+rng.Intn(9))
+			}
+		}
+	}
+	return g
+}
+
+func TestNew_RanksArePermutation(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	g := randomGraph(rng, true, 200, 800, 1, 20)
+	h, err := New(g, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make([]bool, g.Order())
+	for v := range g.Order() {
+		r := h.Rank(v)
+		if r < 0 || r >= g.Order() || seen[r] {
+			t.Fatalf("node %d of rank %d", v, r)
+		}
+		seen[r] = true
+		for _, a := range h.up[v] {
+			if h.Rank(a.to) <= r {
+				t.Errorf("upward edge %d->%d to rank %d from %d", v, a.to, h.Rank(a.to), r)
+			}
+		}
+		for _, a := range h.down[v] {
+			if h.Rank(a.to) <= r {
+				t.Errorf("downward edge %d->%d from rank %d to %d", a.to, v, h.Rank(a.to), r)
+			}
+		}
+	}
+}
+
+func TestNew_WitnessLimit(t *testing.T) {
+	// Shorter witness searches miss paths, adding shortcuts that are not
+	// needed but never wrong.
+	g := grid(rand.New(rand.NewSource(2)), 20, 20)
+	full, err := New(g, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	short, err := New(g, Config{WitnessLimit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if short.Shortcuts() < full.Shortcuts() {
+		t.Errorf("%d shortcuts with a witness limit of 1, %d without", short.Shortcuts(), full.Shortcuts())
+	}
+	checkDistances(t, g, short, 0, 57, 399)
+}
+
+func TestNew_NegativeWeight(t *testing.T) {
+	g := graph.NewDirected[struct{}, int]()
+	g.AddNodes(struct{}{}, struct{}{})
+	g.AddEdge(0, 1, -1)
+	if _, err := New(g, Config{}); !errors.Is(err, graph.ErrNegativeWeight) {
+		t.Errorf("error %v, want %v", err, graph.ErrNegativeWeight)
+	}
+}
@@ -0,0 +1,178 @@
+package contraction
+
+import (
+	"container/heap"
+	"fmt"
+	"slices"
+
+	"github.com/sanderblue/algorithms/pkg/graph"
+)
+
+// Query returns a shortest path from source to target, as the nodes along
+// it, and its length, or false if target is unreachable. The path is that
+// of the original graph, shortcuts unpacked. Queries may run concurrently.
+func (h *Hierarchy[W]) Query(source, target int) ([]int, W, bool) {
+	h.mustExist(source)
+	h.mustExist(target)
+	searches := h.searches()
+	defer h.release(searches)
+	forward, backward := &searches[0], &searches[1]
+	forward.start(source)
+	backward.start(target)
+	var best W
+	meet := -1
+	// Settle a node in the direction of the smaller key each time, until
+	// neither can settle a node closer than the best path found. Nodes
+	// reached suboptimally, as a path down from a higher node shows, are
+	// stalled: their edges are not relaxed.
+	for {
+		s, arcs, against, other := forward, h.up, h.down, backward
+		if !forward.active(best, meet >= 0) || backward.active(best, meet >= 0) && backward.queue[0].dist < forward.queue[0].dist {
+			s, arcs, against, other = backward, h.down, h.up, forward
+		}
+		if !s.active(best, meet >= 0) {
+			break
+		}
+		u, du := s.settle()
+		if other.state[u] != unreached && (meet < 0 || du+other.dist[u] < best) {
+			best, meet = du+other.dist[u], u
+		}
+		if s.stalled(against[u], du) {
+			continue
+		}
+		for _, a := range arcs[u] {
+			s.relax(u, a.to, du+a.weight)
+		}
+	}
+	if meet < 0 {
+		return nil, best, false
+	}
+
+	var nodes []int // on the path through the hierarchy, shortcuts packed
+	for v := meet; v != source; v = forward.parent[v] {
+		nodes = append(nodes, v)
+	}
+	nodes = append(nodes, source)
+	slices.Reverse(nodes)
+	for v := meet; v != target; {
+		v = backward.parent[v]
+		nodes = append(nodes, v)
+	}
+	path := []int{source}
+	for i := 1; i < len(nodes); i++ {
+		path = h.unpack(path, nodes[i-1], nodes[i])
+	}
+	return path, best, true
+}
+
+// Distance returns the length of a shortest path from source to target, or
+// false if target is unreachable.
+func (h *Hierarchy[W]) Distance(source, target int) (W, bool) {
+	_, d, ok := h.Query(source, target)
+	return d, ok
+}
+
+// unpack appends to path the nodes after u of the path the edge from u to
+// v stands for, expanding shortcuts recursively.
+func (h *Hierarchy[W]) unpack(path []int, u, v int) []int {
+	m, ok := h.middle[pair{u, v}]
+	if !ok {
+		return append(path, v)
+	}
+	return h.unpack(h.unpack(path, u, m), m, v)
+}
+
+func (h *Hierarchy[W]) mustExist(v int) {
+	if v < 0 || v >= len(h.rank) {
+		panic(fmt.Sprintf("contraction: node %d out of range [0, %d)", v, len(h.rank)))
+	}
+}
+
+// search is one direction of a query. Its slices span the whole graph but
+// are reset only where touched, as a query reaches few nodes.
+type search[W graph.Weight] struct {
+	dist    []W
+	parent  []int
+	state   []uint8 // unreached, reached or settled
+	touched []int
+	queue   distHeap[W]
+}
+
+const (
+	unreached uint8 = iota
+	reached
+	settled
+)
+
+// searches returns a forward and a backward search, from the pool if it
+// holds any, to return with release.
+func (h *Hierarchy[W]) searches() *[2]search[W] {
+	if s, ok := h.pool.Get().(*[2]search[W]); ok {
+		return s
+	}
+	n := len(h.rank)
+	s := new([2]search[W])
+	for i := range s {
+		s[i] = search[W]{dist: make([]W, n), parent: make([]int, n), state: make([]uint8, n)}
+	}
+	return s
+}
+
+func (h *Hierarchy[W]) release(s *[2]search[W]) {
+	for i := range s {
+		for _, v := range s[i].touched {
+			s[i].state[v] = unreached
+		}
+		s[i].touched = s[i].touched[:0]
+		s[i].queue = s[i].queue[:0]
+	}
+	h.pool.Put(s)
+}
+
+func (s *search[W]) start(source int) {
+	s.dist[source], s.parent[source], s.state[source] = 0, -1, reached
+	s.touched = append(s.touched, source)
+	heap.Push(&s.queue, nodeDist[W]{source, 0})
+}
+
+// active reports whether s can still settle a node closer than best, the
+// length of the path found if found.
+func (s *search[W]) active(best W, found bool) bool {
+	for s.queue.Len() > 0 && s.state[s.queue[0].node] == settled {
+		heap.Pop(&s.queue)
+	}
+	return s.queue.Len() > 0 && (!found || s.queue[0].dist < best)
+}
+
+func (s *search[W]) settle() (int, W) {
+	nd := heap.Pop(&s.queue).(nodeDist[W])
+	s.state[nd.node] = settled
+	return nd.node, nd.dist
+}
+
+// stalled reports whether a shorter path to a node at distance d than d
+// comes down along one of arcs, from a higher node.
+func (s *search[W]) stalled(arcs []arc[W], d W) bool {
+	for _, a := range arcs {
+		if s.state[a.to] != unreached && s.dist[a.to]+a.weight < d {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *search[W]) relax(u, v int, d W) {
+	switch s.state[v] {
+	case settled:
+		return
+	case reached:
+		if s.dist[v] <= d {
+			return
+		}
+	default:
+		s.state[v] = reached
+		s.touched = append(s.touched, v)
+	}
+	s.dist[v], s.parent[v] = d, u
+	heap.Push(&s.queue, nodeDist[W]{v, d})
+}
@@ -0,0 +1,164 @@
+package contraction
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/sanderblue/algorithms/pkg/graph"
+)
+
+// checkDistances checks the queries from every source against Dijkstra,
+// and that every path returned is one of g of the length returned.
+func checkDistances[W graph.Weight](t *testing.T, g *graph.Graph[struct{}, W], h *Hierarchy[W], sources ...int) {
+	t.Helper()
+	lightest := g.Matrix()
+	for _, s := range sources {
+		p, err := graph.Dijkstra(g, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for v := range g.Order() {
+			path, d, ok := h.Query(s, v)
+			if ok != p.Reached(v) {
+				t.Fatalf("%d to %d: reachable %v, want %v", s, v, ok, p.Reached(v))
+			}
+			if !ok {
+				continue
+			}
+			if math.Abs(float64(d)-float64(p.Dist[v])) > 1e-9 {
+				t.Fatalf("%d to %d: distance %v, want %v", s, v, d, p.Dist[v])
+			}
+			if path[0] != s || path[len(path)-1] != v {
+				t.Fatalf("%d to %d: path %v", s, v, path)
+			}
+			var length W
+			for i := 1; i < len(path); i++ {
+				w, ok := lightest.At(path[i-1], path[i])
+				if !ok {
+					t.Fatalf("%d to %d: path %v uses a missing edge %d->%d", s, v, path, path[i-1], path[i])
+				}
+				length += w
+			}
+			if math.Abs(float64(length)-float64(d)) > 1e-9 {
+				t.Fatalf("%d to %d: path %v of length %v, want %v", s, v, path, length, d)
+			}
+		}
+	}
+}
+
+func TestQuery_MatchesDijkstra(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tests := []struct {
+		name string
+		g    *graph.Graph[struct{}, int]
+	}{
+		{"sparse directed", randomGraph(rng, true, 100, 250, 1, 20)},
+		{"dense directed", randomGraph(rng, true, 40, 600, 1, 20)},
+		{"undirected", randomGraph(rng, false, 100, 200, 1, 20)},
+		{"zero weights", randomGraph(rng, true, 60, 200, 0, 3)},
+		{"grid", grid(rng, 15, 15)},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			h, err := New(tc.g, Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkDistances(t, tc.g, h, 0, 1, 7, tc.g.Order()-1)
+		})
+	}
+}
+
+func TestQuery_FloatWeights(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	g := graph.NewDirected[struct{}, float64]()
+	g.AddNodes(make([]struct{}, 60)...)
+	for range 240 {
+		g.AddEdge(rng.Intn(60), rng.Intn(60), rng.Float64())
+	}
+	h, err := New(g, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDistances(t, g, h, 0, 30, 59)
+}
+
+func TestQuery_Trivial(t *testing.T) {
+	g := graph.NewDirected[struct{}, int]()
+	g.AddNodes(make([]struct{}, 3)...)
+	g.AddEdge(0, 1, 4)
+	h, err := New(g, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path, d, ok := h.Query(2, 2); !ok || d != 0 || len(path) != 1 {
+		t.Errorf("2 to itself: %v, %v, %v", path, d, ok)
+	}
+	if _, _, ok := h.Query(1, 0); ok {
+		t.Error("1 to 0: reachable against the edge")
+	}
+	if d, ok := h.Distance(0, 1); !ok || d != 4 {
+		t.Errorf("0 to 1: %v, %v", d, ok)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("node 3: no panic")
+		}
+	}()
+	h.Query(0, 3)
+}
+
+func TestQuery_Concurrent(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	g := grid(rng, 20, 20)
+	h, err := New(g, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for w := range 4 {
+		wg.Add(1)
+		go func(source int) {
+			defer wg.Done()
+			p, err := graph.Dijkstra(g, source)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			for v := range g.Order() {
+				if d, ok := h.Distance(source, v); !ok || d != p.Dist[v] {
+					t.Errorf("%d to %d: %v, %v, want %v", source, v, d, ok, p.Dist[v])
+				}
+			}
+		}(w * 97)
+	}
+	wg.Wait()
+}
+
+func BenchmarkQuery(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	g := grid(rng, 100, 100)
+	h, err := New(g, Config{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	pairs := make([][2]int, 100)
+	for i := range pairs {
+		pairs[i] = [2]int{rng.Intn(g.Order()), rng.Intn(g.Order())}
+	}
+	b.Run("hierarchy", func(b *testing.B) {
+		for i := range b.N {
+			p := pairs[i%len(pairs)]
+			h.Query(p[0], p[1])
+		}
+	})
+	b.Run("dijkstra", func(b *testing.B) {
+		for i := range b.N {
+			p := pairs[i%len(pairs)]
+			graph.DijkstraTo(g, p[0], p[1])
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package crdt
+
+import (
+	"encoding/json"
+	"maps"
+)
+
+// GCounter is a grow-only counter: every replica counts its own increments,
+// and the value is their sum.
+type GCounter struct {
+	Replica int
+
+	counts map[int]uint64
+}
+
+func NewGCounter(replica int) *GCounter {
+	return &GCounter{Replica: replica, counts: make(map[int]uint64)}
+}
+
+// Inc adds delta to the counter.
+func (c *GCounter) Inc(delta uint64) {
+	c.counts[c.Replica] += delta
+}
+
+// Value returns the sum of the increments of all replicas seen so far.
+func (c *GCounter) Value() uint64 {
+	var sum uint64
+	for _, n := range c.counts {
+		sum += n
+	}
+	return sum
+}
+
+// Merge takes the larger count of every replica.
+func (c *GCounter) Merge(o *GCounter) {
+	for r, n := range o.counts {
+		c.counts[r] = max(c.counts[r], n)
+	}
+}
+
+// Equal reports whether c and o have the same state.
+func (c *GCounter) Equal(o *GCounter) bool {
+	return maps.Equal(c.counts, o.counts)
+}
+
+// Clone returns a copy of c.
+func (c *GCounter) Clone() *GCounter {
+	return &GCounter{Replica: c.Replica, counts: maps.Clone(c.counts)}
+}
+
+// MarshalJSON encodes the state: the count of every replica.
+func (c *GCounter) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.counts)
+}
+
+// UnmarshalJSON replaces the state of c. The replica ID is kept.
+func (c *GCounter) UnmarshalJSON(b []byte) error {
+	counts := make(map[int]uint64)
+	if err := json.Unmarshal(b, &counts); err != nil {
+		return err
+	}
+	c.counts = counts
+	return nil
+}
+
+// PNCounter is a counter that can also be decremented: a pair of grow-only
+// counters of the increments and the decrements.
+type PNCounter struct {
+	Replica int
+
+	p, n *GCounter
+}
+
+func NewPNCounter(replica int) *PNCounter {
+	return &PNCounter{Replica: replica, p: NewGCounter(replica), n: NewGCounter(replica)}
+}
+
+// Inc adds delta, which may be negative, to the counter.
+func (c *PNCounter) Inc(delta int64) {
+	if delta >= 0 {
+		c.p.Inc(uint64(delta))
+	} else {
+		c.n.Inc(uint64(-delta))
+	}
+}
+
+// Value returns the increments minus the decrements of all replicas seen so
+// far.
+func (c *PNCounter) Value() int64 {
+	return int64(c.p.Value() - c.n.Value())
+}
+
+func (c *PNCounter) Merge(o *PNCounter) {
+	c.p.Merge(o.p)
+	c.n.Merge(o.n)
+}
+
+func (c *PNCounter) Equal(o *PNCounter) bool {
+	return c.p.Equal(o.p) && c.n.Equal(o.n)
+}
+
+func (c *PNCounter) Clone() *PNCounter {
+	return &PNCounter{Replica: c.Replica, p: c.p.Clone(), n: c.n.Clone()}
+}
+
+type pnState struct {
+	P *GCounter `json:"p"`
+	N *GCounter `json:"n"`
+}
+
+func (c *PNCounter) MarshalJSON() ([]byte, error) {
+	return json.Marshal(pnState{P: c.p, N: c.n})
+}
+
+// UnmarshalJSON replaces the state of c. The replica ID is kept.
+func (c *PNCounter) UnmarshalJSON(b []byte) error {
+	s := pnState{P: NewGCounter(c.Replica), N: NewGCounter(c.Replica)}
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	c.p, c.n = s.P, s.N
+	return nil
+}
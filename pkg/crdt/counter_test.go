@@ -0,0 +1,67 @@
+package crdt
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestGCounter(t *testing.T) {
+	a, b := NewGCounter(0), NewGCounter(1)
+	a.Inc(3)
+	b.Inc(4)
+	a.Merge(b)
+	a.Merge(b)
+	if a.Value() != 7 {
+		t.Errorf("value %d, want 7", a.Value())
+	}
+	b.Inc(1)
+	b.Merge(a)
+	if b.Value() != 8 || a.Equal(b) {
+		t.Errorf("value %d, want 8 and a differing replica", b.Value())
+	}
+
+	decoded := NewGCounter(5)
+	roundTrip(t, b, decoded)
+	if !decoded.Equal(b) || decoded.Replica != 5 {
+		t.Errorf("decoded %+v, want the state of %+v on replica 5", decoded, b)
+	}
+}
+
+func TestPNCounter(t *testing.T) {
+	a, b := NewPNCounter(0), NewPNCounter(1)
+	a.Inc(5)
+	b.Inc(-8)
+	a.Merge(b)
+	if a.Value() != -3 {
+		t.Errorf("value %d, want -3", a.Value())
+	}
+	decoded := NewPNCounter(2)
+	roundTrip(t, a, decoded)
+	if !decoded.Equal(a) || decoded.Value() != -3 {
+		t.Errorf("decoded value %d, want -3", decoded.Value())
+	}
+}
+
+func TestPNCounter_Convergence(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for seed := 0; seed < 10; seed++ {
+		replicas := make([]*PNCounter, 5)
+		for i := range replicas {
+			replicas[i] = NewPNCounter(i)
+		}
+		want := int64(0)
+		for op := 0; op < 100; op++ {
+			r := replicas[rng.Intn(len(replicas))]
+			if rng.Intn(4) == 0 {
+				r.Merge(replicas[rng.Intn(len(replicas))])
+				continue
+			}
+			delta := int64(rng.Intn(21) - 10)
+			r.Inc(delta)
+			want += delta
+		}
+		if got := checkConvergence(t, replicas, rng).Value(); got != want {
+			t.Fatalf("converged to %d, want %d", got, want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Package crdt implements state-based conflict-free replicated data types:
+// counters, a set and a register whose replicas accept updates independently
+// and converge to the same state once they have merged each other's states,
+// in any order and any number of times.
+//
+// Replicas are identified by an int, unique among the replicas of one
+// object. States serialize to JSON for transfer; Replicate shows them
+// converging over gossip.
+//
+// References:
+//
+// Shapiro, Preguiça, Baquero and Zawirski, A Comprehensive Study of
+// Convergent and Commutative Replicated Data Types, INRIA RR-7506, 2011.
+package crdt
+
+import (
+	"fmt"
+
+	"github.com/sanderblue/algorithms/pkg/gossip"
+)
+
+// CRDT is a replica of type T, typically a pointer, that merges the states
+// of other replicas into its own.
+type CRDT[T any] interface {
+	Merge(other T)
+	Equal(other T) bool
+}
+
+// Replicate gossips the states of replicas between one another, as set up
+// by cfg, until all of them are equal, and returns the number of rounds it
+// took. cfg.N is set to the number of replicas.
+func Replicate[T CRDT[T]](replicas []T, cfg gossip.Config) (int, error) {
+	cfg.N = len(replicas)
+	rounds, ok, err := gossip.Exchange(cfg,
+		func(from, to int) { replicas[to].Merge(replicas[from]) },
+		func() bool {
+			for _, r := range replicas[1:] {
+				if !r.Equal(replicas[0]) {
+					return false
+				}
+			}
+			return true
+		})
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return rounds, fmt.Errorf("replicas still differ after %d rounds", rounds)
+	}
+	return rounds, nil
+}
@@ -0,0 +1,98 @@
+package crdt
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/sanderblue/algorithms/pkg/gossip"
+)
+
+type cloner[T any] interface {
+	CRDT[T]
+	Clone() T
+}
+
+// checkConvergence merges states in many random orders, each state any
+// number of times, and checks every order ends in the same state. It
+// returns that state.
+func checkConvergence[T cloner[T]](t *testing.T, states []T, rng *rand.Rand) T {
+	t.Helper()
+	want := states[0].Clone()
+	for _, s := range states[1:] {
+		want.Merge(s)
+	}
+	for trial := 0; trial < 20; trial++ {
+		order := rng.Perm(len(states))
+		got := states[order[0]].Clone()
+		for _, i := range order {
+			for k := rng.Intn(3); k >= 0; k-- {
+				got.Merge(states[i])
+			}
+			// Merging into another replica first doesn't change the result:
+			// merge is associative.
+			if j := rng.Intn(len(states)); rng.Intn(2) == 0 {
+				via := states[j].Clone()
+				via.Merge(states[i])
+				got.Merge(via)
+			}
+		}
+		if !got.Equal(want) {
+			t.Fatalf("order %v converged to a different state", order)
+		}
+	}
+	return want
+}
+
+// roundTrip encodes src and decodes it into dst.
+func roundTrip(t *testing.T, src, dst any) {
+	t.Helper()
+	b, err := json.Marshal(src)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if err := json.Unmarshal(b, dst); err != nil {
+		t.Fatalf("unmarshal %s: %v", b, err)
+	}
+}
+
+func TestReplicate(t *testing.T) {
+	const n = 30
+	counters := make([]*PNCounter, n)
+	sets := make([]*ORSet[int], n)
+	want := int64(0)
+	for i := range counters {
+		counters[i] = NewPNCounter(i)
+		counters[i].Inc(int64(i))
+		counters[i].Inc(-1)
+		want += int64(i) - 1
+		sets[i] = NewORSet[int](i)
+		sets[i].Add(i % 7)
+	}
+	cfg := gossip.Config{Mode: gossip.PushPull, Loss: 0.2, Seed: 5}
+	if _, err := Replicate(counters, cfg); err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range counters {
+		if c.Value() != want {
+			t.Fatalf("replica %d: value %d, want %d", i, c.Value(), want)
+		}
+	}
+	rounds, err := Replicate(sets, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rounds == 0 || sets[n-1].Len() != 7 {
+		t.Errorf("%d rounds, %d elements, want 7", rounds, sets[n-1].Len())
+	}
+
+	cfg.Rounds = 1
+	if _, err := Replicate(counters[:2], cfg); err != nil {
+		t.Errorf("equal replicas: %v", err)
+	}
+	fresh := []*GCounter{NewGCounter(0), NewGCounter(1)}
+	fresh[0].Inc(1)
+	if _, err := Replicate(fresh, gossip.Config{Mode: gossip.Pull, Rounds: 1, Loss: 0.99}); err == nil {
+		t.Error("expected an error when replicas don't converge")
+	}
+}
@@ -0,0 +1,71 @@
+package crdt
+
+import (
+	"encoding/json"
+
+	"github.com/sanderblue/algorithms/pkg/logicalclock"
+)
+
+// LWWRegister is a last-writer-wins register: every write is stamped with a
+// Lamport timestamp, and merging keeps the value of the latest one, ties
+// broken by replica. A write always wins over the writes its replica has
+// seen.
+type LWWRegister[T comparable] struct {
+	Replica int
+
+	value T
+	stamp logicalclock.Timestamp // Time 0: never written
+}
+
+func NewLWWRegister[T comparable](replica int) *LWWRegister[T] {
+	return &LWWRegister[T]{Replica: replica}
+}
+
+// Set writes v.
+func (r *LWWRegister[T]) Set(v T) {
+	r.value = v
+	r.stamp = logicalclock.Timestamp{Time: r.stamp.Time + 1, Process: r.Replica}
+}
+
+// Get returns the value and whether it was ever written.
+func (r *LWWRegister[T]) Get() (T, bool) {
+	return r.value, r.stamp.Time > 0
+}
+
+// Stamp returns the timestamp of the write that holds the value.
+func (r *LWWRegister[T]) Stamp() logicalclock.Timestamp { return r.stamp }
+
+func (r *LWWRegister[T]) Merge(o *LWWRegister[T]) {
+	if r.stamp.Less(o.stamp) {
+		r.value, r.stamp = o.value, o.stamp
+	}
+}
+
+func (r *LWWRegister[T]) Equal(o *LWWRegister[T]) bool {
+	return r.stamp == o.stamp && r.value == o.value
+}
+
+func (r *LWWRegister[T]) Clone() *LWWRegister[T] {
+	c := *r
+	return &c
+}
+
+type lwwState[T any] struct {
+	Value   T      `json:"value"`
+	Time    uint64 `json:"time"`
+	Replica int    `json:"replica"`
+}
+
+func (r *LWWRegister[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(lwwState[T]{Value: r.value, Time: r.stamp.Time, Replica: r.stamp.Process})
+}
+
+// UnmarshalJSON replaces the state of r. The replica ID is kept.
+func (r *LWWRegister[T]) UnmarshalJSON(b []byte) error {
+	var s lwwState[T]
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	r.value, r.stamp = s.Value, logicalclock.Timestamp{Time: s.Time, Process: s.Replica}
+	return nil
+}
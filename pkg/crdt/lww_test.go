@@ -0,0 +1,61 @@
+package crdt
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestLWWRegister(t *testing.T) {
+	a, b := NewLWWRegister[string](0), NewLWWRegister[string](1)
+	if _, ok := a.Get(); ok {
+		t.Fatal("a fresh register holds a value")
+	}
+	a.Set("x")
+	b.Set("y")
+	// Concurrent writes of the same Lamport time: the higher replica wins.
+	a.Merge(b)
+	if v, _ := a.Get(); v != "y" {
+		t.Errorf("got %q, want y", v)
+	}
+	// A write after seeing another wins over it.
+	a.Set("z")
+	b.Merge(a)
+	if v, _ := b.Get(); v != "z" || b.Stamp() != a.Stamp() {
+		t.Errorf("got %q at %v, want z at %v", v, b.Stamp(), a.Stamp())
+	}
+
+	decoded := NewLWWRegister[string](3)
+	roundTrip(t, b, decoded)
+	if !decoded.Equal(b) {
+		t.Errorf("decoded %+v, want %+v", decoded, b)
+	}
+}
+
+func TestLWWRegister_Convergence(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for seed := 0; seed < 10; seed++ {
+		replicas := make([]*LWWRegister[int], 4)
+		for i := range replicas {
+			replicas[i] = NewLWWRegister[int](i)
+		}
+		for op := 0; op < 50; op++ {
+			r := replicas[rng.Intn(len(replicas))]
+			if rng.Intn(3) == 0 {
+				r.Merge(replicas[rng.Intn(len(replicas))])
+			} else {
+				r.Set(rng.Intn(1000))
+			}
+		}
+		// The converged value is the one of the latest write.
+		latest := replicas[0]
+		for _, r := range replicas[1:] {
+			if latest.Stamp().Less(r.Stamp()) {
+				latest = r
+			}
+		}
+		got, _ := checkConvergence(t, replicas, rng).Get()
+		if want, _ := latest.Get(); got != want {
+			t.Fatalf("converged to %d, want %d", got, want)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package crdt
+
+import "encoding/json"
+
+// Tag identifies one addition of an element to an ORSet.
+type Tag struct {
+	Replica int    `json:"replica"`
+	Seq     uint64 `json:"seq"`
+}
+
+// ORSet is an observed-remove set: every addition of an element gets a
+// unique tag, and a removal removes the tags its replica has observed. An
+// addition concurrent with a removal therefore wins. Removed tags are kept
+// as tombstones, so the state grows with every addition.
+type ORSet[T comparable] struct {
+	Replica int
+
+	seq     uint64
+	adds    map[T]map[Tag]bool
+	removed map[Tag]bool
+}
+
+func NewORSet[T comparable](replica int) *ORSet[T] {
+	return &ORSet[T]{Replica: replica, adds: make(map[T]map[Tag]bool), removed: make(map[Tag]bool)}
+}
+
+// Add adds e to the set.
+func (s *ORSet[T]) Add(e T) {
+	s.seq++
+	if s.adds[e] == nil {
+		s.adds[e] = make(map[Tag]bool)
+	}
+	s.adds[e][Tag{Replica: s.Replica, Seq: s.seq}] = true
+}
+
+// Remove removes e, as far as the replica has observed its additions.
+func (s *ORSet[T]) Remove(e T) {
+	for tag := range s.adds[e] {
+		s.removed[tag] = true
+	}
+	delete(s.adds, e)
+}
+
+// Contains reports whether e is in the set.
+func (s *ORSet[T]) Contains(e T) bool {
+	return len(s.adds[e]) > 0
+}
+
+// Elements returns the elements of the set, in no particular order.
+func (s *ORSet[T]) Elements() []T {
+	out := make([]T, 0, len(s.adds))
+	for e := range s.adds {
+		out = append(out, e)
+	}
+	return out
+}
+
+// Len returns the number of elements in the set.
+func (s *ORSet[T]) Len() int { return len(s.adds) }
+
+func (s *ORSet[T]) Merge(o *ORSet[T]) {
+	for tag := range o.removed {
+		s.removed[tag] = true
+		if tag.Replica == s.Replica {
+			s.seq = max(s.seq, tag.Seq)
+		}
+	}
+	for e, tags := range o.adds {
+		for tag := range tags {
+			if s.adds[e] == nil {
+				s.adds[e] = make(map[Tag]bool)
+			}
+			s.adds[e][tag] = true
+		}
+	}
+	for e, tags := range s.adds {
+		for tag := range tags {
+			if s.removed[tag] {
+				delete(tags, tag)
+			}
+			if tag.Replica == s.Replica {
+				s.seq = max(s.seq, tag.Seq)
+			}
+		}
+		if len(tags) == 0 {
+			delete(s.adds, e)
+		}
+	}
+}
+
+func (s *ORSet[T]) Equal(o *ORSet[T]) bool {
+	if len(s.adds) != len(o.adds) || len(s.removed) != len(o.removed) {
+		return false
+	}
+	for tag := range s.removed {
+		if !o.removed[tag] {
+			return false
+		}
+	}
+	for e, tags := range s.adds {
+		other := o.adds[e]
+		if len(tags) != len(other) {
+			return false
+		}
+		for tag := range tags {
+			if !other[tag] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (s *ORSet[T]) Clone() *ORSet[T] {
+	c := NewORSet[T](s.Replica)
+	c.seq = s.seq
+	c.Merge(s)
+	return c
+}
+
+type orsetElement[T any] struct {
+	Value T     `json:"value"`
+	Tags  []Tag `json:"tags"`
+}
+
+type orsetState[T any] struct {
+	Elements []orsetElement[T] `json:"elements"`
+	Removed  []Tag             `json:"removed"`
+}
+
+func (s *ORSet[T]) MarshalJSON() ([]byte, error) {
+	st := orsetState[T]{Removed: []Tag{}, Elements: []orsetElement[T]{}}
+	for e, tags := range s.adds {
+		el := orsetElement[T]{Value: e}
+		for tag := range tags {
+			el.Tags = append(el.Tags, tag)
+		}
+		st.Elements = append(st.Elements, el)
+	}
+	for tag := range s.removed {
+		st.Removed = append(st.Removed, tag)
+	}
+	return json.Marshal(st)
+}
+
+// UnmarshalJSON replaces the state of s. The replica ID is kept.
+func (s *ORSet[T]) UnmarshalJSON(b []byte) error {
+	var st orsetState[T]
+	if err := json.Unmarshal(b, &st); err != nil {
+		return err
+	}
+	fresh := NewORSet[T](s.Replica)
+	for _, tag := range st.Removed {
+		fresh.removed[tag] = true
+		if tag.Replica == s.Replica {
+			fresh.seq = max(fresh.seq, tag.Seq)
+		}
+	}
+	for _, el := range st.Elements {
+		for _, tag := range el.Tags {
+			if fresh.adds[el.Value] == nil {
+				fresh.adds[el.Value] = make(map[Tag]bool)
+			}
+			fresh.adds[el.Value][tag] = true
+		}
+	}
+	// Merging drops the removed tags and restores seq from the live ones.
+	fresh.Merge(NewORSet[T](s.Replica))
+	*s = *fresh
+	return nil
+}
@@ -0,0 +1,112 @@
+package crdt
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestORSet(t *testing.T) {
+	a, b := NewORSet[string](0), NewORSet[string](1)
+	a.Add("x")
+	b.Merge(a)
+	// Concurrently, b removes x while a adds it again: the addition wins.
+	b.Remove("x")
+	a.Add("x")
+	a.Add("y")
+	a.Merge(b)
+	b.Merge(a)
+	if !a.Contains("x") || !b.Contains("x") || !a.Equal(b) {
+		t.Errorf("x lost to a concurrent removal: %v, %v", a.Elements(), b.Elements())
+	}
+	// A removal of every observed addition sticks.
+	b.Remove("x")
+	a.Merge(b)
+	got := a.Elements()
+	if a.Contains("x") || len(got) != 1 || got[0] != "y" {
+		t.Errorf("elements %v, want [y]", got)
+	}
+
+	decoded := NewORSet[string](0)
+	roundTrip(t, a, decoded)
+	if !decoded.Equal(a) {
+		t.Fatalf("decoded %v, want %v", decoded.Elements(), a.Elements())
+	}
+	// The decoded replica doesn't reuse the tags it had already handed out.
+	decoded.Add("z")
+	a.Merge(decoded)
+	if a.Len() != 2 || !a.Contains("z") {
+		t.Errorf("elements %v, want [y z]", a.Elements())
+	}
+}
+
+func TestORSet_RoundTripAfterRemove(t *testing.T) {
+	// The only tag replica 0 handed out is a tombstone: its next addition
+	// must still get a fresh tag.
+	a := NewORSet[string](0)
+	a.Add("x")
+	a.Remove("x")
+	decoded := NewORSet[string](0)
+	roundTrip(t, a, decoded)
+	decoded.Add("y")
+	a.Merge(decoded)
+	if !a.Contains("y") || !decoded.Contains("y") {
+		t.Errorf("y lost to the tombstone of x: %v, %v", a.Elements(), decoded.Elements())
+	}
+}
+
+func TestORSet_Convergence(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	for seed := 0; seed < 10; seed++ {
+		replicas := make([]*ORSet[int], 4)
+		for i := range replicas {
+			replicas[i] = NewORSet[int](i)
+		}
+		for op := 0; op < 100; op++ {
+			r := replicas[rng.Intn(len(replicas))]
+			switch rng.Intn(3) {
+			case 0:
+				r.Merge(replicas[rng.Intn(len(replicas))])
+			case 1:
+				r.Add(rng.Intn(10))
+			case 2:
+				r.Remove(rng.Intn(10))
+			}
+		}
+		got := checkConvergence(t, replicas, rng).Elements()
+		sort.Ints(got)
+		// An element is in the converged set exactly when some addition of
+		// it wasn't observed by any removal.
+		var want []int
+		for e := 0; e < 10; e++ {
+			for _, r := range replicas {
+				if survives(r, e, replicas) {
+					want = append(want, e)
+					break
+				}
+			}
+		}
+		if len(got) != len(want) {
+			t.Fatalf("converged to %v, want %v", got, want)
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("converged to %v, want %v", got, want)
+			}
+		}
+	}
+}
+
+// survives reports whether a tag of e in r was removed by no replica.
+func survives(r *ORSet[int], e int, replicas []*ORSet[int]) bool {
+	for tag := range r.adds[e] {
+		removed := false
+		for _, o := range replicas {
+			removed = removed || o.removed[tag]
+		}
+		if !removed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,260 @@
+// Package disjointset implements the disjoint-set forest, or union-find, of
+// Galler and Fischer: a partition of elements into sets that can be merged
+// and asked which set an element belongs to.
+//
+//   - Set links trees by rank or by size and compresses paths in Find, so
+//     that any sequence of m operations on n elements takes O(m α(n)),
+//     where α is the inverse Ackermann function, below 5 in practice.
+//   - Sets made by NewRollback instead record every change, to be undone
+//     with Rollback back to a Checkpoint. They do not compress paths, which
+//     rollback could not undo cheaply, and take O(log n) per operation.
+//   - Connectivity answers connectivity queries over a sequence of edge
+//     insertions and deletions, offline, on a rollback Set.
+//
+// References:
+//
+// Galler and Fischer, An Improved Equivalence Algorithm, Communications of
+// the ACM 7(5), 1964.
+//
+// Tarjan and van Leeuwen, Worst-Case Analysis of Set Union Algorithms,
+// Journal of the ACM 31(2), 1984.
+//
+// Cormen, Leiserson, Rivest and Stein, Introduction to Algorithms, 3rd
+// edition, 2009, chapter 21.
+package disjointset
+
+import "fmt"
+
+// Linking decides which of two roots becomes the parent of the other in a
+// union.
+type Linking int
+
+const (
+	// BySize makes the root of the larger set the parent.
+	BySize Linking = iota
+	// ByRank makes the root of the taller tree, by an upper bound on its
+	// height, the parent.
+	ByRank
+)
+
+func (l Linking) String() string {
+	switch l {
+	case BySize:
+		return "by size"
+	case ByRank:
+		return "by rank"
+	default:
+		return fmt.Sprintf("Linking(%d)", int(l))
+	}
+}
+
+// Set is a partition of comparable elements into disjoint sets. Elements
+// join as singletons the first time they are added or used in a union.
+type Set[T comparable] struct {
+	linking Linking
+	index   map[T]int // of every element into the slices below
+	items   []T
+	parent  []int
+	size    []int // of the set of every root
+	rank    []int // of every root, with ByRank
+	count   int   // number of sets
+
+	rollback bool
+	history  []change
+}
+
+// change records an addition or a union, for rollback.
+type change struct {
+	added  bool // a singleton was added; otherwise child was linked below parent
+	child  int
+	parent int
+	bumped bool // the rank of parent grew
+}
+
+// New returns an empty set linking roots as given and compressing paths.
+func New[T comparable](linking Linking) *Set[T] {
+	return &Set[T]{linking: linking, index: make(map[T]int)}
+}
+
+// NewRollback returns an empty set linking roots as given whose changes
+// can be undone with Rollback. It never compresses paths.
+func NewRollback[T comparable](linking Linking) *Set[T] {
+	s := New[T](linking)
+	s.rollback = true
+	return s
+}
+
+// Len returns the number of elements.
+func (s *Set[T]) Len() int { return len(s.items) }
+
+// Count returns the number of sets.
+func (s *Set[T]) Count() int { return s.count }
+
+// Add adds x as a singleton unless it is already an element, and reports
+// whether it was added.
+func (s *Set[T]) Add(x T) bool {
+	if _, ok := s.index[x]; ok {
+		return false
+	}
+	s.add(x)
+	return true
+}
+
+func (s *Set[T]) add(x T) int {
+	i := len(s.items)
+	s.index[x] = i
+	s.items = append(s.items, x)
+	s.parent = append(s.parent, i)
+	s.size = append(s.size, 1)
+	s.rank = append(s.rank, 0)
+	s.count++
+	if s.rollback {
+		s.history = append(s.history, change{added: true, child: i})
+	}
+	return i
+}
+
+// Contains reports whether x is an element.
+func (s *Set[T]) Contains(x T) bool {
+	_, ok := s.index[x]
+	return ok
+}
+
+// Find returns the representative of the set of x, the same for all its
+// elements until the set is merged, and false if x is not an element.
+func (s *Set[T]) Find(x T) (T, bool) {
+	i, ok := s.index[x]
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return s.items[s.find(i)], true
+}
+
+func (s *Set[T]) find(i int) int {
+	root := i
+	for s.parent[root] != root {
+		root = s.parent[root]
+	}
+	if !s.rollback {
+		for s.parent[i] != root {
+			s.parent[i], i = root, s.parent[i]
+		}
+	}
+	return root
+}
+
+// Union merges the sets of x and y, adding either if it is not an element,
+// and reports whether they were apart.
+func (s *Set[T]) Union(x, y T) bool {
+	i, ok := s.index[x]
+	if !ok {
+		i = s.add(x)
+	}
+	j, ok := s.index[y]
+	if !ok {
+		j = s.add(y)
+	}
+	i, j = s.find(i), s.find(j)
+	if i == j {
+		return false
+	}
+	switch s.linking {
+	case ByRank:
+		if s.rank[i] < s.rank[j] {
+			i, j = j, i
+		}
+	default:
+		if s.size[i] < s.size[j] {
+			i, j = j, i
+		}
+	}
+	bumped := s.linking == ByRank && s.rank[i] == s.rank[j]
+	s.parent[j] = i
+	s.size[i] += s.size[j]
+	if bumped {
+		s.rank[i]++
+	}
+	s.count--
+	if s.rollback {
+		s.history = append(s.history, change{child: j, parent: i, bumped: bumped})
+	}
+	return true
+}
+
+// Connected reports whether x and y are elements of the same set.
+func (s *Set[T]) Connected(x, y T) bool {
+	i, ok := s.index[x]
+	j, ok2 := s.index[y]
+	return ok && ok2 && s.find(i) == s.find(j)
+}
+
+// SizeOf returns the number of elements in the set of x, 0 if x is not an
+// element.
+func (s *Set[T]) SizeOf(x T) int {
+	i, ok := s.index[x]
+	if !ok {
+		return 0
+	}
+	return s.size[s.find(i)]
+}
+
+// Sets returns the elements of every set, in the order they were added,
+// with the sets in order of their first element.
+func (s *Set[T]) Sets() [][]T {
+	var sets [][]T
+	of := make(map[int]int, s.count) // index in sets of every root
+	for i, x := range s.items {
+		r := s.find(i)
+		k, ok := of[r]
+		if !ok {
+			k = len(sets)
+			of[r] = k
+			sets = append(sets, nil)
+		}
+		sets[k] = append(sets[k], x)
+	}
+	return sets
+}
+
+// Checkpoint returns a point of the history of a rollback set to return to
+// with Rollback. It panics on a set made by New.
+func (s *Set[T]) Checkpoint() int {
+	s.mustRollback()
+	return len(s.history)
+}
+
+// Rollback undoes the additions and unions made since checkpoint, most
+// recent first. It panics on a set made by New, and on a checkpoint beyond
+// the history.
+func (s *Set[T]) Rollback(checkpoint int) {
+	s.mustRollback()
+	if checkpoint < 0 || checkpoint > len(s.history) {
+		panic(fmt.Sprintf("disjointset: checkpoint %d outside history of %d changes", checkpoint, len(s.history)))
+	}
+	for len(s.history) > checkpoint {
+		c := s.history[len(s.history)-1]
+		s.history = s.history[:len(s.history)-1]
+		if c.added {
+			delete(s.index, s.items[c.child])
+			s.items = s.items[:c.child]
+			s.parent = s.parent[:c.child]
+			s.size = s.size[:c.child]
+			s.rank = s.rank[:c.child]
+			s.count--
+			continue
+		}
+		s.parent[c.child] = c.child
+		s.size[c.parent] -= s.size[c.child]
+		if c.bumped {
+			s.rank[c.parent]--
+		}
+		s.count++
+	}
+}
+
+func (s *Set[T]) mustRollback() {
+	if !s.rollback {
+		panic("disjointset: rollback of a set made without NewRollback")
+	}
+}
@@ -0,0 +1,212 @@
+package disjointset
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// naive is a partition kept as the label of every element, relabeling a
+// whole set on every union.
+type naive map[int]int
+
+func (p naive) union(x, y int) bool {
+	for _, v := range []int{x, y} {
+		if _, ok := p[v]; !ok {
+			p[v] = v
+		}
+	}
+	a, b := p[x], p[y]
+	if a == b {
+		return false
+	}
+	for v, l := range p {
+		if l == b {
+			p[v] = a
+		}
+	}
+	return true
+}
+
+func (p naive) count() int {
+	labels := make(map[int]bool)
+	for _, l := range p {
+		labels[l] = true
+	}
+	return len(labels)
+}
+
+func (p naive) size(x int) int {
+	l, ok := p[x]
+	n := 0
+	for _, m := range p {
+		if ok && m == l {
+			n++
+		}
+	}
+	return n
+}
+
+// checkSame checks that s holds the partition p.
+func checkSame(t *testing.T, s *Set[int], p naive) {
+	t.Helper()
+	if s.Len() != len(p) || s.Count() != p.count() {
+		t.Fatalf("%d elements in %d sets, want %d in %d", s.Len(), s.Count(), len(p), p.count())
+	}
+	for x := range p {
+		rx, ok := s.Find(x)
+		if !ok {
+			t.Fatalf("Find(%d): not an element", x)
+		}
+		if s.SizeOf(x) != p.size(x) {
+			t.Fatalf("SizeOf(%d) = %d, want %d", x, s.SizeOf(x), p.size(x))
+		}
+		for y := range p {
+			ry, _ := s.Find(y)
+			if want := p[x] == p[y]; s.Connected(x, y) != want || (rx == ry) != want {
+				t.Fatalf("%d and %d: connected %v, same representative %v, want %v", x, y, s.Connected(x, y), rx == ry, want)
+			}
+		}
+	}
+}
+
+func TestSet_MatchesNaive(t *testing.T) {
+	for _, linking := range []Linking{BySize, ByRank} {
+		for _, rollback := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%v rollback=%v", linking, rollback), func(t *testing.T) {
+				rng := rand.New(rand.NewSource(1))
+				s := New[int](linking)
+				if rollback {
+					s = NewRollback[int](linking)
+				}
+				p := naive{}
+				for range 300 {
+					x, y := rng.Intn(60), rng.Intn(60)
+					if got, want := s.Union(x, y), p.union(x, y); got != want {
+						t.Fatalf("Union(%d, %d) = %v, want %v", x, y, got, want)
+					}
+				}
+				checkSame(t, s, p)
+			})
+		}
+	}
+}
+
+func TestSet_Sets(t *testing.T) {
+	s := New[string](BySize)
+	s.Add("a")
+	s.Union("b", "c")
+	s.Union("d", "a")
+	if s.Add("a") {
+		t.Error(`Add("a") twice: added`)
+	}
+	s.Add("e")
+	s.Union("c", "e")
+	want := [][]string{{"a", "d"}, {"b", "c", "e"}}
+	if got := s.Sets(); !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("Sets() = %v, want %v", got, want)
+	}
+	if s.Contains("f") || s.Connected("a", "f") || s.SizeOf("f") != 0 {
+		t.Error(`"f" looks like an element`)
+	}
+	if _, ok := s.Find("f"); ok {
+		t.Error(`Find("f"): found`)
+	}
+}
+
+func TestSet_RankBoundsHeight(t *testing.T) {
+	// Without path compression, trees linked by rank or size are at most
+	// log2 n tall.
+	for _, linking := range []Linking{BySize, ByRank} {
+		s := NewRollback[int](linking)
+		const n = 1 << 10
+		for step := 1; step < n; step *= 2 {
+			for x := 0; x+step < n; x += 2 * step {
+				s.Union(x, x+step)
+			}
+		}
+		height := 0
+		for x := range n {
+			h := 0
+			for i := s.index[x]; s.parent[i] != i; i = s.parent[i] {
+				h++
+			}
+			height = max(height, h)
+		}
+		if height > 10 {
+			t.Errorf("%v: height %d over %d elements", linking, height, n)
+		}
+	}
+}
+
+func TestSet_Rollback(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	s := NewRollback[int](ByRank)
+	var unions [][2]int // every union made, to replay up to a checkpoint
+	var checkpoints []int
+	var replayed []int // unions made at every checkpoint
+	for range 500 {
+		switch r := rng.Intn(10); {
+		case r < 6:
+			x, y := rng.Intn(40), rng.Intn(40)
+			s.Union(x, y)
+			unions = append(unions, [2]int{x, y})
+		case r < 8:
+			checkpoints = append(checkpoints, s.Checkpoint())
+			replayed = append(replayed, len(unions))
+		case len(checkpoints) > 0:
+			k := rng.Intn(len(checkpoints))
+			s.Rollback(checkpoints[k])
+			unions = unions[:replayed[k]]
+			checkpoints, replayed = checkpoints[:k], replayed[:k]
+
+			p := naive{}
+			for _, u := range unions {
+				p.union(u[0], u[1])
+			}
+			checkSame(t, s, p)
+		}
+	}
+}
+
+func TestSet_RollbackPanics(t *testing.T) {
+	tests := []struct {
+		name string
+		f    func()
+	}{
+		{"checkpoint without rollback", func() { New[int](BySize).Checkpoint() }},
+		{"rollback without rollback", func() { New[int](BySize).Rollback(0) }},
+		{"checkpoint beyond history", func() { NewRollback[int](BySize).Rollback(1) }},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("no panic")
+				}
+			}()
+			tc.f()
+		})
+	}
+}
+
+func BenchmarkSet(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	const n = 1 << 16
+	pairs := make([][2]int, 4*n)
+	for i := range pairs {
+		pairs[i] = [2]int{rng.Intn(n), rng.Intn(n)}
+	}
+	for _, linking := range []Linking{BySize, ByRank} {
+		b.Run(linking.String(), func(b *testing.B) {
+			for range b.N {
+				s := New[int](linking)
+				for _, p := range pairs {
+					s.Union(p[0], p[1])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package disjointset
+
+import "fmt"
+
+// OpKind is the kind of an Op.
+type OpKind int
+
+const (
+	// Link inserts an edge between U and V.
+	Link OpKind = iota
+	// Cut deletes an edge between U and V inserted earlier.
+	Cut
+	// Query asks whether U and V are connected.
+	Query
+)
+
+func (k OpKind) String() string {
+	switch k {
+	case Link:
+		return "link"
+	case Cut:
+		return "cut"
+	case Query:
+		return "query"
+	default:
+		return fmt.Sprintf("OpKind(%d)", int(k))
+	}
+}
+
+// Op is an operation on an undirected graph whose edges come and go.
+type Op[T comparable] struct {
+	Kind OpKind
+	U, V T
+}
+
+// Connectivity answers the queries of ops, applied in order to a graph
+// without edges, and returns whether U and V were connected at every
+// Query, in order. Edges are unordered pairs and may be inserted several
+// times, each Cut deleting one copy. An element is connected to itself.
+//
+// It works offline, knowing every operation in advance: each copy of an
+// edge lives over an interval of the operations, which a segment tree over
+// them splits into O(log q) nodes. A depth-first walk of the tree unions
+// the edges of every node on the way down and rolls them back on the way
+// up, so every query, at a leaf, sees exactly the edges alive at its time.
+// That takes O(q log q log n) for q operations on n elements.
+func Connectivity[T comparable](ops []Op[T]) ([]bool, error) {
+	type pair struct{ u, v T }
+	q := len(ops)
+	tree := make([][]pair, 4*max(q, 1)) // edges alive over every node of the segment tree
+	var insert func(node, lo, hi, from, to int, e pair)
+	insert = func(node, lo, hi, from, to int, e pair) {
+		if to <= lo || hi <= from {
+			return
+		}
+		if from <= lo && hi <= to {
+			tree[node] = append(tree[node], e)
+			return
+		}
+		mid := (lo + hi) / 2
+		insert(2*node, lo, mid, from, to, e)
+		insert(2*node+1, mid, hi, from, to, e)
+	}
+
+	alive := make(map[pair][]int) // insertion times of the copies of every edge
+	for t, op := range ops {
+		e := pair{op.U, op.V}
+		if _, ok := alive[e]; !ok {
+			e = pair{op.V, op.U} // as inserted, if it was
+		}
+		switch op.Kind {
+		case Link:
+			alive[e] = append(alive[e], t)
+		case Cut:
+			times := alive[e]
+			if len(times) == 0 {
+				return nil, fmt.Errorf("disjointset: operation %d cuts edge %v-%v, not linked", t, op.U, op.V)
+			}
+			insert(1, 0, q, times[len(times)-1], t, e)
+			if len(times) == 1 {
+				delete(alive, e)
+			} else {
+				alive[e] = times[:len(times)-1]
+			}
+		case Query:
+		default:
+			return nil, fmt.Errorf("disjointset: operation %d of unknown kind %v", t, op.Kind)
+		}
+	}
+	for e, times := range alive {
+		for _, from := range times {
+			insert(1, 0, q, from, q, e)
+		}
+	}
+
+	answers := make([]bool, q)
+	s := NewRollback[T](ByRank)
+	var walk func(node, lo, hi int)
+	walk = func(node, lo, hi int) {
+		checkpoint := s.Checkpoint()
+		for _, e := range tree[node] {
+			s.Union(e.u, e.v)
+		}
+		if hi-lo == 1 {
+			if op := ops[lo]; op.Kind == Query {
+				answers[lo] = op.U == op.V || s.Connected(op.U, op.V)
+			}
+		} else {
+			mid := (lo + hi) / 2
+			walk(2*node, lo, mid)
+			walk(2*node+1, mid, hi)
+		}
+		s.Rollback(checkpoint)
+	}
+	if q > 0 {
+		walk(1, 0, q)
+	}
+
+	var results []bool
+	for t, op := range ops {
+		if op.Kind == Query {
+			results = append(results, answers[t])
+		}
+	}
+	return results, nil
+}
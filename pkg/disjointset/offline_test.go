@@ -0,0 +1,106 @@
+package disjointset
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// connected reports whether u and v are connected by the edges, searching
+// from u.
+func connected(edges map[[2]int]int, u, v int) bool {
+	seen := map[int]bool{u: true}
+	stack := []int{u}
+	for len(stack) > 0 {
+		x := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for e, copies := range edges {
+			if copies == 0 {
+				continue
+			}
+			for _, y := range []int{e[0], e[1]} {
+				if (e[0] == x || e[1] == x) && !seen[y] {
+					seen[y] = true
+					stack = append(stack, y)
+				}
+			}
+		}
+	}
+	return seen[v]
+}
+
+func TestConnectivity_Known(t *testing.T) {
+	ops := []Op[string]{
+		{Link, "a", "b"},
+		{Link, "b", "c"},
+		{Query, "a", "c"},
+		{Link, "c", "b"}, // a second copy
+		{Cut, "b", "c"},
+		{Query, "a", "c"},
+		{Cut, "c", "b"},
+		{Query, "a", "c"},
+		{Query, "d", "d"},
+		{Query, "a", "d"},
+	}
+	got, err := Connectivity(ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []bool{true, true, false, true, false}; !slices.Equal(got, want) {
+		t.Errorf("answers %v, want %v", got, want)
+	}
+}
+
+func TestConnectivity_MatchesSearch(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for range 20 {
+		const n = 12
+		edges := make(map[[2]int]int) // copies of every edge, by its ends in order
+		var ops []Op[int]
+		var want []bool
+		for range 200 {
+			u, v := rng.Intn(n), rng.Intn(n)
+			e := [2]int{min(u, v), max(u, v)}
+			switch r := rng.Intn(3); {
+			case r == 0:
+				edges[e]++
+				ops = append(ops, Op[int]{Link, u, v})
+			case r == 1 && edges[e] > 0:
+				edges[e]--
+				ops = append(ops, Op[int]{Cut, u, v})
+			default:
+				ops = append(ops, Op[int]{Query, u, v})
+				want = append(want, connected(edges, u, v))
+			}
+		}
+		got, err := Connectivity(ops)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("answers %v, want %v", got, want)
+		}
+	}
+}
+
+func TestConnectivity_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  []Op[int]
+	}{
+		{"cut never linked", []Op[int]{{Cut, 0, 1}}},
+		{"cut twice", []Op[int]{{Link, 0, 1}, {Cut, 1, 0}, {Cut, 0, 1}}},
+		{"unknown kind", []Op[int]{{OpKind(7), 0, 1}}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Connectivity(tc.ops); err == nil {
+				t.Error("no error")
+			}
+		})
+	}
+	if got, err := Connectivity[int](nil); err != nil || len(got) != 0 {
+		t.Errorf("no operations: %v, %v", got, err)
+	}
+}
@@ -0,0 +1,289 @@
+// Package election implements leader election algorithms: the bully
+// algorithm, for fully connected processes that can detect failures by
+// timeouts, and the Chang–Roberts and Hirschberg–Sinclair algorithms for
+// rings.
+//
+// References:
+//
+// Garcia-Molina, Elections in a Distributed Computing System, IEEE
+// Transactions on Computers, 1982.
+package election
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sanderblue/algorithms/internal/mailbox"
+)
+
+// None is the ID of no process: no leader known.
+const None = -1
+
+// MessageType is the kind of a message of the bully algorithm.
+type MessageType int
+
+const (
+	MsgElection    MessageType = iota // a lower process starts an election
+	MsgAnswer                         // a higher process takes the election over
+	MsgCoordinator                    // the winner announces itself
+	MsgPing                           // a process checks the coordinator is alive
+	MsgPong                           // the coordinator replies
+)
+
+func (t MessageType) String() string {
+	switch t {
+	case MsgElection:
+		return "election"
+	case MsgAnswer:
+		return "answer"
+	case MsgCoordinator:
+		return "coordinator"
+	case MsgPing:
+		return "ping"
+	case MsgPong:
+		return "pong"
+	default:
+		return fmt.Sprintf("message(%d)", int(t))
+	}
+}
+
+type message struct {
+	typ  MessageType
+	from int
+}
+
+// Bully runs the bully algorithm among P processes with IDs 0..P-1, each on
+// its own goroutine. The alive process with the highest ID becomes the
+// coordinator. Processes ping the coordinator and start an election when it
+// doesn't answer within Timeout; a process that starts an election and hears
+// no answer from a higher process within Timeout announces itself.
+type Bully struct {
+	P       int
+	Timeout time.Duration
+
+	procs []*process
+	wg    sync.WaitGroup
+	done  chan struct{}
+
+	mu   sync.Mutex
+	sent map[MessageType]int
+}
+
+type process struct {
+	id    int
+	b     *Bully
+	inbox *mailbox.Mailbox[message]
+
+	mu       sync.Mutex
+	crashed  bool
+	leader   int
+	electing bool      // waiting for answers to our election
+	answered bool      // a higher process took over; waiting for its announcement
+	deadline time.Time // of the election phase in progress
+	pinged   time.Time // when the unanswered ping went out, or zero
+	lastPing time.Time
+}
+
+// NewBully starts p processes, which immediately elect a coordinator.
+func NewBully(p int, timeout time.Duration) *Bully {
+	b := &Bully{P: p, Timeout: timeout, done: make(chan struct{}), sent: make(map[MessageType]int)}
+	for id := 0; id < p; id++ {
+		b.procs = append(b.procs, &process{id: id, b: b, inbox: mailbox.New[message](), leader: None})
+	}
+	b.wg.Add(p)
+	for _, pr := range b.procs {
+		go pr.run()
+	}
+	for _, pr := range b.procs {
+		pr.mu.Lock()
+		pr.startElection()
+		pr.mu.Unlock()
+	}
+	return b
+}
+
+// Leader returns the coordinator process id knows of, or None.
+func (b *Bully) Leader(id int) int {
+	pr := b.procs[id]
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	return pr.leader
+}
+
+// Crash stops process id: it ignores every message until Recover.
+func (b *Bully) Crash(id int) {
+	pr := b.procs[id]
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	pr.crashed = true
+	pr.leader = None
+}
+
+// Recover restarts a crashed process, which starts an election: if it has
+// the highest ID, it bullies its way back into coordination.
+func (b *Bully) Recover(id int) {
+	pr := b.procs[id]
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	pr.crashed = false
+	pr.startElection()
+}
+
+// WaitLeader waits up to timeout for every alive process to agree on the
+// alive process with the highest ID as coordinator, and returns it.
+func (b *Bully) WaitLeader(timeout time.Duration) (int, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		want := None
+		for _, pr := range b.procs {
+			pr.mu.Lock()
+			if !pr.crashed {
+				want = pr.id
+			}
+			pr.mu.Unlock()
+		}
+		agreed := want != None
+		for _, pr := range b.procs {
+			pr.mu.Lock()
+			if !pr.crashed && (pr.leader != want || pr.electing || pr.answered) {
+				agreed = false
+			}
+			pr.mu.Unlock()
+		}
+		if agreed {
+			return want, nil
+		}
+		if time.Now().After(deadline) {
+			return None, fmt.Errorf("no agreement on coordinator %d within %v", want, timeout)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Messages returns the number of messages sent so far, by type.
+func (b *Bully) Messages() map[MessageType]int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make(map[MessageType]int, len(b.sent))
+	for t, n := range b.sent {
+		out[t] = n
+	}
+	return out
+}
+
+// Close stops every process.
+func (b *Bully) Close() {
+	close(b.done)
+	b.wg.Wait()
+}
+
+func (b *Bully) send(from, to int, typ MessageType) {
+	b.mu.Lock()
+	b.sent[typ]++
+	b.mu.Unlock()
+	b.procs[to].inbox.Push(message{typ: typ, from: from})
+}
+
+func (pr *process) run() {
+	defer pr.b.wg.Done()
+	ticker := time.NewTicker(max(pr.b.Timeout/4, time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-pr.inbox.Signal():
+			for _, m := range pr.inbox.Drain() {
+				pr.mu.Lock()
+				if !pr.crashed {
+					pr.handle(m)
+				}
+				pr.mu.Unlock()
+			}
+		case now := <-ticker.C:
+			pr.mu.Lock()
+			if !pr.crashed {
+				pr.tick(now)
+			}
+			pr.mu.Unlock()
+		case <-pr.b.done:
+			return
+		}
+	}
+}
+
+// startElection sends an election message to every higher process, or
+// announces the process right away if it has the highest ID.
+func (pr *process) startElection() {
+	pr.electing, pr.answered = true, false
+	pr.pinged = time.Time{}
+	pr.deadline = time.Now().Add(pr.b.Timeout)
+	if pr.id == pr.b.P-1 {
+		pr.announce()
+		return
+	}
+	for to := pr.id + 1; to < pr.b.P; to++ {
+		pr.b.send(pr.id, to, MsgElection)
+	}
+}
+
+func (pr *process) announce() {
+	pr.electing, pr.answered = false, false
+	pr.leader = pr.id
+	for to := 0; to < pr.b.P; to++ {
+		if to != pr.id {
+			pr.b.send(pr.id, to, MsgCoordinator)
+		}
+	}
+}
+
+func (pr *process) handle(m message) {
+	switch m.typ {
+	case MsgElection:
+		pr.b.send(pr.id, m.from, MsgAnswer)
+		if !pr.electing && !pr.answered {
+			pr.startElection()
+		}
+	case MsgAnswer:
+		if pr.electing {
+			pr.electing, pr.answered = false, true
+			pr.deadline = time.Now().Add(pr.b.Timeout)
+		}
+	case MsgCoordinator:
+		if m.from < pr.id {
+			// A lower process missed us: bully it.
+			pr.startElection()
+			return
+		}
+		pr.leader = m.from
+		pr.electing, pr.answered = false, false
+		pr.pinged = time.Time{}
+		pr.lastPing = time.Now()
+	case MsgPing:
+		pr.b.send(pr.id, m.from, MsgPong)
+	case MsgPong:
+		if m.from == pr.leader {
+			pr.pinged = time.Time{}
+		}
+	}
+}
+
+func (pr *process) tick(now time.Time) {
+	switch {
+	case pr.electing && now.After(pr.deadline):
+		// No higher process answered.
+		pr.announce()
+	case pr.answered && now.After(pr.deadline):
+		// The higher process that answered failed before announcing.
+		pr.startElection()
+	case pr.electing || pr.answered || pr.leader == pr.id:
+	case pr.leader == None:
+		pr.startElection()
+	case !pr.pinged.IsZero() && now.Sub(pr.pinged) > pr.b.Timeout:
+		// The coordinator failed.
+		pr.leader = None
+		pr.startElection()
+	case pr.pinged.IsZero() && now.Sub(pr.lastPing) >= pr.b.Timeout:
+		pr.pinged, pr.lastPing = now, now
+		pr.b.send(pr.id, pr.leader, MsgPing)
+	}
+}
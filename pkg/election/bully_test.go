@@ -0,0 +1,80 @@
+package election
+
+import (
+	"testing"
+	"time"
+)
+
+const (
+	timeout = 10 * time.Millisecond
+	wait    = 5 * time.Second
+)
+
+func TestBully_ElectsHighest(t *testing.T) {
+	for _, p := range []int{1, 2, 5} {
+		b := NewBully(p, timeout)
+		leader, err := b.WaitLeader(wait)
+		b.Close()
+		if err != nil {
+			t.Fatalf("p=%d: %v", p, err)
+		}
+		if leader != p-1 {
+			t.Errorf("p=%d: elected %d, want %d", p, leader, p-1)
+		}
+	}
+}
+
+func TestBully_CoordinatorFailure(t *testing.T) {
+	b := NewBully(5, timeout)
+	defer b.Close()
+	if _, err := b.WaitLeader(wait); err != nil {
+		t.Fatal(err)
+	}
+
+	// The others notice the coordinator is gone and elect the next highest.
+	b.Crash(4)
+	leader, err := b.WaitLeader(wait)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leader != 3 {
+		t.Fatalf("elected %d after crashing 4, want 3", leader)
+	}
+	if b.Messages()[MsgPing] == 0 {
+		t.Error("the failure was detected without pings")
+	}
+
+	b.Crash(3)
+	b.Crash(2)
+	if leader, err = b.WaitLeader(wait); err != nil || leader != 1 {
+		t.Fatalf("elected %d, %v after crashing 2 and 3, want 1", leader, err)
+	}
+
+	// A recovered process with a higher ID takes over.
+	b.Recover(4)
+	if leader, err = b.WaitLeader(wait); err != nil || leader != 4 {
+		t.Fatalf("elected %d, %v after recovering 4, want 4", leader, err)
+	}
+	for _, id := range []int{0, 1, 4} {
+		if got := b.Leader(id); got != 4 {
+			t.Errorf("process %d follows %d, want 4", id, got)
+		}
+	}
+	if got := b.Leader(3); got != None {
+		t.Errorf("crashed process 3 follows %d, want None", got)
+	}
+}
+
+func TestBully_Messages(t *testing.T) {
+	b := NewBully(4, timeout)
+	defer b.Close()
+	if _, err := b.WaitLeader(wait); err != nil {
+		t.Fatal(err)
+	}
+	m := b.Messages()
+	// Every process sends an election message to every higher one, at
+	// least once, and the winner announces itself to the other three.
+	if m[MsgElection] < 6 || m[MsgCoordinator] < 3 || m[MsgAnswer] == 0 {
+		t.Errorf("unexpected message counts %v", m)
+	}
+}
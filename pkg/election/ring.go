@@ -0,0 +1,245 @@
+package election
+
+import (
+	"fmt"
+	"math/bits"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sanderblue/algorithms/internal/mailbox"
+	"github.com/sanderblue/algorithms/pkg/ringallreduce"
+)
+
+// RingResult reports a ring election.
+type RingResult struct {
+	Leader   int // rank elected: the one with the highest ID
+	Messages int // messages sent, including the announcement of the leader
+	Phases   int // Hirschberg–Sinclair: phases the leader went through
+}
+
+type direction int
+
+const (
+	clockwise        direction = iota // towards the right (send) neighbor
+	counterclockwise                  // towards the left (receive) neighbor
+)
+
+type ringKind int
+
+const (
+	ringProbe ringKind = iota
+	ringReply
+	ringElected
+)
+
+type ringMsg struct {
+	kind  ringKind
+	id    int
+	phase int
+	hops  int
+	dir   direction // direction of travel
+}
+
+// ringNet connects the ranks of a ring topology in both directions.
+type ringNet struct {
+	ids         []int
+	left, right []int
+	inboxes     []*mailbox.Mailbox[ringMsg]
+	sent        atomic.Int64
+}
+
+func newRingNet(t ringallreduce.Topology, ids []int) (*ringNet, error) {
+	p := t.Size()
+	if ids == nil {
+		ids = make([]int, p)
+		for r := range ids {
+			ids[r] = r
+		}
+	}
+	if len(ids) != p {
+		return nil, fmt.Errorf("got %d IDs for %d ranks", len(ids), p)
+	}
+	seen := map[int]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			return nil, fmt.Errorf("ID %d is not unique", id)
+		}
+		seen[id] = true
+	}
+	n := &ringNet{ids: ids, left: make([]int, p), right: make([]int, p)}
+	for r := 0; r < p; r++ {
+		switch nb := t.Neighbors(r); len(nb) {
+		case 0:
+			n.left[r], n.right[r] = r, r
+		case 1:
+			n.left[r], n.right[r] = nb[0], nb[0]
+		case 2:
+			n.left[r], n.right[r] = nb[0], nb[1]
+		default:
+			return nil, fmt.Errorf("%s is not a ring: rank %d has %d neighbors", t.Name(), r, len(nb))
+		}
+		n.inboxes = append(n.inboxes, mailbox.New[ringMsg]())
+	}
+	for r := 0; r < p; r++ {
+		if n.left[n.right[r]] != r {
+			return nil, fmt.Errorf("%s is not a ring: rank %d sends to %d, which receives from %d", t.Name(), r, n.right[r], n.left[n.right[r]])
+		}
+	}
+	return n, nil
+}
+
+// send passes m on from rank in the direction m travels.
+func (n *ringNet) send(from int, m ringMsg) {
+	n.sent.Add(1)
+	to := n.right[from]
+	if m.dir == counterclockwise {
+		to = n.left[from]
+	}
+	n.inboxes[to].Push(m)
+}
+
+// run runs rank on its own goroutine until it returns the leader it learned
+// of, and checks that every rank learned of the same one.
+func (n *ringNet) run(rank func(r int, recv func() ringMsg) (leader, phases int)) (RingResult, error) {
+	p := len(n.ids)
+	leaders := make([]int, p)
+	phases := make([]int, p)
+	var wg sync.WaitGroup
+	for r := 0; r < p; r++ {
+		wg.Add(1)
+		go func(r int) {
+			defer wg.Done()
+			var queue []ringMsg
+			recv := func() ringMsg {
+				for len(queue) == 0 {
+					<-n.inboxes[r].Signal()
+					queue = n.inboxes[r].Drain()
+				}
+				m := queue[0]
+				queue = queue[1:]
+				return m
+			}
+			leaders[r], phases[r] = rank(r, recv)
+		}(r)
+	}
+	wg.Wait()
+	res := RingResult{Leader: leaders[0], Messages: int(n.sent.Load())}
+	for r, l := range leaders {
+		if l != res.Leader {
+			return res, fmt.Errorf("rank %d elected %d, rank 0 elected %d", r, l, res.Leader)
+		}
+		res.Phases = max(res.Phases, phases[r])
+	}
+	return res, nil
+}
+
+// announce sends the elected leader around the ring from the leader, and
+// returns once every rank knows it.
+func (n *ringNet) announce(r int, recv func() ringMsg) {
+	if len(n.ids) == 1 {
+		return
+	}
+	n.send(r, ringMsg{kind: ringElected, id: r, dir: clockwise})
+	for m := recv(); m.kind != ringElected; m = recv() {
+		// Stale election messages still circulating are dropped.
+	}
+}
+
+// follow passes the announcement of the leader on and returns the leader.
+func (n *ringNet) follow(r int, m ringMsg) int {
+	n.send(r, m)
+	return m.id
+}
+
+// ChangRoberts elects the rank with the highest ID on ring t with the
+// Chang–Roberts algorithm: every rank sends its ID clockwise, ranks forward
+// only IDs higher than their own, and the rank that gets its own ID back is
+// the leader. ids gives the ID of every rank, the rank itself if nil. It
+// sends O(n²) messages in the worst case and O(n log n) on average.
+func ChangRoberts(t ringallreduce.Topology, ids []int) (RingResult, error) {
+	n, err := newRingNet(t, ids)
+	if err != nil {
+		return RingResult{}, err
+	}
+	return n.run(func(r int, recv func() ringMsg) (int, int) {
+		own := n.ids[r]
+		if len(n.ids) == 1 {
+			return r, 0
+		}
+		n.send(r, ringMsg{kind: ringProbe, id: own, dir: clockwise})
+		for {
+			m := recv()
+			switch {
+			case m.kind == ringElected:
+				return n.follow(r, m), 0
+			case m.id == own:
+				n.announce(r, recv)
+				return r, 0
+			case m.id > own:
+				n.send(r, m)
+			}
+		}
+	})
+}
+
+// HirschbergSinclair elects the rank with the highest ID on ring t with the
+// Hirschberg–Sinclair algorithm: in phase k, every rank still in the running
+// probes 2^k hops in both directions and stays in the running only if no
+// higher ID lies within that distance. The rank whose probe travels all the
+// way around is the leader. It sends O(n log n) messages. ids gives the ID
+// of every rank, the rank itself if nil.
+//
+// References:
+//
+// Hirschberg and Sinclair, Decentralized Extrema-Finding in Circular
+// Configurations of Processors, Communications of the ACM, 1980.
+func HirschbergSinclair(t ringallreduce.Topology, ids []int) (RingResult, error) {
+	n, err := newRingNet(t, ids)
+	if err != nil {
+		return RingResult{}, err
+	}
+	return n.run(func(r int, recv func() ringMsg) (int, int) {
+		own := n.ids[r]
+		if len(n.ids) == 1 {
+			return r, 0
+		}
+		phase, replies := 0, 0
+		probe := func() {
+			for _, dir := range []direction{clockwise, counterclockwise} {
+				n.send(r, ringMsg{kind: ringProbe, id: own, phase: phase, hops: 1, dir: dir})
+			}
+		}
+		probe()
+		for {
+			m := recv()
+			switch {
+			case m.kind == ringElected:
+				return n.follow(r, m), 0
+			case m.kind == ringProbe && m.id == own:
+				// The probe went all the way around.
+				n.announce(r, recv)
+				return r, phase + 1
+			case m.kind == ringProbe && m.id > own && m.hops < 1<<m.phase:
+				m.hops++
+				n.send(r, m)
+			case m.kind == ringProbe && m.id > own:
+				m.kind = ringReply
+				m.dir = 1 - m.dir
+				n.send(r, m)
+			case m.kind == ringReply && m.id != own:
+				n.send(r, m)
+			case m.kind == ringReply && m.phase == phase:
+				if replies++; replies == 2 {
+					phase, replies = phase+1, 0
+					probe()
+				}
+			}
+		}
+	})
+}
+
+// maxHirschbergSinclair bounds the messages Hirschberg–Sinclair sends on n
+// ranks, without the announcement.
+func maxHirschbergSinclair(n int) int {
+	return 8 * n * (bits.Len(uint(n-1)) + 1)
+}
@@ -0,0 +1,105 @@
+package election
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/sanderblue/algorithms/pkg/ringallreduce"
+)
+
+type ringElection func(ringallreduce.Topology, []int) (RingResult, error)
+
+func TestRingElection(t *testing.T) {
+	permuted, _ := ringallreduce.NewPermutedRing([]int{3, 0, 4, 1, 2})
+	descending := make([]int, 64)
+	for r := range descending {
+		descending[r] = 64 - r
+	}
+	tests := []struct {
+		name     string
+		topology ringallreduce.Topology
+		ids      []int
+		leader   int
+	}{
+		{"one rank", ringallreduce.NewRing(1), nil, 0},
+		{"two ranks", ringallreduce.NewRing(2), []int{7, 3}, 0},
+		{"ranks as IDs", ringallreduce.NewRing(8), nil, 7},
+		{"shuffled IDs", ringallreduce.NewRing(50), rand.New(rand.NewSource(1)).Perm(50), -1},
+		{"permuted ring", permuted, []int{10, 50, 20, 40, 30}, 1},
+		{"descending IDs", ringallreduce.NewRing(64), descending, 0},
+	}
+	for _, algo := range []struct {
+		name string
+		run  ringElection
+	}{{"chang-roberts", ChangRoberts}, {"hirschberg-sinclair", HirschbergSinclair}} {
+		for _, tc := range tests {
+			tc := tc
+			t.Run(algo.name+"/"+tc.name, func(t *testing.T) {
+				want := tc.leader
+				if want == -1 {
+					for r, id := range tc.ids {
+						if id == len(tc.ids)-1 {
+							want = r
+						}
+					}
+				}
+				res, err := algo.run(tc.topology, tc.ids)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if res.Leader != want {
+					t.Errorf("elected rank %d, want %d", res.Leader, want)
+				}
+				if p := tc.topology.Size(); p > 1 && res.Messages < 2*p {
+					t.Errorf("%d messages, fewer than the %d needed to go around twice", res.Messages, 2*p)
+				}
+			})
+		}
+	}
+}
+
+func TestRingElection_Messages(t *testing.T) {
+	const p = 64
+	ring := ringallreduce.NewRing(p)
+	// IDs decreasing clockwise are the worst case of Chang–Roberts: the ID of
+	// rank r travels p-r hops before rank 0 drops it.
+	worst := make([]int, p)
+	for r := range worst {
+		worst[r] = p - r
+	}
+	cr, err := ChangRoberts(ring, worst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := p*(p+1)/2 + p; cr.Messages != want {
+		t.Errorf("Chang–Roberts sent %d messages, want %d", cr.Messages, want)
+	}
+	// Increasing IDs are its best case: every ID but the highest is dropped
+	// after one hop.
+	if best, _ := ChangRoberts(ring, nil); best.Messages != 2*p-1+p {
+		t.Errorf("Chang–Roberts sent %d messages on increasing IDs, want %d", best.Messages, 3*p-1)
+	}
+
+	hs, err := HirschbergSinclair(ring, worst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bound := maxHirschbergSinclair(p) + p; hs.Messages > bound || hs.Messages >= cr.Messages {
+		t.Errorf("Hirschberg–Sinclair sent %d messages, want at most %d and fewer than Chang–Roberts' %d", hs.Messages, bound, cr.Messages)
+	}
+	if hs.Phases != 7 {
+		t.Errorf("%d phases on %d ranks, want 7", hs.Phases, p)
+	}
+}
+
+func TestRingElection_Errors(t *testing.T) {
+	if _, err := ChangRoberts(ringallreduce.NewRing(3), []int{1, 1, 2}); err == nil {
+		t.Error("expected an error for duplicate IDs")
+	}
+	if _, err := HirschbergSinclair(ringallreduce.NewRing(3), []int{1, 2}); err == nil {
+		t.Error("expected an error for missing IDs")
+	}
+	if _, err := ChangRoberts(ringallreduce.NewTree(7), nil); err == nil {
+		t.Error("expected an error for a tree")
+	}
+}
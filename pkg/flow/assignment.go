@@ -0,0 +1,110 @@
+package flow
+
+import "fmt"
+
+// Assignment pairs workers with jobs at the least total cost, where
+// cost[i][j] is the cost of worker i doing job j. Every worker does at most
+// one job and every job is done by at most one worker; as many pairs as
+// possible are made, min(workers, jobs). It returns the job of every
+// worker, -1 for workers left idle, and the total cost.
+func Assignment[T Number](cost [][]T) ([]int, T, error) {
+	workers := len(cost)
+	if workers == 0 {
+		return nil, 0, nil
+	}
+	jobs := len(cost[0])
+	for i, row := range cost {
+		if len(row) != jobs {
+			return nil, 0, fmt.Errorf("flow: cost row %d has %d jobs, want %d", i, len(row), jobs)
+		}
+	}
+
+	// Source, workers, jobs and sink, with unit capacities throughout.
+	source, sink := workers+jobs, workers+jobs+1
+	nw := NewNetwork[T](workers + jobs + 2)
+	for i := range workers {
+		nw.AddEdge(source, i, 1, 0)
+	}
+	for j := range jobs {
+		nw.AddEdge(workers+j, sink, 1, 0)
+	}
+	first := workers + jobs // ID of the edge from worker 0 to job 0
+	for i, row := range cost {
+		for j, c := range row {
+			nw.AddEdge(i, workers+j, 1, c)
+		}
+	}
+	r, err := MinCostMaxFlow(nw, source, sink)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	assigned := make([]int, workers)
+	for i := range assigned {
+		assigned[i] = -1
+		for j := range jobs {
+			if nw.Edge(first+i*jobs+j).Flow > 0 {
+				assigned[i] = j
+			}
+		}
+	}
+	return assigned, r.Cost, nil
+}
+
+// Transportation ships goods from sources holding supply[i] units to
+// destinations needing demand[j] units at the least total cost, where
+// cost[i][j] is the cost per unit shipped from source i to destination j.
+// Every demand must be met and no supply exceeded. It returns the units
+// shipped from every source to every destination and the total cost.
+func Transportation[T Number](supply, demand []T, cost [][]T) ([][]T, T, error) {
+	if len(cost) != len(supply) {
+		return nil, 0, fmt.Errorf("flow: %d cost rows for %d sources", len(cost), len(supply))
+	}
+	for i, s := range supply {
+		if s < 0 {
+			return nil, 0, fmt.Errorf("flow: negative supply %v of source %d", s, i)
+		}
+	}
+	var needed T
+	for j, d := range demand {
+		if d < 0 {
+			return nil, 0, fmt.Errorf("flow: negative demand %v of destination %d", d, j)
+		}
+		needed += d
+	}
+
+	m, n := len(supply), len(demand)
+	source, sink := m+n, m+n+1
+	nw := NewNetwork[T](m + n + 2)
+	for i, s := range supply {
+		nw.AddEdge(source, i, s, 0)
+	}
+	for j, d := range demand {
+		nw.AddEdge(m+j, sink, d, 0)
+	}
+	first := m + n
+	for i, row := range cost {
+		if len(row) != n {
+			return nil, 0, fmt.Errorf("flow: cost row %d has %d destinations, want %d", i, len(row), n)
+		}
+		for j, c := range row {
+			nw.AddEdge(i, m+j, needed, c)
+		}
+	}
+	r, err := MinCostMaxFlow(nw, source, sink)
+	if err != nil {
+		return nil, 0, err
+	}
+	if r.Flow < needed {
+		return nil, 0, fmt.Errorf("flow: supplies cover %v of the %v units demanded", r.Flow, needed)
+	}
+
+	shipped := make([][]T, m)
+	for i := range shipped {
+		shipped[i] = make([]T, n)
+		for j := range shipped[i] {
+			shipped[i][j] = nw.Edge(first + i*n + j).Flow
+		}
+	}
+	return shipped, r.Cost, nil
+}
@@ -0,0 +1,137 @@
+package flow
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// bestAssignment returns the cost of the cheapest assignment of the square
+// cost matrix by trying every permutation.
+func bestAssignment(cost [][]int) int {
+	n := len(cost)
+	perm := make([]int, n)
+	used := make([]bool, n)
+	best, found := 0, false
+	var try func(i, sum int)
+	try = func(i, sum int) {
+		if i == n {
+			if !found || sum < best {
+				best, found = sum, true
+			}
+			return
+		}
+		for j := range n {
+			if !used[j] {
+				used[j], perm[i] = true, j
+				try(i+1, sum+cost[i][j])
+				used[j] = false
+			}
+		}
+	}
+	try(0, 0)
+	return best
+}
+
+func TestAssignment_MatchesExhaustive(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := range 30 {
+		n := 1 + i%7
+		cost := make([][]int, n)
+		for w := range cost {
+			cost[w] = make([]int, n)
+			for j := range cost[w] {
+				cost[w][j] = rng.Intn(40) - 10
+			}
+		}
+		assigned, total, err := Assignment(cost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum, used := 0, map[int]bool{}
+		for w, j := range assigned {
+			if j < 0 || used[j] {
+				t.Fatalf("matrix %d: invalid assignment %v", i, assigned)
+			}
+			used[j] = true
+			sum += cost[w][j]
+		}
+		if want := bestAssignment(cost); total != want || sum != want {
+			t.Fatalf("matrix %d: assignment %v costs %d (reported %d), want %d", i, assigned, sum, total, want)
+		}
+	}
+}
+
+func TestAssignment_Rectangular(t *testing.T) {
+	tests := []struct {
+		name string
+		cost [][]int
+		want []int
+		sum  int
+	}{
+		{"more jobs", [][]int{{4, 1, 5}, {2, 0, 6}}, []int{1, 0}, 3},
+		{"more workers", [][]int{{4}, {2}, {3}}, []int{-1, 0, -1}, 2},
+		{"no workers", nil, nil, 0},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, sum, err := Assignment(tc.cost)
+			if err != nil || !reflect.DeepEqual(got, tc.want) || sum != tc.sum {
+				t.Errorf("got %v costing %d, %v, want %v costing %d", got, sum, err, tc.want, tc.sum)
+			}
+		})
+	}
+	if _, _, err := Assignment([][]int{{1, 2}, {3}}); err == nil {
+		t.Error("expected an error for a ragged matrix")
+	}
+}
+
+func TestTransportation(t *testing.T) {
+	// Three plants supplying four cities; an optimal plan costs 1020.
+	supply := []int{35, 50, 40}
+	demand := []int{45, 20, 30, 30}
+	cost := [][]int{
+		{8, 6, 10, 9},
+		{9, 12, 13, 7},
+		{14, 9, 16, 5},
+	}
+	shipped, total, err := Transportation(supply, demand, cost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1020 {
+		t.Errorf("plan %v costs %d, want 1020", shipped, total)
+	}
+	for i, row := range shipped {
+		out := 0
+		for _, x := range row {
+			out += x
+		}
+		if out > supply[i] {
+			t.Errorf("source %d ships %d of its %d units", i, out, supply[i])
+		}
+	}
+	for j, d := range demand {
+		in := 0
+		for i := range shipped {
+			in += shipped[i][j]
+		}
+		if in != d {
+			t.Errorf("destination %d receives %d units, needs %d", j, in, d)
+		}
+	}
+
+	if _, _, err := Transportation([]int{5}, []int{3, 3}, [][]int{{1, 1}}); err == nil {
+		t.Error("expected an error for demand exceeding supply")
+	}
+	if _, _, err := Transportation([]int{5, 1}, []int{3}, [][]int{{1}}); err == nil {
+		t.Error("expected an error for a missing cost row")
+	}
+	if _, _, err := Transportation([]int{-1}, []int{0}, [][]int{{1}}); err == nil {
+		t.Error("expected an error for a negative supply")
+	}
+	if _, _, err := Transportation([]int{5}, []int{-2}, [][]int{{1}}); err == nil {
+		t.Error("expected an error for a negative demand")
+	}
+}
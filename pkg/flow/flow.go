@@ -0,0 +1,155 @@
+// Package flow implements flow networks, directed graphs whose edges carry
+// a capacity and a cost per unit of flow, and minimum-cost maximum flow on
+// them:
+//
+//   - MinCostMaxFlow and MinCostFlow send flow from a source to a sink, as
+//     much as fits or up to a limit, at the least total cost. They augment
+//     along successive shortest paths, found by Dijkstra over costs made
+//     non-negative by Johnson potentials; the first potentials come from
+//     SPFA, so costs may be negative as long as no cycle is.
+//   - Assignment and Transportation solve the classic problems reducing to
+//     it: pairing workers with jobs, and shipping goods from supplies to
+//     demands, at the least cost.
+//
+// With integer capacities the flows are integral and successive shortest
+// paths take at most F augmentations for a flow of F.
+//
+// References:
+//
+// Ahuja, Magnanti and Orlin, Network Flows: Theory, Algorithms, and
+// Applications, 1993, chapters 9 and 12.
+//
+// Edmonds and Karp, Theoretical Improvements in Algorithmic Efficiency for
+// Network Flow Problems, Journal of the ACM 19(2), 1972.
+//
+// Johnson, Efficient Algorithms for Shortest Paths in Sparse Networks,
+// Journal of the ACM 24(1), 1977.
+package flow
+
+import (
+	"fmt"
+
+	"github.com/sanderblue/algorithms/pkg/graph"
+)
+
+// Number is the type of capacities and costs. It must be signed: residual
+// arcs carry negated costs.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~float32 | ~float64
+}
+
+// Edge is an edge of a network and the flow it carries.
+type Edge[T Number] struct {
+	From     int
+	To       int
+	Capacity T
+	Cost     T // per unit of flow
+	Flow     T
+}
+
+// arc is an edge of the residual network. Arcs come in pairs, an edge of the
+// network at an even index and its reverse right after, so the twin of arc
+// i is arc i^1.
+type arc[T Number] struct {
+	to   int
+	cap  T // residual capacity
+	cost T
+}
+
+// Network is a flow network of a fixed set of nodes, identified by dense
+// integers 0..n-1 like the nodes of package graph.
+type Network[T Number] struct {
+	arcs     []arc[T]
+	from     []int   // tail of every arc
+	capacity []T     // capacity of every edge, by edge ID
+	out      [][]int // arcs leaving every node
+}
+
+// NewNetwork returns a network of n nodes without edges.
+func NewNetwork[T Number](n int) *Network[T] {
+	return &Network[T]{out: make([][]int, n)}
+}
+
+// Order returns the number of nodes.
+func (nw *Network[T]) Order() int { return len(nw.out) }
+
+// AddNode adds a node and returns its ID.
+func (nw *Network[T]) AddNode() int {
+	nw.out = append(nw.out, nil)
+	return len(nw.out) - 1
+}
+
+// AddEdge adds an edge from u to v that carries up to capacity units of
+// flow at cost each, and returns its ID. IDs are consecutive from 0.
+func (nw *Network[T]) AddEdge(u, v int, capacity, cost T) int {
+	nw.mustExist(u)
+	nw.mustExist(v)
+	if capacity < 0 {
+		panic(fmt.Sprintf("flow: edge %d->%d of negative capacity %v", u, v, capacity))
+	}
+	id := len(nw.capacity)
+	nw.out[u] = append(nw.out[u], len(nw.arcs))
+	nw.arcs = append(nw.arcs, arc[T]{to: v, cap: capacity, cost: cost})
+	nw.from = append(nw.from, u)
+	nw.out[v] = append(nw.out[v], len(nw.arcs))
+	nw.arcs = append(nw.arcs, arc[T]{to: u, cost: -cost})
+	nw.from = append(nw.from, v)
+	nw.capacity = append(nw.capacity, capacity)
+	return id
+}
+
+// Edge returns edge id and the flow it carries.
+func (nw *Network[T]) Edge(id int) Edge[T] {
+	a := nw.arcs[2*id]
+	return Edge[T]{From: nw.from[2*id], To: a.to, Capacity: nw.capacity[id], Cost: a.cost,
+		Flow: nw.capacity[id] - a.cap}
+}
+
+// Edges returns every edge and the flow it carries, by ID.
+func (nw *Network[T]) Edges() []Edge[T] {
+	edges := make([]Edge[T], len(nw.capacity))
+	for id := range edges {
+		edges[id] = nw.Edge(id)
+	}
+	return edges
+}
+
+// Cost returns the total cost of the flow in the network.
+func (nw *Network[T]) Cost() T {
+	var cost T
+	for id := range nw.capacity {
+		e := nw.Edge(id)
+		cost += e.Flow * e.Cost
+	}
+	return cost
+}
+
+// Reset removes all flow.
+func (nw *Network[T]) Reset() {
+	for id, c := range nw.capacity {
+		nw.arcs[2*id].cap = c
+		nw.arcs[2*id+1].cap = 0
+	}
+}
+
+// Residual returns the residual network as a graph: an edge weighing its
+// cost for every arc with capacity left, that is, every edge not saturated
+// and the reverse, weighing minus the cost, of every edge carrying flow.
+// A flow is of minimum cost for its value exactly when the residual network
+// has no negative cycle.
+func (nw *Network[T]) Residual() *graph.Graph[struct{}, T] {
+	g := graph.NewDirected[struct{}, T]()
+	g.AddNodes(make([]struct{}, nw.Order())...)
+	for i, a := range nw.arcs {
+		if a.cap > 0 {
+			g.AddEdge(nw.from[i], a.to, a.cost)
+		}
+	}
+	return g
+}
+
+func (nw *Network[T]) mustExist(u int) {
+	if u < 0 || u >= len(nw.out) {
+		panic(fmt.Sprintf("flow: node %d out of range [0, %d)", u, len(nw.out)))
+	}
+}
@@ -0,0 +1,63 @@
+package flow
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNetwork_Edges(t *testing.T) {
+	nw := NewNetwork[int](2)
+	c := nw.AddNode()
+	a := nw.AddEdge(0, 1, 3, 2)
+	b := nw.AddEdge(1, c, 2, -1)
+	if a != 0 || b != 1 || nw.Order() != 3 {
+		t.Fatalf("got edge IDs %d and %d and order %d", a, b, nw.Order())
+	}
+
+	// Push two units along 0->1->2 by hand.
+	for _, i := range []int{0, 2} {
+		nw.arcs[i].cap -= 2
+		nw.arcs[i^1].cap += 2
+	}
+	want := []Edge[int]{{0, 1, 3, 2, 2}, {1, 2, 2, -1, 2}}
+	if got := nw.Edges(); !reflect.DeepEqual(got, want) {
+		t.Errorf("edges %v, want %v", got, want)
+	}
+	if nw.Cost() != 2 {
+		t.Errorf("cost %d, want 2", nw.Cost())
+	}
+	// 0->1 has capacity left and flow to send back; 1->2 only the latter.
+	r := nw.Residual()
+	if got := r.Edges(); len(got) != 3 || !r.HasEdge(0, 1) || !r.HasEdge(1, 0) || !r.HasEdge(2, 1) {
+		t.Errorf("residual edges %v", got)
+	}
+	if w, _ := r.Weight(2, 1); w != 1 {
+		t.Errorf("residual 2->1 weighs %d, want 1", w)
+	}
+
+	nw.Reset()
+	if nw.Edge(0).Flow != 0 || nw.Edge(1).Flow != 0 || nw.Residual().Size() != 2 {
+		t.Errorf("flow left after Reset: %v", nw.Edges())
+	}
+}
+
+func TestNetwork_Panics(t *testing.T) {
+	tests := []struct {
+		name string
+		add  func(nw *Network[int])
+	}{
+		{"missing node", func(nw *Network[int]) { nw.AddEdge(0, 2, 1, 1) }},
+		{"negative capacity", func(nw *Network[int]) { nw.AddEdge(0, 1, -1, 1) }},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			tc.add(NewNetwork[int](2))
+		})
+	}
+}
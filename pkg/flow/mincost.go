@@ -0,0 +1,161 @@
+package flow
+
+import (
+	"container/heap"
+	"fmt"
+
+	"github.com/sanderblue/algorithms/pkg/graph"
+)
+
+// Result describes a flow found by MinCostFlow or MinCostMaxFlow.
+type Result[T Number] struct {
+	Flow          T   // units sent from the source to the sink
+	Cost          T   // total cost of the flow
+	Augmentations int // shortest paths the flow was sent along
+}
+
+// MinCostMaxFlow sends as much flow as fits from source to sink, at the
+// least cost among maximum flows, replacing any flow already in nw. The
+// flow of every edge can be read back with nw.Edge. It returns an error
+// wrapping graph.ErrNegativeCycle if the costs have a negative cycle
+// reachable from source.
+func MinCostMaxFlow[T Number](nw *Network[T], source, sink int) (Result[T], error) {
+	return minCostFlow(nw, source, sink, 0, false)
+}
+
+// MinCostFlow is MinCostMaxFlow sending at most limit units of flow.
+func MinCostFlow[T Number](nw *Network[T], source, sink int, limit T) (Result[T], error) {
+	return minCostFlow(nw, source, sink, limit, true)
+}
+
+func minCostFlow[T Number](nw *Network[T], source, sink int, limit T, limited bool) (Result[T], error) {
+	nw.mustExist(source)
+	nw.mustExist(sink)
+	nw.Reset()
+	var r Result[T]
+	if source == sink {
+		return r, nil
+	}
+	pot, err := nw.potentials(source)
+	if err != nil {
+		return r, err
+	}
+
+	n := nw.Order()
+	dist := make([]T, n)
+	via := make([]int, n) // arc into every node on its shortest path
+	done := make([]bool, n)
+	for !limited || r.Flow < limit {
+		if !nw.shortestPaths(source, pot, dist, via, done) || !done[sink] {
+			break
+		}
+		for v := range pot {
+			if done[v] {
+				pot[v] += dist[v]
+			}
+		}
+
+		// Send as much as the path allows, then update the residual
+		// capacities along it.
+		push := nw.arcs[via[sink]].cap
+		for v := sink; v != source; v = nw.from[via[v]] {
+			push = min(push, nw.arcs[via[v]].cap)
+		}
+		if limited {
+			push = min(push, limit-r.Flow)
+		}
+		for v := sink; v != source; v = nw.from[via[v]] {
+			i := via[v]
+			nw.arcs[i].cap -= push
+			nw.arcs[i^1].cap += push
+			r.Cost += push * nw.arcs[i].cost
+		}
+		r.Flow += push
+		r.Augmentations++
+	}
+	return r, nil
+}
+
+// potentials returns node potentials π making the reduced cost
+// cost+π(u)-π(v) of every arc with capacity left non-negative: the
+// distances from source, found by SPFA, if some cost is negative, and zero
+// otherwise.
+func (nw *Network[T]) potentials(source int) ([]T, error) {
+	pot := make([]T, nw.Order())
+	negative := false
+	for _, a := range nw.arcs {
+		negative = negative || a.cap > 0 && a.cost < 0
+	}
+	if !negative {
+		return pot, nil
+	}
+	p, err := graph.BellmanFord(nw.Residual(), source, graph.BellmanFordConfig{SPFA: true})
+	if err != nil {
+		return nil, fmt.Errorf("flow: costs: %w", err)
+	}
+	for v := range pot {
+		if p.Reached(v) {
+			pot[v] = p.Dist[v]
+		}
+	}
+	return pot, nil
+}
+
+// shortestPaths runs Dijkstra from source over the arcs with capacity left,
+// by reduced cost, filling in the distance and last arc of every node
+// reached and marking it done. It reports whether any node beyond source
+// was reached.
+func (nw *Network[T]) shortestPaths(source int, pot, dist []T, via []int, done []bool) bool {
+	clear(done)
+	for v := range via {
+		via[v] = -1
+	}
+	h := &nodeHeap[T]{}
+	dist[source] = 0
+	heap.Push(h, nodeDist[T]{source, 0})
+	reached := false
+	for h.Len() > 0 {
+		nd := heap.Pop(h).(nodeDist[T])
+		u := nd.node
+		if done[u] || nd.dist != dist[u] {
+			continue
+		}
+		done[u] = true
+		reached = reached || u != source
+		for _, i := range nw.out[u] {
+			a := nw.arcs[i]
+			if a.cap <= 0 || done[a.to] {
+				continue
+			}
+			// Reduced costs are non-negative; clamp the rounding errors of
+			// floating point costs.
+			d := dist[u] + max(a.cost+pot[u]-pot[a.to], 0)
+			if via[a.to] < 0 || d < dist[a.to] {
+				dist[a.to], via[a.to] = d, i
+				heap.Push(h, nodeDist[T]{a.to, d})
+			}
+		}
+	}
+	return reached
+}
+
+type nodeDist[T Number] struct {
+	node int
+	dist T
+}
+
+// nodeHeap is a heap of tentative distances; nodes pushed again with a
+// shorter distance leave stale entries behind, skipped when popped.
+type nodeHeap[T Number] []nodeDist[T]
+
+func (h nodeHeap[T]) Len() int           { return len(h) }
+func (h nodeHeap[T]) Less(i, j int) bool { return h[i].dist < h[j].dist }
+func (h nodeHeap[T]) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *nodeHeap[T]) Push(x any)        { *h = append(*h, x.(nodeDist[T])) }
+
+func (h *nodeHeap[T]) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,183 @@
+package flow
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/sanderblue/algorithms/pkg/graph"
+)
+
+// randomNetwork returns a network of n nodes and up to m random edges,
+// with capacities in [0, 10] and costs in [lo, hi]. With forward set,
+// edges lead from a node to a larger one only, so there is no cycle.
+func randomNetwork(rng *rand.Rand, n, m, lo, hi int, forward bool) *Network[int] {
+	nw := NewNetwork[int](n)
+	for range m {
+		u, v := rng.Intn(n), rng.Intn(n)
+		if u == v || forward && u > v {
+			continue
+		}
+		nw.AddEdge(u, v, rng.Intn(11), lo+rng.Intn(hi-lo+1))
+	}
+	return nw
+}
+
+// minCut returns the capacity of a minimum cut between source and sink,
+// trying every set of nodes on the side of source.
+func minCut(nw *Network[int], source, sink int) int {
+	n := nw.Order()
+	best := -1
+	for set := range 1 << n {
+		if set&(1<<source) == 0 || set&(1<<sink) != 0 {
+			continue
+		}
+		c := 0
+		for _, e := range nw.Edges() {
+			if set&(1<<e.From) != 0 && set&(1<<e.To) == 0 {
+				c += e.Capacity
+			}
+		}
+		if best < 0 || c < best {
+			best = c
+		}
+	}
+	return best
+}
+
+// checkFlow verifies that the flow in nw is feasible, sends r.Flow units
+// from source to sink at cost r.Cost, and has no negative residual cycle,
+// so no flow of the same value is cheaper.
+func checkFlow(t *testing.T, nw *Network[int], source, sink int, r Result[int]) {
+	t.Helper()
+	balance := make([]int, nw.Order())
+	for _, e := range nw.Edges() {
+		if e.Flow < 0 || e.Flow > e.Capacity {
+			t.Fatalf("edge %+v carries an infeasible flow", e)
+		}
+		balance[e.From] -= e.Flow
+		balance[e.To] += e.Flow
+	}
+	for v, b := range balance {
+		want := 0
+		switch v {
+		case source:
+			want = -r.Flow
+		case sink:
+			want = r.Flow
+		}
+		if b != want {
+			t.Fatalf("node %d: net inflow %d, want %d", v, b, want)
+		}
+	}
+	if nw.Cost() != r.Cost {
+		t.Fatalf("flow costs %d, reported %d", nw.Cost(), r.Cost)
+	}
+
+	// A node leading to every other at no cost lets Bellman-Ford look for
+	// negative cycles anywhere in the residual network.
+	res := nw.Residual()
+	root := res.AddNode(struct{}{})
+	for v := range root {
+		res.AddEdge(root, v, 0)
+	}
+	if _, err := graph.BellmanFord(res, root, graph.BellmanFordConfig{}); err != nil {
+		t.Fatalf("flow is not of minimum cost: %v", err)
+	}
+}
+
+func TestMinCostMaxFlow_Known(t *testing.T) {
+	nw := NewNetwork[int](4)
+	nw.AddEdge(0, 1, 2, 1)
+	nw.AddEdge(0, 2, 1, 2)
+	nw.AddEdge(1, 2, 1, 1)
+	nw.AddEdge(1, 3, 1, 3)
+	nw.AddEdge(2, 3, 2, 1)
+	r, err := MinCostMaxFlow(nw, 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Flow != 3 || r.Cost != 10 || r.Augmentations != 3 {
+		t.Errorf("got %+v, want a flow of 3 costing 10 in 3 augmentations", r)
+	}
+	checkFlow(t, nw, 0, 3, r)
+}
+
+func TestMinCostMaxFlow_Random(t *testing.T) {
+	tests := []struct {
+		name    string
+		lo, hi  int
+		forward bool
+	}{
+		{"non-negative costs", 0, 10, false},
+		{"negative costs", -5, 10, true},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(1))
+			for i := range 50 {
+				nw := randomNetwork(rng, 8, 24, tc.lo, tc.hi, tc.forward)
+				r, err := MinCostMaxFlow(nw, 0, 7)
+				if err != nil {
+					t.Fatalf("network %d: %v", i, err)
+				}
+				if want := minCut(nw, 0, 7); r.Flow != want {
+					t.Fatalf("network %d: flow %d, want the minimum cut %d", i, r.Flow, want)
+				}
+				checkFlow(t, nw, 0, 7, r)
+			}
+		})
+	}
+}
+
+func TestMinCostFlow_Limit(t *testing.T) {
+	nw := NewNetwork[int](4)
+	nw.AddEdge(0, 1, 5, 1)
+	nw.AddEdge(1, 3, 5, 1)
+	nw.AddEdge(0, 2, 5, 3)
+	nw.AddEdge(2, 3, 5, 3)
+	tests := []struct {
+		limit, flow, cost int
+	}{
+		{0, 0, 0},
+		{4, 4, 8},
+		{7, 7, 22},
+		{20, 10, 40},
+	}
+	for _, tc := range tests {
+		r, err := MinCostFlow(nw, 0, 3, tc.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Flow != tc.flow || r.Cost != tc.cost {
+			t.Errorf("limit %d: got %+v, want flow %d costing %d", tc.limit, r, tc.flow, tc.cost)
+		}
+		checkFlow(t, nw, 0, 3, r)
+	}
+}
+
+func TestMinCostMaxFlow_FloatCosts(t *testing.T) {
+	nw := NewNetwork[float64](3)
+	nw.AddEdge(0, 1, 1.5, 0.5)
+	nw.AddEdge(1, 2, 1, 0.25)
+	nw.AddEdge(0, 2, 1, 2)
+	r, err := MinCostMaxFlow(nw, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Flow != 2 || r.Cost != 2.75 {
+		t.Errorf("got %+v, want a flow of 2 costing 2.75", r)
+	}
+}
+
+func TestMinCostMaxFlow_NegativeCycle(t *testing.T) {
+	nw := NewNetwork[int](4)
+	nw.AddEdge(0, 1, 1, 1)
+	nw.AddEdge(1, 2, 1, -3)
+	nw.AddEdge(2, 1, 1, 1)
+	nw.AddEdge(2, 3, 1, 1)
+	if _, err := MinCostMaxFlow(nw, 0, 3); !errors.Is(err, graph.ErrNegativeCycle) {
+		t.Errorf("expected graph.ErrNegativeCycle, got %v", err)
+	}
+}
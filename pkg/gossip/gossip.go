@@ -0,0 +1,244 @@
+// Package gossip simulates epidemic dissemination of a rumor: in every round,
+// every node contacts a few random peers, and the rumor spreads from informed
+// nodes to the peers they push to (push), to the nodes that pull from them
+// (pull), or both ways (push-pull). Push spreads quickly while few nodes know
+// the rumor, pull while few don't; push-pull informs n nodes in about log3 n
+// plus O(log log n) rounds.
+//
+// References:
+//
+// Demers et al., Epidemic Algorithms for Replicated Database Maintenance,
+// PODC 1987.
+//
+// Karp, Schindelhauer, Shenker and Vöcking, Randomized Rumor Spreading,
+// FOCS 2000.
+package gossip
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Mode is the direction in which the rumor travels.
+type Mode int
+
+const (
+	Push Mode = iota
+	Pull
+	PushPull
+)
+
+func (m Mode) String() string {
+	switch m {
+	case Push:
+		return "push"
+	case Pull:
+		return "pull"
+	case PushPull:
+		return "push-pull"
+	default:
+		return fmt.Sprintf("mode(%d)", int(m))
+	}
+}
+
+// Config describes one dissemination.
+type Config struct {
+	N      int // nodes
+	Mode   Mode
+	Fanout int     // peers every node contacts per round; 1 if 0
+	Rounds int     // rounds to run at most; until every node is informed if 0
+	Loss   float64 // probability that a message is lost
+	Seed   int64
+}
+
+// Result reports the progress of a dissemination.
+type Result struct {
+	Config
+	// Informed counts the informed nodes after every round; Informed[0] is
+	// the single node that starts with the rumor.
+	Informed []int
+	// Converged reports whether every node was informed; Rounds is then the
+	// number of rounds it took, else the number of rounds run.
+	Converged bool
+	Rounds    int
+	// Messages counts the messages sent: rumors pushed, pull requests and
+	// the rumors sent in reply. Redundant counts the rumors received by
+	// nodes that already knew them.
+	Messages  int
+	Redundant int
+}
+
+// maxRounds bounds a run without Rounds; far more than any mode needs
+// unless messages are all but always lost.
+const maxRounds = 10000
+
+// Spread runs a dissemination from node 0.
+func Spread(cfg Config) (Result, error) {
+	if cfg.N < 1 {
+		return Result{}, fmt.Errorf("need at least one node, got %d", cfg.N)
+	}
+	if cfg.Loss < 0 || cfg.Loss >= 1 {
+		return Result{}, fmt.Errorf("loss %v is not in [0, 1)", cfg.Loss)
+	}
+	if cfg.Fanout <= 0 {
+		cfg.Fanout = 1
+	}
+	cfg.Fanout = min(cfg.Fanout, cfg.N-1)
+	rounds := cfg.Rounds
+	if rounds <= 0 {
+		rounds = maxRounds
+	}
+
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	informed := make([]bool, cfg.N)
+	informed[0] = true
+	res := Result{Config: cfg, Informed: []int{1}}
+	count := 1
+	next := make([]bool, cfg.N)
+	peers := make([]int, 0, cfg.Fanout)
+	deliver := func(to int) {
+		res.Messages++
+		if rng.Float64() < cfg.Loss {
+			return
+		}
+		if informed[to] || next[to] {
+			res.Redundant++
+			return
+		}
+		next[to] = true
+		count++
+	}
+	for res.Rounds < rounds && count < cfg.N {
+		res.Rounds++
+		// Nodes informed during a round only spread the rumor from the next
+		// round on.
+		for node := 0; node < cfg.N; node++ {
+			peers = sample(rng, peers[:0], cfg.N, node, cfg.Fanout)
+			for _, peer := range peers {
+				if cfg.Mode != Pull && informed[node] {
+					deliver(peer)
+				}
+				if cfg.Mode != Push {
+					res.Messages++ // the request
+					if rng.Float64() >= cfg.Loss && informed[peer] {
+						deliver(node)
+					}
+				}
+			}
+		}
+		for node, ok := range next {
+			if ok {
+				informed[node] = true
+				next[node] = false
+			}
+		}
+		res.Informed = append(res.Informed, count)
+	}
+	res.Converged = count == cfg.N
+	return res, nil
+}
+
+// Exchange runs the contacts of gossip rounds for replicas that carry their
+// own state, such as CRDTs: in every round, every node contacts Fanout
+// random peers, and transfer is called for every message that isn't lost,
+// from the node whose state travels to the node that receives it. It stops
+// once done returns true, checked before every round, or after Rounds
+// rounds, and returns the number of rounds run and whether done returned
+// true.
+func Exchange(cfg Config, transfer func(from, to int), done func() bool) (int, bool, error) {
+	if cfg.N < 1 {
+		return 0, false, fmt.Errorf("need at least one node, got %d", cfg.N)
+	}
+	if cfg.Loss < 0 || cfg.Loss >= 1 {
+		return 0, false, fmt.Errorf("loss %v is not in [0, 1)", cfg.Loss)
+	}
+	if cfg.Fanout <= 0 {
+		cfg.Fanout = 1
+	}
+	cfg.Fanout = min(cfg.Fanout, cfg.N-1)
+	rounds := cfg.Rounds
+	if rounds <= 0 {
+		rounds = maxRounds
+	}
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	peers := make([]int, 0, cfg.Fanout)
+	for round := 0; round < rounds; round++ {
+		if done() {
+			return round, true, nil
+		}
+		for node := 0; node < cfg.N; node++ {
+			peers = sample(rng, peers[:0], cfg.N, node, cfg.Fanout)
+			for _, peer := range peers {
+				if cfg.Mode != Pull && rng.Float64() >= cfg.Loss {
+					transfer(node, peer)
+				}
+				// A pull needs both the request and the reply.
+				if cfg.Mode != Push && rng.Float64() >= cfg.Loss && rng.Float64() >= cfg.Loss {
+					transfer(peer, node)
+				}
+			}
+		}
+	}
+	return rounds, done(), nil
+}
+
+// sample appends k distinct random nodes of n, other than self, to out.
+func sample(rng *rand.Rand, out []int, n, self, k int) []int {
+	for len(out) < k {
+		peer := rng.Intn(n - 1)
+		if peer >= self {
+			peer++
+		}
+		dup := false
+		for _, p := range out {
+			dup = dup || p == peer
+		}
+		if !dup {
+			out = append(out, peer)
+		}
+	}
+	return out
+}
+
+// Stats summarizes the results of several disseminations.
+type Stats struct {
+	Trials       int
+	Converged    int     // trials that informed every node
+	MeanRounds   float64 // over the converged trials
+	MaxRounds    int
+	MeanMessages float64 // per node, over all trials
+	Redundancy   float64 // fraction of received rumors that were redundant
+}
+
+// Trials runs trials disseminations of cfg with seeds cfg.Seed, cfg.Seed+1
+// and so on, and summarizes them.
+func Trials(cfg Config, trials int) (Stats, error) {
+	st := Stats{Trials: trials}
+	rounds, messages, received, redundant := 0, 0, 0, 0
+	for i := 0; i < trials; i++ {
+		c := cfg
+		c.Seed += int64(i)
+		res, err := Spread(c)
+		if err != nil {
+			return Stats{}, err
+		}
+		if res.Converged {
+			st.Converged++
+			rounds += res.Rounds
+			st.MaxRounds = max(st.MaxRounds, res.Rounds)
+		}
+		messages += res.Messages
+		received += res.Informed[len(res.Informed)-1] - 1 + res.Redundant
+		redundant += res.Redundant
+	}
+	if st.Converged > 0 {
+		st.MeanRounds = float64(rounds) / float64(st.Converged)
+	}
+	if trials > 0 {
+		st.MeanMessages = float64(messages) / float64(trials*cfg.N)
+	}
+	if received > 0 {
+		st.Redundancy = float64(redundant) / float64(received)
+	}
+	return st, nil
+}
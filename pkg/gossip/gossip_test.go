@@ -0,0 +1,114 @@
+package gossip
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSpread_Converges(t *testing.T) {
+	const n = 1000
+	tests := []struct {
+		name   string
+		cfg    Config
+		rounds float64 // bound on the mean rounds to inform every node
+	}{
+		// Push needs about log2 n + ln n rounds, push-pull about log3 n plus
+		// a few.
+		{"push", Config{N: n, Mode: Push}, math.Log2(n) + math.Log(n) + 4},
+		{"pull", Config{N: n, Mode: Pull}, math.Log2(n) + math.Log(n) + 4},
+		{"push-pull", Config{N: n, Mode: PushPull}, math.Log(n)/math.Log(3) + 6},
+		{"push fanout 3", Config{N: n, Mode: Push, Fanout: 3}, math.Log(n)/math.Log(4) + math.Log(n)/3 + 4},
+		{"push-pull with loss", Config{N: n, Mode: PushPull, Loss: 0.3}, math.Log(n)/math.Log(3) + 10},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			st, err := Trials(tc.cfg, 20)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if st.Converged != st.Trials {
+				t.Fatalf("%d of %d trials converged", st.Converged, st.Trials)
+			}
+			if st.MeanRounds > tc.rounds {
+				t.Errorf("mean rounds %.1f, want at most %.1f", st.MeanRounds, tc.rounds)
+			}
+			if st.Redundancy <= 0 || st.Redundancy >= 1 {
+				t.Errorf("redundancy %v out of (0, 1)", st.Redundancy)
+			}
+		})
+	}
+}
+
+func TestSpread_Result(t *testing.T) {
+	res, err := Spread(Config{N: 50, Mode: PushPull, Seed: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Converged || len(res.Informed) != res.Rounds+1 || res.Informed[0] != 1 || res.Informed[res.Rounds] != 50 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	for r := 1; r <= res.Rounds; r++ {
+		if res.Informed[r] < res.Informed[r-1] {
+			t.Errorf("round %d: informed dropped from %d to %d", r, res.Informed[r-1], res.Informed[r])
+		}
+	}
+	// Every node pulls once per round, and informed nodes push once.
+	pushes := 0
+	for _, c := range res.Informed[:res.Rounds] {
+		pushes += c
+	}
+	if res.Messages < 50*res.Rounds+pushes {
+		t.Errorf("%d messages, want at least %d", res.Messages, 50*res.Rounds+pushes)
+	}
+
+	capped, _ := Spread(Config{N: 1000, Mode: Push, Rounds: 3})
+	if capped.Converged || capped.Rounds != 3 || capped.Informed[3] > 8 {
+		t.Errorf("three push rounds informed %v", capped.Informed)
+	}
+}
+
+func TestSpread_Errors(t *testing.T) {
+	for _, cfg := range []Config{{N: 0}, {N: 5, Loss: 1}, {N: 5, Loss: -0.1}} {
+		if _, err := Spread(cfg); err == nil {
+			t.Errorf("expected an error for %+v", cfg)
+		}
+	}
+	if res, err := Spread(Config{N: 1}); err != nil || !res.Converged || res.Rounds != 0 {
+		t.Errorf("a single node: got %+v, %v", res, err)
+	}
+}
+
+func TestExchange(t *testing.T) {
+	// Replicas keep the highest value they have seen, which spreads like a
+	// rumor.
+	values := make([]int, 100)
+	values[42] = 7
+	done := func() bool {
+		for _, v := range values {
+			if v != 7 {
+				return false
+			}
+		}
+		return true
+	}
+	transfers := 0
+	rounds, ok, err := Exchange(Config{N: 100, Mode: PushPull, Loss: 0.1, Seed: 1}, func(from, to int) {
+		transfers++
+		values[to] = max(values[to], values[from])
+	}, done)
+	if err != nil || !ok {
+		t.Fatalf("Exchange: %v, converged %v", err, ok)
+	}
+	if rounds == 0 || rounds > 20 || transfers == 0 {
+		t.Errorf("%d rounds and %d transfers", rounds, transfers)
+	}
+
+	rounds, ok, _ = Exchange(Config{N: 3, Rounds: 2}, func(int, int) {}, func() bool { return false })
+	if ok || rounds != 2 {
+		t.Errorf("ran %d rounds, done %v, want 2 rounds, not done", rounds, ok)
+	}
+	if _, _, err := Exchange(Config{N: 0}, nil, nil); err == nil {
+		t.Error("expected an error without nodes")
+	}
+}
@@ -0,0 +1,162 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrNegativeCycle is matched by the *NegativeCycleError of BellmanFord.
+var ErrNegativeCycle = errors.New("graph: negative cycle")
+
+// NegativeCycleError reports a cycle of negative weight reachable from the
+// source, around which paths get shorter without bound.
+type NegativeCycleError struct {
+	// Cycle holds the nodes of the cycle in edge order, starting at the
+	// smallest: there are edges Cycle[i]->Cycle[i+1] and a closing edge
+	// from the last node to the first.
+	Cycle []int
+}
+
+func (e *NegativeCycleError) Error() string {
+	return fmt.Sprintf("graph: negative cycle through %v", e.Cycle)
+}
+
+func (e *NegativeCycleError) Is(target error) bool { return target == ErrNegativeCycle }
+
+// BellmanFordConfig tunes BellmanFord.
+type BellmanFordConfig struct {
+	// SPFA relaxes only the edges of nodes whose distance changed, kept in
+	// a FIFO queue, instead of every edge in each of up to n-1 rounds. It
+	// has the same O(nm) worst case but is usually much faster.
+	SPFA bool
+}
+
+// BellmanFord returns the shortest paths from source to every node of g,
+// whose edge weights may be negative. If a cycle of negative weight is
+// reachable from source there are no shortest paths, and it returns a
+// *NegativeCycleError holding the cycle instead. Note that a negative edge
+// of an undirected graph is such a cycle.
+func BellmanFord[N any, W Weight](g *Graph[N, W], source int, cfg BellmanFordConfig) (*ShortestPaths[W], error) {
+	g.mustExist(source)
+	p := newShortestPaths[W](g.Order(), source)
+	p.reached[source] = true
+	var cycle []int
+	if cfg.SPFA {
+		cycle = spfa(g, p)
+	} else {
+		cycle = rounds(g, p)
+	}
+	if cycle != nil {
+		return nil, &NegativeCycleError{Cycle: cycle}
+	}
+	return p, nil
+}
+
+// relax shortens the path to e.To through e, if it is shorter, and reports
+// whether it did.
+func relax[W Weight](p *ShortestPaths[W], e Edge[W]) bool {
+	d := p.Dist[e.From] + e.Weight
+	if p.reached[e.To] && d >= p.Dist[e.To] {
+		return false
+	}
+	p.reached[e.To], p.Dist[e.To] = true, d
+	p.Pred[e.To], p.via[e.To] = e.From, e.Weight
+	return true
+}
+
+// rounds relaxes every edge of g until a round changes nothing, and returns
+// a negative cycle if there is one.
+func rounds[N any, W Weight](g *Graph[N, W], p *ShortestPaths[W]) []int {
+	// Without negative cycles n-1 rounds suffice. Past that, the
+	// predecessors of relaxed nodes end up forming a cycle, which is
+	// negative; keep going until it shows.
+	for round := 1; ; round++ {
+		changed := false
+		for u, out := range g.out {
+			if !p.reached[u] {
+				continue
+			}
+			for _, e := range out {
+				changed = relax(p, e) || changed
+			}
+		}
+		if !changed {
+			return nil
+		}
+		if round >= g.Order() {
+			if cycle := predecessorCycle(p.Pred); cycle != nil {
+				return cycle
+			}
+		}
+	}
+}
+
+// spfa relaxes the edges of nodes whose distance changed, in FIFO order,
+// and returns a negative cycle if there is one.
+func spfa[N any, W Weight](g *Graph[N, W], p *ShortestPaths[W]) []int {
+	n := g.Order()
+	queued := make([]bool, n)
+	queue := []int{p.Source}
+	queued[p.Source] = true
+	relaxed := 0
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		queued[u] = false
+		for _, e := range g.out[u] {
+			if !relax(p, e) {
+				continue
+			}
+			// Checking the predecessors for a cycle every n relaxations
+			// costs O(1) amortized per relaxation.
+			if relaxed++; relaxed%n == 0 {
+				if cycle := predecessorCycle(p.Pred); cycle != nil {
+					return cycle
+				}
+			}
+			if !queued[e.To] {
+				queued[e.To] = true
+				queue = append(queue, e.To)
+			}
+		}
+	}
+	return nil
+}
+
+// predecessorCycle returns a cycle of the graph of predecessors, in edge
+// order starting at its smallest node, or nil if there is none.
+func predecessorCycle(pred []int) []int {
+	walk := make([]int, len(pred)) // the walk that first visited each node, 1-based
+	for s := range pred {
+		v := s
+		for v >= 0 && walk[v] == 0 {
+			walk[v] = s + 1
+			v = pred[v]
+		}
+		if v < 0 || walk[v] != s+1 {
+			continue
+		}
+		// The walk from s came back to v: v is on a cycle. Following
+		// predecessors lists it backwards.
+		cycle := []int{v}
+		for u := pred[v]; u != v; u = pred[u] {
+			cycle = append(cycle, u)
+		}
+		slices.Reverse(cycle)
+		return canonicalCycle(cycle)
+	}
+	return nil
+}
+
+// canonicalCycle rotates a cycle, given in edge order, to start at its
+// smallest node.
+func canonicalCycle(cycle []int) []int {
+	first := 0
+	for i, u := range cycle {
+		if u < cycle[first] {
+			first = i
+		}
+	}
+	return append(cycle[first:len(cycle):len(cycle)], cycle[:first]...)
+}
@@ -0,0 +1,142 @@
+package graph
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+var bellmanFordModes = []struct {
+	name string
+	cfg  BellmanFordConfig
+}{
+	{"rounds", BellmanFordConfig{}},
+	{"spfa", BellmanFordConfig{SPFA: true}},
+}
+
+// reweighted returns a random directed graph with negative weights but no
+// negative cycle: weights w+π(u)-π(v) for non-negative w and random
+// potentials π, which add up to the same amount around every cycle.
+func reweighted(rng *rand.Rand, n, m int) *Graph[struct{}, int] {
+	g := randomGraph(rng, true, n, m, 0, 20)
+	pot := make([]int, n)
+	for i := range pot {
+		pot[i] = rng.Intn(50)
+	}
+	r := NewDirected[struct{}, int]()
+	r.AddNodes(g.Nodes()...)
+	for _, e := range g.Edges() {
+		r.AddEdge(e.From, e.To, e.Weight+pot[e.From]-pot[e.To])
+	}
+	return r
+}
+
+func TestBellmanFord_MatchesRelaxation(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	graphs := []*Graph[struct{}, int]{
+		reweighted(rng, 60, 300),
+		reweighted(rng, 200, 400),
+		randomGraph(rng, false, 80, 200, 0, 10),
+	}
+	for _, mode := range bellmanFordModes {
+		mode := mode
+		t.Run(mode.name, func(t *testing.T) {
+			for i, g := range graphs {
+				p, err := BellmanFord(g, 0, mode.cfg)
+				if err != nil {
+					t.Fatalf("graph %d: %v", i, err)
+				}
+				checkShortestPaths(t, g, p, relaxAll(g, 0))
+			}
+		})
+	}
+}
+
+func TestBellmanFord_NegativeCycle(t *testing.T) {
+	tests := []struct {
+		name     string
+		directed bool
+		n        int
+		edges    []Edge[int]
+		cycle    []int
+	}{
+		{"triangle", true, 5, edges([3]int{0, 1, 1}, [3]int{1, 2, 1}, [3]int{2, 3, -1}, [3]int{3, 1, -2}, [3]int{3, 4, 1}), []int{1, 2, 3}},
+		{"self-loop", true, 3, edges([3]int{0, 1, 4}, [3]int{1, 1, -1}, [3]int{1, 2, 4}), []int{1}},
+		{"undirected negative edge", false, 3, edges([3]int{0, 1, 2}, [3]int{1, 2, -1}), []int{1, 2}},
+	}
+	for _, mode := range bellmanFordModes {
+		for _, tc := range tests {
+			mode, tc := mode, tc
+			t.Run(mode.name+"/"+tc.name, func(t *testing.T) {
+				g := mustFromEdges(t, tc.directed, tc.n, tc.edges)
+				p, err := BellmanFord(g, 0, mode.cfg)
+				var cerr *NegativeCycleError
+				if !errors.As(err, &cerr) || !errors.Is(err, ErrNegativeCycle) || p != nil {
+					t.Fatalf("expected a *NegativeCycleError, got %v", err)
+				}
+				if !reflect.DeepEqual(cerr.Cycle, tc.cycle) {
+					t.Errorf("cycle %v, want %v", cerr.Cycle, tc.cycle)
+				}
+			})
+		}
+	}
+}
+
+func TestBellmanFord_RandomNegativeCycles(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, mode := range bellmanFordModes {
+		mode := mode
+		t.Run(mode.name, func(t *testing.T) {
+			for i := range 20 {
+				g := randomGraph(rng, true, 100, 400, -3, 20)
+				p, err := BellmanFord(g, 0, mode.cfg)
+				var cerr *NegativeCycleError
+				if !errors.As(err, &cerr) {
+					// No negative cycle reachable: the distances must
+					// hold up against relaxation.
+					if err != nil {
+						t.Fatalf("graph %d: %v", i, err)
+					}
+					checkShortestPaths(t, g, p, relaxAll(g, 0))
+					continue
+				}
+				m, weight := g.Matrix(), 0
+				for j, u := range cerr.Cycle {
+					w, ok := m.At(u, cerr.Cycle[(j+1)%len(cerr.Cycle)])
+					if !ok {
+						t.Fatalf("graph %d: cycle %v uses a missing edge", i, cerr.Cycle)
+					}
+					weight += w
+				}
+				if weight >= 0 {
+					t.Fatalf("graph %d: cycle %v weighs %d", i, cerr.Cycle, weight)
+				}
+			}
+		})
+	}
+}
+
+func TestBellmanFord_UnreachableCycleIsIgnored(t *testing.T) {
+	g := mustFromEdges(t, true, 4, edges([3]int{0, 1, -2}, [3]int{2, 3, -1}, [3]int{3, 2, -1}))
+	for _, mode := range bellmanFordModes {
+		p, err := BellmanFord(g, 0, mode.cfg)
+		if err != nil {
+			t.Fatalf("%s: %v", mode.name, err)
+		}
+		if p.Dist[1] != -2 || p.Reached(2) {
+			t.Errorf("%s: distance to 1 is %d, 2 reached %v", mode.name, p.Dist[1], p.Reached(2))
+		}
+	}
+}
+
+func BenchmarkBellmanFord(b *testing.B) {
+	g := reweighted(rand.New(rand.NewSource(1)), 2000, 10000)
+	for _, mode := range bellmanFordModes {
+		b.Run(mode.name, func(b *testing.B) {
+			for range b.N {
+				BellmanFord(g, 0, mode.cfg)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package graph
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+)
+
+// Blocks is the decomposition of an undirected graph into biconnected
+// components, the maximal sets of edges no single node removal separates.
+type Blocks[W Weight] struct {
+	// CutVertices holds the articulation points, in increasing order: the
+	// nodes whose removal disconnects the component they are in. They are
+	// exactly the nodes shared by several biconnected components.
+	CutVertices []int
+	// Bridges holds the edges whose removal disconnects their endpoints,
+	// from the smaller endpoint, in increasing order. They are exactly the
+	// biconnected components of a single edge.
+	Bridges []Edge[W]
+	// Components holds the edges of every biconnected component, each from
+	// its smaller endpoint, in increasing order. Self-loops belong to none.
+	Components [][]Edge[W]
+}
+
+// Nodes returns the nodes of biconnected component i, in increasing order.
+func (b *Blocks[W]) Nodes(i int) []int {
+	var nodes []int
+	for _, e := range b.Components[i] {
+		nodes = append(nodes, e.From, e.To)
+	}
+	slices.Sort(nodes)
+	return slices.Compact(nodes)
+}
+
+// Biconnected decomposes the undirected graph g into biconnected components,
+// finding its articulation points and bridges along the way, with the
+// low-link DFS of Hopcroft and Tarjan in O(n+m). Parallel edges are
+// told apart: two of them between the same nodes are no bridge.
+func Biconnected[N any, W Weight](g *Graph[N, W]) (*Blocks[W], error) {
+	if g.directed {
+		return nil, errors.New("graph: biconnected components of a directed graph")
+	}
+	n := g.Order()
+	disc := make([]int, n) // discovery index, 1-based, 0 if not discovered
+	low := make([]int, n)  // smallest index reachable through the subtree and one back edge
+	cut := make([]bool, n)
+	b := &Blocks[W]{}
+	var edges []Edge[W] // edges of the components not complete yet
+	next := 1
+
+	type frame struct {
+		u, parent int
+		edge      int  // index of the next edge of u to examine
+		skipped   bool // the edge back to the parent was skipped
+		children  int
+	}
+	var calls []frame
+	visit := func(u, parent int) {
+		disc[u], low[u] = next, next
+		next++
+		calls = append(calls, frame{u: u, parent: parent})
+	}
+	for s := range n {
+		if disc[s] != 0 {
+			continue
+		}
+		visit(s, -1)
+		for len(calls) > 0 {
+			f := &calls[len(calls)-1]
+			u := f.u
+			if f.edge < len(g.out[u]) {
+				e := g.out[u][f.edge]
+				f.edge++
+				switch v := e.To; {
+				case v == u:
+				case v == f.parent && !f.skipped:
+					f.skipped = true
+				case disc[v] == 0:
+					f.children++
+					edges = append(edges, e)
+					visit(v, u)
+				case disc[v] < disc[u]:
+					low[u] = min(low[u], disc[v])
+					edges = append(edges, e)
+				}
+				// Edges to descendants were examined from their other end.
+				continue
+			}
+
+			done := *f
+			calls = calls[:len(calls)-1]
+			if done.parent < 0 {
+				cut[u] = done.children > 1
+				continue
+			}
+			p := done.parent
+			low[p] = min(low[p], low[u])
+			if low[u] < disc[p] {
+				continue
+			}
+			// Nothing below u reaches above p: p separates the subtree of u
+			// from the rest, and the edges pushed since p-u form a
+			// component.
+			if calls[len(calls)-1].parent >= 0 {
+				cut[p] = true
+			}
+			i := len(edges) - 1
+			for edges[i].From != p || edges[i].To != u {
+				i--
+			}
+			component := make([]Edge[W], 0, len(edges)-i)
+			for _, e := range edges[i:] {
+				component = append(component, ordered(e))
+			}
+			edges = edges[:i]
+			slices.SortFunc(component, compareEdges)
+			b.Components = append(b.Components, component)
+			if low[u] > disc[p] {
+				b.Bridges = append(b.Bridges, component[0])
+			}
+		}
+	}
+	for v, ok := range cut {
+		if ok {
+			b.CutVertices = append(b.CutVertices, v)
+		}
+	}
+	slices.SortFunc(b.Bridges, compareEdges)
+	return b, nil
+}
+
+// ArticulationPoints returns the nodes whose removal disconnects the
+// component of the undirected graph g they are in, in increasing order.
+func ArticulationPoints[N any, W Weight](g *Graph[N, W]) ([]int, error) {
+	b, err := Biconnected(g)
+	if err != nil {
+		return nil, err
+	}
+	return b.CutVertices, nil
+}
+
+// Bridges returns the edges of the undirected graph g whose removal
+// disconnects their endpoints, from the smaller endpoint, in increasing
+// order.
+func Bridges[N any, W Weight](g *Graph[N, W]) ([]Edge[W], error) {
+	b, err := Biconnected(g)
+	if err != nil {
+		return nil, err
+	}
+	return b.Bridges, nil
+}
+
+// ordered returns the undirected edge e from its smaller endpoint.
+func ordered[W Weight](e Edge[W]) Edge[W] {
+	if e.From > e.To {
+		return e.reversed()
+	}
+	return e
+}
+
+func compareEdges[W Weight](a, b Edge[W]) int {
+	if c := cmp.Compare(a.From, b.From); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.To, b.To); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Weight, b.Weight)
+}
@@ -0,0 +1,162 @@
+package graph
+
+import (
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestBiconnected_KnownTopologies(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       int
+		edges   []Edge[int]
+		cuts    []int
+		bridges []Edge[int]
+		blocks  [][]int // nodes of every component
+	}{
+		{
+			"path", 4,
+			edges([3]int{0, 1, 1}, [3]int{1, 2, 1}, [3]int{2, 3, 1}),
+			[]int{1, 2},
+			edges([3]int{0, 1, 1}, [3]int{1, 2, 1}, [3]int{2, 3, 1}),
+			[][]int{{2, 3}, {1, 2}, {0, 1}},
+		},
+		{
+			"cycle", 5,
+			edges([3]int{0, 1, 1}, [3]int{1, 2, 1}, [3]int{2, 3, 1}, [3]int{3, 4, 1}, [3]int{4, 0, 1}),
+			nil, nil,
+			[][]int{{0, 1, 2, 3, 4}},
+		},
+		{
+			"star", 4,
+			edges([3]int{0, 1, 1}, [3]int{0, 2, 1}, [3]int{0, 3, 1}),
+			[]int{0},
+			edges([3]int{0, 1, 1}, [3]int{0, 2, 1}, [3]int{0, 3, 1}),
+			[][]int{{0, 1}, {0, 2}, {0, 3}},
+		},
+		{
+			"bowtie", 5,
+			edges([3]int{0, 1, 1}, [3]int{1, 2, 1}, [3]int{2, 0, 1}, [3]int{2, 3, 1}, [3]int{3, 4, 1}, [3]int{4, 2, 1}),
+			[]int{2}, nil,
+			[][]int{{2, 3, 4}, {0, 1, 2}},
+		},
+		{
+			// Two triangles joined by the bridge 2-3, plus an isolated node.
+			"barbell", 7,
+			edges([3]int{0, 1, 1}, [3]int{1, 2, 1}, [3]int{2, 0, 1}, [3]int{2, 3, 1}, [3]int{3, 4, 1}, [3]int{4, 5, 1}, [3]int{5, 3, 1}),
+			[]int{2, 3},
+			edges([3]int{2, 3, 1}),
+			[][]int{{3, 4, 5}, {2, 3}, {0, 1, 2}},
+		},
+		{
+			"parallel edges and a loop", 3,
+			edges([3]int{0, 1, 1}, [3]int{1, 0, 2}, [3]int{1, 2, 1}, [3]int{2, 2, 1}),
+			[]int{1},
+			edges([3]int{1, 2, 1}),
+			[][]int{{1, 2}, {0, 1}},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := mustFromEdges(t, false, tc.n, tc.edges)
+			b, err := Biconnected(g)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(b.CutVertices, tc.cuts) {
+				t.Errorf("cut vertices %v, want %v", b.CutVertices, tc.cuts)
+			}
+			if !reflect.DeepEqual(b.Bridges, tc.bridges) {
+				t.Errorf("bridges %v, want %v", b.Bridges, tc.bridges)
+			}
+			var blocks [][]int
+			for i := range b.Components {
+				blocks = append(blocks, b.Nodes(i))
+			}
+			if !reflect.DeepEqual(blocks, tc.blocks) {
+				t.Errorf("components %v, want %v", blocks, tc.blocks)
+			}
+		})
+	}
+}
+
+// componentCount returns the number of connected components of g without
+// node skip, -1 for none, and its first edge equal to skipEdge.
+func componentCount(g *Graph[struct{}, int], skip int, skipEdge Edge[int]) int {
+	h := NewUndirected[struct{}, int]()
+	h.AddNodes(g.Nodes()...)
+	dropped := false
+	for _, e := range g.Edges() {
+		if e.From == skip || e.To == skip {
+			continue
+		}
+		if !dropped && e == skipEdge {
+			dropped = true
+			continue
+		}
+		h.AddEdge(e.From, e.To, e.Weight)
+	}
+	count := 0
+	for _, p := range BFS(h, Visitor[int]{}).Parent {
+		if p < 0 {
+			count++
+		}
+	}
+	if skip >= 0 {
+		count-- // the removed node is left on its own
+	}
+	return count
+}
+
+func TestBiconnected_MatchesRemoval(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := range 30 {
+		g := randomGraph(rng, false, 30, 20+i, 1, 2)
+		b, err := Biconnected(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		base := componentCount(g, -1, Edge[int]{From: -1})
+		for v := range g.Order() {
+			isolated := g.OutDegree(v) == 0
+			want := componentCount(g, v, Edge[int]{From: -1}) > base-btoi(isolated)
+			if got := slices.Contains(b.CutVertices, v); got != want {
+				t.Fatalf("graph %d: node %d cut vertex %v, want %v", i, v, got, want)
+			}
+		}
+		for _, e := range g.Edges() {
+			want := componentCount(g, -1, e) > base
+			if got := slices.Contains(b.Bridges, ordered(e)); got != want {
+				t.Fatalf("graph %d: edge %v bridge %v, want %v", i, e, got, want)
+			}
+		}
+
+		// Every edge but self-loops is in exactly one component.
+		count := 0
+		for _, c := range b.Components {
+			count += len(c)
+		}
+		loops := 0
+		for _, e := range g.Edges() {
+			loops += btoi(e.From == e.To)
+		}
+		if count != g.Size()-loops {
+			t.Fatalf("graph %d: components hold %d edges, want %d", i, count, g.Size()-loops)
+		}
+	}
+}
+
+func TestBiconnected_Directed(t *testing.T) {
+	if _, err := Biconnected(NewDirected[struct{}, int]()); err == nil {
+		t.Error("expected an error for a directed graph")
+	}
+	if _, err := Bridges(NewDirected[struct{}, int]()); err == nil {
+		t.Error("expected an error for a directed graph")
+	}
+	if _, err := ArticulationPoints(NewDirected[struct{}, int]()); err == nil {
+		t.Error("expected an error for a directed graph")
+	}
+}
@@ -0,0 +1,155 @@
+package graph
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Coloring assigns every node a color so that adjacent nodes differ.
+type Coloring struct {
+	Colors []int // color of every node, from 0
+	Count  int   // colors used, an upper bound on the chromatic number
+}
+
+// Classes returns the nodes of every color, in increasing order.
+func (c *Coloring) Classes() [][]int {
+	classes := make([][]int, c.Count)
+	for v, color := range c.Colors {
+		classes[color] = append(classes[color], v)
+	}
+	return classes
+}
+
+// GreedyColoring colors g with the largest-first heuristic of Welsh and
+// Powell: nodes in decreasing order of degree, ties by ID, each taking the
+// smallest color none of its neighbors has. It uses at most one color more
+// than the largest degree, in O(n log n + m). Edge directions and weights
+// are ignored; a self-loop makes g impossible to color.
+func GreedyColoring[N any, W Weight](g *Graph[N, W]) (*Coloring, error) {
+	adj, err := coloringNeighbors(g)
+	if err != nil {
+		return nil, err
+	}
+	order := make([]int, len(adj))
+	for v := range order {
+		order[v] = v
+	}
+	slices.SortStableFunc(order, func(u, v int) int { return len(adj[v]) - len(adj[u]) })
+
+	c := newColoring(len(adj))
+	taken := make([]int, len(adj)+1) // node that last saw every color taken, plus one
+	for _, u := range order {
+		for _, v := range adj[u] {
+			if color := c.Colors[v]; color >= 0 {
+				taken[color] = u + 1
+			}
+		}
+		color := 0
+		for taken[color] == u+1 {
+			color++
+		}
+		c.set(u, color)
+	}
+	return c, nil
+}
+
+// DSatur colors g with the DSATUR heuristic of Brélaz: it colors next the
+// node whose neighbors have the most distinct colors, ties broken by
+// degree, then ID, giving it the smallest color none of them has. It colors
+// bipartite graphs with two colors and is often better than GreedyColoring
+// elsewhere, in O((n+m) log n). Edge directions and weights are ignored; a
+// self-loop makes g impossible to color.
+func DSatur[N any, W Weight](g *Graph[N, W]) (*Coloring, error) {
+	adj, err := coloringNeighbors(g)
+	if err != nil {
+		return nil, err
+	}
+	n := len(adj)
+	// Keyed by saturation, then degree, in a single int.
+	h := newIndexHeap[int](n)
+	h.max = true
+	for v := range n {
+		h.Set(v, len(adj[v]))
+	}
+	seen := make([]map[int]bool, n) // colors among the neighbors of every node
+	c := newColoring(n)
+	taken := make([]int, n+1) // node that last saw every color taken, plus one
+	for h.Len() > 0 {
+		u, _ := h.Pop()
+		for color := range seen[u] {
+			taken[color] = u + 1
+		}
+		color := 0
+		for taken[color] == u+1 {
+			color++
+		}
+		c.set(u, color)
+		for _, v := range adj[u] {
+			if c.Colors[v] >= 0 || seen[v][color] {
+				continue
+			}
+			if seen[v] == nil {
+				seen[v] = make(map[int]bool)
+			}
+			seen[v][color] = true
+			h.Set(v, h.Key(v)+n)
+		}
+	}
+	return c, nil
+}
+
+// CheckColoring returns an error if colors is not a valid coloring of g:
+// one color from 0 per node, with no edge between nodes of the same color.
+func CheckColoring[N any, W Weight](g *Graph[N, W], colors []int) error {
+	if len(colors) != g.Order() {
+		return fmt.Errorf("graph: %d colors for %d nodes", len(colors), g.Order())
+	}
+	for v, color := range colors {
+		if color < 0 {
+			return fmt.Errorf("graph: node %d of negative color %d", v, color)
+		}
+	}
+	for u, out := range g.out {
+		for _, e := range out {
+			if colors[u] == colors[e.To] {
+				return fmt.Errorf("graph: edge %v between nodes of color %d", e, colors[u])
+			}
+		}
+	}
+	return nil
+}
+
+func newColoring(n int) *Coloring {
+	c := &Coloring{Colors: make([]int, n)}
+	for v := range c.Colors {
+		c.Colors[v] = -1
+	}
+	return c
+}
+
+func (c *Coloring) set(v, color int) {
+	c.Colors[v] = color
+	c.Count = max(c.Count, color+1)
+}
+
+// coloringNeighbors returns the distinct neighbors of every node of g,
+// following edges both ways, or an error if g has a self-loop.
+func coloringNeighbors[N any, W Weight](g *Graph[N, W]) ([][]int, error) {
+	adj := make([][]int, g.Order())
+	for u, out := range g.out {
+		for _, e := range out {
+			if e.To == u {
+				return nil, fmt.Errorf("graph: coloring node %d with a self-loop", u)
+			}
+			adj[u] = append(adj[u], e.To)
+			if g.directed {
+				adj[e.To] = append(adj[e.To], u)
+			}
+		}
+	}
+	for u := range adj {
+		slices.Sort(adj[u])
+		adj[u] = slices.Compact(adj[u])
+	}
+	return adj, nil
+}
@@ -0,0 +1,232 @@
+package graph
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// colorings lists the coloring heuristics under test.
+var colorings = []struct {
+	name  string
+	color func(g *Graph[struct{}, int]) (*Coloring, error)
+}{
+	{"greedy", GreedyColoring[struct{}, int]},
+	{"dsatur", DSatur[struct{}, int]},
+}
+
+// chromaticNumber returns the least number of colors of g by trying every
+// coloring with k colors, for growing k.
+func chromaticNumber(g *Graph[struct{}, int]) int {
+	n := g.Order()
+	colors := make([]int, n)
+	var try func(v, k int) bool
+	try = func(v, k int) bool {
+		if v == n {
+			return true
+		}
+		for c := range k {
+			ok := true
+			for _, e := range g.Neighbors(v) {
+				ok = ok && (e.To >= v || colors[e.To] != c)
+			}
+			if ok {
+				colors[v] = c
+				if try(v+1, k) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	k := 0
+	for !try(0, k) {
+		k++
+	}
+	return k
+}
+
+// crown returns the crown graph of 2k nodes: u_i at 2i and v_i at 2i+1,
+// with u_i and v_j adjacent whenever i != j. It is bipartite, yet coloring
+// its nodes in ID order takes k colors.
+func crown(k int) *Graph[struct{}, int] {
+	g := NewUndirected[struct{}, int]()
+	g.AddNodes(make([]struct{}, 2*k)...)
+	for i := range k {
+		for j := range k {
+			if i != j {
+				g.AddEdge(2*i, 2*j+1, 1)
+			}
+		}
+	}
+	return g
+}
+
+func TestColoring_Known(t *testing.T) {
+	wheel := NewUndirected[struct{}, int]()
+	wheel.AddNodes(make([]struct{}, 6)...)
+	for i := range 5 {
+		wheel.AddEdge(5, i, 1)
+		wheel.AddEdge(i, (i+1)%5, 1)
+	}
+	petersen := NewUndirected[struct{}, int]()
+	petersen.AddNodes(make([]struct{}, 10)...)
+	for i := range 5 {
+		petersen.AddEdge(i, (i+1)%5, 1)
+		petersen.AddEdge(i, i+5, 1)
+		petersen.AddEdge(i+5, (i+2)%5+5, 1)
+	}
+	tests := []struct {
+		name   string
+		g      *Graph[struct{}, int]
+		greedy int
+		dsatur int
+	}{
+		{"even cycle", ringOfCliques(6, 1), 2, 2},
+		{"odd cycle", ringOfCliques(7, 1), 3, 3},
+		{"complete", ringOfCliques(1, 5), 5, 5},
+		{"wheel", wheel, 4, 4},
+		{"petersen", petersen, 3, 3},
+		{"crown", crown(5), 5, 2},
+		{"grid", grid(4, 5), 2, 2},
+		{"no edges", randomGraph(rand.New(rand.NewSource(1)), false, 4, 0, 1, 1), 1, 1},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			for _, alg := range colorings {
+				c, err := alg.color(tc.g)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := CheckColoring(tc.g, c.Colors); err != nil {
+					t.Fatalf("%s: %v", alg.name, err)
+				}
+				want := tc.greedy
+				if alg.name == "dsatur" {
+					want = tc.dsatur
+				}
+				if c.Count != want {
+					t.Errorf("%s: %d colors, want %d", alg.name, c.Count, want)
+				}
+			}
+		})
+	}
+}
+
+func TestColoring_Random(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for i := range 40 {
+		n := 2 + rng.Intn(10)
+		g := randomGraph(rng, i%2 == 0, n, rng.Intn(3*n), 1, 1)
+		for u := range n { // drop self-loops
+			for g.HasEdge(u, u) {
+				g.RemoveEdge(u, u)
+			}
+		}
+		chromatic := chromaticNumber(g.Undirected())
+		maxDegree := 0
+		adj, _ := coloringNeighbors(g)
+		for _, vs := range adj {
+			maxDegree = max(maxDegree, len(vs))
+		}
+		for _, alg := range colorings {
+			c, err := alg.color(g)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := CheckColoring(g, c.Colors); err != nil {
+				t.Fatalf("graph %d, %s: %v", i, alg.name, err)
+			}
+			if c.Count < chromatic || c.Count > maxDegree+1 {
+				t.Errorf("graph %d, %s: %d colors, chromatic number %d, largest degree %d", i, alg.name, c.Count, chromatic, maxDegree)
+			}
+			total := 0
+			for color, class := range c.Classes() {
+				total += len(class)
+				for _, v := range class {
+					if c.Colors[v] != color {
+						t.Errorf("graph %d, %s: node %d in class %d, of color %d", i, alg.name, v, color, c.Colors[v])
+					}
+				}
+			}
+			if total != n {
+				t.Errorf("graph %d, %s: %d nodes in the classes, want %d", i, alg.name, total, n)
+			}
+		}
+	}
+}
+
+func TestColoring_RegisterAllocation(t *testing.T) {
+	// Variables interfere when they are live at once, and then need
+	// different registers.
+	g := NewUndirected[string, int]()
+	vars := map[string]int{}
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		vars[name] = g.AddNode(name)
+	}
+	for _, live := range [][]string{{"a", "b", "c"}, {"b", "c", "d"}, {"d", "e"}, {"a", "e", "f"}} {
+		for i := range live {
+			for j := i + 1; j < len(live); j++ {
+				if !g.HasEdge(vars[live[i]], vars[live[j]]) {
+					g.AddEdge(vars[live[i]], vars[live[j]], 1)
+				}
+			}
+		}
+	}
+	c, err := DSatur(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckColoring(g, c.Colors); err != nil {
+		t.Fatal(err)
+	}
+	if c.Count != 3 {
+		t.Errorf("%d registers, want 3", c.Count)
+	}
+}
+
+func TestColoring_Errors(t *testing.T) {
+	g := mustFromEdges(t, false, 3, edges([3]int{0, 1, 1}, [3]int{1, 1, 1}))
+	if _, err := GreedyColoring(g); err == nil {
+		t.Error("greedy with a self-loop: no error")
+	}
+	if _, err := DSatur(g); err == nil {
+		t.Error("dsatur with a self-loop: no error")
+	}
+	path := mustFromEdges(t, true, 3, edges([3]int{0, 1, 1}, [3]int{1, 2, 1}))
+	tests := []struct {
+		name   string
+		colors []int
+	}{
+		{"too few", []int{0, 1}},
+		{"negative", []int{0, -1, 0}},
+		{"clash", []int{0, 1, 1}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if err := CheckColoring(path, tc.colors); err == nil {
+				t.Error("no error")
+			}
+		})
+	}
+	if err := CheckColoring(path, []int{0, 1, 0}); err != nil {
+		t.Error(err)
+	}
+}
+
+func BenchmarkColoring(b *testing.B) {
+	g := randomGraph(rand.New(rand.NewSource(1)), false, 10000, 100000, 1, 1)
+	for u := range g.Order() {
+		for g.HasEdge(u, u) {
+			g.RemoveEdge(u, u)
+		}
+	}
+	for _, alg := range colorings {
+		b.Run(alg.name, func(b *testing.B) {
+			for range b.N {
+				alg.color(g)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNegativeWeight is returned by algorithms that require non-negative edge
+// weights when they meet a negative one.
+var ErrNegativeWeight = errors.New("graph: negative edge weight")
+
+// ShortestPaths is a tree of shortest paths from a source.
+type ShortestPaths[W Weight] struct {
+	Source int
+	Dist   []W   // length of the shortest path to every node, zero where not reached
+	Pred   []int // predecessor of every node on its shortest path, -1 for the source and nodes not reached
+
+	reached []bool
+	via     []W // weight of the tree edge into every node
+}
+
+func newShortestPaths[W Weight](n, source int) *ShortestPaths[W] {
+	p := &ShortestPaths[W]{Source: source, Dist: make([]W, n), Pred: make([]int, n),
+		reached: make([]bool, n), via: make([]W, n)}
+	for i := range p.Pred {
+		p.Pred[i] = -1
+	}
+	return p
+}
+
+// Reached reports whether the shortest path to v is known: v is reachable
+// and, after an early exit, was settled before the exit.
+func (p *ShortestPaths[W]) Reached(v int) bool { return p.reached[v] }
+
+// PathTo returns the nodes of the shortest path from the source to v, or nil
+// if v was not reached.
+func (p *ShortestPaths[W]) PathTo(v int) []int {
+	if !p.reached[v] {
+		return nil
+	}
+	var path []int
+	for ; v >= 0; v = p.Pred[v] {
+		path = append(path, v)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// Tree returns the edges of the shortest-path tree, into every reached node
+// but the source, in node order.
+func (p *ShortestPaths[W]) Tree() []Edge[W] {
+	var tree []Edge[W]
+	for v, u := range p.Pred {
+		if u >= 0 && p.reached[v] {
+			tree = append(tree, Edge[W]{From: u, To: v, Weight: p.via[v]})
+		}
+	}
+	return tree
+}
+
+// Dijkstra returns the shortest paths from source to every node of g, whose
+// edge weights must not be negative. It runs in O((n+m) log n) with an
+// indexed heap holding every node at most once.
+func Dijkstra[N any, W Weight](g *Graph[N, W], source int) (*ShortestPaths[W], error) {
+	return dijkstra(g, source, -1)
+}
+
+// DijkstraTo is Dijkstra stopping as soon as the shortest path to target is
+// known. Only the nodes settled before, at least as close as target, are
+// reached in the result.
+func DijkstraTo[N any, W Weight](g *Graph[N, W], source, target int) (*ShortestPaths[W], error) {
+	g.mustExist(target)
+	return dijkstra(g, source, target)
+}
+
+func dijkstra[N any, W Weight](g *Graph[N, W], source, target int) (*ShortestPaths[W], error) {
+	g.mustExist(source)
+	p := newShortestPaths[W](g.Order(), source)
+	h := newIndexHeap[W](g.Order())
+	h.Set(source, 0)
+	for h.Len() > 0 {
+		u, d := h.Pop()
+		p.reached[u], p.Dist[u] = true, d
+		if u == target {
+			break
+		}
+		for _, e := range g.out[u] {
+			if e.Weight < 0 {
+				return nil, fmt.Errorf("edge %v: %w", e, ErrNegativeWeight)
+			}
+			v := e.To
+			if p.reached[v] {
+				continue
+			}
+			if nd := d + e.Weight; !h.Contains(v) || nd < h.Key(v) {
+				h.Set(v, nd)
+				p.Pred[v], p.via[v] = u, e.Weight
+			}
+		}
+	}
+	for v, ok := range p.reached {
+		if !ok {
+			p.Pred[v], p.via[v] = -1, 0 // left tentative by an early exit
+		}
+	}
+	return p, nil
+}
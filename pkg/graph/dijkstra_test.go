@@ -0,0 +1,157 @@
+package graph
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// relaxAll returns the distances from source computed by relaxing every edge
+// n times, the brute force reference for the shortest-path algorithms.
+func relaxAll(g *Graph[struct{}, int], source int) []int {
+	const inf = math.MaxInt
+	dist := make([]int, g.Order())
+	for i := range dist {
+		dist[i] = inf
+	}
+	dist[source] = 0
+	edges := g.Edges()
+	if !g.Directed() {
+		for _, e := range g.Edges() {
+			edges = append(edges, e.reversed())
+		}
+	}
+	for range g.Order() {
+		for _, e := range edges {
+			if dist[e.From] != inf && dist[e.From]+e.Weight < dist[e.To] {
+				dist[e.To] = dist[e.From] + e.Weight
+			}
+		}
+	}
+	return dist
+}
+
+// checkShortestPaths verifies p against the reference distances want, where
+// math.MaxInt marks unreachable nodes.
+func checkShortestPaths(t *testing.T, g *Graph[struct{}, int], p *ShortestPaths[int], want []int) {
+	t.Helper()
+	m := g.Matrix() // the lightest of parallel edges
+	for v, d := range want {
+		if p.Reached(v) != (d != math.MaxInt) {
+			t.Fatalf("node %d: reached %v, reference distance %d", v, p.Reached(v), d)
+		}
+		if !p.Reached(v) {
+			continue
+		}
+		if p.Dist[v] != d {
+			t.Fatalf("node %d: distance %d, want %d", v, p.Dist[v], d)
+		}
+		path, length := p.PathTo(v), 0
+		for i := 1; i < len(path); i++ {
+			w, ok := m.At(path[i-1], path[i])
+			if !ok {
+				t.Fatalf("path %v to %d uses a missing edge", path, v)
+			}
+			length += w
+		}
+		if path[0] != p.Source || length > d {
+			t.Fatalf("path %v to %d of length %d, want %d from %d", path, v, length, d, p.Source)
+		}
+	}
+}
+
+func TestDijkstra_MatchesRelaxation(t *testing.T) {
+	tests := []struct {
+		name     string
+		directed bool
+		n, m     int
+	}{
+		{"sparse directed", true, 100, 200},
+		{"dense directed", true, 40, 1200},
+		{"sparse undirected", false, 100, 150},
+		{"disconnected", true, 100, 60},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(int64(tc.n + tc.m)))
+			g := randomGraph(rng, tc.directed, tc.n, tc.m, 0, 20)
+			for _, source := range []int{0, tc.n / 2} {
+				p, err := Dijkstra(g, source)
+				if err != nil {
+					t.Fatal(err)
+				}
+				checkShortestPaths(t, g, p, relaxAll(g, source))
+				for _, e := range p.Tree() {
+					if p.Dist[e.From]+e.Weight != p.Dist[e.To] || p.Pred[e.To] != e.From {
+						t.Fatalf("tree edge %v is not tight", e)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDijkstraTo_EarlyExit(t *testing.T) {
+	// A path 0-1-2-...-9 of unit edges with a shortcut 0-9 of weight 3.
+	g := NewUndirected[struct{}, int]()
+	g.AddNodes(make([]struct{}, 10)...)
+	for u := range 9 {
+		g.AddEdge(u, u+1, 1)
+	}
+	g.AddEdge(0, 9, 3)
+
+	p, err := DijkstraTo(g, 0, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{0, 9, 8, 7}; !reflect.DeepEqual(p.PathTo(7), want) || p.Dist[7] != 5 {
+		t.Errorf("path %v of length %d, want %v of length 5", p.PathTo(7), p.Dist[7], want)
+	}
+	// Node 5 is at distance 5 as well and wins the tie against 7 by ID;
+	// node 6, farther, is left unsettled.
+	for v, want := range []bool{true, true, true, true, true, true, false, true, true, true} {
+		if p.Reached(v) != want {
+			t.Errorf("node %d: reached %v, want %v", v, p.Reached(v), want)
+		}
+	}
+	if p.Pred[6] != -1 || p.PathTo(6) != nil {
+		t.Errorf("unsettled node 6 has predecessor %d", p.Pred[6])
+	}
+	if got := len(p.Tree()); got != 8 {
+		t.Errorf("tree of %d edges, want 8", got)
+	}
+}
+
+func TestDijkstra_FloatWeights(t *testing.T) {
+	g := NewDirected[string, float64]()
+	g.AddNodes("a", "b", "c")
+	g.AddEdge(0, 1, 0.25)
+	g.AddEdge(1, 2, 0.5)
+	g.AddEdge(0, 2, 1)
+	p, err := Dijkstra(g, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Dist[2] != 0.75 || !reflect.DeepEqual(p.PathTo(2), []int{0, 1, 2}) {
+		t.Errorf("got %v via %v", p.Dist[2], p.PathTo(2))
+	}
+}
+
+func TestDijkstra_NegativeWeight(t *testing.T) {
+	g := mustFromEdges(t, true, 3, edges([3]int{0, 1, 2}, [3]int{1, 2, -1}))
+	if _, err := Dijkstra(g, 0); !errors.Is(err, ErrNegativeWeight) {
+		t.Errorf("expected ErrNegativeWeight, got %v", err)
+	}
+}
+
+func BenchmarkDijkstra(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	g := randomGraph(rng, true, 10000, 50000, 1, 100)
+	b.ResetTimer()
+	for range b.N {
+		Dijkstra(g, 0)
+	}
+}
@@ -0,0 +1,378 @@
+// Package graph implements directed and undirected graphs whose nodes carry
+// a payload and whose edges carry a numeric weight, in the two classic
+// representations:
+//
+//   - Graph is an adjacency list: O(n+m) space, neighbors in O(degree).
+//     It is what the algorithms of this package work on.
+//   - Matrix is an adjacency matrix: O(n²) space, edge lookups in O(1).
+//     It suits dense graphs and algorithms that index by node pairs.
+//
+// Nodes are identified by dense integers 0..n-1, in the order they were
+// added, so algorithms can keep their per-node state in slices. Parallel
+// edges and self-loops are allowed in a Graph; a Matrix holds at most one
+// edge per ordered pair.
+//
+// On top of them come the algorithms:
+//
+//   - BFS and DFS traverse a graph, calling Visitor hooks, and return the
+//     search forest to reconstruct paths from.
+//   - Dijkstra and DijkstraTo find shortest paths from a source over
+//     non-negative weights.
+//   - BellmanFord finds shortest paths over any weights, or a negative
+//     cycle that rules them out, optionally with the SPFA queue.
+//   - TopologicalSort (Kahn) and TopologicalSortDFS order a DAG, or report
+//     a cycle; AllTopologicalOrders lists every order of a small one.
+//   - StronglyConnected (Tarjan) splits a graph into strongly connected
+//     components, and Condensation turns them into a DAG.
+//   - Biconnected splits an undirected graph into biconnected components,
+//     finding its articulation points and bridges.
+//   - Kruskal, Prim and LazyPrim find a minimum or maximum spanning forest
+//     of an undirected graph. Eager Prim is the fastest on dense graphs.
+//   - PageRank ranks nodes by the stationary distribution of a random
+//     surfer, optionally iterating on several goroutines.
+//   - Louvain detects communities of an undirected graph by maximizing
+//     modularity, returning the hierarchy of partitions it passes through;
+//     Modularity scores any partition.
+//   - GreedyColoring (largest first) and DSatur color the nodes so that
+//     adjacent ones differ, bounding the chromatic number from above;
+//     CheckColoring validates any coloring.
+//   - LiftingLCA (binary lifting) and EulerLCA (Euler tour and sparse
+//     table) find lowest common ancestors in a rooted forest, such as the
+//     parents of a Traversal, and with them distances along the tree.
+//
+// References:
+//
+// Cormen, Leiserson, Rivest and Stein, Introduction to Algorithms, 3rd
+// edition, 2009, sections 21.3, 22.1 to 22.5, 23.2, 24.1 and 24.3.
+//
+// Sedgewick and Wayne, Algorithms, 4th edition, 2011, sections 4.1 and 4.2.
+//
+// Dijkstra, A Note on Two Problems in Connexion with Graphs, Numerische
+// Mathematik 1, 1959.
+//
+// Bellman, On a Routing Problem, Quarterly of Applied Mathematics 16(1),
+// 1958.
+//
+// Cherkassky and Goldberg, Negative-Cycle Detection Algorithms, Mathematical
+// Programming 85(2), 1999.
+//
+// Kahn, Topological Sorting of Large Networks, Communications of the ACM
+// 5(11), 1962.
+//
+// Knuth and Szwarcfiter, A Structured Program to Generate All Topological
+// Sorting Arrangements, Information Processing Letters 2(6), 1974.
+//
+// Tarjan, Depth-First Search and Linear Graph Algorithms, SIAM Journal on
+// Computing 1(2), 1972.
+//
+// Hopcroft and Tarjan, Algorithm 447: Efficient Algorithms for Graph
+// Manipulation, Communications of the ACM 16(6), 1973.
+//
+// Kruskal, On the Shortest Spanning Subtree of a Graph and the Traveling
+// Salesman Problem, Proceedings of the American Mathematical Society 7(1),
+// 1956.
+//
+// Prim, Shortest Connection Networks and Some Generalizations, Bell System
+// Technical Journal 36(6), 1957.
+//
+// Tarjan and van Leeuwen, Worst-Case Analysis of Set Union Algorithms,
+// Journal of the ACM 31(2), 1984.
+//
+// Page, Brin, Motwani and Winograd, The PageRank Citation Ranking: Bringing
+// Order to the Web, Stanford InfoLab technical report, 1999.
+//
+// Newman and Girvan, Finding and Evaluating Community Structure in
+// Networks, Physical Review E 69(2), 2004.
+//
+// Blondel, Guillaume, Lambiotte and Lefebvre, Fast Unfolding of Communities
+// in Large Networks, Journal of Statistical Mechanics, 2008.
+//
+// Welsh and Powell, An Upper Bound for the Chromatic Number of a Graph and
+// Its Application to Timetabling Problems, The Computer Journal 10(1), 1967.
+//
+// Brélaz, New Methods to Color the Vertices of a Graph, Communications of
+// the ACM 22(4), 1979.
+//
+// Bender and Farach-Colton, The LCA Problem Revisited, Latin American
+// Symposium on Theoretical Informatics, 2000.
+package graph
+
+import "fmt"
+
+// Weight is the type of edge weights.
+type Weight interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// Edge is an edge from From to To. Edges of undirected graphs are reported
+// from the node whose neighbors are listed.
+type Edge[W Weight] struct {
+	From   int
+	To     int
+	Weight W
+}
+
+func (e Edge[W]) String() string {
+	return fmt.Sprintf("%d->%d (%v)", e.From, e.To, e.Weight)
+}
+
+// reversed returns the edge pointing the other way.
+func (e Edge[W]) reversed() Edge[W] {
+	return Edge[W]{From: e.To, To: e.From, Weight: e.Weight}
+}
+
+// Graph is a graph stored as adjacency lists, with payloads of type N on
+// its nodes and weights of type W on its edges.
+type Graph[N any, W Weight] struct {
+	directed bool
+	nodes    []N
+	out      [][]Edge[W] // out[u] holds the edges leaving u
+	in       [][]Edge[W] // in[v] holds the edges entering v, directed graphs only
+	size     int
+}
+
+// NewDirected returns an empty directed graph.
+func NewDirected[N any, W Weight]() *Graph[N, W] {
+	return &Graph[N, W]{directed: true}
+}
+
+// NewUndirected returns an empty undirected graph.
+func NewUndirected[N any, W Weight]() *Graph[N, W] {
+	return &Graph[N, W]{}
+}
+
+// FromEdges returns a graph of n nodes with zero payloads and the given
+// edges.
+func FromEdges[N any, W Weight](directed bool, n int, edges []Edge[W]) (*Graph[N, W], error) {
+	g := &Graph[N, W]{directed: directed}
+	g.AddNodes(make([]N, n)...)
+	for _, e := range edges {
+		if !g.valid(e.From) || !g.valid(e.To) {
+			return nil, fmt.Errorf("edge %v: node out of range [0, %d)", e, n)
+		}
+		g.AddEdge(e.From, e.To, e.Weight)
+	}
+	return g, nil
+}
+
+// Directed reports whether the graph is directed.
+func (g *Graph[N, W]) Directed() bool { return g.directed }
+
+// Order returns the number of nodes.
+func (g *Graph[N, W]) Order() int { return len(g.nodes) }
+
+// Size returns the number of edges. An undirected edge counts once.
+func (g *Graph[N, W]) Size() int { return g.size }
+
+// AddNode adds a node carrying payload and returns its ID.
+func (g *Graph[N, W]) AddNode(payload N) int {
+	g.nodes = append(g.nodes, payload)
+	g.out = append(g.out, nil)
+	if g.directed {
+		g.in = append(g.in, nil)
+	}
+	return len(g.nodes) - 1
+}
+
+// AddNodes adds a node per payload and returns the ID of the first one; the
+// others follow consecutively.
+func (g *Graph[N, W]) AddNodes(payloads ...N) int {
+	first := len(g.nodes)
+	for _, p := range payloads {
+		g.AddNode(p)
+	}
+	return first
+}
+
+// Node returns the payload of node u.
+func (g *Graph[N, W]) Node(u int) N { return g.nodes[u] }
+
+// SetNode replaces the payload of node u.
+func (g *Graph[N, W]) SetNode(u int, payload N) { g.nodes[u] = payload }
+
+// Nodes returns the payloads of all nodes, indexed by ID.
+func (g *Graph[N, W]) Nodes() []N {
+	return append([]N(nil), g.nodes...)
+}
+
+// AddEdge adds an edge from u to v of weight w. In an undirected graph the
+// edge also leads from v to u. Both nodes must exist.
+func (g *Graph[N, W]) AddEdge(u, v int, w W) {
+	g.mustExist(u)
+	g.mustExist(v)
+	e := Edge[W]{From: u, To: v, Weight: w}
+	g.out[u] = append(g.out[u], e)
+	switch {
+	case g.directed:
+		g.in[v] = append(g.in[v], e)
+	case u != v:
+		g.out[v] = append(g.out[v], e.reversed())
+	}
+	g.size++
+}
+
+// RemoveEdge removes one edge from u to v, the earliest added, and reports
+// whether there was one.
+func (g *Graph[N, W]) RemoveEdge(u, v int) bool {
+	g.mustExist(u)
+	g.mustExist(v)
+	i := index(g.out[u], v)
+	if i < 0 {
+		return false
+	}
+	e := g.out[u][i]
+	g.out[u] = remove(g.out[u], i)
+	switch {
+	case g.directed:
+		g.in[v] = remove(g.in[v], indexEdge(g.in[v], e))
+	case u != v:
+		g.out[v] = remove(g.out[v], indexEdge(g.out[v], e.reversed()))
+	}
+	g.size--
+	return true
+}
+
+// HasEdge reports whether there is an edge from u to v.
+func (g *Graph[N, W]) HasEdge(u, v int) bool {
+	return index(g.out[u], v) >= 0
+}
+
+// Weight returns the weight of the earliest added edge from u to v, and
+// whether there is one.
+func (g *Graph[N, W]) Weight(u, v int) (W, bool) {
+	if i := index(g.out[u], v); i >= 0 {
+		return g.out[u][i].Weight, true
+	}
+	var zero W
+	return zero, false
+}
+
+// Neighbors returns the edges leaving u, in the order they were added. The
+// slice is owned by the graph and must not be modified.
+func (g *Graph[N, W]) Neighbors(u int) []Edge[W] { return g.out[u] }
+
+// InEdges returns the edges entering v. In an undirected graph these are the
+// edges of Neighbors, reversed. The slice must not be modified.
+func (g *Graph[N, W]) InEdges(v int) []Edge[W] {
+	if g.directed {
+		return g.in[v]
+	}
+	in := make([]Edge[W], len(g.out[v]))
+	for i, e := range g.out[v] {
+		in[i] = e.reversed()
+	}
+	return in
+}
+
+// OutDegree returns the number of edges leaving u. A self-loop of an
+// undirected graph counts once.
+func (g *Graph[N, W]) OutDegree(u int) int { return len(g.out[u]) }
+
+// InDegree returns the number of edges entering v.
+func (g *Graph[N, W]) InDegree(v int) int {
+	if g.directed {
+		return len(g.in[v])
+	}
+	return len(g.out[v])
+}
+
+// Edges returns every edge once, grouped by the node they leave. An
+// undirected edge is reported from its smaller endpoint.
+func (g *Graph[N, W]) Edges() []Edge[W] {
+	edges := make([]Edge[W], 0, g.size)
+	for u, out := range g.out {
+		for _, e := range out {
+			if g.directed || u <= e.To {
+				edges = append(edges, e)
+			}
+		}
+	}
+	return edges
+}
+
+// Clone returns a deep copy of the graph. Payloads are copied by value.
+func (g *Graph[N, W]) Clone() *Graph[N, W] {
+	c := &Graph[N, W]{directed: g.directed, nodes: append([]N(nil), g.nodes...), size: g.size}
+	c.out = cloneLists(g.out)
+	if g.directed {
+		c.in = cloneLists(g.in)
+	}
+	return c
+}
+
+// Reverse returns a copy of the graph with every edge reversed. The reverse
+// of an undirected graph is a copy of it.
+func (g *Graph[N, W]) Reverse() *Graph[N, W] {
+	if !g.directed {
+		return g.Clone()
+	}
+	r := &Graph[N, W]{directed: true, nodes: append([]N(nil), g.nodes...), size: g.size}
+	r.out = make([][]Edge[W], len(g.nodes))
+	r.in = make([][]Edge[W], len(g.nodes))
+	for u := range g.nodes {
+		for _, e := range g.in[u] {
+			r.out[u] = append(r.out[u], e.reversed())
+		}
+		for _, e := range g.out[u] {
+			r.in[u] = append(r.in[u], e.reversed())
+		}
+	}
+	return r
+}
+
+// Undirected returns the graph with the direction of its edges forgotten:
+// every edge u->v becomes an undirected edge u-v, so opposite edges become
+// parallel edges. An undirected graph is returned as a copy.
+func (g *Graph[N, W]) Undirected() *Graph[N, W] {
+	if !g.directed {
+		return g.Clone()
+	}
+	ug := &Graph[N, W]{nodes: append([]N(nil), g.nodes...), out: make([][]Edge[W], len(g.nodes))}
+	for _, e := range g.Edges() {
+		ug.AddEdge(e.From, e.To, e.Weight)
+	}
+	return ug
+}
+
+func (g *Graph[N, W]) valid(u int) bool { return u >= 0 && u < len(g.nodes) }
+
+func (g *Graph[N, W]) mustExist(u int) {
+	if !g.valid(u) {
+		panic(fmt.Sprintf("graph: node %d out of range [0, %d)", u, len(g.nodes)))
+	}
+}
+
+// index returns the position of the first edge to v in edges, or -1.
+func index[W Weight](edges []Edge[W], v int) int {
+	for i, e := range edges {
+		if e.To == v {
+			return i
+		}
+	}
+	return -1
+}
+
+// indexEdge returns the position of the first edge equal to e, or -1.
+func indexEdge[W Weight](edges []Edge[W], e Edge[W]) int {
+	for i, f := range edges {
+		if f == e {
+			return i
+		}
+	}
+	return -1
+}
+
+// remove deletes edges[i], keeping the order of the others.
+func remove[W Weight](edges []Edge[W], i int) []Edge[W] {
+	return append(edges[:i], edges[i+1:]...)
+}
+
+func cloneLists[W Weight](lists [][]Edge[W]) [][]Edge[W] {
+	c := make([][]Edge[W], len(lists))
+	for i, l := range lists {
+		c[i] = append([]Edge[W](nil), l...)
+	}
+	return c
+}
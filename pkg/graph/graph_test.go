@@ -0,0 +1,192 @@
+package graph
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func edges(pairs ...[3]int) []Edge[int] {
+	out := make([]Edge[int], len(pairs))
+	for i, p := range pairs {
+		out[i] = Edge[int]{From: p[0], To: p[1], Weight: p[2]}
+	}
+	return out
+}
+
+// randomGraph returns a graph of n nodes and m random edges of weights in
+// [lo, hi], parallel edges and self-loops included.
+func randomGraph(rng *rand.Rand, directed bool, n, m, lo, hi int) *Graph[struct{}, int] {
+	g := NewUndirected[struct{}, int]()
+	if directed {
+		g = NewDirected[struct{}, int]()
+	}
+	g.AddNodes(make([]struct{}, n)...)
+	for range m {
+		g.AddEdge(rng.Intn(n), rng.Intn(n), lo+rng.Intn(hi-lo+1))
+	}
+	return g
+}
+
+func mustFromEdges(t *testing.T, directed bool, n int, e []Edge[int]) *Graph[string, int] {
+	t.Helper()
+	g, err := FromEdges[string](directed, n, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestGraph_Construction(t *testing.T) {
+	g := NewDirected[string, float64]()
+	a := g.AddNode("a")
+	b := g.AddNodes("b", "c", "d")
+	if a != 0 || b != 1 || g.Order() != 4 {
+		t.Fatalf("got IDs %d and %d and order %d", a, b, g.Order())
+	}
+	g.AddEdge(0, 1, 1.5)
+	g.AddEdge(0, 2, 2)
+	g.AddEdge(2, 2, 3)
+	g.SetNode(3, "D")
+
+	if g.Size() != 3 || !g.Directed() {
+		t.Errorf("got size %d, directed %v", g.Size(), g.Directed())
+	}
+	if got := g.Nodes(); !reflect.DeepEqual(got, []string{"a", "b", "c", "D"}) {
+		t.Errorf("unexpected payloads %v", got)
+	}
+	if w, ok := g.Weight(0, 2); !ok || w != 2 {
+		t.Errorf("Weight(0, 2) = %v, %v", w, ok)
+	}
+	if g.HasEdge(1, 0) {
+		t.Error("directed edge 0->1 must not lead back")
+	}
+	if g.OutDegree(0) != 2 || g.InDegree(2) != 2 || g.InDegree(3) != 0 {
+		t.Errorf("unexpected degrees %d %d %d", g.OutDegree(0), g.InDegree(2), g.InDegree(3))
+	}
+}
+
+func TestGraph_DirectedAndUndirected(t *testing.T) {
+	e := edges([3]int{0, 1, 5}, [3]int{1, 2, 6}, [3]int{2, 2, 7}, [3]int{2, 0, 8})
+	tests := []struct {
+		name      string
+		directed  bool
+		neighbors [][]int // targets of the edges leaving each node
+		in        []int   // in-degree of each node
+	}{
+		{"directed", true, [][]int{{1}, {2}, {2, 0}}, []int{1, 1, 2}},
+		{"undirected", false, [][]int{{1, 2}, {0, 2}, {1, 2, 0}}, []int{2, 2, 3}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := mustFromEdges(t, tc.directed, 3, e)
+			for u, want := range tc.neighbors {
+				var got []int
+				for _, e := range g.Neighbors(u) {
+					if e.From != u {
+						t.Errorf("edge %v listed among the neighbors of %d", e, u)
+					}
+					got = append(got, e.To)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("neighbors of %d: got %v, want %v", u, got, want)
+				}
+				if d := g.InDegree(u); d != tc.in[u] || len(g.InEdges(u)) != d {
+					t.Errorf("in-degree of %d: got %d with %d in-edges, want %d", u, d, len(g.InEdges(u)), tc.in[u])
+				}
+				for _, e := range g.InEdges(u) {
+					if e.To != u {
+						t.Errorf("edge %v listed among the in-edges of %d", e, u)
+					}
+				}
+			}
+			if got := g.Edges(); len(got) != len(e) || g.Size() != len(e) {
+				t.Errorf("got edges %v and size %d, want %d edges", got, g.Size(), len(e))
+			}
+		})
+	}
+}
+
+func TestGraph_RemoveEdge(t *testing.T) {
+	tests := []struct {
+		name     string
+		directed bool
+	}{
+		{"directed", true},
+		{"undirected", false},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := mustFromEdges(t, tc.directed, 3, edges([3]int{0, 1, 1}, [3]int{0, 1, 2}, [3]int{1, 2, 3}, [3]int{1, 1, 4}))
+			if !g.RemoveEdge(0, 1) {
+				t.Fatal("expected an edge 0->1 to remove")
+			}
+			if w, ok := g.Weight(0, 1); !ok || w != 2 {
+				t.Errorf("expected the parallel edge of weight 2 to remain, got %v, %v", w, ok)
+			}
+			if !g.RemoveEdge(1, 1) || g.HasEdge(1, 1) {
+				t.Error("expected the self-loop to be removed")
+			}
+			if g.RemoveEdge(2, 0) {
+				t.Error("removed a missing edge")
+			}
+			if got := g.Edges(); !reflect.DeepEqual(got, edges([3]int{0, 1, 2}, [3]int{1, 2, 3})) || g.Size() != 2 {
+				t.Errorf("unexpected edges %v, size %d", got, g.Size())
+			}
+			if d := g.InDegree(1); d != 1+btoi(!tc.directed) {
+				t.Errorf("in-degree of 1 is %d after removals", d)
+			}
+		})
+	}
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestGraph_ReverseAndUndirected(t *testing.T) {
+	g := mustFromEdges(t, true, 3, edges([3]int{0, 1, 1}, [3]int{1, 2, 2}, [3]int{2, 1, 3}))
+	r := g.Reverse()
+	if got, want := r.Edges(), edges([3]int{1, 0, 1}, [3]int{1, 2, 3}, [3]int{2, 1, 2}); !reflect.DeepEqual(got, want) {
+		t.Errorf("reverse: got %v, want %v", got, want)
+	}
+	if r.InDegree(0) != 1 || r.OutDegree(0) != 0 {
+		t.Errorf("reverse: node 0 has in-degree %d and out-degree %d", r.InDegree(0), r.OutDegree(0))
+	}
+
+	u := g.Undirected()
+	if u.Directed() || u.Size() != 3 || u.OutDegree(1) != 3 {
+		t.Errorf("undirected: directed %v, size %d, degree of 1 is %d", u.Directed(), u.Size(), u.OutDegree(1))
+	}
+	if !u.HasEdge(1, 0) {
+		t.Error("undirected: expected 1-0")
+	}
+}
+
+func TestGraph_CloneIsIndependent(t *testing.T) {
+	g := mustFromEdges(t, true, 2, edges([3]int{0, 1, 1}))
+	c := g.Clone()
+	c.AddEdge(1, 0, 2)
+	c.SetNode(0, "changed")
+	c.RemoveEdge(0, 1)
+	if g.Size() != 1 || !g.HasEdge(0, 1) || g.HasEdge(1, 0) || g.Node(0) != "" || g.InDegree(1) != 1 {
+		t.Errorf("changing the clone changed the original: %v", g.Edges())
+	}
+}
+
+func TestFromEdges_OutOfRange(t *testing.T) {
+	if _, err := FromEdges[struct{}](true, 2, edges([3]int{0, 2, 1})); err == nil {
+		t.Error("expected an error for an edge to a missing node")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("expected AddEdge to panic on a missing node")
+		}
+	}()
+	NewUndirected[int, int]().AddEdge(0, 0, 1)
+}
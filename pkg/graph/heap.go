@@ -0,0 +1,94 @@
+package graph
+
+// indexHeap is a binary min-heap of nodes keyed by a weight, which can find a
+// node to change its key in O(log n). Ties are broken by node ID, so the
+// order of pops is deterministic.
+type indexHeap[K Weight] struct {
+	items []int // nodes in heap order
+	pos   []int // position of every node in items, -1 if absent
+	key   []K
+	max   bool // pop the largest key first instead
+}
+
+func newIndexHeap[K Weight](n int) *indexHeap[K] {
+	h := &indexHeap[K]{pos: make([]int, n), key: make([]K, n)}
+	for i := range h.pos {
+		h.pos[i] = -1
+	}
+	return h
+}
+
+func (h *indexHeap[K]) Len() int { return len(h.items) }
+
+func (h *indexHeap[K]) Contains(v int) bool { return h.pos[v] >= 0 }
+
+// Key returns the key of v, which must be in the heap.
+func (h *indexHeap[K]) Key(v int) K { return h.key[v] }
+
+// Set inserts v with key k, or changes the key of v to k.
+func (h *indexHeap[K]) Set(v int, k K) {
+	h.key[v] = k
+	i := h.pos[v]
+	if i < 0 {
+		i = len(h.items)
+		h.items = append(h.items, v)
+		h.pos[v] = i
+	}
+	h.up(i)
+	h.down(h.pos[v])
+}
+
+// Pop removes and returns the node of smallest key.
+func (h *indexHeap[K]) Pop() (int, K) {
+	v := h.items[0]
+	last := len(h.items) - 1
+	h.swap(0, last)
+	h.items = h.items[:last]
+	h.pos[v] = -1
+	if last > 0 {
+		h.down(0)
+	}
+	return v, h.key[v]
+}
+
+func (h *indexHeap[K]) less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	if h.key[a] != h.key[b] {
+		return (h.key[a] < h.key[b]) != h.max
+	}
+	return a < b
+}
+
+func (h *indexHeap[K]) swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.pos[h.items[i]] = i
+	h.pos[h.items[j]] = j
+}
+
+func (h *indexHeap[K]) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !h.less(i, parent) {
+			return
+		}
+		h.swap(i, parent)
+		i = parent
+	}
+}
+
+func (h *indexHeap[K]) down(i int) {
+	n := len(h.items)
+	for {
+		least := i
+		for _, c := range [2]int{2*i + 1, 2*i + 2} {
+			if c < n && h.less(c, least) {
+				least = c
+			}
+		}
+		if least == i {
+			return
+		}
+		h.swap(i, least)
+		i = least
+	}
+}
@@ -0,0 +1,40 @@
+package graph
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestIndexHeap_MatchesSort(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	const n = 200
+	h := newIndexHeap[int](n)
+	keys := map[int]int{}
+	for range 2000 {
+		v, k := rng.Intn(n), rng.Intn(50)
+		h.Set(v, k)
+		keys[v] = k
+	}
+	if h.Len() != len(keys) {
+		t.Fatalf("heap of %d nodes, want %d", h.Len(), len(keys))
+	}
+
+	type item struct{ key, v int }
+	var want []item
+	for v, k := range keys {
+		want = append(want, item{k, v})
+	}
+	slices.SortFunc(want, func(a, b item) int {
+		if a.key != b.key {
+			return a.key - b.key
+		}
+		return a.v - b.v
+	})
+	for i, w := range want {
+		v, k := h.Pop()
+		if v != w.v || k != w.key || h.Contains(v) {
+			t.Fatalf("pop %d: got node %d key %d, want node %d key %d", i, v, k, w.v, w.key)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package graph
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// rootedForest is a forest given by the parent of every node, -1 for the
+// roots, as in Traversal.Parent and ShortestPaths.Pred.
+type rootedForest struct {
+	parent []int
+	depth  []int // edges up to the root
+	root   []int
+}
+
+// newRootedForest checks parent and computes the depth and root of every
+// node. It returns a *CycleError if the parents form a cycle.
+func newRootedForest(parent []int) (rootedForest, error) {
+	n := len(parent)
+	f := rootedForest{parent: parent, depth: make([]int, n), root: make([]int, n)}
+	for v, p := range parent {
+		if p < -1 || p >= n {
+			return f, fmt.Errorf("graph: parent %d of node %d out of range [-1, %d)", p, v, n)
+		}
+	}
+	if cycle := predecessorCycle(parent); cycle != nil {
+		return f, &CycleError{Cycle: cycle}
+	}
+	for v := range f.depth {
+		f.depth[v] = -1
+	}
+	var path []int
+	for v := range parent {
+		// Climb to a node of known depth, then come back down.
+		u := v
+		for u >= 0 && f.depth[u] < 0 {
+			path = append(path, u)
+			u = parent[u]
+		}
+		for i := len(path) - 1; i >= 0; i-- {
+			w := path[i]
+			if p := parent[w]; p < 0 {
+				f.depth[w], f.root[w] = 0, w
+			} else {
+				f.depth[w], f.root[w] = f.depth[p]+1, f.root[p]
+			}
+		}
+		path = path[:0]
+	}
+	return f, nil
+}
+
+// Depth returns the number of edges from v up to its root.
+func (f *rootedForest) Depth(v int) int { return f.depth[v] }
+
+// Root returns the root of the tree of v.
+func (f *rootedForest) Root(v int) int { return f.root[v] }
+
+// LiftingLCA answers lowest common ancestor queries on a rooted forest by
+// binary lifting: it keeps the 2^k-th ancestor of every node, for every k,
+// to climb from two nodes in O(log n) jumps. Preprocessing takes O(n log n)
+// time and space.
+type LiftingLCA struct {
+	rootedForest
+	up [][]int // up[k][v] is the 2^k-th ancestor of v, or its root
+}
+
+// NewLiftingLCA preprocesses the forest given by the parent of every node,
+// -1 for the roots, as in Traversal.Parent or ShortestPaths.Pred. It returns
+// an error wrapping ErrCycle if the parents form a cycle.
+func NewLiftingLCA(parent []int) (*LiftingLCA, error) {
+	f, err := newRootedForest(parent)
+	if err != nil {
+		return nil, err
+	}
+	n := len(parent)
+	a := &LiftingLCA{rootedForest: f}
+	levels := max(bits.Len(uint(n)), 1)
+	a.up = make([][]int, levels)
+	a.up[0] = make([]int, n)
+	for v, p := range parent {
+		if p < 0 {
+			p = v
+		}
+		a.up[0][v] = p
+	}
+	for k := 1; k < levels; k++ {
+		a.up[k] = make([]int, n)
+		for v := range n {
+			a.up[k][v] = a.up[k-1][a.up[k-1][v]]
+		}
+	}
+	return a, nil
+}
+
+// Ancestor returns the k-th ancestor of v, v itself for k = 0, or false if
+// v has fewer than k ancestors. It takes O(log k).
+func (a *LiftingLCA) Ancestor(v, k int) (int, bool) {
+	if k < 0 || k > a.depth[v] {
+		return -1, false
+	}
+	for i := 0; k > 0; i++ {
+		if k&1 != 0 {
+			v = a.up[i][v]
+		}
+		k >>= 1
+	}
+	return v, true
+}
+
+// LCA returns the lowest common ancestor of u and v, the deepest node with
+// both below it or equal to them, or false if they lie in different trees.
+func (a *LiftingLCA) LCA(u, v int) (int, bool) {
+	if a.root[u] != a.root[v] {
+		return -1, false
+	}
+	if a.depth[u] < a.depth[v] {
+		u, v = v, u
+	}
+	u, _ = a.Ancestor(u, a.depth[u]-a.depth[v])
+	if u == v {
+		return u, true
+	}
+	for k := len(a.up) - 1; k >= 0; k-- {
+		if a.up[k][u] != a.up[k][v] {
+			u, v = a.up[k][u], a.up[k][v]
+		}
+	}
+	return a.parent[u], true
+}
+
+// Distance returns the number of edges on the tree path between u and v,
+// or false if they lie in different trees.
+func (a *LiftingLCA) Distance(u, v int) (int, bool) {
+	return TreeDistance(a.LCA, a.depth, u, v)
+}
+
+// EulerLCA answers lowest common ancestor queries on a rooted forest in
+// O(1), as a range minimum query over an Euler tour: between the visits of
+// two nodes, a depth-first walk of their tree passes their lowest common
+// ancestor and nothing shallower. A sparse table answers the queries, with
+// O(n log n) preprocessing time and space.
+type EulerLCA struct {
+	rootedForest
+	first []int   // index in the tour of the first visit of every node
+	table [][]int // table[k][i] is the shallowest node of tour[i:i+2^k]
+}
+
+// NewEulerLCA preprocesses the forest given by the parent of every node,
+// -1 for the roots, as in Traversal.Parent or ShortestPaths.Pred. It returns
+// an error wrapping ErrCycle if the parents form a cycle.
+func NewEulerLCA(parent []int) (*EulerLCA, error) {
+	f, err := newRootedForest(parent)
+	if err != nil {
+		return nil, err
+	}
+	n := len(parent)
+	children := make([][]int, n)
+	for v, p := range parent {
+		if p >= 0 {
+			children[p] = append(children[p], v)
+		}
+	}
+
+	// Walk every tree, the roots in ID order, recording every node on the
+	// way down and again after each child.
+	a := &EulerLCA{rootedForest: f, first: make([]int, n)}
+	tour := make([]int, 0, 2*n)
+	type frame struct{ v, child int }
+	var stack []frame
+	for r, p := range parent {
+		if p >= 0 {
+			continue
+		}
+		stack = append(stack, frame{r, 0})
+		a.first[r] = len(tour)
+		tour = append(tour, r)
+		for len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			if top.child == len(children[top.v]) {
+				stack = stack[:len(stack)-1]
+				if len(stack) > 0 {
+					tour = append(tour, stack[len(stack)-1].v)
+				}
+				continue
+			}
+			c := children[top.v][top.child]
+			top.child++
+			a.first[c] = len(tour)
+			tour = append(tour, c)
+			stack = append(stack, frame{c, 0})
+		}
+	}
+
+	a.table = [][]int{tour}
+	for k := 1; 1<<k <= len(tour); k++ {
+		prev, half := a.table[k-1], 1<<(k-1)
+		row := make([]int, len(tour)-1<<k+1)
+		for i := range row {
+			row[i] = a.shallower(prev[i], prev[i+half])
+		}
+		a.table = append(a.table, row)
+	}
+	return a, nil
+}
+
+func (a *EulerLCA) shallower(u, v int) int {
+	if a.depth[v] < a.depth[u] {
+		return v
+	}
+	return u
+}
+
+// LCA returns the lowest common ancestor of u and v, the deepest node with
+// both below it or equal to them, or false if they lie in different trees.
+func (a *EulerLCA) LCA(u, v int) (int, bool) {
+	if a.root[u] != a.root[v] {
+		return -1, false
+	}
+	i, j := a.first[u], a.first[v]
+	if i > j {
+		i, j = j, i
+	}
+	k := bits.Len(uint(j-i+1)) - 1
+	return a.shallower(a.table[k][i], a.table[k][j-1<<k+1]), true
+}
+
+// Distance returns the number of edges on the tree path between u and v,
+// or false if they lie in different trees.
+func (a *EulerLCA) Distance(u, v int) (int, bool) {
+	return TreeDistance(a.LCA, a.depth, u, v)
+}
+
+// TreeDistance returns the weight of the tree path between u and v, given
+// lca, the LCA method of a LiftingLCA or EulerLCA, and the weight of the
+// path from the root down to every node, such as ShortestPaths.Dist for a
+// shortest-path tree. It returns false if u and v lie in different trees.
+func TreeDistance[W Weight](lca func(u, v int) (int, bool), dist []W, u, v int) (W, bool) {
+	a, ok := lca(u, v)
+	if !ok {
+		return 0, false
+	}
+	return dist[u] + dist[v] - 2*dist[a], true
+}
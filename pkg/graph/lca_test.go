@@ -0,0 +1,209 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// randomForest returns the parents of a random forest of n nodes, every
+// node but the first a root with probability 1/roots.
+func randomForest(rng *rand.Rand, n, roots int) []int {
+	parent := make([]int, n)
+	perm := rng.Perm(n) // nodes in an order where parents come first
+	for i, v := range perm {
+		parent[v] = -1
+		if i > 0 && rng.Intn(roots) != 0 {
+			parent[v] = perm[rng.Intn(i)]
+		}
+	}
+	return parent
+}
+
+// naiveLCA climbs from u and v to their lowest common ancestor.
+func naiveLCA(parent []int, u, v int) (int, bool) {
+	above := make(map[int]bool)
+	for x := u; x >= 0; x = parent[x] {
+		above[x] = true
+	}
+	for x := v; x >= 0; x = parent[x] {
+		if above[x] {
+			return x, true
+		}
+	}
+	return -1, false
+}
+
+// lcas lists the LCA structures under test.
+var lcas = []struct {
+	name string
+	new  func(parent []int) (lcaQuerier, error)
+}{
+	{"lifting", func(parent []int) (lcaQuerier, error) { return NewLiftingLCA(parent) }},
+	{"euler", func(parent []int) (lcaQuerier, error) { return NewEulerLCA(parent) }},
+}
+
+type lcaQuerier interface {
+	LCA(u, v int) (int, bool)
+	Distance(u, v int) (int, bool)
+	Depth(v int) int
+	Root(v int) int
+}
+
+func TestLCA_MatchesNaive(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{1, 2, 10, 200} {
+		for _, roots := range []int{1 << 30, 5} {
+			parent := randomForest(rng, n, roots)
+			for _, alg := range lcas {
+				t.Run(fmt.Sprintf("%s n=%d roots=%d", alg.name, n, roots), func(t *testing.T) {
+					a, err := alg.new(parent)
+					if err != nil {
+						t.Fatal(err)
+					}
+					for range 500 {
+						u, v := rng.Intn(n), rng.Intn(n)
+						want, wantOK := naiveLCA(parent, u, v)
+						got, ok := a.LCA(u, v)
+						if got != want || ok != wantOK {
+							t.Fatalf("LCA(%d, %d) = %d, %v, want %d, %v", u, v, got, ok, want, wantOK)
+						}
+						d, ok := a.Distance(u, v)
+						if ok != wantOK || ok && d != a.Depth(u)+a.Depth(v)-2*a.Depth(want) {
+							t.Fatalf("Distance(%d, %d) = %d, %v", u, v, d, ok)
+						}
+						if (a.Root(u) == a.Root(v)) != wantOK {
+							t.Fatalf("roots %d and %d of %d and %d", a.Root(u), a.Root(v), u, v)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestLiftingLCA_Ancestor(t *testing.T) {
+	// A path 0 <- 1 <- ... <- 99, with 100 hanging off 50.
+	parent := make([]int, 101)
+	for v := range 100 {
+		parent[v] = v - 1
+	}
+	parent[100] = 50
+	a, err := NewLiftingLCA(parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		v, k, want int
+		ok         bool
+	}{
+		{99, 0, 99, true},
+		{99, 1, 98, true},
+		{99, 99, 0, true},
+		{99, 100, -1, false},
+		{100, 1, 50, true},
+		{100, 37, 14, true},
+		{0, 1, -1, false},
+		{5, -1, -1, false},
+	}
+	for _, tc := range tests {
+		if got, ok := a.Ancestor(tc.v, tc.k); got != tc.want || ok != tc.ok {
+			t.Errorf("Ancestor(%d, %d) = %d, %v, want %d, %v", tc.v, tc.k, got, ok, tc.want, tc.ok)
+		}
+	}
+	if d, _ := a.Distance(100, 99); d != 50 {
+		t.Errorf("Distance(100, 99) = %d, want 50", d)
+	}
+}
+
+func TestLCA_FromTraversals(t *testing.T) {
+	// A random weighted tree: the BFS forest gives parents, Dijkstra the
+	// weighted depths, and tree distances must match Dijkstra from u.
+	rng := rand.New(rand.NewSource(2))
+	const n = 60
+	g := NewUndirected[struct{}, int]()
+	g.AddNodes(make([]struct{}, n)...)
+	for v := 1; v < n; v++ {
+		g.AddEdge(rng.Intn(v), v, 1+rng.Intn(9))
+	}
+	root := 17
+	tr := BFS(g, Visitor[int]{}, root)
+	paths, err := Dijkstra(g, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, alg := range lcas {
+		a, err := alg.new(tr.Parent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for u := range n {
+			from, err := Dijkstra(g, u)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for v := range n {
+				if d, ok := TreeDistance(a.LCA, paths.Dist, u, v); !ok || d != from.Dist[v] {
+					t.Fatalf("%s: TreeDistance(%d, %d) = %d, %v, want %d", alg.name, u, v, d, ok, from.Dist[v])
+				}
+				if d, _ := a.Distance(u, v); d != len(BFS(g, Visitor[int]{}, u).PathTo(v))-1 {
+					t.Fatalf("%s: Distance(%d, %d) = %d", alg.name, u, v, d)
+				}
+			}
+		}
+	}
+}
+
+func TestLCA_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		parent []int
+		cycle  bool
+	}{
+		{"out of range", []int{-1, 2}, false},
+		{"below -1", []int{-2}, false},
+		{"self parent", []int{-1, 1}, true},
+		{"cycle", []int{-1, 3, 1, 2, 0}, true},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			for _, alg := range lcas {
+				_, err := alg.new(tc.parent)
+				if err == nil {
+					t.Fatalf("%s: no error", alg.name)
+				}
+				if errors.Is(err, ErrCycle) != tc.cycle {
+					t.Errorf("%s: error %v, cycle %v", alg.name, err, tc.cycle)
+				}
+			}
+		})
+	}
+	var ce *CycleError
+	if _, err := NewEulerLCA([]int{-1, 3, 1, 2, 0}); !errors.As(err, &ce) || fmt.Sprint(ce.Cycle) != "[1 2 3]" {
+		t.Errorf("error %v, want the cycle [1 2 3]", err)
+	}
+}
+
+func BenchmarkLCA(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	const n = 1 << 17
+	parent := randomForest(rng, n, 1<<30)
+	pairs := make([][2]int, 1024)
+	for i := range pairs {
+		pairs[i] = [2]int{rng.Intn(n), rng.Intn(n)}
+	}
+	for _, alg := range lcas {
+		a, err := alg.new(parent)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(alg.name, func(b *testing.B) {
+			for i := range b.N {
+				p := pairs[i%len(pairs)]
+				a.LCA(p[0], p[1])
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+)
+
+// LouvainConfig tunes Louvain.
+type LouvainConfig struct {
+	// Resolution γ weighs the expected fraction of edges inside communities
+	// in the modularity: above 1 it favors smaller communities, below 1
+	// larger ones. Default 1.
+	Resolution float64
+	// MinGain ends a level once a pass over the nodes improves modularity
+	// by less. Default 1e-7.
+	MinGain float64
+}
+
+func (c LouvainConfig) withDefaults() LouvainConfig {
+	if c.Resolution == 0 {
+		c.Resolution = 1
+	}
+	if c.MinGain == 0 {
+		c.MinGain = 1e-7
+	}
+	return c
+}
+
+// Partition is one level of the hierarchy found by Louvain.
+type Partition struct {
+	Communities *Components // community of every node of the graph
+	Modularity  float64
+}
+
+// Louvain detects communities in the undirected graph g, whose weights must
+// not be negative, by greedily maximizing modularity with the Louvain
+// method. Each level moves every node to the neighboring community that
+// improves modularity most, until no move helps, then aggregates the
+// communities into the nodes of the next level. It returns the partition
+// of every level, coarser and of higher modularity than the one before;
+// the last is the best found. Each level takes O(m) per pass over the
+// nodes, and the levels shrink quickly.
+func Louvain[N any, W Weight](g *Graph[N, W], cfg LouvainConfig) ([]Partition, error) {
+	if g.directed {
+		return nil, errors.New("graph: Louvain communities of a directed graph")
+	}
+	cfg = cfg.withDefaults()
+	lg, err := newLouvainGraph(g)
+	if err != nil {
+		return nil, err
+	}
+	n := g.Order()
+	of := make([]int, n) // community of every node of g
+	for v := range of {
+		of[v] = v
+	}
+	if lg.total == 0 {
+		return []Partition{{Communities: renumber(of), Modularity: 0}}, nil
+	}
+
+	var levels []Partition
+	for {
+		community, moved := lg.moveNodes(cfg)
+		if !moved {
+			break
+		}
+		for v := range of {
+			of[v] = community[of[v]]
+		}
+		lg = lg.aggregate(community)
+		levels = append(levels, Partition{Communities: renumber(of), Modularity: lg.modularity(cfg.Resolution)})
+	}
+	if levels == nil {
+		levels = append(levels, Partition{Communities: renumber(of), Modularity: lg.modularity(cfg.Resolution)})
+	}
+	return levels, nil
+}
+
+// Modularity returns the modularity of the partition c of the undirected
+// graph g at resolution γ: the fraction of the edge weight inside
+// communities minus γ times its expectation for random edges preserving
+// the degrees, Σ_c [in_c/m - γ (deg_c / 2m)²].
+func Modularity[N any, W Weight](g *Graph[N, W], c *Components, resolution float64) (float64, error) {
+	if g.directed {
+		return 0, errors.New("graph: modularity of a directed graph")
+	}
+	if len(c.Of) != g.Order() {
+		return 0, fmt.Errorf("graph: partition of %d nodes for a graph of %d", len(c.Of), g.Order())
+	}
+	lg, err := newLouvainGraph(g)
+	if err != nil {
+		return 0, err
+	}
+	if lg.total == 0 {
+		return 0, nil
+	}
+	return lg.aggregate(c.Of).modularity(resolution), nil
+}
+
+// louvainGraph is a weighted undirected graph with the self-loops of every
+// node kept aside, as aggregation creates them.
+type louvainGraph struct {
+	adj    [][]louvainEdge // edges to other nodes, listed from both ends
+	loop   []float64       // weight of the edges inside every node
+	degree []float64       // weighted degree, self-loops counting twice
+	total  float64         // total edge weight m
+}
+
+type louvainEdge struct {
+	to     int
+	weight float64
+}
+
+func newLouvainGraph[N any, W Weight](g *Graph[N, W]) (*louvainGraph, error) {
+	n := g.Order()
+	lg := &louvainGraph{adj: make([][]louvainEdge, n), loop: make([]float64, n), degree: make([]float64, n)}
+	for _, e := range g.Edges() {
+		if e.Weight < 0 {
+			return nil, fmt.Errorf("edge %v: %w", e, ErrNegativeWeight)
+		}
+		lg.add(e.From, e.To, float64(e.Weight))
+	}
+	return lg, nil
+}
+
+func (lg *louvainGraph) add(u, v int, w float64) {
+	if u == v {
+		lg.loop[u] += w
+	} else {
+		lg.adj[u] = append(lg.adj[u], louvainEdge{v, w})
+		lg.adj[v] = append(lg.adj[v], louvainEdge{u, w})
+	}
+	lg.degree[u] += w
+	lg.degree[v] += w
+	lg.total += w
+}
+
+// moveNodes runs passes over the nodes, moving each to the neighboring
+// community of largest modularity gain, until a pass gains less than
+// cfg.MinGain. It returns the community of every node, numbered from 0,
+// and whether any node moved.
+func (lg *louvainGraph) moveNodes(cfg LouvainConfig) ([]int, bool) {
+	n := len(lg.adj)
+	community := make([]int, n)
+	tot := make([]float64, n) // degree of every community
+	for v := range community {
+		community[v] = v
+		tot[v] = lg.degree[v]
+	}
+	m2 := 2 * lg.total
+	gamma := cfg.Resolution
+	links := make([]float64, n) // weight from the node at hand to every community
+	seen := make([]bool, n)
+	var touched []int
+	moved := false
+	for {
+		gain := 0.0
+		for v := range n {
+			for _, c := range touched {
+				links[c], seen[c] = 0, false
+			}
+			touched = touched[:0]
+			for _, e := range lg.adj[v] {
+				c := community[e.to]
+				if !seen[c] {
+					seen[c] = true
+					touched = append(touched, c)
+				}
+				links[c] += e.weight
+			}
+
+			// Take v out of its community, then put it back into the one
+			// where it gains most: joining c gains, up to a factor 1/m,
+			// links[c] - γ tot[c] deg(v) / 2m.
+			from, k := community[v], lg.degree[v]
+			tot[from] -= k
+			best := from
+			bestGain := links[from] - gamma*tot[from]*k/m2
+			for _, c := range touched {
+				if g := links[c] - gamma*tot[c]*k/m2; g > bestGain {
+					best, bestGain = c, g
+				}
+			}
+			tot[best] += k
+			if best != from {
+				community[v] = best
+				gain += (bestGain - (links[from] - gamma*tot[from]*k/m2)) / lg.total
+				moved = true
+			}
+		}
+		if gain < cfg.MinGain {
+			break
+		}
+	}
+	return renumber(community).Of, moved
+}
+
+// aggregate returns the graph of the communities, numbered from 0, of the
+// nodes of lg.
+func (lg *louvainGraph) aggregate(community []int) *louvainGraph {
+	k := 0
+	for _, c := range community {
+		k = max(k, c+1)
+	}
+	members := make([][]int, k)
+	for v, c := range community {
+		members[c] = append(members[c], v)
+	}
+	next := &louvainGraph{adj: make([][]louvainEdge, k), loop: make([]float64, k), degree: make([]float64, k), total: lg.total}
+	weights := make([]float64, k) // weight from the community at hand to every other
+	seen := make([]bool, k)
+	var touched []int
+	for c, vs := range members {
+		for _, v := range vs {
+			next.loop[c] += lg.loop[v]
+			next.degree[c] += lg.degree[v]
+			for _, e := range lg.adj[v] {
+				d := community[e.to]
+				if d == c {
+					next.loop[c] += e.weight / 2 // listed from both ends
+					continue
+				}
+				if !seen[d] {
+					seen[d] = true
+					touched = append(touched, d)
+				}
+				weights[d] += e.weight
+			}
+		}
+		for _, d := range touched {
+			next.adj[c] = append(next.adj[c], louvainEdge{d, weights[d]})
+			weights[d], seen[d] = 0, false
+		}
+		touched = touched[:0]
+	}
+	return next
+}
+
+// modularity returns the modularity of the partition of lg into its nodes.
+func (lg *louvainGraph) modularity(resolution float64) float64 {
+	q := 0.0
+	for c := range lg.loop {
+		share := lg.degree[c] / (2 * lg.total)
+		q += lg.loop[c]/lg.total - resolution*share*share
+	}
+	return q
+}
+
+// renumber returns the partition given by the label of every node, with
+// labels renumbered from 0 in order of their smallest node.
+func renumber(label []int) *Components {
+	c := &Components{Of: make([]int, len(label))}
+	index := make(map[int]int)
+	for v, l := range label {
+		i, ok := index[l]
+		if !ok {
+			i = len(c.Members)
+			index[l] = i
+			c.Members = append(c.Members, nil)
+		}
+		c.Of[v] = i
+		c.Members[i] = append(c.Members[i], v)
+	}
+	return c
+}
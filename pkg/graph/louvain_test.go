@@ -0,0 +1,197 @@
+package graph
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// ringOfCliques returns k cliques of size nodes each, every clique joined
+// to the next by a single edge.
+func ringOfCliques(k, size int) *Graph[struct{}, int] {
+	g := NewUndirected[struct{}, int]()
+	g.AddNodes(make([]struct{}, k*size)...)
+	for c := range k {
+		for i := range size {
+			for j := i + 1; j < size; j++ {
+				g.AddEdge(c*size+i, c*size+j, 1)
+			}
+		}
+		g.AddEdge(c*size, (c+1)%k*size+size-1, 1)
+	}
+	return g
+}
+
+// checkHierarchy checks that every level of the hierarchy merges the
+// communities of the one before, improves its modularity, and reports the
+// modularity of its partition.
+func checkHierarchy(t *testing.T, g *Graph[struct{}, int], levels []Partition, resolution float64) {
+	t.Helper()
+	for i, level := range levels {
+		q, err := Modularity(g, level.Communities, resolution)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(q-level.Modularity) > 1e-9 {
+			t.Errorf("level %d: modularity %v, want %v", i, level.Modularity, q)
+		}
+		if i == 0 {
+			continue
+		}
+		prev := levels[i-1]
+		if level.Modularity <= prev.Modularity {
+			t.Errorf("level %d: modularity %v after %v", i, level.Modularity, prev.Modularity)
+		}
+		if level.Communities.Count() >= prev.Communities.Count() {
+			t.Errorf("level %d: %d communities after %d", i, level.Communities.Count(), prev.Communities.Count())
+		}
+		for _, members := range prev.Communities.Members {
+			for _, v := range members[1:] {
+				if level.Communities.Of[v] != level.Communities.Of[members[0]] {
+					t.Fatalf("level %d splits community %v of level %d", i, members, i-1)
+				}
+			}
+		}
+	}
+}
+
+func TestModularity_Known(t *testing.T) {
+	// Two triangles joined by an edge: m = 7, degrees 2, 2, 3, 3, 2, 2.
+	g := mustFromEdges(t, false, 6, edges([3]int{0, 1, 1}, [3]int{1, 2, 1}, [3]int{2, 0, 1},
+		[3]int{2, 3, 1}, [3]int{3, 4, 1}, [3]int{4, 5, 1}, [3]int{5, 3, 1}))
+	tests := []struct {
+		name  string
+		label []int
+		want  float64
+	}{
+		{"triangles", []int{0, 0, 0, 1, 1, 1}, 2 * (3.0/7 - 0.25)},
+		{"singletons", []int{0, 1, 2, 3, 4, 5}, -34.0 / 196},
+		{"whole", []int{0, 0, 0, 0, 0, 0}, 0},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := Modularity(g, renumber(tc.label), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(q-tc.want) > 1e-12 {
+				t.Errorf("modularity %v, want %v", q, tc.want)
+			}
+		})
+	}
+	if _, err := Modularity(g, renumber([]int{0, 1}), 1); err == nil {
+		t.Error("partition of 2 nodes: no error")
+	}
+}
+
+func TestLouvain_RingOfCliques(t *testing.T) {
+	g := ringOfCliques(8, 5)
+	levels, err := Louvain(g, LouvainConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkHierarchy(t, g, levels, 1)
+	first := levels[0].Communities
+	if first.Count() != 8 {
+		t.Fatalf("first level: %d communities, want the 8 cliques", first.Count())
+	}
+	for c, members := range first.Members {
+		if want := []int{5 * c, 5*c + 1, 5*c + 2, 5*c + 3, 5*c + 4}; !slices.Equal(members, want) {
+			t.Errorf("community %d: %v, want %v", c, members, want)
+		}
+	}
+}
+
+func TestLouvain_PlantedPartition(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	const groups, size = 4, 20
+	g := NewUndirected[struct{}, int]()
+	g.AddNodes(make([]struct{}, groups*size)...)
+	for u := range groups * size {
+		for v := u + 1; v < groups*size; v++ {
+			p := 0.02
+			if u/size == v/size {
+				p = 0.6
+			}
+			if rng.Float64() < p {
+				g.AddEdge(u, v, 1)
+			}
+		}
+	}
+	levels, err := Louvain(g, LouvainConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkHierarchy(t, g, levels, 1)
+	best := levels[len(levels)-1].Communities
+	if best.Count() != groups {
+		t.Fatalf("%d communities, want %d", best.Count(), groups)
+	}
+	for v := range g.Order() {
+		if best.Of[v] != v/size {
+			t.Errorf("node %d in community %d, want %d", v, best.Of[v], v/size)
+		}
+	}
+}
+
+func TestLouvain_RandomHierarchies(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for i := range 20 {
+		g := randomGraph(rng, false, 10+rng.Intn(60), 20+rng.Intn(200), 0, 9)
+		resolution := 0.5 + rng.Float64()
+		levels, err := Louvain(g, LouvainConfig{Resolution: resolution})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(levels) == 0 {
+			t.Fatalf("graph %d: no levels", i)
+		}
+		checkHierarchy(t, g, levels, resolution)
+	}
+}
+
+func TestLouvain_Resolution(t *testing.T) {
+	g := ringOfCliques(16, 4)
+	count := func(resolution float64) int {
+		levels, err := Louvain(g, LouvainConfig{Resolution: resolution})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return levels[len(levels)-1].Communities.Count()
+	}
+	if low, high := count(0.1), count(2); low >= high {
+		t.Errorf("%d communities at resolution 0.1, %d at 2; want fewer at the lower", low, high)
+	}
+}
+
+func TestLouvain_NoEdges(t *testing.T) {
+	g := NewUndirected[struct{}, int]()
+	g.AddNodes(make([]struct{}, 3)...)
+	levels, err := Louvain(g, LouvainConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(levels) != 1 || levels[0].Communities.Count() != 3 || levels[0].Modularity != 0 {
+		t.Errorf("levels %+v, want the singletons at modularity 0", levels)
+	}
+}
+
+func TestLouvain_Errors(t *testing.T) {
+	directed := NewDirected[struct{}, int]()
+	if _, err := Louvain(directed, LouvainConfig{}); err == nil {
+		t.Error("directed graph: no error")
+	}
+	g := mustFromEdges(t, false, 2, edges([3]int{0, 1, -1}))
+	if _, err := Louvain(g, LouvainConfig{}); err == nil {
+		t.Error("negative weight: no error")
+	}
+}
+
+func BenchmarkLouvain(b *testing.B) {
+	g := randomGraph(rand.New(rand.NewSource(1)), false, 10000, 100000, 1, 5)
+	for range b.N {
+		Louvain(g, LouvainConfig{})
+	}
+}
@@ -0,0 +1,132 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/sanderblue/algorithms/pkg/bitset"
+)
+
+// Matrix is a graph of a fixed number of nodes stored as an adjacency
+// matrix. It holds at most one edge per ordered pair of nodes and no
+// payloads.
+type Matrix[W Weight] struct {
+	n        int
+	directed bool
+	weights  []W            // weight of u->v at u*n+v
+	present  *bitset.Bitset // whether u->v exists, at u*n+v
+}
+
+// NewMatrix returns a matrix of n nodes without edges.
+func NewMatrix[W Weight](n int, directed bool) *Matrix[W] {
+	return &Matrix[W]{n: n, directed: directed, weights: make([]W, n*n), present: bitset.New(n * n)}
+}
+
+// Directed reports whether the matrix is directed. Setting or unsetting an
+// edge of an undirected matrix also sets or unsets its mirror.
+func (m *Matrix[W]) Directed() bool { return m.directed }
+
+// Order returns the number of nodes.
+func (m *Matrix[W]) Order() int { return m.n }
+
+// Size returns the number of edges. An undirected edge counts once.
+func (m *Matrix[W]) Size() int {
+	if m.directed {
+		return m.present.Count()
+	}
+	loops := 0
+	for u := 0; u < m.n; u++ {
+		if m.Has(u, u) {
+			loops++
+		}
+	}
+	return (m.present.Count() + loops) / 2
+}
+
+// At returns the weight of the edge from u to v, and whether there is one.
+func (m *Matrix[W]) At(u, v int) (W, bool) {
+	i := m.index(u, v)
+	return m.weights[i], m.present.Test(i)
+}
+
+// Has reports whether there is an edge from u to v.
+func (m *Matrix[W]) Has(u, v int) bool {
+	return m.present.Test(m.index(u, v))
+}
+
+// Set adds the edge from u to v, or replaces its weight.
+func (m *Matrix[W]) Set(u, v int, w W) {
+	m.set(m.index(u, v), w)
+	if !m.directed {
+		m.set(m.index(v, u), w)
+	}
+}
+
+// Unset removes the edge from u to v, if any.
+func (m *Matrix[W]) Unset(u, v int) {
+	m.unset(m.index(u, v))
+	if !m.directed {
+		m.unset(m.index(v, u))
+	}
+}
+
+// Row returns the weights of the edges leaving u, with the zero weight where
+// there is none.
+func (m *Matrix[W]) Row(u int) []W {
+	return append([]W(nil), m.weights[m.index(u, 0):m.index(u, 0)+m.n]...)
+}
+
+func (m *Matrix[W]) set(i int, w W) {
+	m.weights[i] = w
+	m.present.Set(i)
+}
+
+func (m *Matrix[W]) unset(i int) {
+	var zero W
+	m.weights[i] = zero
+	m.present.Clear(i)
+}
+
+func (m *Matrix[W]) index(u, v int) int {
+	if u < 0 || u >= m.n || v < 0 || v >= m.n {
+		panic(fmt.Sprintf("graph: edge %d->%d out of range [0, %d)", u, v, m.n))
+	}
+	return u*m.n + v
+}
+
+// Matrix returns the adjacency matrix of the graph. Parallel edges collapse
+// into one, of the smallest weight.
+func (g *Graph[N, W]) Matrix() *Matrix[W] {
+	m := NewMatrix[W](g.Order(), g.directed)
+	for _, e := range g.Edges() {
+		if w, ok := m.At(e.From, e.To); !ok || e.Weight < w {
+			m.Set(e.From, e.To, e.Weight)
+		}
+	}
+	return m
+}
+
+// FromMatrix returns the adjacency list form of m, with nodes carrying the
+// given payloads. payloads must hold one payload per node, or be nil for
+// zero payloads. Edges leave each node in increasing order of target.
+func FromMatrix[N any, W Weight](m *Matrix[W], payloads []N) (*Graph[N, W], error) {
+	if payloads == nil {
+		payloads = make([]N, m.n)
+	}
+	if len(payloads) != m.n {
+		return nil, fmt.Errorf("got %d payloads for %d nodes", len(payloads), m.n)
+	}
+	g := &Graph[N, W]{directed: m.directed}
+	g.AddNodes(payloads...)
+	for u := 0; u < m.n; u++ {
+		first := 0
+		if !m.directed {
+			first = u
+		}
+		for v := first; v < m.n; v++ {
+			if w, ok := m.At(u, v); ok {
+				g.AddEdge(u, v, w)
+			}
+		}
+	}
+	return g, nil
+}
@@ -0,0 +1,97 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatrix_SetUnset(t *testing.T) {
+	tests := []struct {
+		name     string
+		directed bool
+		size     int
+		at       float64 // weight of 0->1
+	}{
+		{"directed", true, 3, 0.5},
+		{"undirected", false, 2, 1.5},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewMatrix[float64](3, tc.directed)
+			m.Set(0, 1, 0.5)
+			m.Set(1, 0, 1.5) // mirrors 0-1 when undirected
+			m.Set(2, 2, 2)
+			if m.Size() != tc.size {
+				t.Errorf("got size %d, want %d", m.Size(), tc.size)
+			}
+			if w, ok := m.At(0, 1); !ok || w != tc.at {
+				t.Errorf("At(0, 1) = %v, %v", w, ok)
+			}
+			if got, want := m.Row(2), []float64{0, 0, 2}; !reflect.DeepEqual(got, want) {
+				t.Errorf("row 2: got %v, want %v", got, want)
+			}
+			m.Unset(0, 1)
+			if m.Has(0, 1) || m.Has(1, 0) == !tc.directed {
+				t.Errorf("after Unset(0, 1): has 0->1 %v, has 1->0 %v", m.Has(0, 1), m.Has(1, 0))
+			}
+		})
+	}
+}
+
+func TestMatrix_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		directed bool
+		edges    []Edge[int]
+	}{
+		{"directed", true, edges([3]int{0, 1, 4}, [3]int{0, 3, 1}, [3]int{1, 0, 2}, [3]int{2, 2, 7}, [3]int{3, 2, 5})},
+		{"undirected", false, edges([3]int{0, 1, 4}, [3]int{0, 3, 1}, [3]int{1, 2, 2}, [3]int{2, 2, 7}, [3]int{2, 3, 5})},
+		{"empty", true, nil},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := mustFromEdges(t, tc.directed, 4, tc.edges)
+			for i := range g.Order() {
+				g.SetNode(i, string(rune('a'+i)))
+			}
+			m := g.Matrix()
+			if m.Order() != 4 || m.Size() != len(tc.edges) || m.Directed() != tc.directed {
+				t.Fatalf("matrix of order %d, size %d, directed %v", m.Order(), m.Size(), m.Directed())
+			}
+			back, err := FromMatrix(m, g.Nodes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(back.Edges(), g.Edges()) || !reflect.DeepEqual(back.Nodes(), g.Nodes()) {
+				t.Errorf("round trip changed the graph:\n got %v %v\nwant %v %v", back.Edges(), back.Nodes(), g.Edges(), g.Nodes())
+			}
+			for u := range g.Order() {
+				if !reflect.DeepEqual(back.Neighbors(u), g.Neighbors(u)) {
+					t.Errorf("neighbors of %d: got %v, want %v", u, back.Neighbors(u), g.Neighbors(u))
+				}
+			}
+		})
+	}
+}
+
+func TestMatrix_ParallelEdgesKeepLightest(t *testing.T) {
+	g := mustFromEdges(t, false, 2, edges([3]int{0, 1, 3}, [3]int{1, 0, 2}, [3]int{0, 1, 5}))
+	m := g.Matrix()
+	if w, _ := m.At(1, 0); w != 2 || m.Size() != 1 {
+		t.Errorf("got weight %d and size %d, want 2 and 1", w, m.Size())
+	}
+}
+
+func TestFromMatrix_Payloads(t *testing.T) {
+	m := NewMatrix[int](2, true)
+	m.Set(1, 0, 1)
+	if _, err := FromMatrix(m, []string{"only one"}); err == nil {
+		t.Error("expected an error for a payload count mismatch")
+	}
+	g, err := FromMatrix[string](m, nil)
+	if err != nil || g.Order() != 2 || !g.HasEdge(1, 0) {
+		t.Errorf("got %v, %v", g, err)
+	}
+}
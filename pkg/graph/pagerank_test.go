@@ -0,0 +1,178 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// denseRanks returns PageRank by iterating the Google matrix, built
+// explicitly, a fixed number of times.
+func denseRanks(g *Graph[struct{}, int], d float64, weighted bool) []float64 {
+	n := g.Order()
+	m := make([][]float64, n) // m[u][v]: probability of moving from u to v
+	for u := range m {
+		m[u] = make([]float64, n)
+		total := 0.0
+		for _, e := range g.Neighbors(u) {
+			total += weight(e, weighted)
+		}
+		for v := range m[u] {
+			m[u][v] = (1 - d) / float64(n)
+			if total == 0 {
+				m[u][v] += d / float64(n)
+			}
+		}
+		for _, e := range g.Neighbors(u) {
+			if total > 0 {
+				m[u][e.To] += d * weight(e, weighted) / total
+			}
+		}
+	}
+	rank := make([]float64, n)
+	for v := range rank {
+		rank[v] = 1 / float64(n)
+	}
+	for range 200 {
+		next := make([]float64, n)
+		for u := range m {
+			for v, p := range m[u] {
+				next[v] += rank[u] * p
+			}
+		}
+		rank = next
+	}
+	return rank
+}
+
+func weight(e Edge[int], weighted bool) float64 {
+	if weighted {
+		return float64(e.Weight)
+	}
+	return 1
+}
+
+func TestPageRank_MatchesDense(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tests := []struct {
+		name     string
+		g        *Graph[struct{}, int]
+		damping  float64
+		weighted bool
+	}{
+		{"directed with dangling nodes", randomGraph(rng, true, 40, 60, 1, 5), 0, false},
+		{"dense directed", randomGraph(rng, true, 30, 400, 1, 5), 0.5, false},
+		{"weighted", randomGraph(rng, true, 30, 100, 0, 9), 0, true},
+		{"undirected", randomGraph(rng, false, 40, 80, 1, 5), 0.9, false},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			for _, workers := range []int{1, 3, 8} {
+				r, err := PageRank(tc.g, PageRankConfig{Damping: tc.damping, Weighted: tc.weighted, Workers: workers, Tolerance: 1e-12, MaxIterations: 1000})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !r.Converged {
+					t.Fatalf("%d workers: no convergence after %d iterations, delta %g", workers, r.Iterations, r.Delta)
+				}
+				d := tc.damping
+				if d == 0 {
+					d = 0.85
+				}
+				want := denseRanks(tc.g, d, tc.weighted)
+				sum := 0.0
+				for v, got := range r.Ranks {
+					sum += got
+					if math.Abs(got-want[v]) > 1e-9 {
+						t.Fatalf("%d workers: node %d ranks %g, want %g", workers, v, got, want[v])
+					}
+				}
+				if math.Abs(sum-1) > 1e-9 {
+					t.Errorf("%d workers: ranks sum to %g", workers, sum)
+				}
+			}
+		})
+	}
+}
+
+func TestPageRank_WorkersAgree(t *testing.T) {
+	g := randomGraph(rand.New(rand.NewSource(2)), true, 1000, 5000, 1, 5)
+	want, err := PageRank(g, PageRankConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, workers := range []int{2, 7, 2000} {
+		got, err := PageRank(g, PageRankConfig{Workers: workers})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Iterations != want.Iterations {
+			t.Errorf("%d workers: %d iterations, want %d", workers, got.Iterations, want.Iterations)
+		}
+		for v := range want.Ranks {
+			if got.Ranks[v] != want.Ranks[v] {
+				t.Fatalf("%d workers: node %d ranks %g, want %g", workers, v, got.Ranks[v], want.Ranks[v])
+			}
+		}
+	}
+}
+
+func TestPageRank_Known(t *testing.T) {
+	// A cycle ranks every node alike; a star's hub collects the rank of
+	// its leaves, and hands it back to them as a dangling node.
+	cycle := mustFromEdges(t, true, 4, edges([3]int{0, 1, 1}, [3]int{1, 2, 1}, [3]int{2, 3, 1}, [3]int{3, 0, 1}))
+	r, err := PageRank(cycle, PageRankConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for v, rank := range r.Ranks {
+		if math.Abs(rank-0.25) > 1e-12 {
+			t.Errorf("cycle node %d ranks %g, want 0.25", v, rank)
+		}
+	}
+
+	star := mustFromEdges(t, true, 4, edges([3]int{1, 0, 1}, [3]int{2, 0, 1}, [3]int{3, 0, 1}))
+	r, err = PageRank(star, PageRankConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Leaves only get the jumps and their share of the dangling hub's rank,
+	// x = (1-d)/4 + d·h/4, while the hub gets that plus the links of the
+	// leaves, h = x + 3dx.
+	d, h, x := 0.85, r.Ranks[0], r.Ranks[1]
+	if math.Abs(x-((1-d)/4+d*h/4)) > 1e-9 || math.Abs(h-(x+3*d*x)) > 1e-9 || h <= x {
+		t.Errorf("hub ranks %g and leaves %g, not the fixed point", h, x)
+	}
+}
+
+func TestPageRank_Errors(t *testing.T) {
+	g := mustFromEdges(t, true, 2, edges([3]int{0, 1, -1}))
+	if _, err := PageRank(g, PageRankConfig{Damping: 1}); err == nil {
+		t.Error("expected an error for damping 1")
+	}
+	if _, err := PageRank(g, PageRankConfig{Damping: -0.5}); err == nil {
+		t.Error("expected an error for a negative damping")
+	}
+	if r, err := PageRank(g, PageRankConfig{Damping: 0}); err != nil || !r.Converged {
+		t.Errorf("damping 0, the default: %+v, %v", r, err)
+	}
+	if _, err := PageRank(g, PageRankConfig{Weighted: true}); err == nil {
+		t.Error("expected an error for a negative weight")
+	}
+	if r, err := PageRank(NewDirected[struct{}, int](), PageRankConfig{}); err != nil || !r.Converged {
+		t.Errorf("empty graph: %+v, %v", r, err)
+	}
+}
+
+func BenchmarkPageRank(b *testing.B) {
+	g := randomGraph(rand.New(rand.NewSource(1)), true, 100000, 1000000, 1, 5)
+	for _, workers := range []int{1, 4} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for range b.N {
+				PageRank(g, PageRankConfig{Workers: workers, MaxIterations: 10, Tolerance: 1e-300})
+			}
+		})
+	}
+}
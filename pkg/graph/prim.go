@@ -0,0 +1,120 @@
+package graph
+
+import "container/heap"
+
+// Prim returns a minimum spanning forest of the undirected graph g, with
+// the eager form of Prim's algorithm: grow a tree from a node, always
+// adding the lightest edge leaving it. An indexed heap holds each node
+// outside the tree once, keyed by its lightest edge to the tree, so it
+// runs in O(m log n) and O(n) space. Trees grow from every node not yet
+// spanned, in ID order.
+func Prim[N any, W Weight](g *Graph[N, W], cfg SpanningConfig) (*SpanningForest[W], error) {
+	if g.directed {
+		return nil, errDirectedSpanning
+	}
+	n := g.Order()
+	f := &SpanningForest[W]{}
+	trees := newForest(n)
+	inTree := make([]bool, n)
+	best := make([]Edge[W], n) // lightest edge from the tree to every node in the heap
+	h := newIndexHeap[W](n)
+	h.max = cfg.Maximum
+	for s := range n {
+		if inTree[s] {
+			continue
+		}
+		best[s] = Edge[W]{From: -1}
+		h.Set(s, 0)
+		for h.Len() > 0 {
+			u, _ := h.Pop()
+			inTree[u] = true
+			if e := best[u]; e.From >= 0 {
+				f.Edges = append(f.Edges, e)
+				f.Weight += e.Weight
+				trees.Union(e.From, e.To)
+			}
+			for _, e := range g.out[u] {
+				v := e.To
+				if inTree[v] {
+					continue
+				}
+				if !h.Contains(v) || better(e.Weight, h.Key(v), cfg.Maximum) {
+					best[v] = e
+					h.Set(v, e.Weight)
+				}
+			}
+		}
+	}
+	f.Trees = forestComponents(trees)
+	return f, nil
+}
+
+// LazyPrim is Prim with a plain heap of edges: every edge leaving the tree
+// is pushed, and edges found to lead back into the tree when popped are
+// dropped. It runs in O(m log m) and O(m) space, simpler but usually
+// slower than the eager form.
+func LazyPrim[N any, W Weight](g *Graph[N, W], cfg SpanningConfig) (*SpanningForest[W], error) {
+	if g.directed {
+		return nil, errDirectedSpanning
+	}
+	n := g.Order()
+	f := &SpanningForest[W]{}
+	trees := newForest(n)
+	inTree := make([]bool, n)
+	h := &edgeHeap[W]{max: cfg.Maximum}
+	add := func(u int) {
+		inTree[u] = true
+		for _, e := range g.out[u] {
+			if !inTree[e.To] {
+				heap.Push(h, e)
+			}
+		}
+	}
+	for s := range n {
+		if inTree[s] {
+			continue
+		}
+		add(s)
+		for h.Len() > 0 {
+			e := heap.Pop(h).(Edge[W])
+			if inTree[e.To] {
+				continue
+			}
+			f.Edges = append(f.Edges, e)
+			f.Weight += e.Weight
+			trees.Union(e.From, e.To)
+			add(e.To)
+		}
+	}
+	f.Trees = forestComponents(trees)
+	return f, nil
+}
+
+// better reports whether weight a beats weight b: it is smaller, or larger
+// if maximum is set.
+func better[W Weight](a, b W, maximum bool) bool {
+	if maximum {
+		return a > b
+	}
+	return a < b
+}
+
+// edgeHeap is a heap of edges by weight, the lightest on top or the
+// heaviest if max is set.
+type edgeHeap[W Weight] struct {
+	edges []Edge[W]
+	max   bool
+}
+
+func (h *edgeHeap[W]) Len() int { return len(h.edges) }
+func (h *edgeHeap[W]) Less(i, j int) bool {
+	return better(h.edges[i].Weight, h.edges[j].Weight, h.max)
+}
+func (h *edgeHeap[W]) Swap(i, j int) { h.edges[i], h.edges[j] = h.edges[j], h.edges[i] }
+func (h *edgeHeap[W]) Push(x any)    { h.edges = append(h.edges, x.(Edge[W])) }
+
+func (h *edgeHeap[W]) Pop() any {
+	e := h.edges[len(h.edges)-1]
+	h.edges = h.edges[:len(h.edges)-1]
+	return e
+}
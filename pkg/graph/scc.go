@@ -0,0 +1,125 @@
+package graph
+
+import "slices"
+
+// Components is a partition of the nodes of a graph.
+type Components struct {
+	Of      []int   // component of every node
+	Members [][]int // nodes of every component, in increasing order
+}
+
+// Count returns the number of components.
+func (c *Components) Count() int { return len(c.Members) }
+
+// StronglyConnected returns the strongly connected components of g, the
+// maximal sets of nodes that can all reach one another, with Tarjan's
+// algorithm in O(n+m). Components are numbered in topological order of the
+// condensation: every edge between two components leads to the higher
+// numbered one. The components of an undirected graph are its connected
+// components.
+func StronglyConnected[N any, W Weight](g *Graph[N, W]) *Components {
+	n := g.Order()
+	index := make([]int, n) // discovery index, 1-based, 0 if not discovered
+	low := make([]int, n)   // smallest index reachable through the subtree and one more edge
+	onStack := make([]bool, n)
+	var stack []int // discovered nodes whose component is not complete yet
+	var found [][]int
+	next := 1
+
+	type frame struct{ u, edge int }
+	var calls []frame
+	visit := func(u int) {
+		index[u], low[u] = next, next
+		next++
+		stack = append(stack, u)
+		onStack[u] = true
+		calls = append(calls, frame{u: u})
+	}
+	for s := range n {
+		if index[s] != 0 {
+			continue
+		}
+		visit(s)
+		for len(calls) > 0 {
+			f := &calls[len(calls)-1]
+			u := f.u
+			if f.edge < len(g.out[u]) {
+				v := g.out[u][f.edge].To
+				f.edge++
+				switch {
+				case index[v] == 0:
+					visit(v)
+				case onStack[v]:
+					low[u] = min(low[u], index[v])
+				}
+				continue
+			}
+			calls = calls[:len(calls)-1]
+			if len(calls) > 0 {
+				parent := calls[len(calls)-1].u
+				low[parent] = min(low[parent], low[u])
+			}
+			if low[u] != index[u] {
+				continue
+			}
+			// u is the root of a component: the nodes above it on the
+			// stack.
+			i := len(stack) - 1
+			for stack[i] != u {
+				i--
+			}
+			members := slices.Clone(stack[i:])
+			for _, v := range members {
+				onStack[v] = false
+			}
+			stack = stack[:i]
+			slices.Sort(members)
+			found = append(found, members)
+		}
+	}
+
+	// Tarjan completes a component only after every component it reaches:
+	// the reverse of the order found is topological.
+	slices.Reverse(found)
+	c := &Components{Of: make([]int, n), Members: found}
+	for i, members := range found {
+		for _, v := range members {
+			c.Of[v] = i
+		}
+	}
+	return c
+}
+
+// Condensation returns the directed acyclic graph of the components c of g,
+// as returned by StronglyConnected: node i stands for component i and
+// carries its members, and there is an edge between two components if g
+// has edges between their members, weighing the lightest of them.
+func Condensation[N any, W Weight](g *Graph[N, W], c *Components) *Graph[[]int, W] {
+	d := NewDirected[[]int, W]()
+	d.AddNodes(c.Members...)
+	edge := make(map[[2]int]int) // position of the edge between two components in d.out
+	for _, e := range g.Edges() {
+		from, to := c.Of[e.From], c.Of[e.To]
+		if from == to {
+			continue
+		}
+		key := [2]int{from, to}
+		if i, ok := edge[key]; ok {
+			if e.Weight < d.out[from][i].Weight {
+				d.setWeight(from, i, e.Weight)
+			}
+			continue
+		}
+		edge[key] = len(d.out[from])
+		d.AddEdge(from, to, e.Weight)
+	}
+	return d
+}
+
+// setWeight changes the weight of the i-th edge leaving u of a directed
+// graph.
+func (g *Graph[N, W]) setWeight(u, i int, w W) {
+	e := g.out[u][i]
+	g.in[e.To][indexEdge(g.in[e.To], e)].Weight = w
+	g.out[u][i].Weight = w
+}
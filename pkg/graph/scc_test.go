@@ -0,0 +1,103 @@
+package graph
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestStronglyConnected_Known(t *testing.T) {
+	// The graph of figure 22.9 of Cormen et al., nodes a to h as 0 to 7.
+	g, err := FromEdges[struct{}](true, 8, edges(
+		[3]int{0, 1, 1}, [3]int{1, 2, 1}, [3]int{1, 4, 1}, [3]int{1, 5, 1},
+		[3]int{2, 3, 1}, [3]int{2, 6, 1}, [3]int{3, 2, 1}, [3]int{3, 7, 1},
+		[3]int{4, 0, 1}, [3]int{4, 5, 1}, [3]int{5, 6, 1}, [3]int{6, 5, 1},
+		[3]int{6, 7, 1}, [3]int{7, 7, 1},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := StronglyConnected(g)
+	if want := [][]int{{0, 1, 4}, {2, 3}, {5, 6}, {7}}; !reflect.DeepEqual(c.Members, want) {
+		t.Errorf("components %v, want %v", c.Members, want)
+	}
+	if want := []int{0, 0, 1, 1, 0, 2, 2, 3}; !reflect.DeepEqual(c.Of, want) {
+		t.Errorf("component of every node %v, want %v", c.Of, want)
+	}
+
+	d := Condensation(g, c)
+	if want := edges([3]int{0, 1, 1}, [3]int{0, 2, 1}, [3]int{1, 2, 1}, [3]int{1, 3, 1}, [3]int{2, 3, 1}); !reflect.DeepEqual(d.Edges(), want) {
+		t.Errorf("condensation edges %v, want %v", d.Edges(), want)
+	}
+	if !reflect.DeepEqual(d.Node(1), []int{2, 3}) {
+		t.Errorf("condensation node 1 carries %v", d.Node(1))
+	}
+}
+
+// reachability returns whether every node reaches every other.
+func reachability(g *Graph[struct{}, int]) [][]bool {
+	reach := make([][]bool, g.Order())
+	for u := range reach {
+		reach[u] = make([]bool, g.Order())
+		for _, v := range BFS(g, Visitor[int]{}, u).Order {
+			reach[u][v] = true
+		}
+	}
+	return reach
+}
+
+func TestStronglyConnected_MatchesReachability(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := range 30 {
+		g := randomGraph(rng, true, 40, 20+i*3, 1, 5)
+		c := StronglyConnected(g)
+		reach := reachability(g)
+		for u := range g.Order() {
+			for v := range g.Order() {
+				if same := reach[u][v] && reach[v][u]; same != (c.Of[u] == c.Of[v]) {
+					t.Fatalf("graph %d: nodes %d and %d mutually reachable %v, components %d and %d", i, u, v, same, c.Of[u], c.Of[v])
+				}
+			}
+		}
+
+		d := Condensation(g, c)
+		for _, e := range d.Edges() {
+			if e.From >= e.To {
+				t.Fatalf("graph %d: condensation edge %v leads backwards", i, e)
+			}
+		}
+		for _, e := range g.Edges() {
+			if w, ok := d.Weight(c.Of[e.From], c.Of[e.To]); c.Of[e.From] != c.Of[e.To] && (!ok || w > e.Weight) {
+				t.Fatalf("graph %d: edge %v missing from the condensation, or lighter", i, e)
+			}
+		}
+		if d.Order() != c.Count() || d.InDegree(0) != 0 {
+			t.Fatalf("graph %d: condensation of %d nodes for %d components", i, d.Order(), c.Count())
+		}
+	}
+}
+
+func TestStronglyConnected_Undirected(t *testing.T) {
+	g, _ := FromEdges[struct{}](false, 6, edges([3]int{0, 3, 1}, [3]int{3, 4, 1}, [3]int{1, 5, 1}))
+	c := StronglyConnected(g)
+	if want := [][]int{{2}, {1, 5}, {0, 3, 4}}; !reflect.DeepEqual(c.Members, want) {
+		t.Errorf("components %v, want %v", c.Members, want)
+	}
+	if d := Condensation(g, c); d.Size() != 0 {
+		t.Errorf("condensation of an undirected graph has edges %v", d.Edges())
+	}
+}
+
+func TestStronglyConnected_LongCycle(t *testing.T) {
+	const n = 1 << 20
+	g, _ := FromEdges[struct{}, int](true, n, nil)
+	for u := range n {
+		g.AddEdge(u, (u+1)%n, 1)
+	}
+	g.AddNode(struct{}{})
+	g.AddEdge(n, 0, 1)
+	c := StronglyConnected(g)
+	if c.Count() != 2 || len(c.Members[1]) != n || c.Of[n] != 0 {
+		t.Errorf("got %d components, of sizes %d and %d", c.Count(), len(c.Members[0]), len(c.Members[c.Count()-1]))
+	}
+}
@@ -0,0 +1,94 @@
+package graph
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/sanderblue/algorithms/pkg/disjointset"
+)
+
+var errDirectedSpanning = errors.New("graph: spanning tree of a directed graph")
+
+// SpanningConfig tunes the spanning tree algorithms.
+type SpanningConfig struct {
+	// Maximum finds a spanning tree of largest total weight instead of
+	// smallest.
+	Maximum bool
+}
+
+// SpanningForest is a minimum (or maximum) spanning forest: a spanning tree
+// of every connected component.
+type SpanningForest[W Weight] struct {
+	Edges  []Edge[W] // edges of the forest, in the order they were chosen
+	Weight W         // total weight of the edges
+	Trees  *Components
+}
+
+// Spanning reports whether the forest is a single tree, spanning the whole
+// graph.
+func (f *SpanningForest[W]) Spanning() bool { return f.Trees.Count() <= 1 }
+
+// Kruskal returns a minimum spanning forest of the undirected graph g, with
+// Kruskal's algorithm: consider the edges from lightest to heaviest and
+// keep those joining two trees, tracked by a disjoint-set forest. It runs
+// in O(m log m).
+func Kruskal[N any, W Weight](g *Graph[N, W], cfg SpanningConfig) (*SpanningForest[W], error) {
+	if g.directed {
+		return nil, errDirectedSpanning
+	}
+	edges := g.Edges()
+	slices.SortStableFunc(edges, func(a, b Edge[W]) int {
+		if cfg.Maximum {
+			a, b = b, a
+		}
+		switch {
+		case a.Weight < b.Weight:
+			return -1
+		case a.Weight > b.Weight:
+			return 1
+		}
+		return 0
+	})
+	f := &SpanningForest[W]{}
+	trees := newForest(g.Order())
+	for _, e := range edges {
+		if trees.Count() == 1 {
+			break
+		}
+		if trees.Union(e.From, e.To) {
+			f.Edges = append(f.Edges, e)
+			f.Weight += e.Weight
+		}
+	}
+	f.Trees = forestComponents(trees)
+	return f, nil
+}
+
+// newForest returns a disjoint-set forest of the nodes 0..n-1, each a tree
+// of its own.
+func newForest(n int) *disjointset.Set[int] {
+	trees := disjointset.New[int](disjointset.BySize)
+	for v := range n {
+		trees.Add(v)
+	}
+	return trees
+}
+
+// forestComponents returns the trees of a forest made by newForest as
+// Components, numbered in order of their smallest node.
+func forestComponents(trees *disjointset.Set[int]) *Components {
+	c := &Components{Of: make([]int, trees.Len())}
+	index := make(map[int]int, trees.Count())
+	for v := range c.Of {
+		r, _ := trees.Find(v)
+		i, ok := index[r]
+		if !ok {
+			i = len(c.Members)
+			index[r] = i
+			c.Members = append(c.Members, nil)
+		}
+		c.Of[v] = i
+		c.Members[i] = append(c.Members[i], v)
+	}
+	return c
+}
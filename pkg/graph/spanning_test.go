@@ -0,0 +1,211 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// clrsGraph returns the graph of figure 23.1 of Cormen et al., nodes a to i
+// as 0 to 8; its minimum spanning trees weigh 37.
+func clrsGraph(t testing.TB) *Graph[string, int] {
+	g, err := FromEdges[string](false, 9, edges(
+		[3]int{0, 1, 4}, [3]int{0, 7, 8}, [3]int{1, 2, 8}, [3]int{1, 7, 11},
+		[3]int{2, 3, 7}, [3]int{2, 5, 4}, [3]int{2, 8, 2}, [3]int{3, 4, 9},
+		[3]int{3, 5, 14}, [3]int{4, 5, 10}, [3]int{5, 6, 2}, [3]int{6, 7, 1},
+		[3]int{6, 8, 6}, [3]int{7, 8, 7},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+type spanningAlgorithm struct {
+	name  string
+	build func(*Graph[struct{}, int], SpanningConfig) (*SpanningForest[int], error)
+}
+
+var spanningAlgorithms = []spanningAlgorithm{
+	{"kruskal", Kruskal[struct{}, int]},
+	{"prim", Prim[struct{}, int]},
+	{"lazy prim", LazyPrim[struct{}, int]},
+}
+
+// bestSpanningTree returns the weight of the lightest (or heaviest)
+// spanning tree of the connected graph g by trying every set of n-1 edges.
+func bestSpanningTree(g *Graph[struct{}, int], maximum bool) int {
+	edges := g.Edges()
+	n := g.Order()
+	best, found := 0, false
+	var try func(i int, chosen []Edge[int])
+	try = func(i int, chosen []Edge[int]) {
+		if len(chosen) == n-1 {
+			u, w := newForest(n), 0
+			for _, e := range chosen {
+				if !u.Union(e.From, e.To) {
+					return
+				}
+				w += e.Weight
+			}
+			better := w < best
+			if maximum {
+				better = w > best
+			}
+			if !found || better {
+				best, found = w, true
+			}
+			return
+		}
+		if len(edges)-i < n-1-len(chosen) {
+			return
+		}
+		try(i+1, append(chosen, edges[i]))
+		try(i+1, chosen)
+	}
+	try(0, nil)
+	return best
+}
+
+// checkForest verifies that f is a spanning forest of g: its edges are
+// edges of g, acyclic, weigh f.Weight and join every connected component.
+func checkForest(t *testing.T, g *Graph[struct{}, int], f *SpanningForest[int]) {
+	t.Helper()
+	u, w := newForest(g.Order()), 0
+	for _, e := range f.Edges {
+		if !g.HasEdge(e.From, e.To) {
+			t.Fatalf("forest edge %v is not in the graph", e)
+		}
+		if !u.Union(e.From, e.To) {
+			t.Fatalf("forest edge %v closes a cycle", e)
+		}
+		w += e.Weight
+	}
+	if w != f.Weight {
+		t.Fatalf("forest weighs %d, reported %d", w, f.Weight)
+	}
+	components := componentCount(g, -1, Edge[int]{From: -1})
+	if f.Trees.Count() != components || u.Count() != components || len(f.Edges) != g.Order()-components {
+		t.Fatalf("forest of %d trees and %d edges for %d components", f.Trees.Count(), len(f.Edges), components)
+	}
+}
+
+func TestSpanning_Known(t *testing.T) {
+	g := clrsGraph(t)
+	for _, build := range []func(*Graph[string, int], SpanningConfig) (*SpanningForest[int], error){
+		Kruskal[string, int], Prim[string, int], LazyPrim[string, int],
+	} {
+		f, err := build(g, SpanningConfig{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Weight != 37 || len(f.Edges) != 8 || !f.Spanning() {
+			t.Errorf("tree of %d edges weighing %d, want 8 weighing 37", len(f.Edges), f.Weight)
+		}
+	}
+}
+
+func TestPrim_GrowsFromFirstNode(t *testing.T) {
+	g := clrsGraph(t)
+	f, err := Prim(g, SpanningConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The order of figure 23.5, where ties pick the smaller node.
+	var order []int
+	for _, e := range f.Edges {
+		order = append(order, e.To)
+	}
+	if want := []int{1, 2, 8, 5, 6, 7, 3, 4}; !reflect.DeepEqual(order, want) {
+		t.Errorf("nodes added in order %v, want %v", order, want)
+	}
+}
+
+func TestSpanning_MatchesExhaustive(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, alg := range spanningAlgorithms {
+		for _, maximum := range []bool{false, true} {
+			tested := 0
+			for i := range 30 {
+				g := randomGraph(rng, false, 7, 12, -5, 10)
+				if componentCount(g, -1, Edge[int]{From: -1}) != 1 {
+					continue
+				}
+				tested++
+				f, err := alg.build(g, SpanningConfig{Maximum: maximum})
+				if err != nil {
+					t.Fatal(err)
+				}
+				checkForest(t, g, f)
+				if want := bestSpanningTree(g, maximum); f.Weight != want {
+					t.Fatalf("%s, maximum %v, graph %d: tree weighs %d, want %d", alg.name, maximum, i, f.Weight, want)
+				}
+			}
+			if tested < 10 {
+				t.Fatalf("only %d of the random graphs were connected", tested)
+			}
+		}
+	}
+}
+
+func TestSpanning_Forest(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, alg := range spanningAlgorithms {
+		for range 20 {
+			g := randomGraph(rng, false, 50, 40, 1, 10)
+			f, err := alg.build(g, SpanningConfig{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkForest(t, g, f)
+			for _, e := range f.Edges {
+				if f.Trees.Of[e.From] != f.Trees.Of[e.To] {
+					t.Fatalf("%s: edge %v joins trees %d and %d", alg.name, e, f.Trees.Of[e.From], f.Trees.Of[e.To])
+				}
+			}
+		}
+	}
+}
+
+func TestForestComponents(t *testing.T) {
+	trees := newForest(6)
+	for _, p := range [][2]int{{0, 3}, {4, 3}, {1, 5}} {
+		trees.Union(p[0], p[1])
+	}
+	c := forestComponents(trees)
+	if want := [][]int{{0, 3, 4}, {1, 5}, {2}}; !reflect.DeepEqual(c.Members, want) {
+		t.Errorf("components %v, want %v", c.Members, want)
+	}
+	if want := []int{0, 1, 2, 0, 0, 1}; !reflect.DeepEqual(c.Of, want) {
+		t.Errorf("component of every node %v, want %v", c.Of, want)
+	}
+}
+
+func TestSpanning_Directed(t *testing.T) {
+	for _, alg := range spanningAlgorithms {
+		if _, err := alg.build(NewDirected[struct{}, int](), SpanningConfig{}); !errors.Is(err, errDirectedSpanning) {
+			t.Errorf("%s: expected an error for a directed graph, got %v", alg.name, err)
+		}
+	}
+}
+
+func BenchmarkSpanning(b *testing.B) {
+	for _, size := range []struct {
+		name string
+		n, m int
+	}{
+		{"sparse", 10000, 40000},
+		{"dense", 1000, 250000},
+	} {
+		g := randomGraph(rand.New(rand.NewSource(1)), false, size.n, size.m, 1, 1000)
+		for _, alg := range spanningAlgorithms {
+			b.Run(fmt.Sprintf("%s/%s", size.name, alg.name), func(b *testing.B) {
+				for range b.N {
+					alg.build(g, SpanningConfig{})
+				}
+			})
+		}
+	}
+}
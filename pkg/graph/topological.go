@@ -0,0 +1,207 @@
+package graph
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrCycle is matched by the *CycleError of the topological sorts.
+var ErrCycle = errors.New("graph: cycle")
+
+// CycleError reports a cycle that rules out a topological order.
+type CycleError struct {
+	// Cycle holds the nodes of the cycle in edge order, starting at the
+	// smallest.
+	Cycle []int
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("graph: not acyclic, cycle through %v", e.Cycle)
+}
+
+func (e *CycleError) Is(target error) bool { return target == ErrCycle }
+
+var errUndirected = errors.New("graph: no topological order of an undirected graph")
+
+// TopologicalConfig tunes TopologicalSort.
+type TopologicalConfig struct {
+	// Less picks among the nodes ready at the same time, those whose
+	// predecessors are all placed: the least is placed first. For
+	// example, func(u, v int) bool { return u < v } yields the
+	// lexicographically smallest order. Nil places them in the order they
+	// became ready, the cheapest.
+	Less func(u, v int) bool
+}
+
+// TopologicalSort returns the nodes of the directed graph g ordered so that
+// every edge leads forward, with Kahn's algorithm: repeatedly place a node
+// whose predecessors are all placed. It runs in O(n+m), or O(n log n + m)
+// with cfg.Less. If g has a cycle it returns a *CycleError holding one.
+func TopologicalSort[N any, W Weight](g *Graph[N, W], cfg TopologicalConfig) ([]int, error) {
+	if !g.directed {
+		return nil, errUndirected
+	}
+	n := g.Order()
+	indegree := make([]int, n)
+	for v := range indegree {
+		indegree[v] = len(g.in[v])
+	}
+	ready := &readyQueue{less: cfg.Less}
+	for v, d := range indegree {
+		if d == 0 {
+			ready.push(v)
+		}
+	}
+	order := make([]int, 0, n)
+	for ready.Len() > 0 {
+		u := ready.pop()
+		order = append(order, u)
+		for _, e := range g.out[u] {
+			if indegree[e.To]--; indegree[e.To] == 0 {
+				ready.push(e.To)
+			}
+		}
+	}
+	if len(order) < n {
+		return nil, &CycleError{Cycle: unplacedCycle(g, indegree)}
+	}
+	return order, nil
+}
+
+// unplacedCycle returns a cycle among the nodes Kahn's algorithm could not
+// place, those left with a positive in-degree: each has a predecessor among
+// them, so walking predecessors must come back to a node.
+func unplacedCycle[N any, W Weight](g *Graph[N, W], indegree []int) []int {
+	v := slices.IndexFunc(indegree, func(d int) bool { return d > 0 })
+	step := make([]int, g.Order()) // position of every node on the walk, 1-based
+	var walk []int
+	for step[v] == 0 {
+		walk = append(walk, v)
+		step[v] = len(walk)
+		for _, e := range g.in[v] {
+			if indegree[e.From] > 0 {
+				v = e.From
+				break
+			}
+		}
+	}
+	cycle := walk[step[v]-1:]
+	slices.Reverse(cycle)
+	return canonicalCycle(cycle)
+}
+
+// TopologicalSortDFS returns the nodes of the directed graph g ordered so
+// that every edge leads forward: the reverse of the order in which a DFS
+// finishes them. It runs in O(n+m). If g has a cycle it returns a
+// *CycleError holding one, closed by the first back edge the DFS meets.
+func TopologicalSortDFS[N any, W Weight](g *Graph[N, W]) ([]int, error) {
+	if !g.directed {
+		return nil, errUndirected
+	}
+	var back Edge[W]
+	t := DFS(g, Visitor[W]{Edge: func(e Edge[W], kind EdgeKind) bool {
+		back = e
+		return kind != BackEdge
+	}})
+	if t.Stopped {
+		// The back edge leads from back.From to its ancestor back.To; the
+		// tree path between them closes the cycle.
+		var cycle []int
+		for v := back.From; v != back.To; v = t.Parent[v] {
+			cycle = append(cycle, v)
+		}
+		cycle = append(cycle, back.To)
+		slices.Reverse(cycle)
+		return nil, &CycleError{Cycle: canonicalCycle(cycle)}
+	}
+	order := t.Post
+	slices.Reverse(order)
+	return order, nil
+}
+
+// AllTopologicalOrders returns every topological order of the directed graph
+// g, in lexicographic order, or only the first limit if limit is positive.
+// There can be up to n! of them, so it is meant for small graphs. If g has
+// a cycle it returns a *CycleError holding one.
+func AllTopologicalOrders[N any, W Weight](g *Graph[N, W], limit int) ([][]int, error) {
+	if _, err := TopologicalSort(g, TopologicalConfig{}); err != nil {
+		return nil, err
+	}
+	n := g.Order()
+	indegree := make([]int, n)
+	for v := range indegree {
+		indegree[v] = len(g.in[v])
+	}
+	placed := make([]bool, n)
+	order := make([]int, 0, n)
+	var orders [][]int
+
+	// place extends order by every ready node in turn, smallest first, and
+	// reports whether to go on.
+	var place func() bool
+	place = func() bool {
+		if len(order) == n {
+			orders = append(orders, slices.Clone(order))
+			return limit <= 0 || len(orders) < limit
+		}
+		for u := range n {
+			if placed[u] || indegree[u] > 0 {
+				continue
+			}
+			placed[u] = true
+			order = append(order, u)
+			for _, e := range g.out[u] {
+				indegree[e.To]--
+			}
+			more := place()
+			for _, e := range g.out[u] {
+				indegree[e.To]++
+			}
+			order = order[:len(order)-1]
+			placed[u] = false
+			if !more {
+				return false
+			}
+		}
+		return true
+	}
+	place()
+	return orders, nil
+}
+
+// readyQueue holds the nodes ready to be placed: a FIFO queue, or a heap
+// ordered by less if it is set.
+type readyQueue struct {
+	nodes []int
+	less  func(u, v int) bool
+}
+
+func (q *readyQueue) push(v int) {
+	if q.less == nil {
+		q.nodes = append(q.nodes, v)
+		return
+	}
+	heap.Push(q, v)
+}
+
+func (q *readyQueue) pop() int {
+	if q.less == nil {
+		v := q.nodes[0]
+		q.nodes = q.nodes[1:]
+		return v
+	}
+	return heap.Pop(q).(int)
+}
+
+func (q *readyQueue) Len() int           { return len(q.nodes) }
+func (q *readyQueue) Less(i, j int) bool { return q.less(q.nodes[i], q.nodes[j]) }
+func (q *readyQueue) Swap(i, j int)      { q.nodes[i], q.nodes[j] = q.nodes[j], q.nodes[i] }
+func (q *readyQueue) Push(x any)         { q.nodes = append(q.nodes, x.(int)) }
+
+func (q *readyQueue) Pop() any {
+	v := q.nodes[len(q.nodes)-1]
+	q.nodes = q.nodes[:len(q.nodes)-1]
+	return v
+}
@@ -0,0 +1,208 @@
+package graph
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// randomDAG returns a random directed acyclic graph: edges lead from a node
+// to a later one of a random permutation.
+func randomDAG(rng *rand.Rand, n, m int) *Graph[struct{}, int] {
+	perm := rng.Perm(n)
+	g := NewDirected[struct{}, int]()
+	g.AddNodes(make([]struct{}, n)...)
+	for range m {
+		i, j := rng.Intn(n), rng.Intn(n)
+		if i == j {
+			continue
+		}
+		g.AddEdge(perm[min(i, j)], perm[max(i, j)], 1)
+	}
+	return g
+}
+
+func checkTopological(t *testing.T, g *Graph[struct{}, int], order []int) {
+	t.Helper()
+	pos := make([]int, g.Order())
+	for i := range pos {
+		pos[i] = -1
+	}
+	for i, v := range order {
+		if pos[v] >= 0 {
+			t.Fatalf("node %d placed twice in %v", v, order)
+		}
+		pos[v] = i
+	}
+	if len(order) != g.Order() {
+		t.Fatalf("order of %d nodes, want %d", len(order), g.Order())
+	}
+	for _, e := range g.Edges() {
+		if pos[e.From] >= pos[e.To] {
+			t.Fatalf("edge %v leads backwards in %v", e, order)
+		}
+	}
+}
+
+var topologicalSorts = []struct {
+	name string
+	sort func(*Graph[struct{}, int]) ([]int, error)
+}{
+	{"kahn", func(g *Graph[struct{}, int]) ([]int, error) { return TopologicalSort(g, TopologicalConfig{}) }},
+	{"kahn smallest", func(g *Graph[struct{}, int]) ([]int, error) {
+		return TopologicalSort(g, TopologicalConfig{Less: func(u, v int) bool { return u < v }})
+	}},
+	{"dfs", TopologicalSortDFS[struct{}, int]},
+}
+
+func TestTopologicalSort_RandomDAGs(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, s := range topologicalSorts {
+		s := s
+		t.Run(s.name, func(t *testing.T) {
+			for range 20 {
+				g := randomDAG(rng, 60, 150)
+				order, err := s.sort(g)
+				if err != nil {
+					t.Fatal(err)
+				}
+				checkTopological(t, g, order)
+			}
+		})
+	}
+}
+
+func TestTopologicalSort_TieBreaking(t *testing.T) {
+	// 5 and 4 have no predecessors; 2 needs 5, 0 needs 4 and 5, 1 needs 3
+	// and 4, 3 needs 2.
+	g, err := FromEdges[struct{}](true, 6, edges([3]int{5, 2, 1}, [3]int{5, 0, 1}, [3]int{4, 0, 1}, [3]int{4, 1, 1}, [3]int{2, 3, 1}, [3]int{3, 1, 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		less func(u, v int) bool
+		want []int
+	}{
+		{"fifo", nil, []int{4, 5, 2, 0, 3, 1}},
+		{"smallest", func(u, v int) bool { return u < v }, []int{4, 5, 0, 2, 3, 1}},
+		{"largest", func(u, v int) bool { return u > v }, []int{5, 4, 2, 3, 1, 0}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := TopologicalSort(g, TopologicalConfig{Less: tc.less})
+			if err != nil || !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v, %v, want %v", got, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestTopologicalSort_Cycle(t *testing.T) {
+	tests := []struct {
+		name  string
+		edges []Edge[int]
+		cycle []int
+	}{
+		{"triangle", edges([3]int{0, 1, 1}, [3]int{1, 2, 1}, [3]int{2, 3, 1}, [3]int{3, 1, 1}, [3]int{3, 4, 1}), []int{1, 2, 3}},
+		{"self-loop", edges([3]int{0, 1, 1}, [3]int{2, 2, 1}), []int{2}},
+		{"two nodes", edges([3]int{4, 3, 1}, [3]int{3, 4, 1}, [3]int{0, 3, 1}), []int{3, 4}},
+	}
+	for _, s := range topologicalSorts {
+		for _, tc := range tests {
+			s, tc := s, tc
+			t.Run(s.name+"/"+tc.name, func(t *testing.T) {
+				g, err := FromEdges[struct{}](true, 5, tc.edges)
+				if err != nil {
+					t.Fatal(err)
+				}
+				order, err := s.sort(g)
+				var cerr *CycleError
+				if !errors.As(err, &cerr) || !errors.Is(err, ErrCycle) || order != nil {
+					t.Fatalf("expected a *CycleError, got %v, %v", order, err)
+				}
+				if !reflect.DeepEqual(cerr.Cycle, tc.cycle) {
+					t.Errorf("cycle %v, want %v", cerr.Cycle, tc.cycle)
+				}
+			})
+		}
+	}
+}
+
+func TestTopologicalSort_RandomCycles(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, s := range topologicalSorts {
+		for range 20 {
+			g := randomDAG(rng, 50, 100)
+			u, v := rng.Intn(50), rng.Intn(50)
+			g.AddEdge(u, v, 1)
+			order, err := s.sort(g)
+			var cerr *CycleError
+			if !errors.As(err, &cerr) {
+				if err != nil {
+					t.Fatalf("%s: %v", s.name, err)
+				}
+				checkTopological(t, g, order)
+				continue
+			}
+			for i, u := range cerr.Cycle {
+				if !g.HasEdge(u, cerr.Cycle[(i+1)%len(cerr.Cycle)]) {
+					t.Fatalf("%s: cycle %v uses a missing edge", s.name, cerr.Cycle)
+				}
+			}
+		}
+	}
+}
+
+func TestTopologicalSort_Undirected(t *testing.T) {
+	g := NewUndirected[struct{}, int]()
+	if _, err := TopologicalSort(g, TopologicalConfig{}); err == nil {
+		t.Error("expected an error for an undirected graph")
+	}
+	if _, err := TopologicalSortDFS(g); err == nil {
+		t.Error("expected an error for an undirected graph")
+	}
+}
+
+func TestAllTopologicalOrders(t *testing.T) {
+	tests := []struct {
+		name  string
+		n     int
+		edges []Edge[int]
+		limit int
+		want  [][]int
+		count int
+	}{
+		{"chain", 3, edges([3]int{2, 1, 1}, [3]int{1, 0, 1}), 0, [][]int{{2, 1, 0}}, 1},
+		{"diamond", 4, edges([3]int{0, 1, 1}, [3]int{0, 2, 1}, [3]int{1, 3, 1}, [3]int{2, 3, 1}), 0, [][]int{{0, 1, 2, 3}, {0, 2, 1, 3}}, 2},
+		{"no edges", 4, nil, 0, nil, 24},
+		{"limited", 4, nil, 3, [][]int{{0, 1, 2, 3}, {0, 1, 3, 2}, {0, 2, 1, 3}}, 3},
+		{"two chains", 4, edges([3]int{0, 1, 1}, [3]int{2, 3, 1}), 0, nil, 6},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g, err := FromEdges[struct{}](true, tc.n, tc.edges)
+			if err != nil {
+				t.Fatal(err)
+			}
+			orders, err := AllTopologicalOrders(g, tc.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(orders) != tc.count || (tc.want != nil && !reflect.DeepEqual(orders, tc.want)) {
+				t.Fatalf("got %d orders %v, want %d", len(orders), orders, tc.count)
+			}
+			for _, o := range orders {
+				checkTopological(t, g, o)
+			}
+		})
+	}
+
+	g, _ := FromEdges[struct{}](true, 2, edges([3]int{0, 1, 1}, [3]int{1, 0, 1}))
+	if _, err := AllTopologicalOrders(g, 0); !errors.Is(err, ErrCycle) {
+		t.Errorf("expected ErrCycle, got %v", err)
+	}
+}
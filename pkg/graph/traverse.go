@@ -0,0 +1,227 @@
+package graph
+
+import "fmt"
+
+// EdgeKind classifies an edge examined by a traversal with respect to the
+// search forest it builds.
+type EdgeKind int
+
+const (
+	// TreeEdge leads to a node discovered through it.
+	TreeEdge EdgeKind = iota
+	// BackEdge leads to an ancestor still being explored: it closes a cycle.
+	BackEdge
+	// ForwardEdge leads to a descendant already finished. Directed DFS only.
+	ForwardEdge
+	// CrossEdge leads to a finished node that is no descendant. Directed DFS
+	// only.
+	CrossEdge
+	// NonTreeEdge leads to a node discovered before. BFS only, which cannot
+	// tell the kinds above apart.
+	NonTreeEdge
+)
+
+func (k EdgeKind) String() string {
+	switch k {
+	case TreeEdge:
+		return "tree"
+	case BackEdge:
+		return "back"
+	case ForwardEdge:
+		return "forward"
+	case CrossEdge:
+		return "cross"
+	case NonTreeEdge:
+		return "non-tree"
+	default:
+		return fmt.Sprintf("edgekind(%d)", int(k))
+	}
+}
+
+// Visitor holds the hooks a traversal calls. Nil hooks are skipped. A hook
+// returning false stops the traversal at once.
+type Visitor[W Weight] struct {
+	// Discover is called when a node is reached for the first time.
+	Discover func(u int) bool
+	// Edge is called for every edge examined, before the node it leads to
+	// is discovered. DFS of an undirected graph reports every edge once, as
+	// a tree or back edge, and skips the edge back to the parent.
+	Edge func(e Edge[W], kind EdgeKind) bool
+	// Finish is called when all edges leaving a node have been examined.
+	Finish func(u int) bool
+}
+
+// Traversal is the search forest built by BFS or DFS.
+type Traversal struct {
+	Parent  []int // parent of every node in the forest, -1 for roots and nodes not reached
+	Depth   []int // number of tree edges from the root, -1 for nodes not reached
+	Order   []int // nodes in discovery order
+	Post    []int // nodes in finish order
+	Stopped bool  // a hook stopped the traversal
+}
+
+func newTraversal(n int) *Traversal {
+	t := &Traversal{Parent: make([]int, n), Depth: make([]int, n)}
+	for i := range t.Parent {
+		t.Parent[i], t.Depth[i] = -1, -1
+	}
+	return t
+}
+
+// Reached reports whether the traversal discovered v.
+func (t *Traversal) Reached(v int) bool { return t.Depth[v] >= 0 }
+
+// PathTo returns the tree path from the root of v to v, or nil if v was not
+// reached. After BFS it is a path of fewest edges from the nearest source.
+func (t *Traversal) PathTo(v int) []int {
+	if !t.Reached(v) {
+		return nil
+	}
+	path := make([]int, t.Depth[v]+1)
+	for i := len(path) - 1; i >= 0; i-- {
+		path[i] = v
+		v = t.Parent[v]
+	}
+	return path
+}
+
+// BFS explores g breadth first from sources, all at depth 0, or from every
+// node in ID order if there are none, each unreached node starting a new
+// tree.
+func BFS[N any, W Weight](g *Graph[N, W], vis Visitor[W], sources ...int) *Traversal {
+	t := newTraversal(g.Order())
+	queue := make([]int, 0, g.Order())
+	discover := func(u, parent, depth int) bool {
+		t.Parent[u], t.Depth[u] = parent, depth
+		t.Order = append(t.Order, u)
+		queue = append(queue, u)
+		return vis.Discover == nil || vis.Discover(u)
+	}
+	run := func(sources []int) bool {
+		for _, s := range sources {
+			g.mustExist(s)
+			if !t.Reached(s) && !discover(s, -1, 0) {
+				return false
+			}
+		}
+		for ; len(queue) > 0; queue = queue[1:] {
+			u := queue[0]
+			for _, e := range g.out[u] {
+				kind := NonTreeEdge
+				if !t.Reached(e.To) {
+					kind = TreeEdge
+				}
+				if vis.Edge != nil && !vis.Edge(e, kind) {
+					return false
+				}
+				if kind == TreeEdge && !discover(e.To, u, t.Depth[u]+1) {
+					return false
+				}
+			}
+			t.Post = append(t.Post, u)
+			if vis.Finish != nil && !vis.Finish(u) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if len(sources) > 0 {
+		t.Stopped = !run(sources)
+		return t
+	}
+	for u := range g.Order() {
+		if !t.Reached(u) && !run([]int{u}) {
+			t.Stopped = true
+			break
+		}
+	}
+	return t
+}
+
+// DFS explores g depth first from each of sources in turn, or from every
+// node in ID order if there are none, each unreached node starting a new
+// tree. It keeps an explicit stack, so deep graphs don't overflow the
+// goroutine stack.
+func DFS[N any, W Weight](g *Graph[N, W], vis Visitor[W], sources ...int) *Traversal {
+	n := g.Order()
+	t := newTraversal(n)
+	pre := make([]int, n) // discovery index
+	finished := make([]bool, n)
+
+	type frame struct {
+		u      int
+		next   int  // index of the next edge of u to examine
+		parent bool // the edge back to the parent was skipped
+	}
+	var stack []frame
+	discover := func(u, parent int) bool {
+		t.Parent[u] = parent
+		t.Depth[u] = 0
+		if parent >= 0 {
+			t.Depth[u] = t.Depth[parent] + 1
+		}
+		pre[u] = len(t.Order)
+		t.Order = append(t.Order, u)
+		stack = append(stack, frame{u: u})
+		return vis.Discover == nil || vis.Discover(u)
+	}
+	run := func(s int) bool {
+		if !discover(s, -1) {
+			return false
+		}
+		for len(stack) > 0 {
+			f := &stack[len(stack)-1]
+			if f.next == len(g.out[f.u]) {
+				stack = stack[:len(stack)-1]
+				finished[f.u] = true
+				t.Post = append(t.Post, f.u)
+				if vis.Finish != nil && !vis.Finish(f.u) {
+					return false
+				}
+				continue
+			}
+			e := g.out[f.u][f.next]
+			f.next++
+
+			var kind EdgeKind
+			switch v := e.To; {
+			case !t.Reached(v):
+				kind = TreeEdge
+			case !g.directed && v == t.Parent[f.u] && !f.parent:
+				f.parent = true
+				continue
+			case !g.directed && finished[v]:
+				continue // examined from v as a back edge
+			case !finished[v]:
+				kind = BackEdge
+			case pre[v] > pre[f.u]:
+				kind = ForwardEdge
+			default:
+				kind = CrossEdge
+			}
+			if vis.Edge != nil && !vis.Edge(e, kind) {
+				return false
+			}
+			if kind == TreeEdge && !discover(e.To, f.u) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if len(sources) == 0 {
+		sources = make([]int, n)
+		for i := range sources {
+			sources[i] = i
+		}
+	}
+	for _, s := range sources {
+		g.mustExist(s)
+		if !t.Reached(s) && !run(s) {
+			t.Stopped = true
+			break
+		}
+	}
+	return t
+}
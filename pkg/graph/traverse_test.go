@@ -0,0 +1,201 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+// grid returns the undirected rows×cols grid graph, node r*cols+c at row r
+// and column c.
+func grid(rows, cols int) *Graph[struct{}, int] {
+	g := NewUndirected[struct{}, int]()
+	g.AddNodes(make([]struct{}, rows*cols)...)
+	for r := range rows {
+		for c := range cols {
+			u := r*cols + c
+			if c+1 < cols {
+				g.AddEdge(u, u+1, 1)
+			}
+			if r+1 < rows {
+				g.AddEdge(u, u+cols, 1)
+			}
+		}
+	}
+	return g
+}
+
+func TestBFS_DepthAndPaths(t *testing.T) {
+	g := grid(3, 4)
+	tests := []struct {
+		name    string
+		sources []int
+		depth   []int
+		path    []int // path to node 11
+	}{
+		{"corner", []int{0}, []int{0, 1, 2, 3, 1, 2, 3, 4, 2, 3, 4, 5}, []int{0, 1, 2, 3, 7, 11}},
+		{"two sources", []int{0, 11}, []int{0, 1, 2, 2, 1, 2, 2, 1, 2, 2, 1, 0}, []int{11}},
+		{"middle", []int{5}, []int{2, 1, 2, 3, 1, 0, 1, 2, 2, 1, 2, 3}, []int{5, 6, 7, 11}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tr := BFS(g, Visitor[int]{}, tc.sources...)
+			if !reflect.DeepEqual(tr.Depth, tc.depth) {
+				t.Errorf("depths %v, want %v", tr.Depth, tc.depth)
+			}
+			if got := tr.PathTo(11); !reflect.DeepEqual(got, tc.path) {
+				t.Errorf("path to 11: got %v, want %v", got, tc.path)
+			}
+			for i := 1; i < len(tr.Order); i++ {
+				if tr.Depth[tr.Order[i]] < tr.Depth[tr.Order[i-1]] {
+					t.Fatalf("discovery order %v is not by depth", tr.Order)
+				}
+			}
+			if len(tr.Order) != g.Order() || len(tr.Post) != g.Order() || tr.Stopped {
+				t.Errorf("traversal incomplete: %+v", tr)
+			}
+		})
+	}
+}
+
+func TestBFS_Forest(t *testing.T) {
+	g := mustFromEdges(t, true, 5, edges([3]int{1, 0, 1}, [3]int{3, 4, 1}))
+	tr := BFS(g, Visitor[int]{})
+	if want := []int{-1, -1, -1, -1, 3}; !reflect.DeepEqual(tr.Parent, want) {
+		t.Errorf("parents %v, want %v", tr.Parent, want)
+	}
+	if want := []int{0, 1, 2, 3, 4}; !reflect.DeepEqual(tr.Order, want) {
+		t.Errorf("order %v, want %v", tr.Order, want)
+	}
+
+	from1 := BFS(g, Visitor[int]{}, 1)
+	if from1.Reached(3) || from1.PathTo(3) != nil || !reflect.DeepEqual(from1.PathTo(0), []int{1, 0}) {
+		t.Errorf("unexpected traversal from 1: %+v", from1)
+	}
+}
+
+func TestDFS_EdgeKinds(t *testing.T) {
+	type kinded struct {
+		from, to int
+		kind     EdgeKind
+	}
+	tests := []struct {
+		name     string
+		directed bool
+		edges    []Edge[int]
+		want     []kinded
+	}{
+		{
+			"directed", true,
+			edges([3]int{0, 1, 0}, [3]int{0, 2, 0}, [3]int{1, 2, 0}, [3]int{2, 0, 0}, [3]int{3, 2, 0}, [3]int{3, 3, 0}),
+			[]kinded{{0, 1, TreeEdge}, {1, 2, TreeEdge}, {2, 0, BackEdge}, {0, 2, ForwardEdge}, {3, 2, CrossEdge}, {3, 3, BackEdge}},
+		},
+		{
+			// A triangle with a pendant node and a doubled edge: the second
+			// copy of 2-3 closes a cycle, the edges back to parents don't.
+			"undirected", false,
+			edges([3]int{0, 1, 0}, [3]int{1, 2, 0}, [3]int{2, 0, 0}, [3]int{2, 3, 0}, [3]int{3, 2, 0}),
+			[]kinded{{0, 1, TreeEdge}, {1, 2, TreeEdge}, {2, 0, BackEdge}, {2, 3, TreeEdge}, {3, 2, BackEdge}},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := mustFromEdges(t, tc.directed, 4, tc.edges)
+			var got []kinded
+			DFS(g, Visitor[int]{Edge: func(e Edge[int], kind EdgeKind) bool {
+				got = append(got, kinded{e.From, e.To, kind})
+				return true
+			}})
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDFS_Parenthesis(t *testing.T) {
+	g := grid(4, 4)
+	var events []int // +u+1 on discovery, -(u+1) on finish
+	tr := DFS(g, Visitor[int]{
+		Discover: func(u int) bool { events = append(events, u+1); return true },
+		Finish:   func(u int) bool { events = append(events, -(u + 1)); return true },
+	}, 5)
+	var open []int
+	for _, ev := range events {
+		if ev > 0 {
+			open = append(open, ev)
+			continue
+		}
+		if len(open) == 0 || open[len(open)-1] != -ev {
+			t.Fatalf("finish of %d does not close the last discovered node in %v", -ev-1, events)
+		}
+		open = open[:len(open)-1]
+	}
+	if len(tr.Order) != 16 || tr.Order[0] != 5 || tr.Post[15] != 5 {
+		t.Errorf("unexpected orders %v and %v", tr.Order, tr.Post)
+	}
+	for v := range 16 {
+		if p := tr.Parent[v]; p >= 0 && !g.HasEdge(p, v) {
+			t.Errorf("parent %d of %d is not adjacent", p, v)
+		}
+	}
+}
+
+func TestTraversal_EarlyStop(t *testing.T) {
+	g := grid(10, 10)
+	const target = 45
+	tests := []struct {
+		name    string
+		search  func(*Graph[struct{}, int], Visitor[int], ...int) *Traversal
+		vis     Visitor[int]
+		reached bool // the target is discovered before the stop
+	}{
+		{"bfs discover", BFS[struct{}, int], Visitor[int]{Discover: func(u int) bool { return u != target }}, true},
+		{"dfs discover", DFS[struct{}, int], Visitor[int]{Discover: func(u int) bool { return u != target }}, true},
+		{"bfs finish", BFS[struct{}, int], Visitor[int]{Finish: func(u int) bool { return u != target }}, true},
+		{"dfs edge", DFS[struct{}, int], Visitor[int]{Edge: func(e Edge[int], _ EdgeKind) bool { return e.To != target }}, false},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tr := tc.search(g, tc.vis, 0)
+			if !tr.Stopped || len(tr.Order) == g.Order() {
+				t.Fatalf("expected an early stop, visited %d nodes", len(tr.Order))
+			}
+			if tr.Reached(target) != tc.reached {
+				t.Fatalf("target reached %v, want %v", tr.Reached(target), tc.reached)
+			}
+			if !tc.reached {
+				return
+			}
+			path := tr.PathTo(target)
+			if len(path) == 0 || path[0] != 0 || path[len(path)-1] != target {
+				t.Fatalf("unexpected path %v", path)
+			}
+			for i := 1; i < len(path); i++ {
+				if !g.HasEdge(path[i-1], path[i]) {
+					t.Fatalf("path %v is not a walk", path)
+				}
+			}
+			if tc.name == "bfs discover" && len(path) != 10 {
+				t.Errorf("BFS path of %d nodes, want the shortest of 10", len(path))
+			}
+		})
+	}
+}
+
+func TestDFS_DeepGraph(t *testing.T) {
+	const n = 1 << 20
+	g, err := FromEdges[struct{}, int](true, n, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for u := 0; u+1 < n; u++ {
+		g.AddEdge(u, u+1, 1)
+	}
+	tr := DFS(g, Visitor[int]{}, 0)
+	if tr.Depth[n-1] != n-1 || tr.Post[0] != n-1 {
+		t.Errorf("depth of the last node %d, first finished %d", tr.Depth[n-1], tr.Post[0])
+	}
+}
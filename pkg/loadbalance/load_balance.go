@@ -0,0 +1,287 @@
+// Package loadbalance simulates distributing weighted tasks across ranks.
+// Every round the ranks exchange load summaries with an allgather (built on
+// the ring all–reduce), agree on the same migration plan and then work off
+// their queues.
+//
+// References:
+//
+// https://en.wikipedia.org/wiki/Longest-processing-time-first_scheduling
+// https://en.wikipedia.org/wiki/Work_stealing
+package loadbalance
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/sanderblue/algorithms/pkg/ringallreduce"
+)
+
+// Task is a unit of work; Weight is the remaining amount of work.
+type Task struct {
+	ID     int
+	Weight float64
+}
+
+// Strategy selects how queued tasks are moved between ranks.
+type Strategy int
+
+const (
+	// LongestProcessingTime repeatedly moves the largest task that narrows
+	// the gap from the most to the least loaded rank.
+	LongestProcessingTime Strategy = iota
+	// WorkStealing lets underloaded ranks take tasks from the tail of the
+	// most loaded rank's queue.
+	WorkStealing
+)
+
+// Round records the state observed at the start of a round, before
+// migration, together with the migrations performed in it.
+type Round struct {
+	Loads      []float64 // queued work per rank as gathered by the allgather
+	Mean       float64
+	Max        float64
+	Imbalance  float64 // Max / Mean; 1 means perfectly balanced
+	StdDev     float64
+	Migrations int
+}
+
+// Balancer simulates rounds of work over P ranks, rebalancing their queues
+// with Strategy at the start of every round.
+type Balancer struct {
+	P        int
+	Strategy Strategy
+	// Speeds[i] is the work rank i completes per round; defaults to 1.
+	Speeds []float64
+	// StealThreshold is the fraction below the mean load at which a rank
+	// starts stealing. Only used by WorkStealing.
+	StealThreshold float64
+	// Arrivals, when set, returns the tasks that arrive at rank in round.
+	Arrivals func(round, rank int) []Task
+}
+
+// New returns a balancer of p ranks using strategy, with unit speeds and a
+// steal threshold of 0.25.
+func New(p int, strategy Strategy) *Balancer {
+	return &Balancer{P: p, Strategy: strategy, StealThreshold: 0.25}
+}
+
+// LPT assigns tasks to p ranks using the longest-processing-time-first rule:
+// tasks are placed in decreasing weight order onto the least loaded rank.
+// The resulting makespan is at most 4/3 of the optimum when no weight is
+// negative.
+func LPT(tasks []Task, p int) [][]Task {
+	sorted := make([]Task, len(tasks))
+	copy(sorted, tasks)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Weight > sorted[j].Weight })
+
+	queues := make([][]Task, p)
+	loads := make([]float64, p)
+	for _, task := range sorted {
+		min := 0
+		for r := 1; r < p; r++ {
+			if loads[r] < loads[min] {
+				min = r
+			}
+		}
+		queues[min] = append(queues[min], task)
+		loads[min] += task.Weight
+	}
+	return queues
+}
+
+// Run simulates up to rounds rounds starting from queues (one queue per
+// rank) and returns the metrics of every round. It stops early once all
+// queues are drained and no more tasks arrive. Task weights, including
+// those of arriving tasks, must not be negative.
+func (b *Balancer) Run(queues [][]Task, rounds int) ([]Round, error) {
+	if b.P <= 0 {
+		return nil, errors.New("loadbalance: need at least one rank")
+	}
+	if len(queues) != b.P {
+		return nil, errors.New("loadbalance: need one queue per rank")
+	}
+	state := make([][]Task, b.P)
+	for i, q := range queues {
+		if err := checkWeights(q); err != nil {
+			return nil, err
+		}
+		state[i] = append([]Task(nil), q...)
+	}
+
+	r := ringallreduce.New()
+	ring := ringallreduce.NewRing(b.P)
+
+	var history []Round
+	for round := 0; round < rounds; round++ {
+		if b.Arrivals != nil {
+			for rank := range state {
+				arrived := b.Arrivals(round, rank)
+				if err := checkWeights(arrived); err != nil {
+					return history, err
+				}
+				state[rank] = append(state[rank], arrived...)
+			}
+		}
+
+		// Allgather: every rank contributes its own load at its own index.
+		contributions := make([][]float64, b.P)
+		for rank := range state {
+			contributions[rank] = make([]float64, b.P)
+			contributions[rank][rank] = totalWeight(state[rank])
+		}
+		gathered, err := r.AllReduce(ring, contributions)
+		if err != nil {
+			return history, err
+		}
+		loads := gathered[0]
+
+		stats := summarize(loads)
+		if stats.Max == 0 && b.Arrivals == nil {
+			break
+		}
+
+		switch b.Strategy {
+		case WorkStealing:
+			stats.Migrations = b.steal(state, append([]float64(nil), loads...))
+		default:
+			stats.Migrations = b.lpt(state, append([]float64(nil), loads...))
+		}
+		history = append(history, stats)
+
+		for rank := range state {
+			state[rank] = process(state[rank], b.speed(rank))
+		}
+	}
+	return history, nil
+}
+
+func (b *Balancer) speed(rank int) float64 {
+	if rank < len(b.Speeds) {
+		return b.Speeds[rank]
+	}
+	return 1
+}
+
+// lpt moves the largest task from the most to the least loaded rank as long
+// as doing so narrows the gap between them.
+func (b *Balancer) lpt(state [][]Task, loads []float64) int {
+	moves := 0
+	for {
+		hi, lo := argMax(loads), argMin(loads)
+		gap := loads[hi] - loads[lo]
+		best := -1
+		for i, task := range state[hi] {
+			if task.Weight < gap && (best < 0 || task.Weight > state[hi][best].Weight) {
+				best = i
+			}
+		}
+		if best < 0 || hi == lo {
+			return moves
+		}
+		task := state[hi][best]
+		state[hi] = append(state[hi][:best], state[hi][best+1:]...)
+		state[lo] = append(state[lo], task)
+		loads[hi] -= task.Weight
+		loads[lo] += task.Weight
+		moves++
+	}
+}
+
+// steal lets every rank below the stealing threshold take tasks from the
+// tail of the currently most loaded rank while that narrows their gap.
+func (b *Balancer) steal(state [][]Task, loads []float64) int {
+	mean := summarize(loads).Mean
+	moves := 0
+	for thief := range state {
+		if loads[thief] >= mean*(1-b.StealThreshold) {
+			continue
+		}
+		victim := argMax(loads)
+		for victim != thief && len(state[victim]) > 1 {
+			last := len(state[victim]) - 1
+			task := state[victim][last]
+			if loads[thief]+task.Weight >= loads[victim] {
+				break
+			}
+			state[victim] = state[victim][:last]
+			state[thief] = append(state[thief], task)
+			loads[victim] -= task.Weight
+			loads[thief] += task.Weight
+			moves++
+		}
+	}
+	return moves
+}
+
+// process works off speed units from the head of the queue.
+func process(queue []Task, speed float64) []Task {
+	for len(queue) > 0 && speed > 0 {
+		if queue[0].Weight > speed {
+			queue[0].Weight -= speed
+			break
+		}
+		speed -= queue[0].Weight
+		queue = queue[1:]
+	}
+	return queue
+}
+
+func summarize(loads []float64) Round {
+	s := Round{Loads: loads}
+	for _, l := range loads {
+		s.Mean += l
+		s.Max = math.Max(s.Max, l)
+	}
+	s.Mean /= float64(len(loads))
+	for _, l := range loads {
+		s.StdDev += (l - s.Mean) * (l - s.Mean)
+	}
+	s.StdDev = math.Sqrt(s.StdDev / float64(len(loads)))
+	if s.Mean > 0 {
+		s.Imbalance = s.Max / s.Mean
+	} else {
+		s.Imbalance = 1
+	}
+	return s
+}
+
+// checkWeights rejects tasks of negative or NaN weight, which would keep the
+// migration loops moving them back and forth forever.
+func checkWeights(tasks []Task) error {
+	for _, t := range tasks {
+		if !(t.Weight >= 0) {
+			return fmt.Errorf("loadbalance: task %d has invalid weight %v", t.ID, t.Weight)
+		}
+	}
+	return nil
+}
+
+func totalWeight(tasks []Task) float64 {
+	w := 0.0
+	for _, t := range tasks {
+		w += t.Weight
+	}
+	return w
+}
+
+func argMax(v []float64) int {
+	idx := 0
+	for i := range v {
+		if v[i] > v[idx] {
+			idx = i
+		}
+	}
+	return idx
+}
+
+func argMin(v []float64) int {
+	idx := 0
+	for i := range v {
+		if v[i] < v[idx] {
+			idx = i
+		}
+	}
+	return idx
+}
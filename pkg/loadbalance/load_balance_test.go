@@ -0,0 +1,123 @@
+package loadbalance
+
+import (
+	"math"
+	"testing"
+)
+
+func tasksOf(weights ...float64) []Task {
+	out := make([]Task, len(weights))
+	for i, w := range weights {
+		out[i] = Task{ID: i, Weight: w}
+	}
+	return out
+}
+
+func TestLPT(t *testing.T) {
+	queues := LPT(tasksOf(7, 5, 4, 3, 3, 2), 2)
+	loads := []float64{totalWeight(queues[0]), totalWeight(queues[1])}
+	if loads[0] != 12 || loads[1] != 12 {
+		t.Errorf("expected balanced loads [12 12], got %v", loads)
+	}
+	if queues[0][0].Weight != 7 || queues[1][0].Weight != 5 {
+		t.Errorf("expected the largest tasks placed first, got %v", queues)
+	}
+}
+
+func TestBalancer_Run(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy Strategy
+	}{
+		{name: "lpt", strategy: LongestProcessingTime},
+		{name: "work stealing", strategy: WorkStealing},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			// Everything starts on rank 0.
+			queues := [][]Task{tasksOf(4, 4, 3, 3, 2, 2, 1, 1, 1, 1), nil, nil, nil}
+			b := New(4, tc.strategy)
+			history, err := b.Run(queues, 100)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(history) < 2 {
+				t.Fatalf("expected several rounds, got %d", len(history))
+			}
+
+			first := history[0]
+			if first.Loads[0] != 22 || first.Imbalance != 4 {
+				t.Errorf("round 0: expected all 22 units on rank 0 (imbalance 4), got %+v", first)
+			}
+			if first.Migrations == 0 {
+				t.Errorf("round 0: expected migrations")
+			}
+			if second := history[1]; second.Imbalance >= first.Imbalance {
+				t.Errorf("expected imbalance to drop after rebalancing: %f -> %f", first.Imbalance, second.Imbalance)
+			}
+			// Perfect balance needs ceil(22/4) = 6 rounds at speed 1.
+			if len(history) > 8 {
+				t.Errorf("expected the queues to drain within 8 rounds, took %d", len(history))
+			}
+		})
+	}
+}
+
+func TestBalancer_HeterogeneousSpeedsAndArrivals(t *testing.T) {
+	b := New(2, LongestProcessingTime)
+	b.Speeds = []float64{1, 3}
+	b.Arrivals = func(round, rank int) []Task {
+		if rank == 0 && round < 5 {
+			return []Task{{ID: round, Weight: 2}}
+		}
+		return nil
+	}
+	history, err := b.Run([][]Task{nil, nil}, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history) != 10 {
+		t.Fatalf("expected 10 rounds, got %d", len(history))
+	}
+	moved := 0
+	for _, r := range history {
+		moved += r.Migrations
+	}
+	if moved == 0 {
+		t.Errorf("expected tasks arriving on rank 0 to migrate to the idle rank")
+	}
+	if last := history[len(history)-1]; last.Max != 0 {
+		t.Errorf("expected all work done by the last round, got loads %v", last.Loads)
+	}
+}
+
+func TestBalancer_QueueCountMismatch(t *testing.T) {
+	if _, err := New(3, WorkStealing).Run([][]Task{nil}, 1); err == nil {
+		t.Error("expected error for wrong number of queues")
+	}
+}
+
+func TestBalancer_NoRanks(t *testing.T) {
+	if _, err := New(0, LongestProcessingTime).Run(nil, 1); err == nil {
+		t.Error("expected error for zero ranks")
+	}
+}
+
+func TestBalancer_InvalidWeights(t *testing.T) {
+	for _, strategy := range []Strategy{LongestProcessingTime, WorkStealing} {
+		queues := [][]Task{tasksOf(5, -1, 3), nil}
+		if _, err := New(2, strategy).Run(queues, 5); err == nil {
+			t.Errorf("strategy %d: expected error for a negative weight", strategy)
+		}
+
+		b := New(2, strategy)
+		b.Arrivals = func(round, rank int) []Task {
+			return []Task{{ID: round, Weight: math.NaN()}}
+		}
+		if _, err := b.Run([][]Task{nil, nil}, 5); err == nil {
+			t.Errorf("strategy %d: expected error for a NaN arrival", strategy)
+		}
+	}
+}
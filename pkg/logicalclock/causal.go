@@ -0,0 +1,185 @@
+package logicalclock
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// Broadcast is a message broadcast to every process, stamped with the
+// vector of broadcasts its sender had delivered when sending it, its own
+// included.
+type Broadcast[T any] struct {
+	From    int
+	Vector  Vector
+	Payload T
+}
+
+// seq returns the position of b among the broadcasts of its sender, from 1.
+func (b Broadcast[T]) seq() uint64 { return b.Vector.at(b.From) }
+
+// CausalProcess delivers broadcasts in causal order: a broadcast is only
+// delivered after every broadcast its sender had delivered before sending
+// it. Broadcasts that arrive too early are buffered until their
+// dependencies are delivered; duplicates are dropped, so broadcasts can be
+// relayed for reliability.
+//
+// References:
+//
+// Birman, Schiper and Stephenson, Lightweight Causal and Atomic Group
+// Multicast, ACM Transactions on Computer Systems, 1991.
+type CausalProcess[T any] struct {
+	Process int
+
+	delivered Vector // broadcasts delivered from every process
+	pending   []Broadcast[T]
+}
+
+func NewCausalProcess[T any](process int) *CausalProcess[T] {
+	return &CausalProcess[T]{Process: process, delivered: make(Vector, process+1)}
+}
+
+// Broadcast stamps payload for broadcasting and delivers it locally.
+func (p *CausalProcess[T]) Broadcast(payload T) Broadcast[T] {
+	p.delivered[p.Process]++
+	return Broadcast[T]{From: p.Process, Vector: p.delivered.Clone(), Payload: payload}
+}
+
+// Receive takes b from the network and returns the broadcasts it made
+// deliverable, in delivery order: b itself, if its dependencies were all
+// delivered, followed by the buffered broadcasts waiting for it. It
+// reports whether b was new, neither delivered nor buffered before.
+func (p *CausalProcess[T]) Receive(b Broadcast[T]) (delivered []Broadcast[T], fresh bool) {
+	if b.seq() <= p.delivered.at(b.From) {
+		return nil, false
+	}
+	for _, q := range p.pending {
+		if q.From == b.From && q.seq() == b.seq() {
+			return nil, false
+		}
+	}
+	p.pending = append(p.pending, b)
+	for progress := true; progress; {
+		progress = false
+		kept := p.pending[:0]
+		for _, q := range p.pending {
+			if p.deliverable(q) {
+				p.deliver(q)
+				delivered = append(delivered, q)
+				progress = true
+			} else {
+				kept = append(kept, q)
+			}
+		}
+		p.pending = kept
+	}
+	return delivered, true
+}
+
+// deliverable reports whether b is the next broadcast of its sender and
+// every broadcast it depends on from the others was delivered.
+func (p *CausalProcess[T]) deliverable(b Broadcast[T]) bool {
+	for i, n := range b.Vector {
+		switch have := p.delivered.at(i); {
+		case i == b.From && n != have+1:
+			return false
+		case i != b.From && n > have:
+			return false
+		}
+	}
+	return true
+}
+
+func (p *CausalProcess[T]) deliver(b Broadcast[T]) {
+	for len(p.delivered) <= b.From {
+		p.delivered = append(p.delivered, 0)
+	}
+	p.delivered[b.From]++
+}
+
+// Delivered returns how many broadcasts of every process were delivered.
+func (p *CausalProcess[T]) Delivered() Vector { return p.delivered.Clone() }
+
+// Pending returns the number of buffered broadcasts.
+func (p *CausalProcess[T]) Pending() int { return len(p.pending) }
+
+// CausalRun is the outcome of SimulateCausal.
+type CausalRun struct {
+	// Deliveries lists the broadcasts every process delivered, in order.
+	// The payload of a broadcast is its ID, unique per run.
+	Deliveries [][]Broadcast[int]
+	Buffered   int // broadcasts that arrived before their dependencies
+	Relayed    int // copies sent by processes other than the sender
+}
+
+// SimulateCausal runs processes goroutines that each broadcast broadcasts
+// messages, interleaved at random with receipts. The network reorders: a
+// process receives a random one of the messages that arrived. A sender hands
+// each broadcast to a random nonempty subset of the others only, as if it
+// crashed halfway through sending; every process relays a broadcast to the
+// others on its first receipt, so it still reaches everybody.
+func SimulateCausal(processes, broadcasts int, seed int64) CausalRun {
+	inboxes := make([]chan Broadcast[int], processes)
+	for i := range inboxes {
+		inboxes[i] = make(chan Broadcast[int], processes*processes*broadcasts)
+	}
+	run := CausalRun{Deliveries: make([][]Broadcast[int], processes)}
+	var mu sync.Mutex // guards the counters of run
+	var wg sync.WaitGroup
+	for p := 0; p < processes; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(seed + int64(p)))
+			proc := NewCausalProcess[int](p)
+			var arrived []Broadcast[int]
+			sent := 0
+			for len(run.Deliveries[p]) < processes*broadcasts {
+				if sent < broadcasts && (len(arrived) == 0 || rng.Intn(2) == 0) {
+					b := proc.Broadcast(p*broadcasts + sent)
+					sent++
+					run.Deliveries[p] = append(run.Deliveries[p], b)
+					for _, to := range rng.Perm(processes) {
+						if to != p && (rng.Intn(2) == 0 || to == (p+1)%processes) {
+							inboxes[to] <- b
+						}
+					}
+					continue
+				}
+				if len(arrived) == 0 {
+					arrived = append(arrived, <-inboxes[p])
+				}
+				for drained := false; !drained; {
+					select {
+					case b := <-inboxes[p]:
+						arrived = append(arrived, b)
+					default:
+						drained = true
+					}
+				}
+				i := rng.Intn(len(arrived))
+				b := arrived[i]
+				arrived = append(arrived[:i], arrived[i+1:]...)
+				delivered, fresh := proc.Receive(b)
+				if !fresh {
+					continue
+				}
+				if len(delivered) == 0 {
+					mu.Lock()
+					run.Buffered++
+					mu.Unlock()
+				}
+				run.Deliveries[p] = append(run.Deliveries[p], delivered...)
+				for to := 0; to < processes; to++ {
+					if to != p && to != b.From {
+						inboxes[to] <- b
+						mu.Lock()
+						run.Relayed++
+						mu.Unlock()
+					}
+				}
+			}
+		}(p)
+	}
+	wg.Wait()
+	return run
+}
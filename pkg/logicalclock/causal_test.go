@@ -0,0 +1,93 @@
+package logicalclock
+
+import (
+	"reflect"
+	"testing"
+)
+
+func payloads(bs []Broadcast[string]) []string {
+	var out []string
+	for _, b := range bs {
+		out = append(out, b.Payload)
+	}
+	return out
+}
+
+func TestCausalProcess(t *testing.T) {
+	a, b, c := NewCausalProcess[string](0), NewCausalProcess[string](1), NewCausalProcess[string](2)
+
+	// a asks, b answers after delivering the question; c gets the answer
+	// first and must hold it back.
+	question := a.Broadcast("question")
+	if got, _ := b.Receive(question); !reflect.DeepEqual(payloads(got), []string{"question"}) {
+		t.Fatalf("b delivered %v", payloads(got))
+	}
+	answer := b.Broadcast("answer")
+	aside := a.Broadcast("aside")
+
+	if got, fresh := c.Receive(answer); len(got) != 0 || !fresh || c.Pending() != 1 {
+		t.Fatalf("c delivered %v before the question", payloads(got))
+	}
+	if got, _ := c.Receive(aside); len(got) != 0 || c.Pending() != 2 {
+		t.Fatalf("c delivered %v before the question", payloads(got))
+	}
+	got, _ := c.Receive(question)
+	if want := []string{"question", "answer", "aside"}; !reflect.DeepEqual(payloads(got), want) {
+		t.Fatalf("c delivered %v, want %v", payloads(got), want)
+	}
+	if c.Pending() != 0 || !reflect.DeepEqual(c.Delivered(), Vector{2, 1, 0}) {
+		t.Errorf("c: %d pending, delivered %v", c.Pending(), c.Delivered())
+	}
+
+	// Duplicates, delivered or buffered, are dropped.
+	if got, fresh := c.Receive(question); got != nil || fresh {
+		t.Error("c delivered a duplicate")
+	}
+	later := a.Broadcast("later")
+	a.Broadcast("skipped")
+	last := a.Broadcast("last")
+	c.Receive(last)
+	if _, fresh := c.Receive(last); fresh || c.Pending() != 1 {
+		t.Error("a buffered duplicate was buffered again")
+	}
+	// FIFO per sender holds as well.
+	if got, _ := c.Receive(later); !reflect.DeepEqual(payloads(got), []string{"later"}) {
+		t.Errorf("c delivered %v, want only the broadcast before the gap", payloads(got))
+	}
+}
+
+func TestSimulateCausal(t *testing.T) {
+	const processes, broadcasts = 5, 20
+	buffered := 0
+	for seed := int64(0); seed < 10; seed++ {
+		run := SimulateCausal(processes, broadcasts, seed)
+		buffered += run.Buffered
+		if run.Relayed == 0 {
+			t.Fatal("nothing was relayed")
+		}
+		for p, d := range run.Deliveries {
+			if len(d) != processes*broadcasts {
+				t.Fatalf("seed %d: process %d delivered %d broadcasts, want %d", seed, p, len(d), processes*broadcasts)
+			}
+			// Every broadcast delivered before another at this process
+			// must not causally follow it.
+			position := map[int]int{}
+			for i, b := range d {
+				if _, dup := position[b.Payload]; dup {
+					t.Fatalf("seed %d: process %d delivered broadcast %d twice", seed, p, b.Payload)
+				}
+				position[b.Payload] = i
+			}
+			for i, x := range d {
+				for _, y := range d[i+1:] {
+					if y.Vector.HappenedBefore(x.Vector) {
+						t.Fatalf("seed %d: process %d delivered %d before %d, which happened before it", seed, p, x.Payload, y.Payload)
+					}
+				}
+			}
+		}
+	}
+	if buffered == 0 {
+		t.Error("the network never delivered a broadcast ahead of its dependencies")
+	}
+}
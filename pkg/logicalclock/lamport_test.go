@@ -0,0 +1,43 @@
+package logicalclock
+
+import "testing"
+
+func TestClock(t *testing.T) {
+	a, b := NewClock(0), NewClock(1)
+	a.Tick()
+	m := Stamp(a, "hello")
+	if m.Stamp != (Timestamp{2, 0}) {
+		t.Fatalf("sent at %v, want 2@0", m.Stamp)
+	}
+	payload, at := Receive(b, m)
+	if payload != "hello" || at != (Timestamp{3, 1}) {
+		t.Fatalf("received %q at %v, want hello at 3@1", payload, at)
+	}
+	// A receiver ahead of the sender keeps counting from its own time.
+	for i := 0; i < 5; i++ {
+		b.Tick()
+	}
+	if _, at = Receive(b, Stamp(a, "again")); at != (Timestamp{9, 1}) {
+		t.Errorf("received at %v, want 9@1", at)
+	}
+	if b.Now() != at {
+		t.Errorf("Now %v, want %v", b.Now(), at)
+	}
+}
+
+func TestTimestamp_Less(t *testing.T) {
+	tests := []struct {
+		a, b Timestamp
+		want bool
+	}{
+		{Timestamp{1, 5}, Timestamp{2, 0}, true},
+		{Timestamp{2, 0}, Timestamp{2, 1}, true},
+		{Timestamp{2, 1}, Timestamp{2, 0}, false},
+		{Timestamp{2, 1}, Timestamp{2, 1}, false},
+	}
+	for _, tc := range tests {
+		if got := tc.a.Less(tc.b); got != tc.want {
+			t.Errorf("%v.Less(%v) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package logicalclock
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// EventKind is the kind of an Event.
+type EventKind int
+
+const (
+	EventLocal EventKind = iota
+	EventSend
+	EventRecv
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventLocal:
+		return "local"
+	case EventSend:
+		return "send"
+	case EventRecv:
+		return "recv"
+	default:
+		return fmt.Sprintf("event(%d)", int(k))
+	}
+}
+
+// Event is one event of a Simulate run.
+type Event struct {
+	Process int
+	Seq     int // position in the process's own history
+	Kind    EventKind
+	Stamp   Timestamp
+	Vector  Vector
+	Peer    int // EventSend: receiver; EventRecv: sender
+	Message int // EventSend and EventRecv: ID of the message, unique per run
+}
+
+// Simulate runs processes goroutines that each perform events random
+// events: local steps, sends of a message to another random process and
+// receipts of the messages that arrived. It returns the history of every
+// process, in program order, with the Lamport and vector timestamps of
+// every event. Messages still in flight when a process
+// finishes are never received.
+func Simulate(processes, events int, seed int64) [][]Event {
+	type envelope = Message[struct {
+		id     int
+		vector Vector
+	}]
+	inboxes := make([]chan envelope, processes)
+	for i := range inboxes {
+		inboxes[i] = make(chan envelope, processes*events)
+	}
+	var ids struct {
+		sync.Mutex
+		next int
+	}
+	history := make([][]Event, processes)
+	var wg sync.WaitGroup
+	for p := 0; p < processes; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(seed + int64(p)))
+			clock, vclock := NewClock(p), NewVectorClock(p)
+			record := func(kind EventKind, stamp Timestamp, vector Vector, peer, msg int) {
+				history[p] = append(history[p], Event{Process: p, Seq: len(history[p]), Kind: kind, Stamp: stamp,
+					Vector: vector, Peer: peer, Message: msg})
+			}
+			for i := 0; i < events; i++ {
+				switch r := rng.Intn(3); {
+				case r == 1 && processes > 1:
+					to := rng.Intn(processes - 1)
+					if to >= p {
+						to++
+					}
+					ids.Lock()
+					id := ids.next
+					ids.next++
+					ids.Unlock()
+					m := envelope{Stamp: clock.Tick()}
+					m.Payload.id, m.Payload.vector = id, vclock.Tick()
+					record(EventSend, m.Stamp, m.Payload.vector, to, id)
+					inboxes[to] <- m
+				case r == 2:
+					select {
+					case m := <-inboxes[p]:
+						payload, stamp := Receive(clock, m)
+						record(EventRecv, stamp, vclock.Witness(payload.vector), m.Stamp.Process, payload.id)
+						continue
+					default:
+					}
+					fallthrough
+				default:
+					record(EventLocal, clock.Tick(), vclock.Tick(), -1, -1)
+				}
+			}
+		}(p)
+	}
+	wg.Wait()
+	return history
+}
+
+// TotalOrder merges the histories of the processes into the total order of
+// their timestamps.
+func TotalOrder(history [][]Event) []Event {
+	var out []Event
+	for _, h := range history {
+		out = append(out, h...)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Stamp.Less(out[j].Stamp) })
+	return out
+}
@@ -0,0 +1,103 @@
+package logicalclock
+
+import "testing"
+
+func TestSimulate_ClockCondition(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		history := Simulate(4, 50, seed)
+		sends := map[int]Event{}
+		received := 0
+		for p, h := range history {
+			if len(h) != 50 {
+				t.Fatalf("process %d recorded %d events, want 50", p, len(h))
+			}
+			for i, e := range h {
+				if i > 0 && !h[i-1].Stamp.Less(e.Stamp) {
+					t.Fatalf("process %d: event %d at %v not after %v", p, i, e.Stamp, h[i-1].Stamp)
+				}
+				if e.Kind == EventSend {
+					sends[e.Message] = e
+				}
+			}
+		}
+		for _, h := range history {
+			for _, e := range h {
+				if e.Kind != EventRecv {
+					continue
+				}
+				received++
+				s, ok := sends[e.Message]
+				if !ok || s.Process != e.Peer {
+					t.Fatalf("receipt %+v of an unknown message", e)
+				}
+				if !s.Stamp.Less(e.Stamp) || s.Stamp.Time >= e.Stamp.Time {
+					t.Fatalf("message %d sent at %v but received at %v", e.Message, s.Stamp, e.Stamp)
+				}
+			}
+		}
+		if received == 0 {
+			t.Fatalf("seed %d: no message received", seed)
+		}
+
+		checkHappenedBefore(t, history)
+
+		// The total order is strict and keeps every process's own order.
+		order := TotalOrder(history)
+		next := make([]int, len(history))
+		for i, e := range order {
+			if i > 0 && !order[i-1].Stamp.Less(e.Stamp) {
+				t.Fatalf("total order not strict at %v, %v", order[i-1].Stamp, e.Stamp)
+			}
+			if e.Seq != next[e.Process] {
+				t.Fatalf("event %d of process %d out of program order", e.Seq, e.Process)
+			}
+			next[e.Process]++
+		}
+	}
+}
+
+// checkHappenedBefore compares the vector timestamps of every pair of events
+// with the happened-before relation computed from program order and
+// messages.
+func checkHappenedBefore(t *testing.T, history [][]Event) {
+	t.Helper()
+	var events []Event
+	index := map[[2]int]int{} // process and sequence number to position in events
+	sends := map[int]int{}    // message to position of its send
+	for _, h := range history {
+		for _, e := range h {
+			index[[2]int{e.Process, e.Seq}] = len(events)
+			if e.Kind == EventSend {
+				sends[e.Message] = len(events)
+			}
+			events = append(events, e)
+		}
+	}
+	// before[j][i] reports whether event i happened before event j. Events
+	// are visited in Lamport order, which extends happened-before.
+	before := make([][]bool, len(events))
+	for _, e := range TotalOrder(history) {
+		j := index[[2]int{e.Process, e.Seq}]
+		before[j] = make([]bool, len(events))
+		var preds []int
+		if e.Seq > 0 {
+			preds = append(preds, index[[2]int{e.Process, e.Seq - 1}])
+		}
+		if e.Kind == EventRecv {
+			preds = append(preds, sends[e.Message])
+		}
+		for _, i := range preds {
+			before[j][i] = true
+			for k, b := range before[i] {
+				before[j][k] = before[j][k] || b
+			}
+		}
+	}
+	for i, a := range events {
+		for j, b := range events {
+			if got, want := a.Vector.HappenedBefore(b.Vector), before[j][i]; got != want {
+				t.Fatalf("%+v happened before %+v: vectors say %v, want %v", a, b, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package logicalclock
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Ordering is the causal relation between two events.
+type Ordering int
+
+const (
+	Equal Ordering = iota
+	Before
+	After
+	Concurrent
+)
+
+func (o Ordering) String() string {
+	switch o {
+	case Equal:
+		return "equal"
+	case Before:
+		return "before"
+	case After:
+		return "after"
+	case Concurrent:
+		return "concurrent"
+	default:
+		return fmt.Sprintf("ordering(%d)", int(o))
+	}
+}
+
+// Vector is a vector timestamp: entry i counts the events of process i that
+// happened before, or are, the stamped event. Processes past the end of the
+// vector count zero events, so vectors grow as processes join.
+type Vector []uint64
+
+func (v Vector) at(i int) uint64 {
+	if i < len(v) {
+		return v[i]
+	}
+	return 0
+}
+
+// Compare returns whether the event stamped v happened before or after the
+// one stamped o, or neither.
+func (v Vector) Compare(o Vector) Ordering {
+	less, greater := false, false
+	for i := 0; i < max(len(v), len(o)); i++ {
+		switch a, b := v.at(i), o.at(i); {
+		case a < b:
+			less = true
+		case a > b:
+			greater = true
+		}
+	}
+	switch {
+	case less && greater:
+		return Concurrent
+	case less:
+		return Before
+	case greater:
+		return After
+	default:
+		return Equal
+	}
+}
+
+// HappenedBefore reports whether the event stamped v happened before the
+// one stamped o.
+func (v Vector) HappenedBefore(o Vector) bool { return v.Compare(o) == Before }
+
+// Merge returns the element-wise maximum of v and o.
+func (v Vector) Merge(o Vector) Vector {
+	out := make(Vector, max(len(v), len(o)))
+	for i := range out {
+		out[i] = max(v.at(i), o.at(i))
+	}
+	return out
+}
+
+// Clone returns a copy of v.
+func (v Vector) Clone() Vector { return append(Vector(nil), v...) }
+
+// VectorClock is the vector clock of one process. It is safe for concurrent
+// use.
+type VectorClock struct {
+	Process int
+
+	mu sync.Mutex
+	v  Vector
+}
+
+func NewVectorClock(process int) *VectorClock {
+	return &VectorClock{Process: process, v: make(Vector, process+1)}
+}
+
+// Now returns the timestamp of the last event, without advancing the clock.
+func (c *VectorClock) Now() Vector {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.v.Clone()
+}
+
+// Tick advances the clock for a local event, or the sending of a message,
+// and returns its timestamp.
+func (c *VectorClock) Tick() Vector {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.v[c.Process]++
+	return c.v.Clone()
+}
+
+// Witness merges v into the clock for the receipt of a message stamped v,
+// and returns the timestamp of the receipt.
+func (c *VectorClock) Witness(v Vector) Vector {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.v = c.v.Merge(v)
+	c.v[c.Process]++
+	return c.v.Clone()
+}
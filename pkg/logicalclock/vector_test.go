@@ -0,0 +1,63 @@
+package logicalclock
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVector_Compare(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Vector
+		want Ordering
+	}{
+		{"equal", Vector{1, 2}, Vector{1, 2}, Equal},
+		{"equal with a joined process", Vector{1, 2}, Vector{1, 2, 0}, Equal},
+		{"before", Vector{1, 2}, Vector{1, 3}, Before},
+		{"before a longer vector", Vector{1, 2}, Vector{1, 2, 1}, Before},
+		{"after", Vector{2, 2, 1}, Vector{1, 2}, After},
+		{"concurrent", Vector{2, 1}, Vector{1, 2}, Concurrent},
+		{"concurrent with a joined process", Vector{2}, Vector{1, 1}, Concurrent},
+		{"empty", nil, Vector{0, 0}, Equal},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.a.Compare(tc.b); got != tc.want {
+				t.Errorf("%v.Compare(%v) = %v, want %v", tc.a, tc.b, got, tc.want)
+			}
+			reverse := map[Ordering]Ordering{Equal: Equal, Before: After, After: Before, Concurrent: Concurrent}
+			if got := tc.b.Compare(tc.a); got != reverse[tc.want] {
+				t.Errorf("%v.Compare(%v) = %v, want %v", tc.b, tc.a, got, reverse[tc.want])
+			}
+		})
+	}
+}
+
+func TestVectorClock(t *testing.T) {
+	a, b := NewVectorClock(0), NewVectorClock(2)
+	sent := a.Tick()
+	b.Tick()
+	received := b.Witness(sent)
+	if !reflect.DeepEqual(received, Vector{1, 0, 2}) {
+		t.Fatalf("received at %v, want [1 0 2]", received)
+	}
+	if !sent.HappenedBefore(received) {
+		t.Error("the send didn't happen before the receipt")
+	}
+	// A process that joins later starts concurrent with everything it hasn't
+	// heard of.
+	c := NewVectorClock(3)
+	if got := c.Tick().Compare(received); got != Concurrent {
+		t.Errorf("new process's first event is %v the receipt, want concurrent", got)
+	}
+	if got := a.Tick().Merge(c.Now()); !reflect.DeepEqual(got, Vector{2, 0, 0, 1}) {
+		t.Errorf("merge %v, want [2 0 0 1]", got)
+	}
+	// Timestamps are copies.
+	now := b.Now()
+	now[0] = 99
+	if b.Now()[0] != 1 {
+		t.Error("modifying a timestamp changed the clock")
+	}
+}
@@ -0,0 +1,227 @@
+// Package mapreduce runs MapReduce jobs in process: map tasks turn input
+// records into intermediate key/value pairs, a shuffle routes every pair to
+// the partition its key hashes to, and reduce tasks fold the values of every
+// key into one result. Where all–reduce sums dense vectors of the same
+// length everywhere, MapReduce aggregates by key: word counts, inverted
+// indexes, group-bys.
+//
+// Map and reduce tasks run on pools of MapWorkers and ReduceWorkers
+// goroutines. The shuffle is held in memory, or spilled to files in
+// SpillDir once a map task buffers SpillThreshold pairs, which bounds the
+// memory of the map side by the workers rather than by the input. Spilled
+// pairs are gob encoded, so their keys and values must be too.
+//
+// The values of a key reach Reduce in input order: by map task, then in
+// the order the task emitted them.
+//
+// References:
+//
+// Dean and Ghemawat, MapReduce: Simplified Data Processing on Large
+// Clusters, OSDI 2004.
+package mapreduce
+
+import (
+	"fmt"
+	"hash/maphash"
+	"runtime"
+	"sync"
+)
+
+// KeyValue is an input record or an intermediate pair.
+type KeyValue[K, V any] struct {
+	Key   K
+	Value V
+}
+
+// Mapper turns one input record into intermediate pairs, passing each to
+// emit.
+type Mapper[K1, V1, K2, V2 any] func(key K1, value V1, emit func(K2, V2)) error
+
+// Reducer folds the values of one intermediate key into a result.
+type Reducer[K2, V2, R any] func(key K2, values []V2) (R, error)
+
+// Config tunes how a job runs.
+type Config struct {
+	MapWorkers    int // map tasks run at once; GOMAXPROCS if 0
+	ReduceWorkers int // reduce tasks run at once; GOMAXPROCS if 0
+	Partitions    int // reduce tasks; ReduceWorkers if 0
+	SplitSize     int // input records per map task; about 4 tasks per map worker if 0
+
+	SpillDir       string // spill the shuffle to files here; in memory if ""
+	SpillThreshold int    // pairs a map task buffers before spilling; 4096 if 0
+}
+
+func (c Config) withDefaults(records int) Config {
+	if c.MapWorkers <= 0 {
+		c.MapWorkers = runtime.GOMAXPROCS(0)
+	}
+	if c.ReduceWorkers <= 0 {
+		c.ReduceWorkers = runtime.GOMAXPROCS(0)
+	}
+	if c.Partitions <= 0 {
+		c.Partitions = c.ReduceWorkers
+	}
+	if c.SplitSize <= 0 {
+		c.SplitSize = max((records+4*c.MapWorkers-1)/(4*c.MapWorkers), 1)
+	}
+	if c.SpillThreshold <= 0 {
+		c.SpillThreshold = 4096
+	}
+	return c
+}
+
+// Job is a MapReduce job from records of type KeyValue[K1, V1] to a result
+// of type R per intermediate key of type K2.
+type Job[K1, V1 any, K2 comparable, V2, R any] struct {
+	Map    Mapper[K1, V1, K2, V2]
+	Reduce Reducer[K2, V2, R]
+	// Partition assigns a key to one of n partitions; by a hash of the key
+	// if nil.
+	Partition func(key K2, n int) int
+	Config
+}
+
+// Stats describes a run of a job.
+type Stats struct {
+	MapTasks     int
+	Partitions   int
+	Pairs        int   // intermediate pairs emitted
+	Keys         int   // distinct intermediate keys
+	SpillFiles   int   // files the shuffle wrote
+	SpilledBytes int64 // bytes the shuffle wrote
+}
+
+// Run runs the job on input and returns the result of every intermediate
+// key. It stops at the first error of a Mapper, a Reducer or the spill
+// files, and removes the spill files before it returns.
+func (j Job[K1, V1, K2, V2, R]) Run(input []KeyValue[K1, V1]) (map[K2]R, Stats, error) {
+	if j.Map == nil || j.Reduce == nil {
+		return nil, Stats{}, fmt.Errorf("a job needs both Map and Reduce")
+	}
+	cfg := j.Config.withDefaults(len(input))
+	partition := j.Partition
+	if partition == nil {
+		seed := maphash.MakeSeed()
+		partition = func(key K2, n int) int { return int(maphash.Comparable(seed, key) % uint64(n)) }
+	}
+
+	tasks := (len(input) + cfg.SplitSize - 1) / cfg.SplitSize
+	outputs := make([]*mapOutput[K2, V2], tasks)
+	defer func() {
+		for _, o := range outputs {
+			if o != nil {
+				o.remove()
+			}
+		}
+	}()
+	err := parallel(tasks, cfg.MapWorkers, func(t int) error {
+		o := newMapOutput[K2, V2](t, cfg)
+		outputs[t] = o
+		records := input[t*cfg.SplitSize : min((t+1)*cfg.SplitSize, len(input))]
+		for _, rec := range records {
+			var err error
+			if mapErr := j.Map(rec.Key, rec.Value, func(k K2, v V2) {
+				if err == nil {
+					err = o.add(partition(k, cfg.Partitions), KeyValue[K2, V2]{k, v})
+				}
+			}); mapErr != nil {
+				return fmt.Errorf("map task %d: %w", t, mapErr)
+			}
+			if err != nil {
+				return fmt.Errorf("map task %d: %w", t, err)
+			}
+		}
+		if err := o.close(); err != nil {
+			return fmt.Errorf("map task %d: %w", t, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, Stats{}, err
+	}
+
+	stats := Stats{MapTasks: tasks, Partitions: cfg.Partitions}
+	for _, o := range outputs {
+		stats.Pairs += o.pairs
+		stats.SpillFiles += o.files()
+		stats.SpilledBytes += o.bytes
+	}
+
+	results := make([]map[K2]R, cfg.Partitions)
+	err = parallel(cfg.Partitions, cfg.ReduceWorkers, func(p int) error {
+		groups := make(map[K2][]V2)
+		var order []K2
+		for _, o := range outputs {
+			err := o.read(p, func(kv KeyValue[K2, V2]) {
+				if _, ok := groups[kv.Key]; !ok {
+					order = append(order, kv.Key)
+				}
+				groups[kv.Key] = append(groups[kv.Key], kv.Value)
+			})
+			if err != nil {
+				return fmt.Errorf("reduce task %d: %w", p, err)
+			}
+		}
+		results[p] = make(map[K2]R, len(order))
+		for _, k := range order {
+			r, err := j.Reduce(k, groups[k])
+			if err != nil {
+				return fmt.Errorf("reduce task %d: key %v: %w", p, k, err)
+			}
+			results[p][k] = r
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, stats, err
+	}
+
+	out := make(map[K2]R)
+	for _, r := range results {
+		for k, v := range r {
+			out[k] = v
+		}
+	}
+	stats.Keys = len(out)
+	return out, stats, nil
+}
+
+// parallel runs task(0) to task(n-1) on workers goroutines and returns the
+// first error. Tasks not started yet when an error occurs are skipped.
+func parallel(n, workers int, task func(i int) error) error {
+	next := make(chan int)
+	var (
+		mu    sync.Mutex
+		first error
+		wg    sync.WaitGroup
+	)
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return first != nil
+	}
+	for w := 0; w < min(workers, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if failed() {
+					continue
+				}
+				if err := task(i); err != nil {
+					mu.Lock()
+					if first == nil {
+						first = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return first
+}
@@ -0,0 +1,177 @@
+package mapreduce
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math/rand"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// documents returns n random lines of words from a small vocabulary, so
+// that words repeat across lines.
+func documents(n int) []KeyValue[int, string] {
+	rng := rand.New(rand.NewSource(1))
+	docs := make([]KeyValue[int, string], n)
+	for i := range docs {
+		words := make([]string, 1+rng.Intn(20))
+		for j := range words {
+			words[j] = fmt.Sprint("w", rng.Intn(300))
+		}
+		docs[i] = KeyValue[int, string]{i, strings.Join(words, " ")}
+	}
+	return docs
+}
+
+func wordCount(cfg Config) Job[int, string, string, int, int] {
+	return Job[int, string, string, int, int]{
+		Map: func(_ int, line string, emit func(string, int)) error {
+			for _, w := range strings.Fields(line) {
+				emit(w, 1)
+			}
+			return nil
+		},
+		Reduce: func(_ string, counts []int) (int, error) {
+			sum := 0
+			for _, c := range counts {
+				sum += c
+			}
+			return sum, nil
+		},
+		Config: cfg,
+	}
+}
+
+func TestJob_WordCount(t *testing.T) {
+	docs := documents(2000)
+	want := map[string]int{}
+	pairs := 0
+	for _, d := range docs {
+		for _, w := range strings.Fields(d.Value) {
+			want[w]++
+			pairs++
+		}
+	}
+
+	tests := []struct {
+		name  string
+		cfg   Config
+		spill bool
+	}{
+		{"defaults", Config{}, false},
+		{"one worker", Config{MapWorkers: 1, ReduceWorkers: 1}, false},
+		{"many partitions", Config{MapWorkers: 3, ReduceWorkers: 2, Partitions: 37, SplitSize: 7}, false},
+		{"spilled", Config{MapWorkers: 4, ReduceWorkers: 4, Partitions: 5, SpillThreshold: 100}, true},
+		{"under the spill threshold", Config{MapWorkers: 2, SpillThreshold: 1 << 20}, false},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.cfg.SpillThreshold > 0 {
+				tc.cfg.SpillDir = t.TempDir()
+			}
+			got, stats, err := wordCount(tc.cfg).Run(docs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(got, want) {
+				t.Fatalf("counts differ from a sequential count")
+			}
+			if stats.Pairs != pairs || stats.Keys != len(want) {
+				t.Errorf("stats %+v, want %d pairs and %d keys", stats, pairs, len(want))
+			}
+			if spilled := stats.SpillFiles > 0 && stats.SpilledBytes > 0; spilled != tc.spill {
+				t.Errorf("stats %+v: spilled %v, want %v", stats, spilled, tc.spill)
+			}
+			if tc.cfg.SpillDir != "" {
+				if left, _ := os.ReadDir(tc.cfg.SpillDir); len(left) != 0 {
+					t.Errorf("%d spill files left behind", len(left))
+				}
+			}
+		})
+	}
+}
+
+func TestJob_ValuesInInputOrder(t *testing.T) {
+	input := make([]KeyValue[int, int], 1000)
+	for i := range input {
+		input[i] = KeyValue[int, int]{i, i}
+	}
+	job := Job[int, int, int, int, []int]{
+		Map: func(k, v int, emit func(int, int)) error {
+			emit(k%7, v)
+			emit(k%7, v)
+			return nil
+		},
+		Reduce: func(_ int, values []int) ([]int, error) { return values, nil },
+		Config: Config{MapWorkers: 8, SplitSize: 3, SpillDir: t.TempDir(), SpillThreshold: 5},
+	}
+	got, _, err := job.Run(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, values := range got {
+		want := 0
+		for i := k; i < len(input); i += 7 {
+			want += 2
+		}
+		if len(values) != want || !slices.IsSorted(values) {
+			t.Errorf("key %d: values %v out of order", k, values)
+		}
+	}
+}
+
+func TestJob_Errors(t *testing.T) {
+	boom := errors.New("boom")
+	input := []KeyValue[int, string]{{0, "a b"}, {1, "c"}}
+
+	failingMap := wordCount(Config{})
+	failingMap.Map = func(k int, _ string, _ func(string, int)) error {
+		if k == 1 {
+			return boom
+		}
+		return nil
+	}
+	failingReduce := wordCount(Config{})
+	failingReduce.Reduce = func(string, []int) (int, error) { return 0, boom }
+	badPartition := wordCount(Config{})
+	badPartition.Partition = func(string, int) int { return -1 }
+	// Gob can't encode an interface holding an unregistered type, so
+	// spilling it fails.
+	type point struct{ X, Y int }
+	unencodable := Job[int, string, string, any, int]{
+		Map:    func(_ int, s string, emit func(string, any)) error { emit(s, point{1, 2}); return nil },
+		Reduce: func(string, []any) (int, error) { return 0, nil },
+		Config: Config{SpillDir: t.TempDir(), SpillThreshold: 1},
+	}
+	noReduce := Job[int, string, string, int, int]{Map: failingMap.Map}
+
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{"no reduce", func() error { _, _, err := noReduce.Run(input); return err }},
+		{"map", func() error { _, _, err := failingMap.Run(input); return err }},
+		{"reduce", func() error { _, _, err := failingReduce.Run(input); return err }},
+		{"partition", func() error { _, _, err := badPartition.Run(input); return err }},
+		{"spill", func() error { _, _, err := unencodable.Run(input); return err }},
+	}
+	for _, tc := range tests {
+		if err := tc.run(); err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+		}
+	}
+	if _, _, err := failingMap.Run(input); !errors.Is(err, boom) {
+		t.Errorf("expected the mapper's error to be wrapped, got %v", err)
+	}
+}
+
+func TestJob_Empty(t *testing.T) {
+	got, stats, err := wordCount(Config{}).Run(nil)
+	if err != nil || len(got) != 0 || stats.MapTasks != 0 {
+		t.Errorf("Run(nil) = %v, %+v, %v", got, stats, err)
+	}
+}
@@ -0,0 +1,154 @@
+package mapreduce
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+)
+
+// mapOutput holds the pairs one map task emitted, by partition: in memory,
+// or in one spill file per partition.
+type mapOutput[K, V any] struct {
+	task int
+	cfg  Config
+
+	buffers  [][]KeyValue[K, V]
+	buffered int
+	pairs    int
+
+	spills []*spillFile // by partition; nil until a partition spills
+	bytes  int64
+}
+
+type spillFile struct {
+	f   *os.File
+	enc *gob.Encoder
+	n   *countingWriter
+}
+
+type countingWriter struct {
+	w     io.Writer
+	bytes int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.bytes += int64(n)
+	return n, err
+}
+
+func newMapOutput[K, V any](task int, cfg Config) *mapOutput[K, V] {
+	return &mapOutput[K, V]{
+		task:    task,
+		cfg:     cfg,
+		buffers: make([][]KeyValue[K, V], cfg.Partitions),
+		spills:  make([]*spillFile, cfg.Partitions),
+	}
+}
+
+// add buffers kv for partition p, spilling once the buffers are full.
+func (o *mapOutput[K, V]) add(p int, kv KeyValue[K, V]) error {
+	if p < 0 || p >= len(o.buffers) {
+		return fmt.Errorf("partition %d of key %v outside [0, %d)", p, kv.Key, len(o.buffers))
+	}
+	o.buffers[p] = append(o.buffers[p], kv)
+	o.buffered++
+	o.pairs++
+	if o.cfg.SpillDir != "" && o.buffered >= o.cfg.SpillThreshold {
+		return o.spill()
+	}
+	return nil
+}
+
+// spill appends the buffered pairs to the spill files.
+func (o *mapOutput[K, V]) spill() error {
+	for p, buf := range o.buffers {
+		if len(buf) == 0 {
+			continue
+		}
+		s := o.spills[p]
+		if s == nil {
+			f, err := os.CreateTemp(o.cfg.SpillDir, fmt.Sprintf("map-%d-partition-%d-*", o.task, p))
+			if err != nil {
+				return err
+			}
+			n := &countingWriter{w: f}
+			s = &spillFile{f: f, enc: gob.NewEncoder(n), n: n}
+			o.spills[p] = s
+		}
+		for _, kv := range buf {
+			if err := s.enc.Encode(kv); err != nil {
+				return fmt.Errorf("spill: %w", err)
+			}
+		}
+		o.buffers[p] = buf[:0]
+	}
+	o.buffered = 0
+	return nil
+}
+
+// close spills what is left, if the task spilled before, and closes the
+// spill files. A task that never filled its buffers keeps them in memory.
+func (o *mapOutput[K, V]) close() error {
+	if o.files() > 0 && o.buffered > 0 {
+		if err := o.spill(); err != nil {
+			return err
+		}
+	}
+	for _, s := range o.spills {
+		if s == nil {
+			continue
+		}
+		o.bytes += s.n.bytes
+		if err := s.f.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *mapOutput[K, V]) files() int {
+	n := 0
+	for _, s := range o.spills {
+		if s != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// read passes the pairs of partition p to fn in the order they were added.
+func (o *mapOutput[K, V]) read(p int, fn func(KeyValue[K, V])) error {
+	if s := o.spills[p]; s != nil {
+		f, err := os.Open(s.f.Name())
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		dec := gob.NewDecoder(f)
+		for {
+			var kv KeyValue[K, V]
+			if err := dec.Decode(&kv); err == io.EOF {
+				break
+			} else if err != nil {
+				return fmt.Errorf("%s: %w", f.Name(), err)
+			}
+			fn(kv)
+		}
+	}
+	for _, kv := range o.buffers[p] {
+		fn(kv)
+	}
+	return nil
+}
+
+// remove deletes the spill files.
+func (o *mapOutput[K, V]) remove() {
+	for _, s := range o.spills {
+		if s != nil {
+			s.f.Close()
+			os.Remove(s.f.Name())
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package mapreduce
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMapOutput_Spill(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		pairs     int
+		files     int
+	}{
+		{"in memory", 10, 9, 0},
+		{"one spill", 10, 10, 2},
+		{"spill and rest", 4, 9, 2},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := Config{Partitions: 2, SpillDir: t.TempDir(), SpillThreshold: tc.threshold}
+			o := newMapOutput[string, int](0, cfg)
+			defer o.remove()
+			for i := 0; i < tc.pairs; i++ {
+				if err := o.add(i%2, KeyValue[string, int]{"k", i}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := o.close(); err != nil {
+				t.Fatal(err)
+			}
+			if o.files() != tc.files {
+				t.Errorf("%d spill files, want %d", o.files(), tc.files)
+			}
+			var got []int
+			for p := 0; p < 2; p++ {
+				var part []int
+				if err := o.read(p, func(kv KeyValue[string, int]) { part = append(part, kv.Value) }); err != nil {
+					t.Fatal(err)
+				}
+				if !slices.IsSorted(part) {
+					t.Errorf("partition %d out of order: %v", p, part)
+				}
+				got = append(got, part...)
+			}
+			if len(got) != tc.pairs {
+				t.Errorf("read %d pairs, want %d", len(got), tc.pairs)
+			}
+		})
+	}
+}
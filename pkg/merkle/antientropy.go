@@ -0,0 +1,101 @@
+package merkle
+
+import "fmt"
+
+// Report describes an anti-entropy sync between two replicas.
+type Report struct {
+	Rounds        int // round trips
+	Hashes        int // node hashes sent
+	Ranges        int // divergent ranges exchanged
+	Keys          int // entries sent, both ways
+	Bytes         int // bytes sent, both ways
+	FullSyncBytes int // bytes sending both replicas in full would have taken
+}
+
+// Savings returns the fraction of the bytes of a full sync the Merkle sync
+// avoided.
+func (r Report) Savings() float64 {
+	if r.FullSyncBytes == 0 {
+		return 0
+	}
+	return 1 - float64(r.Bytes)/float64(r.FullSyncBytes)
+}
+
+func (r Report) String() string {
+	return fmt.Sprintf("%d rounds, %d hashes, %d ranges, %d keys: %d bytes, %.1f%% less than a full sync of %d bytes",
+		r.Rounds, r.Hashes, r.Ranges, r.Keys, r.Bytes, 100*r.Savings(), r.FullSyncBytes)
+}
+
+// Sync brings replicas a and b, trees of the same depth, to the same
+// contents. A sends the hashes of the nodes of one level to b, which answers
+// with a bitmap of those that differ; the children of those are compared
+// next, down to the leaves. Both then send each other the entries of the
+// divergent ranges, and keep the newer entry of every key.
+func Sync(a, b *Tree) (Report, error) {
+	if a.depth != b.depth {
+		return Report{}, fmt.Errorf("depth %d differs from %d", a.depth, b.depth)
+	}
+	var rep Report
+	for _, t := range []*Tree{a, b} {
+		for r := range t.ranges {
+			for k, e := range t.ranges[r] {
+				rep.FullSyncBytes += size(k, e)
+			}
+		}
+	}
+
+	nodes := []int{0}
+	for level := 0; ; level++ {
+		rep.Rounds++
+		rep.Hashes += len(nodes)
+		rep.Bytes += len(nodes)*len(Hash{}) + (len(nodes)+7)/8
+		var differ []int
+		for _, i := range nodes {
+			if a.hashes[level][i] != b.hashes[level][i] {
+				differ = append(differ, i)
+			}
+		}
+		if len(differ) == 0 {
+			return rep, nil
+		}
+		if level == a.depth {
+			nodes = differ
+			break
+		}
+		nodes = nodes[:0]
+		for _, i := range differ {
+			nodes = append(nodes, 2*i, 2*i+1)
+		}
+	}
+
+	rep.Rounds++
+	rep.Ranges = len(nodes)
+	for _, r := range nodes {
+		for _, t := range []*Tree{a, b} {
+			for k, e := range t.ranges[r] {
+				rep.Keys++
+				rep.Bytes += size(k, e)
+			}
+		}
+		merge(a, b, r)
+	}
+	return rep, nil
+}
+
+// merge makes range r of a and b the union of both, keeping the newer entry
+// of every key.
+func merge(a, b *Tree, r int) {
+	ra, rb := a.ranges[r], b.ranges[r]
+	for k, e := range ra {
+		if o, ok := rb[k]; !ok || e.newer(o) {
+			rb[k] = e
+		}
+	}
+	for k, e := range rb {
+		if o, ok := ra[k]; !ok || e.newer(o) {
+			ra[k] = e
+		}
+	}
+	a.rehash(r)
+	b.rehash(r)
+}
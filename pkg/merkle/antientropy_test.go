@@ -0,0 +1,95 @@
+package merkle
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// diverge writes changed entries to a fraction of the keys of b, and new
+// keys to a.
+func diverge(a, b *Tree, keys int, fraction float64, rng *rand.Rand) {
+	for i := 0; i < keys; i++ {
+		if rng.Float64() >= fraction {
+			continue
+		}
+		if rng.Intn(2) == 0 {
+			b.Put(fmt.Sprint("key-", i), Entry{Value: []byte("updated"), Version: 2})
+		} else {
+			a.Put(fmt.Sprint("new-", i), Entry{Value: []byte("fresh"), Version: 1})
+		}
+	}
+}
+
+func TestSync(t *testing.T) {
+	const keys = 10000
+	tests := []struct {
+		name     string
+		fraction float64
+		savings  float64 // at least
+	}{
+		{"identical", 0, 0.99},
+		{"0.1% divergent", 0.001, 0.95},
+		{"1% divergent", 0.01, 0.8},
+		{"10% divergent", 0.1, 0.1},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			a, b := fill(t, 10, keys), fill(t, 10, keys)
+			diverge(a, b, keys, tc.fraction, rand.New(rand.NewSource(1)))
+			ranges := len(a.Diff(b))
+
+			rep, err := Sync(a, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Log(rep)
+			if a.Root() != b.Root() || len(a.Diff(b)) != 0 {
+				t.Fatal("replicas differ after the sync")
+			}
+			if rep.Ranges != ranges {
+				t.Errorf("%d ranges exchanged, want the %d divergent ones", rep.Ranges, ranges)
+			}
+			if rep.Savings() < tc.savings {
+				t.Errorf("saved %.3f of a full sync, want at least %.3f", rep.Savings(), tc.savings)
+			}
+			if tc.fraction == 0 && (rep.Rounds != 1 || rep.Keys != 0) {
+				t.Errorf("identical replicas: %v, want one round and no keys", rep)
+			}
+
+			// A second sync finds nothing to do.
+			if again, _ := Sync(a, b); again.Keys != 0 || again.Rounds != 1 {
+				t.Errorf("second sync: %v", again)
+			}
+		})
+	}
+}
+
+func TestSync_Conflicts(t *testing.T) {
+	a, b := fill(t, 4, 100), fill(t, 4, 100)
+	a.Put("key-1", Entry{Value: []byte("a"), Version: 3})
+	b.Put("key-1", Entry{Value: []byte("b"), Version: 2})
+	a.Put("key-2", Entry{Value: []byte("a"), Version: 5})
+	b.Put("key-2", Entry{Value: []byte("b"), Version: 5})
+	b.Put("only-b", Entry{Value: []byte("b"), Version: 1})
+
+	if _, err := Sync(a, b); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{"key-1": "a", "key-2": "b", "only-b": "b"} {
+		for name, tree := range map[string]*Tree{"a": a, "b": b} {
+			if e, ok := tree.Get(key); !ok || string(e.Value) != want {
+				t.Errorf("replica %s: %s = %q, want %q", name, key, e.Value, want)
+			}
+		}
+	}
+	if a.Len() != 101 {
+		t.Errorf("%d keys after the sync, want 101", a.Len())
+	}
+
+	c, _ := New(5)
+	if _, err := Sync(a, c); err == nil {
+		t.Error("expected an error for trees of different depths")
+	}
+}
@@ -0,0 +1,180 @@
+// Package merkle keeps replicated key-value data in Merkle trees and
+// synchronizes replicas by anti-entropy.
+//
+// The key space is split into 2^depth ranges by key hash, and a Merkle tree
+// is built over the ranges: a leaf hashes the entries of its range, an inner
+// node the hashes of its two children. Two replicas whose roots match hold
+// the same data. Otherwise they walk down from the root, comparing only
+// the children of nodes that differ, and end up exchanging the entries of
+// the divergent ranges alone: the cost of a sync grows with the differences
+// rather than with the data.
+//
+// References:
+//
+// Merkle, A Digital Signature Based on a Conventional Encryption Function,
+// CRYPTO 1987.
+//
+// DeCandia et al., Dynamo: Amazon's Highly Available Key-value Store, SOSP
+// 2007.
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/sanderblue/algorithms/pkg/consistenthash"
+)
+
+// MaxDepth bounds the depth of a tree: 2^MaxDepth ranges.
+const MaxDepth = 20
+
+// Hash is the hash of a node.
+type Hash [sha256.Size]byte
+
+// Entry is a versioned value.
+type Entry struct {
+	Value   []byte
+	Version uint64
+}
+
+// newer reports whether e wins over o when replicas disagree: the higher
+// version does, and the larger value breaks ties so every replica picks the
+// same one.
+func (e Entry) newer(o Entry) bool {
+	if e.Version != o.Version {
+		return e.Version > o.Version
+	}
+	return bytes.Compare(e.Value, o.Value) > 0
+}
+
+// size is the encoded size of key and e on the wire: both length-prefixed,
+// plus the version.
+func size(key string, e Entry) int {
+	return 4 + len(key) + 4 + len(e.Value) + 8
+}
+
+// Tree is a key-value store with a Merkle tree over the hash ranges of its
+// keys, kept up to date on every write.
+type Tree struct {
+	depth  int
+	hashes [][]Hash // hashes[level][index]; level 0 is the root, level depth the leaves
+	ranges []map[string]Entry
+}
+
+// New returns an empty tree of 2^depth ranges.
+func New(depth int) (*Tree, error) {
+	if depth < 0 || depth > MaxDepth {
+		return nil, fmt.Errorf("depth %d outside [0, %d]", depth, MaxDepth)
+	}
+	t := &Tree{depth: depth, hashes: make([][]Hash, depth+1), ranges: make([]map[string]Entry, 1<<depth)}
+	for level := range t.hashes {
+		t.hashes[level] = make([]Hash, 1<<level)
+	}
+	empty := sha256.Sum256(nil)
+	for i := range t.ranges {
+		t.ranges[i] = make(map[string]Entry)
+		t.hashes[depth][i] = empty
+	}
+	for level := depth - 1; level >= 0; level-- {
+		for i := range t.hashes[level] {
+			t.hashes[level][i] = t.inner(level, i)
+		}
+	}
+	return t, nil
+}
+
+// Depth returns the depth of the tree.
+func (t *Tree) Depth() int { return t.depth }
+
+// RangeOf returns the range key falls in.
+func (t *Tree) RangeOf(key string) int {
+	return int(consistenthash.DefaultHash(key) >> (64 - t.depth))
+}
+
+// Put stores e under key, whatever its version.
+func (t *Tree) Put(key string, e Entry) {
+	r := t.RangeOf(key)
+	t.ranges[r][key] = e
+	t.rehash(r)
+}
+
+// Get returns the entry stored under key.
+func (t *Tree) Get(key string) (Entry, bool) {
+	e, ok := t.ranges[t.RangeOf(key)][key]
+	return e, ok
+}
+
+// Len returns the number of keys.
+func (t *Tree) Len() int {
+	n := 0
+	for _, r := range t.ranges {
+		n += len(r)
+	}
+	return n
+}
+
+// Root returns the root hash, which is the same for trees of the same depth
+// holding the same entries.
+func (t *Tree) Root() Hash { return t.hashes[0][0] }
+
+// Node returns the hash of node index of level, counting levels from the
+// root.
+func (t *Tree) Node(level, index int) Hash { return t.hashes[level][index] }
+
+// Diff returns the ranges whose leaves differ between t and o, which must
+// have the same depth, descending only into differing nodes.
+func (t *Tree) Diff(o *Tree) []int {
+	var out []int
+	var walk func(level, index int)
+	walk = func(level, index int) {
+		if t.hashes[level][index] == o.hashes[level][index] {
+			return
+		}
+		if level == t.depth {
+			out = append(out, index)
+			return
+		}
+		walk(level+1, 2*index)
+		walk(level+1, 2*index+1)
+	}
+	walk(0, 0)
+	return out
+}
+
+// keys returns the keys of range r in order.
+func (t *Tree) keys(r int) []string {
+	keys := make([]string, 0, len(t.ranges[r]))
+	for k := range t.ranges[r] {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// rehash recomputes the leaf of range r and its ancestors.
+func (t *Tree) rehash(r int) {
+	h := sha256.New()
+	var buf []byte
+	for _, k := range t.keys(r) {
+		e := t.ranges[r][k]
+		buf = binary.BigEndian.AppendUint32(buf[:0], uint32(len(k)))
+		buf = append(buf, k...)
+		buf = binary.BigEndian.AppendUint64(buf, e.Version)
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(e.Value)))
+		buf = append(buf, e.Value...)
+		h.Write(buf)
+	}
+	h.Sum(t.hashes[t.depth][r][:0])
+	for level, i := t.depth-1, r/2; level >= 0; level, i = level-1, i/2 {
+		t.hashes[level][i] = t.inner(level, i)
+	}
+}
+
+// inner returns the hash of inner node index of level from its children.
+func (t *Tree) inner(level, index int) Hash {
+	children := t.hashes[level+1]
+	return sha256.Sum256(append(children[2*index][:], children[2*index+1][:]...))
+}
@@ -0,0 +1,72 @@
+package merkle
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func fill(t *testing.T, depth, n int) *Tree {
+	t.Helper()
+	tree, err := New(depth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		tree.Put(fmt.Sprint("key-", i), Entry{Value: []byte(fmt.Sprint("value-", i)), Version: 1})
+	}
+	return tree
+}
+
+func TestTree_Root(t *testing.T) {
+	a, b := fill(t, 6, 500), fill(t, 6, 500)
+	if a.Root() != b.Root() || a.Diff(b) != nil {
+		t.Fatal("equal contents hash differently")
+	}
+	if a.Len() != 500 {
+		t.Errorf("%d keys, want 500", a.Len())
+	}
+
+	// The order of writes doesn't matter, only the contents.
+	c, _ := New(6)
+	for i := 499; i >= 0; i-- {
+		c.Put(fmt.Sprint("key-", i), Entry{Value: []byte(fmt.Sprint("value-", i)), Version: 1})
+	}
+	if c.Root() != a.Root() {
+		t.Error("insertion order changed the root")
+	}
+
+	b.Put("key-7", Entry{Value: []byte("other"), Version: 2})
+	if a.Root() == b.Root() {
+		t.Fatal("a changed value left the root unchanged")
+	}
+	if got, want := a.Diff(b), []int{a.RangeOf("key-7")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("diff %v, want %v", got, want)
+	}
+	if e, ok := b.Get("key-7"); !ok || string(e.Value) != "other" {
+		t.Errorf("Get returned %q, %v", e.Value, ok)
+	}
+	// Only the path to the changed leaf differs.
+	r := a.RangeOf("key-7")
+	for level := a.Depth(); level >= 0; level-- {
+		i := r >> (a.Depth() - level)
+		if a.Node(level, i) == b.Node(level, i) {
+			t.Errorf("level %d: node %d on the changed path is unchanged", level, i)
+		}
+		if j := i ^ 1; level > 0 && a.Node(level, j) != b.Node(level, j) {
+			t.Errorf("level %d: sibling %d off the changed path changed", level, j)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, depth := range []int{-1, MaxDepth + 1} {
+		if _, err := New(depth); err == nil {
+			t.Errorf("expected an error for depth %d", depth)
+		}
+	}
+	a, b := fill(t, 0, 10), fill(t, 0, 10)
+	if a.Root() != b.Root() || a.RangeOf("key-1") != 0 {
+		t.Error("a single range tree misbehaves")
+	}
+}
@@ -0,0 +1,167 @@
+// Package mutex implements distributed mutual exclusion: processes that
+// share no memory take turns in a critical section by exchanging messages.
+//
+// Ricart–Agrawala stamps every request with a Lamport timestamp and sends it
+// to every other process, which replies at once unless it is in the
+// critical section or has an older request of its own pending. A process
+// enters once every other one replied, so entries follow the total order of
+// the requests, at a cost of 2(n-1) messages per entry.
+//
+// A token ring passes a single token around the processes instead, and only
+// the holder may enter: a process waits at most one lap, during which every
+// other process enters at most once, but the token keeps travelling while
+// nobody wants it.
+//
+// References:
+//
+// Ricart and Agrawala, An Optimal Algorithm for Mutual Exclusion in
+// Computer Networks, Communications of the ACM, 1981.
+package mutex
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/sanderblue/algorithms/internal/mailbox"
+	"github.com/sanderblue/algorithms/pkg/logicalclock"
+)
+
+type raMessage struct {
+	reply bool
+	from  int
+	ts    logicalclock.Timestamp
+}
+
+// RicartAgrawala runs the Ricart–Agrawala algorithm among processes with
+// IDs 0..n-1, each handling its messages on its own goroutine.
+type RicartAgrawala struct {
+	procs []*RAProcess
+	wg    sync.WaitGroup
+	done  chan struct{}
+	sent  atomic.Int64
+}
+
+// RAProcess is one process of a RicartAgrawala. Lock and Unlock enter and
+// leave the critical section; goroutines sharing a process take turns.
+type RAProcess struct {
+	id    int
+	ra    *RicartAgrawala
+	inbox *mailbox.Mailbox[raMessage]
+	clock *logicalclock.Clock
+	local sync.Mutex // held from Lock to Unlock
+
+	mu         sync.Mutex
+	requesting bool
+	holding    bool
+	request    logicalclock.Timestamp
+	replies    int
+	deferred   []int
+	granted    chan struct{}
+}
+
+// NewRicartAgrawala starts n processes.
+func NewRicartAgrawala(n int) *RicartAgrawala {
+	ra := &RicartAgrawala{done: make(chan struct{})}
+	for id := 0; id < n; id++ {
+		ra.procs = append(ra.procs, &RAProcess{id: id, ra: ra, inbox: mailbox.New[raMessage](), clock: logicalclock.NewClock(id)})
+	}
+	ra.wg.Add(n)
+	for _, p := range ra.procs {
+		go p.run()
+	}
+	return ra
+}
+
+// Process returns process id.
+func (ra *RicartAgrawala) Process(id int) *RAProcess { return ra.procs[id] }
+
+// Messages returns the number of messages sent so far.
+func (ra *RicartAgrawala) Messages() int { return int(ra.sent.Load()) }
+
+// Close stops every process. No process may be waiting in Lock.
+func (ra *RicartAgrawala) Close() {
+	close(ra.done)
+	ra.wg.Wait()
+}
+
+func (ra *RicartAgrawala) send(to int, m raMessage) {
+	ra.sent.Add(1)
+	ra.procs[to].inbox.Push(m)
+}
+
+// Lock requests the critical section and blocks until every other process
+// granted it.
+func (p *RAProcess) Lock() {
+	p.local.Lock()
+	p.mu.Lock()
+	p.request = p.clock.Tick()
+	if len(p.ra.procs) == 1 {
+		p.holding = true
+		p.mu.Unlock()
+		return
+	}
+	p.requesting, p.replies = true, 0
+	granted := make(chan struct{})
+	p.granted = granted
+	for to := range p.ra.procs {
+		if to != p.id {
+			p.ra.send(to, raMessage{from: p.id, ts: p.request})
+		}
+	}
+	p.mu.Unlock()
+	<-granted
+}
+
+// Unlock leaves the critical section and replies to the requests deferred
+// meanwhile.
+func (p *RAProcess) Unlock() {
+	p.mu.Lock()
+	p.holding = false
+	for _, to := range p.deferred {
+		p.ra.send(to, raMessage{reply: true, from: p.id, ts: p.clock.Tick()})
+	}
+	p.deferred = nil
+	p.mu.Unlock()
+	p.local.Unlock()
+}
+
+// Request returns the timestamp of the last request of the process: the
+// position of its last entry in the order of all entries.
+func (p *RAProcess) Request() logicalclock.Timestamp {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.request
+}
+
+func (p *RAProcess) run() {
+	defer p.ra.wg.Done()
+	for {
+		select {
+		case <-p.inbox.Signal():
+			for _, m := range p.inbox.Drain() {
+				p.handle(m)
+			}
+		case <-p.ra.done:
+			return
+		}
+	}
+}
+
+func (p *RAProcess) handle(m raMessage) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clock.Witness(m.ts)
+	if m.reply {
+		p.replies++
+		if p.requesting && p.replies == len(p.ra.procs)-1 {
+			p.requesting, p.holding = false, true
+			close(p.granted)
+		}
+		return
+	}
+	if p.holding || p.requesting && p.request.Less(m.ts) {
+		p.deferred = append(p.deferred, m.from)
+		return
+	}
+	p.ra.send(m.from, raMessage{reply: true, from: p.id, ts: p.clock.Tick()})
+}
@@ -0,0 +1,82 @@
+package mutex
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sanderblue/algorithms/pkg/logicalclock"
+)
+
+// contend has every locker enter the critical section rounds times at once,
+// calls enter inside it, and reports whether two lockers were ever inside
+// together.
+func contend(t *testing.T, lockers []sync.Locker, rounds int, enter func(i int)) {
+	t.Helper()
+	var inside atomic.Int32
+	var violated atomic.Bool
+	var wg sync.WaitGroup
+	for i, l := range lockers {
+		wg.Add(1)
+		go func(i int, l sync.Locker) {
+			defer wg.Done()
+			for k := 0; k < rounds; k++ {
+				l.Lock()
+				if inside.Add(1) != 1 {
+					violated.Store(true)
+				}
+				enter(i)
+				time.Sleep(10 * time.Microsecond)
+				inside.Add(-1)
+				l.Unlock()
+			}
+		}(i, l)
+	}
+	wg.Wait()
+	if violated.Load() {
+		t.Fatal("two processes were in the critical section together")
+	}
+}
+
+func TestRicartAgrawala(t *testing.T) {
+	for _, n := range []int{1, 2, 5} {
+		const rounds = 20
+		ra := NewRicartAgrawala(n)
+		lockers := make([]sync.Locker, n)
+		for i := range lockers {
+			lockers[i] = ra.Process(i)
+		}
+		// Inside the critical section, so the log needs no lock of its own.
+		var order []logicalclock.Timestamp
+		entries := make([]int, n)
+		contend(t, lockers, rounds, func(i int) {
+			order = append(order, ra.Process(i).Request())
+			entries[i]++
+		})
+		ra.Close()
+
+		// Fairness: entries follow the order of the requests.
+		for i := 1; i < len(order); i++ {
+			if !order[i-1].Less(order[i]) {
+				t.Fatalf("n=%d: entry %d with request %v followed one with request %v", n, i, order[i], order[i-1])
+			}
+		}
+		for i, e := range entries {
+			if e != rounds {
+				t.Errorf("n=%d: process %d entered %d times, want %d", n, i, e, rounds)
+			}
+		}
+		if got, want := ra.Messages(), 2*(n-1)*n*rounds; got != want {
+			t.Errorf("n=%d: %d messages, want 2(n-1) per entry: %d", n, got, want)
+		}
+	}
+}
+
+func TestRicartAgrawala_SharedProcess(t *testing.T) {
+	// Goroutines sharing a process take turns as well.
+	ra := NewRicartAgrawala(2)
+	defer ra.Close()
+	p := ra.Process(0)
+	contend(t, []sync.Locker{p, p, p, ra.Process(1)}, 10, func(int) {})
+}
@@ -0,0 +1,165 @@
+package mutex
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sanderblue/algorithms/internal/mailbox"
+	"github.com/sanderblue/algorithms/pkg/ringallreduce"
+)
+
+// idlePause slows the token down once it went a full lap without anyone
+// entering, so an idle ring doesn't spin.
+const idlePause = 50 * time.Microsecond
+
+type token struct {
+	entries uint64 // entries into the critical section so far
+	idle    int    // hops since the last entry
+}
+
+// TokenRing passes a token around the ranks of a ring topology, each
+// handling its messages on its own goroutine. The token starts at rank 0.
+type TokenRing struct {
+	procs  []*TokenProcess
+	right  []int
+	wg     sync.WaitGroup
+	done   chan struct{}
+	passes atomic.Int64
+}
+
+// TokenProcess is one rank of a TokenRing. Lock and Unlock enter and leave
+// the critical section; goroutines sharing a rank take turns.
+type TokenProcess struct {
+	rank  int
+	ring  *TokenRing
+	inbox *mailbox.Mailbox[token]
+	local sync.Mutex // held from Lock to Unlock
+
+	mu        sync.Mutex
+	wanting   bool
+	granted   chan struct{}
+	held      *token
+	seen      uint64 // entries when the token last left
+	overtaken int
+}
+
+// NewTokenRing starts a token ring over ring topology t: every rank passes
+// the token to its right (send) neighbor.
+func NewTokenRing(t ringallreduce.Topology) (*TokenRing, error) {
+	p := t.Size()
+	tr := &TokenRing{right: make([]int, p), done: make(chan struct{})}
+	for r := 0; r < p; r++ {
+		switch nb := t.Neighbors(r); len(nb) {
+		case 0:
+			tr.right[r] = r
+		case 1, 2:
+			tr.right[r] = nb[len(nb)-1]
+		default:
+			return nil, fmt.Errorf("%s is not a ring: rank %d has %d neighbors", t.Name(), r, len(nb))
+		}
+	}
+	visited := make([]bool, p)
+	for r, i := 0, 0; i < p; r, i = tr.right[r], i+1 {
+		if visited[r] {
+			return nil, fmt.Errorf("%s is not a ring: the token returns to rank %d after %d hops", t.Name(), r, i)
+		}
+		visited[r] = true
+	}
+	for r := 0; r < p; r++ {
+		tr.procs = append(tr.procs, &TokenProcess{rank: r, ring: tr, inbox: mailbox.New[token]()})
+	}
+	tr.wg.Add(p)
+	for _, pr := range tr.procs {
+		go pr.run()
+	}
+	if p > 0 {
+		tr.procs[0].inbox.Push(token{})
+	}
+	return tr, nil
+}
+
+// Process returns rank r.
+func (tr *TokenRing) Process(r int) *TokenProcess { return tr.procs[r] }
+
+// Passes returns the number of times the token was passed on so far.
+func (tr *TokenRing) Passes() int { return int(tr.passes.Load()) }
+
+// Close stops every rank. No rank may be waiting in Lock.
+func (tr *TokenRing) Close() {
+	close(tr.done)
+	tr.wg.Wait()
+}
+
+func (tr *TokenRing) pass(from int, t token) {
+	tr.passes.Add(1)
+	tr.procs[tr.right[from]].inbox.Push(t)
+}
+
+// Lock blocks until the token reaches the rank.
+func (p *TokenProcess) Lock() {
+	p.local.Lock()
+	p.mu.Lock()
+	p.wanting = true
+	granted := make(chan struct{})
+	p.granted = granted
+	p.mu.Unlock()
+	<-granted
+}
+
+// Unlock leaves the critical section and passes the token on.
+func (p *TokenProcess) Unlock() {
+	p.mu.Lock()
+	t := *p.held
+	p.held = nil
+	t.idle = 0
+	p.seen = t.entries
+	p.mu.Unlock()
+	p.ring.pass(p.rank, t)
+	p.local.Unlock()
+}
+
+// Overtaken returns how many times other ranks entered the critical section
+// between the token last leaving this rank and its last entry. That covers
+// the whole wait of its last Lock, and is below the number of ranks: the
+// token visits every other rank at most once on the way.
+func (p *TokenProcess) Overtaken() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.overtaken
+}
+
+func (p *TokenProcess) run() {
+	defer p.ring.wg.Done()
+	for {
+		select {
+		case <-p.inbox.Signal():
+			for _, t := range p.inbox.Drain() {
+				p.receive(t)
+			}
+		case <-p.ring.done:
+			return
+		}
+	}
+}
+
+func (p *TokenProcess) receive(t token) {
+	p.mu.Lock()
+	if p.wanting {
+		p.wanting = false
+		p.overtaken = int(t.entries - p.seen)
+		t.entries++
+		p.held = &t
+		close(p.granted)
+		p.mu.Unlock()
+		return
+	}
+	p.seen = t.entries
+	p.mu.Unlock()
+	if t.idle >= len(p.ring.procs) {
+		time.Sleep(idlePause)
+	}
+	t.idle++
+	p.ring.pass(p.rank, t)
+}
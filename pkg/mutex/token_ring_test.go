@@ -0,0 +1,67 @@
+package mutex
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/sanderblue/algorithms/pkg/ringallreduce"
+)
+
+func TestTokenRing(t *testing.T) {
+	permuted, err := ringallreduce.NewPermutedRing([]int{3, 0, 4, 1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		topology ringallreduce.Topology
+	}{
+		{"single", ringallreduce.NewRing(1)},
+		{"pair", ringallreduce.NewRing(2)},
+		{"ring", ringallreduce.NewRing(6)},
+		{"permuted", permuted},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			const rounds = 20
+			n := tc.topology.Size()
+			tr, err := NewTokenRing(tc.topology)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tr.Close()
+			lockers := make([]sync.Locker, n)
+			for i := range lockers {
+				lockers[i] = tr.Process(i)
+			}
+			var order []int
+			worst := 0
+			contend(t, lockers, rounds, func(i int) {
+				order = append(order, i)
+				worst = max(worst, tr.Process(i).Overtaken())
+			})
+
+			// Fairness: a waiting rank is overtaken by every other rank at
+			// most once.
+			if worst > n-1 {
+				t.Errorf("a rank was overtaken %d times, want at most %d", worst, n-1)
+			}
+			if len(order) != n*rounds {
+				t.Errorf("%d entries, want %d", len(order), n*rounds)
+			}
+			if tr.Passes() < len(order)-1 {
+				t.Errorf("%d entries with only %d passes of the token", len(order), tr.Passes())
+			}
+		})
+	}
+}
+
+func TestTokenRing_NotARing(t *testing.T) {
+	if _, err := NewTokenRing(ringallreduce.NewTree(5)); err == nil {
+		t.Error("expected an error for a tree")
+	}
+	if _, err := NewTokenRing(ringallreduce.NewTorus(3, 3)); err == nil {
+		t.Error("expected an error for a torus")
+	}
+}
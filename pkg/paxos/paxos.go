@@ -0,0 +1,231 @@
+// Package paxos implements the Paxos consensus algorithm: single-decree
+// Paxos, which chooses one value, and Multi-Paxos, which chooses the values
+// of a log of instances with a single prepare phase per leadership.
+//
+// Nodes play the three roles of the algorithm. Proposers drive the prepare
+// and accept phases, acceptors vote, and learners count the votes to find the
+// chosen values. Roles are plain state machines that consume a message and
+// return the messages to send, so any network can carry them; Simulation
+// connects nodes through a network that loses, duplicates and reorders
+// messages, to show that no two learners ever disagree.
+//
+// References:
+//
+// https://lamport.azurewebsites.net/pubs/paxos-simple.pdf
+package paxos
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// Ballot orders proposals. Proposers use distinct IDs, so no two proposers
+// share a ballot. The zero Ballot precedes every ballot a proposer uses.
+type Ballot struct {
+	N  int
+	ID int
+}
+
+// Less reports whether b precedes o.
+func (b Ballot) Less(o Ballot) bool {
+	return b.N < o.N || b.N == o.N && b.ID < o.ID
+}
+
+func (b Ballot) String() string { return fmt.Sprintf("%d.%d", b.N, b.ID) }
+
+// Mode selects between single-decree Paxos and Multi-Paxos.
+type Mode int
+
+const (
+	// SingleDecree chooses one value, in instance 0.
+	SingleDecree Mode = iota
+	// MultiPaxos chooses a log of values, one per instance.
+	MultiPaxos
+)
+
+func (m Mode) String() string {
+	switch m {
+	case SingleDecree:
+		return "single-decree"
+	case MultiPaxos:
+		return "multi-paxos"
+	default:
+		return fmt.Sprintf("mode(%d)", int(m))
+	}
+}
+
+// MessageType is the kind of a Message.
+type MessageType int
+
+const (
+	MsgPrepare  MessageType = iota // phase 1a, proposer to acceptors
+	MsgPromise                     // phase 1b, acceptor to proposer
+	MsgAccept                      // phase 2a, proposer to acceptors
+	MsgAccepted                    // phase 2b, acceptor to learners
+	MsgNack                        // an acceptor promised a higher ballot
+)
+
+func (t MessageType) String() string {
+	switch t {
+	case MsgPrepare:
+		return "prepare"
+	case MsgPromise:
+		return "promise"
+	case MsgAccept:
+		return "accept"
+	case MsgAccepted:
+		return "accepted"
+	case MsgNack:
+		return "nack"
+	default:
+		return fmt.Sprintf("message(%d)", int(t))
+	}
+}
+
+// Message is exchanged between the roles of the nodes.
+type Message struct {
+	Type MessageType
+	From int
+	To   int
+	// Instance is the log slot of MsgAccept and MsgAccepted. A MsgPrepare
+	// and its MsgPromise cover every instance from Instance on.
+	Instance int
+	Ballot   Ballot // of the proposal; in a MsgNack, the ballot promised instead
+	Value    []byte
+	Accepted []Proposal // MsgPromise: what the acceptor accepted in the covered instances
+}
+
+// Proposal is a value proposed, or accepted, in an instance at a ballot. A
+// nil Value is a no-op that fills a gap in the log.
+type Proposal struct {
+	Instance int
+	Ballot   Ballot
+	Value    []byte
+}
+
+func quorum(count, n int) bool { return 2*count > n }
+
+func broadcast(n int, m Message) []Message {
+	out := make([]Message, n)
+	for to := range out {
+		out[to] = m
+		out[to].To = to
+	}
+	return out
+}
+
+// Acceptor votes on proposals. It never accepts a proposal of a ballot lower
+// than one it promised, and reports what it accepted when it promises.
+type Acceptor struct {
+	ID int
+	N  int // nodes in the cluster, all of which learn
+
+	promised Ballot
+	accepted map[int]Proposal
+}
+
+func NewAcceptor(id, n int) *Acceptor {
+	return &Acceptor{ID: id, N: n, accepted: make(map[int]Proposal)}
+}
+
+// Handle processes a MsgPrepare or MsgAccept and returns the replies.
+func (a *Acceptor) Handle(m Message) []Message {
+	if m.Type != MsgPrepare && m.Type != MsgAccept {
+		return nil
+	}
+	if m.Ballot.Less(a.promised) {
+		return []Message{{Type: MsgNack, From: a.ID, To: m.From, Instance: m.Instance, Ballot: a.promised}}
+	}
+	a.promised = m.Ballot
+	if m.Type == MsgPrepare {
+		var accepted []Proposal
+		for inst, p := range a.accepted {
+			if inst >= m.Instance {
+				accepted = append(accepted, p)
+			}
+		}
+		sort.Slice(accepted, func(i, j int) bool { return accepted[i].Instance < accepted[j].Instance })
+		return []Message{{Type: MsgPromise, From: a.ID, To: m.From, Instance: m.Instance, Ballot: m.Ballot, Accepted: accepted}}
+	}
+	a.accepted[m.Instance] = Proposal{Instance: m.Instance, Ballot: m.Ballot, Value: m.Value}
+	return broadcast(a.N, Message{Type: MsgAccepted, From: a.ID, Instance: m.Instance, Ballot: m.Ballot, Value: m.Value})
+}
+
+// Learner finds the chosen values: a proposal is chosen once a majority of
+// acceptors accepted it.
+type Learner struct {
+	N int
+
+	votes  map[int]map[Ballot]map[int]bool // instance, ballot, acceptors
+	chosen map[int]Proposal
+}
+
+func NewLearner(n int) *Learner {
+	return &Learner{N: n, votes: make(map[int]map[Ballot]map[int]bool), chosen: make(map[int]Proposal)}
+}
+
+// Observe counts the vote of a MsgAccepted.
+func (l *Learner) Observe(m Message) {
+	if m.Type != MsgAccepted {
+		return
+	}
+	if _, ok := l.chosen[m.Instance]; ok {
+		return
+	}
+	ballots := l.votes[m.Instance]
+	if ballots == nil {
+		ballots = make(map[Ballot]map[int]bool)
+		l.votes[m.Instance] = ballots
+	}
+	voters := ballots[m.Ballot]
+	if voters == nil {
+		voters = make(map[int]bool)
+		ballots[m.Ballot] = voters
+	}
+	voters[m.From] = true
+	if quorum(len(voters), l.N) {
+		l.chosen[m.Instance] = Proposal{Instance: m.Instance, Ballot: m.Ballot, Value: m.Value}
+		delete(l.votes, m.Instance)
+	}
+}
+
+// Chosen returns the proposal chosen in instance, if the learner knows it.
+func (l *Learner) Chosen(instance int) (Proposal, bool) {
+	p, ok := l.chosen[instance]
+	return p, ok
+}
+
+// FirstUnchosen returns the first instance the learner knows no chosen value
+// of.
+func (l *Learner) FirstUnchosen() int {
+	inst := 0
+	for {
+		if _, ok := l.chosen[inst]; !ok {
+			return inst
+		}
+		inst++
+	}
+}
+
+// Log returns the chosen values of the instances before FirstUnchosen, in
+// order, without no-ops.
+func (l *Learner) Log() [][]byte {
+	var out [][]byte
+	for inst := 0; inst < l.FirstUnchosen(); inst++ {
+		if v := l.chosen[inst].Value; v != nil {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// agree returns an error if l and o know different values for an instance.
+func (l *Learner) agree(o *Learner) error {
+	for inst, p := range l.chosen {
+		if q, ok := o.chosen[inst]; ok && !bytes.Equal(p.Value, q.Value) {
+			return fmt.Errorf("instance %d: chosen %q at ballot %v and %q at ballot %v", inst, p.Value, p.Ballot, q.Value, q.Ballot)
+		}
+	}
+	return nil
+}
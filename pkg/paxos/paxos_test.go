@@ -0,0 +1,79 @@
+package paxos
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBallot_Less(t *testing.T) {
+	tests := []struct {
+		a, b Ballot
+		want bool
+	}{
+		{Ballot{}, Ballot{1, 0}, true},
+		{Ballot{1, 0}, Ballot{1, 2}, true},
+		{Ballot{1, 2}, Ballot{2, 0}, true},
+		{Ballot{2, 0}, Ballot{1, 2}, false},
+		{Ballot{1, 1}, Ballot{1, 1}, false},
+	}
+	for _, tc := range tests {
+		if got := tc.a.Less(tc.b); got != tc.want {
+			t.Errorf("%v.Less(%v) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestAcceptor(t *testing.T) {
+	a := NewAcceptor(0, 3)
+	promise := a.Handle(Message{Type: MsgPrepare, From: 1, Ballot: Ballot{1, 1}})
+	if len(promise) != 1 || promise[0].Type != MsgPromise || len(promise[0].Accepted) != 0 {
+		t.Fatalf("unexpected reply to the first prepare: %+v", promise)
+	}
+
+	accepted := a.Handle(Message{Type: MsgAccept, From: 1, Instance: 4, Ballot: Ballot{1, 1}, Value: []byte("x")})
+	if len(accepted) != 3 || accepted[2].Type != MsgAccepted || accepted[2].To != 2 {
+		t.Fatalf("expected MsgAccepted to all 3 learners, got %+v", accepted)
+	}
+
+	// A higher ballot learns of the accepted value; a lower one is refused.
+	promise = a.Handle(Message{Type: MsgPrepare, From: 2, Instance: 3, Ballot: Ballot{2, 2}})
+	want := []Proposal{{Instance: 4, Ballot: Ballot{1, 1}, Value: []byte("x")}}
+	if len(promise) != 1 || !reflect.DeepEqual(promise[0].Accepted, want) {
+		t.Fatalf("got %+v, want a promise reporting %+v", promise, want)
+	}
+	for _, typ := range []MessageType{MsgPrepare, MsgAccept} {
+		nack := a.Handle(Message{Type: typ, From: 1, Ballot: Ballot{1, 1}})
+		if len(nack) != 1 || nack[0].Type != MsgNack || nack[0].Ballot != (Ballot{2, 2}) {
+			t.Errorf("%v of a lower ballot: got %+v, want a nack of ballot 2.2", typ, nack)
+		}
+	}
+	// Instances before the one prepared aren't reported.
+	if promise = a.Handle(Message{Type: MsgPrepare, From: 2, Instance: 5, Ballot: Ballot{3, 2}}); len(promise[0].Accepted) != 0 {
+		t.Errorf("reported instances before 5: %+v", promise[0].Accepted)
+	}
+}
+
+func TestLearner(t *testing.T) {
+	l := NewLearner(5)
+	vote := func(from int, b Ballot, v string) {
+		l.Observe(Message{Type: MsgAccepted, From: from, Instance: 1, Ballot: b, Value: []byte(v)})
+	}
+	vote(0, Ballot{1, 0}, "a")
+	vote(0, Ballot{1, 0}, "a") // duplicate
+	vote(1, Ballot{2, 1}, "b")
+	vote(2, Ballot{1, 0}, "a")
+	if _, ok := l.Chosen(1); ok {
+		t.Fatal("chosen without a majority of one ballot")
+	}
+	vote(3, Ballot{1, 0}, "a")
+	if p, ok := l.Chosen(1); !ok || string(p.Value) != "a" {
+		t.Fatalf("got %+v, %v, want a chosen", p, ok)
+	}
+	if l.FirstUnchosen() != 0 || len(l.Log()) != 0 {
+		t.Errorf("instance 0 is unknown, yet FirstUnchosen %d and Log %q", l.FirstUnchosen(), l.Log())
+	}
+	l.chosen[0] = Proposal{} // a no-op
+	if got := l.Log(); l.FirstUnchosen() != 2 || len(got) != 1 || string(got[0]) != "a" {
+		t.Errorf("FirstUnchosen %d and Log %q, want 2 and [a]", l.FirstUnchosen(), got)
+	}
+}
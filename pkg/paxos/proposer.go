@@ -0,0 +1,263 @@
+package paxos
+
+import (
+	"math/rand"
+	"sort"
+)
+
+type proposerState int
+
+const (
+	following proposerState = iota // not trying to lead
+	preparing                      // waiting for promises
+	leading                        // promised by a majority, proposing directly
+)
+
+// DefaultTimeout is the number of ticks a proposer waits for progress before
+// retrying; the actual timeout is randomized up to twice that, so dueling
+// proposers eventually let one another finish.
+const DefaultTimeout = 10
+
+type submission struct {
+	id    int
+	value []byte
+}
+
+type slot struct {
+	value []byte
+	item  int // submission ID, or -1 for a value the proposer was bound to
+}
+
+// Proposer gets submitted values chosen. It prepares a ballot once, for every
+// instance the learner of its node doesn't know chosen, then proposes values
+// directly in phase 2 as long as no higher ballot shows up, retransmitting
+// on timeouts.
+//
+// Where a majority reports accepted values, the proposer must propose those
+// values instead, and fills gaps with no-ops. A submission is taken as done
+// once the instance it was proposed in is chosen at the proposer's ballot;
+// a proposer that loses its leadership while a submission is in flight
+// proposes it again, so a value can end up chosen in two instances.
+type Proposer struct {
+	ID      int
+	N       int
+	Mode    Mode
+	Timeout int // in ticks, DefaultTimeout if 0
+
+	learner  *Learner
+	rng      *rand.Rand
+	state    proposerState
+	ballot   Ballot
+	seen     int // highest ballot round seen
+	from     int // first instance the current ballot covers
+	promises map[int]bool
+	bound    map[int]Proposal // highest accepted proposal per instance among the promises
+	inflight map[int]slot
+	pending  []submission
+	ids      int
+	next     int // next instance to propose a submission in
+	elapsed  int
+	timeout  int
+}
+
+// NewProposer creates the proposer of node id, whose learner tells it which
+// instances are chosen.
+func NewProposer(id, n int, mode Mode, learner *Learner, seed int64) *Proposer {
+	return &Proposer{ID: id, N: n, Mode: mode, learner: learner, rng: rand.New(rand.NewSource(seed)),
+		inflight: make(map[int]slot)}
+}
+
+// Idle reports whether the proposer has nothing left to get chosen.
+func (p *Proposer) Idle() bool {
+	p.settle()
+	return len(p.pending) == 0 && len(p.inflight) == 0
+}
+
+// Leading reports whether the proposer holds a ballot promised by a majority.
+func (p *Proposer) Leading() bool { return p.state == leading }
+
+// Submit queues value to be chosen. In SingleDecree mode the proposer only
+// tries to get one value chosen, whichever it is: it stops as soon as
+// instance 0 is chosen.
+func (p *Proposer) Submit(value []byte) []Message {
+	if value == nil {
+		value = []byte{}
+	}
+	p.pending = append(p.pending, submission{id: p.ids, value: value})
+	p.ids++
+	if p.state == leading {
+		return p.assign()
+	}
+	return nil
+}
+
+// Tick advances the proposer's clock by one tick; on a timeout it prepares a
+// new ballot, or retransmits its proposals if it leads.
+func (p *Proposer) Tick() []Message {
+	if p.timeout == 0 {
+		p.resetTimeout()
+	}
+	p.elapsed++
+	if p.elapsed < p.timeout {
+		return nil
+	}
+	p.resetTimeout()
+	if p.Idle() {
+		return nil
+	}
+	if p.state == leading {
+		return p.retransmit()
+	}
+	return p.prepare()
+}
+
+func (p *Proposer) resetTimeout() {
+	base := p.Timeout
+	if base <= 0 {
+		base = DefaultTimeout
+	}
+	p.elapsed = 0
+	p.timeout = base + p.rng.Intn(base)
+}
+
+// Handle processes a MsgPromise, MsgNack or MsgAccepted, the latter after
+// the node's learner observed it.
+func (p *Proposer) Handle(m Message) []Message {
+	switch m.Type {
+	case MsgPromise:
+		if p.state != preparing || m.Ballot != p.ballot {
+			return nil
+		}
+		p.promises[m.From] = true
+		for _, a := range m.Accepted {
+			if b, ok := p.bound[a.Instance]; !ok || b.Ballot.Less(a.Ballot) {
+				p.bound[a.Instance] = a
+			}
+		}
+		if quorum(len(p.promises), p.N) {
+			return p.lead()
+		}
+	case MsgNack:
+		p.seen = max(p.seen, m.Ballot.N)
+		if p.state != following && p.ballot.Less(m.Ballot) {
+			p.state = following
+			p.requeue()
+		}
+	case MsgAccepted:
+		p.settle()
+		if p.state == leading {
+			return p.assign()
+		}
+	}
+	return nil
+}
+
+// prepare starts phase 1 with a ballot higher than any seen.
+func (p *Proposer) prepare() []Message {
+	p.requeue()
+	p.ballot = Ballot{N: max(p.ballot.N, p.seen) + 1, ID: p.ID}
+	p.seen = p.ballot.N
+	p.state = preparing
+	p.from = p.learner.FirstUnchosen()
+	p.promises = make(map[int]bool)
+	p.bound = make(map[int]Proposal)
+	return broadcast(p.N, Message{Type: MsgPrepare, From: p.ID, Instance: p.from, Ballot: p.ballot})
+}
+
+// lead proposes the values the promises bound the proposer to, no-ops in
+// the gaps between them, and then the submissions.
+func (p *Proposer) lead() []Message {
+	p.state = leading
+	last := p.from - 1
+	for inst := range p.bound {
+		last = max(last, inst)
+	}
+	var out []Message
+	for inst := p.from; inst <= last; inst++ {
+		if _, ok := p.learner.Chosen(inst); ok {
+			continue
+		}
+		out = append(out, p.accept(inst, slot{value: p.bound[inst].Value, item: -1})...)
+	}
+	p.next = last + 1
+	return append(out, p.assign()...)
+}
+
+// assign proposes pending submissions in free instances.
+func (p *Proposer) assign() []Message {
+	var out []Message
+	for len(p.pending) > 0 {
+		inst := 0
+		if p.Mode == SingleDecree {
+			if _, busy := p.inflight[0]; busy {
+				break
+			}
+		} else {
+			for _, ok := p.learner.Chosen(p.next); ok; _, ok = p.learner.Chosen(p.next) {
+				p.next++
+			}
+			inst = p.next
+			p.next++
+		}
+		s := p.pending[0]
+		p.pending = p.pending[1:]
+		out = append(out, p.accept(inst, slot{value: s.value, item: s.id})...)
+	}
+	return out
+}
+
+func (p *Proposer) accept(inst int, s slot) []Message {
+	p.inflight[inst] = s
+	return broadcast(p.N, Message{Type: MsgAccept, From: p.ID, Instance: inst, Ballot: p.ballot, Value: s.value})
+}
+
+func (p *Proposer) retransmit() []Message {
+	var out []Message
+	for _, inst := range p.instances() {
+		out = append(out, broadcast(p.N, Message{Type: MsgAccept, From: p.ID, Instance: inst, Ballot: p.ballot, Value: p.inflight[inst].value})...)
+	}
+	return out
+}
+
+// settle retires the proposals the learner knows chosen.
+func (p *Proposer) settle() {
+	if p.Mode == SingleDecree {
+		if _, ok := p.learner.Chosen(0); ok {
+			p.pending, p.inflight = nil, make(map[int]slot)
+		}
+		return
+	}
+	for _, inst := range p.instances() {
+		chosen, ok := p.learner.Chosen(inst)
+		if !ok {
+			continue
+		}
+		s := p.inflight[inst]
+		delete(p.inflight, inst)
+		if s.item >= 0 && chosen.Ballot != p.ballot {
+			// Another proposer's value won the instance.
+			p.pending = append(p.pending, submission{id: s.item, value: s.value})
+		}
+	}
+}
+
+// requeue returns the submissions in flight to the pending queue, in order.
+func (p *Proposer) requeue() {
+	var back []submission
+	for _, inst := range p.instances() {
+		if s := p.inflight[inst]; s.item >= 0 {
+			back = append(back, submission{id: s.item, value: s.value})
+		}
+	}
+	p.pending = append(back, p.pending...)
+	p.inflight = make(map[int]slot)
+}
+
+func (p *Proposer) instances() []int {
+	out := make([]int, 0, len(p.inflight))
+	for inst := range p.inflight {
+		out = append(out, inst)
+	}
+	sort.Ints(out)
+	return out
+}
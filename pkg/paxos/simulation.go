@@ -0,0 +1,142 @@
+package paxos
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Node plays all three roles of Paxos.
+type Node struct {
+	ID       int
+	Proposer *Proposer
+	Acceptor *Acceptor
+	Learner  *Learner
+}
+
+func NewNode(id, n int, mode Mode, seed int64) *Node {
+	l := NewLearner(n)
+	return &Node{ID: id, Proposer: NewProposer(id, n, mode, l, seed), Acceptor: NewAcceptor(id, n), Learner: l}
+}
+
+// Handle routes m to the roles it concerns and returns their replies.
+func (n *Node) Handle(m Message) []Message {
+	switch m.Type {
+	case MsgPrepare, MsgAccept:
+		return n.Acceptor.Handle(m)
+	case MsgAccepted:
+		n.Learner.Observe(m)
+	}
+	return n.Proposer.Handle(m)
+}
+
+// Simulation runs a cluster of nodes over a network that drops a fraction
+// Loss of the messages, duplicates a fraction Duplicate of them and delivers
+// them in random order. Time advances one tick of a random node at a time.
+// Everything is driven by one seeded source, so runs are reproducible.
+type Simulation struct {
+	Nodes     []*Node
+	Loss      float64
+	Duplicate float64
+	// TickRate is the probability that a step ticks a node rather than
+	// delivering a message; 0.1 if 0.
+	TickRate float64
+
+	Sent    map[MessageType]int
+	Dropped int
+	Steps   int
+
+	rng   *rand.Rand
+	queue []Message
+}
+
+func NewSimulation(n int, mode Mode, seed int64) *Simulation {
+	s := &Simulation{Sent: make(map[MessageType]int), rng: rand.New(rand.NewSource(seed))}
+	for id := 0; id < n; id++ {
+		s.Nodes = append(s.Nodes, NewNode(id, n, mode, seed+int64(id)+1))
+	}
+	return s
+}
+
+// Submit hands value to the proposer of node id.
+func (s *Simulation) Submit(id int, value []byte) {
+	s.post(s.Nodes[id].Proposer.Submit(value))
+}
+
+func (s *Simulation) post(msgs []Message) {
+	for _, m := range msgs {
+		s.Sent[m.Type]++
+		if s.rng.Float64() < s.Loss {
+			s.Dropped++
+			continue
+		}
+		s.queue = append(s.queue, m)
+		if s.rng.Float64() < s.Duplicate {
+			s.queue = append(s.queue, m)
+		}
+	}
+}
+
+// Step ticks a random node or delivers a random message in flight.
+func (s *Simulation) Step() {
+	s.Steps++
+	rate := s.TickRate
+	if rate <= 0 {
+		rate = 0.1
+	}
+	if len(s.queue) == 0 || s.rng.Float64() < rate {
+		node := s.Nodes[s.rng.Intn(len(s.Nodes))]
+		s.post(node.Proposer.Tick())
+		return
+	}
+	i := s.rng.Intn(len(s.queue))
+	m := s.queue[i]
+	s.queue[i] = s.queue[len(s.queue)-1]
+	s.queue = s.queue[:len(s.queue)-1]
+	s.post(s.Nodes[m.To].Handle(m))
+}
+
+// RunUntil steps until done returns true, for at most maxSteps steps. It
+// reports whether done returned true.
+func (s *Simulation) RunUntil(done func() bool, maxSteps int) bool {
+	for i := 0; i < maxSteps; i++ {
+		if done() {
+			return true
+		}
+		s.Step()
+	}
+	return done()
+}
+
+// Idle reports whether every proposer has nothing left to get chosen.
+func (s *Simulation) Idle() bool {
+	for _, n := range s.Nodes {
+		if !n.Proposer.Idle() {
+			return false
+		}
+	}
+	return true
+}
+
+// Check returns an error if two learners know different values chosen in
+// one instance: the safety property of Paxos.
+func (s *Simulation) Check() error {
+	for i, a := range s.Nodes {
+		for _, b := range s.Nodes[i+1:] {
+			if err := a.Learner.agree(b.Learner); err != nil {
+				return fmt.Errorf("learners %d and %d disagree: %w", a.ID, b.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Chosen returns the value chosen in every instance any learner knows.
+func (s *Simulation) Chosen() map[int][]byte {
+	out := make(map[int][]byte)
+	for _, n := range s.Nodes {
+		for inst, p := range n.Learner.chosen {
+			out[inst] = p.Value
+		}
+	}
+	return out
+}
@@ -0,0 +1,84 @@
+package paxos
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSimulation_SingleDecree(t *testing.T) {
+	for seed := int64(0); seed < 50; seed++ {
+		s := NewSimulation(5, SingleDecree, seed)
+		s.Loss, s.Duplicate = 0.3, 0.1
+		proposed := map[string]bool{}
+		for id := 0; id < 3; id++ {
+			v := fmt.Sprint("value of ", id)
+			proposed[v] = true
+			s.Submit(id, []byte(v))
+		}
+		if !s.RunUntil(s.Idle, 200000) {
+			t.Fatalf("seed %d: nothing chosen after %d steps", seed, s.Steps)
+		}
+		if err := s.Check(); err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		chosen := s.Chosen()
+		if len(chosen) != 1 || !proposed[string(chosen[0])] {
+			t.Fatalf("seed %d: chosen %q, want one of the proposed values", seed, chosen)
+		}
+	}
+}
+
+func TestSimulation_MultiPaxos(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		s := NewSimulation(5, MultiPaxos, seed)
+		s.Loss, s.Duplicate = 0.2, 0.1
+		want := map[string]bool{}
+		for id := 0; id < 3; id++ {
+			for i := 0; i < 5; i++ {
+				v := fmt.Sprintf("%d/%d", id, i)
+				want[v] = true
+				s.Submit(id, []byte(v))
+			}
+		}
+		if !s.RunUntil(s.Idle, 500000) {
+			t.Fatalf("seed %d: submissions still pending after %d steps", seed, s.Steps)
+		}
+		if err := s.Check(); err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		for _, v := range s.Chosen() {
+			delete(want, string(v))
+		}
+		if len(want) != 0 {
+			t.Fatalf("seed %d: never chosen: %v", seed, want)
+		}
+	}
+}
+
+func TestSimulation_PrepareOnce(t *testing.T) {
+	// Over a reliable network, a stable leader prepares once and then needs
+	// a single round trip per value.
+	s := NewSimulation(3, MultiPaxos, 1)
+	const values = 10
+	for i := 0; i < values; i++ {
+		s.Submit(0, []byte{byte(i)})
+	}
+	if !s.RunUntil(s.Idle, 100000) {
+		t.Fatal("submissions still pending")
+	}
+	if got := s.Sent[MsgPrepare]; got != 3 {
+		t.Errorf("sent %d prepares, want 3", got)
+	}
+	if got := s.Sent[MsgAccept]; got != 3*values {
+		t.Errorf("sent %d accepts, want %d", got, 3*values)
+	}
+	log := s.Nodes[0].Learner.Log()
+	if len(log) != values {
+		t.Fatalf("log %v, want %d values", log, values)
+	}
+	for i, v := range log {
+		if v[0] != byte(i) {
+			t.Errorf("instance %d holds %v, want %d", i, v, i)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Package phiaccrual implements the φ accrual failure detector. Rather than
+// a binary verdict after a fixed timeout, it outputs a suspicion level φ
+// that grows continuously with the time since the last heartbeat, scaled by
+// how regular the heartbeats of that process have been so far: a process
+// with jittery heartbeats is given more slack than a punctual one. A φ of
+// 1 means a 10% chance that suspecting the process is a mistake, 2 a 1%
+// chance, and so on, so applications pick the threshold matching the
+// mistakes they can afford.
+//
+// Inter-arrival times are modelled as normally distributed, with the mean
+// and standard deviation of a sliding window of recent intervals.
+//
+// References:
+//
+// Hayashibara, Défago, Yared and Katayama, The φ Accrual Failure Detector,
+// SRDS 2004.
+package phiaccrual
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Config tunes a Detector.
+type Config struct {
+	Threshold              float64       // φ above which a process is suspected; defaults to 8
+	WindowSize             int           // intervals kept per process; defaults to 100
+	MinStdDev              time.Duration // floor of the standard deviation, so punctual heartbeats don't make φ explode; defaults to 100ms
+	AcceptablePause        time.Duration // added to the mean interval, to tolerate pauses such as garbage collection
+	FirstHeartbeatEstimate time.Duration // interval assumed until a second heartbeat arrives; defaults to 1s
+}
+
+func (c Config) withDefaults() Config {
+	if c.Threshold <= 0 {
+		c.Threshold = 8
+	}
+	if c.WindowSize <= 0 {
+		c.WindowSize = 100
+	}
+	if c.MinStdDev <= 0 {
+		c.MinStdDev = 100 * time.Millisecond
+	}
+	if c.FirstHeartbeatEstimate <= 0 {
+		c.FirstHeartbeatEstimate = time.Second
+	}
+	return c
+}
+
+// history is the heartbeat history of one process.
+type history struct {
+	last      time.Time
+	intervals []float64 // in seconds, a ring buffer once full
+	next      int
+	sum       float64
+	sumSq     float64
+}
+
+func (h *history) add(interval float64, size int) {
+	if len(h.intervals) < size {
+		h.intervals = append(h.intervals, interval)
+	} else {
+		old := h.intervals[h.next]
+		h.sum -= old
+		h.sumSq -= old * old
+		h.intervals[h.next] = interval
+		h.next = (h.next + 1) % size
+	}
+	h.sum += interval
+	h.sumSq += interval * interval
+}
+
+// Detector tracks the heartbeats of processes identified by integers. It
+// implements ringallreduce.FailureDetector and is safe for concurrent use.
+type Detector struct {
+	Config Config
+
+	mu      sync.Mutex
+	history map[int]*history
+}
+
+// New returns a detector that knows no process yet.
+func New(cfg Config) *Detector {
+	return &Detector{Config: cfg.withDefaults(), history: make(map[int]*history)}
+}
+
+// Heartbeat records a heartbeat of process id that arrived at at.
+// Heartbeats older than the last one are ignored.
+func (d *Detector) Heartbeat(id int, at time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	h, ok := d.history[id]
+	if !ok {
+		d.history[id] = &history{last: at}
+		return
+	}
+	if !at.After(h.last) {
+		return
+	}
+	h.add(at.Sub(h.last).Seconds(), d.Config.WindowSize)
+	h.last = at
+}
+
+// Phi returns the suspicion level of process id at now: -log₁₀ of the
+// probability that a heartbeat arrives later than now, given the intervals
+// seen so far. It is 0 for a process that never sent a heartbeat, and +Inf
+// once the probability underflows.
+func (d *Detector) Phi(id int, now time.Time) float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	h, ok := d.history[id]
+	if !ok {
+		return 0
+	}
+	var mean, std float64
+	if n := float64(len(h.intervals)); n == 0 {
+		mean = d.Config.FirstHeartbeatEstimate.Seconds()
+		std = mean / 4
+	} else {
+		mean = h.sum / n
+		std = math.Sqrt(max(h.sumSq/n-mean*mean, 0))
+	}
+	std = max(std, d.Config.MinStdDev.Seconds())
+	mean += d.Config.AcceptablePause.Seconds()
+	elapsed := now.Sub(h.last).Seconds()
+	later := 0.5 * math.Erfc((elapsed-mean)/(std*math.Sqrt2))
+	return -math.Log10(later)
+}
+
+// Suspect reports whether the suspicion level of process id exceeds the
+// threshold.
+func (d *Detector) Suspect(id int, now time.Time) bool {
+	return d.Phi(id, now) > d.Config.Threshold
+}
+
+// Remove forgets process id, for instance once it has been replaced.
+func (d *Detector) Remove(id int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.history, id)
+}
@@ -0,0 +1,107 @@
+package phiaccrual
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/sanderblue/algorithms/pkg/ringallreduce"
+)
+
+var _ ringallreduce.FailureDetector = (*Detector)(nil)
+
+// beat sends n heartbeats of process id to d, every interval plus a normal
+// jitter of standard deviation jitter, and returns the time of the last.
+func beat(d *Detector, id int, start time.Time, n int, interval, jitter time.Duration, rng *rand.Rand) time.Time {
+	at := start
+	for i := 0; i < n; i++ {
+		d.Heartbeat(id, at)
+		at = at.Add(interval + time.Duration(rng.NormFloat64()*float64(jitter)))
+	}
+	return at.Add(-interval)
+}
+
+func TestDetector_Phi(t *testing.T) {
+	d := New(Config{MinStdDev: time.Millisecond})
+	rng := rand.New(rand.NewSource(1))
+	start := time.Unix(0, 0)
+	last := beat(d, 0, start, 50, 100*time.Millisecond, 5*time.Millisecond, rng)
+
+	if phi := d.Phi(0, last); phi > 0.1 {
+		t.Errorf("φ right after a heartbeat: %v", phi)
+	}
+	// φ grows with the time since the last heartbeat.
+	prev := 0.0
+	for _, after := range []time.Duration{90, 100, 105, 110, 120, 150} {
+		phi := d.Phi(0, last.Add(after*time.Millisecond))
+		if phi < prev {
+			t.Errorf("φ fell from %v to %v at %vms", prev, phi, int(after))
+		}
+		prev = phi
+	}
+	if d.Suspect(0, last.Add(110*time.Millisecond)) {
+		t.Error("suspected two standard deviations late")
+	}
+	if !d.Suspect(0, last.Add(150*time.Millisecond)) {
+		t.Error("not suspected ten standard deviations late")
+	}
+	if phi := d.Phi(0, last.Add(time.Hour)); !math.IsInf(phi, 1) {
+		t.Errorf("φ an hour late: %v, want +Inf", phi)
+	}
+	if d.Phi(1, last) != 0 || d.Suspect(1, last) {
+		t.Error("a process that never sent a heartbeat is suspected")
+	}
+	d.Remove(0)
+	if d.Phi(0, last.Add(time.Hour)) != 0 {
+		t.Error("φ of a removed process")
+	}
+}
+
+func TestDetector_AdaptsToJitter(t *testing.T) {
+	d := New(Config{MinStdDev: time.Millisecond})
+	rng := rand.New(rand.NewSource(2))
+	start := time.Unix(0, 0)
+	steady := beat(d, 0, start, 100, 100*time.Millisecond, time.Millisecond, rng)
+	jittery := beat(d, 1, start, 100, 100*time.Millisecond, 30*time.Millisecond, rng)
+
+	// The same delay is damning for a punctual process and ordinary for an
+	// erratic one.
+	if !d.Suspect(0, steady.Add(150*time.Millisecond)) {
+		t.Error("steady process not suspected 50ms late")
+	}
+	if d.Suspect(1, jittery.Add(150*time.Millisecond)) {
+		t.Error("jittery process suspected 50ms late")
+	}
+}
+
+func TestDetector_Window(t *testing.T) {
+	d := New(Config{WindowSize: 10, MinStdDev: time.Millisecond})
+	rng := rand.New(rand.NewSource(3))
+	start := time.Unix(0, 0)
+	// Slow heartbeats, then fast ones: once the window holds only fast
+	// ones, a slow gap is suspicious.
+	last := beat(d, 0, start, 20, time.Second, 0, rng)
+	last = beat(d, 0, last.Add(10*time.Millisecond), 20, 10*time.Millisecond, 0, rng)
+	if !d.Suspect(0, last.Add(500*time.Millisecond)) {
+		t.Error("old intervals still in the window")
+	}
+
+	// Out of order heartbeats are ignored.
+	d.Heartbeat(0, last.Add(-time.Second))
+	if phi := d.Phi(0, last); phi > 0.1 {
+		t.Errorf("φ at the last heartbeat after a stale one: %v", phi)
+	}
+}
+
+func TestDetector_FirstHeartbeat(t *testing.T) {
+	d := New(Config{FirstHeartbeatEstimate: 100 * time.Millisecond})
+	start := time.Unix(0, 0)
+	d.Heartbeat(0, start)
+	if d.Suspect(0, start.Add(100*time.Millisecond)) {
+		t.Error("suspected within the first heartbeat estimate")
+	}
+	if !d.Suspect(0, start.Add(2*time.Second)) {
+		t.Error("not suspected long after the first heartbeat estimate")
+	}
+}
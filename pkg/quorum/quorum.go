@@ -0,0 +1,403 @@
+// Package quorum simulates Dynamo-style quorum replication: every key lives
+// on the first N nodes of its preference list, a write succeeds once W of
+// them acknowledged it and a read once R of them answered. With R+W > N
+// every read quorum overlaps every write quorum, so a read sees the last
+// acknowledged write; smaller quorums answer faster and survive more
+// failures, but may return stale values.
+//
+// Values carry a Lamport timestamp as version, assigned by the coordinator
+// of the write, and replicas keep the newest version they receive. A read
+// can repair the replicas it caught with an older version. With a sloppy
+// quorum, writes skip failed nodes of the preference list and go to the
+// next healthy ones, which hold them as hints and hand them off to the
+// intended owner once it recovers: writes stay available through failures,
+// at the cost of reads that may miss them meanwhile.
+//
+// The simulation runs in virtual time from one seeded source, so runs are
+// reproducible. Message latencies are a fixed floor plus an exponential
+// tail. Failures take effect for messages sent after them.
+//
+// References:
+//
+// Gifford, Weighted Voting for Replicated Data, SOSP 1979.
+//
+// DeCandia et al., Dynamo: Amazon's Highly Available Key-value Store, SOSP
+// 2007.
+package quorum
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/sanderblue/algorithms/pkg/logicalclock"
+	"github.com/sanderblue/algorithms/pkg/rendezvous"
+)
+
+// Config describes a replicated store.
+type Config struct {
+	Nodes      int
+	N, R, W    int
+	Sloppy     bool          // sloppy quorum with hinted handoff
+	ReadRepair bool          // reads update the stale replicas they caught
+	Latency    time.Duration // mean one-way latency; defaults to 1ms
+	Timeout    time.Duration // of a read or write; defaults to 20 Latencies
+	Seed       int64
+}
+
+func (c Config) validate() error {
+	if c.N < 1 || c.N > c.Nodes {
+		return fmt.Errorf("replication factor %d outside [1, %d]", c.N, c.Nodes)
+	}
+	if c.R < 1 || c.R > c.N || c.W < 1 || c.W > c.N {
+		return fmt.Errorf("quorums R=%d, W=%d outside [1, N=%d]", c.R, c.W, c.N)
+	}
+	return nil
+}
+
+// Versioned is a value with its version. The zero Version means absent.
+type Versioned struct {
+	Value   string
+	Version logicalclock.Timestamp
+}
+
+// delivery is a value reaching a replica at a point in virtual time.
+type delivery struct {
+	at time.Duration
+	v  Versioned
+}
+
+// ack is the acknowledgement of a write to the client.
+type ack struct {
+	at      time.Duration
+	version logicalclock.Timestamp
+}
+
+type hint struct {
+	owner int
+	key   string
+	v     Versioned
+}
+
+type node struct {
+	up      bool
+	latency time.Duration
+	clock   *logicalclock.Clock
+	store   map[string][]delivery
+	hints   []hint
+}
+
+// value returns the newest version of key the node received by time at.
+func (n *node) value(key string, at time.Duration) Versioned {
+	var best Versioned
+	for _, d := range n.store[key] {
+		if d.at <= at && best.Version.Less(d.v.Version) {
+			best = d.v
+		}
+	}
+	return best
+}
+
+// WriteResult is the outcome of a write.
+type WriteResult struct {
+	OK      bool
+	Version logicalclock.Timestamp
+	Latency time.Duration
+	Acks    int
+	Hinted  int // replicas that took the write for a failed node
+}
+
+// ReadResult is the outcome of a read.
+type ReadResult struct {
+	OK       bool
+	Value    Versioned
+	Latency  time.Duration
+	Replies  int
+	Stale    bool // older than a write acknowledged before the read began
+	Repaired int  // replicas updated by read repair
+}
+
+// Stats sums up the operations of a simulation.
+type Stats struct {
+	Reads, Writes             int
+	FailedReads, FailedWrites int // fewer than R or W replies within the timeout
+	StaleReads                int
+	ReadLatency, WriteLatency time.Duration // total over successful operations
+	Repairs                   int
+	Hinted                    int // writes taken by a replica for a failed node
+	Handoffs                  int // hints delivered to their recovered owner
+}
+
+// StaleRate returns the fraction of successful reads that were stale.
+func (s Stats) StaleRate() float64 {
+	if ok := s.Reads - s.FailedReads; ok > 0 {
+		return float64(s.StaleReads) / float64(ok)
+	}
+	return 0
+}
+
+// Availability returns the fraction of operations that succeeded.
+func (s Stats) Availability() float64 {
+	if total := s.Reads + s.Writes; total > 0 {
+		return 1 - float64(s.FailedReads+s.FailedWrites)/float64(total)
+	}
+	return 1
+}
+
+// MeanReadLatency returns the mean latency of the successful reads.
+func (s Stats) MeanReadLatency() time.Duration {
+	if ok := s.Reads - s.FailedReads; ok > 0 {
+		return s.ReadLatency / time.Duration(ok)
+	}
+	return 0
+}
+
+// MeanWriteLatency returns the mean latency of the successful writes.
+func (s Stats) MeanWriteLatency() time.Duration {
+	if ok := s.Writes - s.FailedWrites; ok > 0 {
+		return s.WriteLatency / time.Duration(ok)
+	}
+	return 0
+}
+
+// Simulation is a replicated store in virtual time.
+type Simulation struct {
+	Config Config
+
+	now   time.Duration
+	rng   *rand.Rand
+	nodes []*node
+	ring  *rendezvous.Rendezvous
+	acks  map[string][]ack
+	stats Stats
+}
+
+// New returns a store of cfg.Nodes healthy nodes at time 0.
+func New(cfg Config) (*Simulation, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Latency <= 0 {
+		cfg.Latency = time.Millisecond
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 20 * cfg.Latency
+	}
+	s := &Simulation{Config: cfg, rng: rand.New(rand.NewSource(cfg.Seed)), ring: rendezvous.New(nil), acks: make(map[string][]ack)}
+	for i := 0; i < cfg.Nodes; i++ {
+		s.nodes = append(s.nodes, &node{up: true, latency: cfg.Latency, clock: logicalclock.NewClock(i), store: make(map[string][]delivery)})
+		s.ring.Add(strconv.Itoa(i), 1)
+	}
+	return s, nil
+}
+
+// Now returns the virtual time.
+func (s *Simulation) Now() time.Duration { return s.now }
+
+// Advance moves the virtual time forward by d.
+func (s *Simulation) Advance(d time.Duration) { s.now += d }
+
+// Stats returns the statistics of every operation so far.
+func (s *Simulation) Stats() Stats { return s.stats }
+
+// Crash fails node: it drops every message from now on.
+func (s *Simulation) Crash(node int) { s.nodes[node].up = false }
+
+// Recover restarts node with the data it had, and has the nodes holding
+// hints for it hand them off.
+func (s *Simulation) Recover(node int) {
+	s.nodes[node].up = true
+	for from, n := range s.nodes {
+		if !n.up {
+			continue
+		}
+		kept := n.hints[:0]
+		for _, h := range n.hints {
+			if h.owner != node {
+				kept = append(kept, h)
+				continue
+			}
+			s.deliver(node, h.key, h.v, s.now+s.latency(from)+s.latency(node))
+			s.stats.Handoffs++
+		}
+		n.hints = kept
+	}
+}
+
+// SetLatency sets the mean latency of messages to and from node, to model
+// a slow node.
+func (s *Simulation) SetLatency(node int, mean time.Duration) { s.nodes[node].latency = mean }
+
+// PreferenceList returns every node in the order key prefers them: its
+// replicas are the first N.
+func (s *Simulation) PreferenceList(key string) []int {
+	names := s.ring.GetN(key, len(s.nodes))
+	out := make([]int, len(names))
+	for i, name := range names {
+		out[i], _ = strconv.Atoi(name)
+	}
+	return out
+}
+
+// Value returns the newest version of key node holds now.
+func (s *Simulation) Value(node int, key string) Versioned {
+	return s.nodes[node].value(key, s.now)
+}
+
+// latency samples the one-way latency of a message to or from node: half
+// the mean as floor, plus an exponential tail.
+func (s *Simulation) latency(node int) time.Duration {
+	mean := s.nodes[node].latency
+	return mean/2 + time.Duration(s.rng.ExpFloat64()*float64(mean/2))
+}
+
+func (s *Simulation) deliver(node int, key string, v Versioned, at time.Duration) {
+	n := s.nodes[node]
+	n.store[key] = append(n.store[key], delivery{at: at, v: v})
+}
+
+// replicas returns the nodes a request for key goes to, and for a sloppy
+// quorum the failed replica each stands in for, or -1.
+func (s *Simulation) replicas(key string) (targets, owners []int) {
+	pref := s.PreferenceList(key)
+	if !s.Config.Sloppy {
+		targets = pref[:s.Config.N]
+		owners = make([]int, len(targets))
+		for i := range owners {
+			owners[i] = -1
+		}
+		return targets, owners
+	}
+	var down []int
+	for _, id := range pref[:s.Config.N] {
+		if s.nodes[id].up {
+			targets = append(targets, id)
+			owners = append(owners, -1)
+		} else {
+			down = append(down, id)
+		}
+	}
+	for _, id := range pref[s.Config.N:] {
+		if len(down) == 0 {
+			break
+		}
+		if s.nodes[id].up {
+			targets = append(targets, id)
+			owners = append(owners, down[0])
+			down = down[1:]
+		}
+	}
+	return targets, owners
+}
+
+// coordinator returns the first healthy node of the preference list of key,
+// or false if every node failed.
+func (s *Simulation) coordinator(key string) (int, bool) {
+	for _, id := range s.PreferenceList(key) {
+		if s.nodes[id].up {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// Write writes value under key at the current time, coordinated by the
+// first healthy node of the preference list of key.
+func (s *Simulation) Write(key, value string) WriteResult {
+	s.stats.Writes++
+	coord, ok := s.coordinator(key)
+	if !ok {
+		s.stats.FailedWrites++
+		return WriteResult{Latency: s.Config.Timeout}
+	}
+	v := Versioned{Value: value, Version: s.nodes[coord].clock.Tick()}
+	res := WriteResult{Version: v.Version}
+	targets, owners := s.replicas(key)
+	var replies []time.Duration
+	for i, id := range targets {
+		n := s.nodes[id]
+		if !n.up {
+			continue
+		}
+		at := s.now + s.latency(id)
+		n.clock.Witness(v.Version)
+		s.deliver(id, key, v, at)
+		if owners[i] >= 0 {
+			n.hints = append(n.hints, hint{owner: owners[i], key: key, v: v})
+			res.Hinted++
+			s.stats.Hinted++
+		}
+		if back := at + s.latency(id) - s.now; back <= s.Config.Timeout {
+			replies = append(replies, back)
+		}
+	}
+	res.Acks = len(replies)
+	if len(replies) < s.Config.W {
+		res.Latency = s.Config.Timeout
+		s.stats.FailedWrites++
+		return res
+	}
+	sort.Slice(replies, func(i, j int) bool { return replies[i] < replies[j] })
+	res.OK, res.Latency = true, replies[s.Config.W-1]
+	s.stats.WriteLatency += res.Latency
+	s.acks[key] = append(s.acks[key], ack{at: s.now + res.Latency, version: v.Version})
+	return res
+}
+
+// Read reads key at the current time, straight from its replicas.
+func (s *Simulation) Read(key string) ReadResult {
+	s.stats.Reads++
+	type reply struct {
+		node int
+		back time.Duration // since the start of the read
+		v    Versioned
+	}
+	var replies []reply
+	targets, _ := s.replicas(key)
+	for _, id := range targets {
+		n := s.nodes[id]
+		if !n.up {
+			continue
+		}
+		at := s.now + s.latency(id)
+		if back := at + s.latency(id) - s.now; back <= s.Config.Timeout {
+			replies = append(replies, reply{node: id, back: back, v: n.value(key, at)})
+		}
+	}
+	res := ReadResult{Replies: len(replies)}
+	if len(replies) < s.Config.R {
+		res.Latency = s.Config.Timeout
+		s.stats.FailedReads++
+		return res
+	}
+	sort.Slice(replies, func(i, j int) bool { return replies[i].back < replies[j].back })
+	for _, r := range replies[:s.Config.R] {
+		if res.Value.Version.Less(r.v.Version) {
+			res.Value = r.v
+		}
+	}
+	res.OK, res.Latency = true, replies[s.Config.R-1].back
+	s.stats.ReadLatency += res.Latency
+	for _, a := range s.acks[key] {
+		if a.at <= s.now && res.Value.Version.Less(a.version) {
+			res.Stale = true
+		}
+	}
+	if res.Stale {
+		s.stats.StaleReads++
+	}
+
+	if s.Config.ReadRepair && res.Value.Version != (logicalclock.Timestamp{}) {
+		for _, r := range replies {
+			if r.v.Version.Less(res.Value.Version) {
+				s.nodes[r.node].clock.Witness(res.Value.Version)
+				s.deliver(r.node, key, res.Value, s.now+r.back+s.latency(r.node))
+				res.Repaired++
+			}
+		}
+		s.stats.Repairs += res.Repaired
+	}
+	return res
+}
@@ -0,0 +1,100 @@
+package quorum
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	for _, cfg := range []Config{
+		{Nodes: 3, N: 4, R: 1, W: 1},
+		{Nodes: 3, N: 3, R: 0, W: 1},
+		{Nodes: 3, N: 3, R: 1, W: 4},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("expected an error for %+v", cfg)
+		}
+	}
+}
+
+func TestSimulation_ReadYourWrite(t *testing.T) {
+	s, err := New(Config{Nodes: 5, N: 3, R: 2, W: 2, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := s.Read("k"); !r.OK || r.Value.Version != (Versioned{}).Version {
+		t.Fatalf("read of a missing key: %+v", r)
+	}
+	w := s.Write("k", "a")
+	if !w.OK || w.Acks != 3 {
+		t.Fatalf("write: %+v", w)
+	}
+	s.Advance(w.Latency)
+	r := s.Read("k")
+	if !r.OK || r.Value.Value != "a" || r.Stale {
+		t.Fatalf("read after the write: %+v", r)
+	}
+
+	// Newer writes win.
+	s.Advance(time.Millisecond)
+	w2 := s.Write("k", "b")
+	if !w.Version.Less(w2.Version) {
+		t.Fatalf("version %v of the second write isn't newer than %v", w2.Version, w.Version)
+	}
+	s.Advance(w2.Latency)
+	if r := s.Read("k"); r.Value.Value != "b" {
+		t.Errorf("read %q, want b", r.Value.Value)
+	}
+}
+
+func TestSimulation_ReadRepair(t *testing.T) {
+	s, _ := New(Config{Nodes: 3, N: 3, R: 3, W: 2, ReadRepair: true, Seed: 3})
+	s.Crash(2)
+	w := s.Write("k", "a")
+	if !w.OK || w.Acks != 2 {
+		t.Fatalf("write with one replica down: %+v", w)
+	}
+	s.Recover(2)
+	s.Advance(time.Second)
+	if got := s.Value(2, "k"); got.Value != "" {
+		t.Fatalf("the failed replica holds %+v before the read", got)
+	}
+
+	r := s.Read("k")
+	if !r.OK || r.Value.Value != "a" || r.Repaired != 1 {
+		t.Fatalf("read: %+v, want one repair", r)
+	}
+	s.Advance(time.Second)
+	if got := s.Value(2, "k"); got.Value != "a" {
+		t.Errorf("the failed replica holds %+v after read repair", got)
+	}
+	if s.Read("k").Repaired != 0 || s.Stats().Repairs != 1 {
+		t.Error("repaired a replica twice")
+	}
+}
+
+func TestSimulation_Failures(t *testing.T) {
+	s, _ := New(Config{Nodes: 3, N: 3, R: 2, W: 2, Seed: 4})
+	s.Crash(0)
+	s.Crash(1)
+	if w := s.Write("k", "a"); w.OK || w.Latency != s.Config.Timeout {
+		t.Errorf("write with two of three replicas down: %+v", w)
+	}
+	if r := s.Read("k"); r.OK {
+		t.Errorf("read with two of three replicas down: %+v", r)
+	}
+
+	// A slow replica is left out of the quorum.
+	s.Recover(0)
+	s.Recover(1)
+	s.SetLatency(2, time.Second)
+	w := s.Write("k", "a")
+	if !w.OK || w.Acks != 2 || w.Latency > s.Config.Timeout {
+		t.Errorf("write with a slow replica: %+v", w)
+	}
+	st := s.Stats()
+	if st.FailedWrites != 1 || st.FailedReads != 1 || math.Abs(st.Availability()-1.0/3) > 1e-9 {
+		t.Errorf("unexpected stats %+v", st)
+	}
+}
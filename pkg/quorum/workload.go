@@ -0,0 +1,66 @@
+package quorum
+
+import (
+	"fmt"
+	"time"
+)
+
+// Failure crashes or recovers a node before operation Op of a workload.
+type Failure struct {
+	Op      int
+	Node    int
+	Recover bool
+}
+
+// Workload is a stream of reads and writes over uniformly chosen keys.
+type Workload struct {
+	Ops          int
+	ReadFraction float64
+	Keys         int           // defaults to 10
+	Interval     time.Duration // between the starts of two operations; defaults to the latency
+	Failures     []Failure
+}
+
+// Run runs w from the current time and returns the statistics of its
+// operations alone. Operations overlap when Interval is shorter than their
+// latency.
+func (s *Simulation) Run(w Workload) Stats {
+	if w.Keys <= 0 {
+		w.Keys = 10
+	}
+	if w.Interval <= 0 {
+		w.Interval = s.Config.Latency
+	}
+	before := s.stats
+	for op := 0; op < w.Ops; op++ {
+		for _, f := range w.Failures {
+			switch {
+			case f.Op != op:
+			case f.Recover:
+				s.Recover(f.Node)
+			default:
+				s.Crash(f.Node)
+			}
+		}
+		key := fmt.Sprint("key-", s.rng.Intn(w.Keys))
+		if s.rng.Float64() < w.ReadFraction {
+			s.Read(key)
+		} else {
+			s.Write(key, fmt.Sprint("value-", op))
+		}
+		s.Advance(w.Interval)
+	}
+	after := s.stats
+	return Stats{
+		Reads:        after.Reads - before.Reads,
+		Writes:       after.Writes - before.Writes,
+		FailedReads:  after.FailedReads - before.FailedReads,
+		FailedWrites: after.FailedWrites - before.FailedWrites,
+		StaleReads:   after.StaleReads - before.StaleReads,
+		ReadLatency:  after.ReadLatency - before.ReadLatency,
+		WriteLatency: after.WriteLatency - before.WriteLatency,
+		Repairs:      after.Repairs - before.Repairs,
+		Hinted:       after.Hinted - before.Hinted,
+		Handoffs:     after.Handoffs - before.Handoffs,
+	}
+}
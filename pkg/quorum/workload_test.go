@@ -0,0 +1,80 @@
+package quorum
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRun_TradeOffs(t *testing.T) {
+	w := Workload{Ops: 5000, ReadFraction: 0.7, Keys: 5, Interval: 200 * time.Microsecond}
+	tests := []struct {
+		name  string
+		r, w  int
+		stale bool
+	}{
+		{"R+W>N", 2, 2, false},
+		{"read one write all", 1, 3, false},
+		{"read all write one", 3, 1, false},
+		{"R+W<=N", 1, 1, true},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := New(Config{Nodes: 5, N: 3, R: tc.r, W: tc.w, Seed: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			stats := s.Run(w)
+			t.Logf("%+v, read %v, write %v", stats, stats.MeanReadLatency(), stats.MeanWriteLatency())
+			if stats.Availability() != 1 {
+				t.Errorf("availability %v without failures", stats.Availability())
+			}
+			if got := stats.StaleReads > 0; got != tc.stale {
+				t.Errorf("%d stale reads of %d", stats.StaleReads, stats.Reads)
+			}
+		})
+	}
+
+	// Smaller quorums answer faster.
+	fast, _ := New(Config{Nodes: 5, N: 3, R: 1, W: 1, Seed: 1})
+	slow, _ := New(Config{Nodes: 5, N: 3, R: 3, W: 3, Seed: 1})
+	f, s := fast.Run(w), slow.Run(w)
+	if f.MeanReadLatency() >= s.MeanReadLatency() || f.MeanWriteLatency() >= s.MeanWriteLatency() {
+		t.Errorf("R=W=1 took %v/%v, R=W=3 %v/%v", f.MeanReadLatency(), f.MeanWriteLatency(), s.MeanReadLatency(), s.MeanWriteLatency())
+	}
+}
+
+func TestRun_SloppyQuorum(t *testing.T) {
+	failures := []Failure{{Op: 100, Node: 0}, {Op: 100, Node: 1}, {Op: 900, Node: 0, Recover: true}, {Op: 900, Node: 1, Recover: true}}
+	w := Workload{Ops: 1000, ReadFraction: 0.5, Keys: 20, Failures: failures}
+
+	strict, _ := New(Config{Nodes: 6, N: 3, R: 2, W: 3, Seed: 2})
+	st := strict.Run(w)
+	if st.FailedWrites == 0 {
+		t.Error("a strict quorum of W=N wrote through two failures")
+	}
+
+	sloppy, _ := New(Config{Nodes: 6, N: 3, R: 2, W: 3, Sloppy: true, Seed: 2})
+	sl := sloppy.Run(w)
+	t.Logf("strict %+v\nsloppy %+v", st, sl)
+	if sl.FailedWrites != 0 || sl.Hinted == 0 {
+		t.Errorf("sloppy quorum: %d failed writes, %d hinted", sl.FailedWrites, sl.Hinted)
+	}
+	if sl.Handoffs != sl.Hinted {
+		t.Errorf("%d hints handed off of %d", sl.Handoffs, sl.Hinted)
+	}
+
+	// Once the hints are handed off, every replica holds the newest value.
+	sloppy.Advance(time.Second)
+	for k := 0; k < w.Keys; k++ {
+		key := fmt.Sprint("key-", k)
+		pref := sloppy.PreferenceList(key)
+		newest := sloppy.Value(pref[0], key)
+		for _, id := range pref[1:3] {
+			if got := sloppy.Value(id, key); got != newest {
+				t.Errorf("%s: node %d holds %+v, node %d %+v", key, id, got, pref[0], newest)
+			}
+		}
+	}
+}
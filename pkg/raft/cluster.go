@@ -0,0 +1,113 @@
+package raft
+
+import (
+	"fmt"
+	"time"
+)
+
+// Cluster runs n nodes in one process over a ChanTransport.
+type Cluster struct {
+	Transport *ChanTransport
+	Nodes     []*Node
+
+	newSM   func(id int) StateMachine
+	stopped map[int]bool
+}
+
+// NewCluster starts n nodes; newSM creates the state machine of a node, at
+// start and again whenever it recovers from a crash.
+func NewCluster(n int, newSM func(id int) StateMachine, cfg Config) *Cluster {
+	c := &Cluster{Transport: NewChanTransport(n), newSM: newSM, stopped: make(map[int]bool)}
+	for id := 0; id < n; id++ {
+		node := NewNode(id, n, c.Transport, newSM(id), cfg)
+		c.Nodes = append(c.Nodes, node)
+		node.Start()
+	}
+	return c
+}
+
+// Leader returns the ID of a running node that believes it leads in the
+// highest term any running node has seen, or None.
+func (c *Cluster) Leader() int {
+	leader, term := None, -1
+	for id, node := range c.Nodes {
+		if c.stopped[id] {
+			continue
+		}
+		s := node.Status()
+		if s.State == Leader && s.Term > term {
+			leader, term = id, s.Term
+		}
+	}
+	return leader
+}
+
+// WaitLeader waits up to timeout for a leader that isn't isolated.
+func (c *Cluster) WaitLeader(timeout time.Duration) (int, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		if id := c.Leader(); id != None && !c.Transport.isIsolated(id) {
+			return id, nil
+		}
+		if time.Now().After(deadline) {
+			return None, fmt.Errorf("no leader elected within %v", timeout)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Propose submits command to the leader, retrying as leadership moves, and
+// waits up to timeout for the leader to commit it. It returns the index of
+// the committed entry.
+func (c *Cluster) Propose(command []byte, timeout time.Duration) (int, error) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		leader, err := c.WaitLeader(time.Until(deadline))
+		if err != nil {
+			return 0, err
+		}
+		node := c.Nodes[leader]
+		index, err := node.Propose(command)
+		if err != nil {
+			time.Sleep(time.Millisecond)
+			continue
+		}
+		term := node.Status().Term
+		for time.Now().Before(deadline) {
+			s := node.Status()
+			if s.Term != term || s.State != Leader {
+				break // the entry may survive, but resubmit to be sure it's committed
+			}
+			if s.Commit >= index {
+				return index, nil
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	return 0, fmt.Errorf("command not committed within %v", timeout)
+}
+
+// Crash stops node id and cuts it off the network.
+func (c *Cluster) Crash(id int) {
+	c.Transport.Isolate(id)
+	c.Nodes[id].Stop()
+	c.stopped[id] = true
+}
+
+// Recover restarts a crashed node with a fresh state machine.
+func (c *Cluster) Recover(id int) {
+	c.Nodes[id].Restart(c.newSM(id))
+	delete(c.stopped, id)
+	c.Transport.Heal(id)
+}
+
+// Close stops every running node and the transport.
+func (c *Cluster) Close() {
+	for id, node := range c.Nodes {
+		if !c.stopped[id] {
+			node.Stop()
+			c.stopped[id] = true
+		}
+	}
+	c.Transport.Close()
+}
@@ -0,0 +1,148 @@
+package raft
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+const timeout = 5 * time.Second
+
+// recorder is a state machine that records the commands it applies.
+type recorder struct {
+	mu   sync.Mutex
+	cmds []string
+}
+
+func (r *recorder) Apply(e Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cmds = append(r.cmds, string(e.Command))
+}
+
+func (r *recorder) applied() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.cmds...)
+}
+
+// recorded starts a cluster of n nodes whose state machines are recorders,
+// indexed by node ID and replaced when a node recovers.
+func recorded(t *testing.T, n int) (*Cluster, func(id int) *recorder) {
+	var mu sync.Mutex
+	machines := make([]*recorder, n)
+	c := NewCluster(n, func(id int) StateMachine {
+		mu.Lock()
+		defer mu.Unlock()
+		machines[id] = &recorder{}
+		return machines[id]
+	}, Config{Tick: time.Millisecond})
+	t.Cleanup(c.Close)
+	return c, func(id int) *recorder {
+		mu.Lock()
+		defer mu.Unlock()
+		return machines[id]
+	}
+}
+
+// waitApplied waits for every node to have applied exactly want.
+func waitApplied(t *testing.T, n int, machine func(id int) *recorder, want []string) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for id := 0; id < n; id++ {
+		for !reflect.DeepEqual(machine(id).applied(), want) {
+			if time.Now().After(deadline) {
+				t.Fatalf("node %d applied %q, want %q", id, machine(id).applied(), want)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+func propose(t *testing.T, c *Cluster, cmds ...string) {
+	t.Helper()
+	for _, cmd := range cmds {
+		if _, err := c.Propose([]byte(cmd), timeout); err != nil {
+			t.Fatalf("propose %q: %v", cmd, err)
+		}
+	}
+}
+
+func TestCluster_Election(t *testing.T) {
+	for _, n := range []int{1, 3, 5} {
+		n := n
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			c, _ := recorded(t, n)
+			leader, err := c.WaitLeader(timeout)
+			if err != nil {
+				t.Fatal(err)
+			}
+			term := c.Nodes[leader].Status().Term
+			for id, node := range c.Nodes {
+				if s := node.Status(); id != leader && s.State == Leader && s.Term == term {
+					t.Errorf("nodes %d and %d both lead term %d", leader, id, term)
+				}
+			}
+		})
+	}
+}
+
+func TestCluster_Replication(t *testing.T) {
+	c, machine := recorded(t, 3)
+	var want []string
+	for i := 0; i < 20; i++ {
+		want = append(want, fmt.Sprint("set x ", i))
+	}
+	propose(t, c, want...)
+	waitApplied(t, 3, machine, want)
+
+	logs := c.Nodes[0].Log()
+	for id := 1; id < 3; id++ {
+		if got := c.Nodes[id].Log(); !reflect.DeepEqual(got, logs) {
+			t.Errorf("node %d log %v differs from node 0 log %v", id, got, logs)
+		}
+	}
+}
+
+func TestCluster_LeaderCrash(t *testing.T) {
+	c, machine := recorded(t, 5)
+	propose(t, c, "a", "b")
+	old, _ := c.WaitLeader(timeout)
+	oldTerm := c.Nodes[old].Status().Term
+	c.Crash(old)
+
+	propose(t, c, "c", "d")
+	leader, _ := c.WaitLeader(timeout)
+	if leader == old || c.Nodes[leader].Status().Term <= oldTerm {
+		t.Errorf("leader %d of term %d after crashing leader %d of term %d", leader, c.Nodes[leader].Status().Term, old, oldTerm)
+	}
+
+	// The recovered node rebuilds its state machine from the log.
+	c.Recover(old)
+	propose(t, c, "e")
+	waitApplied(t, 5, machine, []string{"a", "b", "c", "d", "e"})
+}
+
+func TestCluster_PartitionedLeader(t *testing.T) {
+	c, machine := recorded(t, 3)
+	propose(t, c, "a")
+	old, _ := c.WaitLeader(timeout)
+
+	// Cut the leader off: it still accepts a command it can never commit,
+	// while the majority elects a new leader and moves on.
+	c.Transport.Isolate(old)
+	if _, err := c.Nodes[old].Propose([]byte("lost")); err != nil {
+		t.Fatalf("propose to the isolated leader: %v", err)
+	}
+	propose(t, c, "b")
+	if s := c.Nodes[old].Status(); s.Commit >= s.LastIndex {
+		t.Errorf("isolated leader committed its entry: %+v", s)
+	}
+
+	// Once healed, the old leader steps down and its entry is overwritten.
+	c.Transport.Heal(old)
+	propose(t, c, "c")
+	waitApplied(t, 3, machine, []string{"a", "b", "c"})
+}
@@ -0,0 +1,548 @@
+// Package raft implements the Raft consensus algorithm: leader election, log
+// replication and commitment of the entries of a replicated log, applied in
+// the same order to a pluggable StateMachine on every node.
+//
+// Every Node runs on its own goroutine and talks to its peers through a
+// Transport, which may lose, duplicate and reorder messages. Time is counted
+// in ticks: followers that hear nothing from a leader for a randomized
+// election timeout start an election, and leaders send heartbeats every few
+// ticks. Cluster wires nodes together in one process for tests and
+// experiments, including crashes and network partitions.
+//
+// References:
+//
+// https://raft.github.io/raft.pdf
+package raft
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ErrNotLeader is returned when proposing to a node that isn't the leader.
+var ErrNotLeader = errors.New("not the leader")
+
+// ErrStopped is returned when proposing to a stopped node.
+var ErrStopped = errors.New("node stopped")
+
+// None is the ID of no node: no vote cast, or no leader known.
+const None = -1
+
+// State is the role of a node.
+type State int
+
+const (
+	Follower State = iota
+	Candidate
+	Leader
+)
+
+func (s State) String() string {
+	switch s {
+	case Follower:
+		return "follower"
+	case Candidate:
+		return "candidate"
+	case Leader:
+		return "leader"
+	default:
+		return fmt.Sprintf("state(%d)", int(s))
+	}
+}
+
+// Entry is one entry of the replicated log. Entries with a nil Command are
+// the no-ops leaders append when elected; they are never applied.
+type Entry struct {
+	Term    int
+	Index   int
+	Command []byte
+}
+
+// StateMachine consumes the committed entries of the log, in log order.
+type StateMachine interface {
+	Apply(e Entry)
+}
+
+// MessageType is the kind of a Message.
+type MessageType int
+
+const (
+	MsgVote       MessageType = iota // RequestVote
+	MsgVoteResp                      // response to RequestVote
+	MsgAppend                        // AppendEntries, also the heartbeat
+	MsgAppendResp                    // response to AppendEntries
+)
+
+func (t MessageType) String() string {
+	switch t {
+	case MsgVote:
+		return "vote"
+	case MsgVoteResp:
+		return "vote-resp"
+	case MsgAppend:
+		return "append"
+	case MsgAppendResp:
+		return "append-resp"
+	default:
+		return fmt.Sprintf("message(%d)", int(t))
+	}
+}
+
+// Message is an RPC between two nodes or its response.
+type Message struct {
+	Type MessageType
+	From int
+	To   int
+	Term int
+
+	LastLogIndex int // MsgVote: the candidate's last entry
+	LastLogTerm  int
+
+	PrevLogIndex int // MsgAppend: the entry preceding Entries
+	PrevLogTerm  int
+	Entries      []Entry
+	LeaderCommit int
+
+	Success bool // MsgVoteResp: vote granted; MsgAppendResp: entries accepted
+	// MatchIndex is, in a successful MsgAppendResp, the last index the
+	// follower has in common with the leader, and in a failed one the
+	// index the leader should retry from.
+	MatchIndex int
+}
+
+// Config tunes the timing of a node.
+type Config struct {
+	Tick           time.Duration // length of a tick; defaults to 5ms
+	ElectionTicks  int           // minimum election timeout, randomized up to twice that; defaults to 10
+	HeartbeatTicks int           // interval between heartbeats; defaults to 2
+	Seed           int64         // of the election timeouts; defaults to the node ID
+}
+
+func (c Config) withDefaults(id int) Config {
+	if c.Tick <= 0 {
+		c.Tick = 5 * time.Millisecond
+	}
+	if c.ElectionTicks <= 0 {
+		c.ElectionTicks = 10
+	}
+	if c.HeartbeatTicks <= 0 {
+		c.HeartbeatTicks = 2
+	}
+	if c.Seed == 0 {
+		c.Seed = int64(id) + 1
+	}
+	return c
+}
+
+// Status is a snapshot of the state of a node.
+type Status struct {
+	ID        int
+	State     State
+	Term      int
+	Leader    int
+	Commit    int // index of the last committed entry
+	Applied   int // index of the last entry given to the state machine
+	LastIndex int // index of the last entry of the log
+}
+
+// Node is one member of a Raft cluster of nodes 0..n-1.
+type Node struct {
+	ID int
+
+	n         int
+	cfg       Config
+	transport Transport
+	sm        StateMachine
+	rng       *rand.Rand
+
+	// Persistent state, kept across Stop and Restart.
+	term     int
+	votedFor int
+	log      []Entry // log[0] is a sentinel of term 0
+
+	// Volatile state.
+	state     State
+	leader    int
+	commit    int
+	applied   int
+	next      []int
+	match     []int
+	votes     map[int]bool
+	elapsed   int // ticks since the last heartbeat or election
+	timeout   int // randomized election timeout in ticks
+	heartbeat int // ticks since the last heartbeat sent
+
+	msgs   chan Message
+	calls  chan func()
+	stop   chan struct{}
+	done   chan struct{}
+	mu     sync.Mutex
+	status Status
+}
+
+// NewNode creates node id of a cluster of n nodes. It does nothing until
+// Start.
+func NewNode(id, n int, transport Transport, sm StateMachine, cfg Config) *Node {
+	cfg = cfg.withDefaults(id)
+	node := &Node{
+		ID:        id,
+		n:         n,
+		cfg:       cfg,
+		transport: transport,
+		sm:        sm,
+		rng:       rand.New(rand.NewSource(cfg.Seed)),
+		votedFor:  None,
+		log:       []Entry{{}},
+	}
+	node.reset()
+	return node
+}
+
+// reset clears the volatile state, as after a crash.
+func (n *Node) reset() {
+	n.state = Follower
+	n.leader = None
+	n.commit, n.applied = 0, 0
+	n.elapsed, n.heartbeat = 0, 0
+	n.resetTimeout()
+	n.publish()
+}
+
+// Start runs the node on its own goroutines.
+func (n *Node) Start() {
+	n.msgs = make(chan Message)
+	n.calls = make(chan func())
+	n.stop = make(chan struct{})
+	n.done = make(chan struct{})
+	go n.pump(n.msgs, n.stop)
+	go n.run()
+}
+
+// Stop halts the node, as a crash would: its persistent state survives for
+// Restart, everything else is lost.
+func (n *Node) Stop() {
+	close(n.stop)
+	<-n.done
+}
+
+// Restart starts a stopped node again with a fresh state machine, which
+// the node rebuilds by applying the log once entries are known committed.
+func (n *Node) Restart(sm StateMachine) {
+	n.sm = sm
+	n.reset()
+	n.Start()
+}
+
+// Status returns the current state of the node.
+func (n *Node) Status() Status {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.status
+}
+
+// Log returns a copy of the entries of the log.
+func (n *Node) Log() []Entry {
+	var out []Entry
+	if !n.call(func() { out = append(out, n.log[1:]...) }) {
+		out = append(out, n.log[1:]...) // stopped: nobody else touches it
+	}
+	return out
+}
+
+// Propose appends command to the log if the node is the leader and returns
+// the index it will be committed at, if it gets committed at all: a leader
+// that loses its leadership before replicating the entry to a majority may
+// see it overwritten.
+func (n *Node) Propose(command []byte) (int, error) {
+	if command == nil {
+		command = []byte{}
+	}
+	var (
+		index int
+		err   error
+	)
+	ok := n.call(func() {
+		if n.state != Leader {
+			err = fmt.Errorf("node %d (leader %d): %w", n.ID, n.leader, ErrNotLeader)
+			return
+		}
+		index = n.appendEntry(command)
+		n.broadcastAppend()
+	})
+	if !ok {
+		return 0, ErrStopped
+	}
+	return index, err
+}
+
+// call runs fn on the node's goroutine and reports whether it ran.
+func (n *Node) call(fn func()) bool {
+	finished := make(chan struct{})
+	select {
+	case n.calls <- func() { fn(); close(finished) }:
+		<-finished
+		return true
+	case <-n.done:
+		return false
+	}
+}
+
+// pump forwards the messages of the transport to the node's goroutine.
+func (n *Node) pump(msgs chan<- Message, stop <-chan struct{}) {
+	for {
+		m, err := n.transport.Recv(n.ID)
+		if err != nil {
+			return
+		}
+		select {
+		case msgs <- m:
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (n *Node) run() {
+	defer close(n.done)
+	ticker := time.NewTicker(n.cfg.Tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-n.stop:
+			return
+		case <-ticker.C:
+			n.tick()
+		case m := <-n.msgs:
+			n.step(m)
+		case fn := <-n.calls:
+			fn()
+		}
+		n.publish()
+	}
+}
+
+func (n *Node) publish() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.status = Status{ID: n.ID, State: n.state, Term: n.term, Leader: n.leader,
+		Commit: n.commit, Applied: n.applied, LastIndex: n.lastIndex()}
+}
+
+func (n *Node) lastIndex() int { return len(n.log) - 1 }
+
+func (n *Node) lastTerm() int { return n.log[len(n.log)-1].Term }
+
+func (n *Node) resetTimeout() {
+	n.timeout = n.cfg.ElectionTicks + n.rng.Intn(n.cfg.ElectionTicks)
+}
+
+func (n *Node) send(m Message) {
+	m.From, m.Term = n.ID, n.term
+	n.transport.Send(m.To, m)
+}
+
+func (n *Node) tick() {
+	if n.state == Leader {
+		n.heartbeat++
+		if n.heartbeat >= n.cfg.HeartbeatTicks {
+			n.broadcastAppend()
+		}
+		return
+	}
+	n.elapsed++
+	if n.elapsed >= n.timeout {
+		n.campaign()
+	}
+}
+
+// campaign starts an election for the next term.
+func (n *Node) campaign() {
+	n.term++
+	n.state = Candidate
+	n.leader = None
+	n.votedFor = n.ID
+	n.votes = map[int]bool{n.ID: true}
+	n.elapsed = 0
+	n.resetTimeout()
+	if n.quorum(len(n.votes)) {
+		n.becomeLeader()
+		return
+	}
+	for peer := 0; peer < n.n; peer++ {
+		if peer != n.ID {
+			n.send(Message{Type: MsgVote, To: peer, LastLogIndex: n.lastIndex(), LastLogTerm: n.lastTerm()})
+		}
+	}
+}
+
+func (n *Node) quorum(count int) bool { return 2*count > n.n }
+
+func (n *Node) becomeFollower(term, leader int) {
+	if term > n.term {
+		n.term = term
+		n.votedFor = None
+	}
+	n.state = Follower
+	n.leader = leader
+}
+
+func (n *Node) becomeLeader() {
+	n.state = Leader
+	n.leader = n.ID
+	n.next = make([]int, n.n)
+	n.match = make([]int, n.n)
+	for i := range n.next {
+		n.next[i] = n.lastIndex() + 1
+	}
+	// A no-op of the new term lets the leader commit the entries of
+	// earlier terms, which it may not count replicas of directly.
+	n.appendEntry(nil)
+	n.broadcastAppend()
+}
+
+// appendEntry appends command to the leader's log and returns its index.
+func (n *Node) appendEntry(command []byte) int {
+	index := n.lastIndex() + 1
+	n.log = append(n.log, Entry{Term: n.term, Index: index, Command: command})
+	n.match[n.ID] = index
+	n.next[n.ID] = index + 1
+	n.maybeCommit()
+	return index
+}
+
+func (n *Node) broadcastAppend() {
+	n.heartbeat = 0
+	for peer := 0; peer < n.n; peer++ {
+		if peer != n.ID {
+			n.sendAppend(peer)
+		}
+	}
+}
+
+// sendAppend sends peer the entries from its next index on.
+func (n *Node) sendAppend(peer int) {
+	prev := n.next[peer] - 1
+	entries := append([]Entry(nil), n.log[prev+1:]...)
+	n.send(Message{Type: MsgAppend, To: peer, PrevLogIndex: prev, PrevLogTerm: n.log[prev].Term,
+		Entries: entries, LeaderCommit: n.commit})
+}
+
+func (n *Node) step(m Message) {
+	switch {
+	case m.Term > n.term:
+		leader := None
+		if m.Type == MsgAppend {
+			leader = m.From
+		}
+		n.becomeFollower(m.Term, leader)
+	case m.Term < n.term:
+		// A stale node: tell it about the current term, ignore responses.
+		switch m.Type {
+		case MsgVote:
+			n.send(Message{Type: MsgVoteResp, To: m.From})
+		case MsgAppend:
+			n.send(Message{Type: MsgAppendResp, To: m.From})
+		}
+		return
+	}
+
+	switch m.Type {
+	case MsgVote:
+		upToDate := m.LastLogTerm > n.lastTerm() || m.LastLogTerm == n.lastTerm() && m.LastLogIndex >= n.lastIndex()
+		grant := (n.votedFor == None || n.votedFor == m.From) && upToDate
+		if grant {
+			n.votedFor = m.From
+			n.elapsed = 0
+		}
+		n.send(Message{Type: MsgVoteResp, To: m.From, Success: grant})
+	case MsgVoteResp:
+		if n.state != Candidate || !m.Success {
+			return
+		}
+		n.votes[m.From] = true
+		if n.quorum(len(n.votes)) {
+			n.becomeLeader()
+		}
+	case MsgAppend:
+		n.becomeFollower(m.Term, m.From)
+		n.elapsed = 0
+		n.handleAppend(m)
+	case MsgAppendResp:
+		if n.state != Leader {
+			return
+		}
+		if m.Success {
+			if m.MatchIndex > n.match[m.From] {
+				n.match[m.From] = m.MatchIndex
+				n.maybeCommit()
+			}
+			n.next[m.From] = max(n.next[m.From], m.MatchIndex+1)
+			return
+		}
+		n.next[m.From] = max(1, min(n.next[m.From]-1, m.MatchIndex))
+		n.sendAppend(m.From)
+	}
+}
+
+func (n *Node) handleAppend(m Message) {
+	if m.PrevLogIndex > n.lastIndex() {
+		n.send(Message{Type: MsgAppendResp, To: m.From, MatchIndex: n.lastIndex() + 1})
+		return
+	}
+	if n.log[m.PrevLogIndex].Term != m.PrevLogTerm {
+		// Skip back over the whole conflicting term at once.
+		conflict := m.PrevLogIndex
+		for conflict > n.commit+1 && n.log[conflict-1].Term == n.log[m.PrevLogIndex].Term {
+			conflict--
+		}
+		n.send(Message{Type: MsgAppendResp, To: m.From, MatchIndex: conflict})
+		return
+	}
+	for i, e := range m.Entries {
+		if e.Index <= n.lastIndex() {
+			if n.log[e.Index].Term == e.Term {
+				continue
+			}
+			// A conflicting suffix was never committed: drop it.
+			n.log = n.log[:e.Index]
+		}
+		n.log = append(n.log, m.Entries[i:]...)
+		break
+	}
+	match := m.PrevLogIndex + len(m.Entries)
+	if m.LeaderCommit > n.commit {
+		n.commit = min(m.LeaderCommit, match)
+		n.applyCommitted()
+	}
+	n.send(Message{Type: MsgAppendResp, To: m.From, Success: true, MatchIndex: match})
+}
+
+// maybeCommit advances the leader's commit index to the highest entry of
+// its term that a majority has replicated.
+func (n *Node) maybeCommit() {
+	for index := n.lastIndex(); index > n.commit && n.log[index].Term == n.term; index-- {
+		count := 0
+		for _, m := range n.match {
+			if m >= index {
+				count++
+			}
+		}
+		if n.quorum(count) {
+			n.commit = index
+			n.applyCommitted()
+			return
+		}
+	}
+}
+
+func (n *Node) applyCommitted() {
+	for n.applied < n.commit {
+		n.applied++
+		if e := n.log[n.applied]; e.Command != nil && n.sm != nil {
+			n.sm.Apply(e)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package raft
+
+import (
+	"reflect"
+	"testing"
+)
+
+// outbox records the messages a node sends instead of delivering them.
+type outbox struct{ sent []Message }
+
+func (o *outbox) Send(to int, msg Message) error {
+	o.sent = append(o.sent, msg)
+	return nil
+}
+
+func (o *outbox) Recv(int) (Message, error) { return Message{}, ErrTransportClosed }
+
+func (o *outbox) last() Message { return o.sent[len(o.sent)-1] }
+
+// stepped returns a node of a 3-node cluster whose log holds entries of the
+// given terms, driven by direct calls to step.
+func stepped(terms ...int) (*Node, *outbox) {
+	out := &outbox{}
+	n := NewNode(0, 3, out, nil, Config{})
+	for i, term := range terms {
+		n.log = append(n.log, Entry{Term: term, Index: i + 1, Command: []byte{byte(i)}})
+	}
+	if len(terms) > 0 {
+		n.term = terms[len(terms)-1]
+	}
+	return n, out
+}
+
+func TestNode_Vote(t *testing.T) {
+	tests := []struct {
+		name     string
+		votedFor int
+		msg      Message
+		granted  bool
+	}{
+		{"longer log", None, Message{Type: MsgVote, From: 1, Term: 3, LastLogIndex: 3, LastLogTerm: 2}, true},
+		{"same log", None, Message{Type: MsgVote, From: 1, Term: 3, LastLogIndex: 2, LastLogTerm: 2}, true},
+		{"shorter log", None, Message{Type: MsgVote, From: 1, Term: 3, LastLogIndex: 1, LastLogTerm: 2}, false},
+		{"older last term", None, Message{Type: MsgVote, From: 1, Term: 3, LastLogIndex: 9, LastLogTerm: 1}, false},
+		{"already voted", 2, Message{Type: MsgVote, From: 1, Term: 2, LastLogIndex: 2, LastLogTerm: 2}, false},
+		{"same candidate again", 1, Message{Type: MsgVote, From: 1, Term: 2, LastLogIndex: 2, LastLogTerm: 2}, true},
+		{"stale term", None, Message{Type: MsgVote, From: 1, Term: 1, LastLogIndex: 5, LastLogTerm: 5}, false},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			n, out := stepped(1, 2)
+			n.votedFor = tc.votedFor
+			n.step(tc.msg)
+			resp := out.last()
+			if resp.Type != MsgVoteResp || resp.Success != tc.granted {
+				t.Fatalf("got %+v, want granted=%v", resp, tc.granted)
+			}
+			if resp.Term != max(2, tc.msg.Term) {
+				t.Errorf("response term %d, want %d", resp.Term, max(2, tc.msg.Term))
+			}
+		})
+	}
+}
+
+func TestNode_AppendTruncatesConflicts(t *testing.T) {
+	// The follower has entries of a deposed leader of term 2 past index 1.
+	n, out := stepped(1, 2, 2)
+	n.step(Message{Type: MsgAppend, From: 1, Term: 3, PrevLogIndex: 1, PrevLogTerm: 1,
+		Entries: []Entry{{Term: 3, Index: 2, Command: []byte("x")}}, LeaderCommit: 2})
+	var terms []int
+	for _, e := range n.log[1:] {
+		terms = append(terms, e.Term)
+	}
+	if !reflect.DeepEqual(terms, []int{1, 3}) {
+		t.Errorf("log terms %v, want [1 3]", terms)
+	}
+	if resp := out.last(); !resp.Success || resp.MatchIndex != 2 {
+		t.Errorf("got %+v, want success with match 2", resp)
+	}
+	if n.commit != 2 || n.leader != 1 || n.term != 3 {
+		t.Errorf("commit %d leader %d term %d, want 2, 1, 3", n.commit, n.leader, n.term)
+	}
+}
+
+func TestNode_AppendRejectsGaps(t *testing.T) {
+	tests := []struct {
+		name  string
+		terms []int
+		prev  Message
+		retry int
+	}{
+		{"missing entries", []int{1}, Message{PrevLogIndex: 4, PrevLogTerm: 3}, 2},
+		// The whole conflicting term 2 is skipped in one round trip.
+		{"conflicting term", []int{1, 2, 2, 2}, Message{PrevLogIndex: 4, PrevLogTerm: 3}, 2},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			n, out := stepped(tc.terms...)
+			m := tc.prev
+			m.Type, m.From, m.Term = MsgAppend, 1, 3
+			n.step(m)
+			if resp := out.last(); resp.Success || resp.MatchIndex != tc.retry {
+				t.Errorf("got %+v, want a rejection retrying from %d", resp, tc.retry)
+			}
+		})
+	}
+}
+
+func TestNode_CommitsOnlyCurrentTerm(t *testing.T) {
+	n, out := stepped(1)
+	n.campaign()
+	n.step(Message{Type: MsgVoteResp, From: 1, Term: n.term, Success: true})
+	if n.state != Leader {
+		t.Fatalf("state %v after a majority of votes", n.state)
+	}
+	// The no-op of the new term sits at index 2; a majority holding only
+	// index 1, of an older term, commits nothing.
+	n.step(Message{Type: MsgAppendResp, From: 1, Term: n.term, Success: true, MatchIndex: 1})
+	if n.commit != 0 {
+		t.Fatalf("committed %d from an older term", n.commit)
+	}
+	n.step(Message{Type: MsgAppendResp, From: 2, Term: n.term, Success: true, MatchIndex: 2})
+	if n.commit != 2 {
+		t.Fatalf("commit %d, want 2", n.commit)
+	}
+	// A higher term deposes the leader.
+	n.step(Message{Type: MsgAppendResp, From: 1, Term: n.term + 1})
+	if n.state != Follower || len(out.sent) == 0 {
+		t.Errorf("state %v, want follower", n.state)
+	}
+}
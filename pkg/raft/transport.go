@@ -0,0 +1,126 @@
+package raft
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrTransportClosed is returned by operations on a closed transport.
+var ErrTransportClosed = errors.New("transport closed")
+
+// Transport moves messages between nodes. Send must not block on the
+// receiver, and a message may be lost, duplicated or reordered: Raft
+// tolerates all three.
+type Transport interface {
+	Send(to int, msg Message) error
+	Recv(id int) (Message, error)
+}
+
+// ChanTransport connects nodes of one process through unbounded in-memory
+// inboxes. Isolate cuts a node off the network, so tests can partition the
+// cluster and heal it again.
+type ChanTransport struct {
+	mu       sync.Mutex
+	inboxes  []*inbox
+	isolated map[int]bool
+}
+
+func NewChanTransport(n int) *ChanTransport {
+	t := &ChanTransport{isolated: make(map[int]bool)}
+	for i := 0; i < n; i++ {
+		t.inboxes = append(t.inboxes, newInbox())
+	}
+	return t
+}
+
+// Send queues msg for node to. Messages from or to an isolated node are
+// silently dropped, as a partitioned network would.
+func (t *ChanTransport) Send(to int, msg Message) error {
+	t.mu.Lock()
+	drop := t.isolated[msg.From] || t.isolated[to]
+	t.mu.Unlock()
+	if drop {
+		return nil
+	}
+	return t.inboxes[to].push(msg)
+}
+
+// Recv blocks until a message for node id arrives.
+func (t *ChanTransport) Recv(id int) (Message, error) {
+	return t.inboxes[id].pop()
+}
+
+// Isolate drops every message from or to node id until Heal.
+func (t *ChanTransport) Isolate(id int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.isolated[id] = true
+}
+
+// Heal reconnects node id.
+func (t *ChanTransport) Heal(id int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.isolated, id)
+}
+
+func (t *ChanTransport) isIsolated(id int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.isolated[id]
+}
+
+// Close unblocks every pending and future Recv with ErrTransportClosed.
+func (t *ChanTransport) Close() error {
+	for _, in := range t.inboxes {
+		in.close()
+	}
+	return nil
+}
+
+// inbox is an unbounded FIFO queue, so a sender never blocks on a receiver
+// that is itself busy sending.
+type inbox struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queue  []Message
+	closed bool
+}
+
+func newInbox() *inbox {
+	in := &inbox{}
+	in.cond = sync.NewCond(&in.mu)
+	return in
+}
+
+func (in *inbox) push(msg Message) error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if in.closed {
+		return ErrTransportClosed
+	}
+	in.queue = append(in.queue, msg)
+	in.cond.Signal()
+	return nil
+}
+
+func (in *inbox) pop() (Message, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	for len(in.queue) == 0 && !in.closed {
+		in.cond.Wait()
+	}
+	if in.closed {
+		return Message{}, ErrTransportClosed
+	}
+	msg := in.queue[0]
+	in.queue = in.queue[1:]
+	return msg, nil
+}
+
+func (in *inbox) close() {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.closed = true
+	in.cond.Broadcast()
+}
@@ -0,0 +1,39 @@
+package raft
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestChanTransport_Isolate(t *testing.T) {
+	tr := NewChanTransport(3)
+	tr.Isolate(1)
+	tr.Send(1, Message{From: 0, Term: 1}) // to an isolated node
+	tr.Send(2, Message{From: 1, Term: 2}) // from an isolated node
+	tr.Send(2, Message{From: 0, Term: 3})
+	tr.Heal(1)
+	tr.Send(1, Message{From: 2, Term: 4})
+
+	if m, err := tr.Recv(2); err != nil || m.Term != 3 {
+		t.Errorf("node 2 got %+v, %v, want the message of term 3", m, err)
+	}
+	if m, err := tr.Recv(1); err != nil || m.Term != 4 {
+		t.Errorf("node 1 got %+v, %v, want the message of term 4", m, err)
+	}
+}
+
+func TestChanTransport_Close(t *testing.T) {
+	tr := NewChanTransport(2)
+	done := make(chan error)
+	go func() {
+		_, err := tr.Recv(0)
+		done <- err
+	}()
+	tr.Close()
+	if err := <-done; !errors.Is(err, ErrTransportClosed) {
+		t.Errorf("blocked Recv returned %v, want ErrTransportClosed", err)
+	}
+	if err := tr.Send(1, Message{}); !errors.Is(err, ErrTransportClosed) {
+		t.Errorf("Send returned %v, want ErrTransportClosed", err)
+	}
+}
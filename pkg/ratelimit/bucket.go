@@ -0,0 +1,46 @@
+package ratelimit
+
+import "time"
+
+// Bucket is a token bucket: it holds at most Burst tokens, gains Rate tokens
+// per second, and admits a request of n tokens if it holds n. Time is given
+// by the caller, as an offset from any fixed origin, so buckets run in
+// virtual time as easily as in real time.
+type Bucket struct {
+	Rate  float64
+	Burst float64
+
+	tokens float64
+	last   time.Duration
+}
+
+// NewBucket returns a full bucket at time 0.
+func NewBucket(rate, burst float64) *Bucket {
+	return &Bucket{Rate: rate, Burst: burst, tokens: burst}
+}
+
+// Tokens returns the tokens held at now.
+func (b *Bucket) Tokens(now time.Duration) float64 {
+	b.refill(now)
+	return b.tokens
+}
+
+// Allow takes n tokens at now if the bucket holds them.
+func (b *Bucket) Allow(now time.Duration, n float64) bool {
+	b.refill(now)
+	if b.tokens < n {
+		return false
+	}
+	b.tokens -= n
+	return true
+}
+
+// refill adds the tokens gained since the last call. Time never runs
+// backwards: an earlier now counts as the last one.
+func (b *Bucket) refill(now time.Duration) {
+	if now <= b.last {
+		return
+	}
+	b.tokens = min(b.Burst, b.tokens+b.Rate*(now-b.last).Seconds())
+	b.last = now
+}
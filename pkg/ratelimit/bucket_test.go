@@ -0,0 +1,29 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucket(t *testing.T) {
+	b := NewBucket(10, 5)
+	steps := []struct {
+		at   time.Duration
+		n    float64
+		want bool
+	}{
+		{0, 5, true},                      // the full burst
+		{0, 1, false},                     // empty
+		{50 * time.Millisecond, 1, false}, // half a token
+		{100 * time.Millisecond, 1, true}, // one token
+		{10 * time.Second, 6, false},      // never more than the burst
+		{10 * time.Second, 5, true},
+		{9 * time.Second, 0.5, false}, // time doesn't run backwards
+		{10*time.Second + 50*time.Millisecond, 0.5, true},
+	}
+	for i, s := range steps {
+		if got := b.Allow(s.at, s.n); got != s.want {
+			t.Fatalf("step %d: Allow(%v, %v) = %v, want %v", i, s.at, s.n, got, s.want)
+		}
+	}
+}
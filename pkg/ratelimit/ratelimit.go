@@ -0,0 +1,226 @@
+// Package ratelimit implements token buckets, alone and shared: the nodes of
+// a Cluster admit requests against one global budget of Rate tokens per
+// second and Burst tokens at once, without asking anyone per request.
+//
+// Every node keeps its own estimate of the global bucket. It refills the
+// estimate at the global rate, takes its own requests out right away, and
+// takes out the requests of the other nodes once it hears of them: every
+// SyncInterval, every node pushes the tokens each node is known to have
+// consumed to Fanout peers, which merge the counts as a grow-only counter.
+// Between exchanges the estimates diverge: every node believes the tokens
+// others just spent are still there, so the cluster as a whole can overshoot
+// the budget. MaxDivergence bounds that: a node admits at most MaxDivergence
+// tokens per exchange, so when every node pushes to all others the cluster
+// admits at most (Nodes-1)·MaxDivergence tokens beyond the budget. A small
+// bound keeps the budget tight but caps the throughput of every node at
+// MaxDivergence per SyncInterval; it should be at least its share of the
+// rate, Rate·SyncInterval/Nodes.
+//
+// References:
+//
+// Turner, New Directions in Communications (or Which Way to the
+// Information Age?), IEEE Communications Magazine, 1986.
+//
+// Raghavan, Vishwanath, Ramabhadran, Yocum and Snoeren, Cloud Control with
+// Distributed Rate Limiting, SIGCOMM 2007.
+package ratelimit
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Config describes a Cluster sharing one budget.
+type Config struct {
+	Nodes int
+	Rate  float64 // global tokens per second
+	Burst float64 // global bucket size
+
+	SyncInterval  time.Duration // between state exchanges; 100ms if 0
+	Fanout        int           // peers every node pushes to; all others if 0
+	MaxDivergence float64       // tokens a node admits per exchange; unbounded if 0
+	Seed          int64
+}
+
+func (c Config) withDefaults() Config {
+	if c.SyncInterval <= 0 {
+		c.SyncInterval = 100 * time.Millisecond
+	}
+	if c.Fanout <= 0 || c.Fanout > c.Nodes-1 {
+		c.Fanout = c.Nodes - 1
+	}
+	return c
+}
+
+// Limiter is the rate limiter of one node of a Cluster.
+type Limiter struct {
+	ID int
+
+	cfg      Config
+	level    float64 // estimate of the tokens in the global bucket
+	last     time.Duration
+	consumed []float64 // tokens every node is known to have consumed
+	unsynced float64   // tokens admitted since the last push
+}
+
+func newLimiter(id int, cfg Config) *Limiter {
+	return &Limiter{ID: id, cfg: cfg, level: cfg.Burst, consumed: make([]float64, cfg.Nodes)}
+}
+
+// Tokens returns the estimate of the tokens in the global bucket at now. It
+// is negative while the node owes tokens others spent before it heard.
+func (l *Limiter) Tokens(now time.Duration) float64 {
+	l.refill(now)
+	return l.level
+}
+
+// Allow admits a request of n tokens at now if the estimate of the global
+// bucket holds them and the node stays within MaxDivergence.
+func (l *Limiter) Allow(now time.Duration, n float64) bool {
+	l.refill(now)
+	if l.level < n || (l.cfg.MaxDivergence > 0 && l.unsynced+n > l.cfg.MaxDivergence) {
+		return false
+	}
+	l.level -= n
+	l.consumed[l.ID] += n
+	l.unsynced += n
+	return true
+}
+
+// State returns the tokens every node is known to have consumed, to push to
+// peers.
+func (l *Limiter) State() []float64 {
+	return append([]float64(nil), l.consumed...)
+}
+
+// Merge takes in the state of a peer at now: the tokens others consumed and
+// l hadn't heard of come out of its estimate. A node's own count is only
+// ever its own.
+func (l *Limiter) Merge(now time.Duration, state []float64) {
+	l.refill(now)
+	for id, c := range state {
+		if id == l.ID || c <= l.consumed[id] {
+			continue
+		}
+		l.level -= c - l.consumed[id]
+		l.consumed[id] = c
+	}
+}
+
+func (l *Limiter) refill(now time.Duration) {
+	if now <= l.last {
+		return
+	}
+	// The estimate only caps at Burst when it is already that full: a
+	// negative level pays off its debt first.
+	l.level = min(l.cfg.Burst, l.level+l.cfg.Rate*(now-l.last).Seconds())
+	l.last = now
+}
+
+// Stats counts the requests of a Cluster.
+type Stats struct {
+	Admitted int
+	Rejected int
+	Tokens   float64 // admitted
+	Syncs    int     // exchanges run
+	Messages int     // states pushed
+}
+
+// Cluster simulates nodes sharing one budget in virtual time. Exchanges are
+// delivered instantly.
+type Cluster struct {
+	cfg   Config
+	nodes []*Limiter
+	rng   *rand.Rand
+	now   time.Duration
+	sync  time.Duration // time of the next exchange
+	stats Stats
+}
+
+// NewCluster returns a cluster at time 0 with a full bucket.
+func NewCluster(cfg Config) (*Cluster, error) {
+	if cfg.Nodes < 1 {
+		return nil, fmt.Errorf("need at least one node, got %d", cfg.Nodes)
+	}
+	if cfg.Rate < 0 || cfg.Burst <= 0 || cfg.MaxDivergence < 0 {
+		return nil, fmt.Errorf("rate %v, burst %v and divergence %v must not be negative, burst must be positive", cfg.Rate, cfg.Burst, cfg.MaxDivergence)
+	}
+	cfg = cfg.withDefaults()
+	c := &Cluster{cfg: cfg, rng: rand.New(rand.NewSource(cfg.Seed)), sync: cfg.SyncInterval}
+	for i := 0; i < cfg.Nodes; i++ {
+		c.nodes = append(c.nodes, newLimiter(i, cfg))
+	}
+	return c, nil
+}
+
+// Limiter returns the limiter of node i.
+func (c *Cluster) Limiter(i int) *Limiter { return c.nodes[i] }
+
+// Now returns the virtual time.
+func (c *Cluster) Now() time.Duration { return c.now }
+
+// Stats returns the counts so far.
+func (c *Cluster) Stats() Stats { return c.stats }
+
+// Bound returns the most tokens the cluster can admit beyond the global
+// budget, or -1 if it is unbounded: without MaxDivergence, or when nodes push
+// to fewer than all others, so news of a spend can take several exchanges to
+// arrive.
+func (c *Cluster) Bound() float64 {
+	if c.cfg.MaxDivergence == 0 || c.cfg.Fanout < c.cfg.Nodes-1 {
+		return -1
+	}
+	return float64(c.cfg.Nodes-1) * c.cfg.MaxDivergence
+}
+
+// Advance moves the virtual time forward by d, running the exchanges due.
+func (c *Cluster) Advance(d time.Duration) {
+	end := c.now + d
+	for c.sync <= end {
+		c.now = c.sync
+		c.exchange()
+		c.sync += c.cfg.SyncInterval
+	}
+	c.now = end
+}
+
+// Allow asks node i to admit a request of n tokens now.
+func (c *Cluster) Allow(i int, n float64) bool {
+	if !c.nodes[i].Allow(c.now, n) {
+		c.stats.Rejected++
+		return false
+	}
+	c.stats.Admitted++
+	c.stats.Tokens += n
+	return true
+}
+
+// exchange has every node push its state to Fanout random peers. The states
+// are taken before any is merged, as if all were sent at once.
+func (c *Cluster) exchange() {
+	c.stats.Syncs++
+	states := make([][]float64, len(c.nodes))
+	for i, l := range c.nodes {
+		states[i] = l.State()
+		l.unsynced = 0
+	}
+	for i := range c.nodes {
+		for _, j := range c.peers(i) {
+			c.nodes[j].Merge(c.now, states[i])
+			c.stats.Messages++
+		}
+	}
+}
+
+// peers returns Fanout random nodes other than i.
+func (c *Cluster) peers(i int) []int {
+	n := len(c.nodes)
+	others := make([]int, 0, n-1)
+	for _, j := range c.rng.Perm(n) {
+		if j != i {
+			others = append(others, j)
+		}
+	}
+	return others[:c.cfg.Fanout]
+}
@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// burst has every node of c ask for one token at a time, up to demand each,
+// and returns the tokens admitted.
+func burst(c *Cluster, demand int) float64 {
+	before := c.Stats().Tokens
+	for k := 0; k < demand; k++ {
+		for i := 0; i < c.cfg.Nodes; i++ {
+			c.Allow(i, 1)
+		}
+	}
+	return c.Stats().Tokens - before
+}
+
+func TestCluster_Burst(t *testing.T) {
+	tests := []struct {
+		name       string
+		divergence float64
+		fanout     int
+		want       float64 // admitted by the first burst
+	}{
+		// Every node believes the whole burst is its own.
+		{"unbounded", 0, 0, 80},
+		{"bounded", 5, 0, 20},
+		{"loose bound", 10, 0, 40},
+		{"gossip", 5, 1, 20},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewCluster(Config{Nodes: 4, Rate: 10, Burst: 20, MaxDivergence: tc.divergence, Fanout: tc.fanout, Seed: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := burst(c, 50); got != tc.want {
+				t.Errorf("first burst admitted %v tokens, want %v", got, tc.want)
+			}
+			if b := c.Bound(); b >= 0 && tc.want > c.cfg.Burst+b {
+				t.Errorf("admitted %v beyond burst %v plus bound %v", tc.want, c.cfg.Burst, b)
+			}
+
+			// Once the nodes heard of each other, they pay off the
+			// overshoot before admitting more.
+			for s := 0; s < 10; s++ {
+				c.Advance(c.cfg.SyncInterval)
+			}
+			owed := tc.want - c.cfg.Burst
+			refill := c.cfg.Rate * c.Now().Seconds()
+			for i := 0; i < 4; i++ {
+				if got, want := c.Limiter(i).Tokens(c.Now()), min(c.cfg.Burst, refill-owed); math.Abs(got-want) > 1e-9 {
+					t.Errorf("node %d estimates %v tokens, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCluster_Sustained(t *testing.T) {
+	tests := []struct {
+		name       string
+		divergence float64
+		fanout     int
+	}{
+		{"unbounded", 0, 0},
+		{"bounded", 2, 0},
+		{"gossip", 2, 2},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := Config{Nodes: 8, Rate: 100, Burst: 50, SyncInterval: 50 * time.Millisecond, MaxDivergence: tc.divergence, Fanout: tc.fanout, Seed: 2}
+			c, err := NewCluster(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Demand of about four times the rate, at random nodes.
+			rng := rand.New(rand.NewSource(3))
+			for c.Now() < 10*time.Second {
+				c.Advance(time.Duration(rng.ExpFloat64() * float64(2500*time.Microsecond)))
+				c.Allow(rng.Intn(cfg.Nodes), 1)
+				budget := cfg.Burst + cfg.Rate*c.Now().Seconds()
+				if b := c.Bound(); b >= 0 && c.Stats().Tokens > budget+b {
+					t.Fatalf("at %v admitted %v tokens, budget %v plus bound %v", c.Now(), c.Stats().Tokens, budget, b)
+				}
+			}
+			rate := c.Stats().Tokens / c.Now().Seconds()
+			t.Logf("%.1f tokens per second, %d rejected, %d messages", rate, c.Stats().Rejected, c.Stats().Messages)
+			if rate < 0.9*cfg.Rate || rate > 1.2*cfg.Rate {
+				t.Errorf("admitted %.1f tokens per second, want about %v", rate, cfg.Rate)
+			}
+		})
+	}
+}
+
+func TestCluster_DivergenceCapsNodes(t *testing.T) {
+	// A bound below a node's share of the rate starves a lone busy node.
+	c, _ := NewCluster(Config{Nodes: 4, Rate: 100, Burst: 10, MaxDivergence: 1})
+	for c.Now() < time.Second {
+		for c.Allow(0, 1) {
+		}
+		c.Advance(10 * time.Millisecond)
+	}
+	if got := c.Stats().Tokens; got > 11 {
+		t.Errorf("one node admitted %v tokens in a second at one per exchange", got)
+	}
+}
+
+func TestNewCluster_Errors(t *testing.T) {
+	for _, cfg := range []Config{
+		{Nodes: 0, Rate: 1, Burst: 1},
+		{Nodes: 1, Rate: -1, Burst: 1},
+		{Nodes: 1, Rate: 1, Burst: 0},
+		{Nodes: 1, Rate: 1, Burst: 1, MaxDivergence: -1},
+	} {
+		if _, err := NewCluster(cfg); err == nil {
+			t.Errorf("expected an error for %+v", cfg)
+		}
+	}
+}
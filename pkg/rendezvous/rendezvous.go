@@ -0,0 +1,144 @@
+// Package rendezvous maps keys to nodes with rendezvous, or
+// highest-random-weight, hashing: every node scores every key with a hash
+// of the pair, and a key belongs to the node with the highest score. Adding
+// or removing a node only moves the keys it wins or loses, the n best
+// scores give n distinct replicas for free, and no virtual nodes are needed
+// for an even spread. A lookup costs one hash per node, where a consistent
+// hash ring costs a binary search.
+//
+// Weighted nodes score -weight/ln(u), with u the hash of the pair in (0, 1),
+// so every node wins a share of the keys proportional to its weight.
+//
+// References:
+//
+// Thaler and Ravishankar, A Name-Based Mapping Scheme for Rendezvous, 1996.
+//
+// Schindelhauer and Schomaker, Weighted Distributed Hash Tables, SPAA 2005.
+package rendezvous
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/sanderblue/algorithms/pkg/consistenthash"
+)
+
+type node struct {
+	name   string
+	weight float64
+	seed   uint64 // hash of the name
+}
+
+// Rendezvous is a set of weighted nodes keys are mapped to. It is safe for
+// concurrent use.
+type Rendezvous struct {
+	hash  consistenthash.Hash
+	mu    sync.RWMutex
+	nodes []node // sorted by name
+}
+
+// New returns an empty set of nodes hashed with hash, or
+// consistenthash.DefaultHash if nil.
+func New(hash consistenthash.Hash) *Rendezvous {
+	if hash == nil {
+		hash = consistenthash.DefaultHash
+	}
+	return &Rendezvous{hash: hash}
+}
+
+// Add adds node with weight, or changes its weight if it is already there.
+func (r *Rendezvous) Add(name string, weight float64) error {
+	if !(weight > 0) || math.IsInf(weight, 0) {
+		return fmt.Errorf("node %q: weight %v is not positive and finite", name, weight)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := sort.Search(len(r.nodes), func(i int) bool { return r.nodes[i].name >= name })
+	if i < len(r.nodes) && r.nodes[i].name == name {
+		r.nodes[i].weight = weight
+		return nil
+	}
+	r.nodes = append(r.nodes, node{})
+	copy(r.nodes[i+1:], r.nodes[i:])
+	r.nodes[i] = node{name: name, weight: weight, seed: r.hash(name)}
+	return nil
+}
+
+// Remove removes node.
+func (r *Rendezvous) Remove(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := sort.Search(len(r.nodes), func(i int) bool { return r.nodes[i].name >= name })
+	if i < len(r.nodes) && r.nodes[i].name == name {
+		r.nodes = append(r.nodes[:i], r.nodes[i+1:]...)
+	}
+}
+
+// Nodes returns the names of the nodes in sorted order.
+func (r *Rendezvous) Nodes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]string, len(r.nodes))
+	for i, n := range r.nodes {
+		out[i] = n.name
+	}
+	return out
+}
+
+// Get returns the node key belongs to, or false if there are no nodes.
+func (r *Rendezvous) Get(key string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	h := r.hash(key)
+	best, bestScore := -1, math.Inf(-1)
+	for i, n := range r.nodes {
+		if s := score(h, n); s > bestScore {
+			best, bestScore = i, s
+		}
+	}
+	if best < 0 {
+		return "", false
+	}
+	return r.nodes[best].name, true
+}
+
+// GetN returns the n nodes with the highest scores for key, best first: the
+// replicas of key. It returns every node if there are fewer than n.
+func (r *Rendezvous) GetN(key string, n int) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	h := r.hash(key)
+	type scored struct {
+		name  string
+		score float64
+	}
+	all := make([]scored, len(r.nodes))
+	for i, nd := range r.nodes {
+		all[i] = scored{nd.name, score(h, nd)}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].score > all[j].score })
+	out := make([]string, 0, min(n, len(all)))
+	for _, s := range all[:cap(out)] {
+		out = append(out, s.name)
+	}
+	return out
+}
+
+// score returns the weighted score of node n for the key hashed to h.
+func score(h uint64, n node) float64 {
+	// The top 53 bits, offset by half a step, give a uniform u in (0, 1).
+	u := (float64(mix(h^n.seed)>>11) + 0.5) / (1 << 53)
+	return -n.weight / math.Log(u)
+}
+
+// mix is the SplitMix64 finalizer.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,137 @@
+package rendezvous
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/sanderblue/algorithms/pkg/consistenthash"
+)
+
+func keys(n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = fmt.Sprint("key-", i)
+	}
+	return out
+}
+
+func uniform(n int) *Rendezvous {
+	r := New(nil)
+	for i := 0; i < n; i++ {
+		r.Add(fmt.Sprint("node-", i), 1)
+	}
+	return r
+}
+
+func TestRendezvous_Balance(t *testing.T) {
+	ks := keys(100000)
+	if got := consistenthash.Imbalance(consistenthash.Load(uniform(10), ks)); got > 1.05 {
+		t.Errorf("imbalance %.3f, want at most 1.05", got)
+	}
+
+	// Shares follow the weights.
+	r := New(nil)
+	weights := map[string]float64{"small": 1, "medium": 2, "large": 5}
+	for name, w := range weights {
+		r.Add(name, w)
+	}
+	load := consistenthash.Load(r, ks)
+	for name, w := range weights {
+		if share := float64(load[name]) / float64(len(ks)); math.Abs(share-w/8) > 0.01 {
+			t.Errorf("%s: share %.3f, want %.3f", name, share, w/8)
+		}
+	}
+}
+
+func TestRendezvous_Movement(t *testing.T) {
+	ks := keys(50000)
+	r := uniform(10)
+	before := uniform(10)
+	r.Add("node-10", 1)
+	m := consistenthash.Moves(before, r, ks)
+	if math.Abs(m.Fraction()-1.0/11) > 0.01 {
+		t.Errorf("adding a node: %v, want about 9.1%%", m)
+	}
+	r.Remove("node-10")
+	if consistenthash.Moves(before, r, ks).Moved != 0 {
+		t.Error("placement differs after adding and removing a node")
+	}
+
+	// Doubling a weight only moves keys to that node.
+	r.Add("node-3", 2)
+	for _, k := range ks {
+		a, _ := before.Get(k)
+		b, _ := r.Get(k)
+		if a != b && b != "node-3" {
+			t.Fatalf("key %s moved from %s to %s", k, a, b)
+		}
+	}
+}
+
+func TestRendezvous_GetN(t *testing.T) {
+	r := uniform(5)
+	for _, k := range keys(1000) {
+		replicas := r.GetN(k, 3)
+		if len(replicas) != 3 || replicas[0] == replicas[1] || replicas[1] == replicas[2] || replicas[0] == replicas[2] {
+			t.Fatalf("key %s: replicas %v", k, replicas)
+		}
+		if first, _ := r.Get(k); first != replicas[0] {
+			t.Fatalf("key %s: Get %s, first replica %s", k, first, replicas[0])
+		}
+		// Removing the primary promotes the next replicas.
+		c := uniform(5)
+		c.Remove(replicas[0])
+		if got := c.GetN(k, 2); !reflect.DeepEqual(got, replicas[1:]) {
+			t.Fatalf("key %s: replicas %v after removing %s, want %v", k, got, replicas[0], replicas[1:])
+		}
+	}
+	if got := r.GetN("k", 10); len(got) != 5 {
+		t.Errorf("got %d replicas of 5 nodes", len(got))
+	}
+	if _, ok := New(nil).Get("k"); ok {
+		t.Error("an empty set placed a key")
+	}
+}
+
+func TestRendezvous_Add(t *testing.T) {
+	r := New(nil)
+	for _, w := range []float64{0, -1, math.Inf(1), math.NaN()} {
+		if err := r.Add("a", w); err == nil {
+			t.Errorf("expected an error for weight %v", w)
+		}
+	}
+	r.Add("b", 1)
+	r.Add("a", 1)
+	r.Add("b", 3)
+	if !reflect.DeepEqual(r.Nodes(), []string{"a", "b"}) {
+		t.Errorf("nodes %v, want [a b]", r.Nodes())
+	}
+}
+
+// BenchmarkLookup compares the lookup cost of rendezvous hashing, linear in
+// the nodes, with the logarithmic one of a consistent hash ring, and reports
+// the imbalance of both.
+func BenchmarkLookup(b *testing.B) {
+	ks := keys(1 << 14)
+	for _, n := range []int{10, 100, 1000} {
+		hrw := uniform(n)
+		ring := consistenthash.New(100, nil)
+		for _, name := range hrw.Nodes() {
+			ring.Add(name)
+		}
+		for _, l := range []struct {
+			name string
+			consistenthash.Locator
+		}{{"rendezvous", hrw}, {"ring", ring}} {
+			b.Run(fmt.Sprintf("%s/nodes=%d", l.name, n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					l.Get(ks[i%len(ks)])
+				}
+				b.StopTimer()
+				b.ReportMetric(consistenthash.Imbalance(consistenthash.Load(l, ks)), "max/mean")
+			})
+		}
+	}
+}
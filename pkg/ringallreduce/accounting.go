@@ -0,0 +1,81 @@
+package ringallreduce
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// RankWork is the work one rank does during an all–reduce.
+type RankWork struct {
+	Steps    int
+	Messages int // chunk messages sent
+	Bytes    int // payload bytes sent
+	Flops    int // element-wise additions performed
+}
+
+// WorkReport attributes cost units to one all–reduce run so that algorithms
+// can be compared on more than wall time.
+type WorkReport struct {
+	Topology string
+	P        int
+	Elements int
+	RankWork // totals over all ranks; Steps is the longest schedule
+	PerRank  []RankWork
+}
+
+// Account computes the work of an all–reduce of n float64 elements over t
+// from its schedule. Padding to a multiple of the group size is included,
+// as it is sent and reduced like real data. A topology of no ranks does no
+// work.
+func Account(t Topology, n int) WorkReport {
+	p := t.Size()
+	if p == 0 {
+		return WorkReport{Topology: t.Name(), Elements: n}
+	}
+	chunkSize := max((n+p-1)/p, 1)
+	report := WorkReport{Topology: t.Name(), P: p, Elements: n, PerRank: make([]RankWork, p)}
+	for r := 0; r < p; r++ {
+		w := &report.PerRank[r]
+		for _, step := range t.Schedule(r) {
+			w.Steps++
+			if step.SendTo != NoPeer {
+				w.Messages += len(step.SendChunks)
+				w.Bytes += 8 * chunkSize * len(step.SendChunks)
+			}
+			if step.RecvFrom != NoPeer && step.Reduce {
+				w.Flops += chunkSize * len(step.RecvChunks)
+			}
+		}
+		report.Steps = max(report.Steps, w.Steps)
+		report.Messages += w.Messages
+		report.Bytes += w.Bytes
+		report.Flops += w.Flops
+	}
+	return report
+}
+
+// EnergyModel prices the cost units of a WorkReport, e.g. in joules.
+type EnergyModel struct {
+	PerMessage float64
+	PerByte    float64
+	PerFlop    float64
+}
+
+// Energy returns the price of the work of all ranks under m.
+func (r WorkReport) Energy(m EnergyModel) float64 {
+	return m.PerMessage*float64(r.Messages) + m.PerByte*float64(r.Bytes) + m.PerFlop*float64(r.Flops)
+}
+
+// WriteWork writes reports as an aligned table, one row per report, so runs
+// of different algorithms can be compared side by side. The energy column
+// is priced with m.
+func WriteWork(w io.Writer, reports []WorkReport, m EnergyModel) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "topology\tp\telements\tsteps\tmessages\tbytes\tflops\tenergy\t")
+	for _, r := range reports {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%.4g\t\n",
+			r.Topology, r.P, r.Elements, r.Steps, r.Messages, r.Bytes, r.Flops, r.Energy(m))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,95 @@
+package ringallreduce
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAccount(t *testing.T) {
+	tests := []struct {
+		name     string
+		topology Topology
+		n        int
+		steps    int
+		messages int
+		bytes    int
+		flops    int
+	}{
+		// Every rank sends 2(P-1) chunks and reduces P-1 of them.
+		{name: "ring", topology: NewRing(4), n: 16, steps: 6, messages: 4 * 6, bytes: 4 * 6 * 32, flops: 4 * 3 * 4},
+		// Padding: 10 elements over 4 ranks are 4 chunks of 3.
+		{name: "ring padded", topology: NewRing(4), n: 10, steps: 6, messages: 24, bytes: 24 * 24, flops: 4 * 3 * 3},
+		// Both non-root ranks send the whole vector up, the root sends it down twice.
+		{name: "tree", topology: NewTree(3), n: 6, steps: 4, messages: 2*3 + 2*3, bytes: 12 * 16, flops: 2 * 6},
+		{name: "single rank", topology: NewRing(1), n: 5},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			w := Account(tc.topology, tc.n)
+			if w.Steps != tc.steps || w.Messages != tc.messages || w.Bytes != tc.bytes || w.Flops != tc.flops {
+				t.Errorf("expected steps=%d messages=%d bytes=%d flops=%d, got %+v", tc.steps, tc.messages, tc.bytes, tc.flops, w.RankWork)
+			}
+			sum := RankWork{}
+			for _, r := range w.PerRank {
+				sum.Messages += r.Messages
+				sum.Bytes += r.Bytes
+				sum.Flops += r.Flops
+			}
+			if sum.Messages != w.Messages || sum.Bytes != w.Bytes || sum.Flops != w.Flops {
+				t.Errorf("per-rank work %+v doesn't add up to %+v", sum, w.RankWork)
+			}
+		})
+	}
+}
+
+func TestAccount_NoRanks(t *testing.T) {
+	report := Account(NewRing(0), 10)
+	if report.P != 0 || report.Steps != 0 || report.Bytes != 0 || len(report.PerRank) != 0 {
+		t.Errorf("got %+v, want an empty report", report)
+	}
+}
+
+func TestAccount_MatchesTrace(t *testing.T) {
+	const p, n = 4, 8
+	trace := tracedRun(t, p, n)
+	w := Account(NewRing(p), n)
+
+	sends, reduced := 0, 0
+	for _, e := range trace.Events() {
+		switch e.Kind {
+		case TraceSend:
+			sends++
+		case TraceReduce:
+			reduced += e.Bytes / 8
+		}
+	}
+	if sends != w.Messages || reduced != w.Flops {
+		t.Errorf("expected %d messages and %d flops, trace shows %d and %d", w.Messages, w.Flops, sends, reduced)
+	}
+}
+
+func TestWriteWork(t *testing.T) {
+	reports := []WorkReport{Account(NewRing(4), 1024), Account(NewTree(4), 1024)}
+	m := EnergyModel{PerMessage: 1, PerByte: 0.01, PerFlop: 0.001}
+	// Both move 2(P-1)n elements in total, but the tree root carries far more
+	// than any ring rank.
+	ring, tree := reports[0], reports[1]
+	if ring.Energy(m) != tree.Energy(m) {
+		t.Errorf("expected equal total energy, got %g and %g", ring.Energy(m), tree.Energy(m))
+	}
+	if tree.PerRank[0].Bytes <= ring.PerRank[0].Bytes {
+		t.Errorf("expected the tree root to send more than a ring rank, got %d and %d", tree.PerRank[0].Bytes, ring.PerRank[0].Bytes)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteWork(&buf, reports, m); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[0], "energy") || !strings.Contains(lines[2], "tree") {
+		t.Errorf("unexpected table:\n%s", buf.String())
+	}
+}
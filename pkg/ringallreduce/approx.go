@@ -0,0 +1,137 @@
+package ringallreduce
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// ApproxConfig configures AllReduceApprox.
+type ApproxConfig struct {
+	Samples    int     // elements to reduce; all of them if 0 or at least the vector length
+	Seed       int64   // shared by all ranks so they sample the same elements
+	Confidence float64 // of the bounds, in (0, 1); defaults to 0.95
+}
+
+// Estimate is an extrapolated statistic with its confidence interval.
+type Estimate struct {
+	Value float64
+	Low   float64
+	High  float64
+}
+
+// ApproxSummary describes the element-wise sum of the vectors of all ranks,
+// extrapolated from a sample of its elements.
+type ApproxSummary struct {
+	Elements   int
+	Samples    int
+	Confidence float64
+	Mean       Estimate // mean element of the sum
+	Sum        Estimate // total of all elements of the sum
+	Norm       Estimate // Euclidean norm of the sum
+}
+
+// AllReduceApprox reduces only a random sample of the elements of data, the
+// vector of every rank, and extrapolates summary statistics of the full sum
+// from it. Every rank draws the same elements from cfg.Seed, so the
+// collective moves cfg.Samples elements instead of the whole vector: a cheap
+// health check of huge vectors. data is left untouched.
+func (c *Communicator) AllReduceApprox(data [][]float64, cfg ApproxConfig) (ApproxSummary, error) {
+	if len(data) == 0 {
+		return ApproxSummary{}, fmt.Errorf("no vectors to reduce")
+	}
+	n := len(data[0])
+	for i, v := range data {
+		if len(v) != n {
+			return ApproxSummary{}, fmt.Errorf("rank %d: vector length %d differs from %d", i, len(v), n)
+		}
+	}
+	confidence := cfg.Confidence
+	if confidence == 0 {
+		confidence = 0.95
+	}
+	if confidence <= 0 || confidence >= 1 {
+		return ApproxSummary{}, fmt.Errorf("confidence %v outside (0, 1)", confidence)
+	}
+
+	idx := sampleIndices(n, cfg.Samples, cfg.Seed)
+	sample := make([][]float64, len(data))
+	for r, v := range data {
+		sample[r] = make([]float64, len(idx))
+		for j, i := range idx {
+			sample[r][j] = v[i]
+		}
+	}
+	if err := c.AllReduce(c.NewOpID(), sample); err != nil {
+		return ApproxSummary{}, err
+	}
+
+	sum := sample[0]
+	squares := make([]float64, len(sum))
+	for i, x := range sum {
+		squares[i] = x * x
+	}
+	z := math.Sqrt2 * math.Erfinv(confidence)
+	mean := estimateMean(sum, n, z)
+	meanSquare := estimateMean(squares, n, z)
+	nf := float64(n)
+	return ApproxSummary{
+		Elements:   n,
+		Samples:    len(idx),
+		Confidence: confidence,
+		Mean:       mean,
+		Sum:        Estimate{Value: nf * mean.Value, Low: nf * mean.Low, High: nf * mean.High},
+		Norm: Estimate{
+			Value: math.Sqrt(nf * meanSquare.Value),
+			Low:   math.Sqrt(nf * max(meanSquare.Low, 0)),
+			High:  math.Sqrt(nf * meanSquare.High),
+		},
+	}, nil
+}
+
+// sampleIndices draws k distinct indices below n, in increasing order,
+// determined by seed alone.
+func sampleIndices(n, k int, seed int64) []int {
+	if k <= 0 || k >= n {
+		idx := make([]int, n)
+		for i := range idx {
+			idx[i] = i
+		}
+		return idx
+	}
+	idx := rand.New(rand.NewSource(seed)).Perm(n)[:k]
+	sort.Ints(idx)
+	return idx
+}
+
+// estimateMean estimates the mean of a population of n values from a sample
+// drawn without replacement, with a normal confidence interval of z
+// standard errors.
+func estimateMean(sample []float64, n int, z float64) Estimate {
+	k := len(sample)
+	if k == 0 {
+		return Estimate{}
+	}
+	var mean float64
+	for _, x := range sample {
+		mean += x
+	}
+	mean /= float64(k)
+	if k == n {
+		return Estimate{Value: mean, Low: mean, High: mean}
+	}
+	if k == 1 {
+		// Nothing is known about the spread.
+		return Estimate{Value: mean, Low: math.Inf(-1), High: math.Inf(1)}
+	}
+	var ss float64
+	for _, x := range sample {
+		ss += (x - mean) * (x - mean)
+	}
+	variance := ss / float64(k-1)
+	// Finite population correction: sampling all n elements leaves no error.
+	fpc := float64(n-k) / float64(n-1)
+	margin := z * math.Sqrt(variance/float64(k)*fpc)
+	return Estimate{Value: mean, Low: mean - margin, High: mean + margin}
+}
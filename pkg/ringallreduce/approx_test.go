@@ -0,0 +1,95 @@
+package ringallreduce
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestAllReduceApprox_Exact(t *testing.T) {
+	c := NewCommunicator(3)
+	data := [][]float64{{1, 2, 3, 4}, {1, 2, 3, 4}, {1, 2, 3, 4}}
+	s, err := c.AllReduceApprox(data, ApproxConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The sum is 3, 6, 9, 12.
+	if s.Samples != 4 || s.Mean != (Estimate{7.5, 7.5, 7.5}) || s.Sum.Value != 30 {
+		t.Errorf("unexpected summary %+v", s)
+	}
+	if want := math.Sqrt(9 + 36 + 81 + 144); math.Abs(s.Norm.Value-want) > 1e-12 {
+		t.Errorf("norm %v, want %v", s.Norm.Value, want)
+	}
+	if data[0][0] != 1 {
+		t.Error("AllReduceApprox must not touch data")
+	}
+}
+
+func TestAllReduceApprox_Sampled(t *testing.T) {
+	const p, n = 4, 20000
+	rng := rand.New(rand.NewSource(1))
+	data := make([][]float64, p)
+	sum := make([]float64, n)
+	for r := range data {
+		data[r] = make([]float64, n)
+		for i := range data[r] {
+			data[r][i] = rng.NormFloat64() + 1
+			sum[i] += data[r][i]
+		}
+	}
+	var mean, norm float64
+	for _, x := range sum {
+		mean += x
+		norm += x * x
+	}
+	mean /= n
+	norm = math.Sqrt(norm)
+
+	c := NewCommunicator(p)
+	misses := 0
+	const trials = 40
+	for seed := int64(0); seed < trials; seed++ {
+		s, err := c.AllReduceApprox(data, ApproxConfig{Samples: 500, Seed: seed, Confidence: 0.95})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Samples != 500 || s.Elements != n {
+			t.Fatalf("unexpected sizes %+v", s)
+		}
+		if mean < s.Mean.Low || mean > s.Mean.High {
+			misses++
+		}
+		if math.Abs(s.Norm.Value-norm)/norm > 0.1 {
+			t.Errorf("seed %d: norm %v far from %v", seed, s.Norm.Value, norm)
+		}
+	}
+	// A 95% interval should miss about twice in 40 trials.
+	if misses > 8 {
+		t.Errorf("true mean outside the 95%% interval in %d of %d trials", misses, trials)
+	}
+}
+
+func TestSampleIndices(t *testing.T) {
+	a, b := sampleIndices(1000, 10, 7), sampleIndices(1000, 10, 7)
+	if len(a) != 10 {
+		t.Fatalf("got %d indices, want 10", len(a))
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatal("the same seed must give every rank the same sample")
+		}
+		if i > 0 && a[i] <= a[i-1] {
+			t.Fatalf("indices must be distinct and increasing: %v", a)
+		}
+	}
+}
+
+func TestAllReduceApprox_Errors(t *testing.T) {
+	c := NewCommunicator(2)
+	if _, err := c.AllReduceApprox([][]float64{{1}, {1, 2}}, ApproxConfig{}); err == nil {
+		t.Error("expected an error for mismatched lengths")
+	}
+	if _, err := c.AllReduceApprox([][]float64{{1}, {1}}, ApproxConfig{Confidence: 1.5}); err == nil {
+		t.Error("expected an error for an invalid confidence")
+	}
+}
@@ -0,0 +1,45 @@
+package ringallreduce
+
+// DefaultCostModel is a rough model of a datacenter network: 5µs of
+// latency per message and 10 GB/s of bandwidth.
+var DefaultCostModel = CostModel{Alpha: 5e-6, Beta: 1e-10}
+
+// Auto picks the collective algorithm for an all–reduce from the vector
+// length and the group size, the way MPI implementations switch between
+// algorithms: latency bound small vectors favour the few rounds of
+// recursive doubling or a tree, bandwidth bound large vectors favour the
+// ring. Rather than hard coded cut-over points, every candidate is priced
+// with Model, which can be tuned by hand or measured with a Tuner.
+type Auto struct {
+	Model CostModel
+	// Candidates build the topologies to choose from for a group size;
+	// defaults to ring, tree and recursive doubling.
+	Candidates []func(size int) Topology
+}
+
+// NewAuto creates a selector over the default candidates priced with m.
+func NewAuto(m CostModel) *Auto {
+	return &Auto{Model: m}
+}
+
+// Select returns the candidate with the lowest predicted cost for an
+// all–reduce of n elements over p ranks. Ties go to the earlier candidate.
+func (a *Auto) Select(p, n int) Topology {
+	candidates := a.Candidates
+	if len(candidates) == 0 {
+		candidates = []func(int) Topology{
+			func(p int) Topology { return NewRing(p) },
+			func(p int) Topology { return NewTree(p) },
+			func(p int) Topology { return NewRecursiveDoubling(p) },
+		}
+	}
+	var best Topology
+	bestCost := 0.0
+	for _, build := range candidates {
+		topo := build(p)
+		if c := a.Model.Predict(topo, n); best == nil || c < bestCost {
+			best, bestCost = topo, c
+		}
+	}
+	return best
+}
@@ -0,0 +1,88 @@
+package ringallreduce
+
+import (
+	"testing"
+)
+
+func TestRecursiveDoubling_AllReduce(t *testing.T) {
+	for _, p := range []int{1, 2, 3, 4, 5, 6, 7, 8, 12} {
+		topo := NewRecursiveDoubling(p)
+		nodes := runNodes(topo, NewChanTransport(topo), 2)
+		expected := float64(p * (p + 1) / 2)
+		for _, n := range nodes {
+			if n.Err != nil {
+				t.Fatalf("p=%d node=%d: unexpected error: %v", p, n.Rank, n.Err)
+			}
+			for j, v := range n.Data {
+				if v != expected {
+					t.Errorf("p=%d node=%d, elem=%d: expected %f, got %f", p, n.Rank, j, expected, v)
+				}
+			}
+		}
+	}
+}
+
+func TestRecursiveDoubling_Rounds(t *testing.T) {
+	tests := []struct {
+		p, rank int
+		steps   int
+	}{
+		{p: 8, rank: 5, steps: 3},
+		{p: 6, rank: 1, steps: 4}, // folds in rank 5
+		{p: 6, rank: 3, steps: 2}, // log2(4) exchange rounds only
+		{p: 6, rank: 5, steps: 2}, // send to rank 1, get the result back
+	}
+	for _, tc := range tests {
+		if got := len(NewRecursiveDoubling(tc.p).Schedule(tc.rank)); got != tc.steps {
+			t.Errorf("p=%d rank=%d: expected %d steps, got %d", tc.p, tc.rank, tc.steps, got)
+		}
+	}
+}
+
+func TestAuto_Select(t *testing.T) {
+	tests := []struct {
+		name     string
+		model    CostModel
+		p, n     int
+		expected string
+	}{
+		{name: "latency bound", model: CostModel{Alpha: 1e-3, Beta: 1e-12}, p: 16, n: 16, expected: "recursive-doubling"},
+		{name: "bandwidth bound", model: CostModel{Alpha: 1e-9, Beta: 1e-6}, p: 16, n: 1 << 20, expected: "ring"},
+		{name: "default small", model: DefaultCostModel, p: 8, n: 64, expected: "recursive-doubling"},
+		{name: "default large", model: DefaultCostModel, p: 8, n: 1 << 24, expected: "ring"},
+		// With two ranks one full exchange beats two half-sized ring steps.
+		{name: "two ranks", model: DefaultCostModel, p: 2, n: 1 << 24, expected: "recursive-doubling"},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if got := NewAuto(tc.model).Select(tc.p, tc.n).Name(); got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestCommunicator_Auto(t *testing.T) {
+	const p = 4
+	c := NewCommunicator(p)
+	c.Auto = NewAuto(DefaultCostModel)
+	var used []string
+	c.NewTransport = func(topo Topology) Transport {
+		used = append(used, topo.Name())
+		return NewChanTransport(topo)
+	}
+
+	for _, n := range []int{8, 1 << 20} {
+		data := vectors(p, n)
+		if err := c.AllReduce(c.NewOpID(), data); err != nil {
+			t.Fatalf("n=%d: unexpected error: %v", n, err)
+		}
+		if data[0][n-1] != 10 {
+			t.Errorf("n=%d: expected 10, got %f", n, data[0][n-1])
+		}
+	}
+	if len(used) != 2 || used[0] != "recursive-doubling" || used[1] != "ring" {
+		t.Errorf("expected recursive-doubling then ring, got %v", used)
+	}
+}
@@ -0,0 +1,95 @@
+package ringallreduce
+
+import "fmt"
+
+// DefaultBucketSize is the bucket capacity of a Bucketer, in elements:
+// 1M float64s, 8 MiB.
+const DefaultBucketSize = 1 << 20
+
+// Bucket is a group of consecutive tensors fused into one all–reduce.
+type Bucket struct {
+	First, Last int // indices of the first and last tensor, inclusive
+	Elements    int
+}
+
+// Bucketer fuses many small tensors, such as the per-layer gradients of a
+// model, into buckets of up to Size elements and runs one all–reduce per
+// bucket instead of one per tensor, so the per-collective latency is paid
+// per bucket. Tensors keep their order; a tensor larger than Size gets a
+// bucket of its own.
+type Bucketer struct {
+	Comm *Communicator
+	Size int // bucket capacity in elements; defaults to DefaultBucketSize
+}
+
+func NewBucketer(comm *Communicator, size int) *Bucketer {
+	return &Bucketer{Comm: comm, Size: size}
+}
+
+// Plan groups tensors of the given lengths into buckets.
+func (b *Bucketer) Plan(lengths []int) []Bucket {
+	size := b.Size
+	if size <= 0 {
+		size = DefaultBucketSize
+	}
+	var buckets []Bucket
+	for i, n := range lengths {
+		if k := len(buckets) - 1; k >= 0 && buckets[k].Elements+n <= size {
+			buckets[k].Last = i
+			buckets[k].Elements += n
+			continue
+		}
+		buckets = append(buckets, Bucket{First: i, Last: i, Elements: n})
+	}
+	return buckets
+}
+
+// AllReduce sums tensors[r][i], tensor i of rank r, across ranks in place.
+// Every rank must hold tensors of the same lengths. The tensors are only
+// updated once every bucket has been reduced, so a failed call leaves them
+// untouched. It returns the buckets it ran.
+func (b *Bucketer) AllReduce(tensors [][][]float64) ([]Bucket, error) {
+	if len(tensors) == 0 {
+		return nil, fmt.Errorf("no tensors to reduce")
+	}
+	lengths := make([]int, len(tensors[0]))
+	for i, t := range tensors[0] {
+		lengths[i] = len(t)
+	}
+	for r, ts := range tensors {
+		if len(ts) != len(lengths) {
+			return nil, fmt.Errorf("rank %d: %d tensors, rank 0 has %d", r, len(ts), len(lengths))
+		}
+		for i, t := range ts {
+			if len(t) != lengths[i] {
+				return nil, fmt.Errorf("rank %d: tensor %d has length %d, rank 0 has %d", r, i, len(t), lengths[i])
+			}
+		}
+	}
+
+	buckets := b.Plan(lengths)
+	fused := make([][][]float64, len(buckets))
+	for k, bucket := range buckets {
+		fused[k] = make([][]float64, len(tensors))
+		for r, ts := range tensors {
+			flat := make([]float64, 0, bucket.Elements)
+			for _, t := range ts[bucket.First : bucket.Last+1] {
+				flat = append(flat, t...)
+			}
+			fused[k][r] = flat
+		}
+		if err := b.Comm.AllReduce(b.Comm.NewOpID(), fused[k]); err != nil {
+			return nil, fmt.Errorf("bucket %d (tensors %d-%d): %w", k, bucket.First, bucket.Last, err)
+		}
+	}
+
+	for k, bucket := range buckets {
+		for r, ts := range tensors {
+			flat := fused[k][r]
+			for _, t := range ts[bucket.First : bucket.Last+1] {
+				flat = flat[copy(t, flat):]
+			}
+		}
+	}
+	return buckets, nil
+}
@@ -0,0 +1,140 @@
+package ringallreduce
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestBucketer_Plan(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		lengths []int
+		want    []Bucket
+	}{
+		{"fits one bucket", 10, []int{2, 3, 5}, []Bucket{{0, 2, 10}}},
+		{"splits at capacity", 4, []int{2, 2, 1, 3}, []Bucket{{0, 1, 4}, {2, 3, 4}}},
+		{"oversized tensor alone", 4, []int{1, 9, 1}, []Bucket{{0, 0, 1}, {1, 1, 9}, {2, 2, 1}}},
+		{"empty tensors join", 2, []int{0, 2, 0}, []Bucket{{0, 2, 2}}},
+		{"no tensors", 4, nil, nil},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := NewBucketer(nil, tc.size).Plan(tc.lengths)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Plan(%v) = %v, want %v", tc.lengths, got, tc.want)
+			}
+		})
+	}
+}
+
+// layers returns the tensors of p ranks with the given lengths; element j of
+// tensor i on rank r is r+1 + 10*i + j.
+func layers(p int, lengths []int) [][][]float64 {
+	out := make([][][]float64, p)
+	for r := range out {
+		out[r] = make([][]float64, len(lengths))
+		for i, n := range lengths {
+			out[r][i] = make([]float64, n)
+			for j := range out[r][i] {
+				out[r][i][j] = float64(r + 1 + 10*i + j)
+			}
+		}
+	}
+	return out
+}
+
+func TestBucketer_AllReduce(t *testing.T) {
+	const p = 4
+	lengths := []int{3, 1, 7, 2, 2, 12, 1}
+	c := NewCommunicator(p)
+	collectives := 0
+	c.NewTransport = func(topo Topology) Transport {
+		collectives++
+		return NewChanTransport(topo)
+	}
+
+	tensors := layers(p, lengths)
+	buckets, err := NewBucketer(c, 8).AllReduce(tensors)
+	if err != nil {
+		t.Fatalf("AllReduce: %v", err)
+	}
+	if collectives != len(buckets) || len(buckets) != 5 {
+		t.Errorf("%d collectives for %d buckets, want 5", collectives, len(buckets))
+	}
+	for r := range tensors {
+		for i, n := range lengths {
+			for j := 0; j < n; j++ {
+				// sum over r of r+1 + 10i + j
+				want := float64(p*(p+1)/2 + p*(10*i+j))
+				if got := tensors[r][i][j]; got != want {
+					t.Fatalf("rank %d tensor %d element %d: got %v, want %v", r, i, j, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestBucketer_FailureLeavesTensorsUntouched(t *testing.T) {
+	c := NewCommunicator(2)
+	attempts := 0
+	c.NewTransport = func(topo Topology) Transport {
+		attempts++
+		if attempts == 2 {
+			return failedSendTransport{NewChanTransport(topo)}
+		}
+		return NewChanTransport(topo)
+	}
+	tensors := layers(2, []int{2, 2, 2})
+	want := layers(2, []int{2, 2, 2})
+	if _, err := NewBucketer(c, 2).AllReduce(tensors); !errors.Is(err, errInjected) {
+		t.Fatalf("expected the injected error, got %v", err)
+	}
+	if !reflect.DeepEqual(tensors, want) {
+		t.Errorf("tensors changed by a failed call: %v", tensors)
+	}
+}
+
+// failedSendTransport fails every send.
+type failedSendTransport struct {
+	*ChanTransport
+}
+
+func (failedSendTransport) Send(int, Msg) error { return errInjected }
+
+func TestBucketer_Errors(t *testing.T) {
+	b := NewBucketer(NewCommunicator(2), 4)
+	if _, err := b.AllReduce(nil); err == nil {
+		t.Error("expected an error without tensors")
+	}
+	if _, err := b.AllReduce([][][]float64{{{1}, {2}}, {{1}}}); err == nil {
+		t.Error("expected an error for a differing tensor count")
+	}
+	if _, err := b.AllReduce([][][]float64{{{1}, {2}}, {{1}, {2, 3}}}); err == nil {
+		t.Error("expected an error for a differing tensor length")
+	}
+}
+
+func BenchmarkBucketer(b *testing.B) {
+	const p, tensors = 4, 64
+	lengths := make([]int, tensors)
+	for i := range lengths {
+		lengths[i] = 16
+	}
+	for _, bench := range []struct {
+		name string
+		size int
+	}{{"per-tensor", 1}, {"bucketed", 256}} {
+		b.Run(bench.name, func(b *testing.B) {
+			bucketer := NewBucketer(NewCommunicator(p), bench.size)
+			data := layers(p, lengths)
+			for i := 0; i < b.N; i++ {
+				if _, err := bucketer.AllReduce(data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
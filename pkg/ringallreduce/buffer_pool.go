@@ -0,0 +1,50 @@
+package ringallreduce
+
+import "sync"
+
+// BufferPool recycles the chunk buffers of messages between the nodes of a
+// collective: a sender takes its outgoing buffer from the pool and the
+// receiver gives it back once the chunk is applied, so after the first few
+// steps the collective runs without allocating. The pool only holds
+// buffers of one length; others are dropped.
+//
+// Recycling on receipt is only safe when the transport hands the sent
+// slice to exactly one receiver and keeps no reference to it, as
+// ChanTransport does. Transports that retain messages, such as
+// ReliableTransport for retransmission, must not be used with a pool.
+type BufferPool struct {
+	Size int // length of the pooled buffers
+
+	mu   sync.Mutex
+	free [][]float64
+}
+
+func NewBufferPool(size int) *BufferPool {
+	return &BufferPool{Size: size}
+}
+
+// get returns a buffer of Size elements and whether it had to be allocated.
+// A nil pool always allocates.
+func (p *BufferPool) get(size int) ([]float64, bool) {
+	if p != nil && size == p.Size {
+		p.mu.Lock()
+		if n := len(p.free); n > 0 {
+			buf := p.free[n-1]
+			p.free = p.free[:n-1]
+			p.mu.Unlock()
+			return buf, false
+		}
+		p.mu.Unlock()
+	}
+	return make([]float64, size), true
+}
+
+// put returns buf to the pool.
+func (p *BufferPool) put(buf []float64) {
+	if p == nil || len(buf) != p.Size {
+		return
+	}
+	p.mu.Lock()
+	p.free = append(p.free, buf)
+	p.mu.Unlock()
+}
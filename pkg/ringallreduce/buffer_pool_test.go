@@ -0,0 +1,109 @@
+package ringallreduce
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestBufferPool(t *testing.T) {
+	p := NewBufferPool(4)
+	a, allocated := p.get(4)
+	if !allocated || len(a) != 4 {
+		t.Fatalf("first get: len %d, allocated %v", len(a), allocated)
+	}
+	p.put(a)
+	p.put(make([]float64, 3)) // wrong size, dropped
+	b, allocated := p.get(4)
+	if allocated || &b[0] != &a[0] {
+		t.Error("expected the returned buffer to be reused")
+	}
+	if _, allocated := p.get(4); !allocated {
+		t.Error("expected an allocation once the pool is empty")
+	}
+	if buf, allocated := p.get(5); !allocated || len(buf) != 5 {
+		t.Error("expected a fresh buffer for another size")
+	}
+
+	var nilPool *BufferPool
+	if buf, allocated := nilPool.get(2); !allocated || len(buf) != 2 {
+		t.Error("expected a nil pool to allocate")
+	}
+	nilPool.put(b)
+}
+
+// runPooled runs a ring all–reduce of p nodes of chunk elements per chunk,
+// with buffers recycled through pool if it isn't nil, and returns the
+// allocations of every rank.
+func runPooled(tb testing.TB, p, chunk int, pool *BufferPool) []int {
+	transport := NewChanTransport(NewRing(p))
+	nodes := make([]*Node, p)
+	for r := range nodes {
+		data := make([]float64, p*chunk)
+		for i := range data {
+			data[i] = float64(r + 1)
+		}
+		nodes[r] = &Node{Rank: r, P: p, ChunkSize: chunk, Data: data, Transport: transport, Pool: pool}
+	}
+	var wg sync.WaitGroup
+	wg.Add(p)
+	for _, n := range nodes {
+		go n.Run(&wg)
+	}
+	wg.Wait()
+	allocs := make([]int, p)
+	for r, n := range nodes {
+		if n.Err != nil {
+			tb.Fatalf("rank %d: %v", r, n.Err)
+		}
+		if got, want := n.Data[len(n.Data)-1], float64(p*(p+1)/2); got != want {
+			tb.Fatalf("rank %d: got %v, want %v", r, got, want)
+		}
+		allocs[r] = n.Stats.Allocs
+	}
+	return allocs
+}
+
+func TestNode_PoolCutsAllocations(t *testing.T) {
+	const p, chunk = 8, 64
+	total := func(allocs []int) int {
+		sum := 0
+		for _, a := range allocs {
+			sum += a
+		}
+		return sum
+	}
+	plain := total(runPooled(t, p, chunk, nil))
+	if plain != p*2*(p-1) {
+		t.Errorf("unpooled: %d allocations, want one per message (%d)", plain, p*2*(p-1))
+	}
+	pool := NewBufferPool(chunk)
+	first := total(runPooled(t, p, chunk, pool))
+	if first >= plain {
+		t.Errorf("pooled: %d allocations, unpooled %d", first, plain)
+	}
+	// A warm pool serves the next collective almost without allocating;
+	// only a scheduling with more messages in flight than before needs
+	// new buffers.
+	if again := total(runPooled(t, p, chunk, pool)); again > p {
+		t.Errorf("warm pool: %d allocations, want at most %d", again, p)
+	}
+}
+
+func BenchmarkNode_Pool(b *testing.B) {
+	const p, chunk = 8, 1 << 12
+	for _, bench := range []struct {
+		name string
+		pool func() *BufferPool
+	}{
+		{"fresh-buffers", func() *BufferPool { return nil }},
+		{"pool", func() *BufferPool { return NewBufferPool(chunk) }},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			pool := bench.pool()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				runPooled(b, p, chunk, pool)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package ringallreduce
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CalibrationConfig controls the ping-pong measurements of Calibrate.
+type CalibrationConfig struct {
+	SmallElements int // elements of the latency probe; defaults to 1
+	LargeElements int // elements of the bandwidth probe; defaults to 64Ki
+	Rounds        int // trips around the ring per probe size; defaults to 8
+	Warmup        int // untimed trips before measuring; defaults to 2
+}
+
+func (cfg CalibrationConfig) withDefaults() CalibrationConfig {
+	if cfg.SmallElements <= 0 {
+		cfg.SmallElements = 1
+	}
+	if cfg.LargeElements <= cfg.SmallElements {
+		cfg.LargeElements = 64 * 1024
+	}
+	if cfg.Rounds <= 0 {
+		cfg.Rounds = 8
+	}
+	if cfg.Warmup < 0 {
+		cfg.Warmup = 0
+	} else if cfg.Warmup == 0 {
+		cfg.Warmup = 2
+	}
+	return cfg
+}
+
+// Calibrate measures the alpha-beta cost of transport by passing a token
+// around a ring of p ranks, first with a small message and then with a large
+// one. The per-hop time of the small probe estimates Alpha, and the growth
+// from small to large estimates Beta.
+func Calibrate(transport Transport, p int, cfg CalibrationConfig) (CostModel, error) {
+	if p < 2 {
+		return CostModel{}, errors.New("calibration needs at least two ranks")
+	}
+	cfg = cfg.withDefaults()
+
+	small, err := ringTrip(transport, p, cfg.SmallElements, cfg.Warmup, cfg.Rounds)
+	if err != nil {
+		return CostModel{}, err
+	}
+	large, err := ringTrip(transport, p, cfg.LargeElements, cfg.Warmup, cfg.Rounds)
+	if err != nil {
+		return CostModel{}, err
+	}
+
+	smallBytes := float64(8 * cfg.SmallElements)
+	largeBytes := float64(8 * cfg.LargeElements)
+	beta := (large - small) / (largeBytes - smallBytes)
+	if beta < 0 {
+		beta = 0
+	}
+	alpha := small - beta*smallBytes
+	if alpha < 0 {
+		alpha = 0
+	}
+	return CostModel{Alpha: alpha, Beta: beta}, nil
+}
+
+// ringTrip sends a token of the given size around the ring warmup+rounds
+// times and returns the mean time of a single hop over the timed trips.
+func ringTrip(transport Transport, p, elements, warmup, rounds int) (float64, error) {
+	trips := warmup + rounds
+	errs := make(chan error, p)
+	var wg sync.WaitGroup
+
+	// Ranks 1..p-1 forward every token to their right neighbor.
+	for r := 1; r < p; r++ {
+		wg.Add(1)
+		go func(r int) {
+			defer wg.Done()
+			for i := 0; i < trips; i++ {
+				m, err := transport.Recv(r)
+				if err != nil {
+					errs <- err
+					return
+				}
+				m.From = r
+				if err := transport.Send((r+1)%p, m); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(r)
+	}
+
+	payload := make([]float64, elements)
+	var start time.Time
+	var err error
+	for i := 0; i < trips && err == nil; i++ {
+		if i == warmup {
+			start = time.Now()
+		}
+		if err = transport.Send(1, Msg{From: 0, ChunkIdx: i, Data: payload}); err != nil {
+			break
+		}
+		var m Msg
+		if m, err = transport.Recv(0); err == nil && m.ChunkIdx != i {
+			err = fmt.Errorf("calibration token %d came back as %d", i, m.ChunkIdx)
+		}
+	}
+	elapsed := time.Since(start)
+	if err != nil {
+		closeTransport(transport)
+		wg.Wait()
+		return 0, err
+	}
+	wg.Wait()
+	select {
+	case err := <-errs:
+		return 0, err
+	default:
+	}
+	return elapsed.Seconds() / float64(rounds*p), nil
+}
+
+// Tuner keeps a calibrated cost model for a Communicator and uses it to pick
+// the cheapest topology for a collective. The model is re-measured whenever
+// the communicator's membership, and with it the ring, changes.
+type Tuner struct {
+	Comm *Communicator
+	// Candidates build the topologies to choose from for a group size;
+	// defaults to ring, tree and recursive doubling.
+	Candidates []func(size int) Topology
+	Config     CalibrationConfig
+
+	mu    sync.Mutex
+	model CostModel
+	epoch uint64
+	valid bool
+}
+
+func NewTuner(c *Communicator) *Tuner {
+	return &Tuner{
+		Comm: c,
+		Candidates: []func(int) Topology{
+			func(p int) Topology { return NewRing(p) },
+			func(p int) Topology { return NewTree(p) },
+			func(p int) Topology { return NewRecursiveDoubling(p) },
+		},
+	}
+}
+
+// Calibrate measures the communicator's transport now.
+func (t *Tuner) Calibrate() (CostModel, error) {
+	epoch := t.Comm.Epoch()
+	p := t.Comm.Size()
+	ring := NewRing(p)
+	transport, release := t.Comm.transport(ring)
+	defer release()
+
+	model, err := Calibrate(transport, p, t.Config)
+	if err != nil {
+		return CostModel{}, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.model, t.epoch, t.valid = model, epoch, true
+	return model, nil
+}
+
+// Model returns the calibrated model, calibrating first if it has never run
+// or the membership changed since the last calibration.
+func (t *Tuner) Model() (CostModel, error) {
+	t.mu.Lock()
+	if t.valid && t.epoch == t.Comm.Epoch() {
+		m := t.model
+		t.mu.Unlock()
+		return m, nil
+	}
+	t.mu.Unlock()
+	return t.Calibrate()
+}
+
+// SetModel installs a model measured elsewhere for the current membership.
+func (t *Tuner) SetModel(m CostModel) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.model, t.epoch, t.valid = m, t.Comm.Epoch(), true
+}
+
+// Select returns the candidate topology with the lowest predicted cost for
+// an all–reduce of n elements.
+func (t *Tuner) Select(n int) (Topology, error) {
+	auto, err := t.Auto()
+	if err != nil {
+		return nil, err
+	}
+	return auto.Select(t.Comm.Size(), n), nil
+}
+
+// Auto returns an Auto selector over the tuner's candidates that uses the
+// calibrated model. Install it as Communicator.Auto to pick the topology of
+// every AllReduce by measurement.
+func (t *Tuner) Auto() (*Auto, error) {
+	model, err := t.Model()
+	if err != nil {
+		return nil, err
+	}
+	return &Auto{Model: model, Candidates: t.Candidates}, nil
+}
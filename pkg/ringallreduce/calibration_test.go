@@ -0,0 +1,73 @@
+package ringallreduce
+
+import (
+	"testing"
+	"time"
+)
+
+// delayTransport adds a fixed latency and a per-element delay to every send.
+type delayTransport struct {
+	*ChanTransport
+	latency    time.Duration
+	perElement time.Duration
+}
+
+func (d delayTransport) Send(rank int, msg Msg) error {
+	time.Sleep(d.latency + time.Duration(len(msg.Data))*d.perElement)
+	return d.ChanTransport.Send(rank, msg)
+}
+
+func TestCalibrate_RecoversLatency(t *testing.T) {
+	transport := delayTransport{ChanTransport: NewChanTransportSize(3, 4), latency: 2 * time.Millisecond}
+	model, err := Calibrate(transport, 3, CalibrationConfig{LargeElements: 1024, Rounds: 4, Warmup: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if model.Alpha < 0.0015 || model.Alpha > 0.02 {
+		t.Errorf("expected alpha around 2ms, got %gs", model.Alpha)
+	}
+}
+
+func TestTuner_RecalibratesOnMembershipChange(t *testing.T) {
+	c := NewCommunicator(3)
+	calibrations := 0
+	c.NewTransport = func(topo Topology) Transport {
+		calibrations++
+		return NewChanTransportSize(topo.Size(), 4)
+	}
+	tuner := NewTuner(c)
+	tuner.Config = CalibrationConfig{LargeElements: 16, Rounds: 1, Warmup: 1}
+
+	if _, err := tuner.Model(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := tuner.Model(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calibrations != 1 {
+		t.Errorf("expected a cached model, calibrated %d times", calibrations)
+	}
+	c.Add(1)
+	if _, err := tuner.Model(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calibrations != 2 {
+		t.Errorf("expected recalibration after membership change, calibrated %d times", calibrations)
+	}
+}
+
+func TestTuner_Select(t *testing.T) {
+	c := NewCommunicator(8)
+	tuner := NewTuner(c)
+
+	// Latency bound network: recursive doubling needs the fewest rounds.
+	tuner.SetModel(CostModel{Alpha: 1e-3, Beta: 1e-12})
+	if topo, err := tuner.Select(8); err != nil || topo.Name() != "recursive-doubling" {
+		t.Errorf("small vector: expected recursive-doubling, got %v (%v)", topo, err)
+	}
+	// Bandwidth bound: the ring moves the least data per rank.
+	tuner.SetModel(CostModel{Alpha: 1e-9, Beta: 1e-6})
+	if topo, err := tuner.Select(1 << 20); err != nil || topo.Name() != "ring" {
+		t.Errorf("large vector: expected ring, got %v (%v)", topo, err)
+	}
+}
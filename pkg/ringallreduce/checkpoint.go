@@ -0,0 +1,91 @@
+package ringallreduce
+
+import (
+	"fmt"
+)
+
+// Checkpoint is a serializable snapshot of a Node part way through an
+// all–reduce. Together with the messages still queued in the transport it
+// is everything needed to finish the collective after the node restarts.
+type Checkpoint struct {
+	Rank      int
+	P         int
+	ChunkSize int
+	Op        uint64
+	Topology  string    // name of the topology the schedule came from
+	Step      int       // schedule step in progress; len(schedule) once done
+	Phase     Phase     // phase of Step
+	Sent      bool      // whether the sends of Step are done
+	Received  int       // receives of Step already applied to Data
+	Data      []float64 // buffer contents
+	Pending   []Msg     // messages received ahead of the step that consumes them
+}
+
+// Checkpoint returns a snapshot of the node's progress. It must not be
+// called while AllReduce is running; use OnCheckpoint to observe a running
+// node.
+func (proc *Node) Checkpoint() Checkpoint {
+	cp := Checkpoint{
+		Rank:      proc.Rank,
+		P:         proc.P,
+		ChunkSize: proc.ChunkSize,
+		Op:        proc.Op,
+		Topology:  proc.topology().Name(),
+		Step:      proc.step,
+		Sent:      proc.sent,
+		Received:  proc.received,
+		Data:      append([]float64(nil), proc.Data...),
+	}
+	if steps := proc.topology().Schedule(proc.Rank); proc.step < len(steps) {
+		cp.Phase = steps[proc.step].Phase
+	} else if len(steps) > 0 {
+		cp.Phase = steps[len(steps)-1].Phase
+	}
+	for _, m := range proc.pending {
+		m.Data = append([]float64(nil), m.Data...)
+		cp.Pending = append(cp.Pending, m)
+	}
+	return cp
+}
+
+// ResumeFrom restores the node from cp so that the next AllReduce continues
+// where the checkpointed node stopped. Topology and Transport are kept; the
+// topology must match the one the checkpoint was taken with. Messages the
+// checkpointed node had already taken off the transport after cp was taken
+// are lost, so cp should be the latest checkpoint reported by OnCheckpoint.
+func (proc *Node) ResumeFrom(cp Checkpoint) error {
+	if proc.Topology == nil {
+		proc.P = cp.P
+	}
+	t := proc.topology()
+	if t.Name() != cp.Topology || t.Size() != cp.P {
+		return fmt.Errorf("checkpoint of %s p=%d doesn't match topology %s p=%d", cp.Topology, cp.P, t.Name(), t.Size())
+	}
+	if steps := len(t.Schedule(cp.Rank)); cp.Step < 0 || cp.Step > steps {
+		return fmt.Errorf("checkpoint step %d out of range [0, %d]", cp.Step, steps)
+	}
+	if len(cp.Data) != cp.P*cp.ChunkSize {
+		return fmt.Errorf("checkpoint data length %d, expected %d", len(cp.Data), cp.P*cp.ChunkSize)
+	}
+
+	proc.Rank = cp.Rank
+	proc.P = cp.P
+	proc.ChunkSize = cp.ChunkSize
+	proc.Op = cp.Op
+	proc.Data = append([]float64(nil), cp.Data...)
+	proc.step = cp.Step
+	proc.sent = cp.Sent
+	proc.received = cp.Received
+	proc.pending = nil
+	for _, m := range cp.Pending {
+		m.Data = append([]float64(nil), m.Data...)
+		proc.pending = append(proc.pending, m)
+	}
+	return nil
+}
+
+func (proc *Node) checkpoint() {
+	if proc.OnCheckpoint != nil {
+		proc.OnCheckpoint(proc.Checkpoint())
+	}
+}
@@ -0,0 +1,153 @@
+package ringallreduce
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errCrashed = errors.New("crashed")
+
+// crashingReceiver fails every receive of its rank after the first after.
+type crashingReceiver struct {
+	Transport
+	after int
+	count int
+}
+
+func (c *crashingReceiver) Recv(rank int) (Msg, error) {
+	if c.count >= c.after {
+		return Msg{}, errCrashed
+	}
+	c.count++
+	return c.Transport.Recv(rank)
+}
+
+func TestNode_ResumeFromCheckpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		topology Topology
+		crashed  int
+		after    int
+	}{
+		{name: "ring early", topology: NewRing(4), crashed: 2, after: 1},
+		{name: "ring allgather", topology: NewRing(4), crashed: 1, after: 4},
+		{name: "torus", topology: NewTorus(2, 3), crashed: 4, after: 3},
+		{name: "tree root", topology: NewTree(5), crashed: 0, after: 1},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			p := tc.topology.Size()
+			chunkSize := 2
+			transport := delayTransport{ChanTransport: NewChanTransportSize(p, 64), latency: time.Millisecond}
+
+			var last Checkpoint
+			nodes := make([]*Node, p)
+			for i := range nodes {
+				data := make([]float64, p*chunkSize)
+				for j := range data {
+					data[j] = float64(i + 1)
+				}
+				nodes[i] = &Node{Rank: i, P: p, ChunkSize: chunkSize, Data: data, Topology: tc.topology, Transport: transport}
+			}
+			crashed := nodes[tc.crashed]
+			crashed.Transport = &crashingReceiver{Transport: transport, after: tc.after}
+			crashed.OnCheckpoint = func(cp Checkpoint) { last = cp }
+
+			var wg, crash sync.WaitGroup
+			wg.Add(p - 1)
+			crash.Add(1)
+			for _, n := range nodes {
+				if n == crashed {
+					go n.Run(&crash)
+				} else {
+					go n.Run(&wg)
+				}
+			}
+			crash.Wait()
+			if !errors.Is(crashed.Err, errCrashed) {
+				t.Fatalf("expected crash, got %v", crashed.Err)
+			}
+
+			// Persist the checkpoint and restart the rank from it.
+			encoded, err := json.Marshal(last)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			var cp Checkpoint
+			if err := json.Unmarshal(encoded, &cp); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			restarted := &Node{Topology: tc.topology, Transport: transport}
+			if err := restarted.ResumeFrom(cp); err != nil {
+				t.Fatalf("resume: %v", err)
+			}
+			nodes[tc.crashed] = restarted
+			wg.Add(1)
+			go restarted.Run(&wg)
+			wg.Wait()
+
+			expected := float64(p * (p + 1) / 2)
+			for _, n := range nodes {
+				if n.Rank != tc.crashed && n.Err != nil {
+					t.Fatalf("node=%d: unexpected error: %v", n.Rank, n.Err)
+				}
+				for j, v := range n.Data {
+					if v != expected {
+						t.Errorf("node=%d, elem=%d: expected %f, got %f", n.Rank, j, expected, v)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestNode_Checkpoint(t *testing.T) {
+	transport := NewChanTransportSize(3, 16)
+	var checkpoints []Checkpoint
+	nodes := runNodesWith(NewRing(3), transport, 1, func(n *Node) {
+		if n.Rank == 0 {
+			n.OnCheckpoint = func(cp Checkpoint) { checkpoints = append(checkpoints, cp) }
+		}
+	})
+	if nodes[0].Err != nil {
+		t.Fatalf("unexpected error: %v", nodes[0].Err)
+	}
+
+	// Every ring step reports after its send and after its receive.
+	if len(checkpoints) != 8 {
+		t.Fatalf("expected 8 checkpoints, got %d", len(checkpoints))
+	}
+	first, last := checkpoints[0], checkpoints[len(checkpoints)-1]
+	if first.Step != 0 || !first.Sent || first.Phase != PhaseReduceScatter {
+		t.Errorf("unexpected first checkpoint %+v", first)
+	}
+	if last.Step != 4 || last.Sent || last.Phase != PhaseAllGather {
+		t.Errorf("unexpected last checkpoint %+v", last)
+	}
+}
+
+func TestNode_ResumeFromMismatch(t *testing.T) {
+	tests := []struct {
+		name string
+		node *Node
+		step int
+	}{
+		{name: "other topology", node: &Node{Topology: NewTree(4)}},
+		{name: "other size", node: &Node{Topology: NewRing(3)}},
+		{name: "step out of range", node: &Node{Topology: NewRing(4)}, step: 7},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cp := Checkpoint{Rank: 0, P: 4, ChunkSize: 1, Topology: "ring", Step: tc.step, Data: make([]float64, 4)}
+			if err := tc.node.ResumeFrom(cp); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
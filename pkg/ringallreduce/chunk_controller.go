@@ -0,0 +1,282 @@
+package ringallreduce
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// ChunkAction is the adjustment a ChunkController made after an iteration.
+type ChunkAction int
+
+const (
+	// ChunkHold keeps the chunk size unchanged.
+	ChunkHold ChunkAction = iota
+	// ChunkShrink reduces the chunk size because step latencies were jittery.
+	ChunkShrink
+	// ChunkGrow increases the chunk size because step latencies were stable.
+	ChunkGrow
+)
+
+func (a ChunkAction) String() string {
+	switch a {
+	case ChunkHold:
+		return "hold"
+	case ChunkShrink:
+		return "shrink"
+	case ChunkGrow:
+		return "grow"
+	default:
+		return fmt.Sprintf("action(%d)", int(a))
+	}
+}
+
+// ChunkDecision records what a ChunkController observed during one iteration
+// and how it reacted.
+type ChunkDecision struct {
+	Iteration int
+	ChunkSize int // chunk size used by the iteration
+	Steps     int // number of step latencies observed
+	Mean      time.Duration
+	StdDev    time.Duration
+	CV        float64 // coefficient of variation, StdDev / Mean
+	Action    ChunkAction
+	Next      int // chunk size for the next iteration
+}
+
+// ChunkMetrics summarizes the decisions of a ChunkController.
+type ChunkMetrics struct {
+	Iterations int
+	Shrinks    int
+	Grows      int
+	Holds      int
+	ChunkSize  int     // current chunk size
+	LastCV     float64 // coefficient of variation of the last iteration
+}
+
+// ChunkController adapts the chunk size between all–reduce iterations.
+// Jittery links favour small chunks, so a slow step holds back less data and
+// the pipeline keeps moving, while stable links favour large chunks that
+// amortize the per-message latency. After every iteration the controller
+// looks at the coefficient of variation of the step latencies: above
+// JitterHigh the chunk size is divided by Factor, below JitterLow it is
+// multiplied by Factor, and in between it is left alone.
+type ChunkController struct {
+	Min        int     // smallest chunk size, at least 1
+	Max        int     // largest chunk size
+	JitterHigh float64 // shrink above this coefficient of variation; defaults to 0.5
+	JitterLow  float64 // grow below this coefficient of variation; defaults to 0.1
+	Factor     int     // multiplicative step; defaults to 2
+
+	mu        sync.Mutex
+	current   int
+	decisions []ChunkDecision
+}
+
+// NewChunkController creates a controller that starts at initial elements
+// per chunk and stays within [min, max]. A max of 0 leaves the chunk size
+// unbounded above; otherwise min must not exceed it.
+func NewChunkController(initial, min, max int) (*ChunkController, error) {
+	if max < 0 || (max > 0 && min > max) {
+		return nil, fmt.Errorf("chunk size bounds [%d, %d] are empty", min, max)
+	}
+	c := &ChunkController{Min: min, Max: max}
+	c.current = c.clamp(initial)
+	return c, nil
+}
+
+// ChunkSize returns the chunk size to use for the next iteration.
+func (c *ChunkController) ChunkSize() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.current == 0 {
+		c.current = c.clamp(c.Min)
+	}
+	return c.current
+}
+
+// Observe feeds the step latencies of one iteration to the controller and
+// returns the resulting decision.
+func (c *ChunkController) Observe(steps []time.Duration) ChunkDecision {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.current == 0 {
+		c.current = c.clamp(c.Min)
+	}
+
+	d := ChunkDecision{
+		Iteration: len(c.decisions),
+		ChunkSize: c.current,
+		Steps:     len(steps),
+		Action:    ChunkHold,
+		Next:      c.current,
+	}
+	if len(steps) > 0 {
+		var sum float64
+		for _, s := range steps {
+			sum += float64(s)
+		}
+		mean := sum / float64(len(steps))
+		var sq float64
+		for _, s := range steps {
+			sq += (float64(s) - mean) * (float64(s) - mean)
+		}
+		std := math.Sqrt(sq / float64(len(steps)))
+		d.Mean = time.Duration(mean)
+		d.StdDev = time.Duration(std)
+		if mean > 0 {
+			d.CV = std / mean
+		}
+
+		factor := c.Factor
+		if factor < 2 {
+			factor = 2
+		}
+		switch {
+		case d.CV > c.jitterHigh():
+			d.Next = c.clamp(c.current / factor)
+		case d.CV < c.jitterLow():
+			d.Next = c.clamp(c.current * factor)
+		}
+		switch {
+		case d.Next < c.current:
+			d.Action = ChunkShrink
+		case d.Next > c.current:
+			d.Action = ChunkGrow
+		}
+	}
+
+	c.current = d.Next
+	c.decisions = append(c.decisions, d)
+	return d
+}
+
+// Decisions returns every decision made so far, oldest first.
+func (c *ChunkController) Decisions() []ChunkDecision {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]ChunkDecision(nil), c.decisions...)
+}
+
+// Metrics returns counters describing the decisions made so far.
+func (c *ChunkController) Metrics() ChunkMetrics {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m := ChunkMetrics{Iterations: len(c.decisions), ChunkSize: c.current}
+	for _, d := range c.decisions {
+		switch d.Action {
+		case ChunkShrink:
+			m.Shrinks++
+		case ChunkGrow:
+			m.Grows++
+		default:
+			m.Holds++
+		}
+	}
+	if len(c.decisions) > 0 {
+		m.LastCV = c.decisions[len(c.decisions)-1].CV
+	}
+	return m
+}
+
+func (c *ChunkController) jitterHigh() float64 {
+	if c.JitterHigh <= 0 {
+		return 0.5
+	}
+	return c.JitterHigh
+}
+
+func (c *ChunkController) jitterLow() float64 {
+	if c.JitterLow <= 0 {
+		return 0.1
+	}
+	return c.JitterLow
+}
+
+func (c *ChunkController) clamp(size int) int {
+	min := c.Min
+	if min < 1 {
+		min = 1
+	}
+	if size < min {
+		size = min
+	}
+	if c.Max > 0 && size > c.Max {
+		size = c.Max
+	}
+	return size
+}
+
+// AllReduceAdaptive is AllReduce with the chunk size picked by ctrl. The
+// vectors are reduced in segments of P chunks of ctrl.ChunkSize() elements
+// each, and the step latencies of all segments are fed back to ctrl once the
+// operation completes, so the next iteration uses the adjusted size. Like
+// AllReduce it is at-most-once, picks the topology with Auto when set,
+// checks the result when Verify is set and updates LastStats, which sums
+// the segments: data is only updated after every segment has succeeded.
+func (c *Communicator) AllReduceAdaptive(id OpID, data [][]float64, ctrl *ChunkController) error {
+	if ctrl == nil {
+		return errors.New("AllReduceAdaptive needs a chunk controller")
+	}
+	size, done, err := c.acquire(id, data)
+	if done || err != nil {
+		return err
+	}
+
+	result, steps, stats, err := c.runSegments(size, data, ctrl.ChunkSize())
+	if err := c.complete(id, data, result, stats, err); err != nil {
+		return err
+	}
+	ctrl.Observe(steps)
+	return nil
+}
+
+// runSegments reduces data in segments of size*chunkSize elements, each as
+// its own attempt so that messages of different segments never mix.
+func (c *Communicator) runSegments(size int, data [][]float64, chunkSize int) ([][]float64, []time.Duration, OpStats, error) {
+	n := vectorLen(data)
+	t := c.topologyForLen(size, n)
+	out := make([][]float64, len(data))
+	for i := range out {
+		out[i] = make([]float64, n)
+	}
+
+	var steps []time.Duration
+	var stats OpStats
+	segment := size * chunkSize
+	for start := 0; start < n; start += segment {
+		end := start + segment
+		if end > n {
+			end = n
+		}
+		inputs := make([][]float64, len(data))
+		for i := range data {
+			if len(data[i]) != n {
+				return nil, nil, OpStats{}, fmt.Errorf("rank %d: vector length %d, expected %d", i, len(data[i]), n)
+			}
+			inputs[i] = data[i][start:end]
+		}
+
+		var segStats OpStats
+		opts := c.runOptions()
+		opts.stats = &segStats
+		if opts.onChunk != nil {
+			offset := start
+			opts.onChunk = func(rank, at int, data []float64) { c.OnChunk(rank, offset+at, data) }
+		}
+		transport, release := c.transport(t)
+		result, segSteps, err := runCollective(t, transport, c.nextTag(), inputs, opts)
+		release()
+		if err != nil {
+			return nil, nil, OpStats{}, err
+		}
+		for i := range out {
+			copy(out[i][start:end], result[i])
+		}
+		steps = append(steps, segSteps...)
+		stats.merge(segStats)
+	}
+	return out, steps, stats, nil
+}
@@ -0,0 +1,185 @@
+package ringallreduce
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// jitterTransport delays every eighth send by a long pause.
+type jitterTransport struct {
+	*ChanTransport
+	sends *int64
+	pause time.Duration
+}
+
+func (j jitterTransport) Send(rank int, msg Msg) error {
+	if atomic.AddInt64(j.sends, 1)%8 == 0 {
+		time.Sleep(j.pause)
+	}
+	return j.ChanTransport.Send(rank, msg)
+}
+
+func TestChunkController_Observe(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name   string
+		steps  []time.Duration
+		action ChunkAction
+		next   int
+	}{
+		{name: "stable", steps: []time.Duration{10 * ms, 10 * ms, 10 * ms}, action: ChunkGrow, next: 16},
+		{name: "jittery", steps: []time.Duration{1 * ms, 1 * ms, 1 * ms, 20 * ms}, action: ChunkShrink, next: 4},
+		{name: "moderate", steps: []time.Duration{8 * ms, 12 * ms, 6 * ms, 14 * ms}, action: ChunkHold, next: 8},
+		{name: "no steps", steps: nil, action: ChunkHold, next: 8},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl, err := NewChunkController(8, 1, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d := ctrl.Observe(tc.steps)
+			if d.Action != tc.action || d.Next != tc.next {
+				t.Errorf("expected %v to %d, got %v to %d (cv=%.2f)", tc.action, tc.next, d.Action, d.Next, d.CV)
+			}
+			if got := ctrl.ChunkSize(); got != tc.next {
+				t.Errorf("expected chunk size %d, got %d", tc.next, got)
+			}
+		})
+	}
+}
+
+func TestChunkController_Bounds(t *testing.T) {
+	ctrl, err := NewChunkController(4, 2, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stable := []time.Duration{time.Millisecond, time.Millisecond}
+	for i := 0; i < 5; i++ {
+		ctrl.Observe(stable)
+	}
+	if got := ctrl.ChunkSize(); got != 8 {
+		t.Errorf("expected chunk size capped at 8, got %d", got)
+	}
+
+	jittery := []time.Duration{time.Millisecond, 30 * time.Millisecond}
+	for i := 0; i < 5; i++ {
+		ctrl.Observe(jittery)
+	}
+	if got := ctrl.ChunkSize(); got != 2 {
+		t.Errorf("expected chunk size floored at 2, got %d", got)
+	}
+
+	m := ctrl.Metrics()
+	if m.Iterations != 10 || m.Grows != 1 || m.Shrinks != 2 || m.Holds != 7 {
+		t.Errorf("unexpected metrics %+v", m)
+	}
+	if len(ctrl.Decisions()) != 10 {
+		t.Errorf("expected 10 decisions, got %d", len(ctrl.Decisions()))
+	}
+}
+
+func TestCommunicator_AllReduceAdaptive(t *testing.T) {
+	const p, n = 4, 50
+	run := func(transport func(topo Topology) Transport) ChunkDecision {
+		c := NewCommunicator(p)
+		c.NewTransport = transport
+		ctrl, err := NewChunkController(4, 1, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data := vectors(p, n)
+		if err := c.AllReduceAdaptive(c.NewOpID(), data, ctrl); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := float64(p * (p + 1) / 2)
+		for i := range data {
+			for j, v := range data[i] {
+				if v != expected {
+					t.Fatalf("rank=%d, elem=%d: expected %f, got %f", i, j, expected, v)
+				}
+			}
+		}
+		decisions := ctrl.Decisions()
+		if len(decisions) != 1 {
+			t.Fatalf("expected 1 decision, got %d", len(decisions))
+		}
+		// 4 segments of 16 elements, 6 ring steps on each of the 4 ranks.
+		if decisions[0].Steps != 4*6*p {
+			t.Errorf("expected %d step latencies, got %d", 4*6*p, decisions[0].Steps)
+		}
+		return decisions[0]
+	}
+
+	stable := run(func(topo Topology) Transport {
+		return delayTransport{ChanTransport: NewChanTransportSize(topo.Size(), 16), latency: 5 * time.Millisecond}
+	})
+	jittery := run(func(topo Topology) Transport {
+		return jitterTransport{ChanTransport: NewChanTransportSize(topo.Size(), 16), sends: new(int64), pause: 30 * time.Millisecond}
+	})
+
+	// Real step times are noisy on a busy machine, so only the jittery run,
+	// whose variation is far above the threshold, is held to a decision.
+	if jittery.Action != ChunkShrink {
+		t.Errorf("expected jittery links to shrink, got %v (cv=%.2f)", jittery.Action, jittery.CV)
+	}
+	if stable.CV >= jittery.CV {
+		t.Errorf("expected stable links to vary less than jittery ones, got cv %.2f and %.2f", stable.CV, jittery.CV)
+	}
+}
+
+func TestNewChunkController_EmptyBounds(t *testing.T) {
+	if _, err := NewChunkController(4, 8, 2); err == nil {
+		t.Error("expected an error for min above max")
+	}
+	if _, err := NewChunkController(4, 1, -1); err == nil {
+		t.Error("expected an error for a negative max")
+	}
+	if _, err := NewChunkController(4, 8, 0); err != nil {
+		t.Errorf("max 0 leaves the size unbounded, got %v", err)
+	}
+}
+
+func TestCommunicator_AllReduceAdaptiveLikeAllReduce(t *testing.T) {
+	const p, n = 3, 20
+	c := NewCommunicator(p)
+	c.Verify = true
+	if err := c.AllReduceAdaptive(c.NewOpID(), vectors(p, n), nil); err == nil {
+		t.Error("expected an error for a nil chunk controller")
+	}
+
+	ctrl, err := NewChunkController(2, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := vectors(p, n)
+	id := c.NewOpID()
+	if err := c.AllReduceAdaptive(id, data, ctrl); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.LastVerification(); !ok {
+		t.Error("expected the result to be verified")
+	}
+	stats, ok := c.LastStats()
+	if !ok || stats.Elements != n || stats.P != p || len(stats.PerRank) != p {
+		t.Errorf("expected stats over %d elements and %d ranks, got %+v", n, p, stats)
+	}
+	if err := c.AllReduceAdaptive(id, data, ctrl); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(ctrl.Decisions()); got != 1 {
+		t.Errorf("expected a retried op to be a no-op, got %d decisions", got)
+	}
+
+	if err := c.Remove(c.Members()...); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AllReduceAdaptive(c.NewOpID(), nil, ctrl); !errors.Is(err, ErrNoRanks) {
+		t.Errorf("got %v, want ErrNoRanks", err)
+	}
+}
@@ -0,0 +1,296 @@
+package ringallreduce
+
+import (
+	"fmt"
+)
+
+// Collective is the set of collective operations every algorithm offers.
+// inputs[i] and the i-th result belong to rank i.
+type Collective interface {
+	Name() string
+	Size() int
+	// AllReduce gives every rank the element–wise sum of all inputs.
+	AllReduce(inputs [][]float64) ([][]float64, error)
+	// ReduceScatter gives rank i block i of the sum, where the sum is cut
+	// into Size() blocks of ceil(n/Size()) elements; trailing blocks may be
+	// shorter or empty.
+	ReduceScatter(inputs [][]float64) ([][]float64, error)
+	// AllGather gives every rank the concatenation of all inputs in rank
+	// order. Inputs must share one length.
+	AllGather(inputs [][]float64) ([][]float64, error)
+	// Broadcast gives every rank a copy of the data of root.
+	Broadcast(root int, data []float64) ([][]float64, error)
+	// Reduce gives root the element–wise sum of all inputs.
+	Reduce(root int, inputs [][]float64) ([]float64, error)
+}
+
+// TopologyCollective implements Collective on top of the all–reduce
+// schedule of a Topology. ReduceScatter runs only the reduce–scatter phase
+// of the schedule, and AllGather only the allgather phase when after the
+// reduce–scatter phase every rank holds exactly one distinct chunk, as in
+// the ring, torus and fully connected topologies. Other operations, and
+// AllGather on topologies like the tree, are expressed as an all–reduce.
+type TopologyCollective struct {
+	Topology Topology
+	// NewTransport creates the transport of every operation; defaults to
+	// NewChanTransport.
+	NewTransport func(t Topology) Transport
+}
+
+// NewCollective returns the collective operations of t.
+func NewCollective(t Topology) *TopologyCollective {
+	return &TopologyCollective{Topology: t}
+}
+
+// NewRingCollective returns the collective operations of a ring of p ranks.
+func NewRingCollective(p int) *TopologyCollective { return NewCollective(NewRing(p)) }
+
+// NewTreeCollective returns the collective operations of a binary tree of p
+// ranks.
+func NewTreeCollective(p int) *TopologyCollective { return NewCollective(NewTree(p)) }
+
+// NewRecursiveDoublingCollective returns the collective operations of
+// recursive doubling over p ranks.
+func NewRecursiveDoublingCollective(p int) *TopologyCollective {
+	return NewCollective(NewRecursiveDoubling(p))
+}
+
+// NewParameterServerCollective returns the collective operations of a
+// parameter server with p-1 workers.
+func NewParameterServerCollective(p int) *TopologyCollective {
+	return NewCollective(NewParameterServer(p))
+}
+
+// NewHierarchicalCollective returns the collective operations of groups
+// groups of perGroup ranks each, e.g. hosts with several devices: data is
+// first reduced within every group, then across groups, then shared within
+// every group again. Rank g*perGroup+i is member i of group g. This is the
+// schedule of a groups x perGroup Torus.
+func NewHierarchicalCollective(groups, perGroup int) *TopologyCollective {
+	return NewCollective(NewTorus(groups, perGroup))
+}
+
+func (c *TopologyCollective) Name() string { return c.Topology.Name() }
+
+func (c *TopologyCollective) Size() int { return c.Topology.Size() }
+
+func (c *TopologyCollective) AllReduce(inputs [][]float64) ([][]float64, error) {
+	return c.run(c.Topology, inputs)
+}
+
+func (c *TopologyCollective) ReduceScatter(inputs [][]float64) ([][]float64, error) {
+	p := c.Size()
+	if p == 0 {
+		return nil, ErrNoRanks
+	}
+	if len(inputs) != p {
+		return nil, fmt.Errorf("got %d input vectors for %d ranks", len(inputs), p)
+	}
+	rs := phaseTopology{c.Topology, PhaseReduceScatter}
+	data, err := c.run(rs, inputs)
+	if err != nil {
+		return nil, err
+	}
+	owners := chunkOwners(c.Topology)
+	n := len(inputs[0])
+	block := max((n+p-1)/p, 1)
+	out := make([][]float64, p)
+	for i := range out {
+		start, end := min(i*block, n), min((i+1)*block, n)
+		out[i] = append([]float64{}, data[owners[i][0]][start:end]...)
+	}
+	return out, nil
+}
+
+func (c *TopologyCollective) AllGather(inputs [][]float64) ([][]float64, error) {
+	p := c.Size()
+	if p == 0 {
+		return nil, ErrNoRanks
+	}
+	if len(inputs) != p {
+		return nil, fmt.Errorf("got %d input vectors for %d ranks", len(inputs), p)
+	}
+	m := len(inputs[0])
+	for i, in := range inputs {
+		if len(in) != m {
+			return nil, fmt.Errorf("rank %d: vector length %d differs from %d", i, len(in), m)
+		}
+	}
+
+	// chunk[r] is where rank r's input travels.
+	chunk := make([]int, p)
+	for r := range chunk {
+		chunk[r] = r
+	}
+	t := c.Topology
+	if own, ok := ownedChunks(c.Topology); ok {
+		chunk, t = own, phaseTopology{c.Topology, PhaseAllGather}
+	}
+
+	placed := make([][]float64, p)
+	for r := range placed {
+		placed[r] = make([]float64, p*m)
+		copy(placed[r][chunk[r]*m:], inputs[r])
+	}
+	data, err := c.run(t, placed)
+	if err != nil {
+		return nil, err
+	}
+	out := make([][]float64, p)
+	for r := range out {
+		out[r] = make([]float64, 0, p*m)
+		for i := 0; i < p; i++ {
+			out[r] = append(out[r], data[r][chunk[i]*m:(chunk[i]+1)*m]...)
+		}
+	}
+	return out, nil
+}
+
+func (c *TopologyCollective) Broadcast(root int, data []float64) ([][]float64, error) {
+	p := c.Size()
+	if root < 0 || root >= p {
+		return nil, fmt.Errorf("root %d out of range [0, %d)", root, p)
+	}
+	inputs := make([][]float64, p)
+	for r := range inputs {
+		inputs[r] = make([]float64, len(data))
+	}
+	copy(inputs[root], data)
+	return c.run(c.Topology, inputs)
+}
+
+func (c *TopologyCollective) Reduce(root int, inputs [][]float64) ([]float64, error) {
+	if root < 0 || root >= c.Size() {
+		return nil, fmt.Errorf("root %d out of range [0, %d)", root, c.Size())
+	}
+	out, err := c.run(c.Topology, inputs)
+	if err != nil {
+		return nil, err
+	}
+	return out[root], nil
+}
+
+func (c *TopologyCollective) run(t Topology, inputs [][]float64) ([][]float64, error) {
+	var transport Transport
+	if c.NewTransport != nil {
+		transport = c.NewTransport(t)
+	} else {
+		transport = NewChanTransport(t)
+	}
+	defer closeTransport(transport)
+	out, _, err := runCollective(t, transport, 0, inputs, runOptions{})
+	return out, err
+}
+
+// phaseTopology runs only the steps of one phase of a topology's schedule.
+type phaseTopology struct {
+	Topology
+	phase Phase
+}
+
+func (t phaseTopology) Schedule(rank int) []Step {
+	var out []Step
+	for _, step := range t.Topology.Schedule(rank) {
+		if step.Phase == t.phase {
+			out = append(out, step)
+		}
+	}
+	return out
+}
+
+// chunkOwners replays the reduce–scatter phase of t, tracking which ranks
+// contributed to every chunk on every rank, and returns for each chunk the
+// ranks that hold it fully reduced afterwards.
+func chunkOwners(t Topology) [][]int {
+	p := t.Size()
+	// have[r][c][i] reports whether rank r's chunk c includes rank i.
+	have := make([][][]bool, p)
+	for r := range have {
+		have[r] = make([][]bool, p)
+		for c := range have[r] {
+			have[r][c] = make([]bool, p)
+			have[r][c][r] = true
+		}
+	}
+
+	rs := phaseTopology{t, PhaseReduceScatter}
+	schedules := make([][]Step, p)
+	for r := range schedules {
+		schedules[r] = rs.Schedule(r)
+	}
+	next := make([]int, p)
+	sent := make([]bool, p)
+	inFlight := map[msgKey][][]bool{}
+	for progress := true; progress; {
+		progress = false
+		for r := 0; r < p; r++ {
+			for next[r] < len(schedules[r]) {
+				step := schedules[r][next[r]]
+				if !sent[r] && step.SendTo != NoPeer {
+					for _, c := range step.SendChunks {
+						k := msgKey{r, step.SendTo, c}
+						inFlight[k] = append(inFlight[k], append([]bool(nil), have[r][c]...))
+					}
+				}
+				sent[r] = true
+				if step.RecvFrom != NoPeer {
+					ready := true
+					for _, c := range step.RecvChunks {
+						if len(inFlight[msgKey{step.RecvFrom, r, c}]) == 0 {
+							ready = false
+						}
+					}
+					if !ready {
+						break
+					}
+					for _, c := range step.RecvChunks {
+						k := msgKey{step.RecvFrom, r, c}
+						got := inFlight[k][0]
+						inFlight[k] = inFlight[k][1:]
+						for i, ok := range got {
+							if step.Reduce {
+								have[r][c][i] = have[r][c][i] || ok
+							} else {
+								have[r][c][i] = ok
+							}
+						}
+					}
+				}
+				next[r]++
+				sent[r] = false
+				progress = true
+			}
+		}
+	}
+
+	owners := make([][]int, p)
+	for c := range owners {
+		for r := 0; r < p; r++ {
+			full := true
+			for _, ok := range have[r][c] {
+				full = full && ok
+			}
+			if full {
+				owners[c] = append(owners[c], r)
+			}
+		}
+	}
+	return owners
+}
+
+// ownedChunks reports the chunk every rank holds fully reduced after the
+// reduce–scatter phase of t, if each rank holds exactly one and no two
+// ranks hold the same.
+func ownedChunks(t Topology) ([]int, bool) {
+	own := make([]int, t.Size())
+	for r := range own {
+		own[r] = -1
+	}
+	for c, ranks := range chunkOwners(t) {
+		if len(ranks) != 1 || own[ranks[0]] != -1 {
+			return nil, false
+		}
+		own[ranks[0]] = c
+	}
+	return own, true
+}
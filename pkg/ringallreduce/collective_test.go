@@ -0,0 +1,135 @@
+package ringallreduce
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCollective_Operations(t *testing.T) {
+	tests := []struct {
+		name       string
+		collective Collective
+	}{
+		{name: "ring", collective: NewRingCollective(4)},
+		{name: "tree", collective: NewTreeCollective(5)},
+		{name: "recursive doubling", collective: NewRecursiveDoublingCollective(6)},
+		{name: "hierarchical", collective: NewHierarchicalCollective(2, 3)},
+		{name: "fully-connected", collective: NewCollective(NewFullyConnected(3))},
+		{name: "single rank", collective: NewRingCollective(1)},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.collective
+			p := c.Size()
+			const n = 10
+			inputs := sequentialInputs(p, n)
+			sum := make([]float64, n)
+			for j := range sum {
+				for r := 0; r < p; r++ {
+					sum[j] += inputs[r][j]
+				}
+			}
+
+			all, err := c.AllReduce(inputs)
+			if err != nil {
+				t.Fatalf("AllReduce: %v", err)
+			}
+			for r := range all {
+				if !reflect.DeepEqual(all[r], sum) {
+					t.Errorf("AllReduce rank=%d: expected %v, got %v", r, sum, all[r])
+				}
+			}
+
+			scattered, err := c.ReduceScatter(inputs)
+			if err != nil {
+				t.Fatalf("ReduceScatter: %v", err)
+			}
+			var joined []float64
+			for _, block := range scattered {
+				joined = append(joined, block...)
+			}
+			if !reflect.DeepEqual(joined, sum) {
+				t.Errorf("ReduceScatter: expected blocks of %v, got %v", sum, scattered)
+			}
+
+			gathered, err := c.AllGather(inputs)
+			if err != nil {
+				t.Fatalf("AllGather: %v", err)
+			}
+			var concat []float64
+			for _, in := range inputs {
+				concat = append(concat, in...)
+			}
+			for r := range gathered {
+				if !reflect.DeepEqual(gathered[r], concat) {
+					t.Errorf("AllGather rank=%d: expected %v, got %v", r, concat, gathered[r])
+				}
+			}
+
+			root := p - 1
+			copies, err := c.Broadcast(root, inputs[root])
+			if err != nil {
+				t.Fatalf("Broadcast: %v", err)
+			}
+			for r := range copies {
+				if !reflect.DeepEqual(copies[r], inputs[root]) {
+					t.Errorf("Broadcast rank=%d: expected %v, got %v", r, inputs[root], copies[r])
+				}
+			}
+
+			reduced, err := c.Reduce(0, inputs)
+			if err != nil {
+				t.Fatalf("Reduce: %v", err)
+			}
+			if !reflect.DeepEqual(reduced, sum) {
+				t.Errorf("Reduce: expected %v, got %v", sum, reduced)
+			}
+		})
+	}
+}
+
+func TestOwnedChunks(t *testing.T) {
+	tests := []struct {
+		name     string
+		topology Topology
+		owned    []int
+		ok       bool
+	}{
+		{name: "ring", topology: NewRing(4), owned: []int{1, 2, 3, 0}, ok: true},
+		{name: "fully-connected", topology: NewFullyConnected(3), owned: []int{0, 1, 2}, ok: true},
+		{name: "tree", topology: NewTree(3)},
+		{name: "recursive doubling", topology: NewRecursiveDoubling(4)},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			owned, ok := ownedChunks(tc.topology)
+			if ok != tc.ok || !reflect.DeepEqual(owned, tc.owned) {
+				t.Errorf("expected %v (%v), got %v (%v)", tc.owned, tc.ok, owned, ok)
+			}
+		})
+	}
+}
+
+func TestCollective_InvalidRoot(t *testing.T) {
+	c := NewRingCollective(3)
+	if _, err := c.Broadcast(3, []float64{1}); err == nil {
+		t.Error("Broadcast: expected an error")
+	}
+	if _, err := c.Reduce(-1, vectors(3, 1)); err == nil {
+		t.Error("Reduce: expected an error")
+	}
+}
+
+func TestCollective_NoRanks(t *testing.T) {
+	c := NewRingCollective(0)
+	if _, err := c.ReduceScatter(nil); !errors.Is(err, ErrNoRanks) {
+		t.Errorf("ReduceScatter: got %v, want ErrNoRanks", err)
+	}
+	if _, err := c.AllGather(nil); !errors.Is(err, ErrNoRanks) {
+		t.Errorf("AllGather: got %v, want ErrNoRanks", err)
+	}
+}
@@ -0,0 +1,483 @@
+package ringallreduce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrOpInProgress is returned when an operation ID is invoked again while an
+// earlier invocation with the same ID is still running.
+var ErrOpInProgress = errors.New("collective operation already in progress")
+
+// ErrMembershipBusy is returned when membership changes while collectives
+// are running. Ranks may only join or leave between rounds.
+var ErrMembershipBusy = errors.New("membership can't change while collectives are running")
+
+// ErrCommunicatorClosed is returned when starting a collective on a
+// communicator that is closing or closed.
+var ErrCommunicatorClosed = errors.New("communicator closed")
+
+// OpID identifies one logical collective operation. Retries of the same
+// logical operation must reuse its OpID.
+type OpID uint64
+
+// MemberID is the stable identity of a participant. Ranks are positions in
+// the current membership and are renumbered when members join or leave,
+// while a MemberID never changes.
+type MemberID int
+
+// Communicator runs collectives over an elastic group of ranks. Members can
+// join and leave between rounds; every collective is wired for the
+// membership at the time it starts, so channels are rebuilt automatically.
+// It guarantees at-most-once application of every operation: buffers are
+// only updated once all ranks have completed an attempt, and invoking an
+// operation that has already completed is a no-op. Callers can therefore
+// retry an AllReduce after a transient transport error without
+// double-applying the reduction.
+type Communicator struct {
+	// Topology builds the wiring for a given group size; defaults to NewRing.
+	Topology func(size int) Topology
+	// NewTransport creates the transport for one attempt; defaults to
+	// NewChanTransport. Every attempt gets a fresh transport so stale
+	// messages of a failed attempt can't leak into the next one.
+	NewTransport func(t Topology) Transport
+	// Auto, if set, picks the topology of every AllReduce from the group
+	// size and the vector length, overriding Topology.
+	Auto *Auto
+	// Trace, if set, records the events of every collective.
+	Trace *Trace
+	// DeadlockTimeout, if positive, aborts a collective with a
+	// *DeadlockError once no rank has made progress for that long.
+	DeadlockTimeout time.Duration
+	// MemoryBudget, if positive, bounds the bytes every rank may hold for
+	// its buffer and queued messages; collectives that would exceed it fail
+	// with a *MemoryError.
+	MemoryBudget int64
+	// Verify, if set, recomputes every AllReduce serially from its inputs
+	// once the collective completes. Results off by more than Tolerance,
+	// relative to the serial sum, fail the operation with a
+	// *VerificationError and leave the buffers untouched.
+	Verify bool
+	// Tolerance is the relative error verification accepts; defaults to
+	// DefaultTolerance.
+	Tolerance float64
+	// OnChunk, if set, is called from the rank's goroutine as soon as a
+	// chunk of its result is final, with offset the position of data in
+	// the vector, so work on finished segments can overlap the rest of the
+	// collective. data must not be modified or retained. Chunks are
+	// delivered before the collective completes: if it then fails, they
+	// are not applied and the operation is retried as a whole.
+	OnChunk func(rank, offset int, data []float64)
+
+	mu         sync.Mutex
+	members    []MemberID // members[rank] is the member at that rank
+	nextMember MemberID
+	epoch      uint64
+	active     int // collectives currently running
+	nextOp     OpID
+	attempts   uint64
+	done       opLog
+	running    map[OpID]bool
+	verified   *Verification // of the last verified operation
+	stats      *OpStats      // of the last successful AllReduce
+
+	closing bool
+	aborted bool          // in-flight transports were torn down
+	drained chan struct{} // closed once active drops to 0 while closing
+	open    map[uint64]Transport
+	openSeq uint64
+}
+
+// NewCommunicator creates a communicator whose initial members 0..size-1
+// hold ranks 0..size-1. It panics if size is negative.
+func NewCommunicator(size int) *Communicator {
+	c := &Communicator{running: make(map[OpID]bool)}
+	if _, err := c.Add(size); err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// completedWindow bounds the completed operation IDs a Communicator
+// remembers past the first one that has not completed.
+const completedWindow = 4096
+
+// opLog records the completed operations in bounded space: every ID below
+// low, and those in recent above it. IDs handed out by NewOpID complete
+// roughly in order, so low keeps up and recent stays small. Should more
+// than completedWindow IDs complete past some that don't, the older half
+// is folded into low: an operation abandoned that far back then counts as
+// completed, and retrying it is a no-op, which keeps at-most-once.
+type opLog struct {
+	low    OpID
+	recent map[OpID]bool
+}
+
+func (l *opLog) has(id OpID) bool { return id < l.low || l.recent[id] }
+
+func (l *opLog) add(id OpID) {
+	if l.has(id) {
+		return
+	}
+	if l.recent == nil {
+		l.recent = make(map[OpID]bool)
+	}
+	l.recent[id] = true
+	if len(l.recent) > completedWindow {
+		ids := slices.Sorted(maps.Keys(l.recent))
+		for _, old := range ids[:len(ids)/2] {
+			delete(l.recent, old)
+		}
+		l.low = ids[len(ids)/2-1] + 1
+	}
+	for l.recent[l.low] {
+		delete(l.recent, l.low)
+		l.low++
+	}
+}
+
+// Size returns the number of ranks.
+func (c *Communicator) Size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.members)
+}
+
+// Epoch returns a counter incremented on every membership change.
+func (c *Communicator) Epoch() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.epoch
+}
+
+// Members returns the current members ordered by rank.
+func (c *Communicator) Members() []MemberID {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]MemberID(nil), c.members...)
+}
+
+// Rank returns the current rank of member id.
+func (c *Communicator) Rank(id MemberID) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for rank, m := range c.members {
+		if m == id {
+			return rank, true
+		}
+	}
+	return 0, false
+}
+
+// Add appends n new members, which take the highest ranks, and returns their IDs.
+func (c *Communicator) Add(n int) ([]MemberID, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n < 0 {
+		return nil, fmt.Errorf("can't add %d members", n)
+	}
+	if c.active > 0 {
+		return nil, ErrMembershipBusy
+	}
+	ids := make([]MemberID, n)
+	for i := range ids {
+		ids[i] = c.nextMember
+		c.nextMember++
+	}
+	c.members = append(c.members, ids...)
+	c.epoch++
+	return ids, nil
+}
+
+// Remove drops the given members. The remaining members keep their relative
+// order and are renumbered to ranks 0..Size()-1.
+func (c *Communicator) Remove(ids ...MemberID) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.active > 0 {
+		return ErrMembershipBusy
+	}
+	present := map[MemberID]bool{}
+	for _, m := range c.members {
+		present[m] = true
+	}
+	drop := map[MemberID]bool{}
+	for _, id := range ids {
+		if !present[id] {
+			return fmt.Errorf("member %d is not part of the communicator", id)
+		}
+		drop[id] = true
+	}
+	var kept []MemberID
+	for _, m := range c.members {
+		if !drop[m] {
+			kept = append(kept, m)
+		}
+	}
+	c.members = kept
+	c.epoch++
+	return nil
+}
+
+// AllReduceMembers is AllReduce keyed by member instead of rank. It must be
+// given exactly one vector per current member.
+func (c *Communicator) AllReduceMembers(id OpID, data map[MemberID][]float64) error {
+	members := c.Members()
+	if len(data) != len(members) {
+		return fmt.Errorf("got %d vectors for %d members", len(data), len(members))
+	}
+	byRank := make([][]float64, len(members))
+	for rank, m := range members {
+		v, ok := data[m]
+		if !ok {
+			return fmt.Errorf("missing vector of member %d", m)
+		}
+		byRank[rank] = v
+	}
+	return c.AllReduce(id, byRank)
+}
+
+// NewOpID returns a fresh operation ID.
+func (c *Communicator) NewOpID() OpID {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nextOp++
+	return c.nextOp
+}
+
+// Completed reports whether the operation id has been applied.
+func (c *Communicator) Completed(id OpID) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.done.has(id)
+}
+
+// AllReduce sums data[i] (the vector of rank i) across all ranks in place.
+// If id has already completed, AllReduce returns nil without touching data.
+func (c *Communicator) AllReduce(id OpID, data [][]float64) error {
+	size, done, err := c.acquire(id, data)
+	if done || err != nil {
+		return err
+	}
+
+	t := c.topologyForLen(size, vectorLen(data))
+	var stats OpStats
+	opts := c.runOptions()
+	opts.stats = &stats
+	transport, release := c.transport(t)
+	result, _, err := runCollective(t, transport, c.nextTag(), data, opts)
+	release()
+	return c.complete(id, data, result, stats, err)
+}
+
+// acquire runs the checks every collective of the communicator starts with
+// and marks id as running. done reports that id already completed, in which
+// case the caller returns without doing anything.
+func (c *Communicator) acquire(id OpID, data [][]float64) (size int, done bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.done.has(id) {
+		return 0, true, nil
+	}
+	if len(data) != len(c.members) {
+		return 0, false, fmt.Errorf("got %d vectors for %d ranks", len(data), len(c.members))
+	}
+	if len(c.members) == 0 {
+		return 0, false, fmt.Errorf("op %d: %w", id, ErrNoRanks)
+	}
+	if c.running[id] {
+		return 0, false, fmt.Errorf("op %d: %w", id, ErrOpInProgress)
+	}
+	if err := c.begin(); err != nil {
+		return 0, false, fmt.Errorf("op %d: %w", id, err)
+	}
+	c.running[id] = true
+	return len(c.members), false, nil
+}
+
+// complete finishes a collective started with acquire: unless err is set or
+// verification fails, result is copied into data and id is recorded as
+// completed, so that retrying it is a no-op.
+func (c *Communicator) complete(id OpID, data, result [][]float64, stats OpStats, err error) error {
+	var verification Verification
+	if err == nil && c.Verify {
+		if verification, err = VerifyAllReduce(data, result); err == nil {
+			err = verification.Check(c.tolerance())
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.running, id)
+	c.end()
+	if c.Verify && verification.Ranks != nil {
+		c.verified = &verification
+	}
+	if err != nil {
+		return fmt.Errorf("op %d: %w", id, err)
+	}
+	for i := range data {
+		copy(data[i], result[i])
+	}
+	c.done.add(id)
+	c.stats = &stats
+	return nil
+}
+
+// nextTag returns the tag of a new attempt, which keeps its messages apart
+// from those of earlier ones.
+func (c *Communicator) nextTag() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.attempts++
+	return c.attempts
+}
+
+// vectorLen returns the length of the first vector of data, or 0.
+func vectorLen(data [][]float64) int {
+	if len(data) == 0 {
+		return 0
+	}
+	return len(data[0])
+}
+
+// LastStats returns the statistics of the last successful AllReduce, and
+// whether there was one.
+func (c *Communicator) LastStats() (OpStats, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stats == nil {
+		return OpStats{}, false
+	}
+	return *c.stats, true
+}
+
+// LastVerification returns the verification of the last AllReduce checked
+// while Verify was set, and whether there was one.
+func (c *Communicator) LastVerification() (Verification, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.verified == nil {
+		return Verification{}, false
+	}
+	return *c.verified, true
+}
+
+func (c *Communicator) tolerance() float64 {
+	if c.Tolerance > 0 {
+		return c.Tolerance
+	}
+	return DefaultTolerance
+}
+
+func (c *Communicator) topologyFor(size int) Topology {
+	if c.Topology != nil {
+		return c.Topology(size)
+	}
+	return NewRing(size)
+}
+
+func (c *Communicator) runOptions() runOptions {
+	return runOptions{trace: c.Trace, deadlock: c.DeadlockTimeout, memory: c.MemoryBudget, onChunk: c.OnChunk}
+}
+
+// topologyForLen is topologyFor for an all–reduce of n elements.
+func (c *Communicator) topologyForLen(size, n int) Topology {
+	if c.Auto != nil {
+		return c.Auto.Select(size, n)
+	}
+	return c.topologyFor(size)
+}
+
+// Close shuts the communicator down. New collectives fail with
+// ErrCommunicatorClosed right away while the ones in flight drain. If ctx
+// ends first, the transports of the in-flight collectives are closed so they
+// fail fast, and Close still waits for them to return before reporting
+// ctx.Err(): once Close returns, no goroutine of the communicator is left
+// running. Transports that don't implement io.Closer can't be torn down and
+// keep Close waiting until their collective finishes.
+func (c *Communicator) Close(ctx context.Context) error {
+	c.mu.Lock()
+	c.closing = true
+	var drained chan struct{}
+	if c.active > 0 {
+		if c.drained == nil {
+			c.drained = make(chan struct{})
+		}
+		drained = c.drained
+	}
+	c.mu.Unlock()
+	if drained == nil {
+		return nil
+	}
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+	}
+	c.mu.Lock()
+	c.aborted = true
+	open := make([]Transport, 0, len(c.open))
+	for _, t := range c.open {
+		open = append(open, t)
+	}
+	c.mu.Unlock()
+	for _, t := range open {
+		closeTransport(t)
+	}
+	<-drained
+	return ctx.Err()
+}
+
+// begin registers a collective about to start. It is called with c.mu held.
+func (c *Communicator) begin() error {
+	if c.closing {
+		return ErrCommunicatorClosed
+	}
+	c.active++
+	return nil
+}
+
+// end unregisters a collective begun with begin. It is called with c.mu held.
+func (c *Communicator) end() {
+	c.active--
+	if c.active == 0 && c.drained != nil {
+		close(c.drained)
+		c.drained = nil
+	}
+}
+
+// transport creates the transport of one attempt. It is tracked until
+// release is called, which closes it, so Close can tear it down mid-flight.
+func (c *Communicator) transport(t Topology) (tr Transport, release func()) {
+	if c.NewTransport != nil {
+		tr = c.NewTransport(t)
+	} else {
+		tr = NewChanTransport(t)
+	}
+	c.mu.Lock()
+	if c.aborted {
+		c.mu.Unlock()
+		closeTransport(tr)
+		return tr, func() {}
+	}
+	if c.open == nil {
+		c.open = make(map[uint64]Transport)
+	}
+	key := c.openSeq
+	c.openSeq++
+	c.open[key] = tr
+	c.mu.Unlock()
+	return tr, func() {
+		c.mu.Lock()
+		delete(c.open, key)
+		c.mu.Unlock()
+		closeTransport(tr)
+	}
+}
@@ -0,0 +1,442 @@
+package ringallreduce
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errInjected = errors.New("injected fault")
+
+// faultyTransport fails sends at random after letting some through, which
+// leaves the attempt partially applied on the ranks that already reduced.
+type faultyTransport struct {
+	*ChanTransport
+	mu   sync.Mutex
+	rng  *rand.Rand
+	rate float64
+}
+
+func (f *faultyTransport) Send(rank int, msg Msg) error {
+	f.mu.Lock()
+	fail := f.rng.Float64() < f.rate
+	f.mu.Unlock()
+	if fail {
+		return errInjected
+	}
+	return f.ChanTransport.Send(rank, msg)
+}
+
+func vectors(p, n int) [][]float64 {
+	out := make([][]float64, p)
+	for i := range out {
+		out[i] = make([]float64, n)
+		for j := range out[i] {
+			out[i][j] = float64(i + 1)
+		}
+	}
+	return out
+}
+
+func TestCommunicator_RetryUnderFaultInjection(t *testing.T) {
+	tests := []struct {
+		name     string
+		p        int
+		rate     float64
+		topology func(int) Topology
+	}{
+		{name: "ring", p: 4, rate: 0.05, topology: func(p int) Topology { return NewRing(p) }},
+		{name: "tree", p: 6, rate: 0.05, topology: func(p int) Topology { return NewTree(p) }},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(11))
+			c := NewCommunicator(tc.p)
+			c.Topology = tc.topology
+			c.NewTransport = func(topo Topology) Transport {
+				return &faultyTransport{ChanTransport: NewChanTransport(topo), rng: rng, rate: tc.rate}
+			}
+
+			data := vectors(tc.p, 10)
+			id := c.NewOpID()
+			failures := 0
+			for attempt := 0; ; attempt++ {
+				if attempt > 1000 {
+					t.Fatal("operation never completed")
+				}
+				err := c.AllReduce(id, data)
+				if err == nil {
+					break
+				}
+				if !errors.Is(err, errInjected) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				failures++
+			}
+			if failures == 0 {
+				t.Log("no fault was injected")
+			}
+
+			// Keep retrying the completed operation: it must not be re-applied.
+			for i := 0; i < 5; i++ {
+				if err := c.AllReduce(id, data); err != nil {
+					t.Fatalf("retry of completed op: %v", err)
+				}
+			}
+
+			expected := float64(tc.p * (tc.p + 1) / 2)
+			for rank, v := range data {
+				for j, x := range v {
+					if x != expected {
+						t.Fatalf("rank=%d, elem=%d: expected %f, got %f", rank, j, expected, x)
+					}
+				}
+			}
+			if !c.Completed(id) {
+				t.Errorf("expected op %d to be completed", id)
+			}
+		})
+	}
+}
+
+func TestCommunicator_DistinctOpsApplyAgain(t *testing.T) {
+	c := NewCommunicator(3)
+	data := vectors(3, 2)
+	for i := 0; i < 2; i++ {
+		if err := c.AllReduce(c.NewOpID(), data); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	// 1+2+3 = 6 after the first op, 18 after the second.
+	want := [][]float64{{18, 18}, {18, 18}, {18, 18}}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("expected %v, got %v", want, data)
+	}
+}
+
+func TestCommunicator_OpInProgress(t *testing.T) {
+	release := make(chan struct{})
+	c := NewCommunicator(2)
+	c.NewTransport = func(topo Topology) Transport {
+		return blockingTransport{Transport: NewChanTransport(topo), release: release}
+	}
+
+	id := c.NewOpID()
+	done := make(chan error)
+	go func() { done <- c.AllReduce(id, vectors(2, 2)) }()
+
+	// Wait until the first invocation is registered as running.
+	for {
+		c.mu.Lock()
+		running := c.running[id]
+		c.mu.Unlock()
+		if running {
+			break
+		}
+	}
+	if err := c.AllReduce(id, vectors(2, 2)); !errors.Is(err, ErrOpInProgress) {
+		t.Errorf("expected ErrOpInProgress, got %v", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+type blockingTransport struct {
+	Transport
+	release chan struct{}
+}
+
+func (b blockingTransport) Send(rank int, msg Msg) error {
+	<-b.release
+	return b.Transport.Send(rank, msg)
+}
+
+func TestNode_DiscardsStaleInvocations(t *testing.T) {
+	transport := NewChanTransport(NewRing(2))
+	// A leftover message from an earlier invocation sits in rank 1's inbox.
+	transport.Send(1, Msg{From: 0, ChunkIdx: 0, Data: []float64{100}, Op: 1})
+
+	nodes := make([]*Node, 2)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	for i := range nodes {
+		nodes[i] = &Node{Rank: i, P: 2, ChunkSize: 1, Data: []float64{1, 1}, Transport: transport, Op: 2}
+		go nodes[i].Run(&wg)
+	}
+	wg.Wait()
+
+	for _, n := range nodes {
+		if !reflect.DeepEqual(n.Data, []float64{2, 2}) {
+			t.Errorf("node=%d: expected [2 2], got %v", n.Rank, n.Data)
+		}
+	}
+}
+
+func TestCommunicator_ElasticMembership(t *testing.T) {
+	c := NewCommunicator(3)
+	if got := c.Members(); !reflect.DeepEqual(got, []MemberID{0, 1, 2}) {
+		t.Fatalf("expected initial members [0 1 2], got %v", got)
+	}
+
+	// Round 1 with three members.
+	data := vectors(3, 4)
+	if err := c.AllReduce(c.NewOpID(), data); err != nil {
+		t.Fatalf("round 1: %v", err)
+	}
+	if data[0][0] != 6 {
+		t.Errorf("round 1: expected 6, got %f", data[0][0])
+	}
+
+	// Two members join, one leaves: ranks are renumbered.
+	joined, err := c.Add(2)
+	if err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if !reflect.DeepEqual(joined, []MemberID{3, 4}) {
+		t.Errorf("expected new members [3 4], got %v", joined)
+	}
+	if err := c.Remove(1); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if got := c.Members(); !reflect.DeepEqual(got, []MemberID{0, 2, 3, 4}) {
+		t.Fatalf("expected members [0 2 3 4], got %v", got)
+	}
+	if rank, ok := c.Rank(3); !ok || rank != 2 {
+		t.Errorf("expected member 3 at rank 2, got %d (%v)", rank, ok)
+	}
+	if _, ok := c.Rank(1); ok {
+		t.Errorf("removed member 1 must not have a rank")
+	}
+	if c.Epoch() != 3 {
+		t.Errorf("expected epoch 3 after three membership changes, got %d", c.Epoch())
+	}
+
+	// Round 2 keyed by member over the rebuilt four rank ring.
+	byMember := map[MemberID][]float64{0: {1}, 2: {2}, 3: {3}, 4: {4}}
+	if err := c.AllReduceMembers(c.NewOpID(), byMember); err != nil {
+		t.Fatalf("round 2: %v", err)
+	}
+	for m, v := range byMember {
+		if v[0] != 10 {
+			t.Errorf("round 2: member %d expected 10, got %f", m, v[0])
+		}
+	}
+
+	if err := c.Remove(42); err == nil {
+		t.Error("expected error removing an unknown member")
+	}
+	if err := c.AllReduce(c.NewOpID(), vectors(3, 1)); err == nil {
+		t.Error("expected error for a stale rank count")
+	}
+}
+
+func TestCommunicator_MembershipBusy(t *testing.T) {
+	release := make(chan struct{})
+	c := NewCommunicator(2)
+	c.NewTransport = func(topo Topology) Transport {
+		return blockingTransport{Transport: NewChanTransport(topo), release: release}
+	}
+
+	done := make(chan error)
+	go func() { done <- c.AllReduce(c.NewOpID(), vectors(2, 2)) }()
+	for {
+		c.mu.Lock()
+		active := c.active
+		c.mu.Unlock()
+		if active > 0 {
+			break
+		}
+	}
+	if _, err := c.Add(1); !errors.Is(err, ErrMembershipBusy) {
+		t.Errorf("expected ErrMembershipBusy from Add, got %v", err)
+	}
+	if err := c.Remove(0); !errors.Is(err, ErrMembershipBusy) {
+		t.Errorf("expected ErrMembershipBusy from Remove, got %v", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := c.Add(1); err != nil {
+		t.Errorf("expected Add to succeed between rounds, got %v", err)
+	}
+}
+
+// stallingTransport never delivers: every Send blocks until the transport is
+// closed.
+type stallingTransport struct {
+	*ChanTransport
+}
+
+func (s stallingTransport) Send(int, Msg) error {
+	<-s.closed
+	return ErrTransportClosed
+}
+
+// waitActive blocks until c runs at least one collective.
+func waitActive(c *Communicator) {
+	for {
+		c.mu.Lock()
+		active := c.active
+		c.mu.Unlock()
+		if active > 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// checkGoroutines fails the test if the number of goroutines doesn't drop
+// back to before shortly.
+func checkGoroutines(t *testing.T, before int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("leaked %d goroutines:\n%s", runtime.NumGoroutine()-before, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCommunicator_CloseDrains(t *testing.T) {
+	before := runtime.NumGoroutine()
+	release := make(chan struct{})
+	c := NewCommunicator(3)
+	c.NewTransport = func(topo Topology) Transport {
+		return blockingTransport{Transport: NewChanTransport(topo), release: release}
+	}
+
+	data := vectors(3, 4)
+	done := make(chan error)
+	go func() { done <- c.AllReduce(c.NewOpID(), data) }()
+	waitActive(c)
+
+	closed := make(chan error)
+	go func() { closed <- c.Close(context.Background()) }()
+	for {
+		c.mu.Lock()
+		closing := c.closing
+		c.mu.Unlock()
+		if closing {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// New collectives are rejected as soon as Close has begun.
+	if err := c.AllReduce(c.NewOpID(), vectors(3, 4)); !errors.Is(err, ErrCommunicatorClosed) {
+		t.Fatalf("expected ErrCommunicatorClosed, got %v", err)
+	}
+	select {
+	case err := <-closed:
+		t.Fatalf("Close returned %v before the collective drained", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("in-flight collective: %v", err)
+	}
+	if data[0][0] != 6 {
+		t.Errorf("expected the drained collective to complete, got %v", data[0])
+	}
+	if err := <-closed; err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := c.AllReduceResilient(vectors(3, 4), FaultTolerance{StepTimeout: time.Second}); !errors.Is(err, ErrCommunicatorClosed) {
+		t.Errorf("expected ErrCommunicatorClosed, got %v", err)
+	}
+	if err := c.Close(context.Background()); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+	checkGoroutines(t, before)
+}
+
+func TestCommunicator_CloseAbortsOnDeadline(t *testing.T) {
+	before := runtime.NumGoroutine()
+	c := NewCommunicator(4)
+	c.NewTransport = func(topo Topology) Transport {
+		return stallingTransport{NewChanTransport(topo)}
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- c.AllReduce(c.NewOpID(), vectors(4, 8)) }()
+	waitActive(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := c.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	// Close only returns once the aborted collective has.
+	c.mu.Lock()
+	active := c.active
+	c.mu.Unlock()
+	if active != 0 {
+		t.Fatalf("%d collectives still running after Close returned", active)
+	}
+	if err := <-done; !errors.Is(err, ErrTransportClosed) {
+		t.Errorf("expected ErrTransportClosed, got %v", err)
+	}
+	checkGoroutines(t, before)
+}
+
+func TestCommunicator_EmptyGroup(t *testing.T) {
+	c := NewCommunicator(2)
+	if _, err := c.Add(-1); err == nil {
+		t.Error("expected error adding a negative number of members")
+	}
+	if err := c.Remove(c.Members()...); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AllReduce(c.NewOpID(), nil); !errors.Is(err, ErrNoRanks) {
+		t.Errorf("AllReduce: got %v, want ErrNoRanks", err)
+	}
+}
+
+func TestCommunicator_NegativeSizePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected NewCommunicator(-1) to panic")
+		}
+	}()
+	NewCommunicator(-1)
+}
+
+func TestOpLog_Bounded(t *testing.T) {
+	var l opLog
+	for id := OpID(0); id < 3*completedWindow; id++ {
+		l.add(id)
+	}
+	if len(l.recent) != 0 || l.low != 3*completedWindow {
+		t.Errorf("in-order completion: low=%d, %d recent; want low=%d, none recent",
+			l.low, len(l.recent), 3*completedWindow)
+	}
+
+	// Leave a gap the log never sees completed.
+	var g opLog
+	for id := OpID(1); id <= 3*completedWindow; id++ {
+		g.add(id)
+		if len(g.recent) > completedWindow {
+			t.Fatalf("after %d: %d recent IDs, want at most %d", id, len(g.recent), completedWindow)
+		}
+	}
+	for id := OpID(0); id <= 3*completedWindow; id++ {
+		if !g.has(id) {
+			t.Fatalf("op %d: not recorded as completed", id)
+		}
+	}
+	if g.has(3*completedWindow + 1) {
+		t.Error("an op never completed reported as completed")
+	}
+}
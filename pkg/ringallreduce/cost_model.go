@@ -0,0 +1,90 @@
+package ringallreduce
+
+import (
+	"math"
+)
+
+// CostModel is the classic alpha-beta model of point-to-point messaging:
+// sending a message of m bytes costs Alpha + Beta*m seconds.
+type CostModel struct {
+	Alpha float64 // per-message latency in seconds
+	Beta  float64 // per-byte transfer time in seconds (inverse bandwidth)
+}
+
+// MessageCost returns the modelled time of one message of the given size.
+func (m CostModel) MessageCost(bytes int) float64 {
+	return m.Alpha + m.Beta*float64(bytes)
+}
+
+// Predict returns the modelled completion time of an all–reduce of n
+// float64 elements over topology t. It replays every rank's schedule, treating
+// the chunks a step sends as one message: a rank pays the cost of each
+// message it sends, the message arrives once its sender finished sending it,
+// and a receive waits for that arrival. The result is the time at which the
+// last rank finishes.
+func (m CostModel) Predict(t Topology, n int) float64 {
+	p := t.Size()
+	if p < 2 {
+		return 0
+	}
+	chunkBytes := 8 * max((n+p-1)/p, 1)
+
+	schedules := make([][]Step, p)
+	for r := range schedules {
+		schedules[r] = t.Schedule(r)
+	}
+	clock := make([]float64, p)
+	next := make([]int, p)  // next step of every rank
+	sent := make([]bool, p) // whether the send half of the next step is done
+	arrivals := map[msgKey][]float64{}
+
+	for progress := true; progress; {
+		progress = false
+		for r := 0; r < p; r++ {
+			for next[r] < len(schedules[r]) {
+				step := schedules[r][next[r]]
+				if !sent[r] && step.SendTo != NoPeer && len(step.SendChunks) > 0 {
+					clock[r] += m.MessageCost(chunkBytes * len(step.SendChunks))
+					for _, c := range step.SendChunks {
+						k := msgKey{r, step.SendTo, c}
+						arrivals[k] = append(arrivals[k], clock[r])
+					}
+				}
+				sent[r] = true
+				if step.RecvFrom != NoPeer && !m.received(arrivals, step, r, clock) {
+					break
+				}
+				next[r]++
+				sent[r] = false
+				progress = true
+			}
+		}
+	}
+
+	total := 0.0
+	for _, c := range clock {
+		total = math.Max(total, c)
+	}
+	return total
+}
+
+// msgKey identifies the messages carrying one chunk over one link.
+type msgKey struct {
+	from, to, chunk int
+}
+
+// received consumes the arrivals of every chunk the step expects, advancing
+// the clock of rank r, or reports false when one hasn't been sent yet.
+func (m CostModel) received(arrivals map[msgKey][]float64, step Step, r int, clock []float64) bool {
+	for _, c := range step.RecvChunks {
+		if len(arrivals[msgKey{step.RecvFrom, r, c}]) == 0 {
+			return false
+		}
+	}
+	for _, c := range step.RecvChunks {
+		k := msgKey{step.RecvFrom, r, c}
+		clock[r] = math.Max(clock[r], arrivals[k][0])
+		arrivals[k] = arrivals[k][1:]
+	}
+	return true
+}
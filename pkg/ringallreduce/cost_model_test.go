@@ -0,0 +1,42 @@
+package ringallreduce
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCostModel_Predict(t *testing.T) {
+	tests := []struct {
+		name     string
+		model    CostModel
+		topology Topology
+		n        int
+		expected float64
+	}{
+		// The ring runs 2(p-1) dependent steps of one chunk each.
+		{name: "ring latency", model: CostModel{Alpha: 1}, topology: NewRing(4), n: 4, expected: 6},
+		{name: "ring bandwidth", model: CostModel{Beta: 1}, topology: NewRing(4), n: 400, expected: 6 * 800},
+		// A 3-rank tree: both leaves send at once, then the root sends the
+		// result to each leaf in turn.
+		{name: "tree latency", model: CostModel{Alpha: 1}, topology: NewTree(3), n: 3, expected: 3},
+		{name: "tree bandwidth", model: CostModel{Beta: 1}, topology: NewTree(3), n: 3, expected: 3 * 24},
+		{name: "single rank", model: CostModel{Alpha: 1}, topology: NewRing(1), n: 10, expected: 0},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.model.Predict(tc.topology, tc.n); math.Abs(got-tc.expected) > 1e-9 {
+				t.Errorf("expected %f, got %f", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestCostModel_RingBeatsTreeForLargeVectors(t *testing.T) {
+	m := CostModel{Alpha: 1e-5, Beta: 1e-9}
+	large := 1 << 20
+	if ring, tree := m.Predict(NewRing(8), large), m.Predict(NewTree(8), large); ring >= tree {
+		t.Errorf("large vectors: expected ring (%g) cheaper than tree (%g)", ring, tree)
+	}
+}
@@ -0,0 +1,297 @@
+package ringallreduce
+
+import (
+	"fmt"
+)
+
+// DebugEvent is one action of a Debugger: a rank sending a chunk (kind
+// TraceSend) or applying a received chunk (TraceReduce or TraceCopy).
+type DebugEvent struct {
+	Index int
+	Kind  string
+	Rank  int
+	Step  int
+	Phase Phase
+	Peer  int
+	Chunk int
+}
+
+func (e DebugEvent) String() string {
+	dir := "to"
+	if e.Kind != TraceSend {
+		dir = "from"
+	}
+	return fmt.Sprintf("#%d rank %d step %d (%s): %s chunk %d %s rank %d", e.Index, e.Rank, e.Step, e.Phase, e.Kind, e.Chunk, dir, e.Peer)
+}
+
+// NodeState is the state of a rank between two events of a Debugger.
+type NodeState struct {
+	Rank     int
+	Step     int  // index of the schedule step in progress
+	Sent     int  // chunks of the step already sent
+	Received int  // chunks of the step already applied
+	Done     bool // finished the schedule
+	Data     []float64
+}
+
+// InFlight is a message sent but not yet applied by its receiver.
+type InFlight struct {
+	From  int
+	To    int
+	Chunk int
+	Data  []float64
+}
+
+// Debugger executes an all–reduce deterministically, one event at a time,
+// so every interleaving problem can be reproduced and inspected: pause at
+// any event, look at the state of every rank and every message in flight,
+// step forward or travel back. Ranks take turns round robin; a rank whose
+// next receive hasn't arrived yet is skipped.
+type Debugger struct {
+	topology Topology
+	inputs   [][]float64
+	chunk    int
+
+	schedules [][]Step
+	nodes     []NodeState
+	inFlight  []InFlight
+	history   []DebugEvent
+	next      int // rank that gets the next turn
+}
+
+// NewDebugger prepares an all–reduce of inputs over t, positioned before its
+// first event.
+func NewDebugger(t Topology, inputs [][]float64) (*Debugger, error) {
+	p := t.Size()
+	if p == 0 {
+		return nil, ErrNoRanks
+	}
+	if len(inputs) != p {
+		return nil, fmt.Errorf("got %d input vectors for %d ranks", len(inputs), p)
+	}
+	n := len(inputs[0])
+	for i, in := range inputs {
+		if len(in) != n {
+			return nil, fmt.Errorf("rank %d: vector length %d differs from %d", i, len(in), n)
+		}
+	}
+	d := &Debugger{topology: t, inputs: inputs, chunk: max((n+p-1)/p, 1)}
+	d.schedules = make([][]Step, p)
+	for r := range d.schedules {
+		d.schedules[r] = t.Schedule(r)
+	}
+	d.reset()
+	return d, nil
+}
+
+func (d *Debugger) reset() {
+	p := d.topology.Size()
+	d.nodes = make([]NodeState, p)
+	for r := range d.nodes {
+		data := make([]float64, p*d.chunk)
+		copy(data, d.inputs[r])
+		d.nodes[r] = NodeState{Rank: r, Data: data, Done: len(d.schedules[r]) == 0}
+		d.advance(r)
+	}
+	d.inFlight = nil
+	d.history = nil
+	d.next = 0
+}
+
+// Index returns the number of events executed so far.
+func (d *Debugger) Index() int { return len(d.history) }
+
+// Done reports whether every rank finished its schedule.
+func (d *Debugger) Done() bool {
+	for _, n := range d.nodes {
+		if !n.Done {
+			return false
+		}
+	}
+	return true
+}
+
+// Stuck reports whether no rank can make progress although some haven't
+// finished: the collective deadlocked.
+func (d *Debugger) Stuck() bool {
+	if d.Done() {
+		return false
+	}
+	for r := range d.nodes {
+		if d.ready(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Step executes the next event and returns it. It returns false when the
+// collective is done or stuck.
+func (d *Debugger) Step() (DebugEvent, bool) {
+	p := len(d.nodes)
+	for i := 0; i < p; i++ {
+		r := (d.next + i) % p
+		if !d.ready(r) {
+			continue
+		}
+		e := d.act(r)
+		d.next = (r + 1) % p
+		return e, true
+	}
+	return DebugEvent{}, false
+}
+
+// Run steps until the collective is done or stuck, or until stop, if not
+// nil, returns true for the event just executed. It returns the number of
+// events executed.
+func (d *Debugger) Run(stop func(DebugEvent) bool) int {
+	count := 0
+	for {
+		e, ok := d.Step()
+		if !ok {
+			return count
+		}
+		count++
+		if stop != nil && stop(e) {
+			return count
+		}
+	}
+}
+
+// Seek moves to the point right after index events, replaying from the start
+// when going backwards.
+func (d *Debugger) Seek(index int) error {
+	if index < 0 {
+		return fmt.Errorf("event index %d is negative", index)
+	}
+	if index < len(d.history) {
+		d.reset()
+	}
+	for len(d.history) < index {
+		if _, ok := d.Step(); !ok {
+			return fmt.Errorf("event index %d is past the last event %d", index, len(d.history))
+		}
+	}
+	return nil
+}
+
+// Back undoes the last event.
+func (d *Debugger) Back() error {
+	if len(d.history) == 0 {
+		return fmt.Errorf("already at the first event")
+	}
+	return d.Seek(len(d.history) - 1)
+}
+
+// Events returns the events executed so far.
+func (d *Debugger) Events() []DebugEvent {
+	return append([]DebugEvent(nil), d.history...)
+}
+
+// Nodes returns a copy of the state of every rank.
+func (d *Debugger) Nodes() []NodeState {
+	out := make([]NodeState, len(d.nodes))
+	for i, n := range d.nodes {
+		n.Data = append([]float64(nil), n.Data...)
+		out[i] = n
+	}
+	return out
+}
+
+// InFlight returns a copy of the messages in flight, oldest first.
+func (d *Debugger) InFlight() []InFlight {
+	out := make([]InFlight, len(d.inFlight))
+	for i, m := range d.inFlight {
+		m.Data = append([]float64(nil), m.Data...)
+		out[i] = m
+	}
+	return out
+}
+
+// Result returns the vector of every rank, without padding.
+func (d *Debugger) Result() [][]float64 {
+	n := len(d.inputs[0])
+	out := make([][]float64, len(d.nodes))
+	for i, node := range d.nodes {
+		out[i] = append([]float64(nil), node.Data[:n]...)
+	}
+	return out
+}
+
+// ready reports whether rank r can execute an event.
+func (d *Debugger) ready(r int) bool {
+	n := &d.nodes[r]
+	if n.Done {
+		return false
+	}
+	step := d.schedules[r][n.Step]
+	if step.SendTo != NoPeer && n.Sent < len(step.SendChunks) {
+		return true
+	}
+	if step.RecvFrom == NoPeer || n.Received == len(step.RecvChunks) {
+		return false
+	}
+	return d.find(step.RecvFrom, r, step.RecvChunks[n.Received]) >= 0
+}
+
+// act executes the next event of rank r, which must be ready.
+func (d *Debugger) act(r int) DebugEvent {
+	n := &d.nodes[r]
+	step := d.schedules[r][n.Step]
+	e := DebugEvent{Index: len(d.history), Rank: r, Step: n.Step, Phase: step.Phase}
+	if step.SendTo != NoPeer && n.Sent < len(step.SendChunks) {
+		idx := step.SendChunks[n.Sent]
+		start := idx * d.chunk
+		d.inFlight = append(d.inFlight, InFlight{From: r, To: step.SendTo, Chunk: idx,
+			Data: append([]float64(nil), n.Data[start:start+d.chunk]...)})
+		n.Sent++
+		e.Kind, e.Peer, e.Chunk = TraceSend, step.SendTo, idx
+	} else {
+		idx := step.RecvChunks[n.Received]
+		i := d.find(step.RecvFrom, r, idx)
+		m := d.inFlight[i]
+		d.inFlight = append(d.inFlight[:i], d.inFlight[i+1:]...)
+		start := idx * d.chunk
+		if step.Reduce {
+			for j, v := range m.Data {
+				n.Data[start+j] += v
+			}
+			e.Kind = TraceReduce
+		} else {
+			copy(n.Data[start:], m.Data)
+			e.Kind = TraceCopy
+		}
+		n.Received++
+		e.Peer, e.Chunk = step.RecvFrom, idx
+	}
+	d.advance(r)
+	d.history = append(d.history, e)
+	return e
+}
+
+// advance moves rank r past steps whose sends and receives are complete.
+func (d *Debugger) advance(r int) {
+	n := &d.nodes[r]
+	for !n.Done {
+		step := d.schedules[r][n.Step]
+		sent := step.SendTo == NoPeer || n.Sent == len(step.SendChunks)
+		received := step.RecvFrom == NoPeer || n.Received == len(step.RecvChunks)
+		if !sent || !received {
+			return
+		}
+		n.Step++
+		n.Sent, n.Received = 0, 0
+		n.Done = n.Step == len(d.schedules[r])
+	}
+}
+
+// find returns the position of the oldest message carrying chunk from rank
+// from to rank to, or -1.
+func (d *Debugger) find(from, to, chunk int) int {
+	for i, m := range d.inFlight {
+		if m.From == from && m.To == to && m.Chunk == chunk {
+			return i
+		}
+	}
+	return -1
+}
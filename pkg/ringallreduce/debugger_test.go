@@ -0,0 +1,119 @@
+package ringallreduce
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDebugger_RunsToCompletion(t *testing.T) {
+	tests := []struct {
+		name string
+		t    Topology
+	}{
+		{"ring", NewRing(4)},
+		{"tree", NewTree(5)},
+		{"torus", NewTorus(2, 3)},
+		{"fully connected", NewFullyConnected(3)},
+		{"recursive doubling", NewRecursiveDoubling(6)},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			p := tc.t.Size()
+			inputs := vectors(p, 7)
+			d, err := NewDebugger(tc.t, inputs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			events := d.Run(nil)
+			if !d.Done() || d.Stuck() || events != d.Index() {
+				t.Fatalf("expected completion, done=%v stuck=%v after %d events", d.Done(), d.Stuck(), events)
+			}
+			if len(d.InFlight()) != 0 {
+				t.Errorf("messages left in flight: %v", d.InFlight())
+			}
+			want := float64(p * (p + 1) / 2)
+			for r, v := range d.Result() {
+				for i, x := range v {
+					if x != want {
+						t.Fatalf("rank %d element %d: got %v, want %v", r, i, x, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDebugger_TimeTravel(t *testing.T) {
+	d, err := NewDebugger(NewRing(3), vectors(3, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first event of the round robin is rank 0 sending its first chunk.
+	e, ok := d.Step()
+	if !ok || e.Kind != TraceSend || e.Rank != 0 || e.Peer != 1 {
+		t.Fatalf("unexpected first event %v", e)
+	}
+	if got := d.InFlight(); len(got) != 1 || got[0].From != 0 || got[0].To != 1 {
+		t.Fatalf("unexpected messages in flight %+v", got)
+	}
+
+	// Stop at the first reduction and remember the state there.
+	d.Run(func(e DebugEvent) bool { return e.Kind == TraceReduce })
+	at := d.Index()
+	nodes, inFlight := d.Nodes(), d.InFlight()
+	d.Run(nil)
+	final := d.Result()
+
+	if err := d.Seek(at); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(d.Nodes(), nodes) || !reflect.DeepEqual(d.InFlight(), inFlight) {
+		t.Fatal("seeking back must restore the state of every rank and message")
+	}
+	if err := d.Back(); err != nil {
+		t.Fatal(err)
+	}
+	e, _ = d.Step()
+	if e.Kind != TraceReduce || d.Index() != at {
+		t.Errorf("stepping forward again should replay the reduction, got %v", e)
+	}
+	d.Run(nil)
+	if !reflect.DeepEqual(d.Result(), final) {
+		t.Error("replay must be deterministic")
+	}
+
+	if err := d.Seek(d.Index() + 1); err == nil {
+		t.Error("expected an error seeking past the last event")
+	}
+	d.Seek(0)
+	if err := d.Back(); err == nil {
+		t.Error("expected an error stepping back from the first event")
+	}
+}
+
+func TestDebugger_Stuck(t *testing.T) {
+	d, err := NewDebugger(skewedRing{NewRing(4)}, vectors(4, 4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Run(nil)
+	if !d.Stuck() || d.Done() {
+		t.Fatal("expected the skewed ring to get stuck")
+	}
+	for _, n := range d.Nodes() {
+		if n.Step != 1 || n.Received != 0 {
+			t.Errorf("rank %d should wait in step 1, got %+v", n.Rank, n)
+		}
+	}
+	if len(d.InFlight()) != 4 {
+		t.Errorf("expected the 4 unmatched chunks in flight, got %d", len(d.InFlight()))
+	}
+}
+
+func TestDebugger_NoRanks(t *testing.T) {
+	if _, err := NewDebugger(NewRing(0), nil); !errors.Is(err, ErrNoRanks) {
+		t.Errorf("got %v, want ErrNoRanks", err)
+	}
+}
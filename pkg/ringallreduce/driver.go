@@ -0,0 +1,147 @@
+package ringallreduce
+
+import (
+	"fmt"
+	"time"
+)
+
+// Backfill decides what a member that misses a round contributes.
+type Backfill int
+
+const (
+	// BackfillLast reuses the member's contribution to the last round it
+	// took part in, or zeros if it never took part.
+	BackfillLast Backfill = iota
+	// BackfillZero contributes zeros.
+	BackfillZero
+)
+
+func (b Backfill) String() string {
+	switch b {
+	case BackfillLast:
+		return "last"
+	case BackfillZero:
+		return "zero"
+	default:
+		return fmt.Sprintf("backfill(%d)", int(b))
+	}
+}
+
+// RoundReport describes one round of a Driver.
+type RoundReport struct {
+	Round        int
+	Participants []MemberID // members that contributed fresh data
+	Stale        []MemberID // missing members whose last contribution was reused
+	Zeroed       []MemberID // missing members that contributed zeros
+	Duration     time.Duration
+}
+
+// Participation returns the fraction of members that contributed fresh data.
+func (r RoundReport) Participation() float64 {
+	total := len(r.Participants) + len(r.Stale) + len(r.Zeroed)
+	if total == 0 {
+		return 0
+	}
+	return float64(len(r.Participants)) / float64(total)
+}
+
+// Driver runs the repeated all–reduce rounds of a training loop over a
+// Communicator. Members may miss rounds, as stragglers or federated clients
+// do; Backfill decides what is summed in their place. Members can join and
+// leave between rounds through the communicator.
+type Driver struct {
+	Comm     *Communicator
+	Backfill Backfill
+
+	last    map[MemberID][]float64
+	reports []RoundReport
+	dim     int
+}
+
+func NewDriver(comm *Communicator, backfill Backfill) *Driver {
+	return &Driver{Comm: comm, Backfill: backfill, last: make(map[MemberID][]float64), dim: -1}
+}
+
+// Round sums the contributions of one round, keyed by member, and returns the
+// sum. Members absent from contributions, or mapped to nil, missed the round.
+// All vectors of all rounds must share one length.
+func (d *Driver) Round(contributions map[MemberID][]float64) ([]float64, RoundReport, error) {
+	members := d.Comm.Members()
+	report := RoundReport{Round: len(d.reports)}
+	dim := d.dim
+	for id, v := range contributions {
+		if _, ok := d.Comm.Rank(id); !ok && v != nil {
+			return nil, report, fmt.Errorf("round %d: member %d is not part of the communicator", report.Round, id)
+		}
+		if v == nil {
+			continue
+		}
+		if dim == -1 {
+			dim = len(v)
+		}
+		if len(v) != dim {
+			return nil, report, fmt.Errorf("round %d: member %d: vector length %d differs from %d", report.Round, id, len(v), dim)
+		}
+	}
+	if dim == -1 {
+		return nil, report, fmt.Errorf("round %d: no member contributed", report.Round)
+	}
+
+	data := make([][]float64, len(members))
+	for rank, id := range members {
+		data[rank] = make([]float64, dim)
+		switch v, last := contributions[id], d.last[id]; {
+		case v != nil:
+			copy(data[rank], v)
+			report.Participants = append(report.Participants, id)
+		case d.Backfill == BackfillLast && last != nil:
+			copy(data[rank], last)
+			report.Stale = append(report.Stale, id)
+		default:
+			report.Zeroed = append(report.Zeroed, id)
+		}
+	}
+
+	began := time.Now()
+	if err := d.Comm.AllReduce(d.Comm.NewOpID(), data); err != nil {
+		return nil, report, fmt.Errorf("round %d: %w", report.Round, err)
+	}
+	report.Duration = time.Since(began)
+	d.dim = dim
+
+	for _, id := range report.Participants {
+		d.last[id] = append(d.last[id][:0], contributions[id]...)
+	}
+	d.reports = append(d.reports, report)
+	return data[0], report, nil
+}
+
+// Run calls Round for rounds rounds. contribute returns the vector of a
+// member for a round, or nil if it misses the round; apply receives every
+// sum and can stop the loop by returning an error.
+func (d *Driver) Run(rounds int, contribute func(round int, id MemberID) []float64, apply func(round int, sum []float64) error) error {
+	for i := 0; i < rounds; i++ {
+		round := len(d.reports)
+		contributions := make(map[MemberID][]float64)
+		for _, id := range d.Comm.Members() {
+			if v := contribute(round, id); v != nil {
+				contributions[id] = v
+			}
+		}
+		sum, _, err := d.Round(contributions)
+		if err != nil {
+			return err
+		}
+		if apply != nil {
+			if err := apply(round, sum); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Reports returns the reports of all rounds so far.
+func (d *Driver) Reports() []RoundReport {
+	return append([]RoundReport(nil), d.reports...)
+}
@@ -0,0 +1,100 @@
+package ringallreduce
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDriver_Backfill(t *testing.T) {
+	tests := []struct {
+		name     string
+		backfill Backfill
+		want     []float64 // sums of rounds 0 and 1
+		stale    []MemberID
+		zeroed   []MemberID
+	}{
+		// Member 2 contributed 3 in round 0 and misses round 1.
+		{"last", BackfillLast, []float64{6, 9}, []MemberID{2}, nil},
+		{"zero", BackfillZero, []float64{6, 6}, nil, []MemberID{2}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d := NewDriver(NewCommunicator(3), tc.backfill)
+			var got []float64
+			err := d.Run(2,
+				func(round int, id MemberID) []float64 {
+					if round == 1 && id == 2 {
+						return nil
+					}
+					return []float64{float64(id+1) * float64(round+1)}
+				},
+				func(round int, sum []float64) error {
+					got = append(got, sum[0])
+					return nil
+				})
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("sums %v, want %v", got, tc.want)
+			}
+			reports := d.Reports()
+			if len(reports) != 2 || reports[0].Participation() != 1 {
+				t.Fatalf("unexpected reports %+v", reports)
+			}
+			r := reports[1]
+			if !reflect.DeepEqual(r.Participants, []MemberID{0, 1}) || !reflect.DeepEqual(r.Stale, tc.stale) || !reflect.DeepEqual(r.Zeroed, tc.zeroed) {
+				t.Errorf("unexpected round report %+v", r)
+			}
+			if p := r.Participation(); p != 2.0/3 {
+				t.Errorf("participation %v, want 2/3", p)
+			}
+		})
+	}
+}
+
+func TestDriver_NeverSeenMemberIsZeroed(t *testing.T) {
+	comm := NewCommunicator(2)
+	d := NewDriver(comm, BackfillLast)
+	if _, _, err := d.Round(map[MemberID][]float64{0: {1, 1}, 1: {2, 2}}); err != nil {
+		t.Fatal(err)
+	}
+	ids, _ := comm.Add(1)
+	sum, report, err := d.Round(map[MemberID][]float64{0: {1, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Member 1 is backfilled, the new member has nothing to reuse.
+	if !reflect.DeepEqual(sum, []float64{3, 3}) {
+		t.Errorf("sum %v, want [3 3]", sum)
+	}
+	if !reflect.DeepEqual(report.Zeroed, ids) || !reflect.DeepEqual(report.Stale, []MemberID{1}) {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
+
+func TestDriver_Errors(t *testing.T) {
+	d := NewDriver(NewCommunicator(2), BackfillZero)
+	if _, _, err := d.Round(nil); err == nil {
+		t.Error("expected an error for a round without contributions")
+	}
+	if _, _, err := d.Round(map[MemberID][]float64{0: {1}, 1: {1, 2}}); err == nil {
+		t.Error("expected an error for mismatched lengths")
+	}
+	if _, _, err := d.Round(map[MemberID][]float64{7: {1}}); err == nil {
+		t.Error("expected an error for an unknown member")
+	}
+
+	stop := errors.New("stop")
+	err := d.Run(5, func(int, MemberID) []float64 { return []float64{1} }, func(round int, _ []float64) error {
+		if round == 1 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || len(d.Reports()) != 2 {
+		t.Errorf("expected Run to stop after round 1, got %v with %d reports", err, len(d.Reports()))
+	}
+}
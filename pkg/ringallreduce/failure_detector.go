@@ -0,0 +1,41 @@
+package ringallreduce
+
+import (
+	"sync"
+	"time"
+)
+
+// FailureDetector decides from heartbeats whether a rank should be
+// considered dead.
+type FailureDetector interface {
+	Heartbeat(rank int, at time.Time)
+	Suspect(rank int, now time.Time) bool
+}
+
+// TimeoutDetector suspects a rank once its last heartbeat is older than
+// Timeout. Ranks that never sent a heartbeat are not suspected.
+type TimeoutDetector struct {
+	Timeout time.Duration
+
+	mu   sync.Mutex
+	last map[int]time.Time
+}
+
+func NewTimeoutDetector(timeout time.Duration) *TimeoutDetector {
+	return &TimeoutDetector{Timeout: timeout, last: make(map[int]time.Time)}
+}
+
+func (d *TimeoutDetector) Heartbeat(rank int, at time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if at.After(d.last[rank]) {
+		d.last[rank] = at
+	}
+}
+
+func (d *TimeoutDetector) Suspect(rank int, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	last, ok := d.last[rank]
+	return ok && now.Sub(last) > d.Timeout
+}
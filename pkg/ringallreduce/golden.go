@@ -0,0 +1,156 @@
+package ringallreduce
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// goldenFS holds the reference schedules shipped with the package.
+//
+//go:embed golden/*.golden
+var goldenFS embed.FS
+
+// GoldenTopologies are the topologies whose schedules are shipped as golden
+// files and checked by VerifySchedules.
+var GoldenTopologies = []Topology{
+	NewRing(2), NewRing(3), NewRing(4), NewRing(8),
+	NewTree(1), NewTree(5), NewTree(7),
+	NewTorus(2, 3), NewTorus(3, 3), NewTorus(4, 2),
+	NewFullyConnected(3), NewFullyConnected(4),
+	NewRecursiveDoubling(4), NewRecursiveDoubling(6),
+	NewParameterServer(4),
+}
+
+// ScheduleLine is one differing line between a golden schedule and the one a
+// topology produces. Line is 1-based; an empty Want or Got means the line is
+// missing on that side.
+type ScheduleLine struct {
+	Line int
+	Want string
+	Got  string
+}
+
+// ScheduleMismatchError reports how a topology's schedule differs from its
+// golden file.
+type ScheduleMismatchError struct {
+	Name  string
+	Lines []ScheduleLine
+}
+
+func (e *ScheduleMismatchError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "schedule %s differs from golden file in %d lines", e.Name, len(e.Lines))
+	for i, l := range e.Lines {
+		if i == 5 {
+			fmt.Fprintf(&b, "\n\t...")
+			break
+		}
+		fmt.Fprintf(&b, "\n\tline %d:\n\t\twant %q\n\t\tgot  %q", l.Line, l.Want, l.Got)
+	}
+	return b.String()
+}
+
+// GoldenName returns the file name of the golden schedule of t.
+func GoldenName(t Topology) string {
+	if torus, ok := t.(Torus); ok {
+		return fmt.Sprintf("torus-%dx%d.golden", torus.Rows, torus.Cols)
+	}
+	return fmt.Sprintf("%s-p%d.golden", t.Name(), t.Size())
+}
+
+// FormatSchedule renders the schedule of every rank of t, one step per line,
+// in the format of the golden files:
+//
+//	rank 0 step 1 reduce-scatter send 1 [3] recv 3 [2] reduce
+func FormatSchedule(t Topology) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s p=%d\n", t.Name(), t.Size())
+	for r := 0; r < t.Size(); r++ {
+		for i, step := range t.Schedule(r) {
+			fmt.Fprintf(&b, "rank %d step %d %s send %s %v recv %s %v", r, i, step.Phase,
+				formatPeer(step.SendTo), formatChunks(step.SendChunks),
+				formatPeer(step.RecvFrom), formatChunks(step.RecvChunks))
+			if step.Reduce {
+				b.WriteString(" reduce")
+			}
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+func formatPeer(rank int) string {
+	if rank == NoPeer {
+		return "-"
+	}
+	return fmt.Sprint(rank)
+}
+
+func formatChunks(chunks []int) string {
+	if chunks == nil {
+		return "[]"
+	}
+	return fmt.Sprint(chunks)
+}
+
+// CompareSchedule compares the schedule of t with golden, the contents of a
+// golden file, and returns a *ScheduleMismatchError listing every differing
+// line.
+func CompareSchedule(t Topology, golden []byte) error {
+	want := strings.Split(strings.TrimRight(string(golden), "\n"), "\n")
+	got := strings.Split(strings.TrimRight(FormatSchedule(t), "\n"), "\n")
+
+	mismatch := &ScheduleMismatchError{Name: GoldenName(t)}
+	for i := 0; i < max(len(want), len(got)); i++ {
+		var w, g string
+		if i < len(want) {
+			w = want[i]
+		}
+		if i < len(got) {
+			g = got[i]
+		}
+		if w != g {
+			mismatch.Lines = append(mismatch.Lines, ScheduleLine{Line: i + 1, Want: w, Got: g})
+		}
+	}
+	if len(mismatch.Lines) > 0 {
+		return mismatch
+	}
+	return nil
+}
+
+// VerifySchedules checks the schedule of every topology in GoldenTopologies
+// against the golden files shipped with the package. Any change to the index
+// arithmetic of a topology shows up as a *ScheduleMismatchError.
+func VerifySchedules() error {
+	var errs []error
+	for _, t := range GoldenTopologies {
+		golden, err := goldenFS.ReadFile("golden/" + GoldenName(t))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := CompareSchedule(t, golden); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// VerifyGoldenDir checks the schedule of t against its golden file in dir.
+func VerifyGoldenDir(dir string, t Topology) error {
+	golden, err := os.ReadFile(filepath.Join(dir, GoldenName(t)))
+	if err != nil {
+		return err
+	}
+	return CompareSchedule(t, golden)
+}
+
+// WriteGolden writes the current schedule of t as its golden file in dir.
+func WriteGolden(dir string, t Topology) error {
+	return os.WriteFile(filepath.Join(dir, GoldenName(t)), []byte(FormatSchedule(t)), 0o644)
+}
@@ -0,0 +1,84 @@
+package ringallreduce
+
+import (
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden schedule files")
+
+func TestVerifySchedules(t *testing.T) {
+	if *update {
+		for _, topo := range GoldenTopologies {
+			if err := WriteGolden("golden", topo); err != nil {
+				t.Fatalf("write %s: %v", GoldenName(topo), err)
+			}
+		}
+		for _, topo := range GoldenTopologies {
+			if err := VerifyGoldenDir("golden", topo); err != nil {
+				t.Fatalf("verify %s: %v", GoldenName(topo), err)
+			}
+		}
+		return
+	}
+	if err := VerifySchedules(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// skewedRing is a ring whose reduce–scatter receives the wrong chunk.
+type skewedRing struct{ Ring }
+
+func (t skewedRing) Schedule(rank int) []Step {
+	steps := t.Ring.Schedule(rank)
+	steps[1].RecvChunks = []int{(steps[1].RecvChunks[0] + 1) % t.P}
+	return steps
+}
+
+func TestCompareSchedule_ReportsDiff(t *testing.T) {
+	golden, err := goldenFS.ReadFile("golden/ring-p4.golden")
+	if err != nil {
+		t.Fatalf("read golden: %v", err)
+	}
+	err = CompareSchedule(skewedRing{NewRing(4)}, golden)
+
+	var mismatch *ScheduleMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("expected a ScheduleMismatchError, got %v", err)
+	}
+	// Step 1 of each of the 4 ranks changed.
+	if len(mismatch.Lines) != 4 {
+		t.Fatalf("expected 4 differing lines, got %d: %v", len(mismatch.Lines), err)
+	}
+	if l := mismatch.Lines[0]; !strings.HasPrefix(l.Got, "rank 0 step 1") || l.Want == l.Got {
+		t.Errorf("unexpected first diff %+v", l)
+	}
+}
+
+func TestCompareSchedule_MissingLines(t *testing.T) {
+	golden := []byte(FormatSchedule(NewRing(3)))
+	err := CompareSchedule(NewRing(4), golden)
+
+	var mismatch *ScheduleMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("expected a ScheduleMismatchError, got %v", err)
+	}
+	last := mismatch.Lines[len(mismatch.Lines)-1]
+	if last.Want != "" || last.Got == "" {
+		t.Errorf("expected the extra steps of p=4 to be reported as missing, got %+v", last)
+	}
+}
+
+func TestFormatSchedule(t *testing.T) {
+	expected := `# ring p=2
+rank 0 step 0 reduce-scatter send 1 [0] recv 1 [1] reduce
+rank 0 step 1 allgather send 1 [1] recv 1 [0]
+rank 1 step 0 reduce-scatter send 0 [1] recv 0 [0] reduce
+rank 1 step 1 allgather send 0 [0] recv 0 [1]
+`
+	if got := FormatSchedule(NewRing(2)); got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+}
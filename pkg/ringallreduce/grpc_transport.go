@@ -0,0 +1,371 @@
+package ringallreduce
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// CollectivePeerServiceName is the fully qualified name of the gRPC service
+// every rank serves. Its single bidirectional-streaming method, Exchange,
+// carries PeerFrames from a dialing rank to the served rank: the first frame
+// is a hello announcing the caller's rank, which the server acknowledges with
+// its own, and every later frame carries a Msg.
+const CollectivePeerServiceName = "ringallreduce.CollectivePeer"
+
+const exchangeMethod = "/" + CollectivePeerServiceName + "/Exchange"
+
+// PeerHello identifies the sending rank of a stream.
+type PeerHello struct {
+	Rank int
+	Size int
+}
+
+// PeerFrame is the unit sent on an Exchange stream; exactly one field is set.
+type PeerFrame struct {
+	Hello *PeerHello
+	Msg   *Msg
+}
+
+// CollectivePeerServer is the server API of the CollectivePeer service.
+type CollectivePeerServer interface {
+	Exchange(stream grpc.ServerStream) error
+}
+
+// CollectivePeerServiceDesc describes the CollectivePeer service for
+// grpc.Server.RegisterService.
+var CollectivePeerServiceDesc = grpc.ServiceDesc{
+	ServiceName: CollectivePeerServiceName,
+	HandlerType: (*CollectivePeerServer)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Exchange",
+			ServerStreams: true,
+			ClientStreams: true,
+			Handler: func(srv any, stream grpc.ServerStream) error {
+				return srv.(CollectivePeerServer).Exchange(stream)
+			},
+		},
+	},
+}
+
+// RegisterCollectivePeerServer registers srv on s.
+func RegisterCollectivePeerServer(s grpc.ServiceRegistrar, srv CollectivePeerServer) {
+	s.RegisterService(&CollectivePeerServiceDesc, srv)
+}
+
+// PeerCodec encodes PeerFrames with the same binary layout as the TCP
+// transport, prefixed by a one byte frame kind. It is forced on both ends so
+// no protobuf code generation is needed.
+type PeerCodec struct{}
+
+const (
+	frameHello byte = 1
+	frameMsg   byte = 2
+)
+
+func (PeerCodec) Name() string { return "ringallreduce-frame" }
+
+func (PeerCodec) Marshal(v any) ([]byte, error) {
+	f, ok := v.(*PeerFrame)
+	if !ok {
+		return nil, fmt.Errorf("PeerCodec can't marshal %T", v)
+	}
+	switch {
+	case f.Hello != nil:
+		buf := []byte{frameHello}
+		buf = binary.BigEndian.AppendUint32(buf, uint32(f.Hello.Rank))
+		return binary.BigEndian.AppendUint32(buf, uint32(f.Hello.Size)), nil
+	case f.Msg != nil:
+		return appendMsg([]byte{frameMsg}, *f.Msg), nil
+	default:
+		return nil, errors.New("empty PeerFrame")
+	}
+}
+
+func (PeerCodec) Unmarshal(data []byte, v any) error {
+	f, ok := v.(*PeerFrame)
+	if !ok {
+		return fmt.Errorf("PeerCodec can't unmarshal into %T", v)
+	}
+	if len(data) == 0 {
+		return errors.New("empty PeerFrame")
+	}
+	switch data[0] {
+	case frameHello:
+		if len(data) != 9 {
+			return fmt.Errorf("hello frame of %d bytes", len(data))
+		}
+		f.Hello = &PeerHello{
+			Rank: int(binary.BigEndian.Uint32(data[1:])),
+			Size: int(binary.BigEndian.Uint32(data[5:])),
+		}
+		return nil
+	case frameMsg:
+		m, err := decodeMsg(data[1:])
+		if err != nil {
+			return err
+		}
+		f.Msg = &m
+		return nil
+	default:
+		return fmt.Errorf("unknown frame kind %d", data[0])
+	}
+}
+
+// GRPCTransport connects one rank to its peers over gRPC. Each rank serves
+// the CollectivePeer service on its address from the static peer list and
+// opens one Exchange stream to every rank it sends to.
+type GRPCTransport struct {
+	Rank          int           // rank of the local process
+	Peers         []string      // listen address of every rank, indexed by rank
+	RetryInterval time.Duration // pause between failed attempts to open a stream
+
+	ln     net.Listener
+	server *grpc.Server
+	inbox  chan Msg
+	errs   chan error
+	closed chan struct{}
+	once   sync.Once
+
+	dialMu sync.Mutex // serializes dials so each peer is dialed once
+	mu     sync.Mutex // guards out
+	out    map[int]*grpcPeer
+}
+
+type grpcPeer struct {
+	mu     sync.Mutex
+	conn   *grpc.ClientConn
+	stream grpc.ClientStream
+	cancel context.CancelFunc
+}
+
+// ListenGRPC serves the CollectivePeer service on peers[rank].
+func ListenGRPC(rank int, peers []string) (*GRPCTransport, error) {
+	if rank < 0 || rank >= len(peers) {
+		return nil, fmt.Errorf("rank %d outside peer list of size %d", rank, len(peers))
+	}
+	ln, err := net.Listen("tcp", peers[rank])
+	if err != nil {
+		return nil, err
+	}
+	return NewGRPCTransport(rank, ln, peers), nil
+}
+
+// NewGRPCTransport serves the CollectivePeer service for rank on ln.
+func NewGRPCTransport(rank int, ln net.Listener, peers []string) *GRPCTransport {
+	t := &GRPCTransport{
+		Rank:          rank,
+		Peers:         peers,
+		RetryInterval: 50 * time.Millisecond,
+		ln:            ln,
+		server:        grpc.NewServer(grpc.ForceServerCodec(PeerCodec{})),
+		inbox:         make(chan Msg, 1024),
+		errs:          make(chan error, 1),
+		closed:        make(chan struct{}),
+		out:           make(map[int]*grpcPeer),
+	}
+	RegisterCollectivePeerServer(t.server, t)
+	go t.server.Serve(ln)
+	return t
+}
+
+// Addr returns the address the transport listens on.
+func (t *GRPCTransport) Addr() net.Addr {
+	return t.ln.Addr()
+}
+
+// Exchange implements CollectivePeerServer.
+func (t *GRPCTransport) Exchange(stream grpc.ServerStream) error {
+	var hello PeerFrame
+	if err := stream.RecvMsg(&hello); err != nil {
+		return err
+	}
+	if hello.Hello == nil || hello.Hello.Size != len(t.Peers) || hello.Hello.Rank < 0 || hello.Hello.Rank >= len(t.Peers) {
+		return fmt.Errorf("rank %d: invalid hello %+v", t.Rank, hello.Hello)
+	}
+	from := hello.Hello.Rank
+	if err := stream.SendMsg(&PeerFrame{Hello: &PeerHello{Rank: t.Rank, Size: len(t.Peers)}}); err != nil {
+		return err
+	}
+
+	for {
+		var f PeerFrame
+		if err := stream.RecvMsg(&f); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			select {
+			case <-t.closed:
+			default:
+				t.fail(fmt.Errorf("receive from rank %d: %w", from, err))
+			}
+			return err
+		}
+		if f.Msg == nil {
+			continue
+		}
+		select {
+		case t.inbox <- *f.Msg:
+		case <-t.closed:
+			return ErrTransportClosed
+		}
+	}
+}
+
+// Connect opens a stream to every listed rank, retrying until it answers or
+// ctx is done.
+func (t *GRPCTransport) Connect(ctx context.Context, ranks []int) error {
+	for _, r := range ranks {
+		if _, err := t.peer(ctx, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConnectTopology opens a stream to every rank the local schedule of
+// topology sends to.
+func (t *GRPCTransport) ConnectTopology(ctx context.Context, topology Topology) error {
+	return t.Connect(ctx, sendTargets(topology, t.Rank))
+}
+
+func (t *GRPCTransport) Send(rank int, msg Msg) error {
+	p, err := t.peer(context.Background(), rank)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.stream.SendMsg(&PeerFrame{Msg: &msg}); err != nil {
+		return fmt.Errorf("send to rank %d: %w", rank, err)
+	}
+	return nil
+}
+
+func (t *GRPCTransport) Recv(rank int) (Msg, error) {
+	if rank != t.Rank {
+		return Msg{}, fmt.Errorf("rank %d can't receive for rank %d", t.Rank, rank)
+	}
+	select {
+	case m := <-t.inbox:
+		return m, nil
+	case err := <-t.errs:
+		return Msg{}, err
+	case <-t.closed:
+		return Msg{}, ErrTransportClosed
+	}
+}
+
+// Close ends every outgoing stream and stops the server.
+func (t *GRPCTransport) Close() error {
+	t.once.Do(func() {
+		close(t.closed)
+		t.mu.Lock()
+		for _, p := range t.out {
+			p.mu.Lock()
+			p.stream.CloseSend()
+			p.mu.Unlock()
+			p.cancel()
+			p.conn.Close()
+		}
+		t.mu.Unlock()
+		t.server.Stop()
+	})
+	return nil
+}
+
+func (t *GRPCTransport) outgoing(rank int) *grpcPeer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.out[rank]
+}
+
+// peer returns the outgoing stream to rank, opening it if needed.
+func (t *GRPCTransport) peer(ctx context.Context, rank int) (*grpcPeer, error) {
+	if rank < 0 || rank >= len(t.Peers) {
+		return nil, fmt.Errorf("send to unknown rank %d", rank)
+	}
+	if p := t.outgoing(rank); p != nil {
+		return p, nil
+	}
+	t.dialMu.Lock()
+	defer t.dialMu.Unlock()
+	if p := t.outgoing(rank); p != nil {
+		return p, nil
+	}
+
+	conn, err := grpc.NewClient(t.Peers[rank],
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(PeerCodec{})),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("dial rank %d at %s: %w", rank, t.Peers[rank], err)
+	}
+	for {
+		select {
+		case <-t.closed:
+			conn.Close()
+			return nil, ErrTransportClosed
+		default:
+		}
+		p, err := t.open(ctx, conn, rank)
+		if err == nil {
+			t.mu.Lock()
+			t.out[rank] = p
+			t.mu.Unlock()
+			return p, nil
+		}
+		select {
+		case <-ctx.Done():
+			conn.Close()
+			return nil, fmt.Errorf("dial rank %d at %s: %w", rank, t.Peers[rank], errors.Join(ctx.Err(), err))
+		case <-t.closed:
+			conn.Close()
+			return nil, ErrTransportClosed
+		case <-time.After(t.RetryInterval):
+		}
+	}
+}
+
+// open starts an Exchange stream on conn and performs the hello exchange.
+func (t *GRPCTransport) open(ctx context.Context, conn *grpc.ClientConn, rank int) (*grpcPeer, error) {
+	// The stream outlives ctx, which only bounds connection establishment.
+	streamCtx, cancel := context.WithCancel(context.Background())
+	stop := context.AfterFunc(ctx, cancel)
+	stream, err := conn.NewStream(streamCtx, &CollectivePeerServiceDesc.Streams[0], exchangeMethod)
+	if err == nil {
+		err = stream.SendMsg(&PeerFrame{Hello: &PeerHello{Rank: t.Rank, Size: len(t.Peers)}})
+	}
+	var ack PeerFrame
+	if err == nil {
+		err = stream.RecvMsg(&ack)
+	}
+	if !stop() && err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if ack.Hello == nil || ack.Hello.Rank != rank {
+		cancel()
+		return nil, fmt.Errorf("handshake: dialed rank %d at %s but got %+v", rank, t.Peers[rank], ack.Hello)
+	}
+	return &grpcPeer{conn: conn, stream: stream, cancel: cancel}, nil
+}
+
+func (t *GRPCTransport) fail(err error) {
+	select {
+	case t.errs <- err:
+	default:
+	}
+}
@@ -0,0 +1,146 @@
+package ringallreduce
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGRPCTransport_AllReduce(t *testing.T) {
+	tests := []struct {
+		name      string
+		topology  Topology
+		chunkSize int
+	}{
+		{name: "ring p=3", topology: NewRing(3), chunkSize: 2},
+		{name: "fully-connected p=4", topology: NewFullyConnected(4), chunkSize: 1},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			p := tc.topology.Size()
+			listeners := make([]net.Listener, p)
+			peers := make([]string, p)
+			for i := range listeners {
+				ln, err := net.Listen("tcp", "127.0.0.1:0")
+				if err != nil {
+					t.Fatalf("listen: %v", err)
+				}
+				listeners[i] = ln
+				peers[i] = ln.Addr().String()
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			nodes := make([]*Node, p)
+			var wg sync.WaitGroup
+			wg.Add(p)
+			for i := 0; i < p; i++ {
+				transport := NewGRPCTransport(i, listeners[i], peers)
+				defer transport.Close()
+
+				data := make([]float64, p*tc.chunkSize)
+				for j := range data {
+					data[j] = float64(i + 1)
+				}
+				nodes[i] = &Node{Rank: i, P: p, ChunkSize: tc.chunkSize, Data: data, Topology: tc.topology, Transport: transport}
+				go func(n *Node, transport *GRPCTransport) {
+					defer wg.Done()
+					if err := transport.ConnectTopology(ctx, tc.topology); err != nil {
+						n.Err = err
+						return
+					}
+					n.Err = n.AllReduce()
+				}(nodes[i], transport)
+			}
+			wg.Wait()
+
+			expected := float64(p * (p + 1) / 2)
+			for _, n := range nodes {
+				if n.Err != nil {
+					t.Fatalf("node=%d: unexpected error: %v", n.Rank, n.Err)
+				}
+				for j, v := range n.Data {
+					if v != expected {
+						t.Errorf("node=%d, elem=%d: expected %f, got %f", n.Rank, j, expected, v)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGRPCTransport_ConnectRetriesUntilPeerIsUp(t *testing.T) {
+	ln0, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	// Reserve an address for rank 1 but start serving it only later.
+	ln1, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	peers := []string{ln0.Addr().String(), ln1.Addr().String()}
+	ln1.Close()
+
+	t0 := NewGRPCTransport(0, ln0, peers)
+	defer t0.Close()
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		t1, err := ListenGRPC(1, peers)
+		if err != nil {
+			return
+		}
+		t.Cleanup(func() { t1.Close() })
+		if m, err := t1.Recv(1); err == nil {
+			t1.Send(0, m)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := t0.Connect(ctx, []int{1}); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	want := Msg{From: 0, ChunkIdx: 4, Data: []float64{1, 2}}
+	if err := t0.Send(1, want); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	got, err := t0.Recv(0)
+	if err != nil {
+		t.Fatalf("recv: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v echoed back, got %+v", want, got)
+	}
+}
+
+func TestPeerCodec_RoundTrip(t *testing.T) {
+	frames := []*PeerFrame{
+		{Hello: &PeerHello{Rank: 2, Size: 8}},
+		{Msg: &Msg{From: 1, ChunkIdx: 3, Data: []float64{0.25, -4}}},
+	}
+	codec := PeerCodec{}
+	for _, want := range frames {
+		b, err := codec.Marshal(want)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		var got PeerFrame
+		if err := codec.Unmarshal(b, &got); err != nil {
+			t.Fatalf("Unmarshal: %v", err)
+		}
+		if !reflect.DeepEqual(&got, want) {
+			t.Errorf("expected %+v, got %+v", want, got)
+		}
+	}
+	if _, err := codec.Marshal(&PeerFrame{}); err == nil {
+		t.Error("expected error marshaling an empty frame")
+	}
+}
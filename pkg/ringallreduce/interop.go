@@ -0,0 +1,528 @@
+package ringallreduce
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+)
+
+// The interop protocol lets workers written in other languages, such as
+// Python with NumPy, take part in a collective as ranks. A worker talks to
+// the Go host over one byte stream, its stdin and stdout or a TCP
+// connection, in frames of a big-endian uint32 length followed by a type
+// byte and the payload:
+//
+//	hello   worker → host  version uint16, rank uint32
+//	welcome host → worker  version uint16, rank uint32, size uint32,
+//	                       chunk size uint32, op uint64, topology name
+//	                       (uint16 length, UTF-8), step count uint32, steps
+//	data    both ways      destination rank uint32, message
+//	bye     both ways      empty; the sender is done
+//	error   both ways      UTF-8 text describing why the sender gave up
+//
+// A step is its phase (uint8), the rank it sends to (int32, -1 for none),
+// the sent chunk count (uint32) and indices (uint32 each), the rank it
+// receives from, the received chunk count and indices, and a reduce flag
+// (uint8). A message is encoded as on TCPTransport: sender rank, chunk index
+// (uint32 each), op, sequence number (uint64 each), flags (uint8), element
+// count (uint32) and the IEEE 754 elements.
+//
+// After the welcome the worker runs the steps it was given: it sends a data
+// frame for every chunk of a step and waits for data frames carrying the
+// chunks it receives, exactly like Node.AllReduce. RunWorker is the
+// reference implementation.
+
+// InteropVersion is the version of the interop protocol spoken by
+// ExternalTransport and RunWorker.
+const InteropVersion = 1
+
+// Interop frame types.
+const (
+	FrameHello byte = 1 + iota
+	FrameWelcome
+	FrameData
+	FrameBye
+	FrameError
+)
+
+// ErrInteropVersion is returned when a peer speaks another protocol version.
+var ErrInteropVersion = errors.New("unsupported interop protocol version")
+
+// WorkerError is an error frame received from a peer.
+type WorkerError struct {
+	Rank    int
+	Message string
+}
+
+func (e *WorkerError) Error() string {
+	return fmt.Sprintf("worker %d: %s", e.Rank, e.Message)
+}
+
+// WorkerConfig is the content of a welcome frame: everything a worker needs
+// to run its part of the collective.
+type WorkerConfig struct {
+	Rank      int
+	Size      int
+	ChunkSize int
+	Op        uint64
+	Topology  string
+	Schedule  []Step
+}
+
+// ExternalTransport connects external workers to ranks running in this
+// process. Every message goes through Inner; the messages of a rank attached
+// with Attach are then forwarded over its stream, so messages sent before
+// the worker attaches wait in Inner. If a worker fails, Inner is closed so
+// no local rank keeps waiting, and receives report the worker's error.
+type ExternalTransport struct {
+	Inner     Transport
+	Topology  Topology
+	ChunkSize int
+	Op        uint64
+
+	mu      sync.Mutex
+	workers map[int]*externalWorker
+	err     error
+	closed  chan struct{}
+	once    sync.Once
+}
+
+type externalWorker struct {
+	mu sync.Mutex // serializes frames
+	r  io.Reader
+	w  io.Writer
+}
+
+func NewExternalTransport(inner Transport, t Topology, chunkSize int) *ExternalTransport {
+	return &ExternalTransport{
+		Inner:     inner,
+		Topology:  t,
+		ChunkSize: chunkSize,
+		workers:   make(map[int]*externalWorker),
+		closed:    make(chan struct{}),
+	}
+}
+
+// Attach performs the handshake with the worker for rank on r and w and
+// starts forwarding the messages it sends.
+func (x *ExternalTransport) Attach(rank int, r io.Reader, w io.Writer) error {
+	if rank < 0 || rank >= x.Topology.Size() {
+		return fmt.Errorf("rank %d out of range [0, %d)", rank, x.Topology.Size())
+	}
+	typ, payload, err := readInteropFrame(r)
+	if err != nil {
+		return fmt.Errorf("handshake with worker %d: %w", rank, err)
+	}
+	if typ != FrameHello || len(payload) != 6 {
+		return fmt.Errorf("handshake with worker %d: unexpected frame type %d", rank, typ)
+	}
+	if v := binary.BigEndian.Uint16(payload); v != InteropVersion {
+		writeInteropFrame(w, FrameError, []byte(fmt.Sprintf("protocol version %d, want %d", v, InteropVersion)))
+		return fmt.Errorf("worker %d: %w %d", rank, ErrInteropVersion, v)
+	}
+	if got := int(binary.BigEndian.Uint32(payload[2:])); got != rank {
+		writeInteropFrame(w, FrameError, []byte(fmt.Sprintf("expected rank %d", rank)))
+		return fmt.Errorf("handshake: worker for rank %d introduced itself as rank %d", rank, got)
+	}
+	cfg := WorkerConfig{
+		Rank:      rank,
+		Size:      x.Topology.Size(),
+		ChunkSize: x.ChunkSize,
+		Op:        x.Op,
+		Topology:  x.Topology.Name(),
+		Schedule:  x.Topology.Schedule(rank),
+	}
+	if err := writeInteropFrame(w, FrameWelcome, appendWelcome(nil, cfg)); err != nil {
+		return fmt.Errorf("handshake with worker %d: %w", rank, err)
+	}
+
+	x.mu.Lock()
+	if x.workers[rank] != nil {
+		x.mu.Unlock()
+		return fmt.Errorf("worker %d already attached", rank)
+	}
+	wk := &externalWorker{r: r, w: w}
+	x.workers[rank] = wk
+	x.mu.Unlock()
+	go x.serve(rank, r)
+	go x.forward(rank, wk)
+	return nil
+}
+
+// AttachCommand starts cmd as the worker for rank, speaking the protocol
+// over its stdin and stdout.
+func (x *ExternalTransport) AttachCommand(rank int, cmd *exec.Cmd) error {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	if err := x.Attach(rank, stdout, stdin); err != nil {
+		stdin.Close()
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+	return nil
+}
+
+func (x *ExternalTransport) Send(rank int, msg Msg) error {
+	return x.Inner.Send(rank, msg)
+}
+
+func (x *ExternalTransport) Recv(rank int) (Msg, error) {
+	m, err := x.Inner.Recv(rank)
+	if err != nil {
+		if werr := x.Err(); werr != nil {
+			return Msg{}, werr
+		}
+	}
+	return m, err
+}
+
+// Err returns the error that made a worker fail, if any.
+func (x *ExternalTransport) Err() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.err
+}
+
+// Close closes Inner, says goodbye to every worker and closes the streams
+// that support it.
+func (x *ExternalTransport) Close() error {
+	x.once.Do(func() {
+		close(x.closed)
+		closeTransport(x.Inner)
+		x.mu.Lock()
+		workers := make([]*externalWorker, 0, len(x.workers))
+		for _, wk := range x.workers {
+			workers = append(workers, wk)
+		}
+		x.mu.Unlock()
+		for _, wk := range workers {
+			wk.mu.Lock()
+			writeInteropFrame(wk.w, FrameBye, nil)
+			wk.mu.Unlock()
+			for _, s := range []any{wk.w, wk.r} {
+				if c, ok := s.(io.Closer); ok {
+					c.Close()
+				}
+			}
+		}
+	})
+	return nil
+}
+
+// forward writes the messages for rank to its worker until Inner closes.
+func (x *ExternalTransport) forward(rank int, wk *externalWorker) {
+	for {
+		m, err := x.Inner.Recv(rank)
+		if err != nil {
+			return
+		}
+		buf := binary.BigEndian.AppendUint32(nil, uint32(rank))
+		wk.mu.Lock()
+		err = writeInteropFrame(wk.w, FrameData, appendMsg(buf, m))
+		wk.mu.Unlock()
+		if err != nil {
+			x.fail(fmt.Errorf("send to worker %d: %w", rank, err))
+			return
+		}
+	}
+}
+
+// serve forwards the messages of the worker for rank until it says goodbye.
+func (x *ExternalTransport) serve(rank int, r io.Reader) {
+	for {
+		typ, payload, err := readInteropFrame(r)
+		if err != nil {
+			select {
+			case <-x.closed:
+			default:
+				x.fail(fmt.Errorf("worker %d: %w", rank, err))
+			}
+			return
+		}
+		switch typ {
+		case FrameData:
+			to, m, err := decodeData(payload)
+			if err == nil && m.From != rank {
+				err = fmt.Errorf("message claims to come from rank %d", m.From)
+			}
+			if err == nil {
+				err = x.Send(to, m)
+			}
+			if err != nil {
+				x.fail(fmt.Errorf("worker %d: %w", rank, err))
+				return
+			}
+		case FrameBye:
+			return
+		case FrameError:
+			x.fail(&WorkerError{Rank: rank, Message: string(payload)})
+			return
+		default:
+			x.fail(fmt.Errorf("worker %d: unexpected frame type %d", rank, typ))
+			return
+		}
+	}
+}
+
+func (x *ExternalTransport) fail(err error) {
+	x.mu.Lock()
+	if x.err == nil {
+		x.err = err
+	}
+	x.mu.Unlock()
+	closeTransport(x.Inner)
+}
+
+// RunWorker is the worker side of the interop protocol: it introduces itself
+// as rank on r and w, runs the schedule it is sent with data as its vector
+// and returns the reduced vector. It is the reference for implementations in
+// other languages.
+func RunWorker(rank int, r io.Reader, w io.Writer, data []float64) ([]float64, error) {
+	hello := binary.BigEndian.AppendUint16(nil, InteropVersion)
+	hello = binary.BigEndian.AppendUint32(hello, uint32(rank))
+	if err := writeInteropFrame(w, FrameHello, hello); err != nil {
+		return nil, err
+	}
+	typ, payload, err := readInteropFrame(r)
+	if err != nil {
+		return nil, err
+	}
+	switch typ {
+	case FrameWelcome:
+	case FrameError:
+		return nil, &WorkerError{Rank: -1, Message: string(payload)}
+	default:
+		return nil, fmt.Errorf("unexpected frame type %d", typ)
+	}
+	cfg, err := decodeWelcome(payload)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Rank != rank {
+		return nil, fmt.Errorf("welcomed as rank %d, want %d", cfg.Rank, rank)
+	}
+	if len(data) > cfg.Size*cfg.ChunkSize {
+		err := fmt.Errorf("vector of %d elements exceeds %d chunks of %d", len(data), cfg.Size, cfg.ChunkSize)
+		writeInteropFrame(w, FrameError, []byte(err.Error()))
+		return nil, err
+	}
+
+	buf := make([]float64, cfg.Size*cfg.ChunkSize)
+	copy(buf, data)
+	node := &Node{
+		Rank:      rank,
+		P:         cfg.Size,
+		ChunkSize: cfg.ChunkSize,
+		Data:      buf,
+		Op:        cfg.Op,
+		Topology:  workerTopology{cfg},
+		Transport: &workerTransport{r: r, w: w},
+	}
+	if err := node.AllReduce(); err != nil {
+		writeInteropFrame(w, FrameError, []byte(err.Error()))
+		return nil, err
+	}
+	if err := writeInteropFrame(w, FrameBye, nil); err != nil {
+		return nil, err
+	}
+	return buf[:len(data)], nil
+}
+
+// workerTopology is the schedule a worker was sent. Only its own rank is
+// known.
+type workerTopology struct{ cfg WorkerConfig }
+
+func (t workerTopology) Name() string { return t.cfg.Topology }
+
+func (t workerTopology) Size() int { return t.cfg.Size }
+
+func (t workerTopology) Neighbors(rank int) []int {
+	if rank != t.cfg.Rank {
+		return nil
+	}
+	seen := map[int]bool{}
+	var out []int
+	for _, step := range t.cfg.Schedule {
+		for _, peer := range []int{step.SendTo, step.RecvFrom} {
+			if peer != NoPeer && !seen[peer] {
+				seen[peer] = true
+				out = append(out, peer)
+			}
+		}
+	}
+	return out
+}
+
+func (t workerTopology) Schedule(rank int) []Step {
+	if rank != t.cfg.Rank {
+		return nil
+	}
+	return t.cfg.Schedule
+}
+
+// workerTransport is the worker end of the stream to the host.
+type workerTransport struct {
+	r io.Reader
+	w io.Writer
+}
+
+func (t *workerTransport) Send(rank int, msg Msg) error {
+	buf := binary.BigEndian.AppendUint32(nil, uint32(rank))
+	return writeInteropFrame(t.w, FrameData, appendMsg(buf, msg))
+}
+
+func (t *workerTransport) Recv(int) (Msg, error) {
+	typ, payload, err := readInteropFrame(t.r)
+	if err != nil {
+		return Msg{}, err
+	}
+	switch typ {
+	case FrameData:
+		_, m, err := decodeData(payload)
+		return m, err
+	case FrameBye:
+		return Msg{}, ErrTransportClosed
+	case FrameError:
+		return Msg{}, &WorkerError{Rank: -1, Message: string(payload)}
+	default:
+		return Msg{}, fmt.Errorf("unexpected frame type %d", typ)
+	}
+}
+
+// writeInteropFrame writes one frame in a single Write.
+func writeInteropFrame(w io.Writer, typ byte, payload []byte) error {
+	buf := make([]byte, 0, 5+len(payload))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(1+len(payload)))
+	buf = append(buf, typ)
+	_, err := w.Write(append(buf, payload...))
+	return err
+}
+
+func readInteropFrame(r io.Reader) (byte, []byte, error) {
+	var prefix [4]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return 0, nil, err
+	}
+	size := binary.BigEndian.Uint32(prefix[:])
+	if size < 1 || size > maxFrameSize {
+		return 0, nil, fmt.Errorf("invalid frame size %d", size)
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return 0, nil, err
+	}
+	return buf[0], buf[1:], nil
+}
+
+func decodeData(payload []byte) (int, Msg, error) {
+	if len(payload) < 4 {
+		return 0, Msg{}, fmt.Errorf("data frame of %d bytes is too short", len(payload))
+	}
+	m, err := decodeMsg(payload[4:])
+	return int(binary.BigEndian.Uint32(payload)), m, err
+}
+
+func appendWelcome(buf []byte, cfg WorkerConfig) []byte {
+	buf = binary.BigEndian.AppendUint16(buf, InteropVersion)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(cfg.Rank))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(cfg.Size))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(cfg.ChunkSize))
+	buf = binary.BigEndian.AppendUint64(buf, cfg.Op)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(cfg.Topology)))
+	buf = append(buf, cfg.Topology...)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(cfg.Schedule)))
+	appendChunks := func(buf []byte, peer int, chunks []int) []byte {
+		buf = binary.BigEndian.AppendUint32(buf, uint32(int32(peer)))
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(chunks)))
+		for _, c := range chunks {
+			buf = binary.BigEndian.AppendUint32(buf, uint32(c))
+		}
+		return buf
+	}
+	for _, step := range cfg.Schedule {
+		buf = append(buf, byte(step.Phase))
+		buf = appendChunks(buf, step.SendTo, step.SendChunks)
+		buf = appendChunks(buf, step.RecvFrom, step.RecvChunks)
+		var reduce byte
+		if step.Reduce {
+			reduce = 1
+		}
+		buf = append(buf, reduce)
+	}
+	return buf
+}
+
+func decodeWelcome(buf []byte) (WorkerConfig, error) {
+	var cfg WorkerConfig
+	short := fmt.Errorf("welcome frame of %d bytes is truncated", len(buf))
+	if len(buf) < 24 {
+		return cfg, short
+	}
+	if v := binary.BigEndian.Uint16(buf); v != InteropVersion {
+		return cfg, fmt.Errorf("%w %d", ErrInteropVersion, v)
+	}
+	cfg.Rank = int(binary.BigEndian.Uint32(buf[2:]))
+	cfg.Size = int(binary.BigEndian.Uint32(buf[6:]))
+	cfg.ChunkSize = int(binary.BigEndian.Uint32(buf[10:]))
+	cfg.Op = binary.BigEndian.Uint64(buf[14:])
+	n := int(binary.BigEndian.Uint16(buf[22:]))
+	buf = buf[24:]
+	if len(buf) < n+4 {
+		return cfg, short
+	}
+	cfg.Topology = string(buf[:n])
+	steps := binary.BigEndian.Uint32(buf[n:])
+	buf = buf[n+4:]
+
+	readChunks := func() (int, []int, bool) {
+		if len(buf) < 8 {
+			return 0, nil, false
+		}
+		peer := int(int32(binary.BigEndian.Uint32(buf)))
+		count := binary.BigEndian.Uint32(buf[4:])
+		buf = buf[8:]
+		if uint64(len(buf)) < 4*uint64(count) {
+			return 0, nil, false
+		}
+		var chunks []int
+		for i := uint32(0); i < count; i++ {
+			chunks = append(chunks, int(binary.BigEndian.Uint32(buf[4*i:])))
+		}
+		buf = buf[4*count:]
+		return peer, chunks, true
+	}
+	for i := uint32(0); i < steps; i++ {
+		if len(buf) < 1 {
+			return cfg, short
+		}
+		step := Step{Phase: Phase(buf[0])}
+		buf = buf[1:]
+		var ok bool
+		if step.SendTo, step.SendChunks, ok = readChunks(); !ok {
+			return cfg, short
+		}
+		if step.RecvFrom, step.RecvChunks, ok = readChunks(); !ok {
+			return cfg, short
+		}
+		if len(buf) < 1 {
+			return cfg, short
+		}
+		step.Reduce = buf[0] == 1
+		buf = buf[1:]
+		cfg.Schedule = append(cfg.Schedule, step)
+	}
+	return cfg, nil
+}
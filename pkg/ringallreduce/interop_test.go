@@ -0,0 +1,154 @@
+package ringallreduce
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// loopbackPair returns the two ends of a TCP connection on the loopback
+// interface.
+func loopbackPair(t *testing.T) (net.Conn, net.Conn) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		c, _ := ln.Accept()
+		accepted <- c
+	}()
+	worker, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	host := <-accepted
+	t.Cleanup(func() { worker.Close(); host.Close() })
+	return host, worker
+}
+
+func TestExternalTransport_MixedRanks(t *testing.T) {
+	tests := []struct {
+		name     string
+		topology Topology
+		external []int
+	}{
+		{"ring", NewRing(4), []int{1, 3}},
+		{"tree", NewTree(5), []int{0, 4}},
+		{"torus", NewTorus(2, 3), []int{0, 1, 2, 5}},
+		{"all external", NewFullyConnected(3), []int{0, 1, 2}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			p := tc.topology.Size()
+			const chunkSize = 2
+			x := NewExternalTransport(NewChanTransport(tc.topology), tc.topology, chunkSize)
+			x.Op = 7
+			defer x.Close()
+
+			inputs := vectors(p, p*chunkSize)
+			results := make([][]float64, p)
+			errs := make([]error, p)
+			var wg sync.WaitGroup
+			external := map[int]bool{}
+			for _, r := range tc.external {
+				external[r] = true
+				host, worker := loopbackPair(t)
+				wg.Add(1)
+				go func(r int) {
+					defer wg.Done()
+					results[r], errs[r] = RunWorker(r, worker, worker, inputs[r])
+				}(r)
+				if err := x.Attach(r, host, host); err != nil {
+					t.Fatalf("attach %d: %v", r, err)
+				}
+			}
+			for r := 0; r < p; r++ {
+				if external[r] {
+					continue
+				}
+				node := &Node{Rank: r, P: p, ChunkSize: chunkSize, Data: append([]float64(nil), inputs[r]...),
+					Topology: tc.topology, Transport: x, Op: x.Op}
+				wg.Add(1)
+				go func(r int) {
+					defer wg.Done()
+					errs[r] = node.AllReduce()
+					results[r] = node.Data
+				}(r)
+			}
+			wg.Wait()
+
+			want := float64(p * (p + 1) / 2)
+			for r := range results {
+				if errs[r] != nil {
+					t.Fatalf("rank %d: %v", r, errs[r])
+				}
+				for i, v := range results[r] {
+					if v != want {
+						t.Fatalf("rank %d element %d: got %v, want %v", r, i, v, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestExternalTransport_VersionMismatch(t *testing.T) {
+	x := NewExternalTransport(NewChanTransport(NewRing(2)), NewRing(2), 1)
+	host, worker := loopbackPair(t)
+	hello := binary.BigEndian.AppendUint16(nil, InteropVersion+1)
+	hello = binary.BigEndian.AppendUint32(hello, 1)
+	if err := writeInteropFrame(worker, FrameHello, hello); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Attach(1, host, host); !errors.Is(err, ErrInteropVersion) {
+		t.Fatalf("expected ErrInteropVersion, got %v", err)
+	}
+	typ, _, err := readInteropFrame(worker)
+	if err != nil || typ != FrameError {
+		t.Fatalf("expected an error frame, got type %d, %v", typ, err)
+	}
+}
+
+func TestExternalTransport_WorkerFailure(t *testing.T) {
+	ring := NewRing(2)
+	x := NewExternalTransport(NewChanTransport(ring), ring, 1)
+	defer x.Close()
+	host, worker := loopbackPair(t)
+
+	go func() {
+		// The vector doesn't fit the welcomed chunk size, so the worker
+		// reports an error instead of running.
+		RunWorker(1, worker, worker, []float64{1, 2, 3})
+	}()
+	if err := x.Attach(1, host, host); err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	node := &Node{Rank: 0, P: 2, ChunkSize: 1, Data: []float64{1, 1}, Topology: ring, Transport: x}
+	err := node.AllReduce()
+	var werr *WorkerError
+	if !errors.As(err, &werr) || werr.Rank != 1 {
+		t.Fatalf("expected a *WorkerError of rank 1, got %v", err)
+	}
+}
+
+func TestWelcome_RoundTrip(t *testing.T) {
+	torus := NewTorus(3, 3)
+	want := WorkerConfig{Rank: 4, Size: 9, ChunkSize: 5, Op: 1 << 40, Topology: torus.Name(), Schedule: torus.Schedule(4)}
+	got, err := decodeWelcome(appendWelcome(nil, want))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip changed the config:\n got %+v\nwant %+v", got, want)
+	}
+	if _, err := decodeWelcome(appendWelcome(nil, want)[:40]); err == nil {
+		t.Error("expected an error for a truncated welcome")
+	}
+}
@@ -0,0 +1,57 @@
+package ringallreduce
+
+// LinkCaps limits the bandwidth of the links of another LinkModel, to model
+// heterogeneous networks: a single slow NIC or an oversubscribed uplink
+// throttles every collective that crosses it. A capped link carries at most
+// the lowest of its own cap, the caps of both its ranks and the bandwidth of
+// the underlying model.
+type LinkCaps struct {
+	Model LinkModel
+
+	links map[link]float64
+	ranks map[int]float64
+}
+
+// NewLinkCaps caps the links of model; without caps it is model itself. A
+// nil model stands for links of no latency and unlimited bandwidth, so only
+// the caps apply.
+func NewLinkCaps(model LinkModel) *LinkCaps {
+	return &LinkCaps{Model: model, links: make(map[link]float64), ranks: make(map[int]float64)}
+}
+
+// CapLink limits the directed link from → to to bytesPerSec. A cap of 0
+// removes it.
+func (c *LinkCaps) CapLink(from, to int, bytesPerSec float64) *LinkCaps {
+	if bytesPerSec <= 0 {
+		delete(c.links, link{from: from, to: to})
+	} else {
+		c.links[link{from: from, to: to}] = bytesPerSec
+	}
+	return c
+}
+
+// CapRank limits every link into or out of rank to bytesPerSec, as a slow
+// NIC would. A cap of 0 removes it.
+func (c *LinkCaps) CapRank(rank int, bytesPerSec float64) *LinkCaps {
+	if bytesPerSec <= 0 {
+		delete(c.ranks, rank)
+	} else {
+		c.ranks[rank] = bytesPerSec
+	}
+	return c
+}
+
+// Link returns the link from → to of the underlying model with its
+// bandwidth lowered to the caps that apply to it.
+func (c *LinkCaps) Link(from, to int) Link {
+	var l Link
+	if c.Model != nil {
+		l = c.Model.Link(from, to)
+	}
+	for _, limit := range []float64{c.links[link{from: from, to: to}], c.ranks[from], c.ranks[to]} {
+		if limit > 0 && (l.Bandwidth == 0 || limit < l.Bandwidth) {
+			l.Bandwidth = limit
+		}
+	}
+	return l
+}
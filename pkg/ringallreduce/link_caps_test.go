@@ -0,0 +1,82 @@
+package ringallreduce
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLinkCaps_Link(t *testing.T) {
+	caps := NewLinkCaps(LinkFunc(func(from, to int) Link {
+		if from == 3 {
+			return Link{Latency: time.Millisecond}
+		}
+		return Link{Latency: time.Millisecond, Bandwidth: 1e9}
+	}))
+	caps.CapLink(0, 1, 1e6).CapLink(1, 0, 5e9).CapRank(2, 1e7)
+
+	tests := []struct {
+		name     string
+		from, to int
+		want     float64
+	}{
+		{"uncapped", 1, 3, 1e9},
+		{"link cap", 0, 1, 1e6},
+		{"cap above the model", 1, 0, 1e9},
+		{"rank cap outbound", 2, 0, 1e7},
+		{"rank cap inbound", 0, 2, 1e7},
+		{"unlimited link capped", 3, 2, 1e7},
+		{"unlimited link", 3, 0, 0},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			l := caps.Link(tc.from, tc.to)
+			if l.Bandwidth != tc.want || l.Latency != time.Millisecond {
+				t.Errorf("Link(%d, %d) = %+v, want bandwidth %g", tc.from, tc.to, l, tc.want)
+			}
+		})
+	}
+
+	caps.CapLink(0, 1, 0).CapRank(2, 0)
+	if got := caps.Link(0, 1).Bandwidth; got != 1e9 {
+		t.Errorf("removed link cap still applies: %g", got)
+	}
+	if got := caps.Link(2, 0).Bandwidth; got != 1e9 {
+		t.Errorf("removed rank cap still applies: %g", got)
+	}
+}
+
+func TestLinkCaps_SlowRankThrottlesRing(t *testing.T) {
+	const p, n = 8, 1 << 16
+	fast := UniformLinks{Latency: time.Microsecond, Bandwidth: 1e10}
+	base, err := Simulate(NewRing(p), n, fast, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Capping one rank to a tenth of the bandwidth slows every ring step,
+	// since each step waits for the chunk crossing the slow rank.
+	capped, err := Simulate(NewRing(p), n, NewLinkCaps(fast).CapRank(5, 1e9), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	allSlow, err := Simulate(NewRing(p), n, UniformLinks{Latency: time.Microsecond, Bandwidth: 1e9}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if capped < 5*base {
+		t.Errorf("expected the slow rank to dominate: base %v, capped %v", base, capped)
+	}
+	if capped > allSlow+allSlow/10 {
+		t.Errorf("one slow rank %v should cost no more than all slow ranks %v", capped, allSlow)
+	}
+}
+
+func TestLinkCaps_NilModel(t *testing.T) {
+	caps := NewLinkCaps(nil).CapRank(1, 1e6)
+	if got := caps.Link(0, 1); got != (Link{Bandwidth: 1e6}) {
+		t.Errorf("capped link: got %+v", got)
+	}
+	if got := caps.Link(0, 2); got != (Link{}) {
+		t.Errorf("uncapped link: expected an unlimited link, got %+v", got)
+	}
+}
@@ -0,0 +1,101 @@
+package ringallreduce
+
+import (
+	"io"
+	"math/rand"
+	"sync"
+)
+
+// LossyConfig sets the probabilities with which a LossyTransport mistreats
+// each message it sends.
+type LossyConfig struct {
+	DropRate      float64 // probability that a message is silently discarded
+	DuplicateRate float64 // probability that a message is delivered twice
+	ReorderRate   float64 // probability that a message is held back behind the next one on the same link
+	Seed          int64   // seed of the random source, for reproducible runs
+}
+
+// LossyTransport simulates an unreliable network on top of another
+// transport. A reordered message is delivered right after the next message
+// sent to the same rank; if no such message follows, it is effectively lost.
+type LossyTransport struct {
+	Inner Transport
+	LossyConfig
+
+	mu         sync.Mutex
+	rng        *rand.Rand
+	held       map[link]Msg
+	dropped    int
+	duplicated int
+	reordered  int
+}
+
+// link is a directed pair of ranks.
+type link struct {
+	from, to int
+}
+
+func NewLossyTransport(inner Transport, cfg LossyConfig) *LossyTransport {
+	return &LossyTransport{
+		Inner:       inner,
+		LossyConfig: cfg,
+		rng:         rand.New(rand.NewSource(cfg.Seed)),
+		held:        make(map[link]Msg),
+	}
+}
+
+func (l *LossyTransport) Send(rank int, msg Msg) error {
+	key := link{from: msg.From, to: rank}
+
+	l.mu.Lock()
+	if l.rng.Float64() < l.DropRate {
+		l.dropped++
+		l.mu.Unlock()
+		return nil
+	}
+	dup := l.rng.Float64() < l.DuplicateRate
+	if _, busy := l.held[key]; !busy && l.rng.Float64() < l.ReorderRate {
+		l.held[key] = msg
+		l.reordered++
+		l.mu.Unlock()
+		return nil
+	}
+	if dup {
+		l.duplicated++
+	}
+	late, hasLate := l.held[key]
+	delete(l.held, key)
+	l.mu.Unlock()
+
+	if err := l.Inner.Send(rank, msg); err != nil {
+		return err
+	}
+	if dup {
+		if err := l.Inner.Send(rank, msg); err != nil {
+			return err
+		}
+	}
+	if hasLate {
+		return l.Inner.Send(rank, late)
+	}
+	return nil
+}
+
+func (l *LossyTransport) Recv(rank int) (Msg, error) {
+	return l.Inner.Recv(rank)
+}
+
+// Close closes the inner transport when it supports it.
+func (l *LossyTransport) Close() error {
+	if c, ok := l.Inner.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Stats returns how many messages were dropped, duplicated and reordered.
+func (l *LossyTransport) Stats() (dropped, duplicated, reordered int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.dropped, l.duplicated, l.reordered
+}
@@ -0,0 +1,52 @@
+package ringallreduce
+
+import (
+	"testing"
+)
+
+func TestLossyTransport_Rates(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       LossyConfig
+		delivered int
+	}{
+		{name: "perfect", cfg: LossyConfig{}, delivered: 10},
+		{name: "drop all", cfg: LossyConfig{DropRate: 1}, delivered: 0},
+		{name: "duplicate all", cfg: LossyConfig{DuplicateRate: 1}, delivered: 20},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			inner := NewChanTransportSize(2, 64)
+			lossy := NewLossyTransport(inner, tc.cfg)
+			for i := 0; i < 10; i++ {
+				if err := lossy.Send(1, Msg{From: 0, ChunkIdx: i}); err != nil {
+					t.Fatalf("send: %v", err)
+				}
+			}
+			if got := len(inner.Inboxes[1]); got != tc.delivered {
+				t.Errorf("expected %d delivered messages, got %d", tc.delivered, got)
+			}
+		})
+	}
+}
+
+func TestLossyTransport_Reorder(t *testing.T) {
+	inner := NewChanTransportSize(2, 8)
+	lossy := NewLossyTransport(inner, LossyConfig{ReorderRate: 1})
+
+	// With a reorder rate of 1 every free slot holds a message back, so the
+	// first message overtakes nothing and is released by the second.
+	lossy.Send(1, Msg{From: 0, ChunkIdx: 0})
+	lossy.Send(1, Msg{From: 0, ChunkIdx: 1})
+
+	first, _ := lossy.Recv(1)
+	second, _ := lossy.Recv(1)
+	if first.ChunkIdx != 1 || second.ChunkIdx != 0 {
+		t.Errorf("expected chunk 1 before chunk 0, got %d then %d", first.ChunkIdx, second.ChunkIdx)
+	}
+	if _, _, reordered := lossy.Stats(); reordered != 1 {
+		t.Errorf("expected 1 reordered message, got %d", reordered)
+	}
+}
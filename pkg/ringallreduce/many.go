@@ -0,0 +1,117 @@
+package ringallreduce
+
+import "fmt"
+
+// interleaving lays several tensors out in the p chunks of one all–reduce
+// buffer: chunk k holds the k-th of p near-equal pieces of every tensor, in
+// tensor order. Every chunk, and so every step of the schedule, carries a
+// fair share of every tensor, however different their lengths.
+type interleaving struct {
+	p       int
+	lengths []int
+	chunk   int // elements per chunk, padding included
+}
+
+func newInterleaving(p int, lengths []int) interleaving {
+	l := interleaving{p: p, lengths: lengths}
+	for k := 0; k < p; k++ {
+		size := 0
+		for _, n := range lengths {
+			lo, hi := l.piece(n, k)
+			size += hi - lo
+		}
+		l.chunk = max(l.chunk, size)
+	}
+	l.chunk = max(l.chunk, 1)
+	return l
+}
+
+// piece returns the bounds of piece k of a tensor of n elements.
+func (l interleaving) piece(n, k int) (int, int) {
+	return k * n / l.p, (k + 1) * n / l.p
+}
+
+// pack returns the buffer holding tensors.
+func (l interleaving) pack(tensors [][]float64) []float64 {
+	buf := make([]float64, l.p*l.chunk)
+	for k := 0; k < l.p; k++ {
+		at := k * l.chunk
+		for _, t := range tensors {
+			lo, hi := l.piece(len(t), k)
+			at += copy(buf[at:], t[lo:hi])
+		}
+	}
+	return buf
+}
+
+// unpack copies buf, laid out by pack, back into tensors.
+func (l interleaving) unpack(buf []float64, tensors [][]float64) {
+	for k := 0; k < l.p; k++ {
+		at := k * l.chunk
+		for _, t := range tensors {
+			lo, hi := l.piece(len(t), k)
+			at += copy(t[lo:hi], buf[at:])
+		}
+	}
+}
+
+// AllReduceMany sums a list of tensors of differing lengths across ranks in
+// place, in a single all–reduce over the node's topology instead of one per
+// tensor. The tensors are interleaved so that every chunk carries a share of
+// each of them. Every rank must pass tensors of the same lengths, in the
+// same order; ChunkSize and Data are replaced by the interleaved buffer.
+func (proc *Node) AllReduceMany(tensors [][]float64) error {
+	lengths := make([]int, len(tensors))
+	for i, t := range tensors {
+		lengths[i] = len(t)
+	}
+	l := newInterleaving(proc.topology().Size(), lengths)
+	proc.ChunkSize = l.chunk
+	proc.Data = l.pack(tensors)
+	if err := proc.AllReduce(); err != nil {
+		return err
+	}
+	l.unpack(proc.Data, tensors)
+	return nil
+}
+
+// AllReduceMany is AllReduce over a list of tensors per rank: data[r][i] is
+// tensor i of rank r. The tensors may differ in length but must have the
+// same lengths on every rank. They are interleaved into one vector per rank
+// and reduced in a single collective.
+func (c *Communicator) AllReduceMany(id OpID, data [][][]float64) error {
+	p := c.Size()
+	if len(data) != p {
+		return fmt.Errorf("got %d tensor lists for %d ranks", len(data), p)
+	}
+	if p == 0 {
+		return ErrNoRanks
+	}
+	lengths := make([]int, len(data[0]))
+	for i, t := range data[0] {
+		lengths[i] = len(t)
+	}
+	for r, ts := range data {
+		if len(ts) != len(lengths) {
+			return fmt.Errorf("rank %d: %d tensors, rank 0 has %d", r, len(ts), len(lengths))
+		}
+		for i, t := range ts {
+			if len(t) != lengths[i] {
+				return fmt.Errorf("rank %d: tensor %d has length %d, rank 0 has %d", r, i, len(t), lengths[i])
+			}
+		}
+	}
+
+	l := newInterleaving(p, lengths)
+	flat := make([][]float64, p)
+	for r, ts := range data {
+		flat[r] = l.pack(ts)
+	}
+	if err := c.AllReduce(id, flat); err != nil {
+		return err
+	}
+	for r, ts := range data {
+		l.unpack(flat[r], ts)
+	}
+	return nil
+}
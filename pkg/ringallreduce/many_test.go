@@ -0,0 +1,132 @@
+package ringallreduce
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestInterleaving_RoundTrip(t *testing.T) {
+	lengths := []int{1, 7, 0, 3, 12}
+	for p := 1; p <= 5; p++ {
+		tensors := layers(1, lengths)[0]
+		l := newInterleaving(p, lengths)
+		buf := l.pack(tensors)
+		if len(buf) != p*l.chunk {
+			t.Fatalf("p=%d: buffer of %d elements, want %d", p, len(buf), p*l.chunk)
+		}
+		// A chunk holds at most a rounded-up p-th of every tensor.
+		limit := 0
+		for _, n := range lengths {
+			limit += (n + p - 1) / p
+		}
+		if l.chunk > limit {
+			t.Errorf("p=%d: chunk of %d elements, want at most %d", p, l.chunk, limit)
+		}
+		got := layers(1, lengths)[0]
+		for _, v := range got {
+			for i := range v {
+				v[i] = 0
+			}
+		}
+		l.unpack(buf, got)
+		if !reflect.DeepEqual(got, tensors) {
+			t.Errorf("p=%d: round trip gave %v, want %v", p, got, tensors)
+		}
+	}
+}
+
+// wantMany returns the sums over p ranks of the tensors built by layers.
+func wantMany(p int, lengths []int) [][]float64 {
+	want := make([][]float64, len(lengths))
+	for i, n := range lengths {
+		want[i] = make([]float64, n)
+		for j := range want[i] {
+			want[i][j] = float64(p*(p+1)/2 + p*(10*i+j))
+		}
+	}
+	return want
+}
+
+func TestCommunicator_AllReduceMany(t *testing.T) {
+	lengths := []int{1, 7, 0, 3, 12}
+	tests := []struct {
+		name     string
+		topology func(int) Topology
+	}{
+		{"ring", func(p int) Topology { return NewRing(p) }},
+		{"tree", func(p int) Topology { return NewTree(p) }},
+		{"recursive-doubling", func(p int) Topology { return NewRecursiveDoubling(p) }},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			const p = 4
+			c := NewCommunicator(p)
+			c.Topology = tc.topology
+			collectives := 0
+			c.NewTransport = func(topo Topology) Transport {
+				collectives++
+				return NewChanTransport(topo)
+			}
+			data := layers(p, lengths)
+			if err := c.AllReduceMany(c.NewOpID(), data); err != nil {
+				t.Fatalf("AllReduceMany: %v", err)
+			}
+			if collectives != 1 {
+				t.Errorf("ran %d collectives, want 1", collectives)
+			}
+			want := wantMany(p, lengths)
+			for r := range data {
+				if !reflect.DeepEqual(data[r], want) {
+					t.Errorf("rank %d: got %v, want %v", r, data[r], want)
+				}
+			}
+		})
+	}
+}
+
+func TestNode_AllReduceMany(t *testing.T) {
+	const p = 3
+	lengths := []int{5, 2, 9}
+	ring := NewRing(p)
+	transport := NewChanTransport(ring)
+	data := layers(p, lengths)
+	errs := make([]error, p)
+	var wg sync.WaitGroup
+	for r := 0; r < p; r++ {
+		wg.Add(1)
+		go func(r int) {
+			defer wg.Done()
+			node := &Node{Rank: r, P: p, Topology: ring, Transport: transport}
+			errs[r] = node.AllReduceMany(data[r])
+		}(r)
+	}
+	wg.Wait()
+	want := wantMany(p, lengths)
+	for r := range data {
+		if errs[r] != nil {
+			t.Fatalf("rank %d: %v", r, errs[r])
+		}
+		if !reflect.DeepEqual(data[r], want) {
+			t.Errorf("rank %d: got %v, want %v", r, data[r], want)
+		}
+	}
+}
+
+func TestCommunicator_AllReduceManyErrors(t *testing.T) {
+	c := NewCommunicator(2)
+	if err := c.AllReduceMany(c.NewOpID(), layers(3, []int{1})); err == nil {
+		t.Error("expected an error for the wrong number of ranks")
+	}
+	if err := c.AllReduceMany(c.NewOpID(), [][][]float64{{{1}, {2}}, {{1}}}); err == nil {
+		t.Error("expected an error for a differing tensor count")
+	}
+	if err := c.AllReduceMany(c.NewOpID(), [][][]float64{{{1}, {2}}, {{1}, {2, 3}}}); err == nil {
+		t.Error("expected an error for a differing tensor length")
+	}
+	if err := NewCommunicator(0).AllReduceMany(1, nil); !errors.Is(err, ErrNoRanks) {
+		t.Errorf("empty group: got %v, want ErrNoRanks", err)
+	}
+}
@@ -0,0 +1,114 @@
+package ringallreduce
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrMemoryBudget is matched by the *MemoryError reported when a rank would
+// exceed its memory budget.
+var ErrMemoryBudget = errors.New("memory budget exceeded")
+
+// MemoryError reports an allocation that doesn't fit a rank's budget.
+type MemoryError struct {
+	Rank   int
+	What   string // what was being allocated, e.g. "buffer" or "queued message"
+	Bytes  int64  // size of the allocation
+	Used   int64  // bytes already in use by the rank
+	Budget int64
+}
+
+func (e *MemoryError) Error() string {
+	return fmt.Sprintf("rank %d: %v: %s of %d bytes with %d of %d bytes in use",
+		e.Rank, ErrMemoryBudget, e.What, e.Bytes, e.Used, e.Budget)
+}
+
+func (e *MemoryError) Unwrap() error { return ErrMemoryBudget }
+
+// MemoryBudget accounts the memory of one rank: its data buffer, the copies
+// of the chunks it sends and the messages it has received but not yet
+// applied. An allocation that would take the rank over Limit bytes fails
+// with a *MemoryError. It is safe for concurrent use.
+type MemoryBudget struct {
+	Limit int64 // in bytes; 0 means unlimited
+
+	mu   sync.Mutex
+	used int64
+	peak int64
+}
+
+func NewMemoryBudget(limit int64) *MemoryBudget {
+	return &MemoryBudget{Limit: limit}
+}
+
+// Used returns the bytes currently accounted.
+func (b *MemoryBudget) Used() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+// Peak returns the largest number of bytes accounted at any time.
+func (b *MemoryBudget) Peak() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.peak
+}
+
+func (b *MemoryBudget) alloc(rank int, what string, bytes int64) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.Limit > 0 && b.used+bytes > b.Limit {
+		return &MemoryError{Rank: rank, What: what, Bytes: bytes, Used: b.used, Budget: b.Limit}
+	}
+	b.used += bytes
+	b.peak = max(b.peak, b.used)
+	return nil
+}
+
+func (b *MemoryBudget) free(bytes int64) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used -= bytes
+}
+
+// EstimateMemory returns the bytes every rank needs at least for an
+// all–reduce of n float64 elements over t: the padded data buffer plus the
+// chunk copies of its largest step. Messages that arrive ahead of their
+// step come on top of that. A topology of no ranks needs none.
+func EstimateMemory(t Topology, n int) int64 {
+	p := t.Size()
+	if p == 0 {
+		return 0
+	}
+	chunk := int64(max((n+p-1)/p, 1)) * 8
+	var most int64
+	for r := 0; r < p; r++ {
+		for _, step := range t.Schedule(r) {
+			most = max(most, int64(len(step.SendChunks)+len(step.RecvChunks))*chunk)
+		}
+	}
+	return int64(p)*chunk + most
+}
+
+// CheckMemory reports a *MemoryError if an all–reduce of n elements over t
+// can't run within budget bytes per rank. A budget of 0 is unlimited.
+func CheckMemory(t Topology, n int, budget int64) error {
+	if t.Size() == 0 {
+		return ErrNoRanks
+	}
+	if budget <= 0 {
+		return nil
+	}
+	if need := EstimateMemory(t, n); need > budget {
+		return &MemoryError{Rank: 0, What: "estimated working set", Bytes: need, Budget: budget}
+	}
+	return nil
+}
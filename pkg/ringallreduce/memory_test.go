@@ -0,0 +1,101 @@
+package ringallreduce
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEstimateMemory(t *testing.T) {
+	cases := []struct {
+		name string
+		t    Topology
+		n    int
+		want int64
+	}{
+		// Buffer of 4 chunks of 2 elements plus one chunk sent and one received.
+		{"ring", NewRing(4), 8, 4*16 + 2*16},
+		// Padded to 3 chunks of 4 elements.
+		{"ring padded", NewRing(3), 10, 3*32 + 2*32},
+		// The root receives the whole vector from a child in one step.
+		{"tree", NewTree(5), 10, 5*16 + 5*16},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if got := EstimateMemory(tc.t, tc.n); got != tc.want {
+				t.Errorf("EstimateMemory = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEstimateMemory_NoRanks(t *testing.T) {
+	if got := EstimateMemory(NewRing(0), 10); got != 0 {
+		t.Errorf("EstimateMemory = %d, want 0", got)
+	}
+	if err := CheckMemory(NewRing(0), 10, 1<<20); !errors.Is(err, ErrNoRanks) {
+		t.Errorf("CheckMemory: got %v, want ErrNoRanks", err)
+	}
+}
+
+func TestCommunicator_MemoryBudget(t *testing.T) {
+	need := EstimateMemory(NewRing(4), 8)
+
+	c := NewCommunicator(4)
+	c.MemoryBudget = need - 1
+	err := c.AllReduce(c.NewOpID(), vectors(4, 8))
+	if !errors.Is(err, ErrMemoryBudget) {
+		t.Fatalf("expected ErrMemoryBudget, got %v", err)
+	}
+	var merr *MemoryError
+	if !errors.As(err, &merr) || merr.Bytes != need || merr.Budget != need-1 {
+		t.Fatalf("unexpected error %#v", merr)
+	}
+
+	c.MemoryBudget = need
+	data := vectors(4, 8)
+	if err := c.AllReduce(c.NewOpID(), data); err != nil {
+		t.Fatalf("AllReduce within budget: %v", err)
+	}
+	if data[0][0] != 10 {
+		t.Errorf("expected 10, got %v", data[0][0])
+	}
+}
+
+func TestSimulateBudget(t *testing.T) {
+	_, err := SimulateBudget(NewRing(64), 1<<30, UniformLinks(Link{}), 1, 1<<20)
+	if !errors.Is(err, ErrMemoryBudget) {
+		t.Fatalf("expected ErrMemoryBudget, got %v", err)
+	}
+}
+
+// TestNode_MemoryQueuedMessages checks that messages received ahead of their
+// step count against the budget until they are applied.
+func TestNode_MemoryQueuedMessages(t *testing.T) {
+	run := func(limit int64) (*Node, error) {
+		transport := NewChanTransportSize(2, 4)
+		// Rank 1's allgather chunk arrives before its reduce–scatter chunk.
+		transport.Inboxes[0] <- Msg{From: 1, ChunkIdx: 0, Data: []float64{5}}
+		transport.Inboxes[0] <- Msg{From: 1, ChunkIdx: 1, Data: []float64{2}}
+		node := &Node{Rank: 0, P: 2, ChunkSize: 1, Data: []float64{1, 1}, Transport: transport, Memory: NewMemoryBudget(limit)}
+		return node, node.AllReduce()
+	}
+
+	// Buffer of 16 bytes plus both queued chunks.
+	node, err := run(32)
+	if err != nil {
+		t.Fatalf("AllReduce: %v", err)
+	}
+	if got := node.Data; got[0] != 5 || got[1] != 3 {
+		t.Errorf("unexpected data %v", got)
+	}
+	if peak, used := node.Memory.Peak(), node.Memory.Used(); peak != 32 || used != 16 {
+		t.Errorf("peak %d used %d, want 32 and 16", peak, used)
+	}
+
+	_, err = run(24)
+	var merr *MemoryError
+	if !errors.As(err, &merr) || merr.What != "queued message" || merr.Used != 24 {
+		t.Fatalf("expected a queued message to exceed the budget, got %v", err)
+	}
+}
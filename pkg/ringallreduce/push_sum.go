@@ -0,0 +1,136 @@
+package ringallreduce
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// GossipConfig configures GossipAverage.
+type GossipConfig struct {
+	MaxRounds int     // defaults to 200
+	Tolerance float64 // relative spread of the estimates to stop at; defaults to 1e-6
+	Seed      int64   // of the peer choices
+}
+
+// GossipResult is the outcome of GossipAverage.
+type GossipResult struct {
+	Estimates [][]float64 // estimate of the mean vector held by every rank
+	Rounds    int
+	Messages  int
+	Spread    float64 // largest relative difference between two ranks' estimates
+	Converged bool    // whether Spread reached the tolerance
+}
+
+// GossipAverage computes the element-wise mean of data, the vector of every
+// rank, with push-sum gossip instead of a collective: there is no schedule,
+// every round each rank keeps half of its (sum, weight) pair and pushes the
+// other half to a random peer. Every rank's sum/weight converges to the mean
+// exponentially fast, which tolerates the churn and unreliable peers of
+// decentralized and federated settings but gives only an approximation.
+// data is left untouched.
+//
+// References:
+//
+// https://www.cs.cornell.edu/johannes/papers/2003/focs2003-gossip.pdf
+func (c *Communicator) GossipAverage(data [][]float64, cfg GossipConfig) (GossipResult, error) {
+	p := c.Size()
+	if len(data) != p {
+		return GossipResult{}, fmt.Errorf("got %d vectors for %d ranks", len(data), p)
+	}
+	if p == 0 {
+		return GossipResult{}, ErrNoRanks
+	}
+	n := len(data[0])
+	for i, v := range data {
+		if len(v) != n {
+			return GossipResult{}, fmt.Errorf("rank %d: vector length %d differs from %d", i, len(v), n)
+		}
+	}
+	if cfg.MaxRounds == 0 {
+		cfg.MaxRounds = 200
+	}
+	if cfg.Tolerance == 0 {
+		cfg.Tolerance = 1e-6
+	}
+
+	sums := make([][]float64, p)
+	weights := make([]float64, p)
+	for r := range sums {
+		sums[r] = append([]float64(nil), data[r]...)
+		weights[r] = 1
+	}
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	res := GossipResult{}
+	inSums := make([][]float64, p)
+	inWeights := make([]float64, p)
+	for r := range inSums {
+		inSums[r] = make([]float64, n)
+	}
+	for res.Rounds < cfg.MaxRounds {
+		res.Spread = spread(sums, weights)
+		if res.Converged = res.Spread <= cfg.Tolerance; res.Converged {
+			break
+		}
+		// Every rank halves its pair, keeps one half and pushes the other.
+		for r := range sums {
+			for i := range sums[r] {
+				sums[r][i] /= 2
+			}
+			weights[r] /= 2
+			peer := r
+			if p > 1 {
+				peer = rng.Intn(p - 1)
+				if peer >= r {
+					peer++
+				}
+			}
+			for i, x := range sums[r] {
+				inSums[peer][i] += x
+			}
+			inWeights[peer] += weights[r]
+			res.Messages++
+		}
+		for r := range sums {
+			for i := range sums[r] {
+				sums[r][i] += inSums[r][i]
+				inSums[r][i] = 0
+			}
+			weights[r] += inWeights[r]
+			inWeights[r] = 0
+		}
+		res.Rounds++
+	}
+	if !res.Converged {
+		res.Spread = spread(sums, weights)
+		res.Converged = res.Spread <= cfg.Tolerance
+	}
+
+	res.Estimates = make([][]float64, p)
+	for r := range sums {
+		res.Estimates[r] = make([]float64, n)
+		for i, x := range sums[r] {
+			res.Estimates[r][i] = x / weights[r]
+		}
+	}
+	return res, nil
+}
+
+// spread returns the largest difference between the estimates of two ranks,
+// relative to the largest estimate magnitude of the element.
+func spread(sums [][]float64, weights []float64) float64 {
+	var worst float64
+	for i := range sums[0] {
+		lo, hi := math.Inf(1), math.Inf(-1)
+		var scale float64
+		for r := range sums {
+			e := sums[r][i] / weights[r]
+			lo, hi = min(lo, e), max(hi, e)
+			scale = max(scale, math.Abs(e))
+		}
+		if d := hi - lo; d > 0 {
+			worst = max(worst, d/max(scale, 1e-300))
+		}
+	}
+	return worst
+}
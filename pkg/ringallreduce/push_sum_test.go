@@ -0,0 +1,69 @@
+package ringallreduce
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestGossipAverage(t *testing.T) {
+	tests := []struct {
+		name string
+		p    int
+	}{
+		{"pair", 2},
+		{"small", 5},
+		{"large", 64},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewCommunicator(tc.p)
+			data := vectors(tc.p, 3)
+			res, err := c.GossipAverage(data, GossipConfig{Seed: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !res.Converged || res.Spread > 1e-6 {
+				t.Fatalf("did not converge: %+v", res)
+			}
+			want := float64(tc.p+1) / 2
+			for r, est := range res.Estimates {
+				for i, x := range est {
+					if math.Abs(x-want)/want > 1e-5 {
+						t.Fatalf("rank %d element %d: got %v, want %v", r, i, x, want)
+					}
+				}
+			}
+			if res.Messages != res.Rounds*tc.p {
+				t.Errorf("%d messages in %d rounds, want one per rank and round", res.Messages, res.Rounds)
+			}
+			if data[0][0] != 1 {
+				t.Error("GossipAverage must not touch data")
+			}
+		})
+	}
+}
+
+func TestGossipAverage_MaxRounds(t *testing.T) {
+	c := NewCommunicator(32)
+	res, err := c.GossipAverage(vectors(32, 2), GossipConfig{MaxRounds: 2, Seed: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Converged || res.Rounds != 2 || res.Spread <= 1e-6 {
+		t.Errorf("expected to stop unconverged after 2 rounds, got %+v", res)
+	}
+}
+
+func TestGossipAverage_SizeMismatch(t *testing.T) {
+	if _, err := NewCommunicator(3).GossipAverage(vectors(2, 2), GossipConfig{}); err == nil {
+		t.Error("expected an error for a vector count that differs from the group size")
+	}
+}
+
+func TestGossipAverage_EmptyGroup(t *testing.T) {
+	if _, err := NewCommunicator(0).GossipAverage(nil, GossipConfig{}); !errors.Is(err, ErrNoRanks) {
+		t.Errorf("expected ErrNoRanks, got %v", err)
+	}
+}
@@ -0,0 +1,242 @@
+package ringallreduce
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// ReliableTransport turns an unreliable transport into one with exactly-once,
+// in-order delivery per link. Every message gets a per-link sequence number;
+// receivers answer with cumulative acknowledgements, drop duplicates and hold
+// back messages that arrive ahead of a gap, and senders retransmit whatever
+// stays unacknowledged for longer than Timeout.
+//
+// A background pump per local rank consumes the inner transport, so the inner
+// inboxes should be sized for acknowledgements and retransmissions too, for
+// example with NewChanTransportSize.
+type ReliableTransport struct {
+	Inner   Transport
+	Timeout time.Duration
+
+	mu          sync.Mutex
+	nextSeq     map[link]uint64
+	unacked     map[link]map[uint64]*inflight
+	expected    map[link]uint64
+	early       map[link]map[uint64]Msg
+	delivered   map[int]*msgQueue
+	retransmits int
+
+	closed chan struct{}
+	once   sync.Once
+	wg     sync.WaitGroup
+}
+
+type inflight struct {
+	to     int
+	msg    Msg
+	sentAt time.Time
+}
+
+func NewReliableTransport(inner Transport, timeout time.Duration) *ReliableTransport {
+	r := &ReliableTransport{
+		Inner:     inner,
+		Timeout:   timeout,
+		nextSeq:   make(map[link]uint64),
+		unacked:   make(map[link]map[uint64]*inflight),
+		expected:  make(map[link]uint64),
+		early:     make(map[link]map[uint64]Msg),
+		delivered: make(map[int]*msgQueue),
+		closed:    make(chan struct{}),
+	}
+	r.wg.Add(1)
+	go r.retransmit()
+	return r
+}
+
+func (r *ReliableTransport) Send(rank int, msg Msg) error {
+	key := link{from: msg.From, to: rank}
+
+	r.mu.Lock()
+	r.queue(msg.From)
+	r.nextSeq[key]++
+	msg.Seq = r.nextSeq[key]
+	msg.Ack = false
+	if r.unacked[key] == nil {
+		r.unacked[key] = make(map[uint64]*inflight)
+	}
+	r.unacked[key][msg.Seq] = &inflight{to: rank, msg: msg, sentAt: time.Now()}
+	r.mu.Unlock()
+
+	return r.Inner.Send(rank, msg)
+}
+
+func (r *ReliableTransport) Recv(rank int) (Msg, error) {
+	r.mu.Lock()
+	q := r.queue(rank)
+	r.mu.Unlock()
+	return q.pop()
+}
+
+// Retransmits returns how many messages were sent again after a timeout.
+func (r *ReliableTransport) Retransmits() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.retransmits
+}
+
+// Close stops retransmitting and closes the inner transport when it supports it.
+func (r *ReliableTransport) Close() error {
+	var err error
+	r.once.Do(func() {
+		close(r.closed)
+		if c, ok := r.Inner.(io.Closer); ok {
+			err = c.Close()
+		}
+		r.wg.Wait()
+	})
+	return err
+}
+
+// queue returns the delivery queue of rank, starting its pump on first use.
+// The caller must hold r.mu.
+func (r *ReliableTransport) queue(rank int) *msgQueue {
+	q, ok := r.delivered[rank]
+	if !ok {
+		q = newMsgQueue()
+		r.delivered[rank] = q
+		r.wg.Add(1)
+		go r.pump(rank, q)
+	}
+	return q
+}
+
+// pump reads the inner transport for rank, handling acknowledgements and
+// releasing data messages to q in sequence order.
+func (r *ReliableTransport) pump(rank int, q *msgQueue) {
+	defer r.wg.Done()
+	for {
+		m, err := r.Inner.Recv(rank)
+		if err != nil {
+			q.fail(err)
+			return
+		}
+		if m.Ack {
+			r.mu.Lock()
+			for seq := range r.unacked[link{from: rank, to: m.From}] {
+				if seq <= m.Seq {
+					delete(r.unacked[link{from: rank, to: m.From}], seq)
+				}
+			}
+			r.mu.Unlock()
+			continue
+		}
+
+		key := link{from: m.From, to: rank}
+		r.mu.Lock()
+		next := r.expected[key]
+		if next == 0 {
+			next = 1
+		}
+		switch {
+		case m.Seq == next:
+			q.push(m)
+			next++
+			for {
+				later, ok := r.early[key][next]
+				if !ok {
+					break
+				}
+				delete(r.early[key], next)
+				q.push(later)
+				next++
+			}
+		case m.Seq > next:
+			if r.early[key] == nil {
+				r.early[key] = make(map[uint64]Msg)
+			}
+			r.early[key][m.Seq] = m
+		}
+		r.expected[key] = next
+		r.mu.Unlock()
+
+		if err := r.Inner.Send(m.From, Msg{From: rank, Seq: next - 1, Ack: true}); err != nil {
+			q.fail(err)
+			return
+		}
+	}
+}
+
+func (r *ReliableTransport) retransmit() {
+	defer r.wg.Done()
+	interval := r.Timeout / 2
+	if interval <= 0 {
+		interval = time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.closed:
+			return
+		case now := <-ticker.C:
+			var due []*inflight
+			r.mu.Lock()
+			for _, msgs := range r.unacked {
+				for _, f := range msgs {
+					if now.Sub(f.sentAt) >= r.Timeout {
+						f.sentAt = now
+						due = append(due, f)
+					}
+				}
+			}
+			r.retransmits += len(due)
+			r.mu.Unlock()
+			for _, f := range due {
+				r.Inner.Send(f.to, f.msg)
+			}
+		}
+	}
+}
+
+// msgQueue is an unbounded FIFO of delivered messages.
+type msgQueue struct {
+	mu    sync.Mutex
+	cond  *sync.Cond
+	items []Msg
+	err   error
+}
+
+func newMsgQueue() *msgQueue {
+	q := &msgQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *msgQueue) push(m Msg) {
+	q.mu.Lock()
+	q.items = append(q.items, m)
+	q.mu.Unlock()
+	q.cond.Signal()
+}
+
+func (q *msgQueue) fail(err error) {
+	q.mu.Lock()
+	q.err = err
+	q.mu.Unlock()
+	q.cond.Broadcast()
+}
+
+func (q *msgQueue) pop() (Msg, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.items) == 0 && q.err == nil {
+		q.cond.Wait()
+	}
+	if len(q.items) == 0 {
+		return Msg{}, q.err
+	}
+	m := q.items[0]
+	q.items = q.items[1:]
+	return m, nil
+}
@@ -0,0 +1,81 @@
+package ringallreduce
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReliableTransport_AllReduceOverLossyLinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		topology Topology
+		cfg      LossyConfig
+	}{
+		{name: "ring drops", topology: NewRing(4), cfg: LossyConfig{DropRate: 0.2, Seed: 1}},
+		{name: "ring duplicates and reorders", topology: NewRing(5), cfg: LossyConfig{DuplicateRate: 0.3, ReorderRate: 0.3, Seed: 2}},
+		{name: "torus everything", topology: NewTorus(2, 3), cfg: LossyConfig{DropRate: 0.1, DuplicateRate: 0.1, ReorderRate: 0.2, Seed: 3}},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			lossy := NewLossyTransport(NewChanTransportSize(tc.topology.Size(), 1024), tc.cfg)
+			reliable := NewReliableTransport(lossy, 5*time.Millisecond)
+			defer reliable.Close()
+
+			nodes := runNodes(tc.topology, reliable, 2)
+
+			p := tc.topology.Size()
+			expected := float64(p * (p + 1) / 2)
+			for _, n := range nodes {
+				if n.Err != nil {
+					t.Fatalf("node=%d: unexpected error: %v", n.Rank, n.Err)
+				}
+				for j, v := range n.Data {
+					if v != expected {
+						t.Errorf("node=%d, elem=%d: expected %f, got %f", n.Rank, j, expected, v)
+					}
+				}
+			}
+			if dropped, _, _ := lossy.Stats(); dropped > 0 && reliable.Retransmits() == 0 {
+				t.Errorf("%d messages dropped but none retransmitted", dropped)
+			}
+		})
+	}
+}
+
+func TestReliableTransport_InOrderExactlyOnce(t *testing.T) {
+	lossy := NewLossyTransport(NewChanTransportSize(2, 1024), LossyConfig{DuplicateRate: 0.5, ReorderRate: 0.5, DropRate: 0.2, Seed: 9})
+	reliable := NewReliableTransport(lossy, 2*time.Millisecond)
+	defer reliable.Close()
+
+	const n = 200
+	go func() {
+		for i := 0; i < n; i++ {
+			reliable.Send(1, Msg{From: 0, ChunkIdx: i})
+		}
+	}()
+	for i := 0; i < n; i++ {
+		m, err := reliable.Recv(1)
+		if err != nil {
+			t.Fatalf("recv: %v", err)
+		}
+		if m.ChunkIdx != i {
+			t.Fatalf("expected message %d, got %d", i, m.ChunkIdx)
+		}
+	}
+}
+
+func TestReliableTransport_Close(t *testing.T) {
+	reliable := NewReliableTransport(NewChanTransportSize(2, 4), time.Millisecond)
+	done := make(chan error)
+	go func() {
+		_, err := reliable.Recv(0)
+		done <- err
+	}()
+	time.Sleep(5 * time.Millisecond)
+	reliable.Close()
+	if err := <-done; err != ErrTransportClosed {
+		t.Errorf("expected ErrTransportClosed, got %v", err)
+	}
+}
@@ -0,0 +1,283 @@
+package ringallreduce
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrRankCrashed is returned by a rank whose process died. Unlike other
+// errors it is not reported to the peers: the rank simply goes silent, and the
+// survivors only notice through step timeouts and missing heartbeats.
+var ErrRankCrashed = errors.New("rank crashed")
+
+// ErrNoSurvivors is returned when every rank of a resilient collective died.
+var ErrNoSurvivors = errors.New("no surviving ranks")
+
+// minHeartbeatInterval bounds the heartbeat rate of AllReduceResilient.
+const minHeartbeatInterval = time.Microsecond
+
+// FaultTolerance configures AllReduceResilient.
+type FaultTolerance struct {
+	// StepTimeout bounds every receive; a timeout aborts the attempt. It
+	// must be positive.
+	StepTimeout time.Duration
+	// HeartbeatInterval is how often live ranks report to the detector;
+	// defaults to a tenth of the step timeout, and is at least
+	// minHeartbeatInterval.
+	HeartbeatInterval time.Duration
+	// Detector decides which ranks are dead after an aborted attempt;
+	// defaults to a TimeoutDetector with half the step timeout. A
+	// phiaccrual.Detector adapts to the heartbeat jitter instead.
+	Detector FailureDetector
+	// Segments splits the vector into independently committed pieces. A
+	// failure restarts only the segment in progress; completed segments are
+	// kept. Defaults to 1.
+	Segments int
+	// MaxRestarts bounds the number of ring re-formations; 0 means P-1.
+	MaxRestarts int
+}
+
+// PartialResult is the outcome of a resilient all–reduce.
+type PartialResult struct {
+	Data           [][]float64 // reduced vector per original rank; nil for failed ranks
+	Alive          []int       // ranks that survived
+	Failed         []int       // ranks excluded from the ring, in detection order
+	SegmentMembers [][]int     // ranks whose inputs were reduced into each segment
+	Restarts       int         // number of times the ring was re-formed
+}
+
+// AllReduceResilient sums inputs across ranks like AllReduce but survives
+// ranks dying mid-collective. When a step times out, the failure detector
+// picks the dead ranks, the ring is re-formed among the survivors and the
+// collective restarts from the last completed segment. The result of a
+// segment is the sum over the ranks that were members when it completed.
+func (c *Communicator) AllReduceResilient(inputs [][]float64, ft FaultTolerance) (PartialResult, error) {
+	if ft.StepTimeout <= 0 {
+		return PartialResult{}, fmt.Errorf("step timeout %v is not positive", ft.StepTimeout)
+	}
+	c.mu.Lock()
+	size := len(c.members)
+	if size == 0 {
+		c.mu.Unlock()
+		return PartialResult{}, ErrNoRanks
+	}
+	if len(inputs) != size {
+		c.mu.Unlock()
+		return PartialResult{}, fmt.Errorf("got %d vectors for %d ranks", len(inputs), size)
+	}
+	if err := c.begin(); err != nil {
+		c.mu.Unlock()
+		return PartialResult{}, err
+	}
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.end()
+		c.mu.Unlock()
+	}()
+
+	n := len(inputs[0])
+	for i, in := range inputs {
+		if len(in) != n {
+			return PartialResult{}, fmt.Errorf("rank %d: vector length %d differs from %d", i, len(in), n)
+		}
+	}
+	if ft.Segments <= 0 {
+		ft.Segments = 1
+	}
+	if ft.HeartbeatInterval <= 0 {
+		ft.HeartbeatInterval = ft.StepTimeout / 10
+	}
+	ft.HeartbeatInterval = max(ft.HeartbeatInterval, minHeartbeatInterval)
+	if ft.Detector == nil {
+		ft.Detector = NewTimeoutDetector(ft.StepTimeout / 2)
+	}
+	if ft.MaxRestarts <= 0 {
+		ft.MaxRestarts = size - 1
+	}
+
+	res := PartialResult{Data: make([][]float64, size)}
+	for i := range res.Data {
+		res.Data[i] = make([]float64, n)
+	}
+	members := make([]int, size)
+	for i := range members {
+		members[i] = i
+	}
+
+	segLen := (n + ft.Segments - 1) / ft.Segments
+	for seg := 0; seg < ft.Segments; seg++ {
+		lo := seg * segLen
+		hi := min(lo+segLen, n)
+		if lo >= hi {
+			res.SegmentMembers = append(res.SegmentMembers, append([]int(nil), members...))
+			continue
+		}
+		for {
+			segInputs := make([][]float64, len(members))
+			for pos, rank := range members {
+				segInputs[pos] = inputs[rank][lo:hi]
+			}
+
+			c.mu.Lock()
+			c.attempts++
+			tag := c.attempts
+			c.mu.Unlock()
+
+			out, crashed, err := c.runMonitored(members, segInputs, tag, ft)
+			if err == nil {
+				for pos, rank := range members {
+					copy(res.Data[rank][lo:hi], out[pos])
+				}
+				res.SegmentMembers = append(res.SegmentMembers, append([]int(nil), members...))
+				// Ranks that died after delivering everything still
+				// contributed to this segment but leave the ring.
+				if len(crashed) > 0 {
+					members = without(members, crashed)
+					res.Failed = append(res.Failed, crashed...)
+					if len(members) == 0 {
+						return res, ErrNoSurvivors
+					}
+				}
+				break
+			}
+			if !errors.Is(err, ErrStepTimeout) && !errors.Is(err, ErrTransportClosed) {
+				return res, err
+			}
+
+			// Re-form the ring without the ranks the detector suspects.
+			now := time.Now()
+			var alive []int
+			for _, rank := range members {
+				if ft.Detector.Suspect(rank, now) {
+					res.Failed = append(res.Failed, rank)
+				} else {
+					alive = append(alive, rank)
+				}
+			}
+			if len(alive) == 0 {
+				return res, ErrNoSurvivors
+			}
+			if len(alive) == len(members) {
+				return res, fmt.Errorf("step timed out but no rank is suspected: %w", err)
+			}
+			if res.Restarts == ft.MaxRestarts {
+				return res, fmt.Errorf("giving up after %d restarts: %w", res.Restarts, err)
+			}
+			members = alive
+			res.Restarts++
+		}
+	}
+
+	failed := map[int]bool{}
+	for _, r := range res.Failed {
+		failed[r] = true
+		res.Data[r] = nil
+	}
+	for i := 0; i < size; i++ {
+		if !failed[i] {
+			res.Alive = append(res.Alive, i)
+		}
+	}
+	sort.Ints(res.Alive)
+	return res, nil
+}
+
+// runMonitored runs one attempt over the ring formed by members. Position i
+// of the ring is original rank members[i]. Every live rank heartbeats to the
+// detector; a crashed rank stops heartbeating and stays silent, while any
+// other failure aborts the attempt for everybody.
+// Ranks that crash after the others completed are returned in crashed.
+func (c *Communicator) runMonitored(members []int, inputs [][]float64, tag uint64, ft FaultTolerance) (out [][]float64, crashed []int, err error) {
+	p := len(members)
+	if p == 0 {
+		return nil, nil, ErrNoSurvivors
+	}
+	t := c.topologyFor(p)
+	transport, release := c.transport(t)
+	defer release()
+
+	n := len(inputs[0])
+	chunkSize := max((n+p-1)/p, 1)
+	nodes := make([]*Node, p)
+	for i := range nodes {
+		data := make([]float64, p*chunkSize)
+		copy(data, inputs[i])
+		nodes[i] = &Node{Rank: i, P: p, ChunkSize: chunkSize, Data: data, Topology: t, Transport: transport, Op: tag, StepTimeout: ft.StepTimeout, Trace: c.Trace}
+	}
+
+	var (
+		wg       sync.WaitGroup
+		abort    sync.Once
+		firstErr error
+	)
+	wg.Add(p)
+	for i := range nodes {
+		go func(n *Node, rank int) {
+			defer wg.Done()
+			stop := make(chan struct{})
+			beating := make(chan struct{})
+			go func() {
+				defer close(beating)
+				ticker := time.NewTicker(ft.HeartbeatInterval)
+				defer ticker.Stop()
+				ft.Detector.Heartbeat(rank, time.Now())
+				for {
+					select {
+					case <-stop:
+						return
+					case now := <-ticker.C:
+						ft.Detector.Heartbeat(rank, now)
+					}
+				}
+			}()
+
+			n.Err = n.AllReduce()
+			if n.Err == nil || !errors.Is(n.Err, ErrRankCrashed) {
+				// Survivors report one last heartbeat on their way out.
+				ft.Detector.Heartbeat(rank, time.Now())
+			}
+			close(stop)
+			<-beating
+
+			if n.Err != nil && !errors.Is(n.Err, ErrRankCrashed) {
+				abort.Do(func() {
+					firstErr = n.Err
+					closeTransport(transport)
+				})
+			}
+		}(nodes[i], members[i])
+	}
+	wg.Wait()
+	closeTransport(transport)
+
+	if firstErr != nil {
+		return nil, nil, firstErr
+	}
+	out = make([][]float64, p)
+	for i, node := range nodes {
+		if node.Err != nil {
+			crashed = append(crashed, members[i])
+		}
+		out[i] = node.Data[:n]
+	}
+	return out, crashed, nil
+}
+
+// without returns ranks minus the excluded ones, keeping order.
+func without(ranks, excluded []int) []int {
+	skip := map[int]bool{}
+	for _, r := range excluded {
+		skip[r] = true
+	}
+	var out []int
+	for _, r := range ranks {
+		if !skip[r] {
+			out = append(out, r)
+		}
+	}
+	return out
+}
@@ -0,0 +1,204 @@
+package ringallreduce
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sanderblue/algorithms/pkg/phiaccrual"
+)
+
+// crashTransport kills one ring position after it has sent a number of
+// messages: from then on its sends fail with ErrRankCrashed.
+type crashTransport struct {
+	*ChanTransport
+	position int
+	after    int
+
+	mu   sync.Mutex
+	sent int
+}
+
+func (c *crashTransport) Send(rank int, msg Msg) error {
+	if msg.From == c.position {
+		c.mu.Lock()
+		c.sent++
+		dead := c.sent > c.after
+		c.mu.Unlock()
+		if dead {
+			return ErrRankCrashed
+		}
+	}
+	return c.ChanTransport.Send(rank, msg)
+}
+
+// crashOnAttempt returns a transport factory that injects a crash of ring
+// position into the given attempt (0-based) only.
+func crashOnAttempt(attempt, position, after int) func(Topology) Transport {
+	var mu sync.Mutex
+	calls := 0
+	return func(t Topology) Transport {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls-1 == attempt {
+			return &crashTransport{ChanTransport: NewChanTransport(t), position: position, after: after}
+		}
+		return NewChanTransport(t)
+	}
+}
+
+func sequentialInputs(p, n int) [][]float64 {
+	out := make([][]float64, p)
+	for i := range out {
+		out[i] = make([]float64, n)
+		for j := range out[i] {
+			out[i][j] = float64((i+1)*100 + j)
+		}
+	}
+	return out
+}
+
+func sumOver(inputs [][]float64, ranks []int, j int) float64 {
+	s := 0.0
+	for _, r := range ranks {
+		s += inputs[r][j]
+	}
+	return s
+}
+
+func TestCommunicator_AllReduceResilient(t *testing.T) {
+	tests := []struct {
+		name           string
+		p              int
+		segments       int
+		attempt        int // attempt receiving the crash; -1 for none
+		position       int
+		after          int
+		failed         []int
+		segmentMembers [][]int
+		restarts       int
+	}{
+		{
+			name: "no failure", p: 4, segments: 2, attempt: -1,
+			segmentMembers: [][]int{{0, 1, 2, 3}, {0, 1, 2, 3}},
+		},
+		{
+			name: "crash in first segment", p: 5, segments: 3, attempt: 0, position: 2, after: 3,
+			failed:         []int{2},
+			segmentMembers: [][]int{{0, 1, 3, 4}, {0, 1, 3, 4}, {0, 1, 3, 4}},
+			restarts:       1,
+		},
+		{
+			name: "crash in last segment", p: 4, segments: 3, attempt: 2, position: 1, after: 1,
+			failed:         []int{1},
+			segmentMembers: [][]int{{0, 1, 2, 3}, {0, 1, 2, 3}, {0, 2, 3}},
+			restarts:       1,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewCommunicator(tc.p)
+			c.NewTransport = crashOnAttempt(tc.attempt, tc.position, tc.after)
+
+			const n = 12
+			inputs := sequentialInputs(tc.p, n)
+			res, err := c.AllReduceResilient(inputs, FaultTolerance{StepTimeout: 100 * time.Millisecond, Segments: tc.segments})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(res.Failed, tc.failed) {
+				t.Errorf("expected failed %v, got %v", tc.failed, res.Failed)
+			}
+			if !reflect.DeepEqual(res.SegmentMembers, tc.segmentMembers) {
+				t.Errorf("expected segment members %v, got %v", tc.segmentMembers, res.SegmentMembers)
+			}
+			if res.Restarts != tc.restarts {
+				t.Errorf("expected %d restarts, got %d", tc.restarts, res.Restarts)
+			}
+
+			segLen := (n + tc.segments - 1) / tc.segments
+			for _, rank := range res.Alive {
+				for j := 0; j < n; j++ {
+					want := sumOver(inputs, tc.segmentMembers[j/segLen], j)
+					if res.Data[rank][j] != want {
+						t.Errorf("rank=%d, elem=%d: expected %f, got %f", rank, j, want, res.Data[rank][j])
+					}
+				}
+			}
+			for _, rank := range res.Failed {
+				if res.Data[rank] != nil {
+					t.Errorf("rank=%d: expected no data for failed rank", rank)
+				}
+			}
+		})
+	}
+}
+
+func TestCommunicator_AllReduceResilientPhiAccrual(t *testing.T) {
+	c := NewCommunicator(5)
+	c.NewTransport = crashOnAttempt(0, 3, 2)
+	inputs := sequentialInputs(5, 10)
+	detector := phiaccrual.New(phiaccrual.Config{MinStdDev: 5 * time.Millisecond, FirstHeartbeatEstimate: 10 * time.Millisecond})
+	res, err := c.AllReduceResilient(inputs, FaultTolerance{StepTimeout: 100 * time.Millisecond, Detector: detector})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(res.Failed, []int{3}) || res.Restarts != 1 {
+		t.Fatalf("expected rank 3 to fail once, got failed %v after %d restarts", res.Failed, res.Restarts)
+	}
+	for _, rank := range res.Alive {
+		for j := range res.Data[rank] {
+			if want := sumOver(inputs, res.Alive, j); res.Data[rank][j] != want {
+				t.Fatalf("rank=%d, elem=%d: expected %f, got %f", rank, j, want, res.Data[rank][j])
+			}
+		}
+	}
+}
+
+func TestCommunicator_AllReduceResilientConfig(t *testing.T) {
+	c := NewCommunicator(3)
+	inputs := [][]float64{{1}, {2}, {3}}
+	if _, err := c.AllReduceResilient(inputs, FaultTolerance{}); err == nil {
+		t.Error("expected error for a zero step timeout")
+	}
+	if _, err := c.AllReduceResilient(inputs, FaultTolerance{StepTimeout: -time.Second}); err == nil {
+		t.Error("expected error for a negative step timeout")
+	}
+
+	// A step timeout under 10ns used to default to a zero heartbeat
+	// interval, which made the heartbeat ticker panic.
+	res, err := c.AllReduceResilient(inputs, FaultTolerance{StepTimeout: 5 * time.Nanosecond})
+	if err == nil && len(res.Alive) == 0 {
+		t.Error("succeeded without survivors")
+	}
+}
+
+func TestTimeoutDetector(t *testing.T) {
+	d := NewTimeoutDetector(time.Second)
+	now := time.Now()
+	d.Heartbeat(0, now)
+	d.Heartbeat(1, now.Add(-2*time.Second))
+
+	if d.Suspect(0, now) {
+		t.Error("rank 0 heartbeat is fresh")
+	}
+	if !d.Suspect(1, now) {
+		t.Error("rank 1 heartbeat is stale")
+	}
+	if d.Suspect(2, now) {
+		t.Error("rank 2 never reported and must not be suspected")
+	}
+}
+
+func TestCommunicator_AllReduceResilientEmptyGroup(t *testing.T) {
+	c := NewCommunicator(0)
+	if _, err := c.AllReduceResilient(nil, FaultTolerance{StepTimeout: time.Second}); !errors.Is(err, ErrNoRanks) {
+		t.Errorf("got %v, want ErrNoRanks", err)
+	}
+}
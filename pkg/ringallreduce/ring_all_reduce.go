@@ -5,10 +5,15 @@
 package ringallreduce
 
 import (
+	"errors"
 	"fmt"
 	"sync"
+	"time"
 )
 
+// ErrNoRanks is returned when running a collective over a group of no ranks.
+var ErrNoRanks = errors.New("collective needs at least one rank")
+
 type RingAllReduce struct{}
 
 func New() RingAllReduce {
@@ -17,105 +22,310 @@ func New() RingAllReduce {
 
 // Msg models a message sent between processes.
 type Msg struct {
+	From     int       // rank of the sender
 	ChunkIdx int       // which chunk the message contains
 	Data     []float64 // the slice of data for that chunk
+	Op       uint64    // collective invocation the message belongs to
+	Seq      uint64    // per-link sequence number, set by ReliableTransport
+	Ack      bool      // acknowledgement of every Seq up to and including this one
 }
 
 // Node models a participant in the ring all–reduce.
 type Node struct {
-	Rank      int       // process index (0..P-1)
-	P         int       // total number of processes
-	ChunkSize int       // size of a single chunk (each vector length is P*ChunkSize)
-	Data      []float64 // local data buffer; logically divided into P chunks
-	In        chan Msg  // channel from which this process receives messages (from its left neighbor)
-	Out       chan Msg  // channel to which this process sends messages (to its right neighbor)
+	Rank        int             // process index (0..P-1)
+	P           int             // total number of processes
+	ChunkSize   int             // size of a single chunk (each vector length is P*ChunkSize)
+	Data        []float64       // local data buffer; logically divided into P chunks
+	In          chan Msg        // channel from which this process receives messages (from its left neighbor)
+	Out         chan Msg        // channel to which this process sends messages (to its right neighbor)
+	Topology    Topology        // wiring and schedule; defaults to a Ring of size P
+	Transport   Transport       // message transport; defaults to sending on Out and receiving on In
+	Op          uint64          // tag of the collective invocation; messages with another tag are discarded
+	StepTimeout time.Duration   // bound on each receive when the transport is a TimeoutReceiver; 0 waits forever
+	StepTimes   []time.Duration // duration of every schedule step of the last AllReduce
+	Trace       *Trace          // records send, receive and reduce events when set
+	Watchdog    *Watchdog       // told when the node blocks and progresses, when set
+	Memory      *MemoryBudget   // accounts buffers and queued messages when set
+	Pool        *BufferPool     // recycles message buffers when set; see BufferPool for the caveats
+	Stats       RankStats       // measurements of the last AllReduce
+	Err         error           // error that stopped Run, if any
+
+	// OnCheckpoint, if set, is called with the node's state whenever the
+	// node makes progress: after the sends of a step, after every chunk it
+	// receives and after every step.
+	OnCheckpoint func(Checkpoint)
+
+	// OnChunk, if set, is called as soon as chunk idx of Data holds its
+	// final value: right after the last receive of that chunk in the
+	// schedule, which in a ring is the end of the reduce–scatter for the
+	// chunk the rank owns and every allgather arrival for the others. data
+	// aliases Data and must not be modified. It lets callers consume
+	// finished chunks while the rest of the vector is still in flight.
+	OnChunk func(idx int, data []float64)
+
+	pending  []Msg // messages received ahead of the step that consumes them
+	phase    Phase // phase of the step in progress
+	step     int   // index of the schedule step in progress
+	sent     bool  // whether the sends of the current step are done
+	received int   // receives of the current step already applied
+	charged  bool  // whether Data and pending are accounted in Memory
 }
 
-// Run executes the ring all–reduce algorithm for one process.
-// It performs a reduce–scatter phase followed by an allgather phase.
+// Run executes the all–reduce for one process and records any transport
+// error in Err. With the default ring it performs a reduce–scatter phase
+// followed by an allgather phase.
 func (proc *Node) Run(wg *sync.WaitGroup) {
 	defer wg.Done()
+	proc.Err = proc.AllReduce()
+}
+
+// AllReduce executes the all–reduce schedule of the node's topology. If an
+// earlier call failed part way, or the node was restored with ResumeFrom, it
+// continues from the step where it stopped.
+func (proc *Node) AllReduce() error {
+	steps := proc.topology().Schedule(proc.Rank)
+	fresh := proc.step == 0 && !proc.sent && proc.received == 0
+	if fresh {
+		proc.StepTimes = proc.StepTimes[:0]
+		proc.Stats = RankStats{}
+	}
+	var last map[int][2]int
+	if proc.OnChunk != nil {
+		last = lastReceives(steps)
+		if fresh {
+			// Chunks the rank never receives are final from the start.
+			for idx := 0; idx*proc.ChunkSize < len(proc.Data); idx++ {
+				if _, ok := last[idx]; !ok {
+					proc.chunkDone(idx)
+				}
+			}
+		}
+	}
+	if !proc.charged {
+		bytes := 8 * int64(len(proc.Data))
+		for _, m := range proc.pending {
+			bytes += 8 * int64(len(m.Data))
+		}
+		if err := proc.Memory.alloc(proc.Rank, "buffer", bytes); err != nil {
+			return err
+		}
+		proc.charged = true
+	}
+	defer proc.Watchdog.done(proc.Rank)
+	for proc.step < len(steps) {
+		step := steps[proc.step]
+		proc.phase = step.Phase
+		proc.Watchdog.running(proc.Rank, proc.step, step.Phase)
+		began := time.Now()
+		if step.SendTo != NoPeer && !proc.sent {
+			for _, idx := range step.SendChunks {
+				at := time.Now()
+				proc.Watchdog.blocked(proc.Rank, proc.step, step.Phase, true, step.SendTo, idx)
+				if err := proc.send(step.SendTo, idx); err != nil {
+					return fmt.Errorf("node %d (%s): %w", proc.Rank, step.Phase, err)
+				}
+				proc.Watchdog.running(proc.Rank, proc.step, step.Phase)
+				proc.trace(TraceSend, step, step.SendTo, idx, at)
+			}
+			proc.sent = true
+			proc.checkpoint()
+		}
+		if step.RecvFrom != NoPeer {
+			for proc.received < len(step.RecvChunks) {
+				idx := step.RecvChunks[proc.received]
+				at := time.Now()
+				received, err := proc.recv(step.RecvFrom, idx)
+				if err != nil {
+					return fmt.Errorf("node %d (%s): %w", proc.Rank, step.Phase, err)
+				}
+				proc.trace(TraceRecv, step, step.RecvFrom, idx, at)
+				at = time.Now()
+				start := idx * proc.ChunkSize
+				if step.Reduce {
+					// Element–wise reduction.
+					for i := 0; i < proc.ChunkSize; i++ {
+						proc.Data[start+i] += received.Data[i]
+					}
+					proc.trace(TraceReduce, step, NoPeer, idx, at)
+				} else {
+					copy(proc.Data[start:start+proc.ChunkSize], received.Data)
+					proc.trace(TraceCopy, step, NoPeer, idx, at)
+				}
+				proc.Memory.free(8 * int64(len(received.Data)))
+				proc.Stats.MessagesReceived++
+				proc.Stats.BytesReceived += 8 * len(received.Data)
+				proc.Pool.put(received.Data)
+				if pos, ok := last[idx]; ok && pos == [2]int{proc.step, proc.received} {
+					proc.chunkDone(idx)
+				}
+				proc.received++
+				if proc.received < len(step.RecvChunks) {
+					proc.checkpoint()
+				}
+			}
+		}
+		proc.step++
+		proc.sent = false
+		proc.received = 0
+		proc.StepTimes = append(proc.StepTimes, time.Since(began))
+		proc.Stats.Steps++
+		proc.Stats.phaseTime(step.Phase, time.Since(began))
+		proc.checkpoint()
+	}
+	proc.step = 0
+	return nil
+}
+
+// lastReceives maps every chunk received in steps to the step and the
+// position within the step of its last receive.
+func lastReceives(steps []Step) map[int][2]int {
+	last := make(map[int][2]int)
+	for i, step := range steps {
+		if step.RecvFrom == NoPeer {
+			continue
+		}
+		for j, idx := range step.RecvChunks {
+			last[idx] = [2]int{i, j}
+		}
+	}
+	return last
+}
+
+// chunkDone reports chunk idx as final to OnChunk.
+func (proc *Node) chunkDone(idx int) {
+	start := idx * proc.ChunkSize
+	proc.OnChunk(idx, proc.Data[start:start+proc.ChunkSize:start+proc.ChunkSize])
+}
+
+func (proc *Node) topology() Topology {
+	if proc.Topology != nil {
+		return proc.Topology
+	}
+	return NewRing(proc.P)
+}
+
+func (proc *Node) transport() Transport {
+	if proc.Transport != nil {
+		return proc.Transport
+	}
+	return pairTransport{in: proc.In, out: proc.Out}
+}
+
+// send copies chunk idx and delivers it to rank to.
+func (proc *Node) send(to, idx int) error {
+	bytes := 8 * int64(proc.ChunkSize)
+	if err := proc.Memory.alloc(proc.Rank, "send buffer", bytes); err != nil {
+		return err
+	}
+	defer proc.Memory.free(bytes)
+
+	start := idx * proc.ChunkSize
+	msgData, allocated := proc.Pool.get(proc.ChunkSize)
+	copy(msgData, proc.Data[start:start+proc.ChunkSize])
+	if allocated {
+		proc.Stats.Allocs++
+	}
+	proc.Stats.MessagesSent++
+	proc.Stats.BytesSent += 8 * proc.ChunkSize
+
+	return proc.transport().Send(to, Msg{From: proc.Rank, ChunkIdx: idx, Data: msgData, Op: proc.Op})
+}
+
+// recv returns the next message carrying chunk idx from rank from. Messages
+// for later steps that arrive early are kept until they are asked for, and
+// stale messages of other invocations are dropped.
+func (proc *Node) recv(from, idx int) (Msg, error) {
+	for i, m := range proc.pending {
+		if m.From == from && m.ChunkIdx == idx {
+			proc.pending = append(proc.pending[:i], proc.pending[i+1:]...)
+			proc.consume(m)
+			return m, nil
+		}
+	}
+	for {
+		proc.Watchdog.blocked(proc.Rank, proc.step, proc.phase, false, from, idx)
+		m, err := proc.receive()
+		proc.Watchdog.running(proc.Rank, proc.step, proc.phase)
+		if err != nil {
+			if errors.Is(err, ErrStepTimeout) {
+				return Msg{}, fmt.Errorf("waiting for chunk %d from rank %d: %w", idx, from, err)
+			}
+			return Msg{}, err
+		}
+		if m.Op != proc.Op {
+			continue
+		}
+		if err := proc.Memory.alloc(proc.Rank, "queued message", 8*int64(len(m.Data))); err != nil {
+			return Msg{}, err
+		}
+		if m.From == from && m.ChunkIdx == idx {
+			proc.consume(m)
+			return m, nil
+		}
+		proc.pending = append(proc.pending, m)
+		proc.checkpoint()
+	}
+}
+
+// trace records an event of the current step that began at start.
+func (proc *Node) trace(kind string, step Step, peer, idx int, start time.Time) {
+	if proc.Trace == nil {
+		return
+	}
+	proc.Trace.record(TraceEvent{
+		Rank:     proc.Rank,
+		Kind:     kind,
+		Op:       proc.Op,
+		Step:     proc.step,
+		Phase:    step.Phase,
+		Peer:     peer,
+		Chunk:    idx,
+		Bytes:    8 * proc.ChunkSize,
+		Start:    start,
+		Duration: time.Since(start),
+	})
+}
+
+// consume tells transports that track it that m is being used now.
+func (proc *Node) consume(m Msg) {
+	if c, ok := proc.transport().(consumer); ok {
+		c.consumed(proc.Rank, m)
+	}
+}
 
-	// -------------------------------------------------
-	// Reduce–Scatter phase:
-	// In P–1 steps, each process sends a chunk (using indices computed cyclically)
-	// and receives a corresponding chunk from its left neighbor.
-	// The received chunk is added (element–wise) to the chunk in the local buffer.
-	// At the end of this phase, each process holds a fully reduced segment.
-	// The designated segment is at index: D = (Rank - (P-1) + P) mod P,
-	// which simplifies to: D = (Rank + 1) mod P.
-	// -------------------------------------------------
-	for s := 0; s < proc.P-1; s++ {
-		sendIdx := (proc.Rank - s + proc.P) % proc.P
-		recvIdx := (proc.Rank - s - 1 + proc.P) % proc.P
-
-		// Copy chunk to send.
-		startSend := sendIdx * proc.ChunkSize
-		msgData := make([]float64, proc.ChunkSize)
-		copy(msgData, proc.Data[startSend:startSend+proc.ChunkSize])
-		proc.Out <- Msg{ChunkIdx: sendIdx, Data: msgData}
-
-		// Receive message.
-		received := <-proc.In
-		if received.ChunkIdx != recvIdx {
-			fmt.Printf("Node %d (Reduce–Scatter): Expected chunk %d but received %d\n",
-				proc.Rank, recvIdx, received.ChunkIdx)
-		}
-		// Element–wise reduction.
-		startRecv := recvIdx * proc.ChunkSize
-		for i := 0; i < proc.ChunkSize; i++ {
-			proc.Data[startRecv+i] += received.Data[i]
-		}
-	}
-
-	// -------------------------------------------------
-	// Allgather phase:
-	// After reduce–scatter, each process holds a complete reduced chunk.
-	// We choose the reduced chunk to reside at index D = (Rank + 1) mod P.
-	// In the allgather phase the goal is to circulate the reduced chunks so that each
-	// process ends with the complete reduced vector.
-	// We perform a rotation for s = 0, 1, …, P–2:
-	//   sendIdx = (Rank + 1 + s) mod P
-	//   recvIdx = (Rank + 2 + s) mod P
-	// This way, the designated reduced segment is first sent to the right and all segments are filled in.
-	// -------------------------------------------------
-	for s := 0; s < proc.P-1; s++ {
-		sendIdx := (proc.Rank + 1 - s + proc.P) % proc.P
-		recvIdx := (proc.Rank - s + proc.P) % proc.P
-
-		// Copy chunk to send.
-		startSend := sendIdx * proc.ChunkSize
-		msgData := make([]float64, proc.ChunkSize)
-		copy(msgData, proc.Data[startSend:startSend+proc.ChunkSize])
-		proc.Out <- Msg{ChunkIdx: sendIdx, Data: msgData}
-
-		// Receive chunk and place it into the proper position.
-		received := <-proc.In
-		if received.ChunkIdx != recvIdx {
-			fmt.Printf("Node %d (Allgather): Expected chunk %d but received %d\n",
-				proc.Rank, recvIdx, received.ChunkIdx)
-		}
-		startRecv := recvIdx * proc.ChunkSize
-		copy(proc.Data[startRecv:startRecv+proc.ChunkSize], received.Data)
+func (proc *Node) receive() (Msg, error) {
+	if proc.StepTimeout > 0 {
+		if tr, ok := proc.transport().(TimeoutReceiver); ok {
+			return tr.RecvTimeout(proc.Rank, proc.StepTimeout)
+		}
 	}
+	return proc.transport().Recv(proc.Rank)
 }
 
 // Each process’ vector is composed of n chunks (total length = n * chunkSize = vector)
 func (r *RingAllReduce) Execute(procs int, chunkSize int) []*Node {
-	// For demonstration, simulate 4 processes.
-	p := procs
+	return r.ExecuteTopology(NewRing(procs), chunkSize)
+}
+
+// Execute is RingAllReduce.Execute as a package–level function.
+func Execute(procs int, chunkSize int) []*Node {
+	r := New()
+	return r.Execute(procs, chunkSize)
+}
+
+// ExecuteTopology runs the all–reduce over an arbitrary topology. The vector
+// of every process is composed of t.Size() chunks of chunkSize elements.
+func (r *RingAllReduce) ExecuteTopology(t Topology, chunkSize int) []*Node {
+	p := t.Size()
 	totalSize := p * chunkSize // total number of elements
 
-	// Create a channel for each process.
-	// We arrange the ring so that process i sends to process (i+1) mod p.
-	channels := make([]chan Msg, p)
-	for i := 0; i < p; i++ {
-		channels[i] = make(chan Msg, 2) // buffered to help avoid deadlock.
-	}
+	// Create an inbox for each process.
+	transport := NewChanTransport(t)
+	channels := transport.Inboxes
 
 	// Initialize processes.
 	// Each process’s vector is filled with a constant equal to (Rank+1).
-	// Therefore, the element–wise reduction (using addition) should yield sum 1+2+3+4 = 10.
+	// Therefore, the element–wise reduction (using addition) should yield sum 1+2+…+p.
 	processes := make([]*Node, p)
 	for i := 0; i < p; i++ {
 		data := make([]float64, totalSize)
@@ -129,6 +339,8 @@ func (r *RingAllReduce) Execute(procs int, chunkSize int) []*Node {
 			Data:      data,
 			In:        channels[i],
 			Out:       channels[(i+1)%p],
+			Topology:  t,
+			Transport: transport,
 		}
 	}
 
@@ -141,10 +353,139 @@ func (r *RingAllReduce) Execute(procs int, chunkSize int) []*Node {
 	wg.Wait()
 
 	// Print final data.
-	// Every element should equal 10.
+	// Every element should equal p(p+1)/2.
 	for i := 0; i < p; i++ {
 		fmt.Printf("Node %d final data: %v\n", i, processes[i].Data)
 	}
 
 	return processes
 }
+
+// AllReduce sums the vectors of all ranks over topology t, where inputs[i] is
+// the vector of rank i, and returns the reduced vector seen by every rank.
+// Vectors must share one length; they are zero padded internally to a
+// multiple of the topology size.
+func (r *RingAllReduce) AllReduce(t Topology, inputs [][]float64) ([][]float64, error) {
+	out, _, err := runCollective(t, NewChanTransport(t), 0, inputs, runOptions{})
+	return out, err
+}
+
+// AllReduceStats is AllReduce that also returns the statistics of the run.
+func (r *RingAllReduce) AllReduceStats(t Topology, inputs [][]float64) ([][]float64, OpStats, error) {
+	var stats OpStats
+	out, _, err := runCollective(t, NewChanTransport(t), 0, inputs, runOptions{stats: &stats})
+	return out, stats, err
+}
+
+// runOptions are the optional instruments of runCollective.
+type runOptions struct {
+	trace    *Trace        // records events when set
+	deadlock time.Duration // watchdog threshold; 0 disables the watchdog
+	memory   int64         // per-rank memory budget in bytes; 0 is unlimited
+	// onChunk, if set, receives every chunk of every rank once it is final,
+	// with offset its position in the vector and the padding cut off.
+	onChunk func(rank, offset int, data []float64)
+	stats   *OpStats // filled in on success when set
+}
+
+// runCollective runs one all–reduce invocation tagged op over transport
+// without touching inputs and returns the results along with the step times
+// of all ranks. When a rank fails the transport is closed, if it supports it,
+// so that no other rank stays blocked waiting for messages.
+func runCollective(t Topology, transport Transport, op uint64, inputs [][]float64, opts runOptions) ([][]float64, []time.Duration, error) {
+	p := t.Size()
+	if p == 0 {
+		return nil, nil, ErrNoRanks
+	}
+	if len(inputs) != p {
+		return nil, nil, fmt.Errorf("got %d input vectors for %d ranks", len(inputs), p)
+	}
+	n := len(inputs[0])
+	for i, in := range inputs {
+		if len(in) != n {
+			return nil, nil, fmt.Errorf("rank %d: vector length %d differs from %d", i, len(in), n)
+		}
+	}
+	if err := CheckMemory(t, n, opts.memory); err != nil {
+		return nil, nil, err
+	}
+	chunkSize := (n + p - 1) / p
+	if chunkSize == 0 {
+		chunkSize = 1
+	}
+
+	// ChanTransport hands every message to its receiver and forgets it, so
+	// the buffers can be recycled.
+	var pool *BufferPool
+	if _, ok := transport.(*ChanTransport); ok {
+		pool = NewBufferPool(chunkSize)
+	}
+	nodes := make([]*Node, p)
+	for i := 0; i < p; i++ {
+		data := make([]float64, p*chunkSize)
+		copy(data, inputs[i])
+		nodes[i] = &Node{Rank: i, P: p, ChunkSize: chunkSize, Data: data, Topology: t, Transport: transport, Op: op, Trace: opts.trace, Pool: pool}
+		if opts.memory > 0 {
+			nodes[i].Memory = NewMemoryBudget(opts.memory)
+		}
+		if opts.onChunk != nil {
+			rank := i
+			nodes[i].OnChunk = func(idx int, data []float64) {
+				start := idx * chunkSize
+				if start < n {
+					opts.onChunk(rank, start, data[:min(chunkSize, n-start)])
+				}
+			}
+		}
+	}
+
+	var watchdog *Watchdog
+	if opts.deadlock > 0 {
+		watchdog = NewWatchdog(opts.deadlock)
+		for _, n := range nodes {
+			n.Watchdog = watchdog
+			watchdog.running(n.Rank, 0, PhaseReduceScatter)
+		}
+		watchdog.Start(func() { closeTransport(transport) })
+		defer watchdog.Stop()
+	}
+
+	var (
+		wg       sync.WaitGroup
+		abort    sync.Once
+		firstErr error
+	)
+	began := time.Now()
+	wg.Add(p)
+	for i := 0; i < p; i++ {
+		go func(n *Node) {
+			defer wg.Done()
+			if n.Err = n.AllReduce(); n.Err != nil {
+				abort.Do(func() {
+					firstErr = n.Err
+					closeTransport(transport)
+				})
+			}
+		}(nodes[i])
+	}
+	wg.Wait()
+
+	if watchdog != nil && watchdog.Err() != nil {
+		return nil, nil, watchdog.Err()
+	}
+	if firstErr != nil {
+		return nil, nil, firstErr
+	}
+	if opts.stats != nil {
+		*opts.stats = StatsOf(nodes)
+		opts.stats.Elements = n
+		opts.stats.Duration = time.Since(began)
+	}
+	out := make([][]float64, p)
+	var steps []time.Duration
+	for i, node := range nodes {
+		out[i] = node.Data[:n]
+		steps = append(steps, node.StepTimes...)
+	}
+	return out, steps, nil
+}
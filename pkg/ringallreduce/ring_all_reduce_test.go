@@ -1,6 +1,8 @@
 package ringallreduce
 
 import (
+	"errors"
+	"reflect"
 	"sync"
 	"testing"
 )
@@ -122,3 +124,146 @@ func TestRingAllReduce_CustomData_DistinctChunks(t *testing.T) {
 		})
 	}
 }
+
+func TestRingAllReduce_AllReduce(t *testing.T) {
+	tests := []struct {
+		name     string
+		topology Topology
+		inputs   [][]float64
+		expected []float64
+	}{
+		{
+			name:     "ring padded",
+			topology: NewRing(3),
+			inputs:   [][]float64{{1, 2, 3, 4}, {10, 20, 30, 40}, {100, 200, 300, 400}},
+			expected: []float64{111, 222, 333, 444},
+		},
+		{
+			name:     "tree shorter than p",
+			topology: NewTree(4),
+			inputs:   [][]float64{{1}, {2}, {3}, {4}},
+			expected: []float64{10},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			r := New()
+			result, err := r.AllReduce(tc.topology, tc.inputs)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for rank, got := range result {
+				if !reflect.DeepEqual(got, tc.expected) {
+					t.Errorf("rank=%d: expected %v, got %v", rank, tc.expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRingAllReduce_AllReduce_InvalidInputs(t *testing.T) {
+	r := New()
+	if _, err := r.AllReduce(NewRing(2), [][]float64{{1}}); err == nil {
+		t.Error("expected error for missing rank input")
+	}
+	if _, err := r.AllReduce(NewRing(2), [][]float64{{1}, {1, 2}}); err == nil {
+		t.Error("expected error for mismatched lengths")
+	}
+	if _, err := r.AllReduce(NewRing(0), nil); !errors.Is(err, ErrNoRanks) {
+		t.Errorf("empty ring: got %v, want ErrNoRanks", err)
+	}
+}
+
+func TestNode_OnChunk(t *testing.T) {
+	tests := []struct {
+		name     string
+		topology Topology
+	}{
+		{"ring", NewRing(4)},
+		{"tree", NewTree(5)},
+		{"torus", NewTorus(2, 3)},
+		{"fully-connected", NewFullyConnected(3)},
+		{"recursive-doubling", NewRecursiveDoubling(4)},
+		{"parameter-server", NewParameterServer(4)},
+		{"single rank", NewRing(1)},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			const n = 10
+			p := tc.topology.Size()
+			c := NewCommunicator(p)
+			c.Topology = func(int) Topology { return tc.topology }
+			var mu sync.Mutex
+			seen := make([][]float64, p) // what every rank saw, by offset
+			order := make([][]int, p)
+			for r := range seen {
+				seen[r] = make([]float64, n)
+			}
+			c.OnChunk = func(rank, offset int, data []float64) {
+				mu.Lock()
+				defer mu.Unlock()
+				copy(seen[rank][offset:], data)
+				order[rank] = append(order[rank], offset)
+			}
+
+			data := vectors(p, n)
+			if err := c.AllReduce(c.NewOpID(), data); err != nil {
+				t.Fatalf("AllReduce: %v", err)
+			}
+			for r := range data {
+				// Every element was reported exactly once, already final.
+				if !reflect.DeepEqual(seen[r], data[r]) {
+					t.Errorf("rank %d saw %v, result %v", r, seen[r], data[r])
+				}
+				covered := 0
+				for _, offset := range order[r] {
+					covered += min(n-offset, (n+p-1)/p)
+				}
+				if covered != n {
+					t.Errorf("rank %d: chunks at %v cover %d elements, want %d", r, order[r], covered, n)
+				}
+			}
+		})
+	}
+}
+
+func TestNode_OnChunkRingOwnershipFirst(t *testing.T) {
+	// At the end of the ring's reduce–scatter every rank owns a distinct
+	// chunk, which is reported before any allgather arrival.
+	const p = 4
+	nodes := make([]*Node, p)
+	transport := NewChanTransport(NewRing(p))
+	first := make([]int, p)
+	for r := range nodes {
+		r := r
+		first[r] = -1
+		nodes[r] = &Node{Rank: r, P: p, ChunkSize: 2, Data: vectors(p, 2*p)[r], Transport: transport}
+		nodes[r].OnChunk = func(idx int, _ []float64) {
+			if first[r] == -1 {
+				first[r] = idx
+				if nodes[r].phase != PhaseReduceScatter {
+					t.Errorf("rank %d: first chunk %d reported in phase %v", r, idx, nodes[r].phase)
+				}
+			}
+		}
+	}
+	var wg sync.WaitGroup
+	wg.Add(p)
+	for _, n := range nodes {
+		go n.Run(&wg)
+	}
+	wg.Wait()
+	owned := map[int]bool{}
+	for r, idx := range first {
+		if nodes[r].Err != nil {
+			t.Fatal(nodes[r].Err)
+		}
+		owned[idx] = true
+	}
+	if len(owned) != p {
+		t.Errorf("first chunks %v are not distinct", first)
+	}
+}
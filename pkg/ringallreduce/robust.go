@@ -0,0 +1,232 @@
+package ringallreduce
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// RobustRule is an aggregation rule that tolerates Byzantine ranks.
+type RobustRule int
+
+const (
+	// RobustMedian takes the coordinate-wise median. Needs 2f+1 ranks.
+	RobustMedian RobustRule = iota
+	// RobustTrimmedMean drops the f largest and f smallest values of every
+	// coordinate and averages the rest. Needs 2f+1 ranks.
+	RobustTrimmedMean
+	// RobustKrum picks the vector whose p-f-2 nearest neighbours are the
+	// closest, in squared Euclidean distance. Needs 2f+3 ranks.
+	RobustKrum
+)
+
+func (r RobustRule) String() string {
+	switch r {
+	case RobustMedian:
+		return "median"
+	case RobustTrimmedMean:
+		return "trimmed-mean"
+	case RobustKrum:
+		return "krum"
+	default:
+		return fmt.Sprintf("robust(%d)", int(r))
+	}
+}
+
+// minRanks returns the smallest group in which r tolerates f faulty ranks.
+func (r RobustRule) minRanks(f int) int {
+	if r == RobustKrum {
+		return 2*f + 3
+	}
+	return 2*f + 1
+}
+
+// RobustConfig configures AllReduceRobust.
+type RobustConfig struct {
+	Rule   RobustRule
+	Faulty int // number of malicious ranks to tolerate
+}
+
+// AllReduceRobust aggregates data, the vector of every rank, with a rule
+// that tolerates up to cfg.Faulty ranks contributing arbitrary vectors.
+// Robust rules need every vector rather than partial sums, so instead of the
+// communicator's topology the ranks exchange their vectors directly, all to
+// all: a malicious rank can corrupt nothing but its own contribution, and
+// every rank aggregates what it received on its own. The aggregate is on the
+// scale of one input, like a mean, not of the sum. Result i is the aggregate
+// computed by rank i; data is left untouched.
+//
+// References:
+//
+// https://arxiv.org/abs/1803.01498 (coordinate-wise median, trimmed mean)
+// https://papers.nips.cc/paper/6617-machine-learning-with-adversaries-byzantine-tolerant-gradient-descent (Krum)
+func (c *Communicator) AllReduceRobust(data [][]float64, cfg RobustConfig) ([][]float64, error) {
+	c.mu.Lock()
+	p := len(c.members)
+	if len(data) != p {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("got %d vectors for %d ranks", len(data), p)
+	}
+	if p == 0 {
+		c.mu.Unlock()
+		return nil, ErrNoRanks
+	}
+	if err := c.begin(); err != nil {
+		c.mu.Unlock()
+		return nil, err
+	}
+	c.attempts++
+	tag := c.attempts
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.end()
+		c.mu.Unlock()
+	}()
+
+	if cfg.Rule < RobustMedian || cfg.Rule > RobustKrum {
+		return nil, fmt.Errorf("unknown robust rule %v", cfg.Rule)
+	}
+	if cfg.Faulty < 0 || p < cfg.Rule.minRanks(cfg.Faulty) {
+		return nil, fmt.Errorf("%v can't tolerate %d faulty ranks out of %d", cfg.Rule, cfg.Faulty, p)
+	}
+	m := len(data[0])
+	for i, v := range data {
+		if len(v) != m {
+			return nil, fmt.Errorf("rank %d: vector length %d differs from %d", i, len(v), m)
+		}
+	}
+	if m == 0 {
+		return make([][]float64, p), nil
+	}
+
+	// Rank r's vector is chunk r of a p*m buffer that only r ever sends.
+	t := exchangeTopology{p: p}
+	placed := make([][]float64, p)
+	for r := range placed {
+		placed[r] = make([]float64, p*m)
+		copy(placed[r][r*m:], data[r])
+	}
+	opts := c.runOptions()
+	opts.onChunk = nil // the exchanged chunks are inputs, not results
+	transport, release := c.transport(t)
+	gathered, _, err := runCollective(t, transport, tag, placed, opts)
+	release()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([][]float64, p)
+	for r, buf := range gathered {
+		vecs := make([][]float64, p)
+		for i := range vecs {
+			vecs[i] = buf[i*m : (i+1)*m]
+		}
+		out[r] = aggregateRobust(cfg.Rule, cfg.Faulty, vecs)
+	}
+	return out, nil
+}
+
+// aggregateRobust applies rule to vecs, f of which may be malicious.
+func aggregateRobust(rule RobustRule, f int, vecs [][]float64) []float64 {
+	p, m := len(vecs), len(vecs[0])
+	if rule == RobustKrum {
+		return append([]float64(nil), vecs[krum(f, vecs)]...)
+	}
+	out := make([]float64, m)
+	column := make([]float64, p)
+	for j := range out {
+		for i, v := range vecs {
+			column[i] = v[j]
+		}
+		sort.Float64s(column)
+		if rule == RobustMedian {
+			if p%2 == 1 {
+				out[j] = column[p/2]
+			} else {
+				out[j] = (column[p/2-1] + column[p/2]) / 2
+			}
+			continue
+		}
+		var sum float64
+		for _, x := range column[f : p-f] {
+			sum += x
+		}
+		out[j] = sum / float64(p-2*f)
+	}
+	return out
+}
+
+// krum returns the index of the vector with the smallest sum of squared
+// distances to its p-f-2 nearest neighbours.
+func krum(f int, vecs [][]float64) int {
+	p := len(vecs)
+	dist := make([][]float64, p)
+	for i := range dist {
+		dist[i] = make([]float64, p)
+	}
+	for i := range vecs {
+		for k := i + 1; k < p; k++ {
+			var d float64
+			for j := range vecs[i] {
+				x := vecs[i][j] - vecs[k][j]
+				d += x * x
+			}
+			dist[i][k], dist[k][i] = d, d
+		}
+	}
+	best, bestScore := 0, math.Inf(1)
+	for i := range vecs {
+		others := make([]float64, 0, p-1)
+		for k, d := range dist[i] {
+			if k != i {
+				others = append(others, d)
+			}
+		}
+		sort.Float64s(others)
+		var score float64
+		for _, d := range others[:p-f-2] {
+			score += d
+		}
+		if score < bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// exchangeTopology sends chunk r of rank r directly to every other rank and
+// never reduces: an all-to-all broadcast where each chunk only ever travels
+// from its owner.
+type exchangeTopology struct {
+	p int
+}
+
+func (t exchangeTopology) Name() string { return "exchange" }
+
+func (t exchangeTopology) Size() int { return t.p }
+
+func (t exchangeTopology) Neighbors(rank int) []int {
+	var out []int
+	for r := 0; r < t.p; r++ {
+		if r != rank {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func (t exchangeTopology) Schedule(rank int) []Step {
+	steps := make([]Step, 0, t.p-1)
+	for k := 1; k < t.p; k++ {
+		from := (rank - k + t.p) % t.p
+		steps = append(steps, Step{
+			Phase:      PhaseAllGather,
+			SendTo:     (rank + k) % t.p,
+			SendChunks: []int{rank},
+			RecvFrom:   from,
+			RecvChunks: []int{from},
+		})
+	}
+	return steps
+}
@@ -0,0 +1,134 @@
+package ringallreduce
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// poisoned returns the vectors of p ranks where rank i holds i+1 everywhere,
+// except ranks 0 and 3 which send huge values of opposite signs.
+func poisoned(p, n int) [][]float64 {
+	data := vectors(p, n)
+	for j := 0; j < n; j++ {
+		data[0][j] = 1e12
+		data[3][j] = -1e12
+	}
+	return data
+}
+
+func TestCommunicator_AllReduceRobust(t *testing.T) {
+	tests := []struct {
+		rule RobustRule
+		want float64
+	}{
+		// The honest ranks hold 2, 3, 5, 6 and 7.
+		{RobustMedian, 5},
+		{RobustTrimmedMean, 14.0 / 3},
+		{RobustKrum, 5},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.rule.String(), func(t *testing.T) {
+			const p, n = 7, 10
+			data := poisoned(p, n)
+			out, err := NewCommunicator(p).AllReduceRobust(data, RobustConfig{Rule: tc.rule, Faulty: 2})
+			if err != nil {
+				t.Fatalf("AllReduceRobust: %v", err)
+			}
+			for r, v := range out {
+				for j, x := range v {
+					if math.Abs(x-tc.want) > 1e-12 {
+						t.Fatalf("rank %d element %d: got %v, want %v", r, j, x, tc.want)
+					}
+				}
+			}
+			if data[0][0] != 1e12 {
+				t.Error("inputs were modified")
+			}
+		})
+	}
+}
+
+func TestCommunicator_AllReduceRobustEvenMedian(t *testing.T) {
+	out, err := NewCommunicator(4).AllReduceRobust(vectors(4, 3), RobustConfig{Rule: RobustMedian, Faulty: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out[2][1] != 2.5 {
+		t.Errorf("median of 1..4: got %v, want 2.5", out[2][1])
+	}
+}
+
+// equivocatingTransport makes rank From send a different bogus value to
+// every receiver.
+type equivocatingTransport struct {
+	*ChanTransport
+	From int
+}
+
+func (e *equivocatingTransport) Send(rank int, msg Msg) error {
+	if msg.From == e.From {
+		data := make([]float64, len(msg.Data))
+		for i := range data {
+			data[i] = float64(rank-2) * 1e9
+		}
+		msg.Data = data
+	}
+	return e.ChanTransport.Send(rank, msg)
+}
+
+func TestCommunicator_AllReduceRobustEquivocation(t *testing.T) {
+	const p = 5
+	c := NewCommunicator(p)
+	c.NewTransport = func(t Topology) Transport { return &equivocatingTransport{NewChanTransport(t), 1} }
+	for _, rule := range []RobustRule{RobustMedian, RobustTrimmedMean} {
+		out, err := c.AllReduceRobust(vectors(p, 4), RobustConfig{Rule: rule, Faulty: 1})
+		if err != nil {
+			t.Fatalf("%v: %v", rule, err)
+		}
+		// Whatever rank 1 tells each rank, honest results stay within the
+		// range of the honest inputs 1, 3, 4 and 5.
+		for r, v := range out {
+			if r == 1 {
+				continue
+			}
+			for _, x := range v {
+				if x < 1 || x > 5 {
+					t.Errorf("%v: rank %d aggregated %v outside the honest range", rule, r, x)
+				}
+			}
+		}
+	}
+}
+
+func TestCommunicator_AllReduceRobustErrors(t *testing.T) {
+	c := NewCommunicator(4)
+	tests := []struct {
+		name string
+		data [][]float64
+		cfg  RobustConfig
+	}{
+		{"rank count", vectors(3, 2), RobustConfig{Rule: RobustMedian}},
+		{"too many faulty for median", vectors(4, 2), RobustConfig{Rule: RobustMedian, Faulty: 2}},
+		{"too many faulty for krum", vectors(4, 2), RobustConfig{Rule: RobustKrum, Faulty: 1}},
+		{"negative faulty", vectors(4, 2), RobustConfig{Rule: RobustMedian, Faulty: -1}},
+		{"unknown rule", vectors(4, 2), RobustConfig{Rule: RobustRule(9)}},
+		{"ragged", [][]float64{{1}, {1}, {1, 2}, {1}}, RobustConfig{Rule: RobustMedian}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := c.AllReduceRobust(tc.data, tc.cfg); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestCommunicator_AllReduceRobustEmptyGroup(t *testing.T) {
+	_, err := NewCommunicator(0).AllReduceRobust(nil, RobustConfig{Rule: RobustMedian})
+	if !errors.Is(err, ErrNoRanks) {
+		t.Errorf("expected ErrNoRanks, got %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package ringallreduce
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrSchedulerClosed is returned when submitting to a closed Scheduler.
+var ErrSchedulerClosed = errors.New("scheduler closed")
+
+// Policy decides in which order a Scheduler dispatches queued collectives.
+type Policy int
+
+const (
+	// PolicyFIFO dispatches collectives in submission order.
+	PolicyFIFO Policy = iota
+	// PolicyPriority dispatches the highest Priority first.
+	PolicyPriority
+	// PolicyDeadline dispatches the earliest Deadline first; requests
+	// without a deadline go last, ordered by priority.
+	PolicyDeadline
+)
+
+func (p Policy) String() string {
+	switch p {
+	case PolicyFIFO:
+		return "fifo"
+	case PolicyPriority:
+		return "priority"
+	case PolicyDeadline:
+		return "deadline"
+	default:
+		return fmt.Sprintf("policy(%d)", int(p))
+	}
+}
+
+// Request is a collective waiting to be run by a Scheduler.
+type Request struct {
+	ID       OpID
+	Data     [][]float64 // reduced in place, as by Communicator.AllReduce
+	Priority int         // larger runs first under PolicyPriority
+	Deadline time.Time   // earlier runs first under PolicyDeadline; zero means none
+}
+
+// Ticket tracks a submitted Request.
+type Ticket struct {
+	Request Request
+	Order   int // dispatch position, valid once Wait returns
+
+	seq  int
+	done chan struct{}
+	err  error
+}
+
+// Wait blocks until the collective has run and returns its error.
+func (t *Ticket) Wait() error {
+	<-t.done
+	return t.err
+}
+
+// Scheduler queues collectives on a Communicator and runs at most
+// MaxInFlight of them at a time, picking the next one by Policy. Urgent
+// small reductions submitted behind a burst of large ones therefore don't
+// wait for the whole burst to drain. Policy must not change once requests
+// have been submitted.
+type Scheduler struct {
+	Comm        *Communicator
+	Policy      Policy
+	MaxInFlight int // defaults to 1
+
+	mu         sync.Mutex
+	queue      ticketQueue
+	inFlight   int
+	submitted  int
+	dispatched int
+	closed     bool
+	idle       sync.Cond
+}
+
+// NewScheduler creates a scheduler for c.
+func NewScheduler(c *Communicator, policy Policy, maxInFlight int) *Scheduler {
+	s := &Scheduler{Comm: c, Policy: policy, MaxInFlight: maxInFlight}
+	s.queue.policy = &s.Policy
+	s.idle.L = &s.mu
+	return s
+}
+
+// Submit queues req and returns a ticket to wait on. A communicator of no
+// ranks can run nothing, so Submit rejects req with ErrNoRanks right away.
+func (s *Scheduler) Submit(req Request) (*Ticket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, ErrSchedulerClosed
+	}
+	if s.Comm.Size() == 0 {
+		return nil, ErrNoRanks
+	}
+	t := &Ticket{Request: req, seq: s.submitted, done: make(chan struct{})}
+	s.submitted++
+	heap.Push(&s.queue, t)
+	s.dispatch()
+	return t, nil
+}
+
+// Queued returns the number of collectives waiting to be dispatched.
+func (s *Scheduler) Queued() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queue.Len()
+}
+
+// InFlight returns the number of collectives currently running.
+func (s *Scheduler) InFlight() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inFlight
+}
+
+// Close stops accepting new requests and waits until everything already
+// submitted has run.
+func (s *Scheduler) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for s.inFlight > 0 || s.queue.Len() > 0 {
+		s.idle.Wait()
+	}
+}
+
+// dispatch starts queued collectives while there is capacity. It must be
+// called with s.mu held.
+func (s *Scheduler) dispatch() {
+	limit := s.MaxInFlight
+	if limit < 1 {
+		limit = 1
+	}
+	for s.inFlight < limit && s.queue.Len() > 0 {
+		t := heap.Pop(&s.queue).(*Ticket)
+		t.Order = s.dispatched
+		s.dispatched++
+		s.inFlight++
+		go s.run(t)
+	}
+}
+
+func (s *Scheduler) run(t *Ticket) {
+	t.err = s.Comm.AllReduce(t.Request.ID, t.Request.Data)
+	close(t.done)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight--
+	s.dispatch()
+	if s.inFlight == 0 && s.queue.Len() == 0 {
+		s.idle.Broadcast()
+	}
+}
+
+// ticketQueue is a heap of tickets ordered by the scheduler's policy.
+type ticketQueue struct {
+	items  []*Ticket
+	policy *Policy
+}
+
+func (q ticketQueue) Len() int { return len(q.items) }
+
+func (q ticketQueue) Less(i, j int) bool {
+	a, b := q.items[i], q.items[j]
+	switch *q.policy {
+	case PolicyPriority:
+		if a.Request.Priority != b.Request.Priority {
+			return a.Request.Priority > b.Request.Priority
+		}
+	case PolicyDeadline:
+		da, db := a.Request.Deadline, b.Request.Deadline
+		switch {
+		case da.IsZero() != db.IsZero():
+			return !da.IsZero()
+		case !da.Equal(db):
+			return da.Before(db)
+		case a.Request.Priority != b.Request.Priority:
+			return a.Request.Priority > b.Request.Priority
+		}
+	}
+	return a.seq < b.seq
+}
+
+func (q ticketQueue) Swap(i, j int) { q.items[i], q.items[j] = q.items[j], q.items[i] }
+
+func (q *ticketQueue) Push(x any) { q.items = append(q.items, x.(*Ticket)) }
+
+func (q *ticketQueue) Pop() any {
+	last := q.items[len(q.items)-1]
+	q.items = q.items[:len(q.items)-1]
+	return last
+}
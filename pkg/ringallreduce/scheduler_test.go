@@ -0,0 +1,135 @@
+package ringallreduce
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatedTransport blocks every send until gate is closed.
+type gatedTransport struct {
+	*ChanTransport
+	gate chan struct{}
+}
+
+func (g gatedTransport) Send(rank int, msg Msg) error {
+	<-g.gate
+	return g.ChanTransport.Send(rank, msg)
+}
+
+func TestScheduler_Order(t *testing.T) {
+	now := time.Now()
+	requests := []Request{
+		{Priority: 0},
+		{Priority: 1, Deadline: now.Add(3 * time.Second)},
+		{Priority: 5},
+		{Priority: 1, Deadline: now.Add(time.Second)},
+		{Priority: 9, Deadline: now.Add(2 * time.Second)},
+	}
+	tests := []struct {
+		policy   Policy
+		expected []int // request indices in dispatch order
+	}{
+		{policy: PolicyFIFO, expected: []int{0, 1, 2, 3, 4}},
+		{policy: PolicyPriority, expected: []int{4, 2, 1, 3, 0}},
+		{policy: PolicyDeadline, expected: []int{3, 4, 1, 2, 0}},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.policy.String(), func(t *testing.T) {
+			const p = 3
+			c := NewCommunicator(p)
+			gate := make(chan struct{})
+			c.NewTransport = func(topo Topology) Transport {
+				return gatedTransport{ChanTransport: NewChanTransportSize(topo.Size(), 8), gate: gate}
+			}
+			s := NewScheduler(c, tc.policy, 1)
+
+			// The blocker occupies the only slot while the rest queue up.
+			blocker, err := s.Submit(Request{ID: c.NewOpID(), Data: vectors(p, 3)})
+			if err != nil {
+				t.Fatalf("submit: %v", err)
+			}
+			tickets := make([]*Ticket, len(requests))
+			for i, req := range requests {
+				req.ID = c.NewOpID()
+				req.Data = vectors(p, 3)
+				if tickets[i], err = s.Submit(req); err != nil {
+					t.Fatalf("submit: %v", err)
+				}
+			}
+			if s.InFlight() != 1 || s.Queued() != len(requests) {
+				t.Fatalf("expected 1 in flight and %d queued, got %d and %d", len(requests), s.InFlight(), s.Queued())
+			}
+			close(gate)
+			s.Close()
+
+			if err := blocker.Wait(); err != nil || blocker.Order != 0 {
+				t.Fatalf("blocker: order %d, err %v", blocker.Order, err)
+			}
+			for pos, i := range tc.expected {
+				if err := tickets[i].Wait(); err != nil {
+					t.Fatalf("request %d: %v", i, err)
+				}
+				if tickets[i].Order != pos+1 {
+					t.Errorf("request %d: expected dispatch position %d, got %d", i, pos+1, tickets[i].Order)
+				}
+			}
+		})
+	}
+}
+
+func TestScheduler_MaxInFlight(t *testing.T) {
+	const p, limit = 3, 2
+	c := NewCommunicator(p)
+	peak := 0
+	c.NewTransport = func(topo Topology) Transport {
+		return delayTransport{ChanTransport: NewChanTransportSize(topo.Size(), 8), latency: time.Millisecond}
+	}
+	s := NewScheduler(c, PolicyFIFO, limit)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		data := vectors(p, 4)
+		ticket, err := s.Submit(Request{ID: c.NewOpID(), Data: data})
+		if err != nil {
+			t.Fatalf("submit: %v", err)
+		}
+		if n := s.InFlight(); n > peak {
+			peak = n
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := ticket.Wait(); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			for _, v := range data[0] {
+				if v != 6 {
+					t.Errorf("expected 6, got %f", v)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	s.Close()
+
+	if peak > limit {
+		t.Errorf("expected at most %d collectives in flight, saw %d", limit, peak)
+	}
+	if _, err := s.Submit(Request{ID: c.NewOpID(), Data: vectors(p, 1)}); err != ErrSchedulerClosed {
+		t.Errorf("expected ErrSchedulerClosed, got %v", err)
+	}
+}
+
+func TestScheduler_EmptyGroup(t *testing.T) {
+	s := NewScheduler(NewCommunicator(0), PolicyFIFO, 1)
+	defer s.Close()
+	if _, err := s.Submit(Request{ID: 1}); err != ErrNoRanks {
+		t.Errorf("expected ErrNoRanks, got %v", err)
+	}
+	if n := s.Queued(); n != 0 {
+		t.Errorf("expected nothing queued, got %d", n)
+	}
+}
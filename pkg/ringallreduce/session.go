@@ -0,0 +1,267 @@
+package ringallreduce
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Event kinds recorded by RecordResult.
+const (
+	EventCrash   = "crash"
+	EventRestart = "restart"
+)
+
+// TopologyInfo is the serializable description of a Topology.
+type TopologyInfo struct {
+	Name      string
+	Size      int
+	Neighbors [][]int // Neighbors[r] lists the neighbors of rank r
+}
+
+// SessionEvent is something that happened during a run, e.g. a rank crash.
+type SessionEvent struct {
+	At     time.Time
+	Kind   string
+	Rank   int // NoPeer when the event isn't tied to a rank
+	Detail string
+}
+
+// Sample is one point of a metric time series.
+type Sample struct {
+	At    time.Time
+	Value float64
+}
+
+// Session is everything recorded about one run: its configuration and
+// topology, the fault events and metric samples observed while it ran and
+// the final buffers of every rank.
+type Session struct {
+	Started  time.Time
+	Config   map[string]string
+	Topology TopologyInfo
+	Events   []SessionEvent
+	Metrics  map[string][]Sample
+	Buffers  [][]float64
+}
+
+// Recorder collects a Session while a run is in progress. It is safe for
+// concurrent use, so ranks and transports can report from their goroutines.
+type Recorder struct {
+	mu      sync.Mutex
+	session Session
+	now     func() time.Time
+}
+
+// NewRecorder starts recording a run over t with the given configuration.
+func NewRecorder(t Topology, config map[string]string) *Recorder {
+	info := TopologyInfo{Name: t.Name(), Size: t.Size()}
+	for r := 0; r < t.Size(); r++ {
+		info.Neighbors = append(info.Neighbors, t.Neighbors(r))
+	}
+	cfg := make(map[string]string, len(config))
+	for k, v := range config {
+		cfg[k] = v
+	}
+	r := &Recorder{now: time.Now}
+	r.session = Session{
+		Started:  r.now(),
+		Config:   cfg,
+		Topology: info,
+		Metrics:  make(map[string][]Sample),
+	}
+	return r
+}
+
+// Event records an event of the given kind.
+func (r *Recorder) Event(kind string, rank int, detail string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.session.Events = append(r.session.Events, SessionEvent{At: r.now(), Kind: kind, Rank: rank, Detail: detail})
+}
+
+// Metric appends a sample to the time series name.
+func (r *Recorder) Metric(name string, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.session.Metrics[name] = append(r.session.Metrics[name], Sample{At: r.now(), Value: value})
+}
+
+// Buffers records the final buffer of every rank.
+func (r *Recorder) Buffers(data [][]float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.session.Buffers = make([][]float64, len(data))
+	for i, d := range data {
+		r.session.Buffers[i] = append([]float64(nil), d...)
+	}
+}
+
+// RecordResult records the outcome of AllReduceResilient: a crash event for
+// every failed rank, the number of restarts and the surviving buffers.
+func (r *Recorder) RecordResult(res PartialResult) {
+	for _, rank := range res.Failed {
+		r.Event(EventCrash, rank, "excluded from the ring")
+	}
+	for i := 0; i < res.Restarts; i++ {
+		r.Event(EventRestart, NoPeer, fmt.Sprintf("ring re-formed (%d of %d)", i+1, res.Restarts))
+	}
+	r.Metric("restarts", float64(res.Restarts))
+	r.Buffers(res.Data)
+}
+
+// Session returns a copy of everything recorded so far.
+func (r *Recorder) Session() Session {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.session
+	s.Events = append([]SessionEvent(nil), s.Events...)
+	s.Metrics = make(map[string][]Sample, len(r.session.Metrics))
+	for k, v := range r.session.Metrics {
+		s.Metrics[k] = append([]Sample(nil), v...)
+	}
+	return s
+}
+
+// Save writes the recorded session to w as a zip archive.
+func (r *Recorder) Save(w io.Writer) error {
+	return r.Session().Save(w)
+}
+
+// The archive holds one JSON document per part of the session, so the parts
+// can also be inspected with ordinary tools.
+var sessionEntries = []string{"session.json", "topology.json", "events.json", "metrics.json", "buffers.json"}
+
+// Save writes s to w as a zip archive.
+func (s Session) Save(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	parts := []any{
+		struct {
+			Started time.Time
+			Config  map[string]string
+		}{s.Started, s.Config},
+		s.Topology,
+		s.Events,
+		s.Metrics,
+		s.Buffers,
+	}
+	for i, name := range sessionEntries {
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		if err := json.NewEncoder(f).Encode(parts[i]); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return zw.Close()
+}
+
+// SaveFile writes s to the archive at path.
+func (s Session) SaveFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := s.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadSession reads a session archive written by Save.
+func LoadSession(r io.ReaderAt, size int64) (*Session, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		s    Session
+		head struct {
+			Started time.Time
+			Config  map[string]string
+		}
+	)
+	parts := []any{&head, &s.Topology, &s.Events, &s.Metrics, &s.Buffers}
+	for i, name := range sessionEntries {
+		f, err := zr.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		err = json.NewDecoder(f).Decode(parts[i])
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	s.Started, s.Config = head.Started, head.Config
+	if s.Metrics == nil {
+		s.Metrics = make(map[string][]Sample)
+	}
+	return &s, nil
+}
+
+// OpenSession reads the session archive at path.
+func OpenSession(path string) (*Session, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return LoadSession(f, info.Size())
+}
+
+// EventsOf returns the events of the given kind in the order they happened.
+func (s *Session) EventsOf(kind string) []SessionEvent {
+	var out []SessionEvent
+	for _, e := range s.Events {
+		if e.Kind == kind {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// EventsFor returns the events of rank in the order they happened.
+func (s *Session) EventsFor(rank int) []SessionEvent {
+	var out []SessionEvent
+	for _, e := range s.Events {
+		if e.Rank == rank {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// MetricNames returns the names of all recorded time series, sorted.
+func (s *Session) MetricNames() []string {
+	names := make([]string, 0, len(s.Metrics))
+	for name := range s.Metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Series returns the samples of metric name taken in [from, to]. Zero times
+// leave that end open.
+func (s *Session) Series(name string, from, to time.Time) []Sample {
+	var out []Sample
+	for _, sample := range s.Metrics[name] {
+		if (!from.IsZero() && sample.At.Before(from)) || (!to.IsZero() && sample.At.After(to)) {
+			continue
+		}
+		out = append(out, sample)
+	}
+	return out
+}
@@ -0,0 +1,105 @@
+package ringallreduce
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// tickingClock returns a clock that advances by one second per reading.
+func tickingClock() func() time.Time {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		at = at.Add(time.Second)
+		return at
+	}
+}
+
+func TestSession_SaveAndLoad(t *testing.T) {
+	rec := NewRecorder(NewRing(3), map[string]string{"chunk": "4"})
+	rec.now = tickingClock()
+	rec.session.Started = rec.now()
+	rec.Event(EventCrash, 1, "silent")
+	rec.Metric("step_ms", 1.5)
+	rec.Metric("step_ms", 2.5)
+	rec.Metric("bytes", 96)
+	rec.Buffers([][]float64{{6, 6}, nil, {6, 6}})
+
+	var buf bytes.Buffer
+	if err := rec.Save(&buf); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	loaded, err := LoadSession(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if expected := rec.Session(); !reflect.DeepEqual(*loaded, expected) {
+		t.Errorf("expected %+v, got %+v", expected, *loaded)
+	}
+	if !reflect.DeepEqual(loaded.Topology.Neighbors, [][]int{{2, 1}, {0, 2}, {1, 0}}) {
+		t.Errorf("unexpected neighbors %v", loaded.Topology.Neighbors)
+	}
+}
+
+func TestSession_Queries(t *testing.T) {
+	rec := NewRecorder(NewRing(4), nil)
+	rec.now = tickingClock()
+	rec.Event(EventCrash, 2, "")
+	rec.Metric("throughput", 10) // 00:00:02
+	rec.Event(EventRestart, NoPeer, "")
+	rec.Metric("throughput", 20) // 00:00:04
+	rec.Metric("throughput", 30) // 00:00:05
+	rec.Metric("latency", 1)
+	s := rec.Session()
+
+	if got := s.EventsOf(EventCrash); len(got) != 1 || got[0].Rank != 2 {
+		t.Errorf("unexpected crash events %+v", got)
+	}
+	if got := s.EventsFor(NoPeer); len(got) != 1 || got[0].Kind != EventRestart {
+		t.Errorf("unexpected events without rank %+v", got)
+	}
+	if got := s.MetricNames(); !reflect.DeepEqual(got, []string{"latency", "throughput"}) {
+		t.Errorf("unexpected metric names %v", got)
+	}
+	from := time.Date(2024, 1, 1, 0, 0, 3, 0, time.UTC)
+	var values []float64
+	for _, sample := range s.Series("throughput", from, time.Time{}) {
+		values = append(values, sample.Value)
+	}
+	if !reflect.DeepEqual(values, []float64{20, 30}) {
+		t.Errorf("expected samples [20 30], got %v", values)
+	}
+}
+
+func TestRecorder_RecordResult(t *testing.T) {
+	c := NewCommunicator(4)
+	c.NewTransport = crashOnAttempt(0, 1, 1)
+	res, err := c.AllReduceResilient(sequentialInputs(4, 8), FaultTolerance{StepTimeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rec := NewRecorder(c.topologyFor(4), map[string]string{"step_timeout": "100ms"})
+	rec.RecordResult(res)
+	path := filepath.Join(t.TempDir(), "run.zip")
+	if err := rec.Session().SaveFile(path); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	s, err := OpenSession(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	crashes := s.EventsOf(EventCrash)
+	if len(crashes) != 1 || crashes[0].Rank != 1 {
+		t.Errorf("expected a crash of rank 1, got %+v", crashes)
+	}
+	if len(s.EventsOf(EventRestart)) != res.Restarts {
+		t.Errorf("expected %d restart events, got %d", res.Restarts, len(s.EventsOf(EventRestart)))
+	}
+	if s.Buffers[1] != nil || !reflect.DeepEqual(s.Buffers[0], res.Data[0]) {
+		t.Errorf("unexpected buffers %v", s.Buffers)
+	}
+}
@@ -0,0 +1,167 @@
+package ringallreduce
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Link describes one directed network hop.
+type Link struct {
+	Latency   time.Duration // one-way latency of the hop
+	Bandwidth float64       // bytes per second; 0 means unlimited
+	Jitter    time.Duration // extra delay drawn uniformly from [0, Jitter)
+}
+
+// LinkModel returns the link between two ranks. LinkCaps throttles the
+// links of another model.
+type LinkModel interface {
+	Link(from, to int) Link
+}
+
+// UniformLinks uses the same Link between every pair of ranks.
+type UniformLinks Link
+
+func (u UniformLinks) Link(_, _ int) Link { return Link(u) }
+
+// LinkFunc adapts a function to a LinkModel, e.g. to make links between
+// racks slower than links within one.
+type LinkFunc func(from, to int) Link
+
+func (f LinkFunc) Link(from, to int) Link { return f(from, to) }
+
+// SimTransport delays messages on top of another transport according to a
+// LinkModel, without actually sleeping. Every rank has a simulated clock:
+// sending a message of m payload bytes advances the sender's clock by the
+// link's latency, jitter and m / bandwidth, and the message arrives at that
+// time; using it moves the receiver's clock forward to the arrival.
+// Elapsed then reports the simulated wall-clock time of the collective.
+//
+// This mirrors CostModel.Predict, except that every chunk is its own message
+// and pays the latency on its own. The inner transport must keep messages
+// from one rank to another in order.
+type SimTransport struct {
+	Inner Transport
+	Model LinkModel
+
+	mu       sync.Mutex
+	rng      *rand.Rand
+	clock    map[int]time.Duration
+	arrivals map[link][]time.Duration   // in flight, in send order
+	received map[simKey][]time.Duration // taken off the wire but not yet used
+}
+
+// simKey identifies the messages carrying one chunk of one collective over
+// one link.
+type simKey struct {
+	link
+	chunk int
+	op    uint64
+}
+
+// NewSimTransport wraps inner; seed drives the jitter.
+func NewSimTransport(inner Transport, model LinkModel, seed int64) *SimTransport {
+	return &SimTransport{
+		Inner:    inner,
+		Model:    model,
+		rng:      rand.New(rand.NewSource(seed)),
+		clock:    make(map[int]time.Duration),
+		arrivals: make(map[link][]time.Duration),
+		received: make(map[simKey][]time.Duration),
+	}
+}
+
+func (s *SimTransport) Send(rank int, msg Msg) error {
+	l := s.Model.Link(msg.From, rank)
+	key := link{from: msg.From, to: rank}
+
+	s.mu.Lock()
+	delay := l.Latency
+	if l.Jitter > 0 {
+		delay += time.Duration(s.rng.Int63n(int64(l.Jitter)))
+	}
+	if l.Bandwidth > 0 {
+		delay += time.Duration(float64(8*len(msg.Data)) / l.Bandwidth * float64(time.Second))
+	}
+	s.clock[msg.From] += delay
+	s.arrivals[key] = append(s.arrivals[key], s.clock[msg.From])
+	s.mu.Unlock()
+
+	return s.Inner.Send(rank, msg)
+}
+
+func (s *SimTransport) Recv(rank int) (Msg, error) {
+	msg, err := s.Inner.Recv(rank)
+	if err != nil {
+		return msg, err
+	}
+	key := link{from: msg.From, to: rank}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if q := s.arrivals[key]; len(q) > 0 {
+		k := simKey{link: key, chunk: msg.ChunkIdx, op: msg.Op}
+		s.received[k] = append(s.received[k], q[0])
+		s.arrivals[key] = q[1:]
+	}
+	return msg, nil
+}
+
+func (s *SimTransport) consumed(rank int, msg Msg) {
+	k := simKey{link: link{from: msg.From, to: rank}, chunk: msg.ChunkIdx, op: msg.Op}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if q := s.received[k]; len(q) > 0 {
+		s.clock[rank] = max(s.clock[rank], q[0])
+		s.received[k] = q[1:]
+	}
+}
+
+// Close closes the inner transport if it supports it.
+func (s *SimTransport) Close() error {
+	closeTransport(s.Inner)
+	return nil
+}
+
+// Clock returns the simulated time of rank.
+func (s *SimTransport) Clock(rank int) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.clock[rank]
+}
+
+// Elapsed returns the simulated time of the rank that is furthest ahead.
+func (s *SimTransport) Elapsed() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var total time.Duration
+	for _, c := range s.clock {
+		total = max(total, c)
+	}
+	return total
+}
+
+// Simulate runs an all–reduce of n float64 elements over t with links from
+// model and returns its simulated completion time.
+func Simulate(t Topology, n int, model LinkModel, seed int64) (time.Duration, error) {
+	return SimulateBudget(t, n, model, seed, 0)
+}
+
+// SimulateBudget is Simulate with every rank limited to budget bytes. It
+// fails with a *MemoryError before allocating anything if the estimated
+// working set doesn't fit, so large P × n sweeps can't exhaust the host.
+func SimulateBudget(t Topology, n int, model LinkModel, seed int64, budget int64) (time.Duration, error) {
+	if err := CheckMemory(t, n, budget); err != nil {
+		return 0, err
+	}
+	inputs := make([][]float64, t.Size())
+	for i := range inputs {
+		inputs[i] = make([]float64, n)
+	}
+	sim := NewSimTransport(NewChanTransport(t), model, seed)
+	if _, _, err := runCollective(t, sim, 0, inputs, runOptions{memory: budget}); err != nil {
+		return 0, err
+	}
+	return sim.Elapsed(), nil
+}
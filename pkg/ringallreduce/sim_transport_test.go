@@ -0,0 +1,94 @@
+package ringallreduce
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSimulate_MatchesCostModel(t *testing.T) {
+	link := Link{Latency: 50 * time.Microsecond, Bandwidth: 1e9}
+	model := CostModel{Alpha: link.Latency.Seconds(), Beta: 1 / link.Bandwidth}
+	tests := []struct {
+		name     string
+		topology Topology
+		n        int
+	}{
+		{name: "ring", topology: NewRing(4), n: 1 << 16},
+		{name: "ring small", topology: NewRing(8), n: 64},
+		{name: "fully-connected", topology: NewFullyConnected(5), n: 1000},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Simulate(tc.topology, tc.n, UniformLinks(link), 1)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expected := model.Predict(tc.topology, tc.n)
+			if math.Abs(got.Seconds()-expected) > 1e-6 {
+				t.Errorf("expected %gs, got %v", expected, got)
+			}
+		})
+	}
+}
+
+func TestSimulate_SlowLinkDominates(t *testing.T) {
+	fast := Link{Latency: time.Microsecond, Bandwidth: 1e10}
+	slow := Link{Latency: time.Millisecond, Bandwidth: 1e8}
+	// Rank 2 sits behind a slow link in both directions.
+	model := LinkFunc(func(from, to int) Link {
+		if from == 2 || to == 2 {
+			return slow
+		}
+		return fast
+	})
+
+	uniform, err := Simulate(NewRing(4), 4096, UniformLinks(fast), 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mixed, err := Simulate(NewRing(4), 4096, model, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Every one of the 6 ring steps waits on a slow hop.
+	if mixed < 6*slow.Latency || mixed < 10*uniform {
+		t.Errorf("expected the slow link to dominate: uniform %v, mixed %v", uniform, mixed)
+	}
+}
+
+func TestSimulate_Jitter(t *testing.T) {
+	link := Link{Latency: time.Millisecond, Jitter: time.Millisecond}
+	got, err := Simulate(NewRing(4), 16, UniformLinks(link), 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 6 steps of 1ms latency plus up to 1ms jitter each.
+	if got <= 6*time.Millisecond || got >= 12*time.Millisecond {
+		t.Errorf("expected between 6ms and 12ms, got %v", got)
+	}
+}
+
+func TestSimTransport_Clock(t *testing.T) {
+	sim := NewSimTransport(NewChanTransportSize(2, 4), UniformLinks{Latency: time.Millisecond, Bandwidth: 8000}, 1)
+	if err := sim.Send(1, Msg{From: 0, Data: make([]float64, 10)}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	msg, err := sim.Recv(1)
+	if err != nil {
+		t.Fatalf("recv: %v", err)
+	}
+	if got := sim.Clock(1); got != 0 {
+		t.Errorf("expected the clock to wait until the message is used, got %v", got)
+	}
+	sim.consumed(1, msg)
+	// 80 bytes at 8000 B/s take 10ms on top of the 1ms latency.
+	if got := sim.Clock(1); got != 11*time.Millisecond {
+		t.Errorf("expected 11ms, got %v", got)
+	}
+	if got := sim.Elapsed(); got != 11*time.Millisecond {
+		t.Errorf("expected elapsed 11ms, got %v", got)
+	}
+}
@@ -0,0 +1,303 @@
+package ringallreduce
+
+import (
+	"fmt"
+	"sync"
+)
+
+// SparseVector is a vector of length Dim that stores only the entries at
+// Indices. Indices are strictly increasing and Values[i] is the entry at
+// Indices[i].
+type SparseVector struct {
+	Dim     int
+	Indices []int
+	Values  []float64
+}
+
+// NewSparse returns the non-zero entries of dense.
+func NewSparse(dense []float64) SparseVector {
+	v := SparseVector{Dim: len(dense)}
+	for i, x := range dense {
+		if x != 0 {
+			v.Indices = append(v.Indices, i)
+			v.Values = append(v.Values, x)
+		}
+	}
+	return v
+}
+
+// Dense expands v into a []float64 of length Dim.
+func (v SparseVector) Dense() []float64 {
+	out := make([]float64, v.Dim)
+	for i, idx := range v.Indices {
+		out[idx] = v.Values[i]
+	}
+	return out
+}
+
+// Density returns the fraction of entries that are stored.
+func (v SparseVector) Density() float64 {
+	if v.Dim == 0 {
+		return 0
+	}
+	return float64(len(v.Indices)) / float64(v.Dim)
+}
+
+func (v SparseVector) validate() error {
+	if len(v.Indices) != len(v.Values) {
+		return fmt.Errorf("%d indices for %d values", len(v.Indices), len(v.Values))
+	}
+	for i, idx := range v.Indices {
+		if idx < 0 || idx >= v.Dim {
+			return fmt.Errorf("index %d out of range [0, %d)", idx, v.Dim)
+		}
+		if i > 0 && idx <= v.Indices[i-1] {
+			return fmt.Errorf("indices not strictly increasing at position %d", i)
+		}
+	}
+	return nil
+}
+
+// AllReduceSparse sums sparse vectors over topology t, where inputs[i] is
+// the vector of rank i. The result on every rank holds the union of all
+// input indices with overlapping values added up.
+//
+// The index space is split into t.Size() chunks that follow the same
+// schedule as AllReduce. A chunk whose density exceeds densify is switched
+// to a dense representation, which is cheaper on the wire once most of its
+// entries are set; every index of such a chunk appears in the result. A
+// densify of 0 keeps every chunk sparse.
+func (r *RingAllReduce) AllReduceSparse(t Topology, inputs []SparseVector, densify float64) ([]SparseVector, error) {
+	p := t.Size()
+	if p == 0 {
+		return nil, ErrNoRanks
+	}
+	if len(inputs) != p {
+		return nil, fmt.Errorf("got %d input vectors for %d ranks", len(inputs), p)
+	}
+	dim := inputs[0].Dim
+	for i, in := range inputs {
+		if in.Dim != dim {
+			return nil, fmt.Errorf("rank %d: vector length %d differs from %d", i, in.Dim, dim)
+		}
+		if err := in.validate(); err != nil {
+			return nil, fmt.Errorf("rank %d: %w", i, err)
+		}
+	}
+	chunkSize := (dim + p - 1) / p
+	if chunkSize == 0 {
+		chunkSize = 1
+	}
+
+	transport := NewChanTransport(t)
+	nodes := make([]*sparseNode, p)
+	for i := range nodes {
+		nodes[i] = newSparseNode(i, t, transport, chunkSize, densify, inputs[i])
+	}
+
+	var (
+		wg       sync.WaitGroup
+		abort    sync.Once
+		firstErr error
+	)
+	wg.Add(p)
+	for _, n := range nodes {
+		go func(n *sparseNode) {
+			defer wg.Done()
+			if err := n.allReduce(); err != nil {
+				abort.Do(func() {
+					firstErr = err
+					closeTransport(transport)
+				})
+			}
+		}(n)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	out := make([]SparseVector, p)
+	for i, n := range nodes {
+		out[i] = n.vector(dim)
+	}
+	return out, nil
+}
+
+// sparseChunk is one chunk of a sparse vector covering [start, start+size).
+// It is dense once dense is non-nil; otherwise idx and val hold its entries.
+type sparseChunk struct {
+	start int
+	size  int
+	idx   []int
+	val   []float64
+	dense []float64
+}
+
+// add accumulates o into c, densifying c when it grows past threshold.
+func (c *sparseChunk) add(o sparseChunk, threshold float64) {
+	if o.dense != nil && c.dense == nil {
+		c.toDense()
+	}
+	if c.dense != nil {
+		if o.dense != nil {
+			for i, x := range o.dense {
+				c.dense[i] += x
+			}
+		} else {
+			for i, idx := range o.idx {
+				c.dense[idx-c.start] += o.val[i]
+			}
+		}
+		return
+	}
+
+	idx := make([]int, 0, len(c.idx)+len(o.idx))
+	val := make([]float64, 0, len(c.idx)+len(o.idx))
+	i, j := 0, 0
+	for i < len(c.idx) || j < len(o.idx) {
+		switch {
+		case j == len(o.idx) || (i < len(c.idx) && c.idx[i] < o.idx[j]):
+			idx, val = append(idx, c.idx[i]), append(val, c.val[i])
+			i++
+		case i == len(c.idx) || o.idx[j] < c.idx[i]:
+			idx, val = append(idx, o.idx[j]), append(val, o.val[j])
+			j++
+		default:
+			idx, val = append(idx, c.idx[i]), append(val, c.val[i]+o.val[j])
+			i++
+			j++
+		}
+	}
+	c.idx, c.val = idx, val
+	if threshold > 0 && float64(len(c.idx)) > threshold*float64(c.size) {
+		c.toDense()
+	}
+}
+
+func (c *sparseChunk) toDense() {
+	c.dense = make([]float64, c.size)
+	for i, idx := range c.idx {
+		c.dense[idx-c.start] = c.val[i]
+	}
+	c.idx, c.val = nil, nil
+}
+
+// Chunks travel as a flag followed by either index/value pairs (flag 0) or
+// all values of the chunk (flag 1).
+func (c sparseChunk) encode() []float64 {
+	if c.dense != nil {
+		return append([]float64{1}, c.dense...)
+	}
+	out := make([]float64, 1, 1+2*len(c.idx))
+	for i, idx := range c.idx {
+		out = append(out, float64(idx), c.val[i])
+	}
+	return out
+}
+
+func (c sparseChunk) decode(data []float64) (sparseChunk, error) {
+	out := sparseChunk{start: c.start, size: c.size}
+	if len(data) == 0 {
+		return out, fmt.Errorf("empty sparse chunk")
+	}
+	if data[0] == 1 {
+		if len(data)-1 != c.size {
+			return out, fmt.Errorf("dense chunk of %d values, expected %d", len(data)-1, c.size)
+		}
+		out.dense = append([]float64(nil), data[1:]...)
+		return out, nil
+	}
+	if len(data)%2 != 1 {
+		return out, fmt.Errorf("sparse chunk with odd payload length %d", len(data)-1)
+	}
+	for i := 1; i < len(data); i += 2 {
+		out.idx = append(out.idx, int(data[i]))
+		out.val = append(out.val, data[i+1])
+	}
+	return out, nil
+}
+
+// sparseNode runs a schedule like Node, but on sparse chunks. It borrows a
+// Node for message matching.
+type sparseNode struct {
+	node      *Node
+	chunks    []sparseChunk
+	threshold float64
+}
+
+func newSparseNode(rank int, t Topology, transport Transport, chunkSize int, threshold float64, in SparseVector) *sparseNode {
+	p := t.Size()
+	n := &sparseNode{
+		node:      &Node{Rank: rank, P: p, ChunkSize: chunkSize, Topology: t, Transport: transport},
+		chunks:    make([]sparseChunk, p),
+		threshold: threshold,
+	}
+	for j := range n.chunks {
+		n.chunks[j] = sparseChunk{start: j * chunkSize, size: chunkSize}
+	}
+	for i, idx := range in.Indices {
+		c := &n.chunks[idx/chunkSize]
+		c.idx = append(c.idx, idx)
+		c.val = append(c.val, in.Values[i])
+	}
+	for j := range n.chunks {
+		c := &n.chunks[j]
+		if threshold > 0 && float64(len(c.idx)) > threshold*float64(c.size) {
+			c.toDense()
+		}
+	}
+	return n
+}
+
+func (n *sparseNode) allReduce() error {
+	proc := n.node
+	for _, step := range proc.topology().Schedule(proc.Rank) {
+		if step.SendTo != NoPeer {
+			for _, idx := range step.SendChunks {
+				msg := Msg{From: proc.Rank, ChunkIdx: idx, Data: n.chunks[idx].encode(), Op: proc.Op}
+				if err := proc.transport().Send(step.SendTo, msg); err != nil {
+					return fmt.Errorf("node %d (%s): %w", proc.Rank, step.Phase, err)
+				}
+			}
+		}
+		if step.RecvFrom != NoPeer {
+			for _, idx := range step.RecvChunks {
+				received, err := proc.recv(step.RecvFrom, idx)
+				if err != nil {
+					return fmt.Errorf("node %d (%s): %w", proc.Rank, step.Phase, err)
+				}
+				chunk, err := n.chunks[idx].decode(received.Data)
+				if err != nil {
+					return fmt.Errorf("node %d (%s): chunk %d: %w", proc.Rank, step.Phase, idx, err)
+				}
+				if step.Reduce {
+					n.chunks[idx].add(chunk, n.threshold)
+				} else {
+					n.chunks[idx] = chunk
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// vector assembles the chunks into a SparseVector of length dim, leaving
+// out the padding past dim.
+func (n *sparseNode) vector(dim int) SparseVector {
+	v := SparseVector{Dim: dim}
+	for _, c := range n.chunks {
+		if c.dense != nil {
+			for i, x := range c.dense {
+				if c.start+i < dim {
+					v.Indices = append(v.Indices, c.start+i)
+					v.Values = append(v.Values, x)
+				}
+			}
+			continue
+		}
+		v.Indices = append(v.Indices, c.idx...)
+		v.Values = append(v.Values, c.val...)
+	}
+	return v
+}
@@ -0,0 +1,133 @@
+package ringallreduce
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func randomSparse(rng *rand.Rand, dim int, density float64) SparseVector {
+	dense := make([]float64, dim)
+	for i := range dense {
+		if rng.Float64() < density {
+			dense[i] = float64(rng.Intn(9) + 1)
+		}
+	}
+	return NewSparse(dense)
+}
+
+func TestAllReduceSparse(t *testing.T) {
+	tests := []struct {
+		name     string
+		topology Topology
+		dim      int
+		density  float64
+		densify  float64
+	}{
+		{name: "ring sparse", topology: NewRing(4), dim: 40, density: 0.1},
+		{name: "ring densify", topology: NewRing(4), dim: 40, density: 0.3, densify: 0.5},
+		{name: "tree", topology: NewTree(5), dim: 23, density: 0.2},
+		{name: "torus densify", topology: NewTorus(2, 3), dim: 30, density: 0.2, densify: 0.25},
+		{name: "fully-connected", topology: NewFullyConnected(3), dim: 10, density: 0.5, densify: 1},
+		{name: "empty", topology: NewRing(3), dim: 12},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(int64(tc.dim)))
+			p := tc.topology.Size()
+			inputs := make([]SparseVector, p)
+			expected := make([]float64, tc.dim)
+			present := make(map[int]bool)
+			for i := range inputs {
+				inputs[i] = randomSparse(rng, tc.dim, tc.density)
+				for k, idx := range inputs[i].Indices {
+					expected[idx] += inputs[i].Values[k]
+					present[idx] = true
+				}
+			}
+
+			r := New()
+			out, err := r.AllReduceSparse(tc.topology, inputs, tc.densify)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for rank, v := range out {
+				if err := v.validate(); err != nil {
+					t.Fatalf("rank=%d: invalid result: %v", rank, err)
+				}
+				if got := v.Dense(); !reflect.DeepEqual(got, expected) {
+					t.Errorf("rank=%d: expected %v, got %v", rank, expected, got)
+				}
+				for idx := range present {
+					if v.Dense()[idx] == 0 {
+						t.Errorf("rank=%d: index %d of the union is missing", rank, idx)
+					}
+				}
+				if tc.densify == 0 && len(v.Indices) != len(present) {
+					t.Errorf("rank=%d: expected the %d union indices, got %d", rank, len(present), len(v.Indices))
+				}
+			}
+		})
+	}
+}
+
+func TestAllReduceSparse_OverlapSums(t *testing.T) {
+	inputs := []SparseVector{
+		{Dim: 6, Indices: []int{0, 3}, Values: []float64{1, 2}},
+		{Dim: 6, Indices: []int{3, 5}, Values: []float64{4, 8}},
+	}
+	r := New()
+	out, err := r.AllReduceSparse(NewRing(2), inputs, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := SparseVector{Dim: 6, Indices: []int{0, 3, 5}, Values: []float64{1, 6, 8}}
+	for rank, v := range out {
+		if !reflect.DeepEqual(v, expected) {
+			t.Errorf("rank=%d: expected %+v, got %+v", rank, expected, v)
+		}
+	}
+}
+
+func TestAllReduceSparse_InvalidInput(t *testing.T) {
+	tests := []struct {
+		name   string
+		inputs []SparseVector
+	}{
+		{name: "rank count", inputs: []SparseVector{{Dim: 4}}},
+		{name: "dimension", inputs: []SparseVector{{Dim: 4}, {Dim: 5}}},
+		{name: "unsorted", inputs: []SparseVector{{Dim: 4}, {Dim: 4, Indices: []int{2, 1}, Values: []float64{1, 1}}}},
+		{name: "out of range", inputs: []SparseVector{{Dim: 4}, {Dim: 4, Indices: []int{4}, Values: []float64{1}}}},
+		{name: "lengths", inputs: []SparseVector{{Dim: 4}, {Dim: 4, Indices: []int{1}}}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			r := New()
+			if _, err := r.AllReduceSparse(NewRing(2), tc.inputs, 0); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestSparseVector_Dense(t *testing.T) {
+	dense := []float64{0, 1.5, 0, 0, -2}
+	v := NewSparse(dense)
+	if !reflect.DeepEqual(v.Indices, []int{1, 4}) || v.Density() != 0.4 {
+		t.Errorf("unexpected sparse form %+v", v)
+	}
+	if got := v.Dense(); !reflect.DeepEqual(got, dense) {
+		t.Errorf("expected %v, got %v", dense, got)
+	}
+}
+
+func TestAllReduceSparse_NoRanks(t *testing.T) {
+	r := New()
+	if _, err := r.AllReduceSparse(NewRing(0), nil, 0); !errors.Is(err, ErrNoRanks) {
+		t.Errorf("got %v, want ErrNoRanks", err)
+	}
+}
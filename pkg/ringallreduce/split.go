@@ -0,0 +1,73 @@
+package ringallreduce
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SplitUndefined is the color of ranks that join no sub-communicator.
+const SplitUndefined = -1
+
+// Split partitions the communicator the way MPI_Comm_split does: rank i
+// joins the sub-communicator of colors[i], where it is ordered by keys[i],
+// ties broken by its rank in c. Ranks of color SplitUndefined join none.
+// keys may be nil to keep the order of c. Members keep their IDs, so
+// AllReduceMembers works across the split, and every sub-communicator
+// inherits the settings of c. The result is keyed by color. Splitting a
+// communicator of no ranks fails with ErrNoRanks.
+func (c *Communicator) Split(colors, keys []int) (map[int]*Communicator, error) {
+	members := c.Members()
+	if len(members) == 0 {
+		return nil, ErrNoRanks
+	}
+	if len(colors) != len(members) {
+		return nil, fmt.Errorf("got %d colors for %d ranks", len(colors), len(members))
+	}
+	if keys != nil && len(keys) != len(members) {
+		return nil, fmt.Errorf("got %d keys for %d ranks", len(keys), len(members))
+	}
+	groups := map[int][]int{}
+	for rank, color := range colors {
+		if color == SplitUndefined {
+			continue
+		}
+		if color < 0 {
+			return nil, fmt.Errorf("rank %d: negative color %d", rank, color)
+		}
+		groups[color] = append(groups[color], rank)
+	}
+
+	c.mu.Lock()
+	next := c.nextMember
+	c.mu.Unlock()
+	subs := make(map[int]*Communicator, len(groups))
+	for color, ranks := range groups {
+		if keys != nil {
+			sort.SliceStable(ranks, func(i, j int) bool { return keys[ranks[i]] < keys[ranks[j]] })
+		}
+		sub := c.derive()
+		sub.members = make([]MemberID, len(ranks))
+		for i, rank := range ranks {
+			sub.members[i] = members[rank]
+		}
+		sub.nextMember = next
+		subs[color] = sub
+	}
+	return subs, nil
+}
+
+// derive returns an empty communicator with the settings of c.
+func (c *Communicator) derive() *Communicator {
+	return &Communicator{
+		Topology:        c.Topology,
+		NewTransport:    c.NewTransport,
+		Auto:            c.Auto,
+		Trace:           c.Trace,
+		DeadlockTimeout: c.DeadlockTimeout,
+		MemoryBudget:    c.MemoryBudget,
+		Verify:          c.Verify,
+		Tolerance:       c.Tolerance,
+		OnChunk:         c.OnChunk,
+		running:         make(map[OpID]bool),
+	}
+}
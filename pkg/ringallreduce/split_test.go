@@ -0,0 +1,86 @@
+package ringallreduce
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCommunicator_Split(t *testing.T) {
+	tests := []struct {
+		name   string
+		colors []int
+		keys   []int
+		want   map[int][]MemberID
+	}{
+		{"halves", []int{0, 0, 0, 1, 1, 1}, nil, map[int][]MemberID{0: {0, 1, 2}, 1: {3, 4, 5}}},
+		{"interleaved", []int{0, 1, 0, 1, 0, 1}, nil, map[int][]MemberID{0: {0, 2, 4}, 1: {1, 3, 5}}},
+		{"keys reorder", []int{0, 0, 0, 0, 0, 0}, []int{5, 4, 3, 2, 1, 0}, map[int][]MemberID{0: {5, 4, 3, 2, 1, 0}}},
+		{"key ties keep rank order", []int{2, 2, 2, 2, 7, 7}, []int{1, 0, 1, 0, 0, 0}, map[int][]MemberID{2: {1, 3, 0, 2}, 7: {4, 5}}},
+		{"undefined", []int{0, SplitUndefined, 0, SplitUndefined, 0, SplitUndefined}, nil, map[int][]MemberID{0: {0, 2, 4}}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			subs, err := NewCommunicator(6).Split(tc.colors, tc.keys)
+			if err != nil {
+				t.Fatalf("Split: %v", err)
+			}
+			got := map[int][]MemberID{}
+			for color, sub := range subs {
+				got[color] = sub.Members()
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("members %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCommunicator_SplitAllReduce(t *testing.T) {
+	// Two data-parallel groups of a 2x4 grid reduce independently.
+	comm := NewCommunicator(8)
+	comm.Topology = func(size int) Topology { return NewTree(size) }
+	subs, err := comm.Split([]int{0, 0, 0, 0, 1, 1, 1, 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for color, sub := range subs {
+		if sub.Size() != 4 {
+			t.Fatalf("color %d: size %d, want 4", color, sub.Size())
+		}
+		data := map[MemberID][]float64{}
+		for _, id := range sub.Members() {
+			data[id] = []float64{float64(id), 1}
+		}
+		if err := sub.AllReduceMembers(sub.NewOpID(), data); err != nil {
+			t.Fatalf("color %d: %v", color, err)
+		}
+		// Members 0..3 sum to 6, members 4..7 to 22.
+		want := []float64{float64(6 + 16*color), 4}
+		for id, v := range data {
+			if !reflect.DeepEqual(v, want) {
+				t.Errorf("color %d member %d: got %v, want %v", color, id, v, want)
+			}
+		}
+	}
+	if comm.Size() != 8 {
+		t.Errorf("parent size %d, want 8", comm.Size())
+	}
+}
+
+func TestCommunicator_SplitErrors(t *testing.T) {
+	comm := NewCommunicator(3)
+	if _, err := comm.Split([]int{0, 0}, nil); err == nil {
+		t.Error("expected an error for too few colors")
+	}
+	if _, err := comm.Split([]int{0, 0, 0}, []int{1}); err == nil {
+		t.Error("expected an error for too few keys")
+	}
+	if _, err := comm.Split([]int{0, -2, 0}, nil); err == nil {
+		t.Error("expected an error for a negative color")
+	}
+	if _, err := NewCommunicator(0).Split(nil, nil); !errors.Is(err, ErrNoRanks) {
+		t.Errorf("empty group: got %v, want ErrNoRanks", err)
+	}
+}
@@ -0,0 +1,107 @@
+package ringallreduce
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// RankStats is what one rank measured during its last all–reduce.
+type RankStats struct {
+	Steps            int
+	MessagesSent     int
+	MessagesReceived int
+	BytesSent        int // payload bytes
+	BytesReceived    int
+	Allocs           int           // message buffers the rank allocated instead of reusing
+	ReduceScatter    time.Duration // time spent in reduce–scatter steps
+	AllGather        time.Duration // time spent in allgather steps
+}
+
+// OpStats summarizes one all–reduce as measured by its ranks, unlike
+// Account, which predicts the work from the schedule. Totals are over all
+// ranks, Steps is the longest schedule and the phase durations are those of
+// the slowest rank.
+type OpStats struct {
+	Topology string
+	P        int
+	Elements int
+	Duration time.Duration // wall time of the whole collective, if known
+	RankStats
+	PerRank []RankStats
+}
+
+// StatsOf summarizes the last all–reduce of nodes, e.g. the nodes returned
+// by Execute.
+func StatsOf(nodes []*Node) OpStats {
+	s := OpStats{P: len(nodes), PerRank: make([]RankStats, len(nodes))}
+	for i, n := range nodes {
+		if i == 0 {
+			s.Topology = n.topology().Name()
+			s.Elements = len(n.Data)
+		}
+		r := n.Stats
+		s.PerRank[i] = r
+		s.Steps = max(s.Steps, r.Steps)
+		s.MessagesSent += r.MessagesSent
+		s.MessagesReceived += r.MessagesReceived
+		s.BytesSent += r.BytesSent
+		s.BytesReceived += r.BytesReceived
+		s.Allocs += r.Allocs
+		s.ReduceScatter = max(s.ReduceScatter, r.ReduceScatter)
+		s.AllGather = max(s.AllGather, r.AllGather)
+	}
+	return s
+}
+
+// merge adds the statistics of a later segment of the same all–reduce to s.
+// The segments run one after another, so their steps and durations add up.
+func (s *OpStats) merge(seg OpStats) {
+	if s.PerRank == nil {
+		s.Topology, s.P = seg.Topology, seg.P
+		s.PerRank = make([]RankStats, len(seg.PerRank))
+	}
+	s.Elements += seg.Elements
+	s.Duration += seg.Duration
+	s.RankStats.add(seg.RankStats)
+	for i := range s.PerRank {
+		s.PerRank[i].add(seg.PerRank[i])
+	}
+}
+
+func (s *RankStats) add(o RankStats) {
+	s.Steps += o.Steps
+	s.MessagesSent += o.MessagesSent
+	s.MessagesReceived += o.MessagesReceived
+	s.BytesSent += o.BytesSent
+	s.BytesReceived += o.BytesReceived
+	s.Allocs += o.Allocs
+	s.ReduceScatter += o.ReduceScatter
+	s.AllGather += o.AllGather
+}
+
+// WriteStats writes s as an aligned table with one row per rank and a row
+// of totals.
+func WriteStats(w io.Writer, s OpStats) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "rank\tsteps\tsent\trecv\tbytes sent\tbytes recv\tallocs\treduce-scatter\tallgather\t")
+	row := func(name string, r RankStats) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%v\t%v\t\n", name, r.Steps, r.MessagesSent, r.MessagesReceived,
+			r.BytesSent, r.BytesReceived, r.Allocs, r.ReduceScatter.Round(time.Microsecond), r.AllGather.Round(time.Microsecond))
+	}
+	for i, r := range s.PerRank {
+		row(fmt.Sprint(i), r)
+	}
+	row("total", s.RankStats)
+	return tw.Flush()
+}
+
+// phaseTime adds d to the time spent in phase.
+func (s *RankStats) phaseTime(phase Phase, d time.Duration) {
+	if phase == PhaseAllGather {
+		s.AllGather += d
+	} else {
+		s.ReduceScatter += d
+	}
+}
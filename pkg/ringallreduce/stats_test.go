@@ -0,0 +1,86 @@
+package ringallreduce
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAllReduceStats_MatchesAccount(t *testing.T) {
+	tests := []struct {
+		name     string
+		topology Topology
+		n        int
+	}{
+		{"ring", NewRing(4), 16},
+		{"tree", NewTree(5), 12},
+		{"torus", NewTorus(2, 3), 30},
+		{"fully-connected", NewFullyConnected(3), 7},
+		{"parameter-server", NewParameterServer(4), 8},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			p := tc.topology.Size()
+			r := New()
+			_, stats, err := r.AllReduceStats(tc.topology, vectors(p, tc.n))
+			if err != nil {
+				t.Fatalf("AllReduceStats: %v", err)
+			}
+			want := Account(tc.topology, tc.n)
+			if stats.Steps != want.Steps || stats.MessagesSent != want.Messages || stats.BytesSent != want.Bytes {
+				t.Errorf("measured %d steps, %d messages, %d bytes; accounted %d, %d, %d",
+					stats.Steps, stats.MessagesSent, stats.BytesSent, want.Steps, want.Messages, want.Bytes)
+			}
+			if stats.MessagesReceived != stats.MessagesSent || stats.BytesReceived != stats.BytesSent {
+				t.Errorf("received %d messages, %d bytes; sent %d, %d",
+					stats.MessagesReceived, stats.BytesReceived, stats.MessagesSent, stats.BytesSent)
+			}
+			// Buffers are recycled, so there are fewer allocations than
+			// messages.
+			if stats.Allocs == 0 || stats.Allocs >= stats.MessagesSent {
+				t.Errorf("%d allocations for %d messages", stats.Allocs, stats.MessagesSent)
+			}
+			if stats.P != p || stats.Elements != tc.n || stats.Topology != tc.topology.Name() || len(stats.PerRank) != p {
+				t.Errorf("unexpected header %+v", stats)
+			}
+			for r, rs := range stats.PerRank {
+				if rs.ReduceScatter+rs.AllGather > stats.Duration {
+					t.Errorf("rank %d: phases %v + %v don't fit in %v", r, rs.ReduceScatter, rs.AllGather, stats.Duration)
+				}
+				if want := want.PerRank[r]; rs.Steps != want.Steps || rs.MessagesSent != want.Messages {
+					t.Errorf("rank %d: measured %+v, accounted %+v", r, rs, want)
+				}
+			}
+		})
+	}
+}
+
+func TestStatsOf_Execute(t *testing.T) {
+	stats := StatsOf(Execute(3, 2))
+	// 2(p-1) steps of one chunk of 2 elements for every rank.
+	if stats.Steps != 4 || stats.MessagesSent != 12 || stats.BytesSent != 12*16 || stats.Elements != 6 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	var b strings.Builder
+	if err := WriteStats(&b, stats); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(b.String()), "\n"); len(lines) != 5 || !strings.Contains(lines[4], "total") {
+		t.Errorf("unexpected table:\n%s", b.String())
+	}
+}
+
+func TestCommunicator_LastStats(t *testing.T) {
+	c := NewCommunicator(4)
+	if _, ok := c.LastStats(); ok {
+		t.Fatal("expected no stats before the first collective")
+	}
+	if err := c.AllReduce(c.NewOpID(), vectors(4, 10)); err != nil {
+		t.Fatal(err)
+	}
+	stats, ok := c.LastStats()
+	if !ok || stats.Elements != 10 || stats.MessagesSent != Account(NewRing(4), 10).Messages {
+		t.Errorf("unexpected stats %+v, %v", stats, ok)
+	}
+}
@@ -0,0 +1,141 @@
+package ringallreduce
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// StripeShares splits n elements among parallel links, one ring per link,
+// in proportion to their bandwidth so that all stripes finish at about the
+// same time. If any link has unlimited bandwidth, the split is even.
+func StripeShares(n int, links []Link) []int {
+	shares := make([]int, len(links))
+	if len(links) == 0 {
+		return shares
+	}
+	weights := make([]float64, len(links))
+	var total float64
+	for i, l := range links {
+		weights[i] = l.Bandwidth
+		total += l.Bandwidth
+	}
+	for _, l := range links {
+		if l.Bandwidth <= 0 {
+			for i := range weights {
+				weights[i] = 1
+			}
+			total = float64(len(links))
+			break
+		}
+	}
+	assigned := 0
+	for i, w := range weights {
+		shares[i] = int(float64(n) * w / total)
+		assigned += shares[i]
+	}
+	// Hand out what rounding left over where it delays the least.
+	for assigned < n {
+		best := 0
+		for i := range weights {
+			// Finish time of the link with one more element; ties go to the
+			// faster link.
+			ti, tb := float64(shares[i]+1)/weights[i], float64(shares[best]+1)/weights[best]
+			if ti < tb || ti == tb && weights[i] > weights[best] {
+				best = i
+			}
+		}
+		shares[best]++
+		assigned++
+	}
+	return shares
+}
+
+// AllReduceStriped sums inputs over one all–reduce of t per link, running
+// concurrently as over separate NICs. Stripe i carries a contiguous slice of
+// the vector sized by StripeShares.
+func (r *RingAllReduce) AllReduceStriped(t Topology, links []Link, inputs [][]float64) ([][]float64, error) {
+	if len(links) == 0 {
+		return nil, fmt.Errorf("no links to stripe over")
+	}
+	if t.Size() == 0 {
+		return nil, ErrNoRanks
+	}
+	if len(inputs) != t.Size() {
+		return nil, fmt.Errorf("got %d input vectors for %d ranks", len(inputs), t.Size())
+	}
+	n := len(inputs[0])
+	out := make([][]float64, len(inputs))
+	for i := range out {
+		out[i] = make([]float64, n)
+	}
+	err := runStripes(n, StripeShares(n, links), func(stripe, start, end int) error {
+		part := make([][]float64, len(inputs))
+		for i, in := range inputs {
+			if len(in) != n {
+				return fmt.Errorf("rank %d: vector length %d differs from %d", i, len(in), n)
+			}
+			part[i] = in[start:end]
+		}
+		res, _, err := runCollective(t, NewChanTransport(t), 0, part, runOptions{})
+		if err != nil {
+			return fmt.Errorf("stripe %d: %w", stripe, err)
+		}
+		for i := range out {
+			copy(out[i][start:end], res[i])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SimulateStriped is Simulate for an all–reduce of n elements striped over
+// one ring of t per link. Every stripe runs over its own link, so the
+// collective takes as long as the slowest stripe.
+func SimulateStriped(t Topology, n int, links []Link, seed int64) (time.Duration, error) {
+	if len(links) == 0 {
+		return 0, fmt.Errorf("no links to stripe over")
+	}
+	var mu sync.Mutex
+	var elapsed time.Duration
+	err := runStripes(n, StripeShares(n, links), func(stripe, start, end int) error {
+		d, err := Simulate(t, end-start, UniformLinks(links[stripe]), seed+int64(stripe))
+		if err != nil {
+			return fmt.Errorf("stripe %d: %w", stripe, err)
+		}
+		mu.Lock()
+		elapsed = max(elapsed, d)
+		mu.Unlock()
+		return nil
+	})
+	return elapsed, err
+}
+
+// runStripes calls run concurrently for every non-empty stripe with the
+// element range it covers and returns the first error.
+func runStripes(n int, shares []int, run func(stripe, start, end int) error) error {
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	start := 0
+	for i, share := range shares {
+		if share == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(i, start, end int) {
+			defer wg.Done()
+			if err := run(i, start, end); err != nil {
+				once.Do(func() { firstErr = err })
+			}
+		}(i, start, start+share)
+		start += share
+	}
+	wg.Wait()
+	return firstErr
+}
@@ -0,0 +1,114 @@
+package ringallreduce
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStripeShares(t *testing.T) {
+	tests := []struct {
+		name  string
+		n     int
+		links []Link
+		want  []int
+	}{
+		{"even", 12, []Link{{Bandwidth: 1e9}, {Bandwidth: 1e9}, {Bandwidth: 1e9}}, []int{4, 4, 4}},
+		{"weighted", 12, []Link{{Bandwidth: 3e9}, {Bandwidth: 1e9}}, []int{9, 3}},
+		{"remainder to the fastest", 10, []Link{{Bandwidth: 1e9}, {Bandwidth: 2e9}, {Bandwidth: 1e9}}, []int{2, 6, 2}},
+		{"unlimited", 5, []Link{{}, {Bandwidth: 1e9}}, []int{3, 2}},
+		{"fewer elements than links", 1, []Link{{Bandwidth: 1}, {Bandwidth: 1}}, []int{1, 0}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if got := StripeShares(tc.n, tc.links); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("StripeShares = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAllReduceStriped(t *testing.T) {
+	r := New()
+	links := []Link{{Bandwidth: 2e9}, {Bandwidth: 1e9}, {Bandwidth: 1e9}}
+	inputs := make([][]float64, 4)
+	for i := range inputs {
+		inputs[i] = make([]float64, 21)
+		for j := range inputs[i] {
+			inputs[i][j] = float64(i*100 + j)
+		}
+	}
+	got, err := r.AllReduceStriped(NewRing(4), links, inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := r.AllReduce(NewRing(4), inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("striped result %v differs from %v", got[0], want[0])
+	}
+}
+
+func TestSimulateStriped_Speedup(t *testing.T) {
+	nic := Link{Latency: 10 * time.Microsecond, Bandwidth: 1e9}
+	const n = 1 << 20
+	one, err := SimulateStriped(NewRing(8), n, []Link{nic}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	four, err := SimulateStriped(NewRing(8), n, []Link{nic, nic, nic, nic}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if speedup := float64(one) / float64(four); speedup < 3.5 {
+		t.Errorf("four NICs are only %.2fx faster than one (%v vs %v)", speedup, one, four)
+	}
+
+	// A slow NIC gets a smaller stripe instead of holding the others back.
+	mixed, err := SimulateStriped(NewRing(8), n, []Link{nic, {Latency: nic.Latency, Bandwidth: nic.Bandwidth / 4}}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if speedup := float64(one) / float64(mixed); speedup < 1.2 {
+		t.Errorf("an extra quarter-speed NIC should still help, got %.2fx", speedup)
+	}
+}
+
+func BenchmarkSimulateStriped(b *testing.B) {
+	nic := Link{Latency: 10 * time.Microsecond, Bandwidth: 1e9}
+	var base time.Duration
+	for _, stripes := range []int{1, 2, 4, 8} {
+		links := make([]Link, stripes)
+		for i := range links {
+			links[i] = nic
+		}
+		b.Run(fmt.Sprintf("nics=%d", stripes), func(b *testing.B) {
+			var d time.Duration
+			for i := 0; i < b.N; i++ {
+				var err error
+				if d, err = SimulateStriped(NewRing(8), 1<<18, links, 1); err != nil {
+					b.Fatal(err)
+				}
+			}
+			if stripes == 1 {
+				base = d
+			}
+			b.ReportMetric(d.Seconds()*1e3, "sim-ms")
+			if base > 0 {
+				b.ReportMetric(float64(base)/float64(d), "speedup")
+			}
+		})
+	}
+}
+
+func TestAllReduceStriped_NoRanks(t *testing.T) {
+	r := New()
+	if _, err := r.AllReduceStriped(NewRing(0), []Link{{}}, nil); !errors.Is(err, ErrNoRanks) {
+		t.Errorf("got %v, want ErrNoRanks", err)
+	}
+}
@@ -0,0 +1,391 @@
+package ringallreduce
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"slices"
+	"sync"
+	"time"
+)
+
+// handshakeMagic opens every TCP connection, followed by the dialer's rank
+// and the ring size. The acceptor answers with its own rank so the dialer
+// can verify it reached the peer it expected.
+var handshakeMagic = [4]byte{'A', 'R', 'v', '1'}
+
+// maxFrameSize bounds a single frame so that a corrupted length prefix can't
+// make the reader allocate unbounded memory.
+const maxFrameSize = 1 << 30
+
+// TCPTransport connects one rank to its peers over TCP, so the all–reduce can
+// span OS processes and machines. Every rank listens on its own address from
+// the static peer list and dials the ranks it sends to. Messages travel as
+// length-prefixed binary frames.
+type TCPTransport struct {
+	Rank          int           // rank of the local process
+	Peers         []string      // listen address of every rank, indexed by rank
+	RetryInterval time.Duration // pause between failed dials
+
+	ln     net.Listener
+	inbox  chan Msg
+	errs   chan error
+	closed chan struct{}
+	once   sync.Once
+
+	dialMu sync.Mutex // serializes dials so each peer is dialed once
+	mu     sync.Mutex // guards out and conns
+	out    map[int]*tcpConn
+	conns  []net.Conn
+	wg     sync.WaitGroup
+}
+
+type tcpConn struct {
+	mu sync.Mutex
+	c  net.Conn
+	w  *bufio.Writer
+}
+
+// ListenTCP listens on peers[rank] and accepts connections from other ranks.
+func ListenTCP(rank int, peers []string) (*TCPTransport, error) {
+	if rank < 0 || rank >= len(peers) {
+		return nil, fmt.Errorf("rank %d outside peer list of size %d", rank, len(peers))
+	}
+	ln, err := net.Listen("tcp", peers[rank])
+	if err != nil {
+		return nil, err
+	}
+	return NewTCPTransport(rank, ln, peers), nil
+}
+
+// NewTCPTransport serves the given listener for rank. It is useful when the
+// listener has to be created before the peer list is known, for example when
+// binding to port 0.
+func NewTCPTransport(rank int, ln net.Listener, peers []string) *TCPTransport {
+	t := &TCPTransport{
+		Rank:          rank,
+		Peers:         peers,
+		RetryInterval: 50 * time.Millisecond,
+		ln:            ln,
+		inbox:         make(chan Msg, 1024),
+		errs:          make(chan error, 1),
+		closed:        make(chan struct{}),
+		out:           make(map[int]*tcpConn),
+	}
+	t.wg.Add(1)
+	go t.accept()
+	return t
+}
+
+// Addr returns the address the transport listens on.
+func (t *TCPTransport) Addr() net.Addr {
+	return t.ln.Addr()
+}
+
+// Connect dials every listed rank, retrying until it answers or ctx is done.
+func (t *TCPTransport) Connect(ctx context.Context, ranks []int) error {
+	for _, r := range ranks {
+		if _, err := t.conn(ctx, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConnectTopology dials every rank the local schedule of topology sends to.
+// For a ring this forms the ring by connecting each rank to its right neighbor.
+func (t *TCPTransport) ConnectTopology(ctx context.Context, topology Topology) error {
+	return t.Connect(ctx, sendTargets(topology, t.Rank))
+}
+
+func (t *TCPTransport) Send(rank int, msg Msg) error {
+	c, err := t.conn(context.Background(), rank)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err = writeFrame(c.w, msg)
+	if err == nil {
+		err = c.w.Flush()
+	}
+	if err != nil {
+		// The stream may hold part of a frame now, so the next Send dials
+		// a fresh connection instead.
+		t.drop(rank, c)
+		return fmt.Errorf("send to rank %d: %w", rank, err)
+	}
+	return nil
+}
+
+func (t *TCPTransport) Recv(rank int) (Msg, error) {
+	if rank != t.Rank {
+		return Msg{}, fmt.Errorf("rank %d can't receive for rank %d", t.Rank, rank)
+	}
+	select {
+	case m := <-t.inbox:
+		return m, nil
+	case err := <-t.errs:
+		return Msg{}, err
+	case <-t.closed:
+		return Msg{}, ErrTransportClosed
+	}
+}
+
+// Close stops accepting connections and closes every open connection.
+func (t *TCPTransport) Close() error {
+	var err error
+	t.once.Do(func() {
+		close(t.closed)
+		err = t.ln.Close()
+		t.mu.Lock()
+		for _, c := range t.conns {
+			c.Close()
+		}
+		t.mu.Unlock()
+		t.wg.Wait()
+	})
+	return err
+}
+
+// conn returns the outgoing connection to rank, dialing it if needed.
+func (t *TCPTransport) conn(ctx context.Context, rank int) (*tcpConn, error) {
+	if rank < 0 || rank >= len(t.Peers) {
+		return nil, fmt.Errorf("send to unknown rank %d", rank)
+	}
+	if c := t.outgoing(rank); c != nil {
+		return c, nil
+	}
+	t.dialMu.Lock()
+	defer t.dialMu.Unlock()
+	if c := t.outgoing(rank); c != nil {
+		return c, nil
+	}
+
+	var d net.Dialer
+	for {
+		select {
+		case <-t.closed:
+			return nil, ErrTransportClosed
+		default:
+		}
+		c, err := d.DialContext(ctx, "tcp", t.Peers[rank])
+		if err == nil {
+			if err = t.handshake(ctx, c, rank); err != nil {
+				c.Close()
+				return nil, err
+			}
+			tc := &tcpConn{c: c, w: bufio.NewWriter(c)}
+			t.mu.Lock()
+			t.out[rank] = tc
+			t.conns = append(t.conns, c)
+			t.mu.Unlock()
+			return tc, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("dial rank %d at %s: %w", rank, t.Peers[rank], ctx.Err())
+		case <-t.closed:
+			return nil, ErrTransportClosed
+		case <-time.After(t.RetryInterval):
+		}
+	}
+}
+
+func (t *TCPTransport) outgoing(rank int) *tcpConn {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.out[rank]
+}
+
+// drop forgets the outgoing connection c to rank and closes it.
+func (t *TCPTransport) drop(rank int, c *tcpConn) {
+	t.mu.Lock()
+	if t.out[rank] == c {
+		delete(t.out, rank)
+	}
+	t.conns = slices.DeleteFunc(t.conns, func(nc net.Conn) bool { return nc == c.c })
+	t.mu.Unlock()
+	c.c.Close()
+}
+
+// handshake introduces the local rank on c and checks that rank answers. It
+// gives up at the deadline of ctx, if any.
+func (t *TCPTransport) handshake(ctx context.Context, c net.Conn, rank int) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := c.SetDeadline(deadline); err != nil {
+			return fmt.Errorf("handshake with rank %d: %w", rank, err)
+		}
+		defer c.SetDeadline(time.Time{})
+	}
+	var hello [12]byte
+	copy(hello[:4], handshakeMagic[:])
+	binary.BigEndian.PutUint32(hello[4:], uint32(t.Rank))
+	binary.BigEndian.PutUint32(hello[8:], uint32(len(t.Peers)))
+	if _, err := c.Write(hello[:]); err != nil {
+		return fmt.Errorf("handshake with rank %d: %w", rank, err)
+	}
+	var reply [4]byte
+	if _, err := io.ReadFull(c, reply[:]); err != nil {
+		return fmt.Errorf("handshake with rank %d: %w", rank, err)
+	}
+	if got := int(binary.BigEndian.Uint32(reply[:])); got != rank {
+		return fmt.Errorf("handshake: dialed rank %d but %s answered as rank %d", rank, t.Peers[rank], got)
+	}
+	return nil
+}
+
+func (t *TCPTransport) accept() {
+	defer t.wg.Done()
+	for {
+		c, err := t.ln.Accept()
+		if err != nil {
+			return
+		}
+		t.mu.Lock()
+		t.conns = append(t.conns, c)
+		t.mu.Unlock()
+		t.wg.Add(1)
+		go t.serve(c)
+	}
+}
+
+// serve validates the handshake of an incoming connection and forwards its
+// frames to the inbox.
+func (t *TCPTransport) serve(c net.Conn) {
+	defer t.wg.Done()
+	defer c.Close()
+
+	var hello [12]byte
+	if _, err := io.ReadFull(c, hello[:]); err != nil {
+		return
+	}
+	if [4]byte(hello[:4]) != handshakeMagic {
+		return
+	}
+	from := int(binary.BigEndian.Uint32(hello[4:]))
+	size := int(binary.BigEndian.Uint32(hello[8:]))
+	if size != len(t.Peers) || from < 0 || from >= size {
+		return
+	}
+	var reply [4]byte
+	binary.BigEndian.PutUint32(reply[:], uint32(t.Rank))
+	if _, err := c.Write(reply[:]); err != nil {
+		return
+	}
+
+	r := bufio.NewReader(c)
+	for {
+		m, err := readFrame(r)
+		if err != nil {
+			select {
+			case <-t.closed:
+			default:
+				if !errors.Is(err, io.EOF) {
+					t.fail(fmt.Errorf("receive from rank %d: %w", from, err))
+				}
+			}
+			return
+		}
+		select {
+		case t.inbox <- m:
+		case <-t.closed:
+			return
+		}
+	}
+}
+
+func (t *TCPTransport) fail(err error) {
+	select {
+	case t.errs <- err:
+	default:
+	}
+}
+
+// sendTargets lists the distinct ranks the schedule of rank sends to.
+func sendTargets(topology Topology, rank int) []int {
+	seen := map[int]bool{}
+	var ranks []int
+	for _, step := range topology.Schedule(rank) {
+		if step.SendTo != NoPeer && !seen[step.SendTo] {
+			seen[step.SendTo] = true
+			ranks = append(ranks, step.SendTo)
+		}
+	}
+	return ranks
+}
+
+// msgHeaderSize is the encoded size of a Msg without its elements.
+const msgHeaderSize = 4 + 4 + 8 + 8 + 1 + 4
+
+// writeFrame writes msg as a big-endian length prefix followed by the
+// encoding of appendMsg.
+func writeFrame(w io.Writer, msg Msg) error {
+	_, err := w.Write(msg.Marshal())
+	return err
+}
+
+// readFrame reads one frame written by writeFrame.
+func readFrame(r io.Reader) (Msg, error) {
+	var prefix [4]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return Msg{}, err
+	}
+	size := binary.BigEndian.Uint32(prefix[:])
+	if size < msgHeaderSize || size > maxFrameSize {
+		return Msg{}, fmt.Errorf("invalid frame size %d", size)
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return Msg{}, err
+	}
+	return decodeMsg(buf)
+}
+
+// appendMsg appends the sender rank, the chunk index, the invocation tag,
+// the sequence number, the ack flag, the element count and the IEEE 754
+// elements of msg to buf, all big-endian.
+func appendMsg(buf []byte, msg Msg) []byte {
+	buf = binary.BigEndian.AppendUint32(buf, uint32(msg.From))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(msg.ChunkIdx))
+	buf = binary.BigEndian.AppendUint64(buf, msg.Op)
+	buf = binary.BigEndian.AppendUint64(buf, msg.Seq)
+	var flags byte
+	if msg.Ack {
+		flags |= 1
+	}
+	buf = append(buf, flags)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(msg.Data)))
+	for _, v := range msg.Data {
+		buf = binary.BigEndian.AppendUint64(buf, math.Float64bits(v))
+	}
+	return buf
+}
+
+// decodeMsg decodes a message encoded by appendMsg.
+func decodeMsg(buf []byte) (Msg, error) {
+	if len(buf) < msgHeaderSize {
+		return Msg{}, fmt.Errorf("message of %d bytes is too short", len(buf))
+	}
+	n := binary.BigEndian.Uint32(buf[25:])
+	if uint64(len(buf)-msgHeaderSize) != 8*uint64(n) {
+		return Msg{}, fmt.Errorf("message of %d bytes can't hold %d elements", len(buf), n)
+	}
+	m := Msg{
+		From:     int(int32(binary.BigEndian.Uint32(buf))),
+		ChunkIdx: int(int32(binary.BigEndian.Uint32(buf[4:]))),
+		Op:       binary.BigEndian.Uint64(buf[8:]),
+		Seq:      binary.BigEndian.Uint64(buf[16:]),
+		Ack:      buf[24]&1 != 0,
+		Data:     make([]float64, n),
+	}
+	for i := range m.Data {
+		m.Data[i] = math.Float64frombits(binary.BigEndian.Uint64(buf[msgHeaderSize+8*i:]))
+	}
+	return m, nil
+}
@@ -0,0 +1,203 @@
+package ringallreduce
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// listenLoopback starts p TCP transports on ephemeral loopback ports.
+func listenLoopback(t *testing.T, p int) []*TCPTransport {
+	t.Helper()
+	listeners := make([]net.Listener, p)
+	peers := make([]string, p)
+	for i := range listeners {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+		listeners[i] = ln
+		peers[i] = ln.Addr().String()
+	}
+	transports := make([]*TCPTransport, p)
+	for i, ln := range listeners {
+		transports[i] = NewTCPTransport(i, ln, peers)
+		t.Cleanup(func() { transports[i].Close() })
+	}
+	return transports
+}
+
+func TestTCPTransport_AllReduce(t *testing.T) {
+	tests := []struct {
+		name      string
+		topology  Topology
+		chunkSize int
+	}{
+		{name: "ring p=4", topology: NewRing(4), chunkSize: 3},
+		{name: "tree p=5", topology: NewTree(5), chunkSize: 2},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			p := tc.topology.Size()
+			transports := listenLoopback(t, p)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			nodes := make([]*Node, p)
+			var wg sync.WaitGroup
+			wg.Add(p)
+			for i := 0; i < p; i++ {
+				data := make([]float64, p*tc.chunkSize)
+				for j := range data {
+					data[j] = float64(i + 1)
+				}
+				nodes[i] = &Node{Rank: i, P: p, ChunkSize: tc.chunkSize, Data: data, Topology: tc.topology, Transport: transports[i]}
+				go func(n *Node) {
+					defer wg.Done()
+					if err := transports[n.Rank].ConnectTopology(ctx, tc.topology); err != nil {
+						n.Err = err
+						return
+					}
+					n.Err = n.AllReduce()
+				}(nodes[i])
+			}
+			wg.Wait()
+
+			expected := float64(p * (p + 1) / 2)
+			for _, n := range nodes {
+				if n.Err != nil {
+					t.Fatalf("node=%d: unexpected error: %v", n.Rank, n.Err)
+				}
+				for j, v := range n.Data {
+					if v != expected {
+						t.Errorf("node=%d, elem=%d: expected %f, got %f", n.Rank, j, expected, v)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestTCPTransport_ConnectTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	// Rank 1's address accepts nothing: grab a port and release it.
+	dead, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	deadAddr := dead.Addr().String()
+	dead.Close()
+
+	tr := NewTCPTransport(0, ln, []string{ln.Addr().String(), deadAddr})
+	defer tr.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := tr.Connect(ctx, []int{1}); err == nil {
+		t.Fatal("expected dial error")
+	}
+}
+
+func TestTCPTransport_HandshakeTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	// Rank 1 accepts connections but never answers the handshake.
+	mute, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer mute.Close()
+	go func() {
+		for {
+			c, err := mute.Accept()
+			if err != nil {
+				return
+			}
+			defer c.Close()
+		}
+	}()
+
+	tr := NewTCPTransport(0, ln, []string{ln.Addr().String(), mute.Addr().String()})
+	defer tr.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- tr.Connect(ctx, []int{1}) }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected handshake error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("handshake ignored the context deadline")
+	}
+}
+
+func TestTCPTransport_SendDropsBrokenConn(t *testing.T) {
+	transports := listenLoopback(t, 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := transports[0].Connect(ctx, []int{1}); err != nil {
+		t.Fatal(err)
+	}
+	transports[1].Close()
+
+	// Writes only fail once the peer's reset arrives.
+	var err error
+	for i := 0; i < 100 && err == nil; i++ {
+		err = transports[0].Send(1, Msg{Data: make([]float64, 1024)})
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err == nil {
+		t.Fatal("expected an error sending to a closed peer")
+	}
+	if c := transports[0].outgoing(1); c != nil {
+		t.Error("expected the broken connection to be dropped")
+	}
+}
+
+func TestTCPTransport_RecvAfterClose(t *testing.T) {
+	transports := listenLoopback(t, 2)
+	transports[0].Close()
+	if _, err := transports[0].Recv(0); err != ErrTransportClosed {
+		t.Errorf("expected ErrTransportClosed, got %v", err)
+	}
+	if _, err := transports[1].Recv(0); err == nil {
+		t.Errorf("expected error receiving for a foreign rank")
+	}
+}
+
+func TestFrame_RoundTrip(t *testing.T) {
+	msgs := []Msg{
+		{From: 3, ChunkIdx: 7, Data: []float64{1.5, -2, 0}, Op: 9, Seq: 2, Ack: true},
+		{From: 0, ChunkIdx: 0, Data: []float64{}},
+	}
+	var buf bytes.Buffer
+	for _, m := range msgs {
+		if err := writeFrame(&buf, m); err != nil {
+			t.Fatalf("writeFrame: %v", err)
+		}
+	}
+	for _, want := range msgs {
+		got, err := readFrame(&buf)
+		if err != nil {
+			t.Fatalf("readFrame: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %+v, got %+v", want, got)
+		}
+	}
+}
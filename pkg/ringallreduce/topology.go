@@ -0,0 +1,563 @@
+package ringallreduce
+
+import (
+	"fmt"
+)
+
+// Phase identifies which half of an all–reduce a schedule step belongs to.
+type Phase int
+
+const (
+	// PhaseReduceScatter steps accumulate received chunks into the local buffer.
+	PhaseReduceScatter Phase = iota
+	// PhaseAllGather steps overwrite local chunks with fully reduced ones.
+	PhaseAllGather
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseReduceScatter:
+		return "reduce-scatter"
+	case PhaseAllGather:
+		return "allgather"
+	default:
+		return fmt.Sprintf("phase(%d)", int(p))
+	}
+}
+
+// NoPeer marks the missing half of a send-only or receive-only step.
+const NoPeer = -1
+
+// Step is one entry of a rank's communication schedule.
+// The listed chunks are first sent to SendTo and then the listed chunks are
+// received from RecvFrom. Received chunks are added element–wise to the local
+// buffer when Reduce is set and copied over it otherwise.
+type Step struct {
+	Phase      Phase
+	SendTo     int   // destination rank, or NoPeer
+	SendChunks []int // chunk indices sent to SendTo
+	RecvFrom   int   // source rank, or NoPeer
+	RecvChunks []int // chunk indices received from RecvFrom
+	Reduce     bool  // accumulate (true) or overwrite (false) received chunks
+}
+
+// Topology describes how ranks are wired together and the order in which
+// they exchange chunks. Schedules operate on P chunks, where P is Size().
+type Topology interface {
+	Name() string
+	Size() int
+	Neighbors(rank int) []int
+	Schedule(rank int) []Step
+}
+
+// Ring is the classic unidirectional ring: rank i sends to (i+1) mod P.
+type Ring struct {
+	P int
+}
+
+func NewRing(p int) Ring {
+	return Ring{P: p}
+}
+
+func (t Ring) Name() string { return "ring" }
+
+func (t Ring) Size() int { return t.P }
+
+// Neighbors returns the left (receive) and right (send) neighbor of rank.
+func (t Ring) Neighbors(rank int) []int {
+	if t.P < 2 {
+		return nil
+	}
+	left := (rank - 1 + t.P) % t.P
+	right := (rank + 1) % t.P
+	if left == right {
+		return []int{right}
+	}
+	return []int{left, right}
+}
+
+func (t Ring) Schedule(rank int) []Step {
+	members := make([]int, t.P)
+	chunks := make([]int, t.P)
+	for i := range members {
+		members[i] = i
+		chunks[i] = i
+	}
+	steps := ringReduceScatter(members, rank, chunks)
+	return append(steps, ringAllGather(members, rank, chunks)...)
+}
+
+// PermutedRing is a Ring whose positions are taken by ranks in a custom
+// order: Order[i] sends to Order[i+1 mod P]. Placing ranks that share a
+// host or rack next to each other keeps most hops on fast links; see
+// NearestRingOrder.
+type PermutedRing struct {
+	Order []int
+	pos   []int // pos[rank] is the position of rank in Order
+}
+
+// NewPermutedRing returns the ring visiting ranks in order, which must be a
+// permutation of 0..len(order)-1.
+func NewPermutedRing(order []int) (PermutedRing, error) {
+	pos := make([]int, len(order))
+	for i := range pos {
+		pos[i] = -1
+	}
+	for i, r := range order {
+		if r < 0 || r >= len(order) || pos[r] != -1 {
+			return PermutedRing{}, fmt.Errorf("order %v is not a permutation of 0..%d", order, len(order)-1)
+		}
+		pos[r] = i
+	}
+	return PermutedRing{Order: append([]int(nil), order...), pos: pos}, nil
+}
+
+func (t PermutedRing) Name() string { return "permuted-ring" }
+
+func (t PermutedRing) Size() int { return len(t.Order) }
+
+// Neighbors returns the left (receive) and right (send) neighbor of rank.
+func (t PermutedRing) Neighbors(rank int) []int {
+	p := len(t.Order)
+	if p < 2 {
+		return nil
+	}
+	i := t.pos[rank]
+	left, right := t.Order[(i-1+p)%p], t.Order[(i+1)%p]
+	if left == right {
+		return []int{right}
+	}
+	return []int{left, right}
+}
+
+func (t PermutedRing) Schedule(rank int) []Step {
+	chunks := make([]int, len(t.Order))
+	for i := range chunks {
+		chunks[i] = i
+	}
+	steps := ringReduceScatter(t.Order, t.pos[rank], chunks)
+	return append(steps, ringAllGather(t.Order, t.pos[rank], chunks)...)
+}
+
+// NearestRingOrder builds a ring order for p ranks greedily from model:
+// starting at rank 0, it always moves on to the unvisited rank behind the
+// lowest-latency link, ties broken by bandwidth and then by rank. It is a
+// cheap heuristic for placement, not an optimal tour.
+func NearestRingOrder(p int, model LinkModel) []int {
+	if p == 0 {
+		return nil
+	}
+	order := []int{0}
+	visited := make([]bool, p)
+	visited[0] = true
+	for len(order) < p {
+		from, best := order[len(order)-1], -1
+		var bestLink Link
+		for to := 0; to < p; to++ {
+			if visited[to] {
+				continue
+			}
+			l := model.Link(from, to)
+			if best == -1 || l.Latency < bestLink.Latency || l.Latency == bestLink.Latency && faster(l, bestLink) {
+				best, bestLink = to, l
+			}
+		}
+		visited[best] = true
+		order = append(order, best)
+	}
+	return order
+}
+
+// faster reports whether link a has more bandwidth than b; 0 is unlimited.
+func faster(a, b Link) bool {
+	if a.Bandwidth == 0 || b.Bandwidth == 0 {
+		return a.Bandwidth == 0 && b.Bandwidth != 0
+	}
+	return a.Bandwidth > b.Bandwidth
+}
+
+// Tree is a binary tree rooted at rank 0: rank i has children 2i+1 and 2i+2.
+// The whole vector is reduced up to the root and broadcast back down.
+type Tree struct {
+	P int
+}
+
+func NewTree(p int) Tree {
+	return Tree{P: p}
+}
+
+func (t Tree) Name() string { return "tree" }
+
+func (t Tree) Size() int { return t.P }
+
+func (t Tree) parent(rank int) int {
+	if rank == 0 {
+		return NoPeer
+	}
+	return (rank - 1) / 2
+}
+
+func (t Tree) children(rank int) []int {
+	var out []int
+	for _, c := range []int{2*rank + 1, 2*rank + 2} {
+		if c < t.P {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Neighbors returns the parent (if any) followed by the children of rank.
+func (t Tree) Neighbors(rank int) []int {
+	var out []int
+	if p := t.parent(rank); p != NoPeer {
+		out = append(out, p)
+	}
+	return append(out, t.children(rank)...)
+}
+
+func (t Tree) Schedule(rank int) []Step {
+	all := make([]int, t.P)
+	for i := range all {
+		all[i] = i
+	}
+
+	var steps []Step
+	// Reduce: gather every child's subtree sum, then pass ours up.
+	for _, c := range t.children(rank) {
+		steps = append(steps, Step{Phase: PhaseReduceScatter, SendTo: NoPeer, RecvFrom: c, RecvChunks: all, Reduce: true})
+	}
+	parent := t.parent(rank)
+	if parent != NoPeer {
+		steps = append(steps, Step{Phase: PhaseReduceScatter, SendTo: parent, SendChunks: all, RecvFrom: NoPeer})
+		// Broadcast: take the final result from the parent.
+		steps = append(steps, Step{Phase: PhaseAllGather, SendTo: NoPeer, RecvFrom: parent, RecvChunks: all})
+	}
+	for _, c := range t.children(rank) {
+		steps = append(steps, Step{Phase: PhaseAllGather, SendTo: c, SendChunks: all, RecvFrom: NoPeer})
+	}
+	return steps
+}
+
+// Torus is a two dimensional Rows x Cols wrap-around grid. Rank r sits at
+// row r / Cols and column r % Cols. The all–reduce runs a reduce–scatter
+// along each row, an all–reduce along each column over the row-owned chunks
+// and finally an allgather along each row.
+type Torus struct {
+	Rows int
+	Cols int
+}
+
+func NewTorus(rows, cols int) Torus {
+	return Torus{Rows: rows, Cols: cols}
+}
+
+func (t Torus) Name() string { return "torus" }
+
+func (t Torus) Size() int { return t.Rows * t.Cols }
+
+func (t Torus) row(rank int) []int {
+	r := rank / t.Cols
+	out := make([]int, t.Cols)
+	for c := range out {
+		out[c] = r*t.Cols + c
+	}
+	return out
+}
+
+func (t Torus) col(rank int) []int {
+	c := rank % t.Cols
+	out := make([]int, t.Rows)
+	for r := range out {
+		out[r] = r*t.Cols + c
+	}
+	return out
+}
+
+// Neighbors returns the distinct left, right, up and down neighbors of rank.
+func (t Torus) Neighbors(rank int) []int {
+	r, c := rank/t.Cols, rank%t.Cols
+	candidates := []int{
+		r*t.Cols + (c-1+t.Cols)%t.Cols,
+		r*t.Cols + (c+1)%t.Cols,
+		((r-1+t.Rows)%t.Rows)*t.Cols + c,
+		((r+1)%t.Rows)*t.Cols + c,
+	}
+	return distinctExcept(candidates, rank)
+}
+
+func (t Torus) Schedule(rank int) []Step {
+	p := t.Size()
+	row := t.row(rank)
+	col := t.col(rank)
+	rowPos := rank % t.Cols
+	colPos := rank / t.Cols
+
+	// Row groups: chunk j belongs to group j mod Cols.
+	groups := make([]int, p)
+	for j := range groups {
+		groups[j] = j
+	}
+	steps := ringReduceScatterGroups(row, rowPos, groups, t.Cols)
+
+	// After the row reduce–scatter the rank owns group (rowPos+1) mod Cols,
+	// which is the same for every rank in its column.
+	owned := (rowPos + 1) % t.Cols
+	var ownedChunks []int
+	for j := owned; j < p; j += t.Cols {
+		ownedChunks = append(ownedChunks, j)
+	}
+	steps = append(steps, ringReduceScatterGroups(col, colPos, ownedChunks, t.Rows)...)
+	steps = append(steps, ringAllGatherGroups(col, colPos, ownedChunks, t.Rows)...)
+
+	return append(steps, ringAllGatherGroups(row, rowPos, groups, t.Cols)...)
+}
+
+// FullyConnected lets every rank talk to every other rank directly. Chunk j
+// is reduced on rank j and then sent to everybody else.
+type FullyConnected struct {
+	P int
+}
+
+func NewFullyConnected(p int) FullyConnected {
+	return FullyConnected{P: p}
+}
+
+func (t FullyConnected) Name() string { return "fully-connected" }
+
+func (t FullyConnected) Size() int { return t.P }
+
+func (t FullyConnected) Neighbors(rank int) []int {
+	out := make([]int, 0, t.P-1)
+	for i := 0; i < t.P; i++ {
+		if i != rank {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+func (t FullyConnected) Schedule(rank int) []Step {
+	var steps []Step
+	for s := 1; s < t.P; s++ {
+		to := (rank + s) % t.P
+		from := (rank - s + t.P) % t.P
+		steps = append(steps, Step{
+			Phase:  PhaseReduceScatter,
+			SendTo: to, SendChunks: []int{to},
+			RecvFrom: from, RecvChunks: []int{rank},
+			Reduce: true,
+		})
+	}
+	for s := 1; s < t.P; s++ {
+		to := (rank + s) % t.P
+		from := (rank - s + t.P) % t.P
+		steps = append(steps, Step{
+			Phase:  PhaseAllGather,
+			SendTo: to, SendChunks: []int{rank},
+			RecvFrom: from, RecvChunks: []int{from},
+		})
+	}
+	return steps
+}
+
+// RecursiveDoubling exchanges the whole vector with rank r XOR 2^k in round
+// k, so that after log2(P) rounds every rank holds the sum. When P is not a
+// power of two, the ranks beyond the largest power of two P' first fold
+// their vector into rank r-P' and get the result back at the end.
+type RecursiveDoubling struct {
+	P int
+}
+
+func NewRecursiveDoubling(p int) RecursiveDoubling {
+	return RecursiveDoubling{P: p}
+}
+
+func (t RecursiveDoubling) Name() string { return "recursive-doubling" }
+
+func (t RecursiveDoubling) Size() int { return t.P }
+
+// pow2 returns the largest power of two not above P.
+func (t RecursiveDoubling) pow2() int {
+	n := 1
+	for n*2 <= t.P {
+		n *= 2
+	}
+	return n
+}
+
+// Neighbors returns the exchange partners of rank in round order, preceded
+// by the rank folded into it, if any.
+func (t RecursiveDoubling) Neighbors(rank int) []int {
+	n := t.pow2()
+	if rank >= n {
+		return []int{rank - n}
+	}
+	var out []int
+	if rank+n < t.P {
+		out = append(out, rank+n)
+	}
+	for mask := 1; mask < n; mask *= 2 {
+		out = append(out, rank^mask)
+	}
+	return out
+}
+
+func (t RecursiveDoubling) Schedule(rank int) []Step {
+	all := make([]int, t.P)
+	for i := range all {
+		all[i] = i
+	}
+	n := t.pow2()
+	if rank >= n {
+		return []Step{
+			{Phase: PhaseReduceScatter, SendTo: rank - n, SendChunks: all, RecvFrom: NoPeer},
+			{Phase: PhaseAllGather, SendTo: NoPeer, RecvFrom: rank - n, RecvChunks: all},
+		}
+	}
+
+	var steps []Step
+	extra := rank+n < t.P
+	if extra {
+		steps = append(steps, Step{Phase: PhaseReduceScatter, SendTo: NoPeer, RecvFrom: rank + n, RecvChunks: all, Reduce: true})
+	}
+	for mask := 1; mask < n; mask *= 2 {
+		partner := rank ^ mask
+		steps = append(steps, Step{
+			Phase:  PhaseReduceScatter,
+			SendTo: partner, SendChunks: all,
+			RecvFrom: partner, RecvChunks: all,
+			Reduce: true,
+		})
+	}
+	if extra {
+		steps = append(steps, Step{Phase: PhaseAllGather, SendTo: rank + n, SendChunks: all, RecvFrom: NoPeer})
+	}
+	return steps
+}
+
+// ringReduceScatter builds the reduce–scatter steps for the rank at position
+// pos of members, where chunks[i] is handled as its own group.
+func ringReduceScatter(members []int, pos int, chunks []int) []Step {
+	return ringReduceScatterGroups(members, pos, chunks, len(members))
+}
+
+// ringAllGather is the allgather counterpart of ringReduceScatter.
+func ringAllGather(members []int, pos int, chunks []int) []Step {
+	return ringAllGatherGroups(members, pos, chunks, len(members))
+}
+
+// ringReduceScatterGroups splits chunks into n groups (chunks[i] belongs to
+// group i mod n) and circulates them around the ring formed by members.
+// At the end the member at position pos holds group (pos+1) mod n fully
+// reduced across the ring.
+func ringReduceScatterGroups(members []int, pos int, chunks []int, n int) []Step {
+	if n < 2 {
+		return nil
+	}
+	to := members[(pos+1)%n]
+	from := members[(pos-1+n)%n]
+	steps := make([]Step, 0, n-1)
+	for s := 0; s < n-1; s++ {
+		sendIdx := (pos - s + n) % n
+		recvIdx := (pos - s - 1 + n) % n
+		steps = append(steps, Step{
+			Phase:  PhaseReduceScatter,
+			SendTo: to, SendChunks: chunkGroup(chunks, sendIdx, n),
+			RecvFrom: from, RecvChunks: chunkGroup(chunks, recvIdx, n),
+			Reduce: true,
+		})
+	}
+	return steps
+}
+
+// ringAllGatherGroups circulates the groups reduced by
+// ringReduceScatterGroups until every member holds all of them.
+func ringAllGatherGroups(members []int, pos int, chunks []int, n int) []Step {
+	if n < 2 {
+		return nil
+	}
+	to := members[(pos+1)%n]
+	from := members[(pos-1+n)%n]
+	steps := make([]Step, 0, n-1)
+	for s := 0; s < n-1; s++ {
+		sendIdx := (pos + 1 - s + n) % n
+		recvIdx := (pos - s + n) % n
+		steps = append(steps, Step{
+			Phase:  PhaseAllGather,
+			SendTo: to, SendChunks: chunkGroup(chunks, sendIdx, n),
+			RecvFrom: from, RecvChunks: chunkGroup(chunks, recvIdx, n),
+		})
+	}
+	return steps
+}
+
+// chunkGroup returns the entries of chunks whose position is g mod n.
+func chunkGroup(chunks []int, g, n int) []int {
+	var out []int
+	for i := g; i < len(chunks); i += n {
+		out = append(out, chunks[i])
+	}
+	return out
+}
+
+func distinctExcept(ranks []int, except int) []int {
+	seen := map[int]bool{except: true}
+	var out []int
+	for _, r := range ranks {
+		if !seen[r] {
+			seen[r] = true
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// ParameterServer is the centralized baseline: rank 0 acts as the server and
+// every other rank as a worker. Workers push their whole vector to the
+// server, which reduces them one after another and sends the sum back to
+// every worker. The server's link carries (P-1) times the vector in each
+// direction, which is what ring all–reduce avoids.
+type ParameterServer struct {
+	P int
+}
+
+func NewParameterServer(p int) ParameterServer {
+	return ParameterServer{P: p}
+}
+
+func (t ParameterServer) Name() string { return "parameter-server" }
+
+func (t ParameterServer) Size() int { return t.P }
+
+func (t ParameterServer) Neighbors(rank int) []int {
+	if rank != 0 {
+		return []int{0}
+	}
+	out := make([]int, 0, t.P-1)
+	for w := 1; w < t.P; w++ {
+		out = append(out, w)
+	}
+	return out
+}
+
+func (t ParameterServer) Schedule(rank int) []Step {
+	all := make([]int, t.P)
+	for i := range all {
+		all[i] = i
+	}
+	if rank != 0 {
+		return []Step{
+			{Phase: PhaseReduceScatter, SendTo: 0, SendChunks: all, RecvFrom: NoPeer},
+			{Phase: PhaseAllGather, SendTo: NoPeer, RecvFrom: 0, RecvChunks: all},
+		}
+	}
+	var steps []Step
+	for w := 1; w < t.P; w++ {
+		steps = append(steps, Step{Phase: PhaseReduceScatter, SendTo: NoPeer, RecvFrom: w, RecvChunks: all, Reduce: true})
+	}
+	for w := 1; w < t.P; w++ {
+		steps = append(steps, Step{Phase: PhaseAllGather, SendTo: w, SendChunks: all, RecvFrom: NoPeer})
+	}
+	return steps
+}
@@ -0,0 +1,178 @@
+package ringallreduce
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExecuteTopology_UniformData(t *testing.T) {
+	tests := []struct {
+		name      string
+		topology  Topology
+		chunkSize int
+	}{
+		{name: "ring p=5", topology: NewRing(5), chunkSize: 2},
+		{name: "tree p=1", topology: NewTree(1), chunkSize: 3},
+		{name: "tree p=6", topology: NewTree(6), chunkSize: 2},
+		{name: "torus 2x3", topology: NewTorus(2, 3), chunkSize: 1},
+		{name: "torus 3x3", topology: NewTorus(3, 3), chunkSize: 2},
+		{name: "torus 4x2", topology: NewTorus(4, 2), chunkSize: 1},
+		{name: "fully-connected p=4", topology: NewFullyConnected(4), chunkSize: 3},
+		{name: "parameter-server p=1", topology: NewParameterServer(1), chunkSize: 2},
+		{name: "parameter-server p=5", topology: NewParameterServer(5), chunkSize: 2},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			r := New()
+			result := r.ExecuteTopology(tc.topology, tc.chunkSize)
+
+			p := tc.topology.Size()
+			expected := float64((p * (p + 1)) / 2)
+			if len(result) != p {
+				t.Fatalf("expected %d nodes, got %d", p, len(result))
+			}
+			for procIdx, proc := range result {
+				if len(proc.Data) != p*tc.chunkSize {
+					t.Fatalf("node=%d: expected len=%d, got %d", procIdx, p*tc.chunkSize, len(proc.Data))
+				}
+				for j, v := range proc.Data {
+					if v != expected {
+						t.Errorf("%s: node=%d, elem=%d: expected %f, got %f", tc.topology.Name(), procIdx, j, expected, v)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestTopology_Neighbors(t *testing.T) {
+	tests := []struct {
+		name     string
+		topology Topology
+		rank     int
+		expected []int
+	}{
+		{name: "ring", topology: NewRing(4), rank: 0, expected: []int{3, 1}},
+		{name: "ring p=2", topology: NewRing(2), rank: 0, expected: []int{1}},
+		{name: "tree root", topology: NewTree(5), rank: 0, expected: []int{1, 2}},
+		{name: "tree inner", topology: NewTree(5), rank: 1, expected: []int{0, 3, 4}},
+		{name: "tree leaf", topology: NewTree(5), rank: 4, expected: []int{1}},
+		{name: "torus", topology: NewTorus(3, 3), rank: 4, expected: []int{3, 5, 1, 7}},
+		{name: "fully-connected", topology: NewFullyConnected(4), rank: 2, expected: []int{0, 1, 3}},
+		{name: "parameter-server server", topology: NewParameterServer(4), rank: 0, expected: []int{1, 2, 3}},
+		{name: "parameter-server worker", topology: NewParameterServer(4), rank: 2, expected: []int{0}},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.topology.Neighbors(tc.rank)
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Neighbors(%d): expected %v, got %v", tc.rank, tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestRing_ScheduleMatchesClassicIndices(t *testing.T) {
+	p := 4
+	for rank := 0; rank < p; rank++ {
+		steps := NewRing(p).Schedule(rank)
+		if len(steps) != 2*(p-1) {
+			t.Fatalf("rank=%d: expected %d steps, got %d", rank, 2*(p-1), len(steps))
+		}
+		for s := 0; s < p-1; s++ {
+			rs := steps[s]
+			if rs.SendChunks[0] != (rank-s+p)%p || rs.RecvChunks[0] != (rank-s-1+p)%p || !rs.Reduce {
+				t.Errorf("rank=%d reduce-scatter step %d: unexpected %+v", rank, s, rs)
+			}
+			ag := steps[p-1+s]
+			if ag.SendChunks[0] != (rank+1-s+p)%p || ag.RecvChunks[0] != (rank-s+p)%p || ag.Reduce {
+				t.Errorf("rank=%d allgather step %d: unexpected %+v", rank, s, ag)
+			}
+		}
+	}
+}
+
+func TestParameterServer_ServerLinkIsTheBottleneck(t *testing.T) {
+	link := Link{Latency: 10 * time.Microsecond, Bandwidth: 1e9}
+	const p, n = 8, 1 << 16
+	ring, err := Simulate(NewRing(p), n, UniformLinks(link), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps, err := Simulate(NewParameterServer(p), n, UniformLinks(link), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The server moves (P-1) vectors each way, the ring about two per rank.
+	if ps < 3*ring {
+		t.Errorf("expected the parameter server to be much slower than the ring: %v vs %v", ps, ring)
+	}
+}
+
+func TestPermutedRing(t *testing.T) {
+	order := []int{3, 0, 4, 1, 2}
+	ring, err := NewPermutedRing(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ring.Neighbors(0); !reflect.DeepEqual(got, []int{3, 4}) {
+		t.Errorf("Neighbors(0) = %v, want [3 4]", got)
+	}
+	for rank := 0; rank < ring.Size(); rank++ {
+		for _, step := range ring.Schedule(rank) {
+			i := ring.pos[rank]
+			if step.SendTo != order[(i+1)%5] || step.RecvFrom != order[(i+4)%5] {
+				t.Fatalf("rank %d: step %+v doesn't follow the order %v", rank, step, order)
+			}
+		}
+	}
+	r := New()
+	for rank, proc := range r.ExecuteTopology(ring, 2) {
+		for j, v := range proc.Data {
+			if v != 15 {
+				t.Fatalf("node=%d, elem=%d: expected 15, got %v", rank, j, v)
+			}
+		}
+	}
+
+	for _, bad := range [][]int{{0, 0, 1}, {0, 3, 1}, {-1, 0}} {
+		if _, err := NewPermutedRing(bad); err == nil {
+			t.Errorf("expected an error for order %v", bad)
+		}
+	}
+}
+
+func TestPermutedRing_AvoidsSlowLink(t *testing.T) {
+	// The cable between ranks 0 and 1 is bad; the default ring crosses it
+	// twice per step, a ring ordered by NearestRingOrder not at all.
+	model := LinkFunc(func(from, to int) Link {
+		if from+to == 1 {
+			return Link{Latency: time.Millisecond, Bandwidth: 1e7}
+		}
+		return Link{Latency: time.Microsecond, Bandwidth: 1e10}
+	})
+	order := NearestRingOrder(4, model)
+	if !reflect.DeepEqual(order, []int{0, 2, 1, 3}) {
+		t.Fatalf("NearestRingOrder = %v, want [0 2 1 3]", order)
+	}
+	ring, err := NewPermutedRing(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := Simulate(NewRing(4), 1<<12, model, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	placed, err := Simulate(ring, 1<<12, model, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if placed*100 > identity {
+		t.Errorf("expected placement to avoid the slow link: identity %v, placed %v", identity, placed)
+	}
+}
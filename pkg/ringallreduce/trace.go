@@ -0,0 +1,258 @@
+package ringallreduce
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Trace event kinds.
+const (
+	TraceSend   = "send"
+	TraceRecv   = "recv"
+	TraceReduce = "reduce"
+	TraceCopy   = "copy"
+)
+
+// TraceEvent is one timed action of a rank: sending a chunk, waiting for
+// one, or applying a received chunk to the local buffer.
+type TraceEvent struct {
+	Rank     int
+	Kind     string
+	Op       uint64
+	Step     int
+	Phase    Phase
+	Peer     int // rank the chunk went to or came from; NoPeer for reduce and copy
+	Chunk    int
+	Bytes    int
+	Start    time.Time
+	Duration time.Duration
+}
+
+// Trace collects the events of the nodes it is attached to, via Node.Trace
+// or Communicator.Trace, and exports them for timeline viewers. It is safe
+// for concurrent use.
+type Trace struct {
+	mu     sync.Mutex
+	events []TraceEvent
+}
+
+func NewTrace() *Trace {
+	return &Trace{}
+}
+
+func (t *Trace) record(e TraceEvent) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, e)
+}
+
+// Events returns the recorded events ordered by start time.
+func (t *Trace) Events() []TraceEvent {
+	t.mu.Lock()
+	out := append([]TraceEvent(nil), t.events...)
+	t.mu.Unlock()
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+// chromeEvent is an entry of the Chrome trace_event format.
+type chromeEvent struct {
+	Name string         `json:"name"`
+	Cat  string         `json:"cat,omitempty"`
+	Ph   string         `json:"ph"`
+	Ts   float64        `json:"ts"`
+	Dur  float64        `json:"dur,omitempty"`
+	Pid  uint64         `json:"pid"`
+	Tid  int            `json:"tid"`
+	Args map[string]any `json:"args,omitempty"`
+}
+
+// WriteChrome writes the trace in the Chrome trace_event JSON format, which
+// chrome://tracing and Perfetto open directly. Every collective invocation
+// is a process and every rank a thread of it, so the timeline shows one lane
+// per rank.
+func (t *Trace) WriteChrome(w io.Writer) error {
+	events := t.Events()
+	var origin time.Time
+	if len(events) > 0 {
+		origin = events[0].Start
+	}
+
+	var out []chromeEvent
+	named := map[[2]uint64]bool{}
+	for _, e := range events {
+		if key := [2]uint64{e.Op, uint64(e.Rank)}; !named[key] {
+			named[key] = true
+			out = append(out, chromeEvent{
+				Name: "thread_name", Ph: "M", Pid: e.Op, Tid: e.Rank,
+				Args: map[string]any{"name": fmt.Sprintf("rank %d", e.Rank)},
+			})
+		}
+		args := map[string]any{"step": e.Step, "chunk": e.Chunk, "bytes": e.Bytes}
+		if e.Peer != NoPeer {
+			args["peer"] = e.Peer
+		}
+		out = append(out, chromeEvent{
+			Name: fmt.Sprintf("%s chunk %d", e.Kind, e.Chunk),
+			Cat:  e.Phase.String(),
+			Ph:   "X",
+			Ts:   float64(e.Start.Sub(origin).Nanoseconds()) / 1e3,
+			Dur:  float64(e.Duration.Nanoseconds()) / 1e3,
+			Pid:  e.Op,
+			Tid:  e.Rank,
+			Args: args,
+		})
+	}
+	return json.NewEncoder(w).Encode(struct {
+		TraceEvents     []chromeEvent `json:"traceEvents"`
+		DisplayTimeUnit string        `json:"displayTimeUnit"`
+	}{out, "ns"})
+}
+
+// Span is an OpenTelemetry style span. Every collective invocation is a
+// trace with one root span, a child span per rank and step, and the events
+// of that step as its children.
+type Span struct {
+	TraceID    string // 32 hex digits
+	SpanID     string // 16 hex digits
+	ParentID   string // empty for the root span
+	Name       string
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]string
+}
+
+// Spans converts the trace to OpenTelemetry style spans.
+func (t *Trace) Spans() []Span {
+	events := t.Events()
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	newID := func(n int) string {
+		b := make([]byte, n)
+		for i := 0; i < n; i += 8 {
+			var word [8]byte
+			binary.BigEndian.PutUint64(word[:], rng.Uint64())
+			copy(b[i:], word[:])
+		}
+		return hex.EncodeToString(b)
+	}
+
+	type stepKey struct {
+		op   uint64
+		rank int
+		step int
+	}
+	roots := map[uint64]*Span{}
+	steps := map[stepKey]*Span{}
+	var order []*Span
+	var children []Span
+	for _, e := range events {
+		end := e.Start.Add(e.Duration)
+		root := roots[e.Op]
+		if root == nil {
+			root = &Span{TraceID: newID(16), SpanID: newID(8), Name: "allreduce", Start: e.Start, End: end,
+				Attributes: map[string]string{"op": strconv.FormatUint(e.Op, 10)}}
+			roots[e.Op] = root
+			order = append(order, root)
+		}
+		k := stepKey{e.Op, e.Rank, e.Step}
+		step := steps[k]
+		if step == nil {
+			step = &Span{TraceID: root.TraceID, SpanID: newID(8), ParentID: root.SpanID,
+				Name: fmt.Sprintf("step %d", e.Step), Start: e.Start, End: end,
+				Attributes: map[string]string{"rank": strconv.Itoa(e.Rank), "phase": e.Phase.String()}}
+			steps[k] = step
+			order = append(order, step)
+		}
+		for _, s := range []*Span{root, step} {
+			if e.Start.Before(s.Start) {
+				s.Start = e.Start
+			}
+			if end.After(s.End) {
+				s.End = end
+			}
+		}
+		attrs := map[string]string{"rank": strconv.Itoa(e.Rank), "chunk": strconv.Itoa(e.Chunk), "bytes": strconv.Itoa(e.Bytes)}
+		if e.Peer != NoPeer {
+			attrs["peer"] = strconv.Itoa(e.Peer)
+		}
+		children = append(children, Span{TraceID: root.TraceID, SpanID: newID(8), ParentID: step.SpanID,
+			Name: e.Kind, Start: e.Start, End: end, Attributes: attrs})
+	}
+
+	out := make([]Span, 0, len(order)+len(children))
+	for _, s := range order {
+		out = append(out, *s)
+	}
+	return append(out, children...)
+}
+
+// WriteOTLP writes the spans in the OTLP/JSON encoding accepted by the
+// /v1/traces endpoint of OpenTelemetry collectors.
+func (t *Trace) WriteOTLP(w io.Writer) error {
+	type value struct {
+		StringValue string `json:"stringValue"`
+	}
+	type attribute struct {
+		Key   string `json:"key"`
+		Value value  `json:"value"`
+	}
+	type span struct {
+		TraceID           string      `json:"traceId"`
+		SpanID            string      `json:"spanId"`
+		ParentSpanID      string      `json:"parentSpanId,omitempty"`
+		Name              string      `json:"name"`
+		Kind              int         `json:"kind"`
+		StartTimeUnixNano string      `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string      `json:"endTimeUnixNano"`
+		Attributes        []attribute `json:"attributes,omitempty"`
+	}
+	attributes := func(m map[string]string) []attribute {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := make([]attribute, len(keys))
+		for i, k := range keys {
+			out[i] = attribute{Key: k, Value: value{StringValue: m[k]}}
+		}
+		return out
+	}
+
+	var spans []span
+	for _, s := range t.Spans() {
+		spans = append(spans, span{
+			TraceID:           s.TraceID,
+			SpanID:            s.SpanID,
+			ParentSpanID:      s.ParentID,
+			Name:              s.Name,
+			Kind:              1, // SPAN_KIND_INTERNAL
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Attributes:        attributes(s.Attributes),
+		})
+	}
+	doc := map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": attributes(map[string]string{"service.name": "ringallreduce"}),
+			},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]any{"name": "github.com/sanderblue/algorithms/pkg/ringallreduce"},
+				"spans": spans,
+			}},
+		}},
+	}
+	return json.NewEncoder(w).Encode(doc)
+}
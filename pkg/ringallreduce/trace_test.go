@@ -0,0 +1,130 @@
+package ringallreduce
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func tracedRun(t *testing.T, p, n int) *Trace {
+	t.Helper()
+	c := NewCommunicator(p)
+	c.Trace = NewTrace()
+	if err := c.AllReduce(c.NewOpID(), vectors(p, n)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return c.Trace
+}
+
+func TestTrace_Events(t *testing.T) {
+	const p = 4
+	trace := tracedRun(t, p, 8)
+
+	counts := map[int]map[string]int{}
+	for _, e := range trace.Events() {
+		if counts[e.Rank] == nil {
+			counts[e.Rank] = map[string]int{}
+		}
+		counts[e.Rank][e.Kind]++
+		if e.Bytes != 16 {
+			t.Errorf("expected 16 bytes per chunk, got %d", e.Bytes)
+		}
+		if (e.Kind == TraceReduce && e.Phase != PhaseReduceScatter) || (e.Kind == TraceCopy && e.Phase != PhaseAllGather) {
+			t.Errorf("unexpected %s in %s", e.Kind, e.Phase)
+		}
+	}
+	expected := map[string]int{TraceSend: 2 * (p - 1), TraceRecv: 2 * (p - 1), TraceReduce: p - 1, TraceCopy: p - 1}
+	for rank := 0; rank < p; rank++ {
+		for kind, want := range expected {
+			if counts[rank][kind] != want {
+				t.Errorf("rank=%d: expected %d %s events, got %d", rank, want, kind, counts[rank][kind])
+			}
+		}
+	}
+}
+
+func TestTrace_WriteChrome(t *testing.T) {
+	const p = 3
+	trace := tracedRun(t, p, 3)
+
+	var buf bytes.Buffer
+	if err := trace.WriteChrome(&buf); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	var doc struct {
+		TraceEvents []struct {
+			Name string  `json:"name"`
+			Ph   string  `json:"ph"`
+			Ts   float64 `json:"ts"`
+			Tid  int     `json:"tid"`
+		} `json:"traceEvents"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	threads, complete := 0, 0
+	for _, e := range doc.TraceEvents {
+		switch e.Ph {
+		case "M":
+			threads++
+		case "X":
+			complete++
+			if e.Ts < 0 || e.Tid < 0 || e.Tid >= p {
+				t.Errorf("unexpected event %+v", e)
+			}
+		}
+	}
+	if threads != p || complete != len(trace.Events()) {
+		t.Errorf("expected %d threads and %d events, got %d and %d", p, len(trace.Events()), threads, complete)
+	}
+}
+
+func TestTrace_WriteOTLP(t *testing.T) {
+	const p = 3
+	trace := tracedRun(t, p, 3)
+
+	var buf bytes.Buffer
+	if err := trace.WriteOTLP(&buf); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	var doc struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					TraceID      string `json:"traceId"`
+					SpanID       string `json:"spanId"`
+					ParentSpanID string `json:"parentSpanId"`
+					Name         string `json:"name"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	spans := doc.ResourceSpans[0].ScopeSpans[0].Spans
+	steps := p * 2 * (p - 1)
+	if len(spans) != 1+steps+len(trace.Events()) {
+		t.Fatalf("expected %d spans, got %d", 1+steps+len(trace.Events()), len(spans))
+	}
+
+	ids := map[string]bool{}
+	roots := 0
+	for _, s := range spans {
+		if len(s.TraceID) != 32 || len(s.SpanID) != 16 {
+			t.Errorf("malformed ids %q %q", s.TraceID, s.SpanID)
+		}
+		ids[s.SpanID] = true
+		if s.ParentSpanID == "" {
+			roots++
+		}
+	}
+	if roots != 1 {
+		t.Errorf("expected 1 root span, got %d", roots)
+	}
+	for _, s := range spans {
+		if s.ParentSpanID != "" && !ids[s.ParentSpanID] {
+			t.Errorf("span %s has unknown parent %s", s.Name, s.ParentSpanID)
+		}
+	}
+}
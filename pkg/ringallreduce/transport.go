@@ -0,0 +1,149 @@
+package ringallreduce
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrTransportClosed is returned by operations on a closed transport.
+var ErrTransportClosed = errors.New("transport closed")
+
+// Transport moves messages between ranks. Send delivers msg to the inbox of
+// rank and Recv returns the next message in the inbox of rank. Messages sent
+// from one rank to another are received in the order they were sent.
+type Transport interface {
+	Send(rank int, msg Msg) error
+	Recv(rank int) (Msg, error)
+}
+
+// ErrStepTimeout is returned when a receive waits longer than allowed.
+var ErrStepTimeout = errors.New("step timed out")
+
+// TimeoutReceiver is implemented by transports that can bound how long a
+// receive waits. Nodes with a StepTimeout use it to detect silent peers.
+type TimeoutReceiver interface {
+	RecvTimeout(rank int, timeout time.Duration) (Msg, error)
+}
+
+// consumer is implemented by transports that need to know when a node uses
+// a message rather than when it was taken off the wire; the two differ for
+// messages that arrive ahead of the step that needs them.
+type consumer interface {
+	consumed(rank int, msg Msg)
+}
+
+// ChanTransport is the default in-process transport: every rank owns a
+// buffered channel that acts as its inbox.
+type ChanTransport struct {
+	Inboxes []chan Msg
+
+	closed chan struct{}
+	once   sync.Once
+}
+
+// NewChanTransport creates an inbox for every rank of t, large enough to hold
+// every message the schedule will ever deliver to it so that no send blocks.
+func NewChanTransport(t Topology) *ChanTransport {
+	inboxes := make([]chan Msg, t.Size())
+	for i := range inboxes {
+		inboxes[i] = make(chan Msg, inboxSize(t, i))
+	}
+	return &ChanTransport{Inboxes: inboxes, closed: make(chan struct{})}
+}
+
+// NewChanTransportSize creates p inboxes holding capacity messages each. It
+// suits transports layered on top that send more messages than the schedule
+// alone, such as acknowledgements and retransmissions.
+func NewChanTransportSize(p, capacity int) *ChanTransport {
+	inboxes := make([]chan Msg, p)
+	for i := range inboxes {
+		inboxes[i] = make(chan Msg, capacity)
+	}
+	return &ChanTransport{Inboxes: inboxes, closed: make(chan struct{})}
+}
+
+func (c *ChanTransport) Send(rank int, msg Msg) error {
+	if rank < 0 || rank >= len(c.Inboxes) {
+		return fmt.Errorf("send to unknown rank %d", rank)
+	}
+	select {
+	case c.Inboxes[rank] <- msg:
+		return nil
+	case <-c.closed:
+		return ErrTransportClosed
+	}
+}
+
+func (c *ChanTransport) Recv(rank int) (Msg, error) {
+	if rank < 0 || rank >= len(c.Inboxes) {
+		return Msg{}, fmt.Errorf("receive on unknown rank %d", rank)
+	}
+	select {
+	case m := <-c.Inboxes[rank]:
+		return m, nil
+	case <-c.closed:
+		return Msg{}, ErrTransportClosed
+	}
+}
+
+func (c *ChanTransport) RecvTimeout(rank int, timeout time.Duration) (Msg, error) {
+	if rank < 0 || rank >= len(c.Inboxes) {
+		return Msg{}, fmt.Errorf("receive on unknown rank %d", rank)
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case m := <-c.Inboxes[rank]:
+		return m, nil
+	case <-c.closed:
+		return Msg{}, ErrTransportClosed
+	case <-timer.C:
+		return Msg{}, ErrStepTimeout
+	}
+}
+
+// Close unblocks every pending and future Send and Recv with ErrTransportClosed.
+func (c *ChanTransport) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+// pairTransport adapts the legacy In/Out channel pair of a Node: everything
+// is sent to Out and received from In, which is exactly the ring wiring.
+type pairTransport struct {
+	in  chan Msg
+	out chan Msg
+}
+
+func (c pairTransport) Send(_ int, msg Msg) error {
+	c.out <- msg
+	return nil
+}
+
+func (c pairTransport) Recv(_ int) (Msg, error) {
+	return <-c.in, nil
+}
+
+// inboxSize counts the messages rank receives over its whole schedule.
+func inboxSize(t Topology, rank int) int {
+	n := 0
+	for _, step := range t.Schedule(rank) {
+		if step.RecvFrom != NoPeer {
+			n += len(step.RecvChunks)
+		}
+	}
+	if n < 2 {
+		n = 2
+	}
+	return n
+}
+
+// closeTransport closes t when it supports it.
+func closeTransport(t Transport) {
+	if c, ok := t.(io.Closer); ok {
+		c.Close()
+	}
+}
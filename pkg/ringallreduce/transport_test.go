@@ -0,0 +1,110 @@
+package ringallreduce
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// countingTransport wraps another transport and counts delivered messages.
+type countingTransport struct {
+	Transport
+	sent atomic.Int64
+}
+
+func (c *countingTransport) Send(rank int, msg Msg) error {
+	c.sent.Add(1)
+	return c.Transport.Send(rank, msg)
+}
+
+type failingTransport struct{}
+
+var errLinkDown = errors.New("link down")
+
+func (failingTransport) Send(int, Msg) error { return errLinkDown }
+
+func (failingTransport) Recv(int) (Msg, error) { return Msg{}, errLinkDown }
+
+func runNodes(topology Topology, transport Transport, chunkSize int) []*Node {
+	return runNodesWith(topology, transport, chunkSize, nil)
+}
+
+// runNodesWith is runNodes with a hook that can adjust every node before it
+// starts.
+func runNodesWith(topology Topology, transport Transport, chunkSize int, configure func(*Node)) []*Node {
+	p := topology.Size()
+	nodes := make([]*Node, p)
+	for i := range nodes {
+		data := make([]float64, p*chunkSize)
+		for j := range data {
+			data[j] = float64(i + 1)
+		}
+		nodes[i] = &Node{Rank: i, P: p, ChunkSize: chunkSize, Data: data, Topology: topology, Transport: transport}
+		if configure != nil {
+			configure(nodes[i])
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(p)
+	for _, n := range nodes {
+		go n.Run(&wg)
+	}
+	wg.Wait()
+	return nodes
+}
+
+func TestNode_CustomTransport(t *testing.T) {
+	tests := []struct {
+		name     string
+		topology Topology
+		messages int64
+	}{
+		{name: "ring", topology: NewRing(4), messages: 4 * 2 * 3},
+		{name: "fully-connected", topology: NewFullyConnected(3), messages: 3 * 2 * 2},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			transport := &countingTransport{Transport: NewChanTransport(tc.topology)}
+			nodes := runNodes(tc.topology, transport, 2)
+
+			p := tc.topology.Size()
+			expected := float64(p * (p + 1) / 2)
+			for _, n := range nodes {
+				if n.Err != nil {
+					t.Fatalf("node=%d: unexpected error: %v", n.Rank, n.Err)
+				}
+				for j, v := range n.Data {
+					if v != expected {
+						t.Errorf("node=%d, elem=%d: expected %f, got %f", n.Rank, j, expected, v)
+					}
+				}
+			}
+			if got := transport.sent.Load(); got != tc.messages {
+				t.Errorf("expected %d messages, got %d", tc.messages, got)
+			}
+		})
+	}
+}
+
+func TestNode_TransportError(t *testing.T) {
+	nodes := runNodes(NewRing(3), failingTransport{}, 1)
+	for _, n := range nodes {
+		if !errors.Is(n.Err, errLinkDown) {
+			t.Errorf("node=%d: expected errLinkDown, got %v", n.Rank, n.Err)
+		}
+	}
+}
+
+func TestChanTransport_UnknownRank(t *testing.T) {
+	transport := NewChanTransport(NewRing(2))
+	if err := transport.Send(5, Msg{}); err == nil {
+		t.Error("expected error sending to unknown rank")
+	}
+	if _, err := transport.Recv(-1); err == nil {
+		t.Error("expected error receiving on unknown rank")
+	}
+}
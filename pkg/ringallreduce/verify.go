@@ -0,0 +1,128 @@
+package ringallreduce
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// DefaultTolerance is the relative error Communicator verification accepts
+// when Tolerance is 0. Summing in another order than the serial reference
+// changes the rounding, so results rarely match bit for bit.
+const DefaultTolerance = 1e-9
+
+// ErrVerification is matched by the *VerificationError of a collective
+// whose result differs from the serial reference.
+var ErrVerification = errors.New("result differs from the serial reduction")
+
+// RankError is how far the result of one rank is from the serial reference.
+type RankError struct {
+	Rank   int
+	MaxAbs float64 // largest absolute error
+	MaxRel float64 // largest error relative to the reference element
+	Index  int     // element with the largest relative error, -1 if none
+}
+
+// Verification compares the results of an all–reduce with a serial sum of
+// its inputs.
+type Verification struct {
+	Ranks []RankError
+}
+
+// VerifyAllReduce sums inputs serially, in rank order, and compares every
+// vector of outputs with that sum. inputs and outputs are indexed by rank.
+func VerifyAllReduce(inputs, outputs [][]float64) (Verification, error) {
+	if len(inputs) != len(outputs) {
+		return Verification{}, fmt.Errorf("got %d outputs for %d inputs", len(outputs), len(inputs))
+	}
+	var want []float64
+	for r, in := range inputs {
+		if r == 0 {
+			want = make([]float64, len(in))
+		}
+		if len(in) != len(want) {
+			return Verification{}, fmt.Errorf("rank %d: input length %d differs from %d", r, len(in), len(want))
+		}
+		for i, x := range in {
+			want[i] += x
+		}
+	}
+
+	v := Verification{Ranks: make([]RankError, len(outputs))}
+	for r, out := range outputs {
+		if len(out) != len(want) {
+			return Verification{}, fmt.Errorf("rank %d: output length %d differs from %d", r, len(out), len(want))
+		}
+		e := RankError{Rank: r, Index: -1}
+		for i, got := range out {
+			abs := math.Abs(got - want[i])
+			var rel float64
+			switch {
+			case math.IsNaN(abs):
+				abs, rel = math.Inf(1), math.Inf(1)
+			case want[i] != 0:
+				rel = abs / math.Abs(want[i])
+			case abs != 0:
+				rel = math.Inf(1)
+			}
+			e.MaxAbs = max(e.MaxAbs, abs)
+			if rel > e.MaxRel {
+				e.MaxRel, e.Index = rel, i
+			}
+		}
+		v.Ranks[r] = e
+	}
+	return v, nil
+}
+
+// MaxAbs returns the largest absolute error over all ranks.
+func (v Verification) MaxAbs() float64 {
+	var m float64
+	for _, e := range v.Ranks {
+		m = max(m, e.MaxAbs)
+	}
+	return m
+}
+
+// MaxRel returns the largest relative error over all ranks.
+func (v Verification) MaxRel() float64 {
+	var m float64
+	for _, e := range v.Ranks {
+		m = max(m, e.MaxRel)
+	}
+	return m
+}
+
+// Check returns a *VerificationError listing the ranks whose relative error
+// exceeds tolerance.
+func (v Verification) Check(tolerance float64) error {
+	var bad []RankError
+	for _, e := range v.Ranks {
+		if e.MaxRel > tolerance {
+			bad = append(bad, e)
+		}
+	}
+	if len(bad) == 0 {
+		return nil
+	}
+	return &VerificationError{Tolerance: tolerance, Ranks: bad}
+}
+
+// VerificationError lists the ranks whose result is off by more than
+// Tolerance.
+type VerificationError struct {
+	Tolerance float64
+	Ranks     []RankError
+}
+
+func (e *VerificationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v beyond relative tolerance %g on %d ranks", ErrVerification, e.Tolerance, len(e.Ranks))
+	for _, r := range e.Ranks {
+		fmt.Fprintf(&b, "\n\trank %d: max abs %g, max rel %g at element %d", r.Rank, r.MaxAbs, r.MaxRel, r.Index)
+	}
+	return b.String()
+}
+
+func (e *VerificationError) Unwrap() error { return ErrVerification }
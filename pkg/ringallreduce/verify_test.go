@@ -0,0 +1,97 @@
+package ringallreduce
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestVerifyAllReduce(t *testing.T) {
+	inputs := [][]float64{{1, 2, 0}, {3, 4, 0}}
+	tests := []struct {
+		name    string
+		outputs [][]float64
+		want    []RankError
+	}{
+		{"exact", [][]float64{{4, 6, 0}, {4, 6, 0}},
+			[]RankError{{Rank: 0, Index: -1}, {Rank: 1, Index: -1}}},
+		{"off", [][]float64{{4, 6.6, 0}, {5, 6, 0}},
+			[]RankError{{Rank: 0, MaxAbs: 0.6, MaxRel: 0.1, Index: 1}, {Rank: 1, MaxAbs: 1, MaxRel: 0.25, Index: 0}}},
+		{"zero reference", [][]float64{{4, 6, 0}, {4, 6, 2}},
+			[]RankError{{Rank: 0, Index: -1}, {Rank: 1, MaxAbs: 2, MaxRel: math.Inf(1), Index: 2}}},
+		{"NaN", [][]float64{{4, math.NaN(), 0}, {4, 6, 0}},
+			[]RankError{{Rank: 0, MaxAbs: math.Inf(1), MaxRel: math.Inf(1), Index: 1}, {Rank: 1, Index: -1}}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := VerifyAllReduce(inputs, tc.outputs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range v.Ranks {
+				// Round away the float noise of the expected errors.
+				v.Ranks[i].MaxAbs = math.Round(v.Ranks[i].MaxAbs*1e9) / 1e9
+				v.Ranks[i].MaxRel = math.Round(v.Ranks[i].MaxRel*1e9) / 1e9
+			}
+			if !reflect.DeepEqual(v.Ranks, tc.want) {
+				t.Errorf("got %+v, want %+v", v.Ranks, tc.want)
+			}
+		})
+	}
+
+	if _, err := VerifyAllReduce(inputs, [][]float64{{4, 6, 0}}); err == nil {
+		t.Error("expected an error for a missing output")
+	}
+}
+
+// corruptingTransport perturbs every chunk rank From sends.
+type corruptingTransport struct {
+	*ChanTransport
+	From int
+}
+
+func (c *corruptingTransport) Send(rank int, msg Msg) error {
+	if msg.From == c.From {
+		data := append([]float64(nil), msg.Data...)
+		for i := range data {
+			data[i] += 0.5
+		}
+		msg.Data = data
+	}
+	return c.ChanTransport.Send(rank, msg)
+}
+
+func TestCommunicator_Verify(t *testing.T) {
+	c := NewCommunicator(4)
+	c.Verify = true
+	data := vectors(4, 8)
+	if err := c.AllReduce(c.NewOpID(), data); err != nil {
+		t.Fatalf("AllReduce: %v", err)
+	}
+	v, ok := c.LastVerification()
+	if !ok || len(v.Ranks) != 4 || v.MaxAbs() != 0 {
+		t.Fatalf("unexpected verification %+v", v)
+	}
+
+	c.NewTransport = func(t Topology) Transport { return &corruptingTransport{NewChanTransport(t), 2} }
+	data = vectors(4, 8)
+	id := c.NewOpID()
+	err := c.AllReduce(id, data)
+	var verr *VerificationError
+	if !errors.Is(err, ErrVerification) || !errors.As(err, &verr) {
+		t.Fatalf("expected a *VerificationError, got %v", err)
+	}
+	if c.Completed(id) || data[0][0] != 1 {
+		t.Error("a failed verification must leave the operation unapplied")
+	}
+	if v, _ := c.LastVerification(); v.MaxRel() == 0 {
+		t.Error("expected the failed verification to be recorded")
+	}
+
+	c.Tolerance = 1
+	if err := c.AllReduce(id, data); err != nil {
+		t.Fatalf("AllReduce within a loose tolerance: %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+package ringallreduce
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDeadlock is matched by the *DeadlockError a Watchdog reports.
+var ErrDeadlock = errors.New("collective deadlocked")
+
+// RankState is what a rank was doing when a Watchdog last heard from it.
+type RankState struct {
+	Rank    int
+	Step    int
+	Phase   Phase
+	Blocked bool      // waiting in the transport
+	Sending bool      // blocked in a send rather than a receive
+	Peer    int       // rank sent to or waited for
+	Chunk   int       // chunk sent or waited for
+	Since   time.Time // when the rank blocked
+	Done    bool      // finished its schedule
+}
+
+func (s RankState) String() string {
+	switch {
+	case s.Done:
+		return fmt.Sprintf("rank %d: done", s.Rank)
+	case !s.Blocked:
+		return fmt.Sprintf("rank %d: running step %d (%s)", s.Rank, s.Step, s.Phase)
+	case s.Sending:
+		return fmt.Sprintf("rank %d: step %d (%s) blocked sending chunk %d to rank %d", s.Rank, s.Step, s.Phase, s.Chunk, s.Peer)
+	default:
+		return fmt.Sprintf("rank %d: step %d (%s) waiting for chunk %d from rank %d", s.Rank, s.Step, s.Phase, s.Chunk, s.Peer)
+	}
+}
+
+// DeadlockError describes a collective in which no rank made progress for
+// longer than the watchdog threshold.
+type DeadlockError struct {
+	Threshold time.Duration
+	Ranks     []RankState
+}
+
+func (e *DeadlockError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v: no rank made progress for %v", ErrDeadlock, e.Threshold)
+	for _, s := range e.Ranks {
+		b.WriteString("\n\t")
+		b.WriteString(s.String())
+	}
+	return b.String()
+}
+
+func (e *DeadlockError) Unwrap() error { return ErrDeadlock }
+
+// Watchdog detects collectives that hang: once every rank that hasn't
+// finished has been blocked in its transport for longer than Threshold, it
+// records a *DeadlockError describing each rank and calls the abort
+// function given to Start, which typically closes the transport.
+// Nodes report to the watchdog set in their Watchdog field.
+type Watchdog struct {
+	Threshold time.Duration
+
+	mu     sync.Mutex
+	states map[int]*RankState
+	err    error
+	stop   chan struct{}
+	once   sync.Once
+}
+
+func NewWatchdog(threshold time.Duration) *Watchdog {
+	return &Watchdog{
+		Threshold: threshold,
+		states:    make(map[int]*RankState),
+		stop:      make(chan struct{}),
+	}
+}
+
+// Start begins watching; abort is called once if a deadlock is detected.
+func (w *Watchdog) Start(abort func()) {
+	interval := max(w.Threshold/4, time.Millisecond)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.stop:
+				return
+			case now := <-ticker.C:
+				if w.check(now) {
+					if abort != nil {
+						abort()
+					}
+					return
+				}
+			}
+		}
+	}()
+}
+
+// Stop ends watching.
+func (w *Watchdog) Stop() {
+	w.once.Do(func() { close(w.stop) })
+}
+
+// Err returns the detected deadlock, if any.
+func (w *Watchdog) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// States returns the last known state of every rank, ordered by rank.
+func (w *Watchdog) States() []RankState {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.snapshot()
+}
+
+func (w *Watchdog) snapshot() []RankState {
+	out := make([]RankState, 0, len(w.states))
+	for _, s := range w.states {
+		out = append(out, *s)
+	}
+	for i := 1; i < len(out); i++ {
+		for j := i; j > 0 && out[j].Rank < out[j-1].Rank; j-- {
+			out[j], out[j-1] = out[j-1], out[j]
+		}
+	}
+	return out
+}
+
+// check records a deadlock and reports true when every unfinished rank has
+// been blocked for longer than the threshold.
+func (w *Watchdog) check(now time.Time) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil || len(w.states) == 0 {
+		return w.err != nil
+	}
+	waiting := false
+	for _, s := range w.states {
+		if s.Done {
+			continue
+		}
+		if !s.Blocked || now.Sub(s.Since) <= w.Threshold {
+			return false
+		}
+		waiting = true
+	}
+	if !waiting {
+		return false
+	}
+	w.err = &DeadlockError{Threshold: w.Threshold, Ranks: w.snapshot()}
+	return true
+}
+
+func (w *Watchdog) state(rank int) *RankState {
+	s := w.states[rank]
+	if s == nil {
+		s = &RankState{Rank: rank}
+		w.states[rank] = s
+	}
+	return s
+}
+
+// running records that rank is executing step. Registering every rank this
+// way before the collective starts keeps ranks that haven't been scheduled
+// yet from counting as finished.
+func (w *Watchdog) running(rank, step int, phase Phase) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	s := w.state(rank)
+	s.Step, s.Phase, s.Blocked, s.Done = step, phase, false, false
+}
+
+func (w *Watchdog) blocked(rank, step int, phase Phase, sending bool, peer, chunk int) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	*w.state(rank) = RankState{
+		Rank: rank, Step: step, Phase: phase,
+		Blocked: true, Sending: sending, Peer: peer, Chunk: chunk,
+		Since: time.Now(),
+	}
+}
+
+func (w *Watchdog) done(rank int) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	s := w.state(rank)
+	s.Done, s.Blocked = true, false
+}
@@ -0,0 +1,94 @@
+package ringallreduce
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// slowTransport delays every send, so ranks block for a while but keep
+// making progress.
+type slowTransport struct {
+	*ChanTransport
+	delay time.Duration
+}
+
+func (s *slowTransport) Send(rank int, msg Msg) error {
+	time.Sleep(s.delay)
+	return s.ChanTransport.Send(rank, msg)
+}
+
+func TestWatchdog_DetectsDeadlock(t *testing.T) {
+	c := NewCommunicator(4)
+	c.Topology = func(size int) Topology { return skewedRing{NewRing(size)} }
+	c.DeadlockTimeout = 50 * time.Millisecond
+
+	done := make(chan error, 1)
+	go func() { done <- c.AllReduce(c.NewOpID(), vectors(4, 8)) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("collective still hangs with the watchdog enabled")
+	}
+	if !errors.Is(err, ErrDeadlock) {
+		t.Fatalf("expected ErrDeadlock, got %v", err)
+	}
+	var deadlock *DeadlockError
+	if !errors.As(err, &deadlock) {
+		t.Fatalf("expected a *DeadlockError, got %T", err)
+	}
+	if len(deadlock.Ranks) != 4 {
+		t.Fatalf("expected the state of 4 ranks, got %d", len(deadlock.Ranks))
+	}
+	for r, s := range deadlock.Ranks {
+		if s.Rank != r || !s.Blocked || s.Sending || s.Step != 1 || s.Phase != PhaseReduceScatter {
+			t.Errorf("unexpected state %+v", s)
+		}
+		if want := "waiting for chunk"; !strings.Contains(s.String(), want) {
+			t.Errorf("state %q should contain %q", s, want)
+		}
+	}
+}
+
+func TestWatchdog_SlowProgressDoesNotTrip(t *testing.T) {
+	c := NewCommunicator(4)
+	c.NewTransport = func(t Topology) Transport {
+		return &slowTransport{NewChanTransport(t), 5 * time.Millisecond}
+	}
+	c.DeadlockTimeout = 100 * time.Millisecond
+
+	data := vectors(4, 8)
+	if err := c.AllReduce(c.NewOpID(), data); err != nil {
+		t.Fatalf("AllReduce: %v", err)
+	}
+	for _, v := range data {
+		for _, x := range v {
+			if x != 10 {
+				t.Fatalf("expected 10, got %v", x)
+			}
+		}
+	}
+}
+
+func TestWatchdog_Check(t *testing.T) {
+	w := NewWatchdog(time.Second)
+	now := time.Now()
+	w.running(0, 0, PhaseReduceScatter)
+	w.blocked(1, 2, PhaseAllGather, true, 0, 3)
+	if w.check(now.Add(time.Hour)) {
+		t.Fatal("a running rank must keep the watchdog quiet")
+	}
+	w.done(0)
+	if w.check(now) {
+		t.Fatal("a rank blocked for less than the threshold must keep the watchdog quiet")
+	}
+	if !w.check(now.Add(time.Hour)) {
+		t.Fatal("expected a deadlock once all unfinished ranks are blocked")
+	}
+	if got := w.States()[1].String(); got != "rank 1: step 2 (allgather) blocked sending chunk 3 to rank 0" {
+		t.Errorf("unexpected state %q", got)
+	}
+}
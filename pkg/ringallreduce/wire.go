@@ -0,0 +1,161 @@
+package ringallreduce
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The wire format of a Msg is the frame TCPTransport writes, all integers
+// big-endian:
+//
+//	offset  size  field
+//	0       4     length of the rest of the frame
+//	4       4     From, two's complement
+//	8       4     ChunkIdx, two's complement
+//	12      8     Op
+//	20      8     Seq
+//	28      1     flags: bit 0 is Ack, the others are reserved and zero
+//	29      4     number of elements n
+//	33      8n    elements, IEEE 754 binary64
+//
+// The layout is stable: peers in other languages can rely on it. msg.proto
+// describes the same message for peers that speak protobuf instead.
+
+// Marshal returns the wire frame of m, length prefix included.
+func (m Msg) Marshal() []byte {
+	buf := make([]byte, 4, 4+msgHeaderSize+8*len(m.Data))
+	buf = appendMsg(buf, m)
+	binary.BigEndian.PutUint32(buf, uint32(len(buf)-4))
+	return buf
+}
+
+// Unmarshal decodes a frame produced by Marshal into m. The frame must hold
+// exactly one message.
+func (m *Msg) Unmarshal(frame []byte) error {
+	if len(frame) < 4 {
+		return fmt.Errorf("frame of %d bytes is too short", len(frame))
+	}
+	if size := binary.BigEndian.Uint32(frame); uint64(size) != uint64(len(frame)-4) {
+		return fmt.Errorf("frame announces %d bytes but holds %d", size, len(frame)-4)
+	}
+	msg, err := decodeMsg(frame[4:])
+	if err != nil {
+		return err
+	}
+	*m = msg
+	return nil
+}
+
+// WriteMsg writes the wire frame of msg to w.
+func WriteMsg(w io.Writer, msg Msg) error { return writeFrame(w, msg) }
+
+// ReadMsg reads one wire frame from r.
+func ReadMsg(r io.Reader) (Msg, error) { return readFrame(r) }
+
+// Field numbers of msg.proto.
+const (
+	protoFrom     protowire.Number = 1
+	protoChunkIdx protowire.Number = 2
+	protoData     protowire.Number = 3
+	protoOp       protowire.Number = 4
+	protoSeq      protowire.Number = 5
+	protoAck      protowire.Number = 6
+)
+
+// MarshalProto returns the protobuf encoding of m under msg.proto. Like
+// proto3, it omits fields holding their zero value and packs Data.
+func (m Msg) MarshalProto() []byte {
+	var b []byte
+	if m.From != 0 {
+		b = protowire.AppendTag(b, protoFrom, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(int64(m.From)))
+	}
+	if m.ChunkIdx != 0 {
+		b = protowire.AppendTag(b, protoChunkIdx, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(int64(m.ChunkIdx)))
+	}
+	if len(m.Data) > 0 {
+		b = protowire.AppendTag(b, protoData, protowire.BytesType)
+		b = protowire.AppendVarint(b, uint64(8*len(m.Data)))
+		for _, v := range m.Data {
+			b = protowire.AppendFixed64(b, math.Float64bits(v))
+		}
+	}
+	if m.Op != 0 {
+		b = protowire.AppendTag(b, protoOp, protowire.VarintType)
+		b = protowire.AppendVarint(b, m.Op)
+	}
+	if m.Seq != 0 {
+		b = protowire.AppendTag(b, protoSeq, protowire.VarintType)
+		b = protowire.AppendVarint(b, m.Seq)
+	}
+	if m.Ack {
+		b = protowire.AppendTag(b, protoAck, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+	}
+	return b
+}
+
+// UnmarshalProto decodes the protobuf encoding of a Msg into m. It accepts
+// packed and unpacked Data and skips unknown fields, as protobuf parsers do.
+func (m *Msg) UnmarshalProto(b []byte) error {
+	var msg Msg
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return fmt.Errorf("protobuf tag: %w", protowire.ParseError(n))
+		}
+		b = b[n:]
+		switch {
+		case num == protoData && typ == protowire.BytesType:
+			packed, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return fmt.Errorf("protobuf field data: %w", protowire.ParseError(n))
+			}
+			if len(packed)%8 != 0 {
+				return fmt.Errorf("protobuf field data: %d bytes is not a whole number of doubles", len(packed))
+			}
+			for i := 0; i < len(packed); i += 8 {
+				msg.Data = append(msg.Data, math.Float64frombits(binary.LittleEndian.Uint64(packed[i:])))
+			}
+			b = b[n:]
+		case num == protoData && typ == protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(b)
+			if n < 0 {
+				return fmt.Errorf("protobuf field data: %w", protowire.ParseError(n))
+			}
+			msg.Data = append(msg.Data, math.Float64frombits(v))
+			b = b[n:]
+		case num >= protoFrom && num <= protoAck && num != protoData && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return fmt.Errorf("protobuf field %d: %w", num, protowire.ParseError(n))
+			}
+			switch num {
+			case protoFrom:
+				msg.From = int(int32(v))
+			case protoChunkIdx:
+				msg.ChunkIdx = int(int32(v))
+			case protoOp:
+				msg.Op = v
+			case protoSeq:
+				msg.Seq = v
+			case protoAck:
+				msg.Ack = v != 0
+			}
+			b = b[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return fmt.Errorf("protobuf field %d: %w", num, protowire.ParseError(n))
+			}
+			b = b[n:]
+		}
+	}
+	*m = msg
+	return nil
+}
@@ -0,0 +1,112 @@
+package ringallreduce
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func TestMsg_WireFormatIsStable(t *testing.T) {
+	msg := Msg{From: 1, ChunkIdx: 2, Data: []float64{1.5}, Op: 3, Seq: 4, Ack: true}
+	tests := []struct {
+		name string
+		got  []byte
+		want string
+	}{
+		{"binary", msg.Marshal(), "00000025" + "00000001" + "00000002" + "0000000000000003" + "0000000000000004" + "01" + "00000001" + "3ff8000000000000"},
+		{"protobuf", msg.MarshalProto(), "0801" + "1002" + "1a08" + "000000000000f83f" + "2003" + "2804" + "3001"},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if got := hex.EncodeToString(tc.got); got != tc.want {
+				t.Errorf("encoding changed:\n got %s\nwant %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMsg_RoundTrip(t *testing.T) {
+	msgs := []Msg{
+		{},
+		{From: 3, ChunkIdx: 7, Data: []float64{1, -2.5, 1e300}, Op: 1 << 40, Seq: 9},
+		{From: -1, ChunkIdx: -1, Ack: true, Seq: 12},
+	}
+	for _, want := range msgs {
+		var got Msg
+		if err := got.Unmarshal(want.Marshal()); err != nil {
+			t.Fatalf("Unmarshal: %v", err)
+		}
+		if !sameMsg(got, want) {
+			t.Errorf("binary round trip: got %+v, want %+v", got, want)
+		}
+
+		got = Msg{}
+		if err := got.UnmarshalProto(want.MarshalProto()); err != nil {
+			t.Fatalf("UnmarshalProto: %v", err)
+		}
+		if !sameMsg(got, want) {
+			t.Errorf("protobuf round trip: got %+v, want %+v", got, want)
+		}
+
+		var buf bytes.Buffer
+		if err := WriteMsg(&buf, want); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := ReadMsg(&buf); err != nil || !sameMsg(got, want) {
+			t.Errorf("ReadMsg: got %+v, %v, want %+v", got, err, want)
+		}
+	}
+}
+
+// sameMsg compares messages treating nil and empty Data alike.
+func sameMsg(a, b Msg) bool {
+	if len(a.Data) == 0 && len(b.Data) == 0 {
+		a.Data, b.Data = nil, nil
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func TestMsg_UnmarshalProtoCompat(t *testing.T) {
+	// Unpacked doubles, an unknown string field 15 and an unknown fixed32
+	// field 16, as an older or newer peer may send them.
+	b, _ := hex.DecodeString("0805" + "19000000000000f03f" + "190000000000000040" + "7a026869" + "850100000000" + "2007")
+	var got Msg
+	if err := got.UnmarshalProto(b); err != nil {
+		t.Fatalf("UnmarshalProto: %v", err)
+	}
+	want := Msg{From: 5, Data: []float64{1, 2}, Op: 7}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestMsg_UnmarshalErrors(t *testing.T) {
+	frame := Msg{From: 1, Data: []float64{1, 2}}.Marshal()
+	proto := Msg{From: 1, Data: []float64{1, 2}}.MarshalProto()
+	tests := []struct {
+		name  string
+		proto bool
+		b     []byte
+	}{
+		{"empty frame", false, nil},
+		{"truncated frame", false, frame[:len(frame)-3]},
+		{"trailing bytes", false, append(append([]byte(nil), frame...), 0)},
+		{"truncated protobuf", true, proto[:len(proto)-3]},
+		{"bad packed length", true, []byte{0x1a, 0x03, 1, 2, 3}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var m Msg
+			err := m.Unmarshal(tc.b)
+			if tc.proto {
+				err = m.UnmarshalProto(tc.b)
+			}
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package search
+
+// Exponential returns the first index of the sorted s whose element is not
+// below x, and whether it is x, like Contains. It doubles a bound from the
+// start until it passes x, then bisects below it: O(log i) comparisons for
+// an answer at i, which beats binary search when x is near the front.
+func Exponential[T any](s []T, x T, less func(a, b T) bool) (int, bool) {
+	lo, hi := 0, 1
+	for hi <= len(s) && less(s[hi-1], x) {
+		lo, hi = hi, 2*hi
+	}
+	i, ok := Contains(s[lo:min(hi, len(s))], x, less)
+	return lo + i, ok
+}
+
+// Unbounded is Exponential over a sorted sequence of unknown length, such
+// as a stream or a paged store: at returns element i and whether there is
+// one. The sequence is read at O(log i) indexes, none far past the answer
+// i: at most twice i.
+func Unbounded[T any](at func(i int) (T, bool), x T, less func(a, b T) bool) (int, bool) {
+	// Find lo and hi with the answer in [lo, hi]: the element at lo-1 is
+	// below x and the one at hi-1 isn't, or doesn't exist.
+	lo, hi := 0, 1
+	for {
+		v, ok := at(hi - 1)
+		if !ok || !less(v, x) {
+			break
+		}
+		lo, hi = hi, 2*hi
+	}
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		if v, ok := at(mid); !ok || !less(v, x) {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	v, ok := at(lo)
+	return lo, ok && !less(x, v)
+}
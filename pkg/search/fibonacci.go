@@ -0,0 +1,31 @@
+package search
+
+// Fibonacci returns the first index of the sorted s whose element is not
+// below x, and whether it is x, like Contains. It splits ranges at
+// Fibonacci numbers instead of halving them: the probes only need
+// additions and subtractions, and successive ones lie close together, which
+// once suited tapes and now suits caches a little better. It makes about
+// 1.44·log₂ n comparisons at worst.
+func Fibonacci[T any](s []T, x T, less func(a, b T) bool) (int, bool) {
+	n := len(s)
+	// The answer lies in (offset, offset+fib]. fib1 and fib2 are the two
+	// Fibonacci numbers before fib.
+	fib2, fib1, fib := 0, 1, 1
+	for fib < n+1 {
+		fib2, fib1, fib = fib1, fib, fib1+fib
+	}
+	offset := -1
+	for fib > 1 {
+		i := min(offset+fib2, n-1)
+		if less(s[i], x) {
+			// The answer is in (i, offset+fib]: fib1 long.
+			offset = i
+			fib, fib1, fib2 = fib1, fib2, fib1-fib2
+		} else {
+			// The answer is in (offset, i]: fib2 long.
+			fib, fib1, fib2 = fib2, fib1-fib2, fib2-(fib1-fib2)
+		}
+	}
+	lo := offset + 1
+	return lo, lo < n && !less(x, s[lo])
+}
@@ -0,0 +1,43 @@
+package search
+
+// Number is the set of numeric types Interpolation searches.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// Interpolation returns the first index of the sorted s whose element is
+// not below x, and whether it is x, like Contains. Instead of halving the
+// range, it probes where x would be if the values in the range were evenly
+// spread: O(log log n) probes on uniformly distributed keys. Every other
+// step bisects, which bounds the worst case, on skewed keys, to about
+// 2·log₂ n probes. x must not be NaN.
+func Interpolation[T Number](s []T, x T) (int, bool) {
+	lo, hi := 0, len(s)
+	for bisect := false; lo < hi; bisect = !bisect {
+		var p int
+		if bisect {
+			p = lo + (hi-lo)/2
+		} else {
+			first, last := s[lo], s[hi-1]
+			if x <= first {
+				hi = lo
+				break
+			}
+			if x > last {
+				lo = hi
+				break
+			}
+			// first < x <= last, so last > first.
+			p = lo + int(float64(hi-1-lo)*(float64(x)-float64(first))/(float64(last)-float64(first)))
+			p = min(max(p, lo), hi-1)
+		}
+		if s[p] < x {
+			lo = p + 1
+		} else {
+			hi = p
+		}
+	}
+	return lo, lo < len(s) && s[lo] == x
+}
@@ -0,0 +1,22 @@
+package search
+
+// FindFirst returns the first i in [lo, hi) for which f is true, or hi if
+// there is none. f must be monotonic over the range: false, then true. The
+// range can span all of int64; the midpoint never overflows.
+func FindFirst(lo, hi int64, f func(i int64) bool) int64 {
+	for lo < hi {
+		mid := lo + int64((uint64(hi)-uint64(lo))>>1)
+		if f(mid) {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo
+}
+
+// FindLast returns the last i in [lo, hi) for which f is true, or lo-1 if
+// there is none. f must be monotonic over the range: true, then false.
+func FindLast(lo, hi int64, f func(i int64) bool) int64 {
+	return FindFirst(lo, hi, func(i int64) bool { return !f(i) }) - 1
+}
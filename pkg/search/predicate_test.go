@@ -0,0 +1,51 @@
+package search
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFindFirst(t *testing.T) {
+	tests := []struct {
+		name      string
+		lo, hi    int64
+		threshold int64 // f(i) is i >= threshold
+		want      int64
+	}{
+		{"empty", 5, 5, 0, 5},
+		{"all true", 0, 10, -3, 0},
+		{"none true", 0, 10, 10, 10},
+		{"middle", 0, 10, 7, 7},
+		{"beyond int32", 0, 1 << 40, 1<<35 + 3, 1<<35 + 3},
+		{"negative", -100, 100, -42, -42},
+		{"all of int64", math.MinInt64, math.MaxInt64, math.MaxInt64 - 1, math.MaxInt64 - 1},
+		{"all of int64, low", math.MinInt64, math.MaxInt64, math.MinInt64 + 1, math.MinInt64 + 1},
+	}
+	for _, tc := range tests {
+		calls := 0
+		got := FindFirst(tc.lo, tc.hi, func(i int64) bool {
+			calls++
+			if i < tc.lo || i >= tc.hi {
+				t.Fatalf("%s: f(%d) outside [%d, %d)", tc.name, i, tc.lo, tc.hi)
+			}
+			return i >= tc.threshold
+		})
+		if got != tc.want {
+			t.Errorf("%s: FindFirst = %d, want %d", tc.name, got, tc.want)
+		}
+		if calls > 64 {
+			t.Errorf("%s: %d calls, want at most 64", tc.name, calls)
+		}
+	}
+}
+
+func TestFindLast(t *testing.T) {
+	// The largest integer whose square is at most 10^18.
+	root := FindLast(0, 2e9, func(i int64) bool { return i*i <= 1e18 })
+	if root != 1e9 {
+		t.Errorf("FindLast square root = %d, want 1e9", root)
+	}
+	if got := FindLast(3, 9, func(int64) bool { return false }); got != 2 {
+		t.Errorf("FindLast without a true = %d, want lo-1 = 2", got)
+	}
+}
@@ -0,0 +1,41 @@
+package search
+
+// RotationPoint returns the index of the smallest element of s, a sorted
+// slice rotated left by that many positions: 0 if s is not rotated.
+func RotationPoint[T any](s []T, less func(a, b T) bool) int {
+	lo, hi := 0, len(s)-1
+	for lo < hi {
+		if less(s[lo], s[hi]) {
+			return lo // s[lo:hi+1] is not rotated
+		}
+		mid := lo + (hi-lo)/2
+		switch {
+		case less(s[hi], s[mid]):
+			lo = mid + 1 // the drop is after mid
+		case less(s[mid], s[hi]):
+			hi = mid // the drop is at mid or before
+		default:
+			// s[mid] == s[hi] tells nothing about the side of the drop,
+			// but s[hi] can go: s[mid] is as small. Unless s[hi] is the
+			// drop itself, where s[hi-1] is above it.
+			if less(s[hi], s[hi-1]) {
+				return hi
+			}
+			hi--
+		}
+	}
+	return lo
+}
+
+// Rotated returns an index of x in s, a sorted slice rotated by an unknown
+// amount, and whether x is there at all.
+func Rotated[T any](s []T, x T, less func(a, b T) bool) (int, bool) {
+	r := RotationPoint(s, less)
+	// Search the sorted half x would be in: s[r:] holds the smallest
+	// elements, s[:r] the largest.
+	if r > 0 && !less(x, s[0]) {
+		return Contains(s[:r], x, less)
+	}
+	i, ok := Contains(s[r:], x, less)
+	return r + i, ok
+}
@@ -0,0 +1,60 @@
+package search
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func rotate(s []int, k int) []int {
+	return append(slices.Clone(s[k:]), s[:k]...)
+}
+
+func TestRotated(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 1, 2, 3, 10, 101} {
+		for _, unique := range []bool{true, false} {
+			sorted := make([]int, n)
+			for i := range sorted {
+				if unique {
+					sorted[i] = 2 * i
+				} else {
+					sorted[i] = rng.Intn(5)
+				}
+			}
+			slices.Sort(sorted)
+			for k := 0; k < max(n, 1); k++ {
+				s := rotate(sorted, k%max(n, 1))
+				r := RotationPoint(s, less)
+				if !slices.IsSorted(rotate(s, r%max(n, 1))) {
+					t.Fatalf("%v: rotation point %d doesn't sort it", s, r)
+				}
+				for x := -1; x <= 2*n+1; x++ {
+					i, ok := Rotated(s, x, less)
+					want := slices.Contains(s, x)
+					if ok != want || ok && s[i] != x {
+						t.Fatalf("%v: Rotated(%d) = %d, %v, want found %v", s, x, i, ok, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestRotationPoint_Duplicates(t *testing.T) {
+	tests := []struct {
+		s    []int
+		want int
+	}{
+		{[]int{2, 2, 2, 0, 2}, 3},
+		{[]int{2, 0, 2, 2, 2}, 1},
+		{[]int{1, 1, 1}, 0},
+		{[]int{1, 0, 1}, 1},
+		{[]int{3, 3, 1, 2, 3}, 2},
+	}
+	for _, tc := range tests {
+		if got := RotationPoint(tc.s, less); got != tc.want {
+			t.Errorf("RotationPoint(%v) = %d, want %d", tc.s, got, tc.want)
+		}
+	}
+}
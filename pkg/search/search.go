@@ -0,0 +1,60 @@
+// Package search implements binary search and its variants over sorted
+// slices of any type, ordered by a less function, and over monotonic
+// predicates on int64 ranges:
+//
+//   - LowerBound, UpperBound and EqualRange find where a value is or would
+//     go in a sorted slice.
+//   - Rotated finds a value in a sorted slice rotated by an unknown amount,
+//     and RotationPoint finds the amount.
+//   - Interpolation, Exponential, Unbounded and Fibonacci are variants of
+//     LowerBound: faster on evenly spread numeric keys, near the front or
+//     without a known length, and with additions only.
+//   - FindFirst and FindLast find where a monotonic predicate flips, over
+//     all of int64 rather than the int range of sort.Search.
+//
+// All run in O(log n), but Rotated and RotationPoint degrade to O(n) on
+// runs of equal elements, which can hide the rotation.
+//
+// References:
+//
+// Knuth, The Art of Computer Programming, Volume 3: Sorting and Searching,
+// 2nd edition, 1998, section 6.2.1.
+//
+// Perl, Itai and Avni, Interpolation Search: A Log Log N Search,
+// Communications of the ACM 21(7), 1978.
+//
+// Bentley and Yao, An Almost Optimal Algorithm for Unbounded Searching,
+// Information Processing Letters 5(3), 1976.
+//
+// Bentley, Programming Pearls, 2nd edition, 2000, column 4.
+package search
+
+// LowerBound returns the first index of the sorted s whose element is not
+// below x: where x is, or would be inserted before its equals.
+func LowerBound[T any](s []T, x T, less func(a, b T) bool) int {
+	return first(len(s), func(i int) bool { return !less(s[i], x) })
+}
+
+// UpperBound returns the first index of the sorted s whose element is above
+// x: where x would be inserted after its equals.
+func UpperBound[T any](s []T, x T, less func(a, b T) bool) int {
+	return first(len(s), func(i int) bool { return less(x, s[i]) })
+}
+
+// EqualRange returns the range s[lo:hi] of the elements of the sorted s
+// equal to x, empty at the insertion point if there are none.
+func EqualRange[T any](s []T, x T, less func(a, b T) bool) (lo, hi int) {
+	return LowerBound(s, x, less), UpperBound(s, x, less)
+}
+
+// Contains reports whether the sorted s holds x, and where.
+func Contains[T any](s []T, x T, less func(a, b T) bool) (int, bool) {
+	i := LowerBound(s, x, less)
+	return i, i < len(s) && !less(x, s[i])
+}
+
+// first returns the first index in [0, n) for which f, false then true, is
+// true, or n.
+func first(n int, f func(int) bool) int {
+	return int(FindFirst(0, int64(n), func(i int64) bool { return f(int(i)) }))
+}
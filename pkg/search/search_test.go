@@ -0,0 +1,49 @@
+package search
+
+import (
+	"slices"
+	"sort"
+	"testing"
+)
+
+func less(a, b int) bool { return a < b }
+
+func TestBounds(t *testing.T) {
+	s := []int{1, 3, 3, 3, 5, 8, 8, 13}
+	tests := []struct {
+		x            int
+		lower, upper int
+		contains     bool
+	}{
+		{0, 0, 0, false},
+		{1, 0, 1, true},
+		{3, 1, 4, true},
+		{4, 4, 4, false},
+		{8, 5, 7, true},
+		{13, 7, 8, true},
+		{20, 8, 8, false},
+	}
+	for _, tc := range tests {
+		lo, hi := EqualRange(s, tc.x, less)
+		if lo != tc.lower || hi != tc.upper {
+			t.Errorf("EqualRange(%d) = [%d, %d), want [%d, %d)", tc.x, lo, hi, tc.lower, tc.upper)
+		}
+		if lo != sort.SearchInts(s, tc.x) || hi != sort.SearchInts(s, tc.x+1) {
+			t.Errorf("EqualRange(%d) disagrees with sort.SearchInts", tc.x)
+		}
+		if i, ok := Contains(s, tc.x, less); ok != tc.contains || i != tc.lower {
+			t.Errorf("Contains(%d) = %d, %v, want %d, %v", tc.x, i, ok, tc.lower, tc.contains)
+		}
+	}
+	if lo, hi := EqualRange(nil, 1, less); lo != 0 || hi != 0 {
+		t.Errorf("EqualRange on an empty slice = [%d, %d)", lo, hi)
+	}
+}
+
+func TestBounds_Strings(t *testing.T) {
+	words := []string{"apple", "fig", "fig", "kiwi"}
+	lo, hi := EqualRange(words, "fig", func(a, b string) bool { return a < b })
+	if !slices.Equal(words[lo:hi], []string{"fig", "fig"}) {
+		t.Errorf("EqualRange(fig) = %v", words[lo:hi])
+	}
+}
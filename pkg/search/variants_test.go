@@ -0,0 +1,129 @@
+package search
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// keys returns n sorted keys, spread as named.
+func keys(rng *rand.Rand, n int, spread string) []int {
+	s := make([]int, n)
+	for i := range s {
+		switch spread {
+		case "uniform":
+			s[i] = rng.Intn(10 * n)
+		case "dense":
+			s[i] = i
+		case "duplicates":
+			s[i] = rng.Intn(n/10 + 1)
+		case "skewed":
+			s[i] = int(math.Exp(rng.Float64() * 30))
+		}
+	}
+	slices.Sort(s)
+	return s
+}
+
+var spreads = []string{"uniform", "dense", "duplicates", "skewed"}
+
+func TestVariants(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	variants := []struct {
+		name   string
+		search func(s []int, x int) (int, bool)
+	}{
+		{"interpolation", Interpolation[int]},
+		{"exponential", func(s []int, x int) (int, bool) { return Exponential(s, x, less) }},
+		{"fibonacci", func(s []int, x int) (int, bool) { return Fibonacci(s, x, less) }},
+	}
+	for _, v := range variants {
+		for _, n := range []int{0, 1, 2, 3, 5, 8, 100, 1000} {
+			for _, spread := range spreads {
+				s := keys(rng, n, spread)
+				probes := []int{-1, math.MaxInt}
+				for i := 0; i < 200; i++ {
+					probes = append(probes, rng.Intn(10*n+2)-1)
+					if n > 0 {
+						probes = append(probes, s[rng.Intn(n)])
+					}
+				}
+				for _, x := range probes {
+					i, ok := v.search(s, x)
+					want, wantOK := Contains(s, x, less)
+					if i != want || ok != wantOK {
+						t.Fatalf("%s, %s keys of %d: search(%d) = %d, %v, want %d, %v", v.name, spread, n, x, i, ok, want, wantOK)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestInterpolation_Floats(t *testing.T) {
+	s := []float64{-2.5, -1, 0, 0, 0.5, 3, 1e9}
+	for i, x := range s {
+		if j, ok := Interpolation(s, x); !ok || s[j] != x || j > i {
+			t.Errorf("Interpolation(%v) = %d, %v", x, j, ok)
+		}
+	}
+	if i, ok := Interpolation(s, 2.0); ok || i != 5 {
+		t.Errorf("Interpolation(2) = %d, %v, want 5, false", i, ok)
+	}
+}
+
+func TestUnbounded_Reads(t *testing.T) {
+	// The squares, without end.
+	for _, x := range []int{0, 1, 2, 49, 50, 1 << 40} {
+		maxRead := 0
+		i, ok := Unbounded(func(i int) (int, bool) {
+			maxRead = max(maxRead, i)
+			return i * i, true
+		}, x, less)
+		root := int(math.Ceil(math.Sqrt(float64(x))))
+		if i != root || ok != (root*root == x) {
+			t.Errorf("Unbounded(%d) = %d, %v, want %d", x, i, ok, root)
+		}
+		if maxRead > 2*max(root, 1) {
+			t.Errorf("Unbounded(%d) read index %d, answer %d", x, maxRead, root)
+		}
+	}
+}
+
+func BenchmarkVariants(b *testing.B) {
+	const n = 1 << 20
+	rng := rand.New(rand.NewSource(2))
+	searches := []struct {
+		name   string
+		search func(s []int, x int) (int, bool)
+	}{
+		{"binary", func(s []int, x int) (int, bool) { return Contains(s, x, less) }},
+		{"interpolation", Interpolation[int]},
+		{"exponential", func(s []int, x int) (int, bool) { return Exponential(s, x, less) }},
+		{"fibonacci", func(s []int, x int) (int, bool) { return Fibonacci(s, x, less) }},
+	}
+	for _, spread := range []string{"uniform", "skewed"} {
+		s := keys(rng, n, spread)
+		// Probes anywhere, and probes among the first thousand keys, where
+		// exponential search shines.
+		anywhere, front := make([]int, 1024), make([]int, 1024)
+		for i := range anywhere {
+			anywhere[i] = s[rng.Intn(n)]
+			front[i] = s[rng.Intn(1000)]
+		}
+		for _, where := range []struct {
+			name   string
+			probes []int
+		}{{"anywhere", anywhere}, {"front", front}} {
+			for _, sr := range searches {
+				b.Run(fmt.Sprintf("%s/%s/%s", spread, where.name, sr.name), func(b *testing.B) {
+					for i := 0; i < b.N; i++ {
+						sr.search(s, where.probes[i%len(where.probes)])
+					}
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,245 @@
+// Package shingle turns raw text into overlapping n-grams (shingles) and
+// their 64-bit hashes, the usual input of similarity sketches such as
+// MinHash and SimHash.
+//
+// References:
+//
+// https://en.wikipedia.org/wiki/W-shingling
+package shingle
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Mode selects the unit a shingle is built from.
+type Mode int
+
+const (
+	// Words builds shingles out of k consecutive whitespace separated words.
+	Words Mode = iota
+	// Bytes builds shingles out of k consecutive bytes.
+	Bytes
+)
+
+// Normalizer rewrites a piece of text before it is shingled. In Words mode
+// it is applied to every word, in Bytes mode to every line. A normalizer may
+// return the empty string to drop the token entirely.
+type Normalizer func(string) string
+
+// Lowercase maps the text to lower case.
+func Lowercase(s string) string {
+	return strings.ToLower(s)
+}
+
+// StripPunctuation removes punctuation and symbol runes.
+func StripPunctuation(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// CollapseWhitespace trims the text and folds runs of whitespace into a single space.
+func CollapseWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Chain composes normalizers, applying them left to right.
+func Chain(normalizers ...Normalizer) Normalizer {
+	return func(s string) string {
+		for _, n := range normalizers {
+			s = n(s)
+		}
+		return s
+	}
+}
+
+// Shingler cuts text into shingles of K words or bytes.
+type Shingler struct {
+	K          int          // number of words or bytes per shingle; must be positive
+	Mode       Mode         // Words or Bytes
+	Normalizer []Normalizer // applied in order before shingling
+	// MaxTokenSize bounds the bytes of a single word, or line in Bytes
+	// mode, that is held in memory; longer ones fail the read. 0 means no
+	// limit.
+	MaxTokenSize int
+}
+
+// New returns a Shingler of k units of mode, normalized in order by
+// normalizers.
+func New(k int, mode Mode, normalizers ...Normalizer) Shingler {
+	return Shingler{K: k, Mode: mode, Normalizer: normalizers}
+}
+
+// Each streams the shingles of r to fn in input order, stopping early when fn
+// returns false. Inputs shorter than K produce a single shingle holding
+// everything that was read, so short documents still have a fingerprint.
+func (s Shingler) Each(r io.Reader, fn func(shingle string) bool) error {
+	if s.K <= 0 {
+		return fmt.Errorf("shingle: size %d is not positive", s.K)
+	}
+	if s.Mode == Bytes {
+		return s.eachBytes(r, fn)
+	}
+	return s.eachWords(r, fn)
+}
+
+func (s Shingler) eachWords(r io.Reader, fn func(string) bool) error {
+	scanner := s.scanner(r)
+	scanner.Split(bufio.ScanWords)
+
+	window := make([]string, 0, s.K)
+	emitted := false
+	for scanner.Scan() {
+		word := s.normalize(scanner.Text())
+		if word == "" {
+			continue
+		}
+		if len(window) == s.K {
+			window = append(window[:0], window[1:]...)
+		}
+		window = append(window, word)
+		if len(window) == s.K {
+			emitted = true
+			if !fn(strings.Join(window, " ")) {
+				return nil
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if !emitted && len(window) > 0 {
+		fn(strings.Join(window, " "))
+	}
+	return nil
+}
+
+func (s Shingler) eachBytes(r io.Reader, fn func(string) bool) error {
+	scanner := s.scanner(r)
+
+	window := make([]byte, 0, s.K)
+	emitted := false
+	first := true
+	for scanner.Scan() {
+		line := s.normalize(scanner.Text())
+		if line == "" {
+			continue
+		}
+		// Lines are joined by a single space so shingles span line breaks.
+		if !first {
+			line = " " + line
+		}
+		first = false
+		for i := 0; i < len(line); i++ {
+			if len(window) == s.K {
+				window = append(window[:0], window[1:]...)
+			}
+			window = append(window, line[i])
+			if len(window) == s.K {
+				emitted = true
+				if !fn(string(window)) {
+					return nil
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if !emitted && len(window) > 0 {
+		fn(string(window))
+	}
+	return nil
+}
+
+// scanner returns a scanner of r whose tokens may grow to MaxTokenSize,
+// rather than the 64 KiB bufio defaults to.
+func (s Shingler) scanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	limit := s.MaxTokenSize
+	if limit <= 0 {
+		limit = math.MaxInt
+	}
+	scanner.Buffer(nil, limit)
+	return scanner
+}
+
+func (s Shingler) normalize(text string) string {
+	for _, n := range s.Normalizer {
+		text = n(text)
+	}
+	return text
+}
+
+// Shingles returns all shingles of text in order, duplicates included.
+func (s Shingler) Shingles(text string) ([]string, error) {
+	var out []string
+	err := s.Each(strings.NewReader(text), func(sh string) bool {
+		out = append(out, sh)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Hashes returns the sorted, de-duplicated 64-bit FNV-1a hashes of the
+// shingles of r. This is the set representation consumed by MinHash style
+// sketches.
+func (s Shingler) Hashes(r io.Reader) ([]uint64, error) {
+	seen := make(map[uint64]struct{})
+	err := s.Each(r, func(sh string) bool {
+		seen[Hash(sh)] = struct{}{}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]uint64, 0, len(seen))
+	for h := range seen {
+		out = append(out, h)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out, nil
+}
+
+// Hash returns the 64-bit FNV-1a hash of a shingle.
+func Hash(shingle string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(shingle))
+	return h.Sum64()
+}
+
+// Jaccard returns the Jaccard similarity of two sorted hash sets as returned
+// by Hashes. It is the exact value MinHash estimates.
+func Jaccard(a, b []uint64) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	inter := 0
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			inter++
+			i++
+			j++
+		case a[i] < b[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return float64(inter) / float64(len(a)+len(b)-inter)
+}
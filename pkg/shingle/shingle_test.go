@@ -0,0 +1,153 @@
+package shingle
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestShingler_Shingles(t *testing.T) {
+	tests := []struct {
+		name     string
+		shingler Shingler
+		text     string
+		expected []string
+	}{
+		{
+			name:     "words k=2",
+			shingler: New(2, Words),
+			text:     "the quick brown fox",
+			expected: []string{"the quick", "quick brown", "brown fox"},
+		},
+		{
+			name:     "words normalized",
+			shingler: New(2, Words, Lowercase, StripPunctuation),
+			text:     "The, Quick! -- brown",
+			expected: []string{"the quick", "quick brown"},
+		},
+		{
+			name:     "words shorter than k",
+			shingler: New(3, Words),
+			text:     "hello world",
+			expected: []string{"hello world"},
+		},
+		{
+			name:     "bytes k=3",
+			shingler: New(3, Bytes),
+			text:     "abcd",
+			expected: []string{"abc", "bcd"},
+		},
+		{
+			name:     "bytes across lines",
+			shingler: New(3, Bytes, Chain(Lowercase, CollapseWhitespace)),
+			text:     "Ab\n\n  C  ",
+			expected: []string{"ab ", "b c"},
+		},
+		{
+			name:     "empty",
+			shingler: New(2, Bytes),
+			text:     "",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.shingler.Shingles(tc.text)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Shingles(%q): expected %q, got %q", tc.text, tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestShingler_EachStopsEarly(t *testing.T) {
+	s := New(1, Words)
+	var got []string
+	err := s.Each(strings.NewReader("a b c d"), func(sh string) bool {
+		got = append(got, sh)
+		return len(got) < 2
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("expected [a b], got %q", got)
+	}
+}
+
+func TestShingler_LongTokens(t *testing.T) {
+	// Longer than the 64 KiB bufio.Scanner allows by default.
+	long := strings.Repeat("x", 70000)
+	for _, mode := range []Mode{Words, Bytes} {
+		var last string
+		count := 0
+		err := New(3, mode).Each(strings.NewReader("a "+long+" b\n"), func(sh string) bool {
+			last = sh
+			count++
+			return true
+		})
+		if err != nil {
+			t.Fatalf("mode %d: unexpected error: %v", mode, err)
+		}
+		if mode == Words && (count != 1 || len(last) != len(long)+4) {
+			t.Errorf("words: %d shingles, the last of %d bytes", count, len(last))
+		}
+		if mode == Bytes && (count != len(long)+2 || last != "x b") {
+			t.Errorf("bytes: %d shingles, the last %q", count, last)
+		}
+	}
+
+	s := New(3, Words)
+	s.MaxTokenSize = 1000
+	if err := s.Each(strings.NewReader(long), func(string) bool { return true }); err == nil {
+		t.Error("expected an error for a word over MaxTokenSize")
+	}
+}
+
+func TestShingler_InvalidSize(t *testing.T) {
+	for _, k := range []int{0, -1} {
+		for _, mode := range []Mode{Words, Bytes} {
+			if _, err := New(k, mode).Shingles("a b"); err == nil {
+				t.Errorf("k=%d, mode %d: expected an error", k, mode)
+			}
+		}
+	}
+}
+
+func TestShingler_HashesAndJaccard(t *testing.T) {
+	s := New(2, Words, Lowercase, StripPunctuation)
+
+	a, err := s.Hashes(strings.NewReader("the cat sat on the mat"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := s.Hashes(strings.NewReader("The cat sat on the mat."))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c, err := s.Hashes(strings.NewReader("the cat sat on a hat"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(a) != 5 {
+		t.Errorf("expected 5 distinct shingles, got %d", len(a))
+	}
+	for i := 1; i < len(a); i++ {
+		if a[i-1] >= a[i] {
+			t.Fatalf("hashes not sorted and unique: %v", a)
+		}
+	}
+	if got := Jaccard(a, b); got != 1 {
+		t.Errorf("normalized duplicates: expected similarity 1, got %f", got)
+	}
+	// Shared: "the cat", "cat sat", "sat on" out of 7 distinct shingles.
+	if got, want := Jaccard(a, c), 3.0/7.0; got != want {
+		t.Errorf("near duplicates: expected similarity %f, got %f", want, got)
+	}
+}
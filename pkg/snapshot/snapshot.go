@@ -0,0 +1,321 @@
+// Package snapshot gathers causally consistent global snapshots of per-rank
+// metrics using the Chandy–Lamport algorithm.
+//
+// Ranks run as goroutines connected by FIFO channels. Their state is a set of
+// named counters, and application messages carry counter deltas that are
+// added to the receiver's counters on delivery. A snapshot records every
+// rank's counters at a consistent cut together with the deltas still in
+// flight across it, so totals computed from the report are exact even while
+// ranks keep exchanging messages. Reports keep the state of every channel
+// and the message counts at the cut, so Report.Check can verify the cut.
+//
+// References:
+//
+// https://lamport.azurewebsites.net/pubs/chandy.pdf
+package snapshot
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sanderblue/algorithms/internal/mailbox"
+)
+
+// Counters maps metric names to values.
+type Counters map[string]float64
+
+func (c Counters) clone() Counters {
+	out := make(Counters, len(c))
+	for k, v := range c {
+		out[k] = v
+	}
+	return out
+}
+
+func (c Counters) add(o Counters) {
+	for k, v := range o {
+		c[k] += v
+	}
+}
+
+// Names returns the counter names in sorted order.
+func (c Counters) Names() []string {
+	names := make([]string, 0, len(c))
+	for k := range c {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Report is one consistent global snapshot.
+type Report struct {
+	ID       int
+	Local    []Counters // counters recorded by every rank
+	InFlight []Counters // deltas in flight towards every rank at the cut
+	Messages []int      // number of messages in flight towards every rank
+	Totals   Counters   // sum of all local counters and in-flight deltas
+
+	// Channels[i][j] is the recorded state of the channel from rank i to
+	// rank j; Channels[i][i] is unused.
+	Channels [][]Channel
+	// Sent[i][j] and Received[j][i] count the messages rank i had sent to
+	// rank j, and rank j had received from rank i, at the cut. A cut is
+	// consistent when every message counted as received is counted as
+	// sent, and the difference is exactly what the channel recorded.
+	Sent     [][]int
+	Received [][]int
+}
+
+// Channel is the state of a channel at a cut: the messages sent before the
+// cut at the sender but received after it at the receiver.
+type Channel struct {
+	From     int
+	To       int
+	Messages int
+	Deltas   Counters
+}
+
+// Check returns an error if the cut of r isn't consistent: a message
+// received before the cut but sent after it, or a channel whose recorded
+// state misses messages or holds extra ones.
+func (r Report) Check() error {
+	for i := range r.Sent {
+		for j := range r.Sent[i] {
+			if i == j {
+				continue
+			}
+			sent, received, inFlight := r.Sent[i][j], r.Received[j][i], r.Channels[i][j].Messages
+			if received > sent {
+				return fmt.Errorf("rank %d received %d messages from rank %d, which had sent %d", j, received, i, sent)
+			}
+			if sent != received+inFlight {
+				return fmt.Errorf("channel %d→%d: %d sent, %d received, but %d recorded in flight", i, j, sent, received, inFlight)
+			}
+		}
+	}
+	return nil
+}
+
+// envelope is what travels between ranks: either an application message
+// carrying a delta or a snapshot marker.
+type envelope struct {
+	from   int
+	marker bool
+	id     int
+	delta  Counters
+}
+
+// Rank is the state of one process. Its methods may only be called from
+// functions passed to System.Do, which run on the rank's own goroutine.
+type Rank struct {
+	ID int
+
+	sys        *System
+	counters   Counters
+	recordings map[int]*recording
+	sent       []int // messages sent to every rank
+	received   []int // messages received from every rank
+}
+
+type recording struct {
+	local    Counters
+	inFlight Counters
+	messages int
+	pending  map[int]bool // incoming channels still being recorded
+	channels []Channel    // incoming channels, by sender
+	sent     []int
+	received []int
+}
+
+// Add increments a local counter.
+func (r *Rank) Add(name string, delta float64) {
+	r.counters[name] += delta
+}
+
+// Get returns a local counter.
+func (r *Rank) Get(name string) float64 {
+	return r.counters[name]
+}
+
+// Send sends delta to rank to, where it is added to the counters on delivery.
+func (r *Rank) Send(to int, delta Counters) {
+	r.sent[to]++
+	r.sys.mail[to].Push(envelope{from: r.ID, delta: delta.clone()})
+}
+
+func (r *Rank) deliver(env envelope) {
+	if env.marker {
+		r.marker(env.id, env.from)
+		return
+	}
+	r.counters.add(env.delta)
+	r.received[env.from]++
+	for _, rec := range r.recordings {
+		if rec.pending[env.from] {
+			rec.inFlight.add(env.delta)
+			rec.messages++
+			rec.channels[env.from].Messages++
+			rec.channels[env.from].Deltas.add(env.delta)
+		}
+	}
+}
+
+// marker handles a marker for snapshot id received from rank from, or the
+// initiation of the snapshot when from is -1.
+func (r *Rank) marker(id, from int) {
+	rec, ok := r.recordings[id]
+	if !ok {
+		// First marker: record the local state, start recording every other
+		// incoming channel and pass the marker on.
+		rec = &recording{local: r.counters.clone(), inFlight: Counters{}, pending: map[int]bool{},
+			sent: append([]int(nil), r.sent...), received: append([]int(nil), r.received...)}
+		for i := 0; i < r.sys.P; i++ {
+			rec.channels = append(rec.channels, Channel{From: i, To: r.ID, Deltas: Counters{}})
+			if i != r.ID && i != from {
+				rec.pending[i] = true
+			}
+		}
+		r.recordings[id] = rec
+		for i := 0; i < r.sys.P; i++ {
+			if i != r.ID {
+				r.sys.mail[i].Push(envelope{from: r.ID, marker: true, id: id})
+			}
+		}
+	} else {
+		delete(rec.pending, from)
+	}
+	if len(rec.pending) == 0 {
+		delete(r.recordings, id)
+		r.sys.report(id, r.ID, rec)
+	}
+}
+
+// System runs P ranks connected by FIFO channels.
+type System struct {
+	P int
+
+	ranks   []*Rank
+	mail    []*mailbox.Mailbox[envelope]
+	actions []chan func(*Rank)
+	done    chan struct{}
+	wg      sync.WaitGroup
+
+	mu      sync.Mutex
+	nextID  int
+	pending map[int]*Report
+	waiters map[int]chan Report
+	arrived map[int]int
+}
+
+func New(p int) *System {
+	s := &System{
+		P:       p,
+		done:    make(chan struct{}),
+		pending: map[int]*Report{},
+		waiters: map[int]chan Report{},
+		arrived: map[int]int{},
+	}
+	for i := 0; i < p; i++ {
+		s.ranks = append(s.ranks, &Rank{ID: i, sys: s, counters: Counters{}, recordings: map[int]*recording{},
+			sent: make([]int, p), received: make([]int, p)})
+		s.mail = append(s.mail, mailbox.New[envelope]())
+		s.actions = append(s.actions, make(chan func(*Rank)))
+	}
+	s.wg.Add(p)
+	for i := 0; i < p; i++ {
+		go s.loop(s.ranks[i])
+	}
+	return s
+}
+
+func (s *System) loop(r *Rank) {
+	defer s.wg.Done()
+	box := s.mail[r.ID]
+	for {
+		select {
+		case <-box.Signal():
+			for _, env := range box.Drain() {
+				r.deliver(env)
+			}
+		case fn := <-s.actions[r.ID]:
+			fn(r)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Do runs fn on the goroutine of rank and waits for it to return. It is a
+// no-op once the system is closed.
+func (s *System) Do(rank int, fn func(r *Rank)) {
+	finished := make(chan struct{})
+	select {
+	case s.actions[rank] <- func(r *Rank) {
+		fn(r)
+		close(finished)
+	}:
+		<-finished
+	case <-s.done:
+	}
+}
+
+// Snapshot starts a Chandy–Lamport snapshot at initiator and blocks until
+// every rank has recorded its state and incoming channels.
+func (s *System) Snapshot(initiator int) Report {
+	s.mu.Lock()
+	id := s.nextID
+	s.nextID++
+	wait := make(chan Report, 1)
+	s.waiters[id] = wait
+	s.pending[id] = &Report{
+		ID:       id,
+		Local:    make([]Counters, s.P),
+		InFlight: make([]Counters, s.P),
+		Messages: make([]int, s.P),
+		Channels: make([][]Channel, s.P),
+		Sent:     make([][]int, s.P),
+		Received: make([][]int, s.P),
+	}
+	for i := range s.pending[id].Channels {
+		s.pending[id].Channels[i] = make([]Channel, s.P)
+	}
+	s.mu.Unlock()
+
+	s.Do(initiator, func(r *Rank) { r.marker(id, -1) })
+	return <-wait
+}
+
+func (s *System) report(id, rank int, rec *recording) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rep := s.pending[id]
+	rep.Local[rank] = rec.local
+	rep.InFlight[rank] = rec.inFlight
+	rep.Messages[rank] = rec.messages
+	rep.Sent[rank] = rec.sent
+	rep.Received[rank] = rec.received
+	for from, ch := range rec.channels {
+		rep.Channels[from][rank] = ch
+	}
+	s.arrived[id]++
+	if s.arrived[id] < s.P {
+		return
+	}
+	rep.Totals = Counters{}
+	for i := 0; i < s.P; i++ {
+		rep.Totals.add(rep.Local[i])
+		rep.Totals.add(rep.InFlight[i])
+	}
+	delete(s.pending, id)
+	delete(s.arrived, id)
+	s.waiters[id] <- *rep
+	delete(s.waiters, id)
+}
+
+// Close stops every rank goroutine. Messages still in flight are dropped.
+func (s *System) Close() {
+	close(s.done)
+	s.wg.Wait()
+}
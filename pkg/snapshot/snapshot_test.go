@@ -0,0 +1,196 @@
+package snapshot
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestSystem_SnapshotQuiescent(t *testing.T) {
+	s := New(3)
+	defer s.Close()
+
+	for i := 0; i < 3; i++ {
+		s.Do(i, func(r *Rank) { r.Add("requests", float64(10*(r.ID+1))) })
+	}
+	rep := s.Snapshot(1)
+
+	if got := rep.Totals["requests"]; got != 60 {
+		t.Errorf("expected 60 requests in total, got %f", got)
+	}
+	for i, c := range rep.Local {
+		if c["requests"] != float64(10*(i+1)) {
+			t.Errorf("rank %d: expected %d requests, got %f", i, 10*(i+1), c["requests"])
+		}
+		if rep.Messages[i] != 0 {
+			t.Errorf("rank %d: expected no messages in flight, got %d", i, rep.Messages[i])
+		}
+	}
+}
+
+func TestSystem_SnapshotConsistentUnderTraffic(t *testing.T) {
+	tests := []struct {
+		name string
+		p    int
+	}{
+		{name: "p=2", p: 2},
+		{name: "p=5", p: 5},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			s := New(tc.p)
+			defer s.Close()
+
+			const initial = 1000.0
+			for i := 0; i < tc.p; i++ {
+				s.Do(i, func(r *Rank) { r.Add("tokens", initial) })
+			}
+
+			// Every rank keeps moving tokens to random peers while snapshots
+			// are taken. Tokens are conserved and every send is eventually
+			// received, so a consistent cut must see exactly the initial
+			// total and as many receives as sends.
+			stop := make(chan struct{})
+			var wg sync.WaitGroup
+			for i := 0; i < tc.p; i++ {
+				wg.Add(1)
+				go func(rank int) {
+					defer wg.Done()
+					rng := rand.New(rand.NewSource(int64(rank)))
+					for {
+						select {
+						case <-stop:
+							return
+						default:
+						}
+						s.Do(rank, func(r *Rank) {
+							to := rng.Intn(tc.p - 1)
+							if to >= r.ID {
+								to++
+							}
+							amount := float64(rng.Intn(5) + 1)
+							r.Add("tokens", -amount)
+							r.Add("sent", 1)
+							r.Send(to, Counters{"tokens": amount, "received": 1})
+						})
+					}
+				}(i)
+			}
+
+			sawInFlight := false
+			for k := 0; k < 20; k++ {
+				rep := s.Snapshot(k % tc.p)
+				if got, want := rep.Totals["tokens"], initial*float64(tc.p); got != want {
+					t.Fatalf("snapshot %d: expected %f tokens, got %f", rep.ID, want, got)
+				}
+				if err := rep.Check(); err != nil {
+					t.Fatalf("snapshot %d: %v", rep.ID, err)
+				}
+				checkChannels(t, rep)
+				if rep.Totals["sent"] != rep.Totals["received"] {
+					t.Fatalf("snapshot %d: inconsistent cut, sent=%f received=%f",
+						rep.ID, rep.Totals["sent"], rep.Totals["received"])
+				}
+				for _, m := range rep.Messages {
+					if m > 0 {
+						sawInFlight = true
+					}
+				}
+			}
+			close(stop)
+			wg.Wait()
+
+			if !sawInFlight {
+				t.Log("no snapshot captured messages in flight")
+			}
+		})
+	}
+}
+
+func TestSystem_ConcurrentSnapshots(t *testing.T) {
+	s := New(4)
+	defer s.Close()
+	for i := 0; i < 4; i++ {
+		s.Do(i, func(r *Rank) { r.Add("x", 1) })
+	}
+
+	var wg sync.WaitGroup
+	reports := make([]Report, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			reports[i] = s.Snapshot(i)
+		}(i)
+	}
+	wg.Wait()
+
+	seen := map[int]bool{}
+	for _, rep := range reports {
+		if rep.Totals["x"] != 4 {
+			t.Errorf("snapshot %d: expected x=4, got %f", rep.ID, rep.Totals["x"])
+		}
+		seen[rep.ID] = true
+	}
+	if len(seen) != 4 {
+		t.Errorf("expected 4 distinct snapshot ids, got %v", seen)
+	}
+}
+
+// checkChannels verifies that the channels towards every rank add up to
+// what the rank recorded in flight.
+func checkChannels(t *testing.T, rep Report) {
+	t.Helper()
+	for to := range rep.InFlight {
+		messages, deltas := 0, Counters{}
+		for from := range rep.Channels {
+			ch := rep.Channels[from][to]
+			if from != to && (ch.From != from || ch.To != to) {
+				t.Fatalf("channel %d→%d labelled %d→%d", from, to, ch.From, ch.To)
+			}
+			messages += ch.Messages
+			deltas.add(ch.Deltas)
+		}
+		if messages != rep.Messages[to] || deltas["tokens"] != rep.InFlight[to]["tokens"] {
+			t.Fatalf("rank %d: channels hold %d messages of %v, but %d of %v recorded in flight",
+				to, messages, deltas, rep.Messages[to], rep.InFlight[to])
+		}
+	}
+}
+
+func TestReport_Check(t *testing.T) {
+	s := New(3)
+	defer s.Close()
+	s.Do(0, func(r *Rank) { r.Send(1, Counters{"x": 1}) })
+	s.Do(2, func(r *Rank) { r.Send(0, Counters{"x": 1}) })
+	rep := s.Snapshot(0)
+	if err := rep.Check(); err != nil {
+		t.Fatal(err)
+	}
+	if rep.Sent[0][1] != 1 || rep.Sent[2][0] != 1 || rep.Received[1][0]+rep.Channels[0][1].Messages != 1 {
+		t.Fatalf("unexpected counts: sent %v, received %v", rep.Sent, rep.Received)
+	}
+
+	// A message received before the cut but sent after it.
+	orphan := rep
+	orphan.Received = [][]int{{0, 0, 2}, {1, 0, 0}, {0, 0, 0}}
+	if err := orphan.Check(); err == nil {
+		t.Error("expected an error for a message received but never sent")
+	}
+	// A message neither received nor recorded in flight.
+	lost := rep
+	lost.Sent = [][]int{{0, 2, 0}, {0, 0, 0}, {1, 0, 0}}
+	if err := lost.Check(); err == nil {
+		t.Error("expected an error for a lost message")
+	}
+}
+
+func TestCounters_Names(t *testing.T) {
+	c := Counters{"b": 1, "a": 2, "c": 3}
+	names := c.Names()
+	if len(names) != 3 || names[0] != "a" || names[2] != "c" {
+		t.Errorf("expected sorted names, got %v", names)
+	}
+}
@@ -0,0 +1,31 @@
+package sort
+
+// Heap sorts s by heapsort: it builds a max-heap in place, then moves the
+// maximum to the end one element at a time.
+func Heap[T any](s []T, less func(a, b T) bool) {
+	for i := len(s)/2 - 1; i >= 0; i-- {
+		siftDown(s, i, less)
+	}
+	for end := len(s) - 1; end > 0; end-- {
+		s[0], s[end] = s[end], s[0]
+		siftDown(s[:end], 0, less)
+	}
+}
+
+// siftDown moves s[i] down the heap s until neither child is larger.
+func siftDown[T any](s []T, i int, less func(a, b T) bool) {
+	for {
+		child := 2*i + 1
+		if child >= len(s) {
+			return
+		}
+		if child+1 < len(s) && less(s[child], s[child+1]) {
+			child++
+		}
+		if !less(s[i], s[child]) {
+			return
+		}
+		s[i], s[child] = s[child], s[i]
+		i = child
+	}
+}
@@ -0,0 +1,14 @@
+package sort
+
+// Insertion sorts s by insertion: every element moves left past the larger
+// ones before it.
+func Insertion[T any](s []T, less func(a, b T) bool) {
+	for i := 1; i < len(s); i++ {
+		v := s[i]
+		j := i
+		for ; j > 0 && less(v, s[j-1]); j-- {
+			s[j] = s[j-1]
+		}
+		s[j] = v
+	}
+}
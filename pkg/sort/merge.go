@@ -0,0 +1,23 @@
+package sort
+
+// Merge sorts s by top-down merge sort, stably.
+func Merge[T any](s []T, less func(a, b T) bool) {
+	buf := make([]T, len(s))
+	mergeSort(s, buf, less)
+}
+
+// mergeSort sorts s using buf, of the same length, as scratch space.
+func mergeSort[T any](s, buf []T, less func(a, b T) bool) {
+	if len(s) <= smallSort {
+		Insertion(s, less)
+		return
+	}
+	mid := len(s) / 2
+	mergeSort(s[:mid], buf[:mid], less)
+	mergeSort(s[mid:], buf[mid:], less)
+	if !less(s[mid], s[mid-1]) {
+		return // already in order
+	}
+	copy(buf, s)
+	merge(buf[:mid], buf[mid:], s, less)
+}
@@ -0,0 +1,89 @@
+package sort
+
+// Cycle sorts s by cycle sort: every element is written once, into its
+// final place, found by counting the smaller elements, and displaces the
+// element there, which moves on in turn until the cycle closes. O(n²)
+// comparisons, but at most n writes, the fewest possible.
+func Cycle[T any](s []T, less func(a, b T) bool) {
+	cycleSort(s, less)
+}
+
+// cycleSort is Cycle; it returns the number of writes.
+func cycleSort[T any](s []T, less func(a, b T) bool) int {
+	writes := 0
+	// place returns where item goes in the cycle starting at start: after
+	// the smaller elements and after its equals already placed.
+	place := func(start int, item T) int {
+		pos := start
+		for i := start + 1; i < len(s); i++ {
+			if less(s[i], item) {
+				pos++
+			}
+		}
+		return pos
+	}
+	equal := func(a, b T) bool { return !less(a, b) && !less(b, a) }
+	for start := 0; start < len(s)-1; start++ {
+		item := s[start]
+		pos := place(start, item)
+		if pos == start {
+			continue
+		}
+		for equal(item, s[pos]) {
+			pos++
+		}
+		s[pos], item = item, s[pos]
+		writes++
+		for pos != start {
+			pos = place(start, item)
+			for pos != start && equal(item, s[pos]) {
+				pos++
+			}
+			s[pos], item = item, s[pos]
+			writes++
+		}
+	}
+	return writes
+}
+
+// Pancake sorts s by prefix reversals only, like a stack of pancakes
+// flipped with a spatula: the largest unsorted element is flipped to the
+// top, then down to its place. O(n²), with at most 2n-3 flips.
+func Pancake[T any](s []T, less func(a, b T) bool) {
+	pancakeSort(s, less)
+}
+
+// pancakeSort is Pancake; it returns the number of flips.
+func pancakeSort[T any](s []T, less func(a, b T) bool) int {
+	flips := 0
+	for size := len(s); size > 1; size-- {
+		m := 0
+		for i := 1; i < size; i++ {
+			if less(s[m], s[i]) {
+				m = i
+			}
+		}
+		if m == size-1 {
+			continue
+		}
+		if m > 0 {
+			reverse(s[:m+1])
+			flips++
+		}
+		reverse(s[:size])
+		flips++
+	}
+	return flips
+}
+
+// Wiggle arranges s so that s[0] <= s[1] >= s[2] <= s[3] ... in one pass.
+// It doesn't sort: every element at an odd index is only a local maximum.
+func Wiggle[T any](s []T, less func(a, b T) bool) {
+	for i := 1; i < len(s); i++ {
+		// At an odd index the element must not be below its predecessor,
+		// at an even one not above it.
+		if less(s[i], s[i-1]) == (i%2 == 1) {
+			s[i], s[i-1] = s[i-1], s[i]
+		}
+	}
+}
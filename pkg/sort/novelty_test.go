@@ -0,0 +1,73 @@
+package sort
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCycle_Writes(t *testing.T) {
+	for name, in := range inputs(200) {
+		s := slices.Clone(in)
+		writes := cycleSort(s, less)
+		misplaced := 0
+		sorted := slices.Clone(in)
+		slices.Sort(sorted)
+		for i := range in {
+			if in[i] != sorted[i] {
+				misplaced++
+			}
+		}
+		// Every write puts an element in its place for good.
+		if writes > misplaced || !slices.Equal(s, sorted) {
+			t.Errorf("%s: %d writes for %d misplaced elements", name, writes, misplaced)
+		}
+	}
+}
+
+func TestPancake_Flips(t *testing.T) {
+	for name, in := range inputs(200) {
+		s := slices.Clone(in)
+		if flips := pancakeSort(s, less); flips > 2*len(s)-3 {
+			t.Errorf("%s: %d flips, want at most %d", name, flips, 2*len(s)-3)
+		}
+		if !slices.IsSorted(s) {
+			t.Errorf("%s: not sorted", name)
+		}
+	}
+}
+
+func TestWiggle(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 7, 100} {
+		for name, in := range inputs(n) {
+			s := slices.Clone(in)
+			Wiggle(s, less)
+			for i := 1; i < len(s); i++ {
+				if i%2 == 1 && s[i] < s[i-1] || i%2 == 0 && s[i] > s[i-1] {
+					t.Fatalf("%s input of %d: %v doesn't wiggle at %d", name, n, s, i)
+				}
+			}
+			got, want := slices.Clone(s), slices.Clone(in)
+			slices.Sort(got)
+			slices.Sort(want)
+			if !slices.Equal(got, want) {
+				t.Fatalf("%s input of %d: elements changed", name, n)
+			}
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	names := map[string]bool{}
+	for _, s := range All[string]() {
+		if names[s.Name()] {
+			t.Errorf("two sorters named %q", s.Name())
+		}
+		names[s.Name()] = true
+		if got, ok := Lookup[string](s.Name()); !ok || got.Name() != s.Name() {
+			t.Errorf("Lookup(%q) = %v, %v", s.Name(), got, ok)
+		}
+	}
+	if _, ok := Lookup[int]("bogo"); ok {
+		t.Error("Lookup found a sorter that doesn't exist")
+	}
+}
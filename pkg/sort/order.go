@@ -0,0 +1,120 @@
+package sort
+
+import "cmp"
+
+// Order is an ordering of T by several keys: by the first key, ties broken
+// by the second, and so on. Build one with By or ByFunc and extend it with
+// ThenBy, Then and Desc:
+//
+//	By(func(p Person) string { return p.Last }).
+//		ThenBy(Key(func(p Person) int { return p.Age })).Desc()
+//
+// Orders are values; extending one returns a new Order and leaves the old
+// one as it was.
+type Order[T any] struct {
+	keys []orderKey[T]
+}
+
+type orderKey[T any] struct {
+	cmp  func(a, b T) int
+	desc bool
+}
+
+// Key returns the comparator of T by key, for ThenBy and ByFunc.
+func Key[T any, K cmp.Ordered](key func(T) K) func(a, b T) int {
+	return func(a, b T) int { return cmp.Compare(key(a), key(b)) }
+}
+
+// By returns the ascending order of T by key.
+func By[T any, K cmp.Ordered](key func(T) K) Order[T] {
+	return ByFunc(Key(key))
+}
+
+// ByFunc returns the order of T by the comparator cmp, which returns a
+// negative number, zero or a positive number as a sorts before, with or
+// after b.
+func ByFunc[T any](cmp func(a, b T) int) Order[T] {
+	return Order[T]{keys: []orderKey[T]{{cmp: cmp}}}
+}
+
+// ThenBy returns o with ties broken by cmp.
+func (o Order[T]) ThenBy(cmp func(a, b T) int) Order[T] {
+	return o.Then(ByFunc(cmp))
+}
+
+// Then returns o with ties broken by next.
+func (o Order[T]) Then(next Order[T]) Order[T] {
+	keys := make([]orderKey[T], 0, len(o.keys)+len(next.keys))
+	return Order[T]{keys: append(append(keys, o.keys...), next.keys...)}
+}
+
+// Desc returns o with its last key reversed.
+func (o Order[T]) Desc() Order[T] {
+	if len(o.keys) == 0 {
+		return o
+	}
+	keys := append([]orderKey[T](nil), o.keys...)
+	keys[len(keys)-1].desc = !keys[len(keys)-1].desc
+	return Order[T]{keys: keys}
+}
+
+// Compare compares a and b by the keys of o in turn.
+func (o Order[T]) Compare(a, b T) int {
+	for _, k := range o.keys {
+		if c := k.cmp(a, b); c != 0 {
+			if k.desc {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
+
+// Less reports whether a sorts before b.
+func (o Order[T]) Less(a, b T) bool { return o.Compare(a, b) < 0 }
+
+// Sort sorts s by o, stably.
+func (o Order[T]) Sort(s []T) {
+	SortStableFunc(s, o.Compare)
+}
+
+// SortStableFunc sorts s by cmp by merge sort, stably. It allocates once, a
+// buffer of half of s: merges copy only their left run aside and merge
+// forward into s.
+func SortStableFunc[T any](s []T, cmp func(a, b T) int) {
+	stableSort(s, make([]T, len(s)/2), cmp)
+}
+
+func stableSort[T any](s, buf []T, cmp func(a, b T) int) {
+	if len(s) <= smallSort {
+		for i := 1; i < len(s); i++ {
+			for j := i; j > 0 && cmp(s[j], s[j-1]) < 0; j-- {
+				s[j], s[j-1] = s[j-1], s[j]
+			}
+		}
+		return
+	}
+	mid := len(s) / 2
+	stableSort(s[:mid], buf, cmp)
+	stableSort(s[mid:], buf, cmp)
+	if cmp(s[mid-1], s[mid]) <= 0 {
+		return
+	}
+	left := buf[:mid]
+	copy(left, s[:mid])
+	// The write position k trails the right run's read position j, so the
+	// right run merges in place.
+	i, j, k := 0, mid, 0
+	for i < mid && j < len(s) {
+		if cmp(s[j], left[i]) < 0 {
+			s[k] = s[j]
+			j++
+		} else {
+			s[k] = left[i]
+			i++
+		}
+		k++
+	}
+	copy(s[k:], left[i:])
+}
@@ -0,0 +1,122 @@
+package sort
+
+import (
+	"cmp"
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+type employee struct {
+	dept   string
+	salary int
+	name   string
+	pos    int // input position, to check stability
+}
+
+func employees(n int) []employee {
+	rng := rand.New(rand.NewSource(int64(n)))
+	out := make([]employee, n)
+	for i := range out {
+		out[i] = employee{
+			dept:   []string{"eng", "ops", "sales"}[rng.Intn(3)],
+			salary: 10 * rng.Intn(10),
+			name:   fmt.Sprint("e", rng.Intn(n/2+1)),
+			pos:    i,
+		}
+	}
+	return out
+}
+
+var (
+	byDept   = func(e employee) string { return e.dept }
+	bySalary = func(e employee) int { return e.salary }
+	byName   = func(e employee) string { return e.name }
+)
+
+func TestOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		order Order[employee]
+		want  func(a, b employee) int
+	}{
+		{"one key", By(byDept), func(a, b employee) int { return cmp.Compare(a.dept, b.dept) }},
+		{"two keys", By(byDept).ThenBy(Key(bySalary)), func(a, b employee) int {
+			return cmp.Or(cmp.Compare(a.dept, b.dept), cmp.Compare(a.salary, b.salary))
+		}},
+		{"last key descending", By(byDept).ThenBy(Key(bySalary)).Desc(), func(a, b employee) int {
+			return cmp.Or(cmp.Compare(a.dept, b.dept), cmp.Compare(b.salary, a.salary))
+		}},
+		{"first key descending", By(byDept).Desc().Then(By(byName)), func(a, b employee) int {
+			return cmp.Or(cmp.Compare(b.dept, a.dept), cmp.Compare(a.name, b.name))
+		}},
+		{"three keys", By(bySalary).Desc().ThenBy(Key(byDept)).Then(By(byName).Desc()), func(a, b employee) int {
+			return cmp.Or(cmp.Compare(b.salary, a.salary), cmp.Compare(a.dept, b.dept), cmp.Compare(b.name, a.name))
+		}},
+		{"desc twice", By(byDept).Desc().Desc(), func(a, b employee) int { return cmp.Compare(a.dept, b.dept) }},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			for _, n := range []int{0, 1, 10, 1000} {
+				in := employees(n)
+				want := slices.Clone(in)
+				slices.SortStableFunc(want, tc.want)
+				got := slices.Clone(in)
+				tc.order.Sort(got)
+				if !slices.Equal(got, want) {
+					t.Fatalf("%d employees: differs from a stable sort", n)
+				}
+			}
+		})
+	}
+}
+
+func TestOrder_Immutable(t *testing.T) {
+	base := By(byDept)
+	asc := base.ThenBy(Key(bySalary))
+	_ = asc.Desc()
+	_ = base.Then(By(byName))
+	a := employee{dept: "eng", salary: 10, name: "b"}
+	b := employee{dept: "eng", salary: 20, name: "a"}
+	if base.Compare(a, b) != 0 || !asc.Less(a, b) {
+		t.Error("extending an order changed it")
+	}
+}
+
+func TestSortStableFunc(t *testing.T) {
+	for _, n := range []int{0, 1, 12, 13, 500, 5000} {
+		in := employees(n)
+		got := slices.Clone(in)
+		SortStableFunc(got, func(a, b employee) int { return cmp.Compare(a.salary, b.salary) })
+		for i := 1; i < len(got); i++ {
+			if got[i].salary < got[i-1].salary || got[i].salary == got[i-1].salary && got[i].pos < got[i-1].pos {
+				t.Fatalf("%d employees: out of order at %d", n, i)
+			}
+		}
+	}
+}
+
+func BenchmarkOrder(b *testing.B) {
+	in := employees(100000)
+	order := By(byDept).ThenBy(Key(bySalary)).Desc().Then(By(byName))
+	handwritten := func(a, b employee) int {
+		return cmp.Or(cmp.Compare(a.dept, b.dept), cmp.Compare(b.salary, a.salary), cmp.Compare(a.name, b.name))
+	}
+	sorts := []struct {
+		name string
+		sort func([]employee)
+	}{
+		{"Order.Sort", order.Sort},
+		{"SortStableFunc", func(s []employee) { SortStableFunc(s, handwritten) }},
+		{"slices.SortStableFunc", func(s []employee) { slices.SortStableFunc(s, handwritten) }},
+		{"Merge", func(s []employee) { Merge(s, func(a, b employee) bool { return handwritten(a, b) < 0 }) }},
+	}
+	for _, sort := range sorts {
+		b.Run(sort.name, func(b *testing.B) {
+			b.ReportAllocs()
+			benchSort(b, in, sort.sort)
+		})
+	}
+}
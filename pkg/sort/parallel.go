@@ -0,0 +1,140 @@
+package sort
+
+import (
+	"runtime"
+	"sync"
+)
+
+// ParallelConfig tunes ParallelMerge.
+type ParallelConfig struct {
+	Workers     int // goroutines sorting at once; GOMAXPROCS if 0
+	Cutoff      int // slices shorter than this sort sequentially; 4096 if 0
+	MergeCutoff int // merges shorter than this run sequentially; 8192 if 0
+}
+
+func (c ParallelConfig) withDefaults() ParallelConfig {
+	if c.Workers <= 0 {
+		c.Workers = runtime.GOMAXPROCS(0)
+	}
+	if c.Cutoff <= 0 {
+		c.Cutoff = 4096
+	}
+	if c.MergeCutoff <= 0 {
+		c.MergeCutoff = 8192
+	}
+	return c
+}
+
+// ParallelMerge sorts s by merge sort, stably, on a pool of cfg.Workers
+// goroutines: both halves of a slice of at least cfg.Cutoff elements sort in
+// parallel, and so do both halves of a long merge, split at the median of
+// the longer run and its rank in the shorter one. With P workers it takes
+// O(n log n / P) time plus an O(log³ n) critical path.
+func ParallelMerge[T any](s []T, less func(a, b T) bool, cfg ParallelConfig) {
+	cfg = cfg.withDefaults()
+	p := &pool{slots: make(chan struct{}, cfg.Workers-1)}
+	buf := make([]T, len(s))
+	parallelSort(p, cfg, s, buf, less)
+}
+
+// pool hands out goroutines: a task forks when a slot is free and runs on
+// the caller's goroutine otherwise, so there is no queue to wait in.
+type pool struct {
+	slots chan struct{}
+}
+
+// both runs a and b, in parallel if a slot is free.
+func (p *pool) both(a, b func()) {
+	select {
+	case p.slots <- struct{}{}:
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-p.slots }()
+			a()
+		}()
+		b()
+		wg.Wait()
+	default:
+		a()
+		b()
+	}
+}
+
+func parallelSort[T any](p *pool, cfg ParallelConfig, s, buf []T, less func(a, b T) bool) {
+	if len(s) < cfg.Cutoff || len(s) <= smallSort {
+		mergeSort(s, buf, less)
+		return
+	}
+	mid := len(s) / 2
+	p.both(
+		func() { parallelSort(p, cfg, s[:mid], buf[:mid], less) },
+		func() { parallelSort(p, cfg, s[mid:], buf[mid:], less) },
+	)
+	if !less(s[mid], s[mid-1]) {
+		return
+	}
+	copy(buf, s)
+	parallelMerge(p, cfg, buf[:mid], buf[mid:], s, less)
+}
+
+// parallelMerge merges the sorted runs a and b into out, taking equal
+// elements from a first.
+func parallelMerge[T any](p *pool, cfg ParallelConfig, a, b, out []T, less func(a, b T) bool) {
+	if len(a)+len(b) < cfg.MergeCutoff {
+		merge(a, b, out, less)
+		return
+	}
+	// Split the longer run at its median and the other where the median
+	// would go, on the side that keeps equal elements of a before those of
+	// b.
+	var i, j int
+	if len(a) >= len(b) {
+		i = len(a) / 2
+		j = search(b, func(y T) bool { return !less(y, a[i]) })
+		out[i+j] = a[i]
+		p.both(
+			func() { parallelMerge(p, cfg, a[:i], b[:j], out[:i+j], less) },
+			func() { parallelMerge(p, cfg, a[i+1:], b[j:], out[i+j+1:], less) },
+		)
+	} else {
+		j = len(b) / 2
+		i = search(a, func(x T) bool { return less(b[j], x) })
+		out[i+j] = b[j]
+		p.both(
+			func() { parallelMerge(p, cfg, a[:i], b[:j], out[:i+j], less) },
+			func() { parallelMerge(p, cfg, a[i:], b[j+1:], out[i+j+1:], less) },
+		)
+	}
+}
+
+// merge merges the sorted runs a and b into out sequentially, taking equal
+// elements from a first.
+func merge[T any](a, b, out []T, less func(a, b T) bool) {
+	i, j := 0, 0
+	for k := range out {
+		if j == len(b) || (i < len(a) && !less(b[j], a[i])) {
+			out[k] = a[i]
+			i++
+		} else {
+			out[k] = b[j]
+			j++
+		}
+	}
+}
+
+// search returns the first index of the sorted s for which f, false then
+// true along s, is true.
+func search[T any](s []T, f func(T) bool) int {
+	lo, hi := 0, len(s)
+	for lo < hi {
+		m := int(uint(lo+hi) >> 1)
+		if f(s[m]) {
+			hi = m
+		} else {
+			lo = m + 1
+		}
+	}
+	return lo
+}
@@ -0,0 +1,80 @@
+package sort
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"slices"
+	stdsort "sort"
+	"testing"
+)
+
+func TestParallelMerge(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ParallelConfig
+	}{
+		{"defaults", ParallelConfig{}},
+		{"one worker", ParallelConfig{Workers: 1, Cutoff: 2, MergeCutoff: 2}},
+		{"tiny cutoffs", ParallelConfig{Workers: 4, Cutoff: 2, MergeCutoff: 2}},
+		{"more workers than cores", ParallelConfig{Workers: 64, Cutoff: 100, MergeCutoff: 50}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			for _, n := range []int{0, 1, 13, 1000, 20000} {
+				for name, in := range inputs(n) {
+					want := slices.Clone(in)
+					slices.Sort(want)
+					got := slices.Clone(in)
+					ParallelMerge(got, less, tc.cfg)
+					if !slices.Equal(got, want) {
+						t.Fatalf("%s input of %d: not sorted", name, n)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestParallelMerge_Stable(t *testing.T) {
+	type item struct{ key, pos int }
+	rng := rand.New(rand.NewSource(2))
+	in := make([]item, 20000)
+	for i := range in {
+		in[i] = item{rng.Intn(50), i}
+	}
+	got := slices.Clone(in)
+	ParallelMerge(got, func(a, b item) bool { return a.key < b.key }, ParallelConfig{Workers: 8, Cutoff: 64, MergeCutoff: 16})
+	for i := 1; i < len(got); i++ {
+		if got[i].key < got[i-1].key || got[i].key == got[i-1].key && got[i].pos < got[i-1].pos {
+			t.Fatalf("out of order at %d: %v after %v", i, got[i], got[i-1])
+		}
+	}
+}
+
+func BenchmarkParallelMerge(b *testing.B) {
+	b.Logf("GOMAXPROCS %d", runtime.GOMAXPROCS(0))
+	for _, n := range []int{100000, 1000000} {
+		in := inputs(n)["random"]
+		sorts := []struct {
+			name string
+			sort func(s []int)
+		}{
+			{"sort.Slice", func(s []int) { stdsort.Slice(s, func(i, j int) bool { return s[i] < s[j] }) }},
+			{"merge", func(s []int) { Merge(s, less) }},
+			{"parallel", func(s []int) { ParallelMerge(s, less, ParallelConfig{}) }},
+			{"parallel, 2 workers", func(s []int) { ParallelMerge(s, less, ParallelConfig{Workers: 2}) }},
+			{"parallel, sequential merges", func(s []int) { ParallelMerge(s, less, ParallelConfig{MergeCutoff: n + 1}) }},
+		}
+		for _, sort := range sorts {
+			b.Run(fmt.Sprintf("%s/n=%d", sort.name, n), func(b *testing.B) {
+				s := make([]int, n)
+				for i := 0; i < b.N; i++ {
+					copy(s, in)
+					sort.sort(s)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package sort
+
+import "math/bits"
+
+// Sort sorts s by less, not stably. It is Pdq.
+func Sort[T any](s []T, less func(a, b T) bool) {
+	Pdq(s, less)
+}
+
+// Pdq sorts s by pattern-defeating quicksort: quicksort that notices
+// patterns and gives up on bad luck.
+//
+//   - Pivots are medians of three, or of three medians of three on long
+//     slices, and the order of their samples hints at sorted or reversed
+//     input: reversed input is flipped, and sorted input is finished by an
+//     insertion sort that gives up after a few misplaced elements. Sorted,
+//     reversed and nearly sorted input take O(n).
+//   - When the pivot equals the element before the slice, which the
+//     previous partition put at most as large as any in it, the slice holds
+//     many equal elements: they are split off in one pass, so few distinct
+//     keys take O(n·k) for k of them.
+//   - An unbalanced partition shuffles a few elements to break the pattern
+//     behind it, and after log₂ n of them the slice is heapsorted instead:
+//     O(n log n) at worst, even against inputs built to defeat quicksort.
+//
+// Partitioning is branchless: it swaps every element unconditionally and
+// advances the boundary by the result of the comparison, so the loop
+// doesn't mispredict on random data.
+func Pdq[T any](s []T, less func(a, b T) bool) {
+	pdqsort(s, 0, len(s), bits.Len(uint(len(s))), less)
+}
+
+type sortedHint int
+
+const (
+	unknownHint sortedHint = iota
+	increasingHint
+	decreasingHint
+)
+
+// pdqsort sorts s[a:b]. limit is the number of unbalanced partitions left
+// before switching to heapsort.
+func pdqsort[T any](s []T, a, b, limit int, less func(a, b T) bool) {
+	wasBalanced, wasPartitioned := true, true
+	for {
+		n := b - a
+		if n <= smallSort {
+			Insertion(s[a:b], less)
+			return
+		}
+		if limit == 0 {
+			Heap(s[a:b], less)
+			return
+		}
+		if !wasBalanced {
+			breakPatterns(s[a:b])
+			limit--
+		}
+
+		pivot, hint := choosePivot(s[a:b], less)
+		if hint == decreasingHint {
+			reverse(s[a:b])
+			pivot = n - 1 - pivot
+			hint = increasingHint
+		}
+		if wasBalanced && wasPartitioned && hint == increasingHint && partialInsertion(s[a:b], less) {
+			return
+		}
+		// s[a-1] is at most every element of s[a:b]. If it isn't below
+		// the pivot either, the pivot is the smallest element: split off
+		// its equals and carry on with the rest.
+		if a > 0 && !less(s[a-1], s[a+pivot]) {
+			a += partitionEqual(s[a:b], pivot, less)
+			continue
+		}
+
+		mid, already := partitionBranchless(s[a:b], pivot, less)
+		wasPartitioned = already
+		left, right := mid, n-mid-1
+		if left < right {
+			wasBalanced = left >= n/8
+			pdqsort(s, a, a+mid, limit, less)
+			a += mid + 1
+		} else {
+			wasBalanced = right >= n/8
+			pdqsort(s, a+mid+1, b, limit, less)
+			b = a + mid
+		}
+	}
+}
+
+// partitionBranchless splits s around s[pivot] and returns the pivot's new
+// index mid: s[:mid] is below it and s[mid+1:] not. already reports whether
+// s was partitioned already, with nothing to swap.
+func partitionBranchless[T any](s []T, pivot int, less func(a, b T) bool) (mid int, already bool) {
+	s[0], s[pivot] = s[pivot], s[0]
+	p := s[0]
+	i, j := 1, len(s)-1
+	for i <= j && less(s[i], p) {
+		i++
+	}
+	for i <= j && !less(s[j], p) {
+		j--
+	}
+	already = i > j
+	// Lomuto over s[i:j+1]: s[i:k] is below the pivot, s[k:m] not.
+	k := i
+	for m := i; m <= j; m++ {
+		x := s[m]
+		s[m] = s[k]
+		s[k] = x
+		k += b2i(less(x, p))
+	}
+	s[0], s[k-1] = s[k-1], s[0]
+	return k - 1, already
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// partitionEqual moves the elements of s equal to s[pivot], which no
+// element is below, to the front and returns how many there are.
+func partitionEqual[T any](s []T, pivot int, less func(a, b T) bool) int {
+	s[0], s[pivot] = s[pivot], s[0]
+	p := s[0]
+	i, j := 1, len(s)-1
+	for {
+		for i <= j && !less(p, s[i]) {
+			i++
+		}
+		for i <= j && less(p, s[j]) {
+			j--
+		}
+		if i > j {
+			return i
+		}
+		s[i], s[j] = s[j], s[i]
+		i++
+		j--
+	}
+}
+
+// partialInsertion sorts s by insertion if only a few elements are out of
+// place, and reports whether it did.
+func partialInsertion[T any](s []T, less func(a, b T) bool) bool {
+	const (
+		maxSteps         = 5  // misplaced elements to move at most
+		shortestShifting = 50 // shorter slices aren't worth moving any
+	)
+	i := 1
+	for step := 0; step < maxSteps; step++ {
+		for i < len(s) && !less(s[i], s[i-1]) {
+			i++
+		}
+		if i == len(s) {
+			return true
+		}
+		if len(s) < shortestShifting {
+			return false
+		}
+		s[i], s[i-1] = s[i-1], s[i]
+		for j := i - 1; j >= 1 && less(s[j], s[j-1]); j-- {
+			s[j], s[j-1] = s[j-1], s[j]
+		}
+		for j := i + 1; j < len(s) && less(s[j], s[j-1]); j++ {
+			s[j], s[j-1] = s[j-1], s[j]
+		}
+	}
+	return false
+}
+
+// breakPatterns swaps three elements near the middle of s with random
+// others.
+func breakPatterns[T any](s []T) {
+	n := len(s)
+	if n < 8 {
+		return
+	}
+	random := uint64(n) // xorshift, seeded by the length for reproducibility
+	mask := uint64(1)<<bits.Len(uint(n)) - 1
+	idx := n/4*2 - 1
+	for i := 0; i < 3; i++ {
+		random ^= random << 13
+		random ^= random >> 7
+		random ^= random << 17
+		other := int(random & mask)
+		if other >= n {
+			other -= n
+		}
+		s[idx-1+i], s[other] = s[other], s[idx-1+i]
+	}
+}
+
+// choosePivot returns the index of a pivot for s and what sorting its
+// samples took: none hints s is sorted, all that it is reversed.
+func choosePivot[T any](s []T, less func(a, b T) bool) (int, sortedHint) {
+	const (
+		shortestNinther = 50
+		maxSwaps        = 4 * 3
+	)
+	n := len(s)
+	swaps := 0
+	i, j, k := n/4, n/4*2, n/4*3
+	if n >= 8 {
+		if n >= shortestNinther {
+			i = median(s, i-1, i, i+1, &swaps, less)
+			j = median(s, j-1, j, j+1, &swaps, less)
+			k = median(s, k-1, k, k+1, &swaps, less)
+		}
+		j = median(s, i, j, k, &swaps, less)
+	}
+	switch swaps {
+	case 0:
+		return j, increasingHint
+	case maxSwaps:
+		return j, decreasingHint
+	default:
+		return j, unknownHint
+	}
+}
+
+// median returns the index of the median of s[a], s[b] and s[c], counting
+// the out-of-order pairs it meets in swaps.
+func median[T any](s []T, a, b, c int, swaps *int, less func(a, b T) bool) int {
+	order := func(x, y int) (int, int) {
+		if less(s[y], s[x]) {
+			*swaps++
+			return y, x
+		}
+		return x, y
+	}
+	a, b = order(a, b)
+	b, c = order(b, c)
+	_, b = order(a, b)
+	return b
+}
+
+func reverse[T any](s []T) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
@@ -0,0 +1,131 @@
+package sort
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"slices"
+	"testing"
+)
+
+func TestPdq(t *testing.T) {
+	for _, n := range []int{13, 49, 50, 51, 1000, 30000} {
+		for name, in := range partiallyOrdered(n) {
+			want := slices.Clone(in)
+			slices.Sort(want)
+			got := slices.Clone(in)
+			Sort(got, less)
+			if !slices.Equal(got, want) {
+				t.Fatalf("%s input of %d: not sorted", name, n)
+			}
+		}
+	}
+}
+
+func TestPartitionBranchless(t *testing.T) {
+	for name, in := range inputs(100) {
+		for _, pivot := range []int{0, 37, 99} {
+			s := slices.Clone(in)
+			p := s[pivot]
+			mid, _ := partitionBranchless(s, pivot, less)
+			if s[mid] != p || mid > 0 && slices.Max(s[:mid]) >= p || mid < 99 && slices.Min(s[mid+1:]) < p {
+				t.Fatalf("%s, pivot %d: not partitioned around %d at %d", name, pivot, p, mid)
+			}
+		}
+	}
+	s := []int{5, 1, 2, 7, 9, 8}
+	if mid, already := partitionBranchless(s, 0, less); mid != 2 || !already {
+		t.Errorf("partitioned input: mid %d, already %v", mid, already)
+	}
+}
+
+func TestPartitionEqual(t *testing.T) {
+	s := []int{3, 5, 3, 9, 3, 3, 4, 3}
+	n := partitionEqual(s, 2, less)
+	if n != 5 || slices.Max(s[:n]) != 3 || slices.Min(s[n:]) <= 3 {
+		t.Errorf("partitionEqual: %d equal in %v", n, s)
+	}
+}
+
+// killer builds an input that drives sort to its worst case, by McIlroy's
+// adversary: values start as gas, unknown and larger than any decided
+// value, and are frozen, in increasing order, as late as comparisons allow.
+// The values then make a fixed input that sort treats the same way again.
+func killer(n int, sort Func[int]) []int {
+	gas := n
+	val := make([]int, n)
+	idx := make([]int, n)
+	for i := range val {
+		val[i], idx[i] = gas, i
+	}
+	solid, candidate := 0, 0
+	freeze := func(i int) {
+		val[i] = solid
+		solid++
+	}
+	sort(idx, func(x, y int) bool {
+		if val[x] == gas && val[y] == gas {
+			if x == candidate {
+				freeze(x)
+			} else {
+				freeze(y)
+			}
+		}
+		if val[x] == gas {
+			candidate = x
+		} else if val[y] == gas {
+			candidate = y
+		}
+		return val[x] < val[y]
+	})
+	for i, v := range val {
+		if v == gas {
+			val[i] = solid
+			solid++
+		}
+	}
+	return val
+}
+
+func TestPdq_Adversary(t *testing.T) {
+	const n = 10000
+	bound := 4 * n * bits.Len(n) // comparisons a sort that holds up may take
+	for _, name := range []string{"quick", "pdq"} {
+		alg, _ := Lookup[int](name)
+		in := killer(n, alg.Sort)
+		comparisons := 0
+		alg.Sort(slices.Clone(in), func(a, b int) bool {
+			comparisons++
+			return a < b
+		})
+		t.Logf("%s: %d comparisons on its killer input, n·log₂ n = %.0f", name, comparisons, n*math.Log2(n))
+		if holds := comparisons <= bound; holds != (name == "pdq") {
+			t.Errorf("%s: %d comparisons, bound %d", name, comparisons, bound)
+		}
+	}
+}
+
+func BenchmarkPdq(b *testing.B) {
+	const n = 100000
+	algs := []Sorter[int]{
+		NewSorter("pdq", false, Pdq[int]),
+		NewSorter("quick", false, Quick[int]),
+		NewSorter("slices", false, func(s []int, _ func(a, b int) bool) { slices.Sort(s) }),
+	}
+	orders := partiallyOrdered(n)
+	for _, order := range []string{"random", "sorted", "reversed", "few", "organ", "sawtooth"} {
+		for _, alg := range algs {
+			b.Run(fmt.Sprintf("%s/%s", order, alg.Name()), func(b *testing.B) {
+				benchSort(b, orders[order], func(s []int) { alg.Sort(s, less) })
+			})
+		}
+	}
+	// Each sort against the input built to defeat classic quicksort,
+	// shorter as it takes quicksort quadratic time.
+	killerInput := killer(n/10, Quick[int])
+	for _, alg := range algs {
+		b.Run("quicksort killer/"+alg.Name(), func(b *testing.B) {
+			benchSort(b, killerInput, func(s []int) { alg.Sort(s, less) })
+		})
+	}
+}
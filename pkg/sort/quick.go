@@ -0,0 +1,54 @@
+package sort
+
+// Quick sorts s by quicksort with median-of-three pivots. It recurses into
+// the smaller side of every partition and loops on the larger, so the stack
+// stays O(log n) deep.
+func Quick[T any](s []T, less func(a, b T) bool) {
+	for len(s) > smallSort {
+		p := partition(s, less)
+		if p < len(s)-p {
+			Quick(s[:p], less)
+			s = s[p:]
+		} else {
+			Quick(s[p:], less)
+			s = s[:p]
+		}
+	}
+	Insertion(s, less)
+}
+
+// partition splits s, of at least 3 elements, around the median of its
+// first, middle and last elements. It returns p in [1, len(s)) such that
+// every element of s[:p] is at most every element of s[p:].
+func partition[T any](s []T, less func(a, b T) bool) int {
+	lo, mid, hi := 0, len(s)/2, len(s)-1
+	if less(s[mid], s[lo]) {
+		s[mid], s[lo] = s[lo], s[mid]
+	}
+	if less(s[hi], s[mid]) {
+		s[hi], s[mid] = s[mid], s[hi]
+		if less(s[mid], s[lo]) {
+			s[mid], s[lo] = s[lo], s[mid]
+		}
+	}
+	s[lo], s[mid] = s[mid], s[lo]
+	return hoare(s, less)
+}
+
+// hoare splits s, of at least 2 elements, around its first element, Hoare
+// style, and returns p in [1, len(s)) such that every element of s[:p] is at
+// most every element of s[p:].
+func hoare[T any](s []T, less func(a, b T) bool) int {
+	pivot := s[0]
+	i, j := -1, len(s)
+	for {
+		for i++; less(s[i], pivot); i++ {
+		}
+		for j--; less(pivot, s[j]); j-- {
+		}
+		if i >= j {
+			return j + 1
+		}
+		s[i], s[j] = s[j], s[i]
+	}
+}
@@ -0,0 +1,149 @@
+package sort
+
+import (
+	"math"
+	"unsafe"
+)
+
+// Integer is the set of integer types RadixInts sorts.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Keyed is implemented by types RadixKeyed sorts: by an unsigned key, in
+// ascending order. IntKey and FloatKey turn signed and floating-point keys
+// into unsigned ones of the same order.
+type Keyed interface {
+	RadixKey() uint64
+}
+
+// IntKey maps v to an unsigned key in the same order.
+func IntKey(v int64) uint64 { return uint64(v) ^ 1<<63 }
+
+// FloatKey maps v to an unsigned key in the same order, with -0 before +0
+// and NaNs at both ends by sign.
+func FloatKey(v float64) uint64 {
+	b := math.Float64bits(v)
+	if b&(1<<63) != 0 {
+		return ^b
+	}
+	return b | 1<<63
+}
+
+// RadixInts sorts s by least significant digit radix sort, one byte per
+// pass: O(n) for a fixed width. Passes where all elements share the byte are
+// skipped, so small values in wide types cost little.
+func RadixInts[T Integer](s []T) {
+	var zero T
+	width := int(unsafe.Sizeof(zero))
+	var flip uint64
+	if zero-1 < zero { // signed
+		flip = 1 << (8*width - 1)
+	}
+	keys := make([]uint64, len(s))
+	for i, v := range s {
+		// Conversion sign extends, so mask to the width before flipping
+		// the sign bit.
+		keys[i] = uint64(v)&(math.MaxUint64>>(64-8*width)) ^ flip
+	}
+	lsd(s, keys, width)
+}
+
+// RadixBy sorts s stably by key, by least significant digit radix sort:
+// O(n) for any element type, calling key once per element.
+func RadixBy[T any](s []T, key func(T) uint64) {
+	keys := make([]uint64, len(s))
+	for i, v := range s {
+		keys[i] = key(v)
+	}
+	lsd(s, keys, 8)
+}
+
+// RadixKeyed sorts s stably by RadixKey.
+func RadixKeyed[T Keyed](s []T) {
+	RadixBy(s, T.RadixKey)
+}
+
+// lsd sorts s by keys, of width bytes, moving both together.
+func lsd[T any](s []T, keys []uint64, width int) {
+	if len(s) < 2 {
+		return
+	}
+	var counts [8][256]int
+	for _, k := range keys {
+		for d := 0; d < width; d++ {
+			counts[d][byte(k>>(8*d))]++
+		}
+	}
+	src, dst := s, make([]T, len(s))
+	srcKeys, dstKeys := keys, make([]uint64, len(s))
+	for d := 0; d < width; d++ {
+		c := &counts[d]
+		if c[byte(srcKeys[0]>>(8*d))] == len(s) {
+			continue // every key has the same byte here
+		}
+		var offsets [256]int
+		sum := 0
+		for b, n := range c {
+			offsets[b] = sum
+			sum += n
+		}
+		for i, k := range srcKeys {
+			b := byte(k >> (8 * d))
+			dst[offsets[b]] = src[i]
+			dstKeys[offsets[b]] = k
+			offsets[b]++
+		}
+		src, dst = dst, src
+		srcKeys, dstKeys = dstKeys, srcKeys
+	}
+	if &src[0] != &s[0] {
+		copy(s, src)
+	}
+}
+
+// RadixStrings sorts s by most significant digit radix sort: strings are
+// bucketed by their first byte, each bucket by the second byte, and so on,
+// so the cost is the number of bytes needed to tell the strings apart.
+func RadixStrings[S ~string](s []S) {
+	msd(s, make([]S, len(s)), 0)
+}
+
+// msd sorts s, whose strings share their first depth bytes, using buf as
+// scratch space.
+func msd[S ~string](s, buf []S, depth int) {
+	if len(s) <= smallSort {
+		Insertion(s, func(a, b S) bool { return a[depth:] < b[depth:] })
+		return
+	}
+	// Bucket 0 holds the strings that end at depth, bucket b+1 byte b.
+	var counts [257]int
+	for _, v := range s {
+		counts[bucket(v, depth)]++
+	}
+	var offsets [258]int
+	for b, n := range counts {
+		offsets[b+1] = offsets[b] + n
+	}
+	next := offsets
+	for _, v := range s {
+		b := bucket(v, depth)
+		buf[next[b]] = v
+		next[b]++
+	}
+	copy(s, buf)
+	for b := 1; b < 257; b++ {
+		lo, hi := offsets[b], offsets[b+1]
+		if hi-lo > 1 {
+			msd(s[lo:hi], buf[lo:hi], depth+1)
+		}
+	}
+}
+
+func bucket[S ~string](v S, depth int) int {
+	if depth >= len(v) {
+		return 0
+	}
+	return int(v[depth]) + 1
+}
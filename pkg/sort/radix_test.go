@@ -0,0 +1,148 @@
+package sort
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	stdsort "sort"
+	"strings"
+	"testing"
+)
+
+func randomInts[T Integer](rng *rand.Rand, n int) []T {
+	s := make([]T, n)
+	for i := range s {
+		s[i] = T(rng.Uint64())
+	}
+	return s
+}
+
+func checkRadixInts[T Integer](t *testing.T, name string, s []T) {
+	t.Helper()
+	want := slices.Clone(s)
+	slices.Sort(want)
+	RadixInts(s)
+	if !slices.Equal(s, want) {
+		t.Errorf("%s: got %v, want %v", name, s, want)
+	}
+}
+
+func TestRadixInts(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 1, 2, 100, 5000} {
+		checkRadixInts(t, fmt.Sprint("int8/", n), randomInts[int8](rng, n))
+		checkRadixInts(t, fmt.Sprint("int16/", n), randomInts[int16](rng, n))
+		checkRadixInts(t, fmt.Sprint("int32/", n), randomInts[int32](rng, n))
+		checkRadixInts(t, fmt.Sprint("int/", n), randomInts[int](rng, n))
+		checkRadixInts(t, fmt.Sprint("uint8/", n), randomInts[uint8](rng, n))
+		checkRadixInts(t, fmt.Sprint("uint32/", n), randomInts[uint32](rng, n))
+		checkRadixInts(t, fmt.Sprint("uint64/", n), randomInts[uint64](rng, n))
+	}
+	type celsius int16
+	checkRadixInts(t, "named", []celsius{3, -40, 0, 100, -273, 37})
+	checkRadixInts(t, "extremes", []int64{math.MaxInt64, -1, math.MinInt64, 0, 1, math.MinInt64 + 1})
+	checkRadixInts(t, "small values in a wide type", []uint64{5, 3, 255, 0, 9, 3})
+}
+
+type person struct {
+	name   string
+	height float64
+	age    int
+}
+
+func (p person) RadixKey() uint64 { return IntKey(int64(p.age)) }
+
+func TestRadixKeyed_Stable(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	people := make([]person, 1000)
+	for i := range people {
+		people[i] = person{name: fmt.Sprint("p", i), age: rng.Intn(200) - 100}
+	}
+	want := slices.Clone(people)
+	slices.SortStableFunc(want, func(a, b person) int { return cmp.Compare(a.age, b.age) })
+	RadixKeyed(people)
+	if !slices.Equal(people, want) {
+		t.Error("RadixKeyed differs from a stable sort by age")
+	}
+}
+
+func TestRadixBy_FloatKey(t *testing.T) {
+	values := []float64{3.5, math.Copysign(0, -1), math.Inf(-1), 0, -2.25, 1e-300, math.Inf(1), -1e300, 42}
+	people := make([]person, len(values))
+	for i, v := range values {
+		people[i].height = v
+	}
+	RadixBy(people, func(p person) uint64 { return FloatKey(p.height) })
+	for i := 1; i < len(people); i++ {
+		if people[i].height < people[i-1].height {
+			t.Fatalf("%v before %v", people[i-1].height, people[i].height)
+		}
+	}
+	if !math.Signbit(people[3].height) || math.Signbit(people[4].height) {
+		t.Errorf("want -0 before +0, got %v, %v", people[3].height, people[4].height)
+	}
+}
+
+func TestRadixStrings(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	random := make([]string, 3000)
+	for i := range random {
+		b := make([]byte, rng.Intn(12))
+		for j := range b {
+			b[j] = "abc\x00\xff"[rng.Intn(5)]
+		}
+		random[i] = string(b)
+	}
+	prefixes := make([]string, 500)
+	for i := range prefixes {
+		prefixes[i] = strings.Repeat("x", rng.Intn(40))
+	}
+	tests := []struct {
+		name string
+		in   []string
+	}{
+		{"empty", nil},
+		{"one", []string{"a"}},
+		{"prefixes", prefixes},
+		{"random bytes", random},
+		{"words", strings.Fields("the quick brown fox jumps over the lazy dog and then the dog sleeps while the fox runs off into the woods")},
+	}
+	for _, tc := range tests {
+		want := slices.Clone(tc.in)
+		slices.Sort(want)
+		got := slices.Clone(tc.in)
+		RadixStrings(got)
+		if !slices.Equal(got, want) {
+			t.Errorf("%s: got %q", tc.name, got)
+		}
+	}
+}
+
+func BenchmarkRadix(b *testing.B) {
+	const n = 1000000
+	rng := rand.New(rand.NewSource(4))
+	ints := randomInts[uint64](rng, n)
+	small := make([]uint64, n)
+	for i := range small {
+		small[i] = uint64(rng.Intn(1 << 16))
+	}
+	strs := make([]string, n/10)
+	for i := range strs {
+		strs[i] = fmt.Sprintf("user-%08d", rng.Intn(n))
+	}
+	b.Run("uint64/radix", func(b *testing.B) { benchSort(b, ints, RadixInts[uint64]) })
+	b.Run("uint64/slices", func(b *testing.B) { benchSort(b, ints, slices.Sort[[]uint64]) })
+	b.Run("uint64 below 2^16/radix", func(b *testing.B) { benchSort(b, small, RadixInts[uint64]) })
+	b.Run("strings/radix", func(b *testing.B) { benchSort(b, strs, RadixStrings[string]) })
+	b.Run("strings/sort.Strings", func(b *testing.B) { benchSort(b, strs, stdsort.Strings) })
+}
+
+func benchSort[T any](b *testing.B, in []T, sort func([]T)) {
+	s := make([]T, len(in))
+	for i := 0; i < b.N; i++ {
+		copy(s, in)
+		sort(s)
+	}
+}
@@ -0,0 +1,73 @@
+package sort
+
+import "math/bits"
+
+// SelectK reorders s so that s[k] holds the element a sort would put there,
+// with none larger before it and none smaller after it, in O(n) time
+// without sorting. k must be in [0, len(s)).
+//
+// It is introselect: quickselect with median-of-three pivots, expected
+// O(n), which switches to median-of-medians pivots, O(n) at worst, once it
+// has partitioned about 2·log₂ n times without finishing.
+func SelectK[T any](s []T, k int, less func(a, b T) bool) {
+	if k < 0 || k >= len(s) {
+		panic("sort: SelectK index out of range")
+	}
+	introselect(s, k, 2*bits.Len(uint(len(s))), less)
+}
+
+// introselect is SelectK with budget median-of-three partitions.
+func introselect[T any](s []T, k, budget int, less func(a, b T) bool) {
+	for len(s) > smallSort {
+		var p int
+		if budget > 0 {
+			budget--
+			p = partition(s, less)
+		} else {
+			m := medianOfMedians(s, less)
+			s[0], s[m] = s[m], s[0]
+			p = hoare(s, less)
+		}
+		if k < p {
+			s = s[:p]
+		} else {
+			s, k = s[p:], k-p
+		}
+	}
+	Insertion(s, less)
+}
+
+// medianOfMedians returns the index of an element of s, of more than 5
+// elements, with at least about 3/10 of s on either side: the median of the
+// medians of groups of 5. It reorders s.
+func medianOfMedians[T any](s []T, less func(a, b T) bool) int {
+	groups := 0
+	for i := 0; i+5 <= len(s); i += 5 {
+		g := s[i : i+5]
+		Insertion(g, less)
+		s[groups], g[2] = g[2], s[groups]
+		groups++
+	}
+	SelectK(s[:groups], groups/2, less)
+	return groups / 2
+}
+
+// Smallest returns the k smallest elements of s in ascending order, in
+// O(n + k log k) time. s is left alone; k is capped at len(s).
+func Smallest[T any](s []T, k int, less func(a, b T) bool) []T {
+	k = min(max(k, 0), len(s))
+	if k == 0 {
+		return nil
+	}
+	c := append([]T(nil), s...)
+	SelectK(c, k-1, less)
+	c = c[:k]
+	Quick(c, less)
+	return c
+}
+
+// Largest returns the k largest elements of s in descending order, in
+// O(n + k log k) time. s is left alone; k is capped at len(s).
+func Largest[T any](s []T, k int, less func(a, b T) bool) []T {
+	return Smallest(s, k, func(a, b T) bool { return less(b, a) })
+}
@@ -0,0 +1,113 @@
+package sort
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestSelectK(t *testing.T) {
+	for _, n := range []int{1, 2, 13, 100, 2000} {
+		for name, in := range inputs(n) {
+			sorted := slices.Clone(in)
+			slices.Sort(sorted)
+			check := func(k int, s []int, how string) {
+				if s[k] != sorted[k] {
+					t.Fatalf("%s, %s input of %d, k %d: got %d, want %d", how, name, n, k, s[k], sorted[k])
+				}
+				if k > 0 && slices.Max(s[:k]) > s[k] || k < n-1 && slices.Min(s[k+1:]) < s[k] {
+					t.Fatalf("%s, %s input of %d, k %d: not split around s[k]", how, name, n, k)
+				}
+			}
+			for _, k := range []int{0, n / 3, n / 2, n - 1} {
+				s := slices.Clone(in)
+				SelectK(s, k, less)
+				check(k, s, "SelectK")
+				s = slices.Clone(in)
+				introselect(s, k, 0, less)
+				check(k, s, "median of medians only")
+			}
+		}
+	}
+}
+
+// TestSelectK_MedianOfMedians forces the fallback on every partition.
+func TestSelectK_MedianOfMedians(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{6, 25, 1001} {
+		for trial := 0; trial < 20; trial++ {
+			s := randomInts[int](rng, n)
+			for i := range s {
+				s[i] %= 50
+			}
+			m := medianOfMedians(s, less)
+			below, above := 0, 0
+			for _, v := range s {
+				if v < s[m] {
+					below++
+				} else if v > s[m] {
+					above++
+				}
+			}
+			// At least 3 of every 10 elements are on either side of the
+			// pivot, ties aside.
+			if n >= 25 && (below > 7*n/10+2 || above > 7*n/10+2) {
+				t.Fatalf("n %d: pivot %d has %d below and %d above", n, s[m], below, above)
+			}
+		}
+	}
+}
+
+func TestSmallestLargest(t *testing.T) {
+	in := []int{5, 1, 9, 3, 7, 3, 8}
+	tests := []struct {
+		name string
+		got  []int
+		want []int
+	}{
+		{"smallest 3", Smallest(in, 3, less), []int{1, 3, 3}},
+		{"largest 2", Largest(in, 2, less), []int{9, 8}},
+		{"all", Smallest(in, 10, less), []int{1, 3, 3, 5, 7, 8, 9}},
+		{"none", Largest(in, 0, less), nil},
+	}
+	for _, tc := range tests {
+		if !slices.Equal(tc.got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, tc.got, tc.want)
+		}
+	}
+	if !slices.Equal(in, []int{5, 1, 9, 3, 7, 3, 8}) {
+		t.Errorf("input changed to %v", in)
+	}
+}
+
+func TestSelectK_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for k out of range")
+		}
+	}()
+	SelectK([]int{1, 2}, 2, less)
+}
+
+func BenchmarkSelect(b *testing.B) {
+	const n, k = 1000000, 100
+	in := inputs(n)["random"]
+	b.Run("SelectK median", func(b *testing.B) {
+		benchSort(b, in, func(s []int) { SelectK(s, n/2, less) })
+	})
+	b.Run("Largest", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Largest(in, k, less)
+		}
+	})
+	b.Run("TopK", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			top := NewTopK(k, less)
+			for _, v := range in {
+				top.Push(v)
+			}
+			top.Sorted()
+		}
+	})
+	b.Run("full sort", func(b *testing.B) { benchSort(b, in, slices.Sort[[]int]) })
+}
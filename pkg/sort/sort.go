@@ -0,0 +1,138 @@
+// Package sort implements the classic comparison sorts over slices of any
+// type, ordered by a less function:
+//
+//   - Insertion: O(n²), but the fastest on short or nearly sorted slices;
+//     stable.
+//   - Cycle: O(n²), but writes every element at most once, straight to its
+//     place, for memories where writes are costly.
+//   - Pancake: O(n²), sorting by prefix reversals only.
+//   - Merge: O(n log n) always, with an n-element buffer; stable.
+//   - Quick: O(n log n) expected, in place; median-of-three pivots avoid the
+//     quadratic case on sorted and reversed input.
+//   - Heap: O(n log n) always, in place, but with poor locality.
+//   - Pdq: pattern-defeating quicksort, O(n) on sorted, reversed and
+//     nearly sorted input and O(n log n) at worst; the default, Sort.
+//   - Tim: Timsort, merging the runs already in the input with galloping
+//     merges; close to O(n) on nearly sorted data, O(n log n) at worst;
+//     stable.
+//   - ParallelMerge: Merge on a pool of goroutines, sorting and merging
+//     both halves of long slices in parallel.
+//
+// less must be a strict weak order, as for the standard library.
+//
+// The radix sorts don't compare: RadixInts, RadixBy and RadixKeyed sort by
+// fixed-width integer keys in O(n), RadixStrings by the bytes of strings.
+//
+// Order composes comparators on several keys, as in
+// By(dept).ThenBy(Key(salary)).Desc(), and sorts by them stably.
+//
+// Wiggle arranges rather than sorts: it alternates smaller and larger
+// elements in one pass.
+//
+// All lists the comparison sorts as Sorters, for tools that run or
+// benchmark them all.
+//
+// SelectK, Smallest and Largest pick elements by rank without a full sort;
+// TopK keeps the largest elements of a stream.
+//
+// References:
+//
+// Knuth, The Art of Computer Programming, Volume 3: Sorting and Searching,
+// 2nd edition, 1998.
+//
+// Cormen, Leiserson, Rivest and Stein, Introduction to Algorithms, 3rd
+// edition, 2009, chapter 27: Multithreaded Algorithms.
+//
+// McIlroy, Bostic and McIlroy, Engineering Radix Sort, Computing Systems
+// 6(1), 1993.
+//
+// O. Peters, Pattern-defeating Quicksort, arXiv:2106.05123, 2021.
+//
+// McIlroy, A Killer Adversary for Quicksort, Software: Practice and
+// Experience 29(4), 1999.
+//
+// T. Peters, listsort.txt, CPython, 2002.
+//
+// de Gouw, Rot, de Boer, Bubel and Hähnle, OpenJDK's java.utils.Collection.sort()
+// is broken: The good, the bad and the worst case, CAV 2015.
+//
+// Blum, Floyd, Pratt, Rivest and Tarjan, Time Bounds for Selection, Journal
+// of Computer and System Sciences 7(4), 1973.
+//
+// Musser, Introspective Sorting and Selection Algorithms, Software: Practice
+// and Experience 27(8), 1997.
+//
+// Haddon, Cycle-Sort: A Linear Sorting Method, The Computer Journal 33(4),
+// 1990.
+//
+// Gates and Papadimitriou, Bounds for Sorting by Prefix Reversal, Discrete
+// Mathematics 27(1), 1979.
+//
+// Sedgewick, Implementing Quicksort Programs, Communications of the ACM
+// 21(10), 1978.
+package sort
+
+// Func is a sorting algorithm.
+type Func[T any] func(s []T, less func(a, b T) bool)
+
+// Sorter is a named sorting algorithm, as listed by All.
+type Sorter[T any] interface {
+	Name() string
+	// Stable reports whether equal elements keep their order.
+	Stable() bool
+	Sort(s []T, less func(a, b T) bool)
+}
+
+// NewSorter returns sort as a Sorter, to compare other sorts with those of
+// the package.
+func NewSorter[T any](name string, stable bool, sort Func[T]) Sorter[T] {
+	return sorter[T]{name, stable, sort}
+}
+
+type sorter[T any] struct {
+	name   string
+	stable bool
+	sort   Func[T]
+}
+
+func (s sorter[T]) Name() string                       { return s.name }
+func (s sorter[T]) Stable() bool                       { return s.stable }
+func (s sorter[T]) Sort(x []T, less func(a, b T) bool) { s.sort(x, less) }
+
+// All returns every comparison sort of the package for element type T,
+// roughly from the slowest to the fastest on random input. The radix sorts
+// order by keys instead of by less, so they aren't Sorters.
+func All[T any]() []Sorter[T] {
+	return []Sorter[T]{
+		NewSorter("cycle", false, Cycle[T]),
+		NewSorter("pancake", false, Pancake[T]),
+		NewSorter("insertion", true, Insertion[T]),
+		NewSorter("heap", false, Heap[T]),
+		NewSorter("merge", true, Merge[T]),
+		NewSorter("half-buffer merge", true, func(s []T, less func(a, b T) bool) {
+			SortStableFunc(s, func(a, b T) int {
+				if less(a, b) {
+					return -1
+				}
+				return b2i(less(b, a))
+			})
+		}),
+		NewSorter("tim", true, Tim[T]),
+		NewSorter("quick", false, Quick[T]),
+		NewSorter("pdq", false, Pdq[T]),
+		NewSorter("parallel merge", true, func(s []T, less func(a, b T) bool) { ParallelMerge(s, less, ParallelConfig{}) }),
+	}
+}
+
+// Lookup returns the sorter of All named name.
+func Lookup[T any](name string) (Sorter[T], bool) {
+	for _, s := range All[T]() {
+		if s.Name() == name {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// smallSort is the length below which Merge and Quick finish with Insertion.
+const smallSort = 12
@@ -0,0 +1,119 @@
+package sort
+
+import (
+	"cmp"
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func less(a, b int) bool { return a < b }
+
+// inputs returns slices of n elements in the orders that trip sorts up.
+func inputs(n int) map[string][]int {
+	rng := rand.New(rand.NewSource(int64(n)))
+	in := map[string][]int{
+		"random":   make([]int, n),
+		"sorted":   make([]int, n),
+		"reversed": make([]int, n),
+		"equal":    make([]int, n),
+		"few":      make([]int, n),
+		"organ":    make([]int, n),
+		"nearly":   make([]int, n),
+	}
+	for i := 0; i < n; i++ {
+		in["random"][i] = rng.Int()
+		in["sorted"][i] = i
+		in["reversed"][i] = n - i
+		in["equal"][i] = 7
+		in["few"][i] = rng.Intn(4)
+		in["organ"][i] = min(i, n-i)
+		in["nearly"][i] = i
+	}
+	for k := 0; k < n/20; k++ {
+		i, j := rng.Intn(n), rng.Intn(n)
+		in["nearly"][i], in["nearly"][j] = in["nearly"][j], in["nearly"][i]
+	}
+	return in
+}
+
+func TestSorts(t *testing.T) {
+	for _, alg := range All[int]() {
+		alg := alg
+		t.Run(alg.Name(), func(t *testing.T) {
+			for _, n := range []int{0, 1, 2, 3, 12, 13, 100, 1000} {
+				for name, in := range inputs(n) {
+					want := slices.Clone(in)
+					slices.Sort(want)
+					got := slices.Clone(in)
+					alg.Sort(got, less)
+					if !slices.Equal(got, want) {
+						t.Fatalf("%s input of %d: got %v", name, n, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSorts_Stable(t *testing.T) {
+	type item struct{ key, pos int }
+	rng := rand.New(rand.NewSource(1))
+	in := make([]item, 500)
+	for i := range in {
+		in[i] = item{rng.Intn(10), i}
+	}
+	byKey := func(a, b item) bool { return a.key < b.key }
+	for _, alg := range All[item]() {
+		if !alg.Stable() {
+			continue
+		}
+		got := slices.Clone(in)
+		alg.Sort(got, byKey)
+		for i := 1; i < len(got); i++ {
+			if got[i].key == got[i-1].key && got[i].pos < got[i-1].pos {
+				t.Fatalf("%s: equal keys out of input order at %d", alg.Name(), i)
+			}
+		}
+	}
+}
+
+func TestPartition(t *testing.T) {
+	for name, in := range inputs(50) {
+		s := slices.Clone(in)
+		p := partition(s, less)
+		if p < 1 || p >= len(s) {
+			t.Fatalf("%s: split %d outside [1, %d)", name, p, len(s))
+		}
+		if slices.Max(s[:p]) > slices.Min(s[p:]) {
+			t.Errorf("%s: left half has %d above %d in the right half", name, slices.Max(s[:p]), slices.Min(s[p:]))
+		}
+	}
+}
+
+// quadratic lists the sorts too slow to benchmark on long slices.
+var quadratic = map[string]bool{"insertion": true, "cycle": true, "pancake": true}
+
+func BenchmarkSorts(b *testing.B) {
+	algs := append(All[int](), NewSorter("slices", false, func(s []int, less func(a, b int) bool) {
+		slices.SortFunc(s, cmp.Compare[int])
+	}))
+	for _, n := range []int{16, 1000, 100000} {
+		for _, order := range []string{"random", "sorted", "few"} {
+			in := inputs(n)[order]
+			for _, alg := range algs {
+				if quadratic[alg.Name()] && n > 1000 {
+					continue
+				}
+				b.Run(fmt.Sprintf("%s/n=%d/%s", alg.Name(), n, order), func(b *testing.B) {
+					s := make([]int, n)
+					for i := 0; i < b.N; i++ {
+						copy(s, in)
+						alg.Sort(s, less)
+					}
+				})
+			}
+		}
+	}
+}
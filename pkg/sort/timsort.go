@@ -0,0 +1,310 @@
+package sort
+
+// Tim sorts s by Timsort, stably: it finds the runs already in s, ascending
+// or strictly descending (which it reverses), extends short runs to a
+// minimum length by binary insertion, and merges them in an order that
+// keeps the merges balanced. Merges gallop: when one run keeps winning,
+// they search ahead exponentially instead of comparing element by element.
+// On data made of a few sorted stretches it takes close to O(n); it is
+// O(n log n) at worst.
+func Tim[T any](s []T, less func(a, b T) bool) {
+	n := len(s)
+	if n < 2 {
+		return
+	}
+	if n < timMinMerge {
+		binaryInsertion(s, countRun(s, less), less)
+		return
+	}
+	ts := &timSort[T]{s: s, less: less, minGallop: timMinGallop}
+	minRun := minRunLength(n)
+	for lo := 0; lo < n; {
+		r := countRun(s[lo:], less)
+		if r < minRun {
+			force := min(n-lo, minRun)
+			binaryInsertion(s[lo:lo+force], r, less)
+			r = force
+		}
+		ts.runs = append(ts.runs, timRun{lo, r})
+		ts.mergeCollapse()
+		lo += r
+	}
+	ts.mergeForceCollapse()
+}
+
+const (
+	timMinMerge  = 32 // shorter slices are sorted by binary insertion
+	timMinGallop = 7  // initial wins in a row that start galloping
+)
+
+type timRun struct{ base, len int }
+
+type timSort[T any] struct {
+	s         []T
+	less      func(a, b T) bool
+	minGallop int
+	tmp       []T
+	runs      []timRun
+}
+
+// minRunLength returns the minimum run length for n elements: between
+// timMinMerge/2 and timMinMerge, such that n/minRun is a power of two or
+// just below one, so the final merges are balanced.
+func minRunLength(n int) int {
+	r := 0
+	for n >= timMinMerge {
+		r |= n & 1
+		n >>= 1
+	}
+	return n + r
+}
+
+// countRun returns the length of the run at the start of s, reversing it if
+// it is strictly descending. Strictness keeps the reversal stable.
+func countRun[T any](s []T, less func(a, b T) bool) int {
+	if len(s) < 2 {
+		return len(s)
+	}
+	i := 2
+	if less(s[1], s[0]) {
+		for i < len(s) && less(s[i], s[i-1]) {
+			i++
+		}
+		for a, b := 0, i-1; a < b; a, b = a+1, b-1 {
+			s[a], s[b] = s[b], s[a]
+		}
+		return i
+	}
+	for i < len(s) && !less(s[i], s[i-1]) {
+		i++
+	}
+	return i
+}
+
+// binaryInsertion sorts s, whose first sorted elements are sorted, by
+// inserting the others after a binary search: few comparisons, and moves as
+// fast as copy.
+func binaryInsertion[T any](s []T, sorted int, less func(a, b T) bool) {
+	for i := max(sorted, 1); i < len(s); i++ {
+		pivot := s[i]
+		lo, hi := 0, i
+		for lo < hi {
+			mid := int(uint(lo+hi) >> 1)
+			if less(pivot, s[mid]) {
+				hi = mid
+			} else {
+				lo = mid + 1
+			}
+		}
+		copy(s[lo+1:i+1], s[lo:i])
+		s[lo] = pivot
+	}
+}
+
+// mergeCollapse merges runs until, for the lengths A, B, C, D of the top
+// four runs from the bottom, B > C + D, A > B + C and C > D: run lengths
+// then grow at least as fast as the Fibonacci numbers, so the stack stays
+// O(log n) deep and merges stay balanced. Checking A as well fixes the flaw
+// de Gouw et al. found in the original invariant.
+func (ts *timSort[T]) mergeCollapse() {
+	for len(ts.runs) > 1 {
+		r := ts.runs
+		n := len(r) - 2
+		if n > 0 && r[n-1].len <= r[n].len+r[n+1].len || n > 1 && r[n-2].len <= r[n-1].len+r[n].len {
+			if r[n-1].len < r[n+1].len {
+				n--
+			}
+		} else if r[n].len > r[n+1].len {
+			return
+		}
+		ts.mergeAt(n)
+	}
+}
+
+// mergeForceCollapse merges all runs, at the end.
+func (ts *timSort[T]) mergeForceCollapse() {
+	for len(ts.runs) > 1 {
+		n := len(ts.runs) - 2
+		if n > 0 && ts.runs[n-1].len < ts.runs[n+1].len {
+			n--
+		}
+		ts.mergeAt(n)
+	}
+}
+
+// mergeAt merges runs i and i+1 of the stack.
+func (ts *timSort[T]) mergeAt(i int) {
+	s, less := ts.s, ts.less
+	base1, len1 := ts.runs[i].base, ts.runs[i].len
+	base2, len2 := ts.runs[i+1].base, ts.runs[i+1].len
+	ts.runs[i].len = len1 + len2
+	ts.runs = append(ts.runs[:i+1], ts.runs[i+2:]...)
+
+	// Elements of run 1 not above the first of run 2 are in place already,
+	// and so are elements of run 2 not below the last of run 1.
+	first2 := s[base2]
+	k := gallop(s[base1:base1+len1], 0, func(x T) bool { return less(first2, x) })
+	base1 += k
+	len1 -= k
+	if len1 == 0 {
+		return
+	}
+	last1 := s[base1+len1-1]
+	len2 = gallop(s[base2:base2+len2], len2-1, func(x T) bool { return !less(x, last1) })
+	if len2 == 0 {
+		return
+	}
+	if len1 <= len2 {
+		ts.mergeLo(base1, len1, base2, len2)
+	} else {
+		ts.mergeHi(base1, len1, base2, len2)
+	}
+}
+
+// gallop returns the first index of a for which after, false then true
+// along a, is true, searching out from hint in steps of 1, 3, 7, ... before
+// a binary search: O(log d) comparisons for an answer d away from hint.
+func gallop[T any](a []T, hint int, after func(T) bool) int {
+	var lo, hi int
+	if after(a[hint]) {
+		last, ofs := 0, 1 // after(a[hint-last]) holds
+		for ofs <= hint && after(a[hint-ofs]) {
+			last, ofs = ofs, 2*ofs+1
+		}
+		lo, hi = max(hint-ofs+1, 0), hint-last
+	} else {
+		last, ofs := 0, 1 // !after(a[hint+last])
+		for hint+ofs < len(a) && !after(a[hint+ofs]) {
+			last, ofs = ofs, 2*ofs+1
+		}
+		lo, hi = hint+last+1, min(hint+ofs, len(a))
+	}
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if after(a[mid]) {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo
+}
+
+func (ts *timSort[T]) buffer(n int) []T {
+	if cap(ts.tmp) < n {
+		ts.tmp = make([]T, n, max(n, 2*cap(ts.tmp)))
+	}
+	return ts.tmp[:n]
+}
+
+// mergeLo merges the adjacent runs s[base1:base1+len1] and s[base2:
+// base2+len2], len1 <= len2, moving run 1 aside and merging from the left.
+// Ties go to run 1.
+func (ts *timSort[T]) mergeLo(base1, len1, base2, len2 int) {
+	s, less := ts.s, ts.less
+	tmp := ts.buffer(len1)
+	copy(tmp, s[base1:base1+len1])
+	c1, c2, d, end2 := 0, base2, base1, base2+len2
+	minGallop := ts.minGallop
+outer:
+	for {
+		wins1, wins2 := 0, 0
+		for max(wins1, wins2) < minGallop {
+			if less(s[c2], tmp[c1]) {
+				s[d] = s[c2]
+				c2++
+				wins1, wins2 = 0, wins2+1
+			} else {
+				s[d] = tmp[c1]
+				c1++
+				wins1, wins2 = wins1+1, 0
+			}
+			d++
+			if c1 == len1 || c2 == end2 {
+				break outer
+			}
+		}
+		for {
+			minGallop = max(minGallop-1, 1)
+			key2 := s[c2]
+			k1 := gallop(tmp[c1:len1], 0, func(x T) bool { return less(key2, x) })
+			copy(s[d:], tmp[c1:c1+k1])
+			d, c1 = d+k1, c1+k1
+			if c1 == len1 {
+				break outer
+			}
+			key1 := tmp[c1]
+			k2 := gallop(s[c2:end2], 0, func(x T) bool { return !less(x, key1) })
+			copy(s[d:], s[c2:c2+k2])
+			d, c2 = d+k2, c2+k2
+			if c2 == end2 {
+				break outer
+			}
+			if k1 < timMinGallop && k2 < timMinGallop {
+				minGallop += 2 // galloping didn't pay off
+				break
+			}
+		}
+	}
+	// What is left of run 2 is in place.
+	copy(s[d:], tmp[c1:len1])
+	ts.minGallop = minGallop
+}
+
+// mergeHi merges like mergeLo, for len1 > len2, moving run 2 aside and
+// merging from the right.
+func (ts *timSort[T]) mergeHi(base1, len1, base2, len2 int) {
+	s, less := ts.s, ts.less
+	tmp := ts.buffer(len2)
+	copy(tmp, s[base2:base2+len2])
+	c1, c2, d := base1+len1-1, len2-1, base2+len2-1
+	minGallop := ts.minGallop
+outer:
+	for {
+		wins1, wins2 := 0, 0
+		for max(wins1, wins2) < minGallop {
+			if less(tmp[c2], s[c1]) {
+				s[d] = s[c1]
+				c1--
+				wins1, wins2 = wins1+1, 0
+			} else {
+				s[d] = tmp[c2]
+				c2--
+				wins1, wins2 = 0, wins2+1
+			}
+			d--
+			if c1 < base1 || c2 < 0 {
+				break outer
+			}
+		}
+		for {
+			minGallop = max(minGallop-1, 1)
+			// Run 1 elements above the next of run 2 go first.
+			key2 := tmp[c2]
+			run1 := s[base1 : c1+1]
+			i := gallop(run1, len(run1)-1, func(x T) bool { return less(key2, x) })
+			k1 := len(run1) - i
+			copy(s[d-k1+1:d+1], run1[i:])
+			d, c1 = d-k1, c1-k1
+			if c1 < base1 {
+				break outer
+			}
+			// Then run 2 elements not below the next of run 1.
+			key1 := s[c1]
+			j := gallop(tmp[:c2+1], c2, func(x T) bool { return !less(x, key1) })
+			k2 := c2 + 1 - j
+			copy(s[d-k2+1:d+1], tmp[j:c2+1])
+			d, c2 = d-k2, c2-k2
+			if c2 < 0 {
+				break outer
+			}
+			if k1 < timMinGallop && k2 < timMinGallop {
+				minGallop += 2
+				break
+			}
+		}
+	}
+	// What is left of run 1 is in place.
+	copy(s[d-c2:d+1], tmp[:c2+1])
+	ts.minGallop = minGallop
+}
@@ -0,0 +1,122 @@
+package sort
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// partiallyOrdered returns inputs of n elements with the structure Timsort
+// exploits, which random inputs lack.
+func partiallyOrdered(n int) map[string][]int {
+	rng := rand.New(rand.NewSource(int64(n)))
+	in := inputs(n)
+	runs := make([]int, n)
+	for i := range runs {
+		runs[i] = rng.Intn(n)
+	}
+	for lo := 0; lo < n; lo += 1000 {
+		slices.Sort(runs[lo:min(lo+1000, n)])
+	}
+	in["runs"] = runs
+	tail := make([]int, n)
+	for i := range tail {
+		if i < n-n/100 {
+			tail[i] = i
+		} else {
+			tail[i] = rng.Intn(n)
+		}
+	}
+	in["random tail"] = tail
+	// Two interleaved ranges: merges alternate between long winning
+	// streaks of either run.
+	blocks := make([]int, n)
+	for i := range blocks {
+		blocks[i] = (i%(n/2+1))*2 + i/(n/2+1)
+	}
+	in["halves"] = blocks
+	saw := make([]int, n)
+	for i := range saw {
+		saw[i] = i % 997
+	}
+	in["sawtooth"] = saw
+	return in
+}
+
+func TestTim(t *testing.T) {
+	for _, n := range []int{31, 32, 33, 65, 1000, 50000} {
+		for name, in := range partiallyOrdered(n) {
+			want := slices.Clone(in)
+			slices.Sort(want)
+			got := slices.Clone(in)
+			Tim(got, less)
+			if !slices.Equal(got, want) {
+				t.Fatalf("%s input of %d: not sorted", name, n)
+			}
+		}
+	}
+}
+
+func TestTim_Stable(t *testing.T) {
+	type item struct{ key, pos int }
+	for _, n := range []int{100, 5000, 50000} {
+		for name, in := range partiallyOrdered(n) {
+			items := make([]item, n)
+			for i, v := range in {
+				items[i] = item{v % 64, i}
+			}
+			Tim(items, func(a, b item) bool { return a.key < b.key })
+			for i := 1; i < n; i++ {
+				a, b := items[i-1], items[i]
+				if b.key < a.key || b.key == a.key && b.pos < a.pos {
+					t.Fatalf("%s input of %d: out of order at %d: %v after %v", name, n, i, b, a)
+				}
+			}
+		}
+	}
+}
+
+func TestMinRunLength(t *testing.T) {
+	for _, n := range []int{32, 63, 64, 65, 1000, 1 << 20, 1<<20 + 1} {
+		r := minRunLength(n)
+		if r < timMinMerge/2 || r > timMinMerge {
+			t.Errorf("minRunLength(%d) = %d outside [%d, %d]", n, r, timMinMerge/2, timMinMerge)
+		}
+	}
+	if r := minRunLength(1 << 20); r != 16 {
+		t.Errorf("minRunLength(2^20) = %d, want 16", r)
+	}
+}
+
+func TestGallop(t *testing.T) {
+	a := []int{1, 2, 2, 2, 5, 8, 8, 13, 21, 34}
+	for hint := range a {
+		for key := 0; key <= 35; key++ {
+			left := gallop(a, hint, func(x int) bool { return x >= key })
+			right := gallop(a, hint, func(x int) bool { return x > key })
+			wantLeft, _ := slices.BinarySearch(a, key)
+			wantRight, _ := slices.BinarySearch(a, key+1)
+			if left != wantLeft || right != wantRight {
+				t.Fatalf("hint %d key %d: got %d, %d, want %d, %d", hint, key, left, right, wantLeft, wantRight)
+			}
+		}
+	}
+}
+
+func BenchmarkTim(b *testing.B) {
+	const n = 100000
+	algs := []Sorter[int]{
+		NewSorter("tim", true, Tim[int]),
+		NewSorter("merge", true, Merge[int]),
+		NewSorter("quick", false, Quick[int]),
+	}
+	for _, order := range []string{"random", "sorted", "reversed", "nearly", "runs", "random tail", "halves"} {
+		in := partiallyOrdered(n)[order]
+		for _, alg := range algs {
+			b.Run(fmt.Sprintf("%s/%s", order, alg.Name()), func(b *testing.B) {
+				benchSort(b, in, func(s []int) { alg.Sort(s, less) })
+			})
+		}
+	}
+}
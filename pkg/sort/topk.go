@@ -0,0 +1,62 @@
+package sort
+
+// TopK keeps the k largest elements of a stream, by less, in a min-heap of
+// at most k elements: O(log k) per element and O(k) memory, however long
+// the stream. For the k smallest, reverse less.
+type TopK[T any] struct {
+	k    int
+	less func(a, b T) bool
+	heap []T // min-heap: heap[0] is the smallest kept
+}
+
+// NewTopK returns an empty TopK keeping k elements.
+func NewTopK[T any](k int, less func(a, b T) bool) *TopK[T] {
+	return &TopK[T]{k: max(k, 0), less: less}
+}
+
+// Push offers v: it is kept if fewer than k elements are, or if it is larger
+// than the smallest kept, which it then replaces.
+func (t *TopK[T]) Push(v T) {
+	greater := func(a, b T) bool { return t.less(b, a) }
+	switch {
+	case len(t.heap) < t.k:
+		t.heap = append(t.heap, v)
+		siftUp(t.heap, len(t.heap)-1, greater)
+	case t.k > 0 && t.less(t.heap[0], v):
+		t.heap[0] = v
+		siftDown(t.heap, 0, greater)
+	}
+}
+
+// Len returns the number of elements kept, at most k.
+func (t *TopK[T]) Len() int { return len(t.heap) }
+
+// Min returns the smallest element kept: the one the next larger element
+// replaces once k are kept.
+func (t *TopK[T]) Min() (T, bool) {
+	if len(t.heap) == 0 {
+		var zero T
+		return zero, false
+	}
+	return t.heap[0], true
+}
+
+// Sorted returns the elements kept, largest first.
+func (t *TopK[T]) Sorted() []T {
+	out := append([]T(nil), t.heap...)
+	Quick(out, func(a, b T) bool { return t.less(b, a) })
+	return out
+}
+
+// siftUp moves s[i] up the heap s, ordered by less as for siftDown, until
+// its parent is not smaller.
+func siftUp[T any](s []T, i int, less func(a, b T) bool) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !less(s[parent], s[i]) {
+			return
+		}
+		s[i], s[parent] = s[parent], s[i]
+		i = parent
+	}
+}
@@ -0,0 +1,41 @@
+package sort
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestTopK(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	stream := randomInts[int](rng, 5000)
+	for i := range stream {
+		stream[i] %= 1000
+	}
+	for _, k := range []int{0, 1, 10, 5000, 6000} {
+		top := NewTopK(k, less)
+		for _, v := range stream {
+			top.Push(v)
+		}
+		want := Largest(stream, k, less)
+		if got := top.Sorted(); !slices.Equal(got, want) {
+			t.Fatalf("k %d: got %v, want %v", k, got, want)
+		}
+		if top.Len() != min(k, len(stream)) {
+			t.Errorf("k %d: Len %d", k, top.Len())
+		}
+		if m, ok := top.Min(); ok != (k > 0) || ok && m != want[len(want)-1] {
+			t.Errorf("k %d: Min %d, %v", k, m, ok)
+		}
+	}
+}
+
+func TestTopK_Smallest(t *testing.T) {
+	top := NewTopK(3, func(a, b string) bool { return a > b })
+	for _, w := range []string{"pear", "apple", "fig", "kiwi", "banana", "date"} {
+		top.Push(w)
+	}
+	if got, want := top.Sorted(), []string{"apple", "banana", "date"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
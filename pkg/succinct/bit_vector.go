@@ -0,0 +1,173 @@
+// Package succinct provides compact static structures supporting rank and
+// select queries: a bit vector with constant-time rank/select and a wavelet
+// tree over small integer alphabets.
+//
+// References:
+//
+// https://en.wikipedia.org/wiki/Succinct_data_structure
+// https://en.wikipedia.org/wiki/Wavelet_Tree
+package succinct
+
+import (
+	"math/bits"
+
+	"github.com/sanderblue/algorithms/pkg/bitset"
+)
+
+const (
+	wordSize       = 64
+	wordsPerBlock  = 8                        // a block spans 512 bits
+	selectSampling = wordSize * wordsPerBlock // one select sample every 512 set bits
+)
+
+// BitVector is an immutable bit vector with rank and select directories.
+// Rank is answered from per-block cumulative counts plus at most eight
+// popcounts; Select jumps to a sampled block and scans a bounded number of
+// blocks forward.
+type BitVector struct {
+	n       int
+	words   []uint64
+	blocks  []int // blocks[b] = set bits before block b
+	samples []int // samples[k] = block holding the (k*selectSampling)-th set bit
+	ones    int
+}
+
+// NewBitVector freezes a copy of b into a BitVector.
+func NewBitVector(b *bitset.Bitset) *BitVector {
+	return newBitVector(b.Len(), b.Words())
+}
+
+// FromBools builds a BitVector from a slice of booleans.
+func FromBools(v []bool) *BitVector {
+	b := bitset.New(len(v))
+	for i, set := range v {
+		if set {
+			b.Set(i)
+		}
+	}
+	return NewBitVector(b)
+}
+
+func newBitVector(n int, words []uint64) *BitVector {
+	bv := &BitVector{n: n, words: words}
+	nblocks := (len(words) + wordsPerBlock - 1) / wordsPerBlock
+	bv.blocks = make([]int, nblocks+1)
+	for blk := 0; blk < nblocks; blk++ {
+		c := 0
+		for w := blk * wordsPerBlock; w < len(words) && w < (blk+1)*wordsPerBlock; w++ {
+			c += bits.OnesCount64(words[w])
+		}
+		bv.blocks[blk+1] = bv.blocks[blk] + c
+	}
+	bv.ones = bv.blocks[nblocks]
+	for k := 0; k*selectSampling < bv.ones; k++ {
+		target := k * selectSampling
+		blk := len(bv.samples)
+		if blk > 0 {
+			blk = bv.samples[blk-1]
+		}
+		for bv.blocks[blk+1] <= target {
+			blk++
+		}
+		bv.samples = append(bv.samples, blk)
+	}
+	return bv
+}
+
+// Len returns the number of bits.
+func (bv *BitVector) Len() int {
+	return bv.n
+}
+
+// Ones returns the number of set bits.
+func (bv *BitVector) Ones() int {
+	return bv.ones
+}
+
+func (bv *BitVector) Access(i int) bool {
+	return bv.words[i/wordSize]&(1<<(uint(i)%wordSize)) != 0
+}
+
+// Rank1 returns the number of set bits in [0, i).
+func (bv *BitVector) Rank1(i int) int {
+	if i <= 0 {
+		return 0
+	}
+	if i >= bv.n {
+		return bv.ones
+	}
+	w := i / wordSize
+	blk := w / wordsPerBlock
+	r := bv.blocks[blk]
+	for j := blk * wordsPerBlock; j < w; j++ {
+		r += bits.OnesCount64(bv.words[j])
+	}
+	if off := uint(i) % wordSize; off != 0 {
+		r += bits.OnesCount64(bv.words[w] & (1<<off - 1))
+	}
+	return r
+}
+
+// Rank0 returns the number of clear bits in [0, i).
+func (bv *BitVector) Rank0(i int) int {
+	if i <= 0 {
+		return 0
+	}
+	if i > bv.n {
+		i = bv.n
+	}
+	return i - bv.Rank1(i)
+}
+
+// Select1 returns the position of the k-th set bit (0-based), or -1.
+func (bv *BitVector) Select1(k int) int {
+	if k < 0 || k >= bv.ones {
+		return -1
+	}
+	blk := bv.samples[k/selectSampling]
+	for bv.blocks[blk+1] <= k {
+		blk++
+	}
+	k -= bv.blocks[blk]
+	for w := blk * wordsPerBlock; ; w++ {
+		c := bits.OnesCount64(bv.words[w])
+		if k < c {
+			return w*wordSize + selectInWord(bv.words[w], k)
+		}
+		k -= c
+	}
+}
+
+// Select0 returns the position of the k-th clear bit (0-based), or -1.
+// It binary searches the block directory and then scans within the block.
+func (bv *BitVector) Select0(k int) int {
+	if k < 0 || k >= bv.n-bv.ones {
+		return -1
+	}
+	lo, hi := 0, len(bv.blocks)-1
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if mid*selectSampling-bv.blocks[mid] <= k {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	k -= lo*selectSampling - bv.blocks[lo]
+	for w := lo * wordsPerBlock; ; w++ {
+		inv := ^bv.words[w]
+		c := bits.OnesCount64(inv)
+		if k < c {
+			return w*wordSize + selectInWord(inv, k)
+		}
+		k -= c
+	}
+}
+
+// selectInWord returns the position of the k-th set bit of word.
+func selectInWord(word uint64, k int) int {
+	for ; k > 0; k-- {
+		word &= word - 1
+	}
+	return bits.TrailingZeros64(word)
+}
@@ -0,0 +1,88 @@
+package succinct
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestBitVector_RankSelect(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       int
+		density float64
+	}{
+		{name: "empty", n: 0, density: 0.5},
+		{name: "sparse", n: 5000, density: 0.01},
+		{name: "half", n: 4097, density: 0.5},
+		{name: "dense", n: 3000, density: 0.99},
+		{name: "all ones", n: 1100, density: 1},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(42))
+			v := make([]bool, tc.n)
+			for i := range v {
+				v[i] = rng.Float64() < tc.density
+			}
+			bv := FromBools(v)
+
+			ones, zeros := 0, 0
+			for i, set := range v {
+				if got := bv.Rank1(i); got != ones {
+					t.Fatalf("Rank1(%d): expected %d, got %d", i, ones, got)
+				}
+				if got := bv.Rank0(i); got != zeros {
+					t.Fatalf("Rank0(%d): expected %d, got %d", i, zeros, got)
+				}
+				if bv.Access(i) != set {
+					t.Fatalf("Access(%d): expected %v", i, set)
+				}
+				if set {
+					if got := bv.Select1(ones); got != i {
+						t.Fatalf("Select1(%d): expected %d, got %d", ones, i, got)
+					}
+					ones++
+				} else {
+					if got := bv.Select0(zeros); got != i {
+						t.Fatalf("Select0(%d): expected %d, got %d", zeros, i, got)
+					}
+					zeros++
+				}
+			}
+			if bv.Ones() != ones || bv.Rank1(tc.n) != ones {
+				t.Errorf("expected %d ones, got %d", ones, bv.Ones())
+			}
+			if bv.Select1(ones) != -1 || bv.Select0(zeros) != -1 {
+				t.Errorf("expected -1 selecting past the end")
+			}
+		})
+	}
+}
+
+func BenchmarkBitVector_Rank1(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	v := make([]bool, 1<<20)
+	for i := range v {
+		v[i] = rng.Intn(2) == 0
+	}
+	bv := FromBools(v)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = bv.Rank1(i & (len(v) - 1))
+	}
+}
+
+func BenchmarkBitVector_Select1(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	v := make([]bool, 1<<20)
+	for i := range v {
+		v[i] = rng.Intn(2) == 0
+	}
+	bv := FromBools(v)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = bv.Select1(i % bv.Ones())
+	}
+}
@@ -0,0 +1,142 @@
+package succinct
+
+import (
+	"fmt"
+
+	"github.com/sanderblue/algorithms/pkg/bitset"
+)
+
+// WaveletTree stores a sequence over the alphabet [0, Sigma) and answers
+// Access, Rank and Select in O(log Sigma) bit-vector operations. Every level
+// of the balanced tree splits the current symbol range in half; a set bit
+// sends the symbol to the upper half.
+type WaveletTree struct {
+	n     int
+	sigma int
+	root  *waveletNode
+}
+
+type waveletNode struct {
+	lo, hi      int // symbol range [lo, hi)
+	bits        *BitVector
+	left, right *waveletNode
+}
+
+// NewWaveletTree builds a wavelet tree for seq. Every symbol must lie in
+// [0, sigma).
+func NewWaveletTree(seq []int, sigma int) (*WaveletTree, error) {
+	for i, c := range seq {
+		if c < 0 || c >= sigma {
+			return nil, fmt.Errorf("symbol %d at position %d outside alphabet [0, %d)", c, i, sigma)
+		}
+	}
+	return &WaveletTree{n: len(seq), sigma: sigma, root: buildWavelet(seq, 0, sigma)}, nil
+}
+
+func buildWavelet(seq []int, lo, hi int) *waveletNode {
+	node := &waveletNode{lo: lo, hi: hi}
+	if hi-lo <= 1 {
+		return node
+	}
+	mid := (lo + hi) / 2
+	b := bitset.New(len(seq))
+	var left, right []int
+	for i, c := range seq {
+		if c >= mid {
+			b.Set(i)
+			right = append(right, c)
+		} else {
+			left = append(left, c)
+		}
+	}
+	node.bits = NewBitVector(b)
+	node.left = buildWavelet(left, lo, mid)
+	node.right = buildWavelet(right, mid, hi)
+	return node
+}
+
+// Len returns the length of the sequence.
+func (wt *WaveletTree) Len() int {
+	return wt.n
+}
+
+// Access returns the symbol at position i.
+func (wt *WaveletTree) Access(i int) int {
+	node := wt.root
+	for node.bits != nil {
+		if node.bits.Access(i) {
+			i = node.bits.Rank1(i)
+			node = node.right
+		} else {
+			i = node.bits.Rank0(i)
+			node = node.left
+		}
+	}
+	return node.lo
+}
+
+// Rank returns the number of occurrences of c in [0, i).
+func (wt *WaveletTree) Rank(c, i int) int {
+	if c < 0 || c >= wt.sigma {
+		return 0
+	}
+	if i > wt.n {
+		i = wt.n
+	}
+	node := wt.root
+	for node.bits != nil && i > 0 {
+		mid := (node.lo + node.hi) / 2
+		if c >= mid {
+			i = node.bits.Rank1(i)
+			node = node.right
+		} else {
+			i = node.bits.Rank0(i)
+			node = node.left
+		}
+	}
+	return i
+}
+
+// Select returns the position of the k-th occurrence (0-based) of c, or -1.
+func (wt *WaveletTree) Select(c, k int) int {
+	if c < 0 || c >= wt.sigma || k < 0 {
+		return -1
+	}
+	// Walk down to the leaf, remembering the path, then map the position
+	// back up with select on each level.
+	var path []*waveletNode
+	node := wt.root
+	for node.bits != nil {
+		path = append(path, node)
+		if c >= (node.lo+node.hi)/2 {
+			node = node.right
+		} else {
+			node = node.left
+		}
+	}
+	if k >= wt.countAt(path, c) {
+		return -1
+	}
+	pos := k
+	for d := len(path) - 1; d >= 0; d-- {
+		n := path[d]
+		if c >= (n.lo+n.hi)/2 {
+			pos = n.bits.Select1(pos)
+		} else {
+			pos = n.bits.Select0(pos)
+		}
+	}
+	return pos
+}
+
+// countAt returns how many times c occurs, given the root-to-leaf path of c.
+func (wt *WaveletTree) countAt(path []*waveletNode, c int) int {
+	if len(path) == 0 {
+		return wt.n
+	}
+	last := path[len(path)-1]
+	if c >= (last.lo+last.hi)/2 {
+		return last.bits.Ones()
+	}
+	return last.bits.Len() - last.bits.Ones()
+}
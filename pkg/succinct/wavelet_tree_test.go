@@ -0,0 +1,61 @@
+package succinct
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestWaveletTree(t *testing.T) {
+	tests := []struct {
+		name  string
+		n     int
+		sigma int
+	}{
+		{name: "binary", n: 700, sigma: 2},
+		{name: "dna", n: 2000, sigma: 4},
+		{name: "odd alphabet", n: 1500, sigma: 7},
+		{name: "single symbol", n: 50, sigma: 1},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(7))
+			seq := make([]int, tc.n)
+			for i := range seq {
+				seq[i] = rng.Intn(tc.sigma)
+			}
+			wt, err := NewWaveletTree(seq, tc.sigma)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			counts := make([]int, tc.sigma)
+			for i, c := range seq {
+				if got := wt.Access(i); got != c {
+					t.Fatalf("Access(%d): expected %d, got %d", i, c, got)
+				}
+				for s := 0; s < tc.sigma; s++ {
+					if got := wt.Rank(s, i); got != counts[s] {
+						t.Fatalf("Rank(%d, %d): expected %d, got %d", s, i, counts[s], got)
+					}
+				}
+				if got := wt.Select(c, counts[c]); got != i {
+					t.Fatalf("Select(%d, %d): expected %d, got %d", c, counts[c], i, got)
+				}
+				counts[c]++
+			}
+			for s := 0; s < tc.sigma; s++ {
+				if got := wt.Select(s, counts[s]); got != -1 {
+					t.Errorf("Select(%d, %d) past the end: expected -1, got %d", s, counts[s], got)
+				}
+			}
+		})
+	}
+}
+
+func TestWaveletTree_InvalidSymbol(t *testing.T) {
+	if _, err := NewWaveletTree([]int{0, 3}, 3); err == nil {
+		t.Error("expected error for symbol outside the alphabet")
+	}
+}
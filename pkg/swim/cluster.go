@@ -0,0 +1,100 @@
+package swim
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sanderblue/algorithms/pkg/ringallreduce"
+)
+
+// Cluster runs SWIM members over an in-process transport, and lets them
+// join, crash and recover.
+type Cluster struct {
+	Config    Config
+	Transport *ringallreduce.ChanTransport
+
+	members []*Member // nil until joined
+}
+
+// NewCluster starts members 0..initial-1 of a group of up to n members, all
+// knowing each other.
+func NewCluster(n, initial int, cfg Config) *Cluster {
+	c := &Cluster{Config: cfg, Transport: ringallreduce.NewChanTransportSize(n, 1024), members: make([]*Member, n)}
+	seeds := make([]int, initial)
+	for id := range seeds {
+		seeds[id] = id
+	}
+	for id := 0; id < initial; id++ {
+		c.members[id] = NewMember(id, c.Transport, cfg, seeds...)
+	}
+	return c
+}
+
+// Member returns member id, or nil if it hasn't joined.
+func (c *Cluster) Member(id int) *Member { return c.members[id] }
+
+// Join starts member id, which joins the group through seed.
+func (c *Cluster) Join(id, seed int) error {
+	if c.members[id] != nil {
+		return fmt.Errorf("member %d already joined", id)
+	}
+	c.members[id] = NewMember(id, c.Transport, c.Config, seed)
+	return nil
+}
+
+// Crash crashes member id.
+func (c *Cluster) Crash(id int) { c.members[id].Crash() }
+
+// Recover recovers member id.
+func (c *Cluster) Recover(id int) { c.members[id].Recover() }
+
+// WaitConverged waits up to timeout for every running member to see every
+// member of want in the state want gives, and returns an error describing
+// a disagreement otherwise.
+func (c *Cluster) WaitConverged(want map[int]State, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := c.disagreement(want)
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("no convergence within %v: %w", timeout, err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (c *Cluster) disagreement(want map[int]State) error {
+	for _, m := range c.members {
+		if m == nil || m.isCrashed() {
+			continue
+		}
+		for id, w := range want {
+			got, ok := m.State(id)
+			if !ok {
+				return fmt.Errorf("member %d doesn't know member %d", m.ID, id)
+			}
+			if got != w {
+				return fmt.Errorf("member %d sees member %d %v, want %v", m.ID, id, got, w)
+			}
+		}
+	}
+	return nil
+}
+
+// Close stops every member and the transport.
+func (c *Cluster) Close() {
+	c.Transport.Close()
+	for _, m := range c.members {
+		if m != nil {
+			m.Close()
+		}
+	}
+}
+
+func (m *Member) isCrashed() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.crashed
+}
@@ -0,0 +1,71 @@
+package swim
+
+import (
+	"testing"
+	"time"
+)
+
+var testConfig = Config{
+	ProbeInterval:    10 * time.Millisecond,
+	ProbeTimeout:     3 * time.Millisecond,
+	SuspicionTimeout: 40 * time.Millisecond,
+}
+
+const wait = 10 * time.Second
+
+func states(n int, s State) map[int]State {
+	out := make(map[int]State, n)
+	for id := 0; id < n; id++ {
+		out[id] = s
+	}
+	return out
+}
+
+func TestCluster_Churn(t *testing.T) {
+	c := NewCluster(8, 5, testConfig)
+	defer c.Close()
+	want := states(5, Alive)
+	if err := c.WaitConverged(want, wait); err != nil {
+		t.Fatal(err)
+	}
+
+	// Joiners only know one seed; the rest learn of them by gossip.
+	for id, seed := range map[int]int{5: 0, 6: 3, 7: 5} {
+		if err := c.Join(id, seed); err != nil {
+			t.Fatal(err)
+		}
+		want[id] = Alive
+	}
+	if err := c.Join(7, 0); err == nil {
+		t.Error("expected an error joining twice")
+	}
+	if err := c.WaitConverged(want, wait); err != nil {
+		t.Fatalf("after joins: %v", err)
+	}
+
+	for _, id := range []int{0, 6} {
+		c.Crash(id)
+		want[id] = Dead
+	}
+	if err := c.WaitConverged(want, wait); err != nil {
+		t.Fatalf("after crashes: %v", err)
+	}
+
+	// A recovered member rejoins with a higher incarnation, which beats
+	// the news of its death.
+	c.Recover(0)
+	want[0] = Alive
+	c.Crash(2)
+	want[2] = Dead
+	if err := c.WaitConverged(want, wait); err != nil {
+		t.Fatalf("after recovery: %v", err)
+	}
+	if inc := c.Member(0).Incarnation(); inc == 0 {
+		t.Error("recovered member kept incarnation 0")
+	}
+	for _, u := range c.Member(7).Members() {
+		if u.Member == 0 && u.Incarnation != c.Member(0).Incarnation() {
+			t.Errorf("member 7 knows member 0 at incarnation %d, want %d", u.Incarnation, c.Member(0).Incarnation())
+		}
+	}
+}
@@ -0,0 +1,550 @@
+// Package swim implements the SWIM membership protocol: every member keeps
+// a list of the others and detects their failures with a constant load per
+// member, whatever the size of the group.
+//
+// Once per protocol period a member pings the next member of a shuffled
+// round-robin order. If no ack arrives in time, it asks k other members to
+// ping the target on its behalf, so one bad link doesn't condemn a healthy
+// member. A target that answers neither way becomes suspect; a suspect that
+// doesn't refute the suspicion, by announcing itself alive with a higher
+// incarnation number, within the suspicion timeout is declared dead.
+// Membership changes travel piggybacked on the pings and acks themselves,
+// each a bounded number of times, and spread like an epidemic. A member
+// that joins, or rejoins after a crash, missed the news that went before,
+// so it pulls the whole membership list from a seed first.
+//
+// Members talk over a ringallreduce.Transport, in process or over TCP.
+//
+// References:
+//
+// Das, Gupta and Motivala, SWIM: Scalable Weakly-consistent Infection-style
+// Process Group Membership Protocol, DSN 2002.
+package swim
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sanderblue/algorithms/internal/mailbox"
+	"github.com/sanderblue/algorithms/pkg/ringallreduce"
+)
+
+// State is the state of a member as seen by another. Later states override
+// earlier ones of the same incarnation.
+type State int
+
+const (
+	Alive State = iota
+	Suspect
+	Dead
+)
+
+func (s State) String() string {
+	switch s {
+	case Alive:
+		return "alive"
+	case Suspect:
+		return "suspect"
+	case Dead:
+		return "dead"
+	default:
+		return fmt.Sprintf("state(%d)", int(s))
+	}
+}
+
+// Update is a piece of membership news: the state of a member at an
+// incarnation. Only the member itself raises its incarnation, to refute a
+// suspicion or to rejoin.
+type Update struct {
+	Member      int
+	State       State
+	Incarnation uint64
+}
+
+// overrides reports whether u is newer than o about the same member.
+func (u Update) overrides(o Update) bool {
+	return u.Incarnation > o.Incarnation || u.Incarnation == o.Incarnation && u.State > o.State
+}
+
+// MessageType is the kind of a SWIM message.
+type MessageType int
+
+const (
+	MsgPing    MessageType = iota // a member probes another
+	MsgAck                        // the probed member answers
+	MsgPingReq                    // a member asks another to probe a target on its behalf
+	MsgJoin                       // a joining member asks for the membership list
+	MsgSync                       // the answer: every member the sender knows of
+)
+
+func (t MessageType) String() string {
+	switch t {
+	case MsgPing:
+		return "ping"
+	case MsgAck:
+		return "ack"
+	case MsgPingReq:
+		return "ping-req"
+	case MsgJoin:
+		return "join"
+	case MsgSync:
+		return "sync"
+	default:
+		return fmt.Sprintf("message(%d)", int(t))
+	}
+}
+
+type message struct {
+	typ     MessageType
+	from    int
+	seq     uint64
+	target  int // of a MsgPingReq
+	updates []Update
+}
+
+// encode packs m in a transport message: the type as chunk index, the
+// target as op and the updates as triples of the data.
+func encode(m message) ringallreduce.Msg {
+	data := make([]float64, 0, 3*len(m.updates))
+	for _, u := range m.updates {
+		data = append(data, float64(u.Member), float64(u.State), float64(u.Incarnation))
+	}
+	return ringallreduce.Msg{From: m.from, ChunkIdx: int(m.typ), Seq: m.seq, Op: uint64(m.target), Data: data}
+}
+
+func decode(msg ringallreduce.Msg) (message, error) {
+	m := message{typ: MessageType(msg.ChunkIdx), from: msg.From, seq: msg.Seq, target: int(msg.Op)}
+	if m.typ < MsgPing || m.typ > MsgSync {
+		return m, fmt.Errorf("unknown message type %d", msg.ChunkIdx)
+	}
+	if len(msg.Data)%3 != 0 {
+		return m, fmt.Errorf("%v from %d: %d values don't form updates", m.typ, m.from, len(msg.Data))
+	}
+	for i := 0; i < len(msg.Data); i += 3 {
+		u := Update{Member: int(msg.Data[i]), State: State(msg.Data[i+1]), Incarnation: uint64(msg.Data[i+2])}
+		if u.State < Alive || u.State > Dead {
+			return m, fmt.Errorf("%v from %d: unknown state %d", m.typ, m.from, int(u.State))
+		}
+		m.updates = append(m.updates, u)
+	}
+	return m, nil
+}
+
+// Config tunes a member.
+type Config struct {
+	ProbeInterval    time.Duration // protocol period; defaults to 200ms
+	ProbeTimeout     time.Duration // wait for a direct ack before probing indirectly; defaults to ProbeInterval/4
+	IndirectProbes   int           // members asked to probe indirectly (k); defaults to 3
+	SuspicionTimeout time.Duration // before a suspect is declared dead; defaults to 5 ProbeIntervals
+	RetransmitMult   int           // an update is piggybacked RetransmitMult·⌈log₂(n+1)⌉ times; defaults to 3
+	MaxPiggyback     int           // updates per message; defaults to 8
+	Seed             int64         // of the probe order; defaults to the member ID
+}
+
+func (c Config) withDefaults(id int) Config {
+	if c.ProbeInterval <= 0 {
+		c.ProbeInterval = 200 * time.Millisecond
+	}
+	if c.ProbeTimeout <= 0 {
+		c.ProbeTimeout = c.ProbeInterval / 4
+	}
+	if c.IndirectProbes <= 0 {
+		c.IndirectProbes = 3
+	}
+	if c.SuspicionTimeout <= 0 {
+		c.SuspicionTimeout = 5 * c.ProbeInterval
+	}
+	if c.RetransmitMult <= 0 {
+		c.RetransmitMult = 3
+	}
+	if c.MaxPiggyback <= 0 {
+		c.MaxPiggyback = 8
+	}
+	if c.Seed == 0 {
+		c.Seed = int64(id) + 1
+	}
+	return c
+}
+
+type entry struct {
+	Update
+	deadline time.Time // of the suspicion
+}
+
+type broadcast struct {
+	Update
+	transmits int
+}
+
+type probe struct {
+	target   int
+	seq      uint64
+	start    time.Time
+	indirect bool // ping-reqs sent
+	acked    bool
+}
+
+// relay is a ping sent on behalf of another member, whose ack goes back to
+// it under its own sequence number.
+type relay struct {
+	to      int
+	seq     uint64
+	expires time.Time
+}
+
+// Member is one member of a SWIM group, handling its messages on its own
+// goroutine.
+type Member struct {
+	ID int
+
+	cfg       Config
+	transport ringallreduce.Transport
+	inbox     *mailbox.Mailbox[message]
+	done      chan struct{}
+	wg        sync.WaitGroup
+
+	mu          sync.Mutex
+	rng         *rand.Rand
+	crashed     bool
+	incarnation uint64
+	members     map[int]*entry // every known member but this one
+	order       []int          // probe order
+	next        int
+	queue       []*broadcast
+	seq         uint64
+	probe       *probe
+	nextProbe   time.Time
+	relays      map[uint64]relay
+	sent        map[MessageType]int
+}
+
+// NewMember starts member id on transport, knowing seeds as alive members
+// of the group. It announces itself to them and pulls their membership
+// lists.
+func NewMember(id int, transport ringallreduce.Transport, cfg Config, seeds ...int) *Member {
+	cfg = cfg.withDefaults(id)
+	m := &Member{
+		ID:        id,
+		cfg:       cfg,
+		transport: transport,
+		inbox:     mailbox.New[message](),
+		done:      make(chan struct{}),
+		rng:       rand.New(rand.NewSource(cfg.Seed)),
+		members:   make(map[int]*entry),
+		relays:    make(map[uint64]relay),
+		sent:      make(map[MessageType]int),
+	}
+	for _, s := range seeds {
+		if _, ok := m.members[s]; !ok && s != id {
+			m.members[s] = &entry{Update: Update{Member: s}}
+			m.order = append(m.order, s)
+		}
+	}
+	m.rng.Shuffle(len(m.order), func(i, j int) { m.order[i], m.order[j] = m.order[j], m.order[i] })
+	m.enqueue(Update{Member: id})
+
+	go m.receive()
+	for _, s := range seeds {
+		if s != id {
+			m.send(s, MsgJoin, 0, 0)
+		}
+	}
+	m.wg.Add(1)
+	go m.run()
+	return m
+}
+
+// Members returns the membership list of the member, itself included, in
+// the order of member IDs.
+func (m *Member) Members() []Update {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := []Update{{Member: m.ID, Incarnation: m.incarnation}}
+	for _, e := range m.members {
+		out = append(out, e.Update)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Member < out[j].Member })
+	return out
+}
+
+// State returns the state of member id as seen by m, or false if m doesn't
+// know id.
+func (m *Member) State(id int) (State, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if id == m.ID {
+		return Alive, true
+	}
+	e, ok := m.members[id]
+	if !ok {
+		return 0, false
+	}
+	return e.State, true
+}
+
+// Incarnation returns the incarnation number of the member.
+func (m *Member) Incarnation() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.incarnation
+}
+
+// Messages returns the number of messages sent so far, by type.
+func (m *Member) Messages() map[MessageType]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[MessageType]int, len(m.sent))
+	for t, n := range m.sent {
+		out[t] = n
+	}
+	return out
+}
+
+// Crash stops the member: it ignores every message and stops probing until
+// Recover, and forgets the news it was spreading.
+func (m *Member) Crash() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.crashed = true
+	m.probe = nil
+	m.queue = nil
+	clear(m.relays)
+}
+
+// Recover restarts a crashed member, which rejoins the group with a new
+// incarnation through a member it believes alive.
+func (m *Member) Recover() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.crashed = false
+	m.incarnation++
+	m.enqueue(Update{Member: m.ID, Incarnation: m.incarnation})
+	m.nextProbe = time.Time{}
+	if seed, ok := m.nextTarget(); ok {
+		m.send(seed, MsgJoin, 0, 0)
+	}
+}
+
+// Close stops the member. The goroutine receiving from the transport exits
+// once the transport is closed.
+func (m *Member) Close() {
+	close(m.done)
+	m.wg.Wait()
+}
+
+func (m *Member) receive() {
+	for {
+		msg, err := m.transport.Recv(m.ID)
+		if errors.Is(err, ringallreduce.ErrTransportClosed) {
+			return
+		}
+		if err != nil {
+			continue
+		}
+		if dm, err := decode(msg); err == nil {
+			m.inbox.Push(dm)
+		}
+	}
+}
+
+func (m *Member) run() {
+	defer m.wg.Done()
+	ticker := time.NewTicker(max(m.cfg.ProbeTimeout/4, time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.inbox.Signal():
+			for _, msg := range m.inbox.Drain() {
+				m.mu.Lock()
+				if !m.crashed {
+					m.handle(msg, time.Now())
+				}
+				m.mu.Unlock()
+			}
+		case now := <-ticker.C:
+			m.mu.Lock()
+			if !m.crashed {
+				m.tick(now)
+			}
+			m.mu.Unlock()
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// send sends a message with as many pending updates as fit piggybacked.
+// Errors are ignored: to SWIM a lost message is a lost message.
+func (m *Member) send(to int, typ MessageType, seq uint64, target int) {
+	m.sent[typ]++
+	m.transport.Send(to, encode(message{typ: typ, from: m.ID, seq: seq, target: target, updates: m.piggyback()}))
+}
+
+// sync sends every known member, this one included, to member to.
+func (m *Member) sync(to int) {
+	updates := []Update{{Member: m.ID, Incarnation: m.incarnation}}
+	for _, e := range m.members {
+		updates = append(updates, e.Update)
+	}
+	m.sent[MsgSync]++
+	m.transport.Send(to, encode(message{typ: MsgSync, from: m.ID, updates: updates}))
+}
+
+// piggyback returns the pending updates sent the fewest times so far, and
+// drops those that reached their retransmission limit.
+func (m *Member) piggyback() []Update {
+	if len(m.queue) == 0 {
+		return nil
+	}
+	// With n members, bits.Len(n) is ⌈log₂(n+1)⌉.
+	limit := m.cfg.RetransmitMult * bits.Len(uint(len(m.members)+1))
+	sort.SliceStable(m.queue, func(i, j int) bool { return m.queue[i].transmits < m.queue[j].transmits })
+	var out []Update
+	kept := m.queue[:0]
+	for i, b := range m.queue {
+		if i < m.cfg.MaxPiggyback {
+			out = append(out, b.Update)
+			b.transmits++
+		}
+		if b.transmits < limit {
+			kept = append(kept, b)
+		}
+	}
+	m.queue = kept
+	return out
+}
+
+// enqueue queues u for dissemination, replacing older news about the same
+// member.
+func (m *Member) enqueue(u Update) {
+	for i, b := range m.queue {
+		if b.Member == u.Member {
+			m.queue = append(m.queue[:i], m.queue[i+1:]...)
+			break
+		}
+	}
+	m.queue = append(m.queue, &broadcast{Update: u})
+}
+
+// apply merges u into the membership list and passes it on if it is news.
+func (m *Member) apply(u Update, now time.Time) {
+	if u.Member == m.ID {
+		if u.State != Alive && u.Incarnation >= m.incarnation {
+			// Refute the suspicion.
+			m.incarnation = u.Incarnation + 1
+			m.enqueue(Update{Member: m.ID, Incarnation: m.incarnation})
+		}
+		return
+	}
+	e, ok := m.members[u.Member]
+	if ok && !u.overrides(e.Update) {
+		return
+	}
+	if !ok {
+		e = &entry{}
+		m.members[u.Member] = e
+		i := m.rng.Intn(len(m.order) + 1)
+		m.order = append(m.order, 0)
+		copy(m.order[i+1:], m.order[i:])
+		m.order[i] = u.Member
+	}
+	e.Update = u
+	if u.State == Suspect {
+		e.deadline = now.Add(m.cfg.SuspicionTimeout)
+	}
+	m.enqueue(u)
+}
+
+func (m *Member) handle(msg message, now time.Time) {
+	for _, u := range msg.updates {
+		m.apply(u, now)
+	}
+	switch msg.typ {
+	case MsgPing:
+		m.send(msg.from, MsgAck, msg.seq, 0)
+	case MsgAck:
+		if p := m.probe; p != nil && p.seq == msg.seq {
+			p.acked = true
+		}
+		if r, ok := m.relays[msg.seq]; ok {
+			delete(m.relays, msg.seq)
+			m.send(r.to, MsgAck, r.seq, 0)
+		}
+	case MsgPingReq:
+		m.seq++
+		m.relays[m.seq] = relay{to: msg.from, seq: msg.seq, expires: now.Add(m.cfg.ProbeInterval)}
+		m.send(msg.target, MsgPing, m.seq, 0)
+	case MsgJoin:
+		m.sync(msg.from)
+	}
+}
+
+func (m *Member) tick(now time.Time) {
+	if p := m.probe; p != nil {
+		if !p.acked && !p.indirect && now.Sub(p.start) >= m.cfg.ProbeTimeout {
+			p.indirect = true
+			for _, via := range m.helpers(p.target) {
+				m.send(via, MsgPingReq, p.seq, p.target)
+			}
+		}
+		if p.acked || now.Sub(p.start) >= m.cfg.ProbeInterval {
+			if e := m.members[p.target]; !p.acked && e.State == Alive {
+				m.apply(Update{Member: p.target, State: Suspect, Incarnation: e.Incarnation}, now)
+			}
+			m.probe = nil
+		}
+	}
+	if m.probe == nil && !now.Before(m.nextProbe) {
+		m.nextProbe = now.Add(m.cfg.ProbeInterval)
+		if target, ok := m.nextTarget(); ok {
+			m.seq++
+			m.probe = &probe{target: target, seq: m.seq, start: now}
+			m.send(target, MsgPing, m.seq, 0)
+		}
+	}
+	for _, e := range m.members {
+		if e.State == Suspect && now.After(e.deadline) {
+			m.apply(Update{Member: e.Member, State: Dead, Incarnation: e.Incarnation}, now)
+		}
+	}
+	for seq, r := range m.relays {
+		if now.After(r.expires) {
+			delete(m.relays, seq)
+		}
+	}
+}
+
+// nextTarget returns the next member to probe in the round-robin order,
+// reshuffled after every round, skipping dead members.
+func (m *Member) nextTarget() (int, bool) {
+	for range m.order {
+		if m.next == len(m.order) {
+			m.rng.Shuffle(len(m.order), func(i, j int) { m.order[i], m.order[j] = m.order[j], m.order[i] })
+			m.next = 0
+		}
+		id := m.order[m.next]
+		m.next++
+		if m.members[id].State != Dead {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// helpers picks up to IndirectProbes live members other than target at
+// random.
+func (m *Member) helpers(target int) []int {
+	var live []int
+	for _, id := range m.order {
+		if id != target && m.members[id].State != Dead {
+			live = append(live, id)
+		}
+	}
+	m.rng.Shuffle(len(live), func(i, j int) { live[i], live[j] = live[j], live[i] })
+	return live[:min(len(live), m.cfg.IndirectProbes)]
+}
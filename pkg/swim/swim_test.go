@@ -0,0 +1,150 @@
+package swim
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sanderblue/algorithms/pkg/ringallreduce"
+)
+
+func TestMessage_RoundTrip(t *testing.T) {
+	want := message{typ: MsgPingReq, from: 3, seq: 41, target: 7, updates: []Update{
+		{Member: 1, State: Suspect, Incarnation: 2},
+		{Member: 7, State: Dead, Incarnation: 1 << 40},
+	}}
+	got, err := decode(encode(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip changed the message:\n got %+v\nwant %+v", got, want)
+	}
+	if _, err := decode(ringallreduce.Msg{ChunkIdx: 9}); err == nil {
+		t.Error("expected an error for an unknown type")
+	}
+	if _, err := decode(ringallreduce.Msg{Data: []float64{1, 2}}); err == nil {
+		t.Error("expected an error for a partial update")
+	}
+}
+
+func TestUpdate_Overrides(t *testing.T) {
+	tests := []struct {
+		name string
+		u, o Update
+		want bool
+	}{
+		{"suspect beats alive", Update{State: Suspect, Incarnation: 1}, Update{State: Alive, Incarnation: 1}, true},
+		{"dead beats suspect", Update{State: Dead, Incarnation: 1}, Update{State: Suspect, Incarnation: 1}, true},
+		{"refutation", Update{State: Alive, Incarnation: 2}, Update{State: Suspect, Incarnation: 1}, true},
+		{"rejoin", Update{State: Alive, Incarnation: 2}, Update{State: Dead, Incarnation: 1}, true},
+		{"stale suspicion", Update{State: Suspect, Incarnation: 1}, Update{State: Alive, Incarnation: 2}, false},
+		{"same news", Update{State: Alive, Incarnation: 1}, Update{State: Alive, Incarnation: 1}, false},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.u.overrides(tc.o); got != tc.want {
+				t.Errorf("%+v overrides %+v: %v, want %v", tc.u, tc.o, got, tc.want)
+			}
+		})
+	}
+}
+
+// brokenLink drops every message from one member to another.
+type brokenLink struct {
+	ringallreduce.Transport
+	from, to int
+	dropped  atomic.Int64
+}
+
+func (b *brokenLink) Send(rank int, msg ringallreduce.Msg) error {
+	if msg.From == b.from && rank == b.to {
+		b.dropped.Add(1)
+		return nil
+	}
+	return b.Transport.Send(rank, msg)
+}
+
+func TestMember_IndirectProbe(t *testing.T) {
+	const n = 4
+	inner := ringallreduce.NewChanTransportSize(n, 1024)
+	link := &brokenLink{Transport: inner, from: 0, to: 1}
+	members := make([]*Member, n)
+	for id := range members {
+		members[id] = NewMember(id, link, testConfig, 0, 1, 2, 3)
+	}
+	defer func() {
+		inner.Close()
+		for _, m := range members {
+			m.Close()
+		}
+	}()
+
+	// Member 0 can't reach member 1 directly, but the others vouch for it.
+	time.Sleep(40 * testConfig.ProbeInterval)
+	if link.dropped.Load() == 0 {
+		t.Fatal("member 0 never probed member 1")
+	}
+	if members[0].Messages()[MsgPingReq] == 0 {
+		t.Error("member 0 never probed indirectly")
+	}
+	for _, m := range members {
+		if s, _ := m.State(1); s == Dead {
+			t.Errorf("member %d declared member 1 dead", m.ID)
+		}
+	}
+}
+
+func TestMember_TCP(t *testing.T) {
+	const n = 4
+	listeners := make([]net.Listener, n)
+	peers := make([]string, n)
+	for i := range listeners {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+		listeners[i], peers[i] = ln, ln.Addr().String()
+	}
+	members := make([]*Member, n)
+	transports := make([]*ringallreduce.TCPTransport, n)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for i, ln := range listeners {
+		transports[i] = ringallreduce.NewTCPTransport(i, ln, peers)
+	}
+	ranks := []int{0, 1, 2, 3}
+	for i, tr := range transports {
+		if err := tr.Connect(ctx, ranks); err != nil {
+			t.Fatal(err)
+		}
+		members[i] = NewMember(i, tr, testConfig, 0)
+	}
+	defer func() {
+		for i := range members {
+			transports[i].Close()
+			members[i].Close()
+		}
+	}()
+
+	// A cluster of the TCP members, for WaitConverged.
+	c := &Cluster{members: members}
+	want := states(n, Alive)
+	if err := c.WaitConverged(want, wait); err != nil {
+		t.Fatal(err)
+	}
+	members[2].Crash()
+	want[2] = Dead
+	if err := c.WaitConverged(want, wait); err != nil {
+		t.Fatalf("after crashing member 2: %v", err)
+	}
+	members[2].Recover()
+	want[2] = Alive
+	if err := c.WaitConverged(want, wait); err != nil {
+		t.Fatalf("after recovering member 2: %v", err)
+	}
+}
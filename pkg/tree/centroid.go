@@ -0,0 +1,116 @@
+package tree
+
+import "github.com/sanderblue/algorithms/pkg/graph"
+
+// Centroids returns the centroids of t, in increasing order: the nodes
+// whose removal leaves components of at most n/2 nodes each. Every tree
+// has one, or two adjacent ones. It takes O(n).
+func Centroids[W graph.Weight](t *Tree[W]) []int {
+	parent, order, _ := t.rooted(0)
+	n := len(order)
+	size := subtreeSizes(parent, order)
+	var centroids []int
+	for v := range n {
+		largest := n - size[v] // the component above v
+		for _, nb := range t.adj[v] {
+			if nb.to != parent[v] {
+				largest = max(largest, size[nb.to])
+			}
+		}
+		if 2*largest <= n {
+			centroids = append(centroids, v)
+		}
+	}
+	return centroids
+}
+
+// subtreeSizes returns the number of nodes below every node, itself
+// included, given the parents and an order with parents first.
+func subtreeSizes(parent, order []int) []int {
+	size := make([]int, len(parent))
+	for i := len(order) - 1; i >= 0; i-- {
+		v := order[i]
+		size[v]++
+		if p := parent[v]; p >= 0 {
+			size[p] += size[v]
+		}
+	}
+	return size
+}
+
+// Decomposition is the centroid decomposition of a tree: the centroid of
+// the tree is the root of the centroid tree, and the centroids of the
+// components left by its removal are its children, recursively. The path
+// between any two nodes passes through their lowest common ancestor in the
+// centroid tree, and stays within its component.
+type Decomposition struct {
+	Root   int
+	Parent []int // parent of every node in the centroid tree, -1 for the root
+	Level  []int // depth of every node in the centroid tree, below log2(n)+1
+}
+
+// CentroidDecomposition returns the centroid decomposition of t in
+// O(n log n): every level of the centroid tree splits components of at
+// most half the nodes of the level above.
+func CentroidDecomposition[W graph.Weight](t *Tree[W]) *Decomposition {
+	n := t.Order()
+	d := &Decomposition{Parent: make([]int, n), Level: make([]int, n)}
+	removed := make([]bool, n)
+	parent := make([]int, n) // within the component being split
+	size := make([]int, n)
+	type component struct{ entry, parent, level int }
+	stack := []component{{0, -1, 0}}
+	var order []int
+	for len(stack) > 0 {
+		c := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		// List the component from its entry, parents first, and size its
+		// subtrees.
+		order = append(order[:0], c.entry)
+		parent[c.entry] = -1
+		for i := 0; i < len(order); i++ {
+			u := order[i]
+			for _, nb := range t.adj[u] {
+				if !removed[nb.to] && nb.to != parent[u] {
+					parent[nb.to] = u
+					order = append(order, nb.to)
+				}
+			}
+		}
+		for i := len(order) - 1; i >= 0; i-- {
+			v := order[i]
+			size[v] = 1
+			for _, nb := range t.adj[v] {
+				if !removed[nb.to] && nb.to != parent[v] {
+					size[v] += size[nb.to]
+				}
+			}
+		}
+
+		// Walk down from the entry towards any subtree of more than half
+		// the component: the walk stops at a centroid.
+		total, v := len(order), c.entry
+		for moved := true; moved; {
+			moved = false
+			for _, nb := range t.adj[v] {
+				if !removed[nb.to] && nb.to != parent[v] && 2*size[nb.to] > total {
+					v, moved = nb.to, true
+					break
+				}
+			}
+		}
+
+		removed[v] = true
+		d.Parent[v], d.Level[v] = c.parent, c.level
+		if c.parent < 0 {
+			d.Root = v
+		}
+		for _, nb := range t.adj[v] {
+			if !removed[nb.to] {
+				stack = append(stack, component{nb.to, v, c.level + 1})
+			}
+		}
+	}
+	return d
+}
@@ -0,0 +1,96 @@
+package tree
+
+import (
+	"math/bits"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// componentSizes returns the sizes of the components left by removing the
+// nodes marked removed and v.
+func componentSizes(tr *Tree[int], removed []bool, v int) []int {
+	seen := slices.Clone(removed)
+	seen[v] = true
+	var sizes []int
+	for _, nb := range tr.adj[v] {
+		if seen[nb.to] {
+			continue
+		}
+		size, stack := 0, []int{nb.to}
+		seen[nb.to] = true
+		for len(stack) > 0 {
+			u := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			size++
+			for _, nb := range tr.adj[u] {
+				if !seen[nb.to] {
+					seen[nb.to] = true
+					stack = append(stack, nb.to)
+				}
+			}
+		}
+		sizes = append(sizes, size)
+	}
+	return sizes
+}
+
+func TestCentroids(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for range 50 {
+		n := 1 + rng.Intn(40)
+		tr := mustTree(t, randomTree(rng, n, 1, 1))
+		var want []int
+		for v := range n {
+			if slices.Max(append(componentSizes(tr, make([]bool, n), v), 0))*2 <= n {
+				want = append(want, v)
+			}
+		}
+		if got := Centroids(tr); !slices.Equal(got, want) {
+			t.Fatalf("centroids %v, want %v", got, want)
+		}
+		if len(want) < 1 || len(want) > 2 {
+			t.Fatalf("%d centroids", len(want))
+		}
+	}
+}
+
+func TestCentroidDecomposition(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, n := range []int{1, 2, 3, 10, 100, 1000} {
+		tr := mustTree(t, randomTree(rng, n, 1, 1))
+		d := CentroidDecomposition(tr)
+		if d.Parent[d.Root] != -1 || d.Level[d.Root] != 0 {
+			t.Fatalf("root %d of parent %d, level %d", d.Root, d.Parent[d.Root], d.Level[d.Root])
+		}
+		// Remove the nodes level by level: every one must be a centroid of
+		// its component when removed.
+		byLevel := make([][]int, n)
+		for v, l := range d.Level {
+			if l > bits.Len(uint(n)) {
+				t.Fatalf("%d nodes: node %d at level %d", n, v, l)
+			}
+			if p := d.Parent[v]; v != d.Root && (p < 0 || d.Level[p] != l-1) {
+				t.Fatalf("node %d at level %d, parent %d", v, l, p)
+			}
+			byLevel[l] = append(byLevel[l], v)
+		}
+		removed := make([]bool, n)
+		for _, level := range byLevel {
+			for _, v := range level {
+				component := 1
+				for _, s := range componentSizes(tr, removed, v) {
+					component += s
+				}
+				for _, s := range componentSizes(tr, removed, v) {
+					if 2*s > component {
+						t.Fatalf("%d nodes: node %d leaves a component of %d of %d", n, v, s, component)
+					}
+				}
+			}
+			for _, v := range level {
+				removed[v] = true
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package tree
+
+import (
+	"slices"
+
+	"github.com/sanderblue/algorithms/pkg/graph"
+)
+
+// Diameter returns the length of a longest path of t and the nodes along
+// it. With weights that may be negative the longest path may be a single
+// node, of length 0. It takes O(n): processing the nodes bottom up, every
+// node extends the longest path down from each child, and the longest path
+// through it joins the two longest of those.
+func Diameter[W graph.Weight](t *Tree[W]) (W, []int) {
+	parent, order, up := t.rooted(0)
+	n := len(order)
+	down := make([]W, n)   // length of the longest path down from every node
+	next := make([]int, n) // first node of that path below, -1 if it stops there
+	var best W
+	top, first, second := order[0], -1, -1 // of the longest path found
+	for v := range next {
+		next[v] = -1
+	}
+	for i := n - 1; i >= 0; i-- {
+		v := order[i]
+		// The two longest paths down from v through its children, of
+		// positive length.
+		var l1, l2 W
+		c1, c2 := -1, -1
+		for _, nb := range t.adj[v] {
+			c := nb.to
+			if c == parent[v] {
+				continue
+			}
+			l := up[c] + down[c]
+			switch {
+			case l <= 0:
+			case c1 < 0 || l > l1:
+				l1, l2, c1, c2 = l, l1, c, c1
+			case c2 < 0 || l > l2:
+				l2, c2 = l, c
+			}
+		}
+		if c1 < 0 {
+			continue
+		}
+		down[v], next[v] = l1, c1
+		length := l1
+		if c2 >= 0 {
+			length += l2
+		}
+		if length > best {
+			best, top, first, second = length, v, c1, c2
+		}
+	}
+
+	var path []int
+	for v := first; v >= 0; v = next[v] {
+		path = append(path, v)
+	}
+	slices.Reverse(path)
+	path = append(path, top)
+	for v := second; v >= 0; v = next[v] {
+		path = append(path, v)
+	}
+	return best, path
+}
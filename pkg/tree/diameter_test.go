@@ -0,0 +1,56 @@
+package tree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// pathLength returns the weight of the path of nodes, checking that its
+// edges exist.
+func pathLength(t *testing.T, tr *Tree[int], path []int) int {
+	t.Helper()
+	length := 0
+	for i := 1; i < len(path); i++ {
+		w, ok := tr.Weight(path[i-1], path[i])
+		if !ok {
+			t.Fatalf("path %v: no edge %d-%d", path, path[i-1], path[i])
+		}
+		length += w
+	}
+	return length
+}
+
+func TestDiameter_MatchesAllPairs(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, weights := range [][2]int{{1, 1}, {0, 9}, {-5, 5}, {-9, -1}} {
+		for range 20 {
+			n := 1 + rng.Intn(30)
+			tr := mustTree(t, randomTree(rng, n, weights[0], weights[1]))
+			want := 0
+			for u := range n {
+				for v := range n {
+					want = max(want, pathLength(t, tr, pathTo(tr, u, v)))
+				}
+			}
+			got, path := Diameter(tr)
+			if got != want {
+				t.Fatalf("weights %v: diameter %d, want %d", weights, got, want)
+			}
+			if len(path) == 0 || pathLength(t, tr, path) != got {
+				t.Fatalf("weights %v: path %v, want one of length %d", weights, path, got)
+			}
+		}
+	}
+}
+
+func TestDiameter_Path(t *testing.T) {
+	// A path 0-1-...-9 with a branch 10 off 4: the diameter runs end to end.
+	edges := [][3]int{{4, 10, 3}}
+	for v := 1; v < 10; v++ {
+		edges = append(edges, [3]int{v - 1, v, 1})
+	}
+	got, path := Diameter(mustTree(t, build(false, 11, edges)))
+	if got != 9 || len(path) != 10 || path[0]+path[9] != 9 {
+		t.Errorf("diameter %d along %v, want 9 from end to end", got, path)
+	}
+}
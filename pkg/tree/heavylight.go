@@ -0,0 +1,165 @@
+package tree
+
+import "github.com/sanderblue/algorithms/pkg/graph"
+
+// HeavyLight is the heavy-light decomposition of a rooted tree. Every node
+// has a heavy edge down to its child of the largest subtree, and the heavy
+// edges form chains. Going up from any node crosses O(log n) light edges,
+// as each at least doubles the subtree size, so any path is made of
+// O(log n) sections of chains. The nodes are numbered, their positions, so
+// that every chain, and every subtree, is a range of positions.
+type HeavyLight struct {
+	parent []int // -1 for the root
+	depth  []int
+	head   []int // top of the chain of every node
+	pos    []int // position of every node
+	size   []int
+}
+
+// NewHeavyLight returns the heavy-light decomposition of t rooted at root,
+// in O(n).
+func NewHeavyLight[W graph.Weight](t *Tree[W], root int) *HeavyLight {
+	t.mustExist(root)
+	parent, order, _ := t.rooted(root)
+	n := len(order)
+	h := &HeavyLight{parent: parent, depth: make([]int, n), head: make([]int, n), pos: make([]int, n),
+		size: subtreeSizes(parent, order)}
+	heavy := make([]int, n)
+	for v := range heavy {
+		heavy[v] = -1
+	}
+	for _, v := range order {
+		if p := parent[v]; p >= 0 {
+			h.depth[v] = h.depth[p] + 1
+			if heavy[p] < 0 || h.size[v] > h.size[heavy[p]] {
+				heavy[p] = v
+			}
+		}
+	}
+
+	// Lay the tree out depth first, the heavy child first, so that chains
+	// and subtrees are ranges.
+	next := 0
+	stack := []int{root}
+	h.head[root] = root
+	for len(stack) > 0 {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		h.pos[v] = next
+		next++
+		for _, nb := range t.adj[v] {
+			if c := nb.to; c != parent[v] && c != heavy[v] {
+				h.head[c] = c
+				stack = append(stack, c)
+			}
+		}
+		if c := heavy[v]; c >= 0 {
+			h.head[c] = h.head[v]
+			stack = append(stack, c)
+		}
+	}
+	return h
+}
+
+// Parent returns the parent of v, -1 for the root.
+func (h *HeavyLight) Parent(v int) int { return h.parent[v] }
+
+// Depth returns the number of edges from v up to the root.
+func (h *HeavyLight) Depth(v int) int { return h.depth[v] }
+
+// Position returns the position of v, from 0.
+func (h *HeavyLight) Position(v int) int { return h.pos[v] }
+
+// LCA returns the lowest common ancestor of u and v, in O(log n).
+func (h *HeavyLight) LCA(u, v int) int {
+	for h.head[u] != h.head[v] {
+		if h.depth[h.head[u]] < h.depth[h.head[v]] {
+			u, v = v, u
+		}
+		u = h.parent[h.head[u]]
+	}
+	if h.depth[u] < h.depth[v] {
+		return u
+	}
+	return v
+}
+
+// Distance returns the number of edges on the path between u and v.
+func (h *HeavyLight) Distance(u, v int) int {
+	return h.depth[u] + h.depth[v] - 2*h.depth[h.LCA(u, v)]
+}
+
+// Ranges returns the ranges [lo, hi) of positions of the O(log n) sections
+// of chains making up the path between u and v, or, with edges, that path
+// but for their lowest common ancestor: the nodes below every edge of the
+// path.
+func (h *HeavyLight) Ranges(u, v int, edges bool) [][2]int {
+	var ranges [][2]int
+	for h.head[u] != h.head[v] {
+		if h.depth[h.head[u]] < h.depth[h.head[v]] {
+			u, v = v, u
+		}
+		ranges = append(ranges, [2]int{h.pos[h.head[u]], h.pos[u] + 1})
+		u = h.parent[h.head[u]]
+	}
+	lo, hi := min(h.pos[u], h.pos[v]), max(h.pos[u], h.pos[v])
+	if edges {
+		lo++ // the shallower of u and v is the ancestor
+	}
+	if lo <= hi {
+		ranges = append(ranges, [2]int{lo, hi + 1})
+	}
+	return ranges
+}
+
+// Subtree returns the range [lo, hi) of the positions of the subtree of v.
+func (h *HeavyLight) Subtree(v int) (int, int) {
+	return h.pos[v], h.pos[v] + h.size[v]
+}
+
+// PathQuery aggregates values on the nodes of a tree over paths and
+// subtrees, with a segment tree over the positions of a heavy-light
+// decomposition. The combine function must be commutative as well as
+// associative, as paths are combined in no particular order.
+type PathQuery[T any] struct {
+	h   *HeavyLight
+	seg *SegmentTree[T]
+}
+
+// NewPathQuery returns a PathQuery over h of the value of every node. To
+// aggregate edge values instead, give every node the value of the edge to
+// its parent and query with PathEdges.
+func NewPathQuery[T any](h *HeavyLight, values []T, combine func(a, b T) T, identity T) *PathQuery[T] {
+	laid := make([]T, len(values))
+	for v, x := range values {
+		laid[h.pos[v]] = x
+	}
+	return &PathQuery[T]{h: h, seg: NewSegmentTree(laid, combine, identity)}
+}
+
+// Get returns the value of v.
+func (p *PathQuery[T]) Get(v int) T { return p.seg.Get(p.h.pos[v]) }
+
+// Set sets the value of v to x, in O(log n).
+func (p *PathQuery[T]) Set(v int, x T) { p.seg.Set(p.h.pos[v], x) }
+
+// Path combines the values of the nodes on the path between u and v, both
+// included, in O(log² n).
+func (p *PathQuery[T]) Path(u, v int) T { return p.query(p.h.Ranges(u, v, false)) }
+
+// PathEdges combines the values of the nodes on the path between u and v
+// but for their lowest common ancestor, that is, of the edges on the path
+// when every node holds the value of the edge to its parent.
+func (p *PathQuery[T]) PathEdges(u, v int) T { return p.query(p.h.Ranges(u, v, true)) }
+
+// Subtree combines the values of the nodes of the subtree of v, in
+// O(log n).
+func (p *PathQuery[T]) Subtree(v int) T { return p.seg.Query(p.h.Subtree(v)) }
+
+func (p *PathQuery[T]) query(ranges [][2]int) T {
+	x := p.seg.identity
+	for _, r := range ranges {
+		x = p.seg.combine(x, p.seg.Query(r[0], r[1]))
+	}
+	return x
+}
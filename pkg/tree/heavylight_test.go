@@ -0,0 +1,147 @@
+package tree
+
+import (
+	"math/bits"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestHeavyLight_Layout(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{1, 2, 50, 1000} {
+		tr := mustTree(t, randomTree(rng, n, 1, 1))
+		root := rng.Intn(n)
+		h := NewHeavyLight(tr, root)
+		parent, _, _ := tr.rooted(root)
+
+		// Positions are a permutation, and every subtree a range of them.
+		positions := make([]int, n)
+		for v := range n {
+			positions[h.Position(v)]++
+			if h.Parent(v) != parent[v] {
+				t.Fatalf("parent of %d is %d, want %d", v, h.Parent(v), parent[v])
+			}
+		}
+		for _, c := range positions {
+			if c != 1 {
+				t.Fatalf("positions %v", positions)
+			}
+		}
+		for v := range min(n, 50) {
+			lo, hi := h.Subtree(v)
+			for u := range n {
+				below := false
+				for x := u; x >= 0; x = parent[x] {
+					below = below || x == v
+				}
+				if in := lo <= h.Position(u) && h.Position(u) < hi; in != below {
+					t.Fatalf("node %d in the range of the subtree of %d: %v, want %v", u, v, in, below)
+				}
+			}
+		}
+
+		// Any path crosses O(log n) chains.
+		for range 200 {
+			u, v := rng.Intn(n), rng.Intn(n)
+			path := pathTo(tr, u, v)
+			if got := h.Distance(u, v); got != len(path)-1 {
+				t.Fatalf("Distance(%d, %d) = %d, want %d", u, v, got, len(path)-1)
+			}
+			lca := slices.MinFunc(path, func(a, b int) int { return h.Depth(a) - h.Depth(b) })
+			if got := h.LCA(u, v); got != lca {
+				t.Fatalf("LCA(%d, %d) = %d, want %d", u, v, got, lca)
+			}
+			if r := h.Ranges(u, v, false); len(r) > 2*bits.Len(uint(n))+2 {
+				t.Fatalf("path %d-%d in %d ranges", u, v, len(r))
+			}
+		}
+	}
+}
+
+func TestPathQuery_MatchesNaive(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	const n = 200
+	tr := mustTree(t, randomTree(rng, n, 1, 9))
+	h := NewHeavyLight(tr, 0)
+	values := make([]int, n)
+	for v := range values {
+		values[v] = rng.Intn(100) - 50
+	}
+	sum := NewPathQuery(h, values, func(a, b int) int { return a + b }, 0)
+	largest := NewPathQuery(h, values, func(a, b int) int { return max(a, b) }, -1<<31)
+	for range 1000 {
+		if rng.Intn(4) == 0 {
+			v := rng.Intn(n)
+			values[v] = rng.Intn(100) - 50
+			sum.Set(v, values[v])
+			largest.Set(v, values[v])
+		}
+		u, v := rng.Intn(n), rng.Intn(n)
+		path := pathTo(tr, u, v)
+		lca := h.LCA(u, v)
+		wantSum, wantEdges, wantMax := 0, 0, -1<<31
+		for _, x := range path {
+			wantSum += values[x]
+			wantMax = max(wantMax, values[x])
+			if x != lca {
+				wantEdges += values[x]
+			}
+		}
+		if got := sum.Path(u, v); got != wantSum {
+			t.Fatalf("sum %d-%d = %d, want %d", u, v, got, wantSum)
+		}
+		if got := sum.PathEdges(u, v); got != wantEdges {
+			t.Fatalf("edge sum %d-%d = %d, want %d", u, v, got, wantEdges)
+		}
+		if got := largest.Path(u, v); got != wantMax {
+			t.Fatalf("max %d-%d = %d, want %d", u, v, got, wantMax)
+		}
+		if sum.Get(v) != values[v] {
+			t.Fatalf("Get(%d) = %d, want %d", v, sum.Get(v), values[v])
+		}
+	}
+
+	subtree := 0
+	lo, hi := h.Subtree(7)
+	for x := range n {
+		if p := h.Position(x); lo <= p && p < hi {
+			subtree += values[x]
+		}
+	}
+	if got := sum.Subtree(7); got != subtree {
+		t.Errorf("subtree sum %d, want %d", got, subtree)
+	}
+}
+
+func TestPathQuery_EdgeWeights(t *testing.T) {
+	// Every node holds the weight of the edge to its parent, so PathEdges
+	// sums the weights along a path.
+	rng := rand.New(rand.NewSource(3))
+	tr := mustTree(t, randomTree(rng, 100, 1, 9))
+	h := NewHeavyLight(tr, 5)
+	weights := make([]int, tr.Order())
+	for v := range weights {
+		if p := h.Parent(v); p >= 0 {
+			weights[v], _ = tr.Weight(v, p)
+		}
+	}
+	q := NewPathQuery(h, weights, func(a, b int) int { return a + b }, 0)
+	for range 200 {
+		u, v := rng.Intn(100), rng.Intn(100)
+		if got, want := q.PathEdges(u, v), pathLength(t, tr, pathTo(tr, u, v)); got != want {
+			t.Fatalf("path %d-%d of weight %d, want %d", u, v, got, want)
+		}
+	}
+}
+
+func BenchmarkPathQuery(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	const n = 1 << 16
+	tr := mustTree(b, randomTree(rng, n, 1, 9))
+	h := NewHeavyLight(tr, 0)
+	q := NewPathQuery(h, make([]int, n), func(a, b int) int { return a + b }, 0)
+	for i := range b.N {
+		q.Path(i%n, (i*7919)%n)
+	}
+}
@@ -0,0 +1,74 @@
+package tree
+
+import "fmt"
+
+// SegmentTree holds a sequence of values and aggregates any range of them
+// with an associative combine function, such as a sum, minimum or maximum,
+// in O(log n), while single values change in O(log n).
+type SegmentTree[T any] struct {
+	n        int
+	node     []T // node[i] combines node[2i] and node[2i+1]; the values are at node[n:]
+	combine  func(a, b T) T
+	identity T
+}
+
+// NewSegmentTree returns a segment tree of values, aggregated with combine,
+// of which identity is the identity element: combine(identity, x) and
+// combine(x, identity) are x. The values are copied.
+func NewSegmentTree[T any](values []T, combine func(a, b T) T, identity T) *SegmentTree[T] {
+	n := len(values)
+	s := &SegmentTree[T]{n: n, node: make([]T, 2*n), combine: combine, identity: identity}
+	copy(s.node[n:], values)
+	for i := n - 1; i > 0; i-- {
+		s.node[i] = combine(s.node[2*i], s.node[2*i+1])
+	}
+	return s
+}
+
+// Len returns the number of values.
+func (s *SegmentTree[T]) Len() int { return s.n }
+
+// Get returns value i.
+func (s *SegmentTree[T]) Get(i int) T {
+	s.mustIndex(i)
+	return s.node[s.n+i]
+}
+
+// Set sets value i to x.
+func (s *SegmentTree[T]) Set(i int, x T) {
+	s.mustIndex(i)
+	i += s.n
+	s.node[i] = x
+	for i > 1 {
+		i /= 2
+		s.node[i] = s.combine(s.node[2*i], s.node[2*i+1])
+	}
+}
+
+// Query combines values lo to hi-1, in order, or returns the identity if
+// the range is empty.
+func (s *SegmentTree[T]) Query(lo, hi int) T {
+	if lo < 0 || hi > s.n || lo > hi {
+		panic(fmt.Sprintf("tree: range [%d, %d) out of [0, %d)", lo, hi, s.n))
+	}
+	// Climb from both ends, combining the nodes leaving the range on the
+	// left into left, and those on the right into right.
+	left, right := s.identity, s.identity
+	for lo, hi = lo+s.n, hi+s.n; lo < hi; lo, hi = lo/2, hi/2 {
+		if lo&1 == 1 {
+			left = s.combine(left, s.node[lo])
+			lo++
+		}
+		if hi&1 == 1 {
+			hi--
+			right = s.combine(s.node[hi], right)
+		}
+	}
+	return s.combine(left, right)
+}
+
+func (s *SegmentTree[T]) mustIndex(i int) {
+	if i < 0 || i >= s.n {
+		panic(fmt.Sprintf("tree: index %d out of range [0, %d)", i, s.n))
+	}
+}
@@ -0,0 +1,57 @@
+package tree
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestSegmentTree_MatchesNaive(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 1, 2, 7, 64, 100} {
+		// Concatenation is associative but not commutative, which catches
+		// ranges combined out of order.
+		values := make([]string, n)
+		for i := range values {
+			values[i] = string(rune('a' + rng.Intn(26)))
+		}
+		s := NewSegmentTree(values, func(a, b string) string { return a + b }, "")
+		if s.Len() != n {
+			t.Fatalf("Len() = %d, want %d", s.Len(), n)
+		}
+		for range 300 {
+			if n > 0 && rng.Intn(3) == 0 {
+				i := rng.Intn(n)
+				values[i] = string(rune('a' + rng.Intn(26)))
+				s.Set(i, values[i])
+				if s.Get(i) != values[i] {
+					t.Fatalf("Get(%d) = %q, want %q", i, s.Get(i), values[i])
+				}
+			}
+			lo := rng.Intn(n + 1)
+			hi := lo + rng.Intn(n-lo+1)
+			if got, want := s.Query(lo, hi), strings.Join(values[lo:hi], ""); got != want {
+				t.Fatalf("%d values: Query(%d, %d) = %q, want %q", n, lo, hi, got, want)
+			}
+		}
+	}
+}
+
+func TestSegmentTree_Panics(t *testing.T) {
+	s := NewSegmentTree([]int{1, 2, 3}, func(a, b int) int { return a + b }, 0)
+	for name, f := range map[string]func(){
+		"get":            func() { s.Get(3) },
+		"set":            func() { s.Set(-1, 0) },
+		"reversed range": func() { s.Query(2, 1) },
+		"long range":     func() { s.Query(0, 4) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: no panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
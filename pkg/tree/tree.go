@@ -0,0 +1,114 @@
+// Package tree implements algorithms on weighted trees, built from the
+// undirected graphs of package graph:
+//
+//   - Diameter finds a longest path, by dynamic programming over the tree,
+//     so that it handles negative weights too.
+//   - Centroids and CentroidDecomposition split a tree recursively at the
+//     node whose removal leaves the smallest components, into a centroid
+//     tree of logarithmic depth, for divide and conquer over paths.
+//   - HeavyLight splits a rooted tree into chains such that any path
+//     crosses O(log n) of them, and lays the chains out consecutively. A
+//     SegmentTree over that layout, as in PathQuery, then aggregates node or
+//     edge values along any path, or over any subtree, in O(log² n), while
+//     the values change.
+//
+// References:
+//
+// Jordan, Sur les assemblages de lignes, Journal für die reine und
+// angewandte Mathematik 70, 1869.
+//
+// Sleator and Tarjan, A Data Structure for Dynamic Trees, Journal of
+// Computer and System Sciences 26(3), 1983.
+//
+// Guibas, Hershberger, Leven, Sharir and Tarjan, Linear-Time Algorithms for
+// Visibility and Shortest Path Problems inside Triangulated Simple Polygons,
+// Algorithmica 2, 1987.
+package tree
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sanderblue/algorithms/pkg/graph"
+)
+
+// Tree is a tree of n nodes, identified by 0..n-1 like those of the graph
+// it was built from, whose edges carry a weight.
+type Tree[W graph.Weight] struct {
+	adj [][]neighbor[W]
+}
+
+type neighbor[W graph.Weight] struct {
+	to     int
+	weight W
+}
+
+// FromGraph returns the tree of the undirected graph g, which must be one:
+// connected, with at least one node and no cycle, self-loops and parallel
+// edges included.
+func FromGraph[N any, W graph.Weight](g *graph.Graph[N, W]) (*Tree[W], error) {
+	if g.Directed() {
+		return nil, errors.New("tree: directed graph")
+	}
+	n := g.Order()
+	if n == 0 {
+		return nil, errors.New("tree: no nodes")
+	}
+	if g.Size() != n-1 {
+		return nil, fmt.Errorf("tree: %d edges between %d nodes, want %d", g.Size(), n, n-1)
+	}
+	t := &Tree[W]{adj: make([][]neighbor[W], n)}
+	for _, e := range g.Edges() {
+		t.adj[e.From] = append(t.adj[e.From], neighbor[W]{e.To, e.Weight})
+		t.adj[e.To] = append(t.adj[e.To], neighbor[W]{e.From, e.Weight})
+	}
+	// With n-1 edges, the graph is a tree exactly when it is connected.
+	if _, order, _ := t.rooted(0); len(order) != n {
+		return nil, errors.New("tree: graph not connected")
+	}
+	return t, nil
+}
+
+// Order returns the number of nodes.
+func (t *Tree[W]) Order() int { return len(t.adj) }
+
+// Weight returns the weight of the edge between u and v, or false if there
+// is none. It takes O(degree).
+func (t *Tree[W]) Weight(u, v int) (W, bool) {
+	for _, nb := range t.adj[u] {
+		if nb.to == v {
+			return nb.weight, true
+		}
+	}
+	return 0, false
+}
+
+// rooted roots the tree at root and returns the parent of every node, -1
+// for root, the nodes in BFS order, every parent before its children, and
+// the weight of the edge up from every node.
+func (t *Tree[W]) rooted(root int) ([]int, []int, []W) {
+	n := len(t.adj)
+	parent := make([]int, n)
+	up := make([]W, n)
+	for v := range parent {
+		parent[v] = -2 // not reached
+	}
+	parent[root] = -1
+	order := []int{root}
+	for i := 0; i < len(order); i++ {
+		u := order[i]
+		for _, nb := range t.adj[u] {
+			if parent[nb.to] == -2 {
+				parent[nb.to], up[nb.to] = u, nb.weight
+				order = append(order, nb.to)
+			}
+		}
+	}
+	return parent, order, up
+}
+
+func (t *Tree[W]) mustExist(v int) {
+	if v < 0 || v >= len(t.adj) {
+		panic(fmt.Sprintf("tree: node %d out of range [0, %d)", v, len(t.adj)))
+	}
+}
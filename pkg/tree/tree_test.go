@@ -0,0 +1,87 @@
+package tree
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/sanderblue/algorithms/pkg/graph"
+)
+
+// randomTree returns a random tree of n nodes whose edges weigh between lo
+// and hi.
+func randomTree(rng *rand.Rand, n, lo, hi int) *graph.Graph[struct{}, int] {
+	g := graph.NewUndirected[struct{}, int]()
+	g.AddNodes(make([]struct{}, n)...)
+	perm := rng.Perm(n)
+	for i := 1; i < n; i++ {
+		g.AddEdge(perm[rng.Intn(i)], perm[i], lo+rng.Intn(hi-lo+1))
+	}
+	return g
+}
+
+func mustTree(t testing.TB, g *graph.Graph[struct{}, int]) *Tree[int] {
+	t.Helper()
+	tr, err := FromGraph(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tr
+}
+
+// build returns a graph of n nodes with the edges {from, to, weight}.
+func build(directed bool, n int, edges [][3]int) *graph.Graph[struct{}, int] {
+	g := graph.NewUndirected[struct{}, int]()
+	if directed {
+		g = graph.NewDirected[struct{}, int]()
+	}
+	g.AddNodes(make([]struct{}, n)...)
+	for _, e := range edges {
+		g.AddEdge(e[0], e[1], e[2])
+	}
+	return g
+}
+
+// pathTo returns the path from v up to u in the tree rooted at u.
+func pathTo(t *Tree[int], u, v int) []int {
+	parent, _, _ := t.rooted(u)
+	var path []int
+	for x := v; x >= 0; x = parent[x] {
+		path = append(path, x)
+	}
+	return path
+}
+
+func TestFromGraph(t *testing.T) {
+	tr := mustTree(t, build(false, 4, [][3]int{{0, 1, 5}, {1, 2, 6}, {1, 3, 7}}))
+	if tr.Order() != 4 {
+		t.Errorf("Order() = %d, want 4", tr.Order())
+	}
+	if w, ok := tr.Weight(3, 1); !ok || w != 7 {
+		t.Errorf("Weight(3, 1) = %d, %v, want 7", w, ok)
+	}
+	if _, ok := tr.Weight(0, 2); ok {
+		t.Error("Weight(0, 2): an edge")
+	}
+
+	tests := []struct {
+		name     string
+		directed bool
+		n        int
+		edges    [][3]int
+	}{
+		{"directed", true, 2, [][3]int{{0, 1, 1}}},
+		{"empty", false, 0, nil},
+		{"too few edges", false, 3, [][3]int{{0, 1, 1}}},
+		{"cycle", false, 4, [][3]int{{0, 1, 1}, {1, 2, 1}, {2, 0, 1}}},
+		{"parallel edges", false, 3, [][3]int{{0, 1, 1}, {1, 0, 1}}},
+		{"self-loop", false, 2, [][3]int{{0, 0, 1}}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := FromGraph(build(tc.directed, tc.n, tc.edges)); err == nil {
+				t.Error("no error")
+			}
+		})
+	}
+}
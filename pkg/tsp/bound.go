@@ -0,0 +1,110 @@
+package tsp
+
+import "math"
+
+// heldKarpIterations bounds the subgradient iterations of LowerBound.
+const heldKarpIterations = 200
+
+// LowerBound returns a lower bound on the length of any tour of in, by the
+// Held-Karp relaxation. A 1-tree, a spanning tree of the cities but one
+// plus the two shortest edges to that one, is no longer than a shortest
+// tour, which is itself a 1-tree. Adding a penalty π(c) to every edge at
+// city c shifts the length of every tour by 2Σπ, but favors other 1-trees;
+// subgradient ascent on π, raising it on cities of degree above 2 and
+// lowering it below, tightens the bound, typically to within 1% of optimal
+// on random points. Every iteration takes O(n²).
+func LowerBound(in *Instance) float64 {
+	n := in.n
+	switch n {
+	case 0, 1:
+		return 0
+	case 2:
+		return 2 * in.Dist(0, 1)
+	}
+
+	// Step sizes follow Held and Karp: λ (U - w) / |d - 2|², with U the
+	// length of a tour, halving λ when the bound stalls.
+	upper := in.Length(NearestNeighbor(in, 0))
+	pi := make([]float64, n)
+	degree := make([]int, n)
+	best := math.Inf(-1)
+	lambda, stalled := 2.0, 0
+	for range heldKarpIterations {
+		w := in.oneTree(pi, degree)
+		if w > best+1e-12*max(upper, 1) {
+			best, stalled = w, 0
+		} else if stalled++; stalled == 10 {
+			lambda, stalled = lambda/2, 0
+		}
+		norm := 0
+		for _, d := range degree {
+			norm += (d - 2) * (d - 2)
+		}
+		if norm == 0 {
+			break // the 1-tree is a tour, and optimal
+		}
+		step := lambda * (upper - w) / float64(norm)
+		if step <= 0 {
+			break
+		}
+		for c, d := range degree {
+			pi[c] += step * float64(d-2)
+		}
+	}
+	return max(best, 0)
+}
+
+// oneTree returns the length, net of the penalties, of a shortest 1-tree
+// of in with edges between c and d costing Dist(c, d)+pi[c]+pi[d]: a
+// minimum spanning tree of cities 1..n-1, found by Prim's algorithm on the
+// dense matrix, plus the two cheapest edges at city 0. It fills in the
+// degree of every city in the 1-tree.
+func (in *Instance) oneTree(pi []float64, degree []int) float64 {
+	n := in.n
+	cost := func(c, d int) float64 { return in.Dist(c, d) + pi[c] + pi[d] }
+	clear(degree)
+	key := make([]float64, n) // cheapest edge from the tree to every city outside
+	from := make([]int, n)
+	inTree := make([]bool, n)
+	for c := 2; c < n; c++ {
+		key[c], from[c] = cost(1, c), 1
+	}
+	inTree[1] = true
+	w := 0.0
+	for range n - 2 {
+		u := -1
+		for c := 2; c < n; c++ {
+			if !inTree[c] && (u < 0 || key[c] < key[u]) {
+				u = c
+			}
+		}
+		inTree[u] = true
+		w += key[u]
+		degree[u]++
+		degree[from[u]]++
+		for c := 2; c < n; c++ {
+			if k := cost(u, c); !inTree[c] && k < key[c] {
+				key[c], from[c] = k, u
+			}
+		}
+	}
+
+	first, second := -1, -1 // cheapest edges at city 0
+	for c := 1; c < n; c++ {
+		switch {
+		case first < 0 || cost(0, c) < cost(0, first):
+			first, second = c, first
+		case second < 0 || cost(0, c) < cost(0, second):
+			second = c
+		}
+	}
+	w += cost(0, first) + cost(0, second)
+	degree[0] = 2
+	degree[first]++
+	degree[second]++
+
+	for _, p := range pi {
+		w -= 2 * p
+	}
+	return w
+}
@@ -0,0 +1,46 @@
+package tsp
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestLowerBound_BelowOptimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for range 30 {
+		n := 1 + rng.Intn(9)
+		in := randomPoints(rng, n)
+		if rng.Intn(2) == 0 { // a non-metric matrix
+			dist := make([][]float64, n)
+			for i := range dist {
+				dist[i] = make([]float64, n)
+			}
+			for i := range n {
+				for j := i + 1; j < n; j++ {
+					dist[i][j] = float64(1 + rng.Intn(20))
+					dist[j][i] = dist[i][j]
+				}
+			}
+			var err error
+			if in, err = FromMatrix(dist); err != nil {
+				t.Fatal(err)
+			}
+		}
+		optimal := shortestTour(in)
+		bound := LowerBound(in)
+		if bound > optimal+1e-9 || bound < 0 {
+			t.Errorf("%d cities: bound %v, optimal %v", n, bound, optimal)
+		}
+		if n >= 3 && bound < 0.8*optimal {
+			t.Errorf("%d cities: loose bound %v, optimal %v", n, bound, optimal)
+		}
+	}
+}
+
+func TestLowerBound_Circle(t *testing.T) {
+	in, optimal := circle(rand.New(rand.NewSource(2)), 25)
+	if got := LowerBound(in); math.Abs(got-optimal) > 1e-6 {
+		t.Errorf("bound %v, want the optimum %v", got, optimal)
+	}
+}
@@ -0,0 +1,84 @@
+package tsp
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/sanderblue/algorithms/pkg/disjointset"
+)
+
+// NearestNeighbor builds a tour from start, always going next to the
+// nearest city not visited yet, ties to the smallest, in O(n²). Its tours
+// tend to be about a quarter longer than optimal on random points.
+func NearestNeighbor(in *Instance, start int) []int {
+	if in.n == 0 {
+		return nil
+	}
+	in.mustExist(start)
+	visited := make([]bool, in.n)
+	tour := []int{start}
+	visited[start] = true
+	for len(tour) < in.n {
+		u, next := tour[len(tour)-1], -1
+		for v := range in.n {
+			if !visited[v] && (next < 0 || in.Dist(u, v) < in.Dist(u, next)) {
+				next = v
+			}
+		}
+		tour = append(tour, next)
+		visited[next] = true
+	}
+	return tour
+}
+
+// GreedyEdge builds a tour from city 0 by taking the edges in increasing
+// order of length, ties by cities, skipping those that would give a city
+// three edges or close a cycle too early, in O(n² log n). Its tours tend to
+// be shorter than those of NearestNeighbor.
+func GreedyEdge(in *Instance) []int {
+	n := in.n
+	if n <= 2 {
+		tour := make([]int, n)
+		for c := range tour {
+			tour[c] = c
+		}
+		return tour
+	}
+	type edge struct{ u, v int }
+	edges := make([]edge, 0, n*(n-1)/2)
+	for u := range n {
+		for v := u + 1; v < n; v++ {
+			edges = append(edges, edge{u, v})
+		}
+	}
+	slices.SortStableFunc(edges, func(a, b edge) int { return cmp.Compare(in.Dist(a.u, a.v), in.Dist(b.u, b.v)) })
+
+	adj := make([][]int, n)
+	paths := disjointset.New[int](disjointset.BySize)
+	taken := 0
+	for _, e := range edges {
+		if len(adj[e.u]) == 2 || len(adj[e.v]) == 2 {
+			continue
+		}
+		// Only the last edge may close the cycle.
+		if !paths.Union(e.u, e.v) && taken < n-1 {
+			continue
+		}
+		adj[e.u] = append(adj[e.u], e.v)
+		adj[e.v] = append(adj[e.v], e.u)
+		if taken++; taken == n {
+			break
+		}
+	}
+
+	tour := make([]int, 0, n)
+	for prev, c := -1, 0; len(tour) < n; {
+		tour = append(tour, c)
+		next := adj[c][0]
+		if next == prev {
+			next = adj[c][1]
+		}
+		prev, c = c, next
+	}
+	return tour
+}
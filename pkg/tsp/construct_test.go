@@ -0,0 +1,51 @@
+package tsp
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestConstruction_Circle(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	in, optimal := circle(rng, 20)
+	for name, tour := range map[string][]int{
+		"nearest neighbor": NearestNeighbor(in, 3),
+		"greedy edge":      GreedyEdge(in),
+	} {
+		checkTour(t, in, tour)
+		if got := in.Length(tour); math.Abs(got-optimal) > 1e-9 {
+			t.Errorf("%s: length %v, want %v", name, got, optimal)
+		}
+	}
+}
+
+func TestNearestNeighbor_Known(t *testing.T) {
+	in := FromPoints([]Point{{0, 0}, {5, 0}, {1, 0}, {3, 0}, {-4, 0}})
+	if got, want := NearestNeighbor(in, 0), []int{0, 2, 3, 1, 4}; !slices.Equal(got, want) {
+		t.Errorf("tour %v, want %v", got, want)
+	}
+	if got := NearestNeighbor(FromPoints(nil), 0); got != nil {
+		t.Errorf("no cities: tour %v", got)
+	}
+}
+
+func TestConstruction_Random(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, n := range []int{1, 2, 3, 4, 7, 50} {
+		in := randomPoints(rng, n)
+		nn := NearestNeighbor(in, rng.Intn(n))
+		greedy := GreedyEdge(in)
+		checkTour(t, in, nn)
+		checkTour(t, in, greedy)
+		if n <= 8 {
+			optimal := shortestTour(in)
+			for _, tour := range [][]int{nn, greedy} {
+				if in.Length(tour) < optimal-1e-9 {
+					t.Errorf("%d cities: tour %v shorter than optimal %v", n, tour, optimal)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package tsp
+
+import "slices"
+
+// TwoOpt shortens tour in place by 2-opt moves until none helps, and
+// returns the number of moves made. A move removes two edges of the tour
+// and reconnects the two paths left the other way, reversing one of them.
+// Every pass over the O(n²) pairs of edges makes the first move that
+// shortens the tour for each.
+func TwoOpt(in *Instance, tour []int) int {
+	n := len(tour)
+	moves := 0
+	for improved := true; improved; {
+		improved = false
+		for i := 0; i < n-2; i++ {
+			for j := i + 2; j < n; j++ {
+				if i == 0 && j == n-1 {
+					continue // the two edges share city tour[0]
+				}
+				a, b := tour[i], tour[i+1]
+				c, d := tour[j], tour[(j+1)%n]
+				delta := in.Dist(a, c) + in.Dist(b, d) - in.Dist(a, b) - in.Dist(c, d)
+				if shorter(delta, in.Dist(a, b)+in.Dist(c, d)) {
+					slices.Reverse(tour[i+1 : j+1])
+					moves++
+					improved = true
+				}
+			}
+		}
+	}
+	return moves
+}
+
+// OrOpt shortens tour in place by Or-opt moves until none helps, and
+// returns the number of moves made. A move takes a segment of one to three
+// consecutive cities out of the tour and puts it back, in either
+// direction, between two other consecutive cities. It often finds moves
+// TwoOpt cannot, which the reversals of 2-opt would take several steps to
+// make. Every pass tries O(n²) moves.
+func OrOpt(in *Instance, tour []int) int {
+	n := len(tour)
+	moves := 0
+	for improved := true; improved; {
+		improved = false
+		for length := 1; length <= 3; length++ {
+			if n < length+3 {
+				break
+			}
+			for i := 0; i+length <= n; i++ {
+				first, last := tour[i], tour[i+length-1]
+				prev, next := tour[(i+n-1)%n], tour[(i+length)%n]
+				removed := in.Dist(prev, first) + in.Dist(last, next) - in.Dist(prev, next)
+				best, bestDelta, reversed := -1, 0.0, false
+				for j := range n {
+					// Put the segment between tour[j] and tour[j+1], which
+					// must both lie outside it.
+					if j >= i-1 && j < i+length {
+						continue
+					}
+					if i == 0 && j == n-1 {
+						continue
+					}
+					a, b := tour[j], tour[(j+1)%n]
+					added := in.Dist(a, first) + in.Dist(last, b) - in.Dist(a, b)
+					addedReversed := in.Dist(a, last) + in.Dist(first, b) - in.Dist(a, b)
+					if d := added - removed; d < bestDelta {
+						best, bestDelta, reversed = j, d, false
+					}
+					if d := addedReversed - removed; d < bestDelta {
+						best, bestDelta, reversed = j, d, true
+					}
+				}
+				if best >= 0 && shorter(bestDelta, removed) {
+					moveSegment(tour, i, length, best, reversed)
+					moves++
+					improved = true
+				}
+			}
+		}
+	}
+	return moves
+}
+
+// moveSegment moves the length cities of tour from i to right after the
+// city at j, outside the segment, reversing them if asked.
+func moveSegment(tour []int, i, length, j int, reversed bool) {
+	segment := slices.Clone(tour[i : i+length])
+	if reversed {
+		slices.Reverse(segment)
+	}
+	after := tour[j]
+	rest := slices.Delete(slices.Clone(tour), i, i+length)
+	k := slices.Index(rest, after) + 1
+	copy(tour, slices.Insert(rest, k, segment...))
+}
+
+// ThreeOpt shortens tour in place by 3-opt moves until none helps, and
+// returns the number of moves made. A move removes three edges of the tour
+// and reconnects the three paths left in the best of the ways that need no
+// more than one reversal, which include every 2-opt move and moving a
+// section elsewhere without reversing it. Every pass tries the O(n³)
+// triples of edges, so it suits tours of up to a few hundred cities.
+func ThreeOpt(in *Instance, tour []int) int {
+	n := len(tour)
+	moves := 0
+	for improved := true; improved; {
+		improved = false
+		for i := range n {
+			for j := i + 2; j < n; j++ {
+				for k := j + 2; k < n+min(i, 1); k++ {
+					if threeOptMove(in, tour, i, j, k) {
+						moves++
+						improved = true
+					}
+				}
+			}
+		}
+	}
+	return moves
+}
+
+// threeOptMove removes the edges of tour entering positions i, j and k,
+// i < j < k, which split it into the sections [i, j), [j, k) and the rest,
+// reconnects them the shortest way, and reports whether that shortened it.
+func threeOptMove(in *Instance, tour []int, i, j, k int) bool {
+	n := len(tour)
+	a, b := tour[(i+n-1)%n], tour[i]
+	c, d := tour[j-1], tour[j]
+	e, f := tour[k-1], tour[k%n]
+	d0 := in.Dist(a, b) + in.Dist(c, d) + in.Dist(e, f)
+	deltas := [4]float64{
+		in.Dist(a, c) + in.Dist(b, d) + in.Dist(e, f) - d0, // reverse [i, j)
+		in.Dist(a, b) + in.Dist(c, e) + in.Dist(d, f) - d0, // reverse [j, k)
+		in.Dist(a, d) + in.Dist(e, b) + in.Dist(c, f) - d0, // swap [i, j) and [j, k)
+		in.Dist(f, b) + in.Dist(c, d) + in.Dist(e, a) - d0, // reverse [i, k)
+	}
+	best := 0
+	for m, delta := range deltas {
+		if delta < deltas[best] {
+			best = m
+		}
+	}
+	if !shorter(deltas[best], d0) {
+		return false
+	}
+	switch best {
+	case 0:
+		slices.Reverse(tour[i:j])
+	case 1:
+		slices.Reverse(tour[j:k])
+	case 2:
+		swapped := append(slices.Clone(tour[j:k]), tour[i:j]...)
+		copy(tour[i:k], swapped)
+	case 3:
+		slices.Reverse(tour[i:k])
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+package tsp
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// improvements lists the local searches under test.
+var improvements = []struct {
+	name    string
+	improve func(in *Instance, tour []int) int
+}{
+	{"2-opt", TwoOpt},
+	{"or-opt", OrOpt},
+	{"3-opt", ThreeOpt},
+}
+
+func TestImprovement_LocalOptimum(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{1, 2, 3, 4, 5, 6, 9, 40} {
+		in := randomPoints(rng, n)
+		for _, alg := range improvements {
+			tour := rng.Perm(n)
+			before := in.Length(tour)
+			moves := alg.improve(in, tour)
+			checkTour(t, in, tour)
+			after := in.Length(tour)
+			if after > before+1e-9 || (moves > 0) != (after < before-1e-12) {
+				t.Errorf("%d cities, %s: %d moves from %v to %v", n, alg.name, moves, before, after)
+			}
+			if again := alg.improve(in, tour); again != 0 {
+				t.Errorf("%d cities, %s: %d more moves from a local optimum", n, alg.name, again)
+			}
+		}
+	}
+}
+
+func TestImprovement_NeverBelowOptimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for range 20 {
+		in := randomPoints(rng, 4+rng.Intn(5))
+		optimal := shortestTour(in)
+		for _, alg := range improvements {
+			tour := rng.Perm(in.Len())
+			alg.improve(in, tour)
+			if got := in.Length(tour); got < optimal-1e-9 {
+				t.Errorf("%s: length %v below optimal %v", alg.name, got, optimal)
+			}
+		}
+	}
+}
+
+func TestImprovement_UncrossesCircle(t *testing.T) {
+	// On cities in convex position, a tour is optimal exactly when it
+	// crosses itself nowhere, which 2-opt and 3-opt ensure.
+	rng := rand.New(rand.NewSource(3))
+	in, optimal := circle(rng, 30)
+	for _, alg := range improvements {
+		if alg.name == "or-opt" {
+			continue
+		}
+		tour := rng.Perm(in.Len())
+		alg.improve(in, tour)
+		if got := in.Length(tour); math.Abs(got-optimal) > 1e-9 {
+			t.Errorf("%s: length %v, want %v", alg.name, got, optimal)
+		}
+	}
+}
+
+func TestOrOpt_MovesSegment(t *testing.T) {
+	// Cities 0 to 5 on a line and 6 above it. The tour leaves 2 and 3 for
+	// the way back from 6; moving them between 1 and 4 makes it optimal.
+	in := FromPoints([]Point{{0, 0}, {1, 0}, {2, 0}, {3, 0}, {4, 0}, {5, 0}, {2.5, 1}})
+	tour := []int{0, 1, 4, 5, 6, 3, 2}
+	if moves := OrOpt(in, tour); moves == 0 {
+		t.Fatal("no move")
+	}
+	checkTour(t, in, tour)
+	if got, want := in.Length(tour), shortestTour(in); math.Abs(got-want) > 1e-9 {
+		t.Errorf("tour %v of length %v, want %v", tour, got, want)
+	}
+}
+
+func TestImprovement_Gap(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	in := randomPoints(rng, 100)
+	tour := GreedyEdge(in)
+	TwoOpt(in, tour)
+	OrOpt(in, tour)
+	s, err := Evaluate(in, tour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Gap < 0 || s.Gap > 0.1 {
+		t.Errorf("%+v: want a gap below 10%%", s)
+	}
+}
+
+func BenchmarkImprovement(b *testing.B) {
+	in := randomPoints(rand.New(rand.NewSource(1)), 200)
+	start := NearestNeighbor(in, 0)
+	for _, alg := range improvements {
+		b.Run(alg.name, func(b *testing.B) {
+			for range b.N {
+				tour := append([]int(nil), start...)
+				alg.improve(in, tour)
+			}
+		})
+	}
+}
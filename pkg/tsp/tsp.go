@@ -0,0 +1,141 @@
+// Package tsp implements heuristics for the symmetric traveling salesman
+// problem: finding a shortest tour visiting every city once and returning
+// to the first. Cities are given by an Instance, built from a distance
+// matrix or from points in the plane.
+//
+//   - NearestNeighbor and GreedyEdge construct a tour from scratch.
+//   - TwoOpt, OrOpt and ThreeOpt improve a tour by local search, until no
+//     move of their kind shortens it: reversing a section, moving a short
+//     segment elsewhere, and reconnecting three sections.
+//   - LowerBound bounds the length of any tour from below with the
+//     Held-Karp 1-tree relaxation, and Evaluate reports how far a tour is,
+//     at most, from optimal.
+//
+// References:
+//
+// Lin, Computer Solutions of the Traveling Salesman Problem, Bell System
+// Technical Journal 44(10), 1965.
+//
+// Croes, A Method for Solving Traveling-Salesman Problems, Operations
+// Research 6(6), 1958.
+//
+// Or, Traveling Salesman-Type Combinatorial Problems and Their Relation to
+// the Logistics of Regional Blood Banking, PhD thesis, Northwestern
+// University, 1976.
+//
+// Held and Karp, The Traveling-Salesman Problem and Minimum Spanning Trees,
+// Operations Research 18(6), 1970.
+//
+// Johnson and McGeoch, The Traveling Salesman Problem: A Case Study in Local
+// Optimization, in Local Search in Combinatorial Optimization, 1997.
+package tsp
+
+import (
+	"fmt"
+	"math"
+)
+
+// Instance holds the distances between n cities, identified by 0..n-1.
+type Instance struct {
+	n    int
+	dist []float64 // distance from i to j at i*n+j
+}
+
+// Point is a city in the plane.
+type Point struct {
+	X, Y float64
+}
+
+// FromMatrix returns the instance whose distance from city i to city j is
+// dist[i][j]. The matrix must be square and symmetric, with no negative or
+// NaN distance.
+func FromMatrix(dist [][]float64) (*Instance, error) {
+	n := len(dist)
+	in := &Instance{n: n, dist: make([]float64, n*n)}
+	for i, row := range dist {
+		if len(row) != n {
+			return nil, fmt.Errorf("tsp: row %d has %d distances, want %d", i, len(row), n)
+		}
+		for j, d := range row {
+			if d < 0 || math.IsNaN(d) {
+				return nil, fmt.Errorf("tsp: distance %v from %d to %d", d, i, j)
+			}
+			if d != dist[j][i] {
+				return nil, fmt.Errorf("tsp: distance from %d to %d is %v one way and %v the other", i, j, d, dist[j][i])
+			}
+			in.dist[i*n+j] = d
+		}
+	}
+	return in, nil
+}
+
+// FromPoints returns the instance of the cities at points, with Euclidean
+// distances between them.
+func FromPoints(points []Point) *Instance {
+	n := len(points)
+	in := &Instance{n: n, dist: make([]float64, n*n)}
+	for i, p := range points {
+		for j, q := range points {
+			in.dist[i*n+j] = math.Hypot(p.X-q.X, p.Y-q.Y)
+		}
+	}
+	return in
+}
+
+// Len returns the number of cities.
+func (in *Instance) Len() int { return in.n }
+
+// Dist returns the distance between cities i and j.
+func (in *Instance) Dist(i, j int) float64 { return in.dist[i*in.n+j] }
+
+// Length returns the length of tour, a sequence of cities, including the
+// way back from the last to the first.
+func (in *Instance) Length(tour []int) float64 {
+	length := 0.0
+	for i, c := range tour {
+		length += in.Dist(c, tour[(i+1)%len(tour)])
+	}
+	return length
+}
+
+// Summary describes a tour by Evaluate.
+type Summary struct {
+	Length     float64
+	LowerBound float64 // on the length of any tour, from LowerBound
+	Gap        float64 // Length over LowerBound, minus 1: how far from optimal the tour is, at most
+}
+
+// Evaluate checks that tour visits every city of in exactly once and
+// reports its length against LowerBound.
+func Evaluate(in *Instance, tour []int) (Summary, error) {
+	if len(tour) != in.n {
+		return Summary{}, fmt.Errorf("tsp: tour of %d cities, want %d", len(tour), in.n)
+	}
+	seen := make([]bool, in.n)
+	for _, c := range tour {
+		if c < 0 || c >= in.n {
+			return Summary{}, fmt.Errorf("tsp: city %d out of range [0, %d)", c, in.n)
+		}
+		if seen[c] {
+			return Summary{}, fmt.Errorf("tsp: city %d visited twice", c)
+		}
+		seen[c] = true
+	}
+	s := Summary{Length: in.Length(tour), LowerBound: LowerBound(in)}
+	if s.LowerBound > 0 {
+		s.Gap = s.Length/s.LowerBound - 1
+	}
+	return s, nil
+}
+
+// shorter reports whether a change of length by delta shortens a tour of
+// length about scale, beyond rounding errors.
+func shorter(delta, scale float64) bool {
+	return delta < -1e-9*max(scale, 1)
+}
+
+func (in *Instance) mustExist(c int) {
+	if c < 0 || c >= in.n {
+		panic(fmt.Sprintf("tsp: city %d out of range [0, %d)", c, in.n))
+	}
+}
@@ -0,0 +1,128 @@
+package tsp
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// randomPoints returns n cities placed uniformly in the unit square.
+func randomPoints(rng *rand.Rand, n int) *Instance {
+	points := make([]Point, n)
+	for i := range points {
+		points[i] = Point{rng.Float64(), rng.Float64()}
+	}
+	return FromPoints(points)
+}
+
+// circle returns n cities evenly spread on the unit circle, in a shuffled
+// order, and the length of a shortest tour, around the circle.
+func circle(rng *rand.Rand, n int) (*Instance, float64) {
+	points := make([]Point, n)
+	for i, k := range rng.Perm(n) {
+		a := 2 * math.Pi * float64(k) / float64(n)
+		points[i] = Point{math.Cos(a), math.Sin(a)}
+	}
+	return FromPoints(points), 2 * float64(n) * math.Sin(math.Pi/float64(n))
+}
+
+// shortestTour returns the length of a shortest tour of in, trying every
+// tour from city 0.
+func shortestTour(in *Instance) float64 {
+	n := in.Len()
+	if n <= 1 {
+		return 0
+	}
+	best := math.Inf(1)
+	visited := make([]bool, n)
+	var extend func(c, count int, length float64)
+	extend = func(c, count int, length float64) {
+		if count == n {
+			best = min(best, length+in.Dist(c, 0))
+			return
+		}
+		for d := 1; d < n; d++ {
+			if !visited[d] {
+				visited[d] = true
+				extend(d, count+1, length+in.Dist(c, d))
+				visited[d] = false
+			}
+		}
+	}
+	visited[0] = true
+	extend(0, 1, 0)
+	return best
+}
+
+// checkTour checks that tour visits every city of in exactly once.
+func checkTour(t *testing.T, in *Instance, tour []int) {
+	t.Helper()
+	if _, err := Evaluate(in, tour); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFromMatrix(t *testing.T) {
+	in, err := FromMatrix([][]float64{{0, 1, 2}, {1, 0, 3}, {2, 3, 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in.Len() != 3 || in.Dist(1, 2) != 3 || in.Dist(2, 0) != 2 {
+		t.Errorf("distances %v", in.dist)
+	}
+	if got := in.Length([]int{0, 2, 1}); got != 6 {
+		t.Errorf("Length = %v, want 6", got)
+	}
+
+	tests := []struct {
+		name string
+		dist [][]float64
+	}{
+		{"ragged", [][]float64{{0, 1}, {1}}},
+		{"asymmetric", [][]float64{{0, 1}, {2, 0}}},
+		{"negative", [][]float64{{0, -1}, {-1, 0}}},
+		{"nan", [][]float64{{0, math.NaN()}, {math.NaN(), 0}}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := FromMatrix(tc.dist); err == nil {
+				t.Error("no error")
+			}
+		})
+	}
+}
+
+func TestFromPoints(t *testing.T) {
+	in := FromPoints([]Point{{0, 0}, {3, 0}, {3, 4}})
+	if in.Dist(0, 2) != 5 || in.Dist(2, 0) != 5 || in.Dist(1, 1) != 0 {
+		t.Errorf("distances %v", in.dist)
+	}
+	if got := in.Length([]int{0, 1, 2}); got != 12 {
+		t.Errorf("Length = %v, want 12", got)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	in := FromPoints([]Point{{0, 0}, {1, 0}, {1, 1}, {0, 1}})
+	s, err := Evaluate(in, []int{0, 1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Length != 4 || math.Abs(s.LowerBound-4) > 1e-9 || math.Abs(s.Gap) > 1e-9 {
+		t.Errorf("square: %+v, want length and bound 4", s)
+	}
+	s, err = Evaluate(in, []int{0, 2, 1, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 2 + 2*math.Sqrt2; math.Abs(s.Length-want) > 1e-9 || s.Gap <= 0 {
+		t.Errorf("crossed square: %+v, want length %v and a gap", s, want)
+	}
+
+	for _, tour := range [][]int{{0, 1, 2}, {0, 1, 2, 2}, {0, 1, 2, 4}} {
+		if _, err := Evaluate(in, tour); err == nil {
+			t.Errorf("tour %v: no error", tour)
+		}
+	}
+}